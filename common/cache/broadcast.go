@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis"
+	"takeout/global"
+)
+
+// InvalidateChannel 失效广播频道，各实例收到后清理自己的一级缓存
+const InvalidateChannel = "cache:invalidate"
+
+// degradeBackoff Redis 出错后跳过 Redis 的时长，期间只使用一级缓存并直接回源
+const degradeBackoff = 5 * time.Second
+
+// 当前实例标识，用于忽略自己发出的广播
+var instanceId = strconv.FormatInt(time.Now().UnixNano(), 36)
+
+var (
+	degradedUntil atomic.Int64 // 降级截止时间（UnixNano）
+	missedEvents  atomic.Bool  // 降级期间可能错过了失效广播
+)
+
+// message 失效广播内容
+type message struct {
+	Origin string   `json:"origin"`
+	Cache  string   `json:"cache"`
+	Keys   []string `json:"keys,omitempty"` // 完整的 Redis 键
+	Tags   []string `json:"tags,omitempty"`
+	All    bool     `json:"all,omitempty"`
+}
+
+// Listen 订阅失效广播，连接断开时进入降级并自动重连，恢复后清空一级缓存；返回停止订阅的函数
+func Listen(client *redis.Client) (stop func()) {
+	pubsub := client.Subscribe(InvalidateChannel)
+	done := make(chan struct{})
+	go func() {
+		for {
+			msg, err := pubsub.ReceiveMessage()
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if err != nil {
+				markDegraded(err)
+				time.Sleep(time.Second)
+				continue
+			}
+			markHealthy()
+			var m message
+			if err = json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+				global.Log.Warn("Unmarshal cache invalidation failed", "payload", msg.Payload, "error", err)
+				continue
+			}
+			handle(m)
+		}
+	}()
+	return func() {
+		close(done)
+		_ = pubsub.Close()
+	}
+}
+
+// handle 按广播内容清理本实例的一级缓存
+func handle(m message) {
+	if m.Origin == instanceId {
+		return
+	}
+	registryMu.Lock()
+	c, ok := registry[m.Cache]
+	registryMu.Unlock()
+	if !ok || c.local == nil {
+		return
+	}
+	if m.All {
+		c.local.purge()
+		return
+	}
+	c.local.remove(m.Keys...)
+	c.local.removeTags(m.Tags...)
+}
+
+// publish 广播失效消息，失败时其他实例依靠一级缓存过期时间兜底
+func (c *Cache) publish(m message) {
+	if c.local == nil {
+		return
+	}
+	m.Origin, m.Cache = instanceId, c.prefix
+	data, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	if err = c.client().Publish(InvalidateChannel, data).Err(); err != nil {
+		markDegraded(err)
+	}
+}
+
+// Degraded Redis 是否处于降级状态
+func Degraded() bool {
+	return time.Now().UnixNano() < degradedUntil.Load()
+}
+
+func markDegraded(err error) {
+	if !Degraded() {
+		global.Log.Warn("Redis unavailable, cache degraded to local only", "error", err)
+	}
+	degradedUntil.Store(time.Now().Add(degradeBackoff).UnixNano())
+	missedEvents.Store(true)
+}
+
+// markHealthy Redis 恢复后清空所有一级缓存，避免降级期间错过的失效广播导致脏数据
+func markHealthy() {
+	if !missedEvents.CompareAndSwap(true, false) {
+		return
+	}
+	degradedUntil.Store(0)
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, c := range registry {
+		if c.local != nil {
+			c.local.purge()
+		}
+	}
+	global.Log.Info("Redis recovered, local caches purged")
+}
@@ -0,0 +1,312 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"golang.org/x/sync/singleflight"
+	"takeout/global"
+)
+
+// ErrNotFound 数据不存在，加载函数返回该错误时缓存空值，防止缓存穿透
+var ErrNotFound = errors.New("cache: not found")
+
+const (
+	nullValue  = "\x00null" // 空值占位
+	tagKey     = "tag:"     // 标签集合键前缀，集合中记录打了该标签的缓存键
+	scanCount  = 100
+	defaultTTL = 30 * time.Minute
+	defaultNul = time.Minute
+)
+
+// Cache 旁路缓存：可选的进程内一级缓存 + Redis 二级缓存，读时加载并回填，写后由调用方按键、标签或前缀失效
+type Cache struct {
+	prefix  string
+	local   *local
+	ttl     time.Duration
+	nullTTL time.Duration
+	jitter  float64
+	client  func() *redis.Client
+	group   singleflight.Group
+	stats   counters
+}
+
+// Option 缓存配置项
+type Option func(*Cache)
+
+// WithTTL 缓存过期时间
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Cache) { c.ttl = ttl }
+}
+
+// WithNullTTL 空值及空列表的过期时间，一般远短于正常数据
+func WithNullTTL(ttl time.Duration) Option {
+	return func(c *Cache) { c.nullTTL = ttl }
+}
+
+// WithJitter 过期时间随机浮动比例，避免同一批键同时过期引起雪崩
+func WithJitter(ratio float64) Option {
+	return func(c *Cache) { c.jitter = ratio }
+}
+
+// WithLocal 启用进程内一级缓存，size 为最大条目数，ttl 决定收不到失效广播时的最长不一致时间
+func WithLocal(size int, ttl time.Duration) Option {
+	return func(c *Cache) { c.local = newLocal(size, ttl) }
+}
+
+// WithClient 指定 Redis 客户端，默认使用 global.RedisClient
+func WithClient(client *redis.Client) Option {
+	return func(c *Cache) { c.client = func() *redis.Client { return client } }
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]*Cache)
+)
+
+// New 创建缓存，prefix 为 Redis 键前缀，同时作为指标名称
+func New(prefix string, opts ...Option) *Cache {
+	c := &Cache{
+		prefix:  prefix,
+		ttl:     defaultTTL,
+		nullTTL: defaultNul,
+		jitter:  0.1,
+		client:  func() *redis.Client { return global.RedisClient },
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	registryMu.Lock()
+	registry[prefix] = c
+	registryMu.Unlock()
+	return c
+}
+
+// Key 返回完整的 Redis 键
+func (c *Cache) Key(key string) string {
+	return c.prefix + key
+}
+
+// Fetch 依次读取一级缓存与 Redis，未命中时通过 singleflight 合并并发加载并回填
+// tags 根据加载结果给出标签（如 dish:1），之后可按标签精确失效，不需要时传 nil
+// Redis 不可用时进入降级：只使用一级缓存，未命中直接回源
+func Fetch[T any](ctx context.Context, c *Cache, key string, load func(ctx context.Context) (T, error), tags func(T) []string) (T, error) {
+	var zero T
+	fullKey := c.Key(key)
+	if c.local != nil {
+		if entry, ok := c.local.get(fullKey); ok {
+			if entry.null {
+				c.stats.localHits.Add(1)
+				return zero, ErrNotFound
+			}
+			if v, ok := entry.value.(T); ok {
+				c.stats.localHits.Add(1)
+				return v, nil
+			}
+		}
+	}
+	tagsOf := func(v T) []string {
+		if tags == nil {
+			return nil
+		}
+		return tags(v)
+	}
+
+	redisOK := !Degraded()
+	if redisOK {
+		data, err := c.client().Get(fullKey).Bytes()
+		switch {
+		case err == nil:
+			markHealthy()
+			if string(data) == nullValue {
+				c.stats.nullHits.Add(1)
+				c.setLocal(fullKey, localEntry{null: true}, nil)
+				return zero, ErrNotFound
+			}
+			var v T
+			if err = json.Unmarshal(data, &v); err == nil {
+				c.stats.hits.Add(1)
+				c.setLocal(fullKey, localEntry{value: v}, tagsOf(v))
+				return v, nil
+			}
+			global.Log.Warn("Unmarshal cache failed", "key", fullKey, "error", err)
+		case errors.Is(err, redis.Nil):
+			markHealthy()
+		default:
+			c.stats.errors.Add(1)
+			markDegraded(err)
+			redisOK = false
+		}
+	}
+	c.stats.misses.Add(1)
+	if !redisOK {
+		c.stats.degraded.Add(1)
+	}
+
+	res, err, shared := c.group.Do(fullKey, func() (interface{}, error) {
+		c.stats.loads.Add(1)
+		// 加载结果由并发请求共享，不随首个请求取消
+		v, err := load(context.WithoutCancel(ctx))
+		switch {
+		case errors.Is(err, ErrNotFound):
+			c.setLocal(fullKey, localEntry{null: true}, nil)
+			if redisOK {
+				c.set(fullKey, []byte(nullValue), c.nullTTL, nil)
+			}
+		case err != nil:
+			c.stats.loadErrors.Add(1)
+		default:
+			keyTags := tagsOf(v)
+			c.setLocal(fullKey, localEntry{value: v}, keyTags)
+			if redisOK {
+				c.setValue(fullKey, v, keyTags)
+			}
+		}
+		return v, err
+	})
+	if shared {
+		c.stats.shared.Add(1)
+	}
+	v, ok := res.(T)
+	if !ok {
+		return zero, err
+	}
+	return v, err
+}
+
+func (c *Cache) setLocal(fullKey string, entry localEntry, tags []string) {
+	if c.local != nil {
+		c.local.set(fullKey, entry, tags)
+	}
+}
+
+// setValue 序列化并写入缓存，空列表视同空值使用较短的过期时间
+func (c *Cache) setValue(fullKey string, v any, tags []string) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		global.Log.Warn("Marshal cache failed", "key", fullKey, "error", err)
+		return
+	}
+	ttl := c.ttl
+	if isEmpty(v) {
+		ttl = c.nullTTL
+	}
+	c.set(fullKey, data, ttl, tags)
+}
+
+func (c *Cache) set(fullKey string, data []byte, ttl time.Duration, tags []string) {
+	ttl = c.withJitter(ttl)
+	pipe := c.client().TxPipeline()
+	pipe.Set(fullKey, data, ttl)
+	for _, tag := range tags {
+		// 标签集合比缓存键多存活一个周期，过期的成员在失效时一并删除即可
+		tagSet := c.prefix + tagKey + tag
+		pipe.SAdd(tagSet, fullKey)
+		pipe.Expire(tagSet, c.ttl*2)
+	}
+	if _, err := pipe.Exec(); err != nil {
+		c.stats.errors.Add(1)
+		markDegraded(err)
+	}
+}
+
+func (c *Cache) withJitter(ttl time.Duration) time.Duration {
+	if c.jitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Float64()*c.jitter*float64(ttl))
+}
+
+// Invalidate 按键失效
+func (c *Cache) Invalidate(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	fullKeys := make([]string, len(keys))
+	for i, key := range keys {
+		fullKeys[i] = c.Key(key)
+	}
+	if c.local != nil {
+		c.local.remove(fullKeys...)
+	}
+	c.del(fullKeys)
+	c.publish(message{Keys: fullKeys})
+}
+
+// InvalidateTags 失效打了任一标签的缓存键
+func (c *Cache) InvalidateTags(tags ...string) {
+	if len(tags) == 0 {
+		return
+	}
+	if c.local != nil {
+		c.local.removeTags(tags...)
+	}
+	defer c.publish(message{Tags: tags})
+	client := c.client()
+	var keys []string
+	for _, tag := range tags {
+		tagSet := c.prefix + tagKey + tag
+		members, err := client.SMembers(tagSet).Result()
+		if err != nil {
+			c.stats.errors.Add(1)
+			markDegraded(err)
+			return
+		}
+		keys = append(keys, members...)
+		keys = append(keys, tagSet)
+	}
+	c.del(keys)
+}
+
+// InvalidateAll 使用 SCAN 遍历删除该缓存下的全部键，避免 KEYS 阻塞 Redis
+func (c *Cache) InvalidateAll() {
+	if c.local != nil {
+		c.local.purge()
+	}
+	defer c.publish(message{All: true})
+	client := c.client()
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(cursor, c.prefix+"*", scanCount).Result()
+		if err != nil {
+			c.stats.errors.Add(1)
+			markDegraded(err)
+			return
+		}
+		c.del(keys)
+		if cursor = next; cursor == 0 {
+			return
+		}
+	}
+}
+
+func (c *Cache) del(fullKeys []string) {
+	if len(fullKeys) == 0 {
+		return
+	}
+	if err := c.client().Del(fullKeys...).Err(); err != nil {
+		c.stats.errors.Add(1)
+		markDegraded(err)
+		return
+	}
+	c.stats.invalidations.Add(int64(len(fullKeys)))
+}
+
+// isEmpty 空切片/空 map 视为空结果
+func isEmpty(v any) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	case reflect.Invalid:
+		return true
+	default:
+		return false
+	}
+}
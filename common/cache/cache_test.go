@@ -0,0 +1,228 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"takeout/global"
+)
+
+func newTestCache(t *testing.T, opts ...Option) (*Cache, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return New("test::"+t.Name()+"::", append([]Option{WithClient(client)}, opts...)...), mr
+}
+
+func TestFetchHitAndMiss(t *testing.T) {
+	c, mr := newTestCache(t, WithTTL(time.Hour), WithJitter(0.1))
+	var loads int
+	load := func(context.Context) ([]int, error) {
+		loads++
+		return []int{1, 2}, nil
+	}
+	for i := 0; i < 3; i++ {
+		got, err := Fetch(context.Background(), c, "1", load, nil)
+		if err != nil || len(got) != 2 {
+			t.Fatalf("got %v, %v", got, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loads = %d, want 1", loads)
+	}
+	if ttl := mr.TTL(c.Key("1")); ttl < time.Hour || ttl > time.Hour+6*time.Minute {
+		t.Errorf("ttl %v out of jitter range", ttl)
+	}
+	s := c.Stats()
+	if s.Hits != 2 || s.Misses != 1 || s.Loads != 1 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
+
+func TestFetchSingleflight(t *testing.T) {
+	c, _ := newTestCache(t)
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(context.Context) (string, error) {
+		loads.Add(1)
+		<-release
+		return "v", nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := Fetch(context.Background(), c, "k", load, nil); err != nil || got != "v" {
+				t.Errorf("got %q, %v", got, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := loads.Load(); n != 1 {
+		t.Errorf("loads = %d, want 1", n)
+	}
+}
+
+func TestFetchNullValue(t *testing.T) {
+	c, mr := newTestCache(t, WithNullTTL(30*time.Second), WithJitter(0))
+	var loads int
+	load := func(context.Context) (*int, error) {
+		loads++
+		return nil, ErrNotFound
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := Fetch(context.Background(), c, "missing", load, nil); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v, want ErrNotFound", err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loads = %d, want 1", loads)
+	}
+	if ttl := mr.TTL(c.Key("missing")); ttl != 30*time.Second {
+		t.Errorf("null ttl = %v", ttl)
+	}
+	// 空列表同样使用空值过期时间
+	if _, err := Fetch(context.Background(), c, "empty", func(context.Context) ([]int, error) { return []int{}, nil }, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL(c.Key("empty")); ttl != 30*time.Second {
+		t.Errorf("empty ttl = %v", ttl)
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	c, mr := newTestCache(t)
+	ctx := context.Background()
+	value := func(v string) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) { return v, nil }
+	}
+	tags := func(v string) []string { return []string{"dish:" + v} }
+	for _, k := range []string{"1", "2", "3"} {
+		if _, err := Fetch(ctx, c, k, value(k), tags); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c.InvalidateTags("dish:2")
+	if mr.Exists(c.Key("2")) || !mr.Exists(c.Key("1")) {
+		t.Error("tag invalidation should only remove tagged keys")
+	}
+	c.Invalidate("1")
+	if mr.Exists(c.Key("1")) || !mr.Exists(c.Key("3")) {
+		t.Error("key invalidation should only remove the given key")
+	}
+	mr.Set("other::3", "x")
+	c.InvalidateAll()
+	if len(mr.Keys()) != 1 || !mr.Exists("other::3") {
+		t.Errorf("unexpected keys after InvalidateAll: %v", mr.Keys())
+	}
+}
+
+type nopLog struct{}
+
+func (nopLog) Debug(...interface{}) {}
+func (nopLog) Info(...interface{})  {}
+func (nopLog) Warn(...interface{})  {}
+func (nopLog) Error(...interface{}) {}
+func (nopLog) Fatal(...interface{}) {}
+
+func init() {
+	global.Log = nopLog{}
+}
+
+func resetHealth(t *testing.T) {
+	t.Cleanup(func() {
+		degradedUntil.Store(0)
+		missedEvents.Store(false)
+	})
+}
+
+func TestLocalHit(t *testing.T) {
+	c, mr := newTestCache(t, WithLocal(10, time.Minute))
+	load := func(context.Context) (string, error) { return "v", nil }
+	if _, err := Fetch(context.Background(), c, "k", load, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 一级缓存命中时不访问 Redis
+	mr.Del(c.Key("k"))
+	got, err := Fetch(context.Background(), c, "k", func(context.Context) (string, error) { return "reloaded", nil }, nil)
+	if err != nil || got != "v" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if s := c.Stats(); s.LocalHits != 1 || s.LocalSize != 1 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
+
+func TestBroadcastInvalidation(t *testing.T) {
+	resetHealth(t)
+	c, mr := newTestCache(t, WithLocal(10, time.Minute))
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	stop := Listen(client)
+	defer stop()
+
+	ctx := context.Background()
+	tags := func(v string) []string { return []string{"dish:" + v} }
+	for _, k := range []string{"1", "2"} {
+		k := k
+		if _, err := Fetch(ctx, c, k, func(context.Context) (string, error) { return k, nil }, tags); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 模拟其他实例发出的按标签失效广播
+	data, _ := json.Marshal(message{Origin: "other", Cache: c.prefix, Tags: []string{"dish:1"}})
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		client.Publish(InvalidateChannel, data)
+		if _, ok := c.local.get(c.Key("1")); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("local entry not evicted by broadcast")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if _, ok := c.local.get(c.Key("2")); !ok {
+		t.Error("untagged entry should stay in local cache")
+	}
+}
+
+func TestDegradedMode(t *testing.T) {
+	resetHealth(t)
+	c, mr := newTestCache(t, WithLocal(10, time.Minute))
+	ctx := context.Background()
+	if _, err := Fetch(ctx, c, "k", func(context.Context) (string, error) { return "v", nil }, nil); err != nil {
+		t.Fatal(err)
+	}
+	mr.Close()
+
+	// Redis 不可用时继续使用一级缓存
+	if got, err := Fetch(ctx, c, "k", func(context.Context) (string, error) { return "db", nil }, nil); err != nil || got != "v" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	// 一级缓存未命中时直接回源并写入一级缓存
+	var loads int
+	load := func(context.Context) (string, error) {
+		loads++
+		return "db", nil
+	}
+	for i := 0; i < 2; i++ {
+		if got, err := Fetch(ctx, c, "other", load, nil); err != nil || got != "db" {
+			t.Fatalf("got %q, %v", got, err)
+		}
+	}
+	if loads != 1 || !Degraded() {
+		t.Errorf("loads = %d, degraded = %v", loads, Degraded())
+	}
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+// localEntry 一级缓存条目，值为反序列化后的对象，调用方不应修改
+type localEntry struct {
+	value any
+	null  bool
+}
+
+// local 进程内一级缓存：带过期时间的 LRU，并维护标签到键的索引以便按标签失效
+type local struct {
+	lru     *expirable.LRU[string, localEntry]
+	mu      sync.Mutex
+	tags    map[string]map[string]struct{} // 标签 -> 键
+	keyTags map[string][]string            // 键 -> 标签
+}
+
+func newLocal(size int, ttl time.Duration) *local {
+	l := &local{
+		tags:    make(map[string]map[string]struct{}),
+		keyTags: make(map[string][]string),
+	}
+	l.lru = expirable.NewLRU[string, localEntry](size, func(key string, _ localEntry) {
+		l.untrack(key)
+	}, ttl)
+	return l
+}
+
+func (l *local) get(key string) (localEntry, bool) {
+	return l.lru.Get(key)
+}
+
+func (l *local) set(key string, entry localEntry, tags []string) {
+	// 先写入 LRU 再记录标签：淘汰回调会获取 mu，不能在持有 mu 时调用 LRU
+	l.lru.Add(key, entry)
+	if len(tags) == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.keyTags[key] = tags
+	for _, tag := range tags {
+		keys, ok := l.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			l.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+func (l *local) remove(keys ...string) {
+	for _, key := range keys {
+		l.lru.Remove(key)
+	}
+}
+
+func (l *local) removeTags(tags ...string) {
+	var keys []string
+	l.mu.Lock()
+	for _, tag := range tags {
+		for key := range l.tags[tag] {
+			keys = append(keys, key)
+		}
+	}
+	l.mu.Unlock()
+	l.remove(keys...)
+}
+
+func (l *local) purge() {
+	l.lru.Purge()
+}
+
+func (l *local) len() int {
+	return l.lru.Len()
+}
+
+// untrack 键被淘汰或删除后清理标签索引
+func (l *local) untrack(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, tag := range l.keyTags[key] {
+		if keys, ok := l.tags[tag]; ok {
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(l.tags, tag)
+			}
+		}
+	}
+	delete(l.keyTags, key)
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+type counters struct {
+	localHits     atomic.Int64
+	hits          atomic.Int64
+	nullHits      atomic.Int64
+	misses        atomic.Int64
+	loads         atomic.Int64
+	loadErrors    atomic.Int64
+	shared        atomic.Int64
+	invalidations atomic.Int64
+	errors        atomic.Int64
+	degraded      atomic.Int64
+}
+
+// Stats 缓存命中指标，自进程启动起累计
+type Stats struct {
+	Name          string  `json:"name"`
+	LocalHits     int64   `json:"localHits"`     // 一级缓存命中
+	LocalSize     int     `json:"localSize"`     // 一级缓存条目数
+	Hits          int64   `json:"hits"`          // 命中
+	NullHits      int64   `json:"nullHits"`      // 命中空值
+	Misses        int64   `json:"misses"`        // 未命中
+	Loads         int64   `json:"loads"`         // 实际回源次数，singleflight 合并后小于未命中次数
+	LoadErrors    int64   `json:"loadErrors"`    // 回源失败
+	Shared        int64   `json:"shared"`        // 共享了其他请求加载结果的次数
+	Invalidations int64   `json:"invalidations"` // 删除的键数
+	Errors        int64   `json:"errors"`        // Redis 读写失败
+	Degraded      int64   `json:"degraded"`      // 降级期间跳过 Redis 直接回源的次数
+	HitRate       float64 `json:"hitRate"`
+}
+
+// Stats 返回当前指标
+func (c *Cache) Stats() Stats {
+	s := Stats{
+		Name:          c.prefix,
+		LocalHits:     c.stats.localHits.Load(),
+		Hits:          c.stats.hits.Load(),
+		NullHits:      c.stats.nullHits.Load(),
+		Misses:        c.stats.misses.Load(),
+		Loads:         c.stats.loads.Load(),
+		LoadErrors:    c.stats.loadErrors.Load(),
+		Shared:        c.stats.shared.Load(),
+		Invalidations: c.stats.invalidations.Load(),
+		Errors:        c.stats.errors.Load(),
+		Degraded:      c.stats.degraded.Load(),
+	}
+	if c.local != nil {
+		s.LocalSize = c.local.len()
+	}
+	if total := s.LocalHits + s.Hits + s.NullHits + s.Misses; total > 0 {
+		s.HitRate = float64(s.LocalHits+s.Hits+s.NullHits) / float64(total)
+	}
+	return s
+}
+
+// AllStats 返回所有已创建缓存的指标
+func AllStats() []Stats {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	res := make([]Stats, 0, len(registry))
+	for _, c := range registry {
+		res = append(res, c.Stats())
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
@@ -0,0 +1,229 @@
+package cart
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"takeout/global"
+)
+
+const (
+	UserKey     = "cart:user:"    // 用户购物车 hash，后接用户id
+	DirtyKey    = "cart:dirty"    // 待写回 MySQL 的用户id集合
+	MigratedKey = "cart:migrated" // MySQL 中的购物车已导入 Redis 的标记
+	MetaPrefix  = "meta:"         // 行信息字段前缀，数量单独存放便于 HINCRBY
+
+	seqField = "seq" // 购物车行id序列
+)
+
+// incrScript 原子地增加行的数量，行不存在且未提供行信息时返回 -1 由调用方补全后重试
+// KEYS: 购物车, 待写回集合  ARGV: 数量字段, 信息字段, 行信息, 增量, 过期毫秒, 用户id
+var incrScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[2]) == 0 then
+	if ARGV[3] == '' then
+		return -1
+	end
+	local line = cjson.decode(ARGV[3])
+	line['id'] = redis.call('HINCRBY', KEYS[1], '` + seqField + `', 1)
+	redis.call('HSET', KEYS[1], ARGV[2], cjson.encode(line))
+end
+local n = redis.call('HINCRBY', KEYS[1], ARGV[1], ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+redis.call('SADD', KEYS[2], ARGV[6])
+return n
+`)
+
+// decrScript 原子地减少行的数量，减到 0 时删除该行
+// KEYS: 购物车, 待写回集合  ARGV: 数量字段, 信息字段, 过期毫秒, 用户id
+var decrScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return -1
+end
+local n = redis.call('HINCRBY', KEYS[1], ARGV[1], -1)
+if n <= 0 then
+	redis.call('HDEL', KEYS[1], ARGV[1], ARGV[2])
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+redis.call('SADD', KEYS[2], ARGV[4])
+return n
+`)
+
+// updateScript 更新行信息中的名称、图片与单价，保留行id与加入时间
+// KEYS: 购物车, 待写回集合  ARGV: 信息字段, 名称, 图片, 单价, 用户id
+var updateScript = redis.NewScript(`
+local meta = redis.call('HGET', KEYS[1], ARGV[1])
+if not meta then
+	return 0
+end
+local line = cjson.decode(meta)
+line['name'] = ARGV[2]
+line['image'] = ARGV[3]
+line['amount'] = tonumber(ARGV[4])
+redis.call('HSET', KEYS[1], ARGV[1], cjson.encode(line))
+redis.call('SADD', KEYS[2], ARGV[5])
+return 1
+`)
+
+// importScript 购物车不存在时整体写入，已存在时不做任何修改并返回 0
+// KEYS: 购物车  ARGV: 过期毫秒, 行id序列, 之后每三个为 数量字段, 数量, 行信息
+var importScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+for i = 3, #ARGV, 3 do
+	redis.call('HINCRBY', KEYS[1], ARGV[i], ARGV[i + 1])
+	redis.call('HSET', KEYS[1], '` + MetaPrefix + `' .. ARGV[i], ARGV[i + 2])
+end
+redis.call('HSET', KEYS[1], '` + seqField + `', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return 1
+`)
+
+// Line 购物车的一行，Meta 为调用方序列化的 JSON 行信息，新行的 id 字段由购物车分配
+type Line struct {
+	Field  string // 行标识，同一商品同一口味为一行
+	Meta   string
+	Number int
+}
+
+// Store 购物车存放在 Redis hash 中，多端共享同一份数据，变更的用户记入待写回集合
+type Store struct {
+	client func() *redis.Client
+	ttl    func() time.Duration
+}
+
+// New 创建购物车存储，client 为 nil 时使用 global.RedisClient，ttl 在每次写入时读取
+func New(client *redis.Client, ttl func() time.Duration) *Store {
+	s := &Store{client: func() *redis.Client { return global.RedisClient }, ttl: ttl}
+	if client != nil {
+		s.client = func() *redis.Client { return client }
+	}
+	return s
+}
+
+// Incr 增加行数量并刷新过期时间，meta 为空且该行不存在时返回 -1
+func (s *Store) Incr(userId int, field, meta string, number int) (int64, error) {
+	n, err := incrScript.Run(s.client(), s.keys(userId),
+		field, MetaPrefix+field, meta, number, s.ttl().Milliseconds(), userId,
+	).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to add shopping cart: %w", err)
+	}
+	return n, nil
+}
+
+// Decr 行数量减一，减到 0 时删除该行，行不存在时返回 -1
+func (s *Store) Decr(userId int, field string) (int64, error) {
+	n, err := decrScript.Run(s.client(), s.keys(userId),
+		field, MetaPrefix+field, s.ttl().Milliseconds(), userId,
+	).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to subtract shopping cart: %w", err)
+	}
+	return n, nil
+}
+
+// Update 更新行的名称、图片与单价，行不存在时返回 false
+func (s *Store) Update(userId int, field, name, image string, amount float64) (bool, error) {
+	n, err := updateScript.Run(s.client(), s.keys(userId),
+		MetaPrefix+field, name, image, amount, userId,
+	).Int64()
+	if err != nil {
+		return false, fmt.Errorf("failed to update shopping cart: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Lines 用户购物车中数量大于 0 的行，顺序不固定
+func (s *Store) Lines(userId int) ([]Line, error) {
+	values, err := s.client().HGetAll(UserKey + strconv.Itoa(userId)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shopping cart: %w", err)
+	}
+	lines := make([]Line, 0, len(values)/2)
+	for field, meta := range values {
+		line, ok := strings.CutPrefix(field, MetaPrefix)
+		if !ok {
+			continue
+		}
+		if number, _ := strconv.Atoi(values[line]); number > 0 {
+			lines = append(lines, Line{Field: line, Meta: meta, Number: number})
+		}
+	}
+	return lines, nil
+}
+
+// Clean 清空购物车
+func (s *Store) Clean(userId int) error {
+	pipe := s.client().TxPipeline()
+	pipe.Del(UserKey + strconv.Itoa(userId))
+	pipe.SAdd(DirtyKey, userId)
+	if _, err := pipe.Exec(); err != nil {
+		return fmt.Errorf("failed to clean shopping cart: %w", err)
+	}
+	return nil
+}
+
+// PopDirty 取出最多 limit 个待写回的用户
+func (s *Store) PopDirty(limit int) ([]int, error) {
+	values, err := s.client().SPopN(DirtyKey, int64(limit)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to pop dirty shopping carts: %w", err)
+	}
+	userIds := make([]int, 0, len(values))
+	for _, value := range values {
+		if userId, err := strconv.Atoi(value); err == nil {
+			userIds = append(userIds, userId)
+		}
+	}
+	return userIds, nil
+}
+
+// MarkDirty 将用户放回待写回集合，用于写回失败后重试
+func (s *Store) MarkDirty(userIds ...int) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+	members := make([]any, 0, len(userIds))
+	for _, userId := range userIds {
+		members = append(members, userId)
+	}
+	return s.client().SAdd(DirtyKey, members...).Err()
+}
+
+// Import 用户在 Redis 中还没有购物车时写入 lines，seq 为已用的最大行id，已有购物车时返回 false
+// 导入的数据来自 MySQL，不记入待写回集合
+func (s *Store) Import(userId int, lines []Line, seq int) (bool, error) {
+	args := make([]any, 0, 2+3*len(lines))
+	args = append(args, s.ttl().Milliseconds(), seq)
+	for _, line := range lines {
+		args = append(args, line.Field, line.Number, line.Meta)
+	}
+	n, err := importScript.Run(s.client(), []string{UserKey + strconv.Itoa(userId)}, args...).Int64()
+	if err != nil {
+		return false, fmt.Errorf("failed to import shopping cart: %w", err)
+	}
+	return n == 1, nil
+}
+
+// MarkMigrated 首次调用返回 true，用于保证 MySQL 购物车只导入一次
+func (s *Store) MarkMigrated() (bool, error) {
+	ok, err := s.client().SetNX(MigratedKey, time.Now().Unix(), 0).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark shopping cart migration: %w", err)
+	}
+	return ok, nil
+}
+
+// UnmarkMigrated 导入失败时清除标记，下次启动重试
+func (s *Store) UnmarkMigrated() error {
+	return s.client().Del(MigratedKey).Err()
+}
+
+func (s *Store) keys(userId int) []string {
+	return []string{UserKey + strconv.Itoa(userId), DirtyKey}
+}
@@ -0,0 +1,219 @@
+package cart
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+type testLine struct {
+	Id         int     `json:"id"`
+	Name       string  `json:"name"`
+	Image      string  `json:"image"`
+	Amount     float64 `json:"amount"`
+	CreateTime string  `json:"createTime"`
+}
+
+func newTestStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return New(client, func() time.Duration { return time.Hour }), mr
+}
+
+func meta(t *testing.T, s *Store, userId int, field string) testLine {
+	t.Helper()
+	lines, err := s.Lines(userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range lines {
+		if line.Field == field {
+			var v testLine
+			if err = json.Unmarshal([]byte(line.Meta), &v); err != nil {
+				t.Fatalf("meta %q: %v", line.Meta, err)
+			}
+			return v
+		}
+	}
+	t.Fatalf("line %q not found in %v", field, lines)
+	return testLine{}
+}
+
+func TestIncr(t *testing.T) {
+	s, mr := newTestStore(t)
+	if n, err := s.Incr(7, "1:0:辣", "", 1); err != nil || n != -1 {
+		t.Fatalf("Incr without meta = %d, %v, want -1", n, err)
+	}
+	if n, err := s.Incr(7, "1:0:辣", `{"name":"宫保鸡丁","amount":28}`, 1); err != nil || n != 1 {
+		t.Fatalf("Incr = %d, %v, want 1", n, err)
+	}
+	// 行已存在时不需要行信息，也不会重新分配id
+	if n, err := s.Incr(7, "1:0:辣", "", 2); err != nil || n != 3 {
+		t.Fatalf("Incr = %d, %v, want 3", n, err)
+	}
+	if _, err := s.Incr(7, "0:3:", `{"name":"双人套餐","amount":58}`, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := meta(t, s, 7, "1:0:辣"); got.Id != 1 || got.Name != "宫保鸡丁" {
+		t.Errorf("first line = %+v", got)
+	}
+	if got := meta(t, s, 7, "0:3:"); got.Id != 2 {
+		t.Errorf("second line id = %d, want 2", got.Id)
+	}
+	if ttl := mr.TTL(UserKey + "7"); ttl != time.Hour {
+		t.Errorf("ttl = %v", ttl)
+	}
+	if members, _ := mr.Members(DirtyKey); !slices.Equal(members, []string{"7"}) {
+		t.Errorf("dirty = %v", members)
+	}
+}
+
+func TestDecr(t *testing.T) {
+	s, mr := newTestStore(t)
+	if n, err := s.Decr(7, "1:0:"); err != nil || n != -1 {
+		t.Fatalf("Decr missing line = %d, %v, want -1", n, err)
+	}
+	if _, err := s.Incr(7, "1:0:", `{"name":"a"}`, 2); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.Decr(7, "1:0:"); err != nil || n != 1 {
+		t.Fatalf("Decr = %d, %v, want 1", n, err)
+	}
+	if n, err := s.Decr(7, "1:0:"); err != nil || n != 0 {
+		t.Fatalf("Decr = %d, %v, want 0", n, err)
+	}
+	// 减到 0 时数量与行信息一并删除
+	if keys, _ := mr.HKeys(UserKey + "7"); !slices.Equal(keys, []string{seqField}) {
+		t.Errorf("fields after removal = %v", keys)
+	}
+	if lines, _ := s.Lines(7); len(lines) != 0 {
+		t.Errorf("lines = %v", lines)
+	}
+}
+
+func TestUpdateKeepsIdAndCreateTime(t *testing.T) {
+	s, _ := newTestStore(t)
+	if ok, err := s.Update(7, "1:0:", "b", "b.png", 30); err != nil || ok {
+		t.Fatalf("Update missing line = %v, %v", ok, err)
+	}
+	if _, err := s.Incr(7, "1:0:", `{"name":"a","image":"a.png","amount":28,"createTime":"2024-01-02T03:04:05Z"}`, 1); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.Update(7, "1:0:", "b", "b.png", 30.5); err != nil || !ok {
+		t.Fatalf("Update = %v, %v", ok, err)
+	}
+	got := meta(t, s, 7, "1:0:")
+	want := testLine{Id: 1, Name: "b", Image: "b.png", Amount: 30.5, CreateTime: "2024-01-02T03:04:05Z"}
+	if got != want {
+		t.Errorf("line = %+v, want %+v", got, want)
+	}
+}
+
+func TestPopDirty(t *testing.T) {
+	s, mr := newTestStore(t)
+	for _, userId := range []int{1, 2, 3} {
+		if _, err := s.Incr(userId, "1:0:", `{}`, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, err := s.PopDirty(2)
+	if err != nil || len(first) != 2 {
+		t.Fatalf("PopDirty = %v, %v", first, err)
+	}
+	rest, err := s.PopDirty(2)
+	if err != nil || len(rest) != 1 {
+		t.Fatalf("PopDirty = %v, %v", rest, err)
+	}
+	got := append(first, rest...)
+	slices.Sort(got)
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("popped = %v", got)
+	}
+	if empty, err := s.PopDirty(2); err != nil || len(empty) != 0 {
+		t.Fatalf("PopDirty on empty set = %v, %v", empty, err)
+	}
+	if err = s.MarkDirty(first...); err != nil {
+		t.Fatal(err)
+	}
+	if members, _ := mr.Members(DirtyKey); len(members) != 2 {
+		t.Errorf("dirty after requeue = %v", members)
+	}
+}
+
+func TestClean(t *testing.T) {
+	s, mr := newTestStore(t)
+	if _, err := s.Incr(7, "1:0:", `{}`, 1); err != nil {
+		t.Fatal(err)
+	}
+	mr.Del(DirtyKey)
+	if err := s.Clean(7); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists(UserKey + "7") {
+		t.Error("cart not deleted")
+	}
+	// 清空也需要写回，MySQL 中的旧行才会删除
+	if ok, _ := mr.SIsMember(DirtyKey, "7"); !ok {
+		t.Error("cleaned cart not marked dirty")
+	}
+}
+
+func TestImport(t *testing.T) {
+	s, mr := newTestStore(t)
+	lines := []Line{
+		{Field: "1:0:", Meta: `{"id":4,"name":"a"}`, Number: 2},
+		{Field: "0:3:", Meta: `{"id":9,"name":"b"}`, Number: 1},
+	}
+	if ok, err := s.Import(7, lines, 9); err != nil || !ok {
+		t.Fatalf("Import = %v, %v", ok, err)
+	}
+	got, err := s.Lines(7)
+	if err != nil || len(got) != 2 {
+		t.Fatalf("Lines = %v, %v", got, err)
+	}
+	// 导入后新增的行id接在已有的行之后
+	if _, err = s.Incr(7, "2:0:", `{"name":"c"}`, 1); err != nil {
+		t.Fatal(err)
+	}
+	if line := meta(t, s, 7, "2:0:"); line.Id != 10 {
+		t.Errorf("new line id = %d, want 10", line.Id)
+	}
+	if ttl := mr.TTL(UserKey + "7"); ttl != time.Hour {
+		t.Errorf("ttl = %v", ttl)
+	}
+	// 已有购物车的用户不覆盖
+	if ok, err := s.Import(7, lines[:1], 4); err != nil || ok {
+		t.Fatalf("Import existing = %v, %v", ok, err)
+	}
+	if got, _ = s.Lines(7); len(got) != 3 {
+		t.Errorf("lines after second import = %v", got)
+	}
+	mr.Del(DirtyKey)
+	if ok, err := s.Import(8, lines, 9); err != nil || !ok {
+		t.Fatalf("Import = %v, %v", ok, err)
+	}
+	if mr.Exists(DirtyKey) {
+		t.Error("imported cart marked dirty")
+	}
+}
+
+func TestMarkMigrated(t *testing.T) {
+	s, _ := newTestStore(t)
+	if ok, err := s.MarkMigrated(); err != nil || !ok {
+		t.Fatalf("first MarkMigrated = %v, %v", ok, err)
+	}
+	if ok, err := s.MarkMigrated(); err != nil || ok {
+		t.Fatalf("second MarkMigrated = %v, %v", ok, err)
+	}
+	if err := s.UnmarkMigrated(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.MarkMigrated(); err != nil || !ok {
+		t.Fatalf("MarkMigrated after unmark = %v, %v", ok, err)
+	}
+}
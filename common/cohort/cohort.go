@@ -0,0 +1,101 @@
+package cohort
+
+import (
+	"sort"
+	"takeout/common/period"
+	"time"
+)
+
+// Order 一笔已完成订单
+type Order struct {
+	UserId int
+	Time   time.Time
+	Amount float64
+}
+
+// Row 首单落在同一时间桶的用户群组，Active[i] 为其中在之后第 i 个时间桶仍有下单的用户数
+type Row struct {
+	Cohort time.Time
+	Size   int
+	Active []int
+}
+
+// Retention 按区间的粒度与时区构建留存表，first 为用户的首单时间，首单不在区间内的用户不计入任何群组
+func Retention(r period.Range, first map[int]time.Time, orders []Order) []Row {
+	buckets := r.Buckets()
+	index := make(map[int64]int, len(buckets))
+	rows := make([]Row, len(buckets))
+	for i, bucket := range buckets {
+		index[bucket.Unix()] = i
+		rows[i] = Row{Cohort: bucket, Active: make([]int, len(buckets)-i)}
+	}
+
+	cohortOf := make(map[int]int)
+	for user, t := range first {
+		if t.Before(r.Begin) || t.After(r.End) {
+			continue
+		}
+		if i, ok := index[r.Truncate(t).Unix()]; ok {
+			cohortOf[user] = i
+			rows[i].Size++
+		}
+	}
+	seen := make(map[[2]int]bool)
+	for _, order := range orders {
+		c, ok := cohortOf[order.UserId]
+		if !ok {
+			continue
+		}
+		b, ok := index[r.Truncate(order.Time).Unix()]
+		if !ok || b < c {
+			continue
+		}
+		key := [2]int{order.UserId, b}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		rows[c].Active[b-c]++
+	}
+	return rows
+}
+
+// Stats 一组订单的客户数、复购与下单频次
+type Stats struct {
+	Customers  int
+	Repeaters  int // 下单 2 次及以上的客户数
+	Orders     int
+	Amount     float64
+	AvgGapDays float64 // 同一客户相邻两单的平均间隔天数
+}
+
+// Summarize 汇总订单的客户维度指标
+func Summarize(orders []Order) Stats {
+	byUser := make(map[int][]time.Time)
+	var stats Stats
+	for _, order := range orders {
+		byUser[order.UserId] = append(byUser[order.UserId], order.Time)
+		stats.Orders++
+		stats.Amount += order.Amount
+	}
+	var (
+		gaps  float64
+		count int
+	)
+	for _, times := range byUser {
+		stats.Customers++
+		if len(times) < 2 {
+			continue
+		}
+		stats.Repeaters++
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+		for i := 1; i < len(times); i++ {
+			gaps += times[i].Sub(times[i-1]).Hours() / 24
+			count++
+		}
+	}
+	if count > 0 {
+		stats.AvgGapDays = gaps / float64(count)
+	}
+	return stats
+}
@@ -0,0 +1,74 @@
+package cohort
+
+import (
+	"testing"
+	"time"
+
+	"takeout/common/period"
+)
+
+func day(s string) time.Time {
+	t, _ := time.ParseInLocation(time.DateTime, s, time.Local)
+	return t
+}
+
+func TestRetention(t *testing.T) {
+	// 2024-01-01 为周一，区间覆盖三周
+	r, err := period.Parse("2024-01-01", "2024-01-21", "week", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := map[int]time.Time{
+		1: day("2024-01-01 12:00:00"),
+		2: day("2024-01-03 12:00:00"),
+		3: day("2024-01-09 12:00:00"),
+		4: day("2023-12-20 12:00:00"), // 首单早于区间，不属于任何群组
+	}
+	orders := []Order{
+		{UserId: 1, Time: day("2024-01-01 12:00:00")},
+		{UserId: 1, Time: day("2024-01-02 12:00:00")}, // 同一周重复下单只计一次
+		{UserId: 1, Time: day("2024-01-15 12:00:00")},
+		{UserId: 2, Time: day("2024-01-03 12:00:00")},
+		{UserId: 2, Time: day("2024-01-10 12:00:00")},
+		{UserId: 3, Time: day("2024-01-09 12:00:00")},
+		{UserId: 4, Time: day("2024-01-09 12:00:00")},
+	}
+	rows := Retention(r, first, orders)
+	if len(rows) != 3 {
+		t.Fatalf("got %d cohorts, want 3", len(rows))
+	}
+	want := []struct {
+		size   int
+		active []int
+	}{
+		{2, []int{2, 1, 1}},
+		{1, []int{1, 0}},
+		{0, []int{0}},
+	}
+	for i, w := range want {
+		if rows[i].Size != w.size {
+			t.Errorf("cohort %d size %d, want %d", i, rows[i].Size, w.size)
+		}
+		for j := range w.active {
+			if rows[i].Active[j] != w.active[j] {
+				t.Errorf("cohort %d active %v, want %v", i, rows[i].Active, w.active)
+				break
+			}
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	stats := Summarize([]Order{
+		{UserId: 1, Time: day("2024-01-01 12:00:00"), Amount: 10},
+		{UserId: 1, Time: day("2024-01-05 12:00:00"), Amount: 20},
+		{UserId: 1, Time: day("2024-01-03 12:00:00"), Amount: 30},
+		{UserId: 2, Time: day("2024-01-02 12:00:00"), Amount: 40},
+	})
+	if stats.Customers != 2 || stats.Repeaters != 1 || stats.Orders != 4 || stats.Amount != 100 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if stats.AvgGapDays != 2 {
+		t.Errorf("avg gap %v, want 2", stats.AvgGapDays)
+	}
+}
@@ -22,4 +22,59 @@ var (
 	Error_DISH_BE_RELATED_BY_SETMEAL     = errors.New("当前菜品关联了套餐,不能删除")
 	Error_ORDER_STATUS_ERROR             = errors.New("订单状态错误")
 	Error_ORDER_NOT_FOUND                = errors.New("订单不存在")
+	Error_ORDER_NOT_COMPLETED            = errors.New("订单未完成，不能评价")
+	Error_REVIEW_ALREADY_EXISTS          = errors.New("该订单已评价")
+	Error_REVIEW_NOT_FOUND               = errors.New("评价不存在")
+	Error_REVIEW_RATING_INVALID          = errors.New("评分须为1到5星")
+	Error_REPORT_QUERY_INVALID           = errors.New("报表查询参数错误")
+	Error_EXPORT_JOB_NOT_FOUND           = errors.New("导出任务不存在或已过期")
+	Error_EXPORT_NOT_READY               = errors.New("导出文件尚未生成")
+	Error_SCHEDULE_NOT_FOUND             = errors.New("推送计划不存在")
+	Error_SCHEDULE_INVALID               = errors.New("推送计划参数错误")
+	Error_STATION_NOT_FOUND              = errors.New("工位不存在")
+	Error_STATION_IN_USE                 = errors.New("工位还有未出品的菜品，不能删除")
+	Error_KITCHEN_ITEM_NOT_FOUND         = errors.New("出品项不存在")
+	Error_KITCHEN_ORDER_NOT_FOUND        = errors.New("后厨单不存在或订单已不在制作中")
+	Error_PRINTER_NOT_FOUND              = errors.New("打印机不存在")
+	Error_PRINTER_INVALID                = errors.New("打印机参数错误")
+	Error_PRINT_JOB_NOT_FOUND            = errors.New("打印任务不存在或不是失败状态")
+	Error_PRINT_FAILED                   = errors.New("打印失败")
+	Error_ORDER_TYPE_INVALID             = errors.New("订单类型错误")
+	Error_TABLE_NOT_FOUND                = errors.New("桌台不存在")
+	Error_TABLE_CODE_INVALID             = errors.New("桌台二维码无效，请重新扫码")
+	Error_PICKUP_CODE_NOT_FOUND          = errors.New("取餐码无效或订单已取餐")
+	Error_SLOT_UNAVAILABLE               = errors.New("所选送达时段不可预约")
+	Error_SLOT_FULL                      = errors.New("所选送达时段已约满，请选择其他时段")
+	Error_GROUP_NOT_FOUND                = errors.New("拼单不存在或已过期")
+	Error_GROUP_CLOSED                   = errors.New("拼单已锁定或已结束")
+	Error_GROUP_NOT_MEMBER               = errors.New("未加入该拼单")
+	Error_GROUP_NOT_OWNER                = errors.New("只有发起人可以操作")
+	Error_GROUP_FULL                     = errors.New("拼单人数已满")
+	Error_GROUP_INVALID                  = errors.New("拼单参数错误")
+	Error_ORDER_SPLIT_BILL               = errors.New("AA 支付的订单请由各成员分别支付")
+	Error_CART_ITEM_UNAVAILABLE          = errors.New("购物车中有已下架或口味已调整的商品，请确认后下单")
+	Error_CART_REPRICED                  = errors.New("购物车中有商品价格已变更，请确认后下单")
+	Error_CART_QUANTITY_EXCEEDED         = errors.New("超过该商品的限购数量")
+	Error_CART_BELOW_MINIMUM             = errors.New("未达到起送金额")
+	Error_AFTER_SALE_NOT_FOUND           = errors.New("售后单不存在")
+	Error_AFTER_SALE_INVALID             = errors.New("售后申请参数错误")
+	Error_AFTER_SALE_EXPIRED             = errors.New("订单已超过售后期限")
+	Error_AFTER_SALE_EXISTS              = errors.New("该订单已有处理中的售后单")
+	Error_AFTER_SALE_HANDLED             = errors.New("售后单已处理")
+	Error_ADDRESS_NOT_FOUND              = errors.New("地址不存在")
+	Error_ADDRESS_INVALID                = errors.New("地址信息错误")
+	Error_ADDRESS_PHONE_INVALID          = errors.New("手机号格式错误")
+	Error_ADDRESS_LIMIT                  = errors.New("地址数量已达上限")
+	Error_PHONE_INVALID                  = errors.New("手机号格式错误")
+	Error_SMS_TOO_FREQUENT               = errors.New("验证码发送过于频繁，请稍后再试")
+	Error_SMS_DAILY_LIMIT                = errors.New("今日验证码发送次数已达上限")
+	Error_SMS_CODE_INVALID               = errors.New("验证码错误或已过期")
+	Error_WX_SESSION_EXPIRED             = errors.New("微信登录已过期，请重新登录")
+	Error_MERGE_CODE_INVALID             = errors.New("合并码错误或已过期")
+	Error_PROFILE_INVALID                = errors.New("个人资料填写错误")
+	Error_AVATAR_INVALID                 = errors.New("头像仅支持 2MB 以内的 JPG、PNG、WEBP 图片")
+	Error_ACCOUNT_IN_USE                 = errors.New("存在进行中的订单或售后，暂不能注销")
+	Error_FILE_TOO_LARGE                 = errors.New("文件超过大小限制")
+	Error_FILE_TYPE_INVALID              = errors.New("不支持的文件类型")
+	Error_FILE_KEY_INVALID               = errors.New("文件地址不正确")
 )
@@ -22,6 +22,58 @@ const (
 	Completed
 	// Cancelled 已取消
 	Cancelled
+	// Scheduled 已支付的预约订单，在时段开始前推送给商家后转为待接单
+	Scheduled
+)
+
+// 送达方式，对应订单的 DeliveryStatus
+const (
+	// DeliverAtTime 预约送达，EstimatedDeliveryTime 为所选时段的开始时间
+	DeliverAtTime = iota
+	// DeliverNow 立即送出
+	DeliverNow
+)
+
+// 订单类型，堂食与自取订单接单后直接完成，不经过派送中
+const (
+	// OrderTypeDelivery 外卖配送
+	OrderTypeDelivery = 1 + iota
+	// OrderTypePickup 到店自取
+	OrderTypePickup
+	// OrderTypeDineIn 堂食
+	OrderTypeDineIn
+)
+
+// 拼单状态
+const (
+	// GroupOpen 进行中，成员可加入并修改自己的菜品
+	GroupOpen = iota
+	// GroupLocked 发起人已锁定，等待下单
+	GroupLocked
+	// GroupOrdered 已下单
+	GroupOrdered
+	// GroupCancelled 已取消
+	GroupCancelled
+)
+
+// 售后单状态
+const (
+	// AfterSalePending 待处理
+	AfterSalePending = iota
+	// AfterSaleApproved 已同意
+	AfterSaleApproved
+	// AfterSaleRejected 已拒绝
+	AfterSaleRejected
+	// AfterSaleCancelled 用户已撤销
+	AfterSaleCancelled
+)
+
+// 售后诉求与处理方式
+const (
+	// AfterSaleRefund 部分退款
+	AfterSaleRefund = iota + 1
+	// AfterSaleRemake 重做
+	AfterSaleRemake
 )
 
 // 支付状态
@@ -33,3 +85,25 @@ const (
 	// Refund 已退款
 	Refund
 )
+
+// 后厨出品状态
+const (
+	// KitchenWaiting 待制作
+	KitchenWaiting = iota
+	// KitchenStarted 制作中
+	KitchenStarted
+	// KitchenDone 已出品（整单全部出品即为待派送）
+	KitchenDone
+)
+
+// 打印任务状态
+const (
+	// PrintPending 待打印（含等待重试）
+	PrintPending = iota
+	// PrintPrinting 打印中
+	PrintPrinting
+	// PrintDone 已打印
+	PrintDone
+	// PrintFailed 重试次数用尽
+	PrintFailed
+)
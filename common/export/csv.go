@@ -0,0 +1,64 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+)
+
+// csvFlushRows 每写出若干行刷新一次，使下载端尽早收到数据
+const csvFlushRows = 500
+
+// CSV 逗号分隔文本，带 UTF-8 BOM 以便 Excel 正确识别中文
+type CSV struct{}
+
+func (CSV) Format() string { return FormatCSV }
+
+func (CSV) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (CSV) Render(w io.Writer, r *Report) error {
+	buf := bufio.NewWriter(w)
+	if _, err := buf.WriteString("\ufeff"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(buf)
+	header := make([]string, len(r.Columns))
+	for i, col := range r.Columns {
+		header[i] = col.Title
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	n := 0
+	record := make([]string, len(r.Columns))
+	if r.Rows != nil {
+		err := r.Rows(func(row []any) error {
+			for i, col := range r.Columns {
+				record[i] = ""
+				if i < len(row) {
+					record[i] = FormatValue(col.Kind, row[i])
+				}
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+			if n++; n%csvFlushRows == 0 {
+				cw.Flush()
+				if err := cw.Error(); err != nil {
+					return err
+				}
+				return buf.Flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return buf.Flush()
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// 导出格式
+const (
+	FormatXLSX = "xlsx"
+	FormatCSV  = "csv"
+	FormatPDF  = "pdf"
+)
+
+// 列的取值格式
+const (
+	KindText    = ""
+	KindInt     = "int"
+	KindMoney   = "money"   // 保留两位小数
+	KindPercent = "percent" // 0.1234 展示为 12.34%
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// Column 明细列
+type Column struct {
+	Key   string
+	Title string
+	Kind  string
+}
+
+// Field 汇总指标
+type Field struct {
+	Key   string
+	Label string
+	Kind  string
+	Value any
+}
+
+// RowFunc 逐行产出明细，emit 返回错误时应立即停止
+type RowFunc func(emit func(row []any) error) error
+
+// Report 待导出的报表，明细通过 Rows 逐行产出，渲染器边读边写，避免大报表整体驻留内存
+type Report struct {
+	Title   string
+	Period  string
+	Summary []Field
+	Columns []Column
+	Rows    RowFunc
+}
+
+// Renderer 将报表渲染为某种文件格式
+type Renderer interface {
+	Format() string
+	ContentType() string
+	Render(w io.Writer, r *Report) error
+}
+
+// NewRenderer 按格式创建渲染器，tpl 仅对 xlsx 生效，为空时生成通用表格
+func NewRenderer(format string, tpl *Template) (Renderer, error) {
+	switch format {
+	case FormatXLSX, "":
+		return &XLSX{Template: tpl}, nil
+	case FormatCSV:
+		return CSV{}, nil
+	case FormatPDF:
+		return PDF{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+}
+
+// FormatValue 按列类型格式化为文本，供 CSV/PDF 使用
+func FormatValue(kind string, v any) string {
+	switch kind {
+	case KindMoney:
+		if f, ok := toFloat(v); ok {
+			return strconv.FormatFloat(f, 'f', 2, 64)
+		}
+	case KindPercent:
+		if f, ok := toFloat(v); ok {
+			return strconv.FormatFloat(f*100, 'f', 2, 64) + "%"
+		}
+	}
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
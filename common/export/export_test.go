@@ -0,0 +1,148 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func testReport(rows int) *Report {
+	return &Report{
+		Title:  "运营数据报表",
+		Period: "2024-01-01至2024-01-31",
+		Summary: []Field{
+			{Key: "turnover", Label: "营业额", Kind: KindMoney, Value: 1234.5},
+			{Key: "orderCompletionRate", Label: "订单完成率", Kind: KindPercent, Value: 0.9},
+		},
+		Columns: []Column{
+			{Key: "time", Title: "日期"},
+			{Key: "turnover", Title: "营业额", Kind: KindMoney},
+			{Key: "validOrderCount", Title: "有效订单", Kind: KindInt},
+		},
+		Rows: func(emit func(row []any) error) error {
+			for i := 0; i < rows; i++ {
+				if err := emit([]any{fmt.Sprintf("2024-01-%02d", i%31+1), float64(i) + 0.5, i}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (CSV{}).Render(&buf, testReport(2)); err != nil {
+		t.Fatal(err)
+	}
+	want := "\ufeff日期,营业额,有效订单\n2024-01-01,0.50,0\n2024-01-02,1.50,1\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRowsErrorPropagates(t *testing.T) {
+	boom := errors.New("boom")
+	r := testReport(0)
+	r.Rows = func(emit func(row []any) error) error { return boom }
+	for _, format := range []string{FormatCSV, FormatXLSX, FormatPDF} {
+		renderer, _ := NewRenderer(format, nil)
+		if err := renderer.Render(&bytes.Buffer{}, r); !errors.Is(err, boom) {
+			t.Errorf("%s: got %v, want boom", format, err)
+		}
+	}
+	if _, err := NewRenderer("docx", nil); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("got %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestXLSXStream(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&XLSX{}).Render(&buf, testReport(3)); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := f.GetRows("Sheet1")
+	// 标题、区间、2 项汇总、空行、表头、3 行明细
+	if len(rows) != 9 || rows[5][0] != "日期" || rows[8][0] != "2024-01-03" {
+		t.Errorf("unexpected rows %v", rows)
+	}
+}
+
+func TestXLSXTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tpl.xlsx")
+	tplFile := excelize.NewFile()
+	_ = tplFile.SetCellValue("Sheet1", "A1", "模板")
+	if err := tplFile.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+	tpl := &Template{
+		Path:      path,
+		Sheet:     "Sheet1",
+		TitleCell: "B2",
+		Cells:     map[string]string{"turnover": "C4"},
+		DetailRow: 8,
+		DetailCol: "B",
+		Columns:   []string{"time", "unknown", "turnover"},
+	}
+	var buf bytes.Buffer
+	if err := (&XLSX{Template: tpl}).Render(&buf, testReport(2)); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(cell, want string) {
+		if got, _ := f.GetCellValue("Sheet1", cell); got != want {
+			t.Errorf("%s: got %q, want %q", cell, got, want)
+		}
+	}
+	check("A1", "模板")
+	check("B2", "2024-01-01至2024-01-31")
+	check("C4", "1234.5")
+	check("B9", "2024-01-02")
+	check("C9", "")
+	check("D9", "1.5")
+}
+
+func TestPDF(t *testing.T) {
+	var buf bytes.Buffer
+	// 足够多的行以触发分页
+	if err := (PDF{}).Render(&buf, testReport(120)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-1.4") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Fatal("missing PDF header or trailer")
+	}
+	if n := strings.Count(out, "/Type /Page "); n < 2 {
+		t.Errorf("expected multiple pages, got %d", n)
+	}
+	// 交叉引用表中的偏移量须指向对应对象
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(out)
+	xref, _ := strconv.Atoi(m[1])
+	lines := strings.Split(out[xref:], "\n")
+	for i, line := range lines[3:] {
+		if !strings.HasSuffix(line, " n ") {
+			break
+		}
+		offset, _ := strconv.Atoi(line[:10])
+		if want := fmt.Sprintf("%d 0 obj", i+1); !strings.HasPrefix(out[offset:], want) {
+			t.Fatalf("object %d offset %d points to %q", i+1, offset, out[offset:offset+10])
+		}
+	}
+	if !strings.Contains(out, encodeUCS2("营业额")) {
+		t.Error("missing encoded column title")
+	}
+}
@@ -0,0 +1,215 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	pdfPageWidth  = 595.0 // A4
+	pdfPageHeight = 842.0
+	pdfMargin     = 40.0
+	pdfFontSize   = 10.0
+	pdfTitleSize  = 16.0
+	pdfLineHeight = 16.0
+)
+
+// PDF 简易表格 PDF：A4 纵向、逐页写出，使用阅读器内置的 STSong-Light 中文字体，不嵌入字体文件
+type PDF struct{}
+
+func (PDF) Format() string { return FormatPDF }
+
+func (PDF) ContentType() string { return "application/pdf" }
+
+func (PDF) Render(w io.Writer, r *Report) error {
+	buf := bufio.NewWriter(w)
+	p := &pdfWriter{out: &countingWriter{w: buf}, columns: r.Columns}
+	p.begin()
+	p.text(pdfMargin, pdfTitleSize, r.Title)
+	p.advance(pdfTitleSize + 8)
+	if r.Period != "" {
+		p.text(pdfMargin, pdfFontSize, r.Period)
+		p.advance(pdfLineHeight)
+	}
+	for _, field := range r.Summary {
+		p.text(pdfMargin, pdfFontSize, field.Label+"："+FormatValue(field.Kind, field.Value))
+		p.advance(pdfLineHeight)
+	}
+	p.advance(pdfLineHeight / 2)
+	p.header()
+	if r.Rows != nil {
+		err := r.Rows(func(row []any) error {
+			p.row(row)
+			return p.out.err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	p.finish()
+	if p.out.err != nil {
+		return p.out.err
+	}
+	return buf.Flush()
+}
+
+// countingWriter 记录已写出的字节数用于生成交叉引用表，并保留第一次写入错误
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// pdfWriter 对象编号：1 目录、2 页树（最后写出）、3-5 字体，之后每页依次为内容流与页对象
+type pdfWriter struct {
+	out     *countingWriter
+	offsets []int64
+	pages   []int
+	page    bytes.Buffer
+	y       float64
+	columns []Column
+	inTable bool
+}
+
+func (p *pdfWriter) object(num int, body string) {
+	for len(p.offsets) < num {
+		p.offsets = append(p.offsets, 0)
+	}
+	p.offsets[num-1] = p.out.n
+	fmt.Fprintf(p.out, "%d 0 obj\n%s\nendobj\n", num, body)
+}
+
+func (p *pdfWriter) begin() {
+	fmt.Fprint(p.out, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	p.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	p.object(3, "<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>")
+	p.object(4, "<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>")
+	p.object(5, "<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] "+
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+	p.y = pdfPageHeight - pdfMargin - pdfTitleSize
+}
+
+// flushPage 写出当前页的内容流与页对象
+func (p *pdfWriter) flushPage() {
+	content := len(p.offsets) + 1
+	p.object(content, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.page.Len(), p.page.String()))
+	page := content + 1
+	p.object(page, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Contents %d 0 R "+
+		"/Resources << /Font << /F1 3 0 R >> >> >>", pdfPageWidth, pdfPageHeight, content))
+	p.pages = append(p.pages, page)
+	p.page.Reset()
+	p.y = pdfPageHeight - pdfMargin - pdfFontSize
+}
+
+// advance 下移一行，空间不足时换页，表格跨页时重复表头
+func (p *pdfWriter) advance(h float64) {
+	p.y -= h
+	if p.y >= pdfMargin {
+		return
+	}
+	p.flushPage()
+	if p.inTable {
+		p.header()
+	}
+}
+
+func (p *pdfWriter) text(x, size float64, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(&p.page, "BT /F1 %.1f Tf %.2f %.2f Td <%s> Tj ET\n", size, x, p.y, encodeUCS2(s))
+}
+
+func (p *pdfWriter) columnWidth() float64 {
+	if len(p.columns) == 0 {
+		return pdfPageWidth - 2*pdfMargin
+	}
+	return (pdfPageWidth - 2*pdfMargin) / float64(len(p.columns))
+}
+
+func (p *pdfWriter) header() {
+	p.inTable = false
+	width := p.columnWidth()
+	for i, col := range p.columns {
+		p.text(pdfMargin+float64(i)*width, pdfFontSize, fit(col.Title, width-4, pdfFontSize))
+	}
+	lineY := p.y - 4
+	fmt.Fprintf(&p.page, "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, lineY, pdfPageWidth-pdfMargin, lineY)
+	p.inTable = true
+	p.advance(pdfLineHeight)
+}
+
+func (p *pdfWriter) row(values []any) {
+	width := p.columnWidth()
+	for i, col := range p.columns {
+		if i >= len(values) {
+			break
+		}
+		p.text(pdfMargin+float64(i)*width, pdfFontSize, fit(FormatValue(col.Kind, values[i]), width-4, pdfFontSize))
+	}
+	p.advance(pdfLineHeight)
+}
+
+// finish 写出最后一页、页树与交叉引用表
+func (p *pdfWriter) finish() {
+	if p.page.Len() > 0 || len(p.pages) == 0 {
+		p.flushPage()
+	}
+	kids := make([]string, len(p.pages))
+	for i, page := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	p.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+
+	xref := p.out.n
+	fmt.Fprintf(p.out, "xref\n0 %d\n0000000000 65535 f \n", len(p.offsets)+1)
+	for _, offset := range p.offsets {
+		fmt.Fprintf(p.out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(p.out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(p.offsets)+1, xref)
+}
+
+// encodeUCS2 按 UniGB-UCS2-H 编码为十六进制字符串，基本平面以外的字符替换为问号
+func encodeUCS2(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r > 0xFFFF {
+			r = '?'
+		}
+		for _, u := range utf16.Encode([]rune{r}) {
+			fmt.Fprintf(&b, "%04X", u)
+		}
+	}
+	return b.String()
+}
+
+// fit 按半角 0.5、全角 1 个字号估算宽度，超出时截断
+func fit(s string, width, size float64) string {
+	var w float64
+	for i, r := range s {
+		cw := size
+		if r < 0x80 {
+			cw = size / 2
+		}
+		if w+cw > width {
+			return s[:i]
+		}
+		w += cw
+	}
+	return s
+}
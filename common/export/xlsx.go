@@ -0,0 +1,183 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Template xlsx 模板布局：统计区间、汇总指标与明细分别写入模板的指定位置
+type Template struct {
+	Path      string
+	Sheet     string
+	TitleCell string            // 统计区间写入的单元格
+	Cells     map[string]string // 汇总指标 key -> 单元格
+	DetailRow int               // 明细起始行
+	DetailCol string            // 明细起始列
+	Columns   []string          // 明细各列对应的 Column.Key，报表中不存在的列留空
+}
+
+// XLSX Excel 渲染器，未指定模板时以流式写入生成通用表格
+type XLSX struct {
+	Template *Template
+}
+
+func (x *XLSX) Format() string { return FormatXLSX }
+
+func (x *XLSX) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+func (x *XLSX) Render(w io.Writer, r *Report) error {
+	if x.Template != nil {
+		return x.renderTemplate(w, r)
+	}
+	return x.renderStream(w, r)
+}
+
+// numFmt 按列类型返回 Excel 内置数字格式
+func numFmt(kind string) int {
+	switch kind {
+	case KindMoney:
+		return 2 // 0.00
+	case KindPercent:
+		return 10 // 0.00%
+	case KindInt:
+		return 1 // 0
+	}
+	return 0
+}
+
+func (x *XLSX) renderStream(w io.Writer, r *Report) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	const sheet = "Sheet1"
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	styles := make(map[string]int)
+	styleOf := func(kind string) (int, error) {
+		if id, ok := styles[kind]; ok {
+			return id, nil
+		}
+		id, err := f.NewStyle(&excelize.Style{NumFmt: numFmt(kind)})
+		styles[kind] = id
+		return id, err
+	}
+
+	row := 1
+	setRow := func(values []any) error {
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		row++
+		return sw.SetRow(cell, values)
+	}
+	if err = setRow([]any{excelize.Cell{StyleID: bold, Value: r.Title}}); err != nil {
+		return err
+	}
+	if err = setRow([]any{r.Period}); err != nil {
+		return err
+	}
+	for _, field := range r.Summary {
+		style, err := styleOf(field.Kind)
+		if err != nil {
+			return err
+		}
+		if err = setRow([]any{field.Label, excelize.Cell{StyleID: style, Value: field.Value}}); err != nil {
+			return err
+		}
+	}
+	row++
+
+	header := make([]any, len(r.Columns))
+	colStyles := make([]int, len(r.Columns))
+	for i, col := range r.Columns {
+		header[i] = excelize.Cell{StyleID: bold, Value: col.Title}
+		if colStyles[i], err = styleOf(col.Kind); err != nil {
+			return err
+		}
+	}
+	if err = setRow(header); err != nil {
+		return err
+	}
+	if r.Rows != nil {
+		err = r.Rows(func(values []any) error {
+			cells := make([]any, len(values))
+			for i, v := range values {
+				if i < len(colStyles) {
+					cells[i] = excelize.Cell{StyleID: colStyles[i], Value: v}
+				} else {
+					cells[i] = v
+				}
+			}
+			return setRow(cells)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err = sw.Flush(); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+func (x *XLSX) renderTemplate(w io.Writer, r *Report) error {
+	tpl := x.Template
+	f, err := excelize.OpenFile(tpl.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open export template: %w", err)
+	}
+	defer f.Close()
+
+	if tpl.TitleCell != "" {
+		if err = f.SetCellValue(tpl.Sheet, tpl.TitleCell, r.Period); err != nil {
+			return err
+		}
+		center, err := f.NewStyle(&excelize.Style{
+			Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+		})
+		if err != nil {
+			return err
+		}
+		if err = f.SetCellStyle(tpl.Sheet, tpl.TitleCell, tpl.TitleCell, center); err != nil {
+			return err
+		}
+	}
+	for _, field := range r.Summary {
+		if cell, ok := tpl.Cells[field.Key]; ok {
+			if err = f.SetCellValue(tpl.Sheet, cell, field.Value); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 模板列 -> 报表列下标
+	index := make(map[string]int, len(r.Columns))
+	for i, col := range r.Columns {
+		index[col.Key] = i
+	}
+	row := tpl.DetailRow
+	if r.Rows != nil {
+		err = r.Rows(func(values []any) error {
+			cells := make([]any, len(tpl.Columns))
+			for i, key := range tpl.Columns {
+				if j, ok := index[key]; ok && j < len(values) {
+					cells[i] = values[j]
+				}
+			}
+			cell := fmt.Sprintf("%s%d", tpl.DetailCol, row)
+			row++
+			return f.SetSheetRow(tpl.Sheet, cell, &cells)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return f.Write(w)
+}
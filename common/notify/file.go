@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// File 把消息写入本地目录（Dir/target/时间戳/），开发环境下可替代全部真实渠道
+type File struct {
+	Dir string
+}
+
+func (f *File) Send(_ context.Context, target string, msg *Message) error {
+	dir := filepath.Join(f.Dir, filepath.Base(target), time.Now().Format("20060102_150405.000000000"))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create notify dir: %w", err)
+	}
+	files := map[string][]byte{
+		"subject.txt":  []byte(msg.Subject),
+		"message.md":   []byte(msg.Text),
+		"message.html": []byte(msg.HTML),
+	}
+	for _, a := range msg.Attachments {
+		files[filepath.Base(a.Name)] = a.Data
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Sent 测试替身记录的一次发送
+type Sent struct {
+	Target  string
+	Message *Message
+}
+
+// Fake 测试替身：记录发送内容，Err 非空时发送失败
+type Fake struct {
+	Err error
+
+	mu   sync.Mutex
+	sent []Sent
+}
+
+func (f *Fake) Send(_ context.Context, target string, msg *Message) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, Sent{Target: target, Message: msg})
+	return nil
+}
+
+func (f *Fake) Sent() []Sent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Sent(nil), f.sent...)
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// 推送渠道
+const (
+	ChannelEmail   = "email"   // SMTP 邮件，target 为邮箱地址
+	ChannelWebhook = "webhook" // 通用 HTTP 回调，target 为回调地址
+	ChannelWeCom   = "wecom"   // 企业微信群机器人，target 为机器人 key 或完整 webhook 地址
+	ChannelFile    = "file"    // 写入本地目录，target 为子目录名，用于调试
+)
+
+var (
+	ErrUnknownChannel = errors.New("unknown notify channel")
+	ErrInvalidTarget  = errors.New("invalid notify target")
+)
+
+// Attachment 随消息发送的文件
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Message 一条通知：HTML 用于邮件等富文本渠道，Text 为 markdown 兼容的纯文本摘要
+type Message struct {
+	Subject     string
+	HTML        string
+	Text        string
+	Attachments []Attachment
+}
+
+// Notifier 通知渠道的发送实现
+type Notifier interface {
+	Send(ctx context.Context, target string, msg *Message) error
+}
+
+// Registry 渠道 -> 发送实现
+type Registry map[string]Notifier
+
+func (r Registry) Send(ctx context.Context, channel, target string, msg *Message) error {
+	n, ok := r[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+	return n.Send(ctx, target, msg)
+}
+
+// ValidateTarget 校验渠道与接收方格式，不做连通性检查
+func ValidateTarget(channel, target string) error {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return fmt.Errorf("%w: empty target", ErrInvalidTarget)
+	}
+	switch channel {
+	case ChannelEmail:
+		if _, err := mail.ParseAddress(target); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidTarget, err)
+		}
+	case ChannelWebhook:
+		if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: webhook must be an http(s) url", ErrInvalidTarget)
+		}
+	case ChannelWeCom:
+		if _, err := weComKey(target); err != nil {
+			return err
+		}
+	case ChannelFile:
+		if strings.ContainsAny(target, `/\`) || target == "." || target == ".." {
+			return fmt.Errorf("%w: file target must be a plain directory name", ErrInvalidTarget)
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testMessage = &Message{
+	Subject:     "营业日报 2024-01-01",
+	HTML:        "<p>营业额 100.00</p>",
+	Text:        "**营业额** 100.00",
+	Attachments: []Attachment{{Name: "营业日报.xlsx", ContentType: "application/octet-stream", Data: []byte("xlsx")}},
+}
+
+func TestValidateTarget(t *testing.T) {
+	cases := []struct {
+		channel, target string
+		ok              bool
+	}{
+		{ChannelEmail, "boss@example.com", true},
+		{ChannelEmail, "not-an-email", false},
+		{ChannelWebhook, "https://example.com/hook", true},
+		{ChannelWebhook, "ftp://example.com", false},
+		{ChannelWeCom, "abc-123", true},
+		{ChannelWeCom, "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=abc", true},
+		{ChannelWeCom, "https://qyapi.weixin.qq.com/cgi-bin/webhook/send", false},
+		{ChannelFile, "daily", true},
+		{ChannelFile, "../etc", false},
+		{"sms", "13800000000", false},
+	}
+	for _, c := range cases {
+		if err := ValidateTarget(c.channel, c.target); (err == nil) != c.ok {
+			t.Errorf("%s %q: got %v", c.channel, c.target, err)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	fake := &Fake{}
+	r := Registry{ChannelEmail: fake}
+	if err := r.Send(context.Background(), ChannelEmail, "a@b.c", testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if sent := fake.Sent(); len(sent) != 1 || sent[0].Target != "a@b.c" {
+		t.Errorf("unexpected sent %v", sent)
+	}
+	if err := r.Send(context.Background(), ChannelWebhook, "x", testMessage); !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("got %v, want ErrUnknownChannel", err)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Takeout-Signature") != Sign("s3cret", body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.Unmarshal(body, &got)
+	}))
+	defer srv.Close()
+
+	if err := (&Webhook{Secret: "s3cret"}).Send(context.Background(), srv.URL, testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if got.Subject != testMessage.Subject || len(got.Attachments) != 1 || string(got.Attachments[0].Data) != "xlsx" {
+		t.Errorf("unexpected payload %+v", got)
+	}
+	if err := (&Webhook{Secret: "wrong"}).Send(context.Background(), srv.URL, testMessage); err == nil {
+		t.Error("expected error on non-2xx response")
+	}
+}
+
+func TestWeCom(t *testing.T) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Path+"?"+r.URL.RawQuery)
+		if strings.HasSuffix(r.URL.Path, "/upload_media") {
+			_, header, err := r.FormFile("media")
+			if err != nil || header.Filename != "营业日报.xlsx" {
+				_, _ = w.Write([]byte(`{"errcode":40001,"errmsg":"bad media"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"errcode":0,"media_id":"m1"}`))
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["msgtype"] == "file" && body["file"].(map[string]any)["media_id"] != "m1" {
+			_, _ = w.Write([]byte(`{"errcode":40002,"errmsg":"bad file"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"errcode":0}`))
+	}))
+	defer srv.Close()
+
+	w := &WeCom{BaseURL: srv.URL}
+	if err := w.Send(context.Background(), "https://example.com/send?key=k1", testMessage); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/send?key=k1", "/upload_media?key=k1&type=file", "/send?key=k1"}
+	if strings.Join(calls, " ") != strings.Join(want, " ") {
+		t.Errorf("got calls %v, want %v", calls, want)
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := (&File{Dir: dir}).Send(context.Background(), "daily", testMessage); err != nil {
+		t.Fatal(err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "daily", "*", "营业日报.xlsx"))
+	if len(matches) != 1 {
+		t.Fatalf("attachment not written: %v", matches)
+	}
+	if data, _ := os.ReadFile(matches[0]); string(data) != "xlsx" {
+		t.Errorf("unexpected attachment %q", data)
+	}
+}
+
+func TestBuildMIME(t *testing.T) {
+	out := string(buildMIME("from@example.com", "to@example.com", testMessage, "b1"))
+	for _, want := range []string{
+		"Subject: =?UTF-8?b?",
+		"Content-Type: multipart/mixed; boundary=\"b1\"",
+		"Content-Type: text/html; charset=UTF-8",
+		"Content-Disposition: attachment;",
+		"--b1--\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Webhook 以 JSON POST 推送到回调地址，附件按 base64 内联；配置 Secret 时在 X-Takeout-Signature 头中附带 HMAC-SHA256 签名
+type Webhook struct {
+	Client *http.Client
+	Secret string
+}
+
+type webhookPayload struct {
+	Subject     string              `json:"subject"`
+	Text        string              `json:"text"`
+	HTML        string              `json:"html"`
+	Attachments []webhookAttachment `json:"attachments"`
+}
+
+type webhookAttachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Data        []byte `json:"data"` // 按 base64 编码
+}
+
+func (w *Webhook) Send(ctx context.Context, target string, msg *Message) error {
+	payload := webhookPayload{Subject: msg.Subject, Text: msg.Text, HTML: msg.HTML, Attachments: []webhookAttachment{}}
+	for _, a := range msg.Attachments {
+		payload.Attachments = append(payload.Attachments, webhookAttachment{Name: a.Name, ContentType: a.ContentType, Data: a.Data})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Secret != "" {
+		req.Header.Set("X-Takeout-Signature", Sign(w.Secret, body))
+	}
+	resp, err := httpClient(w.Client).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("webhook responded %d: %s", resp.StatusCode, snippet)
+	}
+	return nil
+}
+
+// Sign 回调签名，接收方以相同密钥对原始请求体计算后比对
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func httpClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	weComDefaultURL   = "https://qyapi.weixin.qq.com/cgi-bin/webhook"
+	weComMarkdownSize = 4096 // markdown 消息内容上限（字节）
+)
+
+// WeCom 企业微信群机器人：先发 markdown 摘要，再逐个上传附件并以文件消息发送
+type WeCom struct {
+	Client  *http.Client
+	BaseURL string // 默认 https://qyapi.weixin.qq.com/cgi-bin/webhook
+}
+
+type weComResult struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	MediaId string `json:"media_id"`
+}
+
+func (w *WeCom) Send(ctx context.Context, target string, msg *Message) error {
+	key, err := weComKey(target)
+	if err != nil {
+		return err
+	}
+	content := msg.Text
+	if content == "" {
+		content = msg.Subject
+	}
+	err = w.post(ctx, "send", key, "application/json", jsonBody(map[string]any{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"content": truncateUTF8(content, weComMarkdownSize)},
+	}), nil)
+	if err != nil {
+		return err
+	}
+	for _, a := range msg.Attachments {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, err := mw.CreateFormFile("media", a.Name)
+		if err != nil {
+			return err
+		}
+		if _, err = part.Write(a.Data); err != nil {
+			return err
+		}
+		if err = mw.Close(); err != nil {
+			return err
+		}
+		var uploaded weComResult
+		if err = w.post(ctx, "upload_media", key+"&type=file", mw.FormDataContentType(), body.Bytes(), &uploaded); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", a.Name, err)
+		}
+		err = w.post(ctx, "send", key, "application/json", jsonBody(map[string]any{
+			"msgtype": "file",
+			"file":    map[string]string{"media_id": uploaded.MediaId},
+		}), nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *WeCom) post(ctx context.Context, api, query, contentType string, body []byte, out *weComResult) error {
+	base := w.BaseURL
+	if base == "" {
+		base = weComDefaultURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(base, "/")+"/"+api+"?key="+query, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := httpClient(w.Client).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call wecom %s: %w", api, err)
+	}
+	defer resp.Body.Close()
+	var result weComResult
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode wecom %s response (status %d): %w", api, resp.StatusCode, err)
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("wecom %s failed: %d %s", api, result.ErrCode, result.ErrMsg)
+	}
+	if out != nil {
+		*out = result
+	}
+	return nil
+}
+
+// weComKey 从机器人 key 或完整 webhook 地址中取出 key
+func weComKey(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrInvalidTarget, err)
+		}
+		target = u.Query().Get("key")
+	}
+	if target == "" || strings.ContainsAny(target, "&?/# ") {
+		return "", fmt.Errorf("%w: wecom robot key required", ErrInvalidTarget)
+	}
+	return url.QueryEscape(target), nil
+}
+
+func jsonBody(v any) []byte {
+	body, _ := json.Marshal(v)
+	return body
+}
+
+// truncateUTF8 按字节截断且不截断多字节字符
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
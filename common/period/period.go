@@ -0,0 +1,215 @@
+package period
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // 精简镜像中可能没有系统时区库
+
+	"takeout/common/e"
+)
+
+// Granularity 统计粒度
+type Granularity string
+
+const (
+	Hour  Granularity = "hour"
+	Day   Granularity = "day"
+	Week  Granularity = "week"
+	Month Granularity = "month"
+)
+
+// 各粒度允许的最大统计天数，避免一次返回过多时间桶
+var maxDays = map[Granularity]int{
+	Hour:  31,
+	Day:   366,
+	Week:  731,
+	Month: 3660,
+}
+
+// 对比方式
+const (
+	ComparePrevious = "previous" // 上一周期（环比）
+	CompareLastYear = "yoy"      // 去年同期（同比）
+)
+
+// Range 按时区解析的统计区间，Begin 为首日零点，End 为末日最后一刻
+type Range struct {
+	Begin       time.Time
+	End         time.Time
+	Granularity Granularity
+	Location    *time.Location
+}
+
+// invalid 包装参数错误，调用方可用 errors.Is(err, e.Error_REPORT_QUERY_INVALID) 区分
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", e.Error_REPORT_QUERY_INVALID, fmt.Sprintf(format, args...))
+}
+
+// Parse 解析 2006-01-02 格式的起止日期、粒度（默认 day）与 IANA 时区（默认服务器本地时区）
+func Parse(begin, end, granularity, tz string) (Range, error) {
+	loc := time.Local
+	if tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return Range{}, invalid("未知时区 %q", tz)
+		}
+		loc = l
+	}
+	g := Granularity(granularity)
+	if g == "" {
+		g = Day
+	}
+	if _, ok := maxDays[g]; !ok {
+		return Range{}, invalid("统计粒度须为 hour/day/week/month")
+	}
+	if begin == "" || end == "" {
+		return Range{}, invalid("begin 与 end 不能为空")
+	}
+	b, err := time.ParseInLocation(time.DateOnly, begin, loc)
+	if err != nil {
+		return Range{}, invalid("开始日期 %q 格式错误，应为 yyyy-MM-dd", begin)
+	}
+	en, err := time.ParseInLocation(time.DateOnly, end, loc)
+	if err != nil {
+		return Range{}, invalid("结束日期 %q 格式错误，应为 yyyy-MM-dd", end)
+	}
+	if en.Before(b) {
+		return Range{}, invalid("结束日期不能早于开始日期")
+	}
+	r := newRange(b, en, g, loc)
+	if days := r.Days(); days > maxDays[g] {
+		return Range{}, invalid("按 %s 统计时区间不能超过 %d 天", g, maxDays[g])
+	}
+	return r, nil
+}
+
+// ParseCompare 解析对比方式，支持重复参数或逗号分隔
+func ParseCompare(compare []string) ([]string, error) {
+	res := make([]string, 0, len(compare))
+	seen := make(map[string]bool)
+	for _, item := range compare {
+		for _, c := range strings.Split(item, ",") {
+			c = strings.TrimSpace(c)
+			if c == "" || seen[c] {
+				continue
+			}
+			if c != ComparePrevious && c != CompareLastYear {
+				return nil, invalid("对比方式须为 previous/yoy")
+			}
+			seen[c] = true
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+func newRange(beginDay, endDay time.Time, g Granularity, loc *time.Location) Range {
+	return Range{
+		Begin:       time.Date(beginDay.Year(), beginDay.Month(), beginDay.Day(), 0, 0, 0, 0, loc),
+		End:         time.Date(endDay.Year(), endDay.Month(), endDay.Day(), 23, 59, 59, 999999999, loc),
+		Granularity: g,
+		Location:    loc,
+	}
+}
+
+// Days 区间包含的自然日数
+func (r Range) Days() int {
+	days := 0
+	for d := r.Begin; !d.After(r.End); d = d.AddDate(0, 0, 1) {
+		days++
+	}
+	return days
+}
+
+// Truncate 返回 t 在区间时区下所属时间桶的起点，周以周一为起点
+func (r Range) Truncate(t time.Time) time.Time {
+	t = t.In(r.Location)
+	switch r.Granularity {
+	case Hour:
+		// 按时刻截断，夏令时回拨时重复的小时各自成桶
+		return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	case Week:
+		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, r.Location)
+		return d.AddDate(0, 0, -(int(d.Weekday())+6)%7)
+	case Month:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, r.Location)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, r.Location)
+	}
+}
+
+// next 下一个时间桶的起点
+func (r Range) next(t time.Time) time.Time {
+	switch r.Granularity {
+	case Hour:
+		return t.Add(time.Hour)
+	case Week:
+		return t.AddDate(0, 0, 7)
+	case Month:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// Buckets 区间内所有时间桶的起点，首尾的周/月可能只覆盖部分日期
+func (r Range) Buckets() []time.Time {
+	res := make([]time.Time, 0)
+	for t := r.Truncate(r.Begin); !t.After(r.End); t = r.next(t) {
+		res = append(res, t)
+	}
+	return res
+}
+
+// Label 时间桶的展示文本
+func (r Range) Label(t time.Time) string {
+	t = t.In(r.Location)
+	switch r.Granularity {
+	case Hour:
+		return t.Format("2006-01-02 15:00")
+	case Month:
+		return t.Format("2006-01")
+	default:
+		return t.Format(time.DateOnly)
+	}
+}
+
+// Previous 紧邻的上一周期：整月区间按月平移，按周统计时保持星期对齐，其余按天数平移
+func (r Range) Previous() Range {
+	if r.Granularity == Month && r.Begin.Day() == 1 && r.End.AddDate(0, 0, 1).Day() == 1 {
+		months := (r.End.Year()-r.Begin.Year())*12 + int(r.End.Month()-r.Begin.Month()) + 1
+		begin := r.Begin.AddDate(0, -months, 0)
+		return newRange(begin, r.Begin.AddDate(0, 0, -1), r.Granularity, r.Location)
+	}
+	days := r.Days()
+	if r.Granularity == Week {
+		days = (days + 6) / 7 * 7
+	}
+	return newRange(r.Begin.AddDate(0, 0, -days), r.End.AddDate(0, 0, -days), r.Granularity, r.Location)
+}
+
+// LastYear 去年同期
+func (r Range) LastYear() Range {
+	return newRange(r.Begin.AddDate(-1, 0, 0), r.End.AddDate(-1, 0, 0), r.Granularity, r.Location)
+}
+
+// Shift 按对比方式返回对比区间
+func (r Range) Shift(compare string) Range {
+	if compare == CompareLastYear {
+		return r.LastYear()
+	}
+	return r.Previous()
+}
+
+// SameZone 区间内时区偏移是否始终与 loc 一致，一致时可直接复用按 loc 预聚合的每日数据
+func (r Range) SameZone(loc *time.Location) bool {
+	for d := r.Begin; !d.After(r.End); d = d.AddDate(0, 0, 1) {
+		_, a := d.Zone()
+		_, b := d.In(loc).Zone()
+		if a != b {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package period
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"takeout/common/e"
+)
+
+func mustParse(t *testing.T, begin, end, granularity, tz string) Range {
+	r, err := Parse(begin, end, granularity, tz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func labels(r Range) []string {
+	res := make([]string, 0)
+	for _, b := range r.Buckets() {
+		res = append(res, r.Label(b))
+	}
+	return res
+}
+
+func TestParseInvalid(t *testing.T) {
+	cases := []struct{ begin, end, granularity, tz string }{
+		{"2024-01-02", "2024-01-01", "", ""},     // 结束早于开始
+		{"2024/01/01", "2024-01-02", "", ""},     // 日期格式
+		{"2024-01-01", "", "", ""},               // 缺少结束日期
+		{"2024-01-01", "2024-01-02", "year", ""}, // 粒度
+		{"2024-01-01", "2024-01-02", "", "Mars/Base"},
+		{"2024-01-01", "2024-03-01", "hour", ""}, // 区间过长
+	}
+	for _, c := range cases {
+		if _, err := Parse(c.begin, c.end, c.granularity, c.tz); !errors.Is(err, e.Error_REPORT_QUERY_INVALID) {
+			t.Errorf("Parse(%v): got %v, want validation error", c, err)
+		}
+	}
+	if _, err := ParseCompare([]string{"previous", "mom"}); !errors.Is(err, e.Error_REPORT_QUERY_INVALID) {
+		t.Errorf("ParseCompare: got %v", err)
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	// 2024-01-03 为周三，按周统计首桶从周一开始
+	week := labels(mustParse(t, "2024-01-03", "2024-01-15", "week", "Asia/Shanghai"))
+	if want := []string{"2024-01-01", "2024-01-08", "2024-01-15"}; !slices.Equal(week, want) {
+		t.Errorf("week buckets %v, want %v", week, want)
+	}
+	month := labels(mustParse(t, "2024-01-31", "2024-03-01", "month", "Asia/Shanghai"))
+	if want := []string{"2024-01", "2024-02", "2024-03"}; !slices.Equal(month, want) {
+		t.Errorf("month buckets %v, want %v", month, want)
+	}
+	// 夏令时切换当天只有 23 个小时
+	hours := mustParse(t, "2024-03-10", "2024-03-10", "hour", "America/New_York").Buckets()
+	if len(hours) != 23 {
+		t.Errorf("got %d hour buckets, want 23", len(hours))
+	}
+}
+
+func TestTruncateAcrossZones(t *testing.T) {
+	r := mustParse(t, "2024-01-01", "2024-01-02", "day", "America/New_York")
+	// 北京时间 1 月 2 日 08:00 是纽约时间 1 月 1 日 19:00
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	got := r.Label(r.Truncate(time.Date(2024, 1, 2, 8, 0, 0, 0, shanghai)))
+	if got != "2024-01-01" {
+		t.Errorf("got %s, want 2024-01-01", got)
+	}
+	if r.SameZone(shanghai) {
+		t.Error("New York range should not match Shanghai offsets")
+	}
+}
+
+func TestCompareRanges(t *testing.T) {
+	day := func(r Range) string { return r.Begin.Format(time.DateOnly) + "~" + r.End.Format(time.DateOnly) }
+	cases := []struct {
+		r        Range
+		previous string
+		lastYear string
+	}{
+		{mustParse(t, "2024-03-08", "2024-03-14", "day", ""), "2024-03-01~2024-03-07", "2023-03-08~2023-03-14"},
+		{mustParse(t, "2024-01-01", "2024-03-31", "month", ""), "2023-10-01~2023-12-31", "2023-01-01~2023-03-31"},
+		{mustParse(t, "2024-01-03", "2024-01-12", "week", ""), "2023-12-20~2023-12-29", "2023-01-03~2023-01-12"},
+	}
+	for _, c := range cases {
+		if got := day(c.r.Shift(ComparePrevious)); got != c.previous {
+			t.Errorf("%s previous: got %s, want %s", day(c.r), got, c.previous)
+		}
+		if got := day(c.r.Shift(CompareLastYear)); got != c.lastYear {
+			t.Errorf("%s yoy: got %s, want %s", day(c.r), got, c.lastYear)
+		}
+	}
+}
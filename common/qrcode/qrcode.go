@@ -0,0 +1,462 @@
+// Package qrcode 生成二维码（字节模式、M 级纠错、版本 1-10，最多 213 字节），用于桌台点餐码等短链接
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+var ErrTooLong = errors.New("qrcode: data too long")
+
+const maxVersion = 10
+
+// M 级纠错的每块纠错码字数与块数，下标为版本号
+var (
+	eccPerBlock = [maxVersion + 1]int{0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26}
+	eccBlocks   = [maxVersion + 1]int{0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5}
+	alignments  = [maxVersion + 1][]int{
+		nil, {}, {6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+		{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50},
+	}
+)
+
+// Code 二维码矩阵，true 为深色模块
+type Code struct {
+	Version int
+	Size    int
+	modules [][]bool
+	reserve [][]bool // 功能图形区域，不放数据也不掩码
+}
+
+// Encode 按数据长度选择最小版本编码
+func Encode(data []byte) (*Code, error) {
+	version := 1
+	for ; version <= maxVersion; version++ {
+		if len(data) <= capacity(version) {
+			break
+		}
+	}
+	if version > maxVersion {
+		return nil, ErrTooLong
+	}
+	c := newCode(version)
+	c.drawFunctionPatterns()
+	c.drawCodewords(c.interleave(c.dataCodewords(data)))
+	c.applyBestMask()
+	return c, nil
+}
+
+// Dark 第 y 行第 x 列是否为深色
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// PNG 每个模块 scale 像素，四周留 border 个模块的空白（标准为 4）
+func (c *Code) PNG(scale, border int) ([]byte, error) {
+	scale = max(scale, 1)
+	side := (c.Size + 2*border) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex((x+border)*scale+dx, (y+border)*scale+dy, 1)
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func newCode(version int) *Code {
+	size := version*4 + 17
+	c := &Code{Version: version, Size: size, modules: make([][]bool, size), reserve: make([][]bool, size)}
+	for i := range c.modules {
+		c.modules[i] = make([]bool, size)
+		c.reserve[i] = make([]bool, size)
+	}
+	return c
+}
+
+// rawCodewords 版本可容纳的码字总数（数据加纠错）
+func rawCodewords(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n / 8
+}
+
+func dataCodewordCount(version int) int {
+	return rawCodewords(version) - eccPerBlock[version]*eccBlocks[version]
+}
+
+// capacity 字节模式可容纳的字节数：4 位模式指示符加 8/16 位长度
+func capacity(version int) int {
+	header := 12
+	if version >= 10 {
+		header = 20
+	}
+	return (dataCodewordCount(version)*8 - header) / 8
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.reserve[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+	pos := alignments[c.Version]
+	last := len(pos) - 1
+	for i, y := range pos {
+		for j, x := range pos {
+			// 跳过与定位图形重叠的三个角
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignment(x, y)
+		}
+	}
+	c.drawFormat(0)
+	c.drawVersion()
+}
+
+func (c *Code) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			c.set(x, y, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormat 写入纠错等级（M 为 00）与掩码编号的格式信息，两处各一份
+func (c *Code) drawFormat(mask int) {
+	data := mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		c.set(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.Size-15+i, bit(i))
+	}
+	c.set(8, c.Size-8, true)
+}
+
+// drawVersion 版本 7 及以上需要写入版本信息
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	rem := c.Version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+	}
+	bits := c.Version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 != 0
+		a, b := c.Size-11+i%3, i/3
+		c.set(a, b, dark)
+		c.set(b, a, dark)
+	}
+}
+
+// dataCodewords 字节模式编码，补齐终止符与填充字节
+func (c *Code) dataCodewords(data []byte) []byte {
+	var bits bitBuffer
+	bits.append(0x4, 4)
+	if c.Version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	total := dataCodewordCount(c.Version) * 8
+	bits.append(0, min(4, total-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xec; len(bits) < total; pad ^= 0xec ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	out := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			out[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+	return out
+}
+
+// interleave 分块计算纠错码后交织，短块在前
+func (c *Code) interleave(data []byte) []byte {
+	numBlocks, eccLen := eccBlocks[c.Version], eccPerBlock[c.Version]
+	raw := rawCodewords(c.Version)
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+	divisor := rsDivisor(eccLen)
+
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte{}, data[k:k+n]...)
+		k += n
+		ecc := rsRemainder(block, divisor)
+		if i < numShort {
+			block = append(block, 0) // 占位，使各块等长便于交织
+		}
+		blocks[i] = append(block, ecc...)
+	}
+	out := make([]byte, 0, raw)
+	for i := 0; i < len(blocks[0]); i++ {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				out = append(out, block[i])
+			}
+		}
+	}
+	return out
+}
+
+// drawCodewords 从右下角起两列一组蛇形放置数据，跳过功能图形与第 6 列
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !c.reserve[y][x] && i < len(data)*8 {
+					c.modules[y][x] = data[i>>3]>>(7-i&7)&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyBestMask 尝试 8 种掩码，选择罚分最低的一种
+func (c *Code) applyBestMask() {
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask) // 异或两次即还原
+	}
+	c.applyMask(best)
+	c.drawFormat(best)
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.reserve[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			c.modules[y][x] = c.modules[y][x] != invert
+		}
+	}
+}
+
+// penalty 按标准的四条规则计算罚分
+func (c *Code) penalty() int {
+	total := 0
+	line := make([]bool, c.Size)
+	for _, horizontal := range []bool{true, false} {
+		for i := 0; i < c.Size; i++ {
+			for j := 0; j < c.Size; j++ {
+				if horizontal {
+					line[j] = c.modules[i][j]
+				} else {
+					line[j] = c.modules[j][i]
+				}
+			}
+			total += linePenalty(line)
+		}
+	}
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				v := c.modules[y][x]
+				if c.modules[y][x+1] == v && c.modules[y+1][x] == v && c.modules[y+1][x+1] == v {
+					total += 3
+				}
+			}
+		}
+	}
+	n := c.Size * c.Size
+	k := (abs(dark*20-n*10)+n-1)/n - 1
+	return total + k*10
+}
+
+var finderLike = [][]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+func linePenalty(line []bool) int {
+	total, run := 0, 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			total += run - 2
+		}
+		run = 1
+	}
+	for i := 0; i+11 <= len(line); i++ {
+		for _, pattern := range finderLike {
+			match := true
+			for j, v := range pattern {
+				if line[i+j] != v {
+					match = false
+					break
+				}
+			}
+			if match {
+				total += 40
+			}
+		}
+	}
+	return total
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 != 0)
+	}
+}
+
+// rsDivisor GF(256) 上 (x-α^0)...(x-α^(degree-1)) 的系数，省略最高次项
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMul(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11d)
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
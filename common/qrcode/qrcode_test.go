@@ -0,0 +1,196 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// 1-M "HELLO WORLD" 的数据码字与纠错码字
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Fatalf("ecc = %v, want %v", got, want)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	if capacity(1) != 14 || capacity(10) != 213 {
+		t.Fatalf("capacity v1=%d v10=%d", capacity(1), capacity(10))
+	}
+	if _, err := Encode(bytes.Repeat([]byte("a"), 214)); !errors.Is(err, ErrTooLong) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// TestRoundTrip 按标准读取格式信息、去掩码、解交织并校验纠错码，还原出原始数据
+func TestRoundTrip(t *testing.T) {
+	for _, text := range []string{
+		"hi",
+		"https://example.com/dine?shop=1&table=12&code=ab12cd34",
+		strings.Repeat("桌台二维码", 12),
+	} {
+		c, err := Encode([]byte(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := decode(t, c); got != text {
+			t.Fatalf("version %d decoded %q, want %q", c.Version, got, text)
+		}
+	}
+}
+
+func TestPNG(t *testing.T) {
+	c, err := Encode([]byte("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.PNG(4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if side := (c.Size + 8) * 4; img.Bounds().Dx() != side {
+		t.Fatalf("width = %d, want %d", img.Bounds().Dx(), side)
+	}
+	// 左上角定位图形的外框为深色，留白为浅色
+	if r, _, _, _ := img.At(16, 16).RGBA(); r != 0 {
+		t.Fatal("finder pattern should be dark")
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r == 0 {
+		t.Fatal("quiet zone should be light")
+	}
+}
+
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+	// 格式信息：去掉 0x5412 后校验 BCH
+	var bits int
+	for i := 0; i <= 5; i++ {
+		bits |= b2i(c.Dark(8, i)) << i
+	}
+	bits |= b2i(c.Dark(8, 7))<<6 | b2i(c.Dark(8, 8))<<7 | b2i(c.Dark(7, 8))<<8
+	for i := 9; i < 15; i++ {
+		bits |= b2i(c.Dark(14-i, 8)) << i
+	}
+	bits ^= 0x5412
+	if rem := bits; bchRemainder(rem) != 0 {
+		t.Fatalf("invalid format bits %015b", bits)
+	}
+	if level := bits >> 13; level != 0 {
+		t.Fatalf("ecc level bits = %b, want M(00)", level)
+	}
+	mask := bits >> 10 & 7
+
+	// 去掩码后读出全部码字
+	probe := newCode(c.Version)
+	probe.drawFunctionPatterns()
+	copyModules := make([][]bool, c.Size)
+	for y := range copyModules {
+		copyModules[y] = append([]bool{}, c.modules[y]...)
+	}
+	probe.modules = copyModules
+	probe.applyMask(mask)
+	raw := make([]byte, rawCodewords(c.Version))
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !probe.reserve[y][x] && i < len(raw)*8 {
+					if probe.modules[y][x] {
+						raw[i>>3] |= 1 << (7 - i&7)
+					}
+					i++
+				}
+			}
+		}
+	}
+
+	// 解交织并用伴随式校验每一块
+	numBlocks, eccLen := eccBlocks[c.Version], eccPerBlock[c.Version]
+	numShort := numBlocks - len(raw)%numBlocks
+	shortData := len(raw)/numBlocks - eccLen
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for n := 0; n <= shortData; n++ {
+		for b := range blocks {
+			if n == shortData && b < numShort {
+				continue
+			}
+			blocks[b] = append(blocks[b], raw[k])
+			k++
+		}
+	}
+	for n := 0; n < eccLen; n++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], raw[k])
+			k++
+		}
+	}
+	var data []byte
+	for _, block := range blocks {
+		root := byte(1)
+		for s := 0; s < eccLen; s++ {
+			var v byte
+			for _, cw := range block {
+				v = gfMul(v, root) ^ cw
+			}
+			if v != 0 {
+				t.Fatalf("non-zero syndrome in block %v", block)
+			}
+			root = gfMul(root, 2)
+		}
+		data = append(data, block[:len(block)-eccLen]...)
+	}
+
+	// 字节模式：0100 + 长度 + 数据
+	if data[0]>>4 != 0x4 {
+		t.Fatalf("mode = %x", data[0]>>4)
+	}
+	lenBits := 8
+	if c.Version >= 10 {
+		lenBits = 16
+	}
+	read := func(pos, n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			v = v<<1 | int(data[(pos+i)>>3]>>(7-(pos+i)&7)&1)
+		}
+		return v
+	}
+	length := read(4, lenBits)
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = byte(read(4+lenBits+8*i, 8))
+	}
+	return string(out)
+}
+
+func bchRemainder(bits int) int {
+	for i := 14; i >= 10; i-- {
+		if bits>>i&1 != 0 {
+			bits ^= 0x537 << (i - 10)
+		}
+	}
+	return bits
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
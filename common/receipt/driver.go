@@ -0,0 +1,112 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 打印驱动
+const (
+	DriverNetwork = "network" // 网口打印机，address 为 host[:port]，默认 9100 端口
+	DriverFile    = "file"    // 写入本地目录，address 为子目录名，用于调试与测试
+)
+
+const networkDefaultPort = "9100"
+
+var (
+	ErrUnknownDriver  = errors.New("unknown printer driver")
+	ErrInvalidAddress = errors.New("invalid printer address")
+)
+
+// Driver 把渲染好的数据发送到打印机，name 为建议的文件名
+type Driver interface {
+	Print(ctx context.Context, address, name string, data []byte) error
+}
+
+// Drivers 驱动名 -> 驱动实现
+type Drivers map[string]Driver
+
+func (d Drivers) Print(ctx context.Context, driver, address, name string, data []byte) error {
+	drv, ok := d[driver]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownDriver, driver)
+	}
+	return drv.Print(ctx, address, name, data)
+}
+
+// ValidateAddress 校验驱动与地址格式，不做连通性检查
+func ValidateAddress(driver, address string) error {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return fmt.Errorf("%w: empty address", ErrInvalidAddress)
+	}
+	switch driver {
+	case DriverNetwork:
+		host := address
+		if h, _, err := net.SplitHostPort(address); err == nil {
+			host = h
+		}
+		if host == "" || strings.ContainsAny(host, "/ ") {
+			return fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+		}
+	case DriverFile:
+		if strings.ContainsAny(address, `/\`) || address == "." || address == ".." {
+			return fmt.Errorf("%w: file address must be a plain directory name", ErrInvalidAddress)
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownDriver, driver)
+	}
+	return nil
+}
+
+// Network 通过 TCP 原始端口（RAW/JetDirect）发送到网口打印机
+type Network struct {
+	Timeout time.Duration
+}
+
+func (n *Network) Print(ctx context.Context, address, _ string, data []byte) error {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, networkDefaultPort)
+	}
+	if n.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.Timeout)
+		defer cancel()
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to connect printer %s: %w", address, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+	}
+	if _, err = conn.Write(data); err != nil {
+		return fmt.Errorf("failed to write to printer %s: %w", address, err)
+	}
+	return nil
+}
+
+// File 写入 Dir/address/ 目录，文件名带时间戳避免覆盖
+type File struct {
+	Dir string
+}
+
+func (f *File) Print(_ context.Context, address, name string, data []byte) error {
+	dir := filepath.Join(f.Dir, filepath.Base(address))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create print dir: %w", err)
+	}
+	name = time.Now().Format("20060102_150405.000000000") + "_" + filepath.Base(name)
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
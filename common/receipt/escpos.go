@@ -0,0 +1,220 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"strings"
+)
+
+// 字号，倍宽时每行可打印的列数减半
+const (
+	sizeNormal byte = 0x00
+	sizeTall   byte = 0x01 // 倍高
+	sizeBig    byte = 0x11 // 倍宽倍高
+)
+
+// 对齐方式
+const (
+	alignLeft   byte = 0
+	alignCenter byte = 1
+)
+
+// ESCPOS 热敏打印机指令流，兼容常见 58mm/80mm 票据打印机
+type ESCPOS struct{}
+
+func (ESCPOS) Format() string      { return FormatESCPOS }
+func (ESCPOS) ContentType() string { return "application/octet-stream" }
+func (ESCPOS) Ext() string         { return ".bin" }
+
+func (ESCPOS) Render(r *Receipt, p Profile) ([]byte, error) {
+	w, err := newEscWriter(p)
+	if err != nil {
+		return nil, err
+	}
+	switch r.Kind {
+	case KindKitchen:
+		renderKitchen(w, r)
+	case KindCustomer:
+		renderCustomer(w, r)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKind, r.Kind)
+	}
+	// 走纸到切刀位置
+	w.cmd(0x1b, 0x64, 4)
+	if p.Cut {
+		w.cmd(0x1d, 0x56, 0x42, 0x00)
+	}
+	return w.bytes()
+}
+
+func renderKitchen(w *escWriter, r *Receipt) {
+	w.style(alignCenter, true, sizeBig)
+	w.println("后厨单")
+	if r.Reprint {
+		w.style(alignCenter, false, sizeNormal)
+		w.println("（补打）")
+	}
+	if r.Tag != "" {
+		w.style(alignCenter, true, sizeBig)
+		w.wrap(r.Tag)
+	}
+	w.style(alignLeft, true, sizeTall)
+	w.println("单号：" + r.OrderNumber)
+	w.style(alignLeft, false, sizeNormal)
+	w.println("下单时间：" + r.OrderTime.Format("2006-01-02 15:04"))
+	w.rule("-")
+	for _, l := range r.Lines {
+		w.style(alignLeft, true, sizeTall)
+		w.println(Columns(l.Name, fmt.Sprintf("x%d", l.Number), w.cols()))
+		if l.Flavor != "" {
+			w.style(alignLeft, false, sizeTall)
+			w.wrap("  " + l.Flavor)
+		}
+	}
+	w.style(alignLeft, false, sizeNormal)
+	w.rule("-")
+	if r.Remark != "" {
+		w.style(alignLeft, true, sizeTall)
+		w.wrap("备注：" + r.Remark)
+	}
+	w.style(alignLeft, false, sizeNormal)
+	if r.Tableware != "" {
+		w.wrap("餐具：" + r.Tableware)
+	}
+}
+
+func renderCustomer(w *escWriter, r *Receipt) {
+	title := r.Title
+	if title == "" {
+		title = "顾客联"
+	}
+	w.style(alignCenter, true, sizeBig)
+	w.wrap(title)
+	w.style(alignCenter, false, sizeNormal)
+	if r.Reprint {
+		w.println("（补打）")
+	}
+	if r.Tag != "" {
+		w.style(alignCenter, true, sizeTall)
+		w.wrap(r.Tag)
+	}
+	w.style(alignLeft, false, sizeNormal)
+	w.println("单号：" + r.OrderNumber)
+	w.println("下单时间：" + r.OrderTime.Format("2006-01-02 15:04"))
+	w.rule("-")
+	cols := w.cols()
+	w.println(Columns("菜品", itemColumns("数量", "金额"), cols))
+	for _, l := range r.Lines {
+		w.println(Columns(l.Name, itemColumns(fmt.Sprintf("x%d", l.Number), Money(l.Total())), cols))
+		if l.Flavor != "" {
+			w.wrap("  " + l.Flavor)
+		}
+	}
+	w.rule("-")
+	w.println(Columns("菜品合计", Money(r.Subtotal()), cols))
+	if r.PackAmount > 0 {
+		w.println(Columns("打包费", Money(r.PackAmount), cols))
+	}
+	w.style(alignLeft, true, sizeTall)
+	w.println(Columns("实付", Money(r.Amount), cols))
+	w.style(alignLeft, false, sizeNormal)
+	w.rule("-")
+	if r.Consignee != "" || r.Phone != "" {
+		w.wrap(strings.TrimSpace(r.Consignee + " " + r.Phone))
+	}
+	if r.Address != "" {
+		w.wrap(r.Address)
+	}
+	if r.Remark != "" {
+		w.wrap("备注：" + r.Remark)
+	}
+	if r.Tableware != "" {
+		w.wrap("餐具：" + r.Tableware)
+	}
+	if r.Footer != "" {
+		w.rule("-")
+		w.style(alignCenter, false, sizeNormal)
+		w.wrap(r.Footer)
+	}
+}
+
+// itemColumns 数量与金额右对齐的固定宽度列
+// escpos 中数量用半角 x，GBK 下全角符号会占两列
+func itemColumns(number, amount string) string {
+	return PadLeft(number, 4) + " " + PadLeft(amount, 8)
+}
+
+type escWriter struct {
+	buf   bytes.Buffer
+	enc   *encoding.Encoder // 为 nil 时直接输出 UTF-8
+	width int
+	size  byte
+	err   error
+}
+
+func newEscWriter(p Profile) (*escWriter, error) {
+	w := &escWriter{width: p.width()}
+	w.cmd(0x1b, 0x40) // 初始化
+	switch strings.ToLower(p.Encoding) {
+	case "", "gbk", "gb18030":
+		w.enc = encoding.ReplaceUnsupported(simplifiedchinese.GBK.NewEncoder())
+		w.cmd(0x1c, 0x26) // 进入汉字模式
+	case "utf-8", "utf8":
+	default:
+		return nil, fmt.Errorf("%w: encoding %s", ErrUnsupportedFormat, p.Encoding)
+	}
+	return w, nil
+}
+
+func (w *escWriter) cmd(b ...byte) {
+	w.buf.Write(b)
+}
+
+// cols 当前字号下每行可打印的列数
+func (w *escWriter) cols() int {
+	if w.size&0xf0 != 0 {
+		return w.width / 2
+	}
+	return w.width
+}
+
+func (w *escWriter) style(align byte, bold bool, size byte) {
+	w.cmd(0x1b, 0x61, align)
+	if bold {
+		w.cmd(0x1b, 0x45, 1)
+	} else {
+		w.cmd(0x1b, 0x45, 0)
+	}
+	w.cmd(0x1d, 0x21, size)
+	w.size = size
+}
+
+func (w *escWriter) println(s string) {
+	if w.enc == nil {
+		w.buf.WriteString(s)
+	} else if b, err := w.enc.Bytes([]byte(s)); err != nil {
+		w.err = err
+	} else {
+		w.buf.Write(b)
+	}
+	w.buf.WriteByte('\n')
+}
+
+func (w *escWriter) wrap(s string) {
+	for _, line := range Wrap(s, w.cols()) {
+		w.println(line)
+	}
+}
+
+func (w *escWriter) rule(ch string) {
+	w.println(Rule(ch, w.cols()))
+}
+
+func (w *escWriter) bytes() ([]byte, error) {
+	if w.err != nil {
+		return nil, fmt.Errorf("failed to encode receipt: %w", w.err)
+	}
+	return w.buf.Bytes(), nil
+}
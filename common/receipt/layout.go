@@ -0,0 +1,99 @@
+package receipt
+
+import (
+	"golang.org/x/text/width"
+	"strconv"
+	"strings"
+)
+
+// RuneWidth 全角字符占两列，其余占一列
+func RuneWidth(r rune) int {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// Width 字符串在热敏纸上占用的列数
+func Width(s string) int {
+	n := 0
+	for _, r := range s {
+		n += RuneWidth(r)
+	}
+	return n
+}
+
+// Wrap 按列数折行，不拆开全角字符，换行符处强制折行
+func Wrap(s string, cols int) []string {
+	if cols <= 0 {
+		return []string{s}
+	}
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		var b strings.Builder
+		n := 0
+		for _, r := range para {
+			w := RuneWidth(r)
+			if n+w > cols {
+				lines = append(lines, b.String())
+				b.Reset()
+				n = 0
+			}
+			b.WriteRune(r)
+			n += w
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// Pad 右侧补空格到指定列数，超出时不截断
+func Pad(s string, cols int) string {
+	if n := Width(s); n < cols {
+		return s + strings.Repeat(" ", cols-n)
+	}
+	return s
+}
+
+// PadLeft 左侧补空格右对齐
+func PadLeft(s string, cols int) string {
+	if n := Width(s); n < cols {
+		return strings.Repeat(" ", cols-n) + s
+	}
+	return s
+}
+
+// Center 居中
+func Center(s string, cols int) string {
+	if n := Width(s); n < cols {
+		return strings.Repeat(" ", (cols-n)/2) + s
+	}
+	return s
+}
+
+// Columns 左侧文字与右侧文字分列两端，左侧过长时折行，右侧跟在最后一行
+func Columns(left, right string, cols int) string {
+	rw := Width(right)
+	lines := Wrap(left, max(cols-rw-1, 1))
+	last := lines[len(lines)-1]
+	if gap := cols - Width(last) - rw; gap > 0 {
+		lines[len(lines)-1] = last + strings.Repeat(" ", gap) + right
+	} else {
+		lines[len(lines)-1] = last + " " + right
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Rule 分隔线
+func Rule(ch string, cols int) string {
+	if ch == "" || Width(ch) > cols {
+		return ""
+	}
+	return strings.Repeat(ch, cols/Width(ch))
+}
+
+// Money 金额保留两位小数
+func Money(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
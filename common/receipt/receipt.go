@@ -0,0 +1,142 @@
+package receipt
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 小票类型
+const (
+	KindKitchen  = "kitchen"  // 后厨单：大字菜品与口味，不含金额
+	KindCustomer = "customer" // 顾客联：含金额与配送信息
+)
+
+// 输出格式
+const (
+	FormatESCPOS = "escpos" // 热敏打印机指令
+	FormatText   = "text"   // 纯文本
+	FormatHTML   = "html"   // 浏览器打印
+)
+
+// 纸宽对应的每行半角字符数
+const (
+	Width58 = 32
+	Width80 = 48
+)
+
+var (
+	ErrUnknownKind       = errors.New("unknown receipt kind")
+	ErrUnsupportedFormat = errors.New("unsupported receipt format")
+)
+
+// Line 一行菜品，Price 为单价
+type Line struct {
+	Name   string
+	Flavor string
+	Number int
+	Price  float64
+}
+
+func (l Line) Total() float64 {
+	return l.Price * float64(l.Number)
+}
+
+// Receipt 待打印的小票，与订单结构无关，由调用方组装
+type Receipt struct {
+	Kind        string
+	Title       string // 抬头，一般为门店名称
+	Footer      string
+	Reprint     bool   // 补打时在抬头下标注
+	Tag         string // 自取与堂食订单在抬头下醒目标注，如 堂食 A区3号桌
+	OrderNumber string
+	OrderTime   time.Time
+	Lines       []Line
+	Remark      string
+	Tableware   string
+	PackAmount  float64
+	Amount      float64 // 实付金额
+	Consignee   string
+	Phone       string
+	Address     string
+}
+
+func (r *Receipt) Kitchen() bool {
+	return r.Kind == KindKitchen
+}
+
+// Subtotal 菜品合计，不含打包费
+func (r *Receipt) Subtotal() float64 {
+	var total float64
+	for _, l := range r.Lines {
+		total += l.Total()
+	}
+	return total
+}
+
+// Profile 打印机排版参数
+type Profile struct {
+	Width    int    // 每行半角字符数，0 时按 58mm 纸
+	Encoding string // escpos 的文字编码：gbk（默认）或 utf-8
+	Cut      bool   // escpos 打印完成后切纸
+	Template string // text/html 的自定义模板，为空时使用内置模板
+}
+
+func (p Profile) width() int {
+	if p.Width <= 0 {
+		return Width58
+	}
+	return p.Width
+}
+
+// Renderer 将小票渲染为某种打印格式
+type Renderer interface {
+	Format() string
+	ContentType() string
+	Ext() string
+	Render(r *Receipt, p Profile) ([]byte, error)
+}
+
+// NewRenderer 按格式创建渲染器
+func NewRenderer(format string) (Renderer, error) {
+	switch format {
+	case FormatESCPOS, "":
+		return ESCPOS{}, nil
+	case FormatText:
+		return Text{}, nil
+	case FormatHTML:
+		return HTML{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+}
+
+// ValidateKind 校验小票类型
+func ValidateKind(kind string) error {
+	if kind != KindKitchen && kind != KindCustomer {
+		return fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+	return nil
+}
+
+// Sample 示例小票，用于测试打印与校验模板
+func Sample(kind string) *Receipt {
+	return &Receipt{
+		Kind:        kind,
+		Title:       "测试打印",
+		Footer:      "谢谢惠顾",
+		OrderNumber: "1700000000000",
+		OrderTime:   time.Now(),
+		Lines: []Line{
+			{Name: "宫保鸡丁", Flavor: "微辣,不要葱", Number: 2, Price: 28},
+			{Name: "米饭", Number: 2, Price: 2},
+		},
+		Remark:     "打印机测试",
+		Tableware:  "按餐量提供",
+		PackAmount: 2,
+		Amount:     62,
+		Consignee:  "张先生",
+		Phone:      "13800000000",
+		Address:    "示例路1号",
+	}
+}
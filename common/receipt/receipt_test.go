@@ -0,0 +1,171 @@
+package receipt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWidthAndColumns(t *testing.T) {
+	if got := Width("宫保鸡丁x2"); got != 10 {
+		t.Fatalf("Width = %d, want 10", got)
+	}
+	if got := Wrap("一二三四五", 4); len(got) != 3 || got[0] != "一二" || got[2] != "五" {
+		t.Fatalf("Wrap = %q", got)
+	}
+	line := Columns("米饭", "×2", 16)
+	if Width(line) != 16 || !strings.HasPrefix(line, "米饭") || !strings.HasSuffix(line, "×2") {
+		t.Fatalf("Columns = %q", line)
+	}
+	// 左侧过长时折行，右侧跟在最后一行
+	lines := strings.Split(Columns("特别长的一道菜品名称", "12.00", 16), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[1], "12.00") || Width(lines[0]) > 10 {
+		t.Fatalf("Columns wrap = %q", lines)
+	}
+}
+
+func TestTextRender(t *testing.T) {
+	r := Sample(KindCustomer)
+	out, err := Text{}.Render(r, Profile{Width: Width58})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(out)
+	for _, want := range []string{"单号：1700000000000", "宫保鸡丁", "56.00", "打包费", "62.00", "谢谢惠顾"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("customer receipt missing %q:\n%s", want, text)
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if Width(line) > Width58 {
+			t.Fatalf("line %q exceeds paper width", line)
+		}
+	}
+
+	kitchen := Sample(KindKitchen)
+	kitchen.Tag = "堂食 A区3号桌 取餐码 0012"
+	out, err = Text{}.Render(kitchen, Profile{Width: Width80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text = string(out); strings.Contains(text, "62.00") || !strings.Contains(text, "微辣,不要葱") {
+		t.Fatalf("kitchen ticket should list flavors without prices:\n%s", text)
+	}
+	if !strings.Contains(text, kitchen.Tag) {
+		t.Fatalf("kitchen ticket missing order tag:\n%s", text)
+	}
+
+	out, err = Text{}.Render(r, Profile{Template: "{{.OrderNumber}}|{{money .Amount}}"})
+	if err != nil || string(out) != "1700000000000|62.00" {
+		t.Fatalf("custom template = %q, %v", out, err)
+	}
+}
+
+func TestHTMLRenderEscapes(t *testing.T) {
+	r := Sample(KindCustomer)
+	r.Remark = "<script>"
+	out, err := HTML{}.Render(r, Profile{Width: Width80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out, []byte("<script>")) || !bytes.Contains(out, []byte("width:48ch")) {
+		t.Fatalf("unexpected html:\n%s", out)
+	}
+}
+
+func TestESCPOSRender(t *testing.T) {
+	out, err := ESCPOS{}.Render(Sample(KindKitchen), Profile{Cut: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(out, []byte{0x1b, 0x40, 0x1c, 0x26}) {
+		t.Fatalf("missing init / chinese mode: % x", out[:4])
+	}
+	if !bytes.HasSuffix(out, []byte{0x1d, 0x56, 0x42, 0x00}) {
+		t.Fatal("missing cut command")
+	}
+	// GBK 编码的“后厨单”
+	if !bytes.Contains(out, []byte{0xba, 0xf3, 0xb3, 0xf8, 0xb5, 0xa5}) {
+		t.Fatal("title is not gbk encoded")
+	}
+
+	out, err = ESCPOS{}.Render(Sample(KindCustomer), Profile{Encoding: "utf-8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte("谢谢惠顾")) || bytes.Contains(out, []byte{0x1d, 0x56}) {
+		t.Fatal("utf-8 receipt without cut expected")
+	}
+	if _, err = (ESCPOS{}).Render(Sample("label"), Profile{}); !errors.Is(err, ErrUnknownKind) {
+		t.Fatalf("err = %v, want ErrUnknownKind", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := ValidateTemplate(FormatText, "{{.Missing}}"); err == nil {
+		t.Fatal("template with unknown field should fail")
+	}
+	if err := ValidateTemplate(FormatESCPOS, "x"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("err = %v", err)
+	}
+	if err := ValidateAddress(DriverNetwork, "192.168.1.20"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateAddress(DriverFile, "../x"); !errors.Is(err, ErrInvalidAddress) {
+		t.Fatalf("err = %v", err)
+	}
+	if err := ValidateAddress("usb", "x"); !errors.Is(err, ErrUnknownDriver) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestDrivers(t *testing.T) {
+	dir := t.TempDir()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	drivers := Drivers{
+		DriverNetwork: &Network{Timeout: time.Second},
+		DriverFile:    &File{Dir: dir},
+	}
+	ctx := context.Background()
+	if err = drivers.Print(ctx, DriverNetwork, ln.Addr().String(), "a.bin", []byte("ticket")); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-received; string(got) != "ticket" {
+		t.Fatalf("printer received %q", got)
+	}
+	if err = drivers.Print(ctx, DriverFile, "front", "a.txt", []byte("receipt")); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "front", "*_a.txt"))
+	if len(files) != 1 {
+		t.Fatalf("files = %v", files)
+	}
+	if data, _ := os.ReadFile(files[0]); string(data) != "receipt" {
+		t.Fatalf("file content %q", data)
+	}
+	if err = drivers.Print(ctx, "usb", "x", "a", nil); !errors.Is(err, ErrUnknownDriver) {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	htmlTemplate "html/template"
+	"strings"
+	"text/template"
+)
+
+// 内置模板的数据为 view，自定义模板可使用同样的字段与函数：
+// pad/center/cols/rule/wrap 按纸宽排版，items 生成数量与金额列，money 格式化金额
+type view struct {
+	*Receipt
+	Width int
+}
+
+func funcs(cols int) map[string]any {
+	return map[string]any{
+		"pad":    func(s string) string { return Pad(s, cols) },
+		"center": func(s string) string { return Center(s, cols) },
+		"cols":   func(left, right string) string { return Columns(left, right, cols) },
+		"rule":   func(ch string) string { return Rule(ch, cols) },
+		"wrap":   func(s string) string { return strings.Join(Wrap(s, cols), "\n") },
+		"items":  itemColumns,
+		"money":  Money,
+	}
+}
+
+const defaultText = `{{if .Kitchen}}{{center "后厨单"}}{{else}}{{center (or .Title "顾客联")}}{{end}}
+{{if .Reprint}}{{center "（补打）"}}
+{{end -}}
+{{if .Tag}}{{center .Tag}}
+{{end -}}
+单号：{{.OrderNumber}}
+下单时间：{{.OrderTime.Format "2006-01-02 15:04"}}
+{{rule "-"}}
+{{if .Kitchen -}}
+{{range .Lines}}{{cols .Name (printf "×%d" .Number)}}
+{{if .Flavor}}{{wrap (print "  " .Flavor)}}
+{{end}}{{end -}}
+{{rule "-"}}
+{{else -}}
+{{cols "菜品" (items "数量" "金额")}}
+{{range .Lines}}{{cols .Name (items (printf "×%d" .Number) (money .Total))}}
+{{if .Flavor}}{{wrap (print "  " .Flavor)}}
+{{end}}{{end -}}
+{{rule "-"}}
+{{cols "菜品合计" (money .Subtotal)}}
+{{if gt .PackAmount 0.0}}{{cols "打包费" (money .PackAmount)}}
+{{end -}}
+{{cols "实付" (money .Amount)}}
+{{rule "-"}}
+{{if or .Consignee .Phone}}{{wrap (print .Consignee " " .Phone)}}
+{{end -}}
+{{if .Address}}{{wrap .Address}}
+{{end -}}
+{{end -}}
+{{if .Remark}}{{wrap (print "备注：" .Remark)}}
+{{end -}}
+{{if .Tableware}}{{wrap (print "餐具：" .Tableware)}}
+{{end -}}
+{{if and (not .Kitchen) .Footer}}{{rule "-"}}
+{{center .Footer}}
+{{end -}}
+`
+
+const defaultHTML = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.OrderNumber}}</title>
+<style>
+body{margin:0;font-family:monospace;font-size:12px;color:#000}
+.receipt{width:{{.Width}}ch;padding:4px}
+.center{text-align:center}.big{font-size:20px;font-weight:bold}
+table{width:100%;border-collapse:collapse}td{padding:1px 0;vertical-align:top}
+.num{text-align:right;white-space:nowrap}.flavor{padding-left:1em;color:#555}
+hr{border:none;border-top:1px dashed #000}
+@media print{@page{margin:0}}
+</style></head>
+<body><div class="receipt">
+<div class="center big">{{if .Kitchen}}后厨单{{else}}{{or .Title "顾客联"}}{{end}}</div>
+{{if .Reprint}}<div class="center">（补打）</div>{{end}}
+{{if .Tag}}<div class="center big">{{.Tag}}</div>{{end}}
+<div{{if .Kitchen}} class="big"{{end}}>单号：{{.OrderNumber}}</div>
+<div>下单时间：{{.OrderTime.Format "2006-01-02 15:04"}}</div>
+<hr>
+<table>
+{{if not .Kitchen}}<tr><td>菜品</td><td class="num">数量</td><td class="num">金额</td></tr>{{end}}
+{{range .Lines}}<tr{{if $.Kitchen}} class="big"{{end}}><td>{{.Name}}</td><td class="num">×{{.Number}}</td>{{if not $.Kitchen}}<td class="num">{{money .Total}}</td>{{end}}</tr>
+{{if .Flavor}}<tr><td class="flavor" colspan="3">{{.Flavor}}</td></tr>{{end}}
+{{end}}</table>
+<hr>
+{{if not .Kitchen}}<table>
+<tr><td>菜品合计</td><td class="num">{{money .Subtotal}}</td></tr>
+{{if gt .PackAmount 0.0}}<tr><td>打包费</td><td class="num">{{money .PackAmount}}</td></tr>{{end}}
+<tr class="big"><td>实付</td><td class="num">{{money .Amount}}</td></tr>
+</table>
+<hr>
+{{if or .Consignee .Phone}}<div>{{.Consignee}} {{.Phone}}</div>{{end}}
+{{if .Address}}<div>{{.Address}}</div>{{end}}
+{{end}}
+{{if .Remark}}<div{{if .Kitchen}} class="big"{{end}}>备注：{{.Remark}}</div>{{end}}
+{{if .Tableware}}<div>餐具：{{.Tableware}}</div>{{end}}
+{{if and (not .Kitchen) .Footer}}<hr><div class="center">{{.Footer}}</div>{{end}}
+</div></body></html>
+`
+
+// Text 纯文本小票，可用于不支持 ESC/POS 的打印机或预览
+type Text struct{}
+
+func (Text) Format() string      { return FormatText }
+func (Text) ContentType() string { return "text/plain; charset=utf-8" }
+func (Text) Ext() string         { return ".txt" }
+
+func (Text) Render(r *Receipt, p Profile) ([]byte, error) {
+	if err := ValidateKind(r.Kind); err != nil {
+		return nil, err
+	}
+	src := p.Template
+	if src == "" {
+		src = defaultText
+	}
+	tpl, err := template.New("receipt").Funcs(funcs(p.width())).Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse receipt template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, view{Receipt: r, Width: p.width()}); err != nil {
+		return nil, fmt.Errorf("failed to render receipt: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// HTML 网页小票，宽度按纸宽的字符数设置，供浏览器打印
+type HTML struct{}
+
+func (HTML) Format() string      { return FormatHTML }
+func (HTML) ContentType() string { return "text/html; charset=utf-8" }
+func (HTML) Ext() string         { return ".html" }
+
+func (HTML) Render(r *Receipt, p Profile) ([]byte, error) {
+	if err := ValidateKind(r.Kind); err != nil {
+		return nil, err
+	}
+	src := p.Template
+	if src == "" {
+		src = defaultHTML
+	}
+	tpl, err := htmlTemplate.New("receipt").Funcs(funcs(p.width())).Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse receipt template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, view{Receipt: r, Width: p.width()}); err != nil {
+		return nil, fmt.Errorf("failed to render receipt: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ValidateTemplate 校验自定义模板能否解析并渲染示例小票，escpos 不支持自定义模板
+func ValidateTemplate(format, src string) error {
+	if src == "" {
+		return nil
+	}
+	renderer, err := NewRenderer(format)
+	if err != nil {
+		return err
+	}
+	if renderer.Format() == FormatESCPOS {
+		return fmt.Errorf("%w: escpos does not use templates", ErrUnsupportedFormat)
+	}
+	for _, kind := range []string{KindKitchen, KindCustomer} {
+		if _, err = renderer.Render(Sample(kind), Profile{Template: src}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
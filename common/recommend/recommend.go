@@ -0,0 +1,176 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// 推荐对象类型
+const (
+	TypeDish    = "dish"
+	TypeSetmeal = "setmeal"
+)
+
+// Item 推荐对象
+type Item struct {
+	Type string `json:"type"`
+	Id   uint64 `json:"id"`
+}
+
+// Key 推荐对象唯一标识，如 dish:1
+func (i Item) Key() string {
+	return i.Type + ":" + strconv.FormatUint(i.Id, 10)
+}
+
+// ScoredItem 带得分的推荐对象
+type ScoredItem struct {
+	Item
+	Score float64 `json:"score"`
+}
+
+// Purchase 一条订单明细
+type Purchase struct {
+	OrderId int
+	UserId  int
+	Item    Item
+	Number  int
+}
+
+// Model 离线计算结果：物品间的共购关联与用户偏好
+type Model struct {
+	Related     map[string][]ScoredItem // 物品 -> 共购物品
+	Preferences map[int][]ScoredItem    // 用户 -> 偏好物品
+}
+
+// Build 根据订单明细计算共购关联（余弦相似度）与用户偏好（按购买订单数），各取前 topN
+func Build(purchases []Purchase, topN int) Model {
+	orders := make(map[int]map[string]Item)
+	userWeights := make(map[int]map[string]float64)
+	for _, p := range purchases {
+		key := p.Item.Key()
+		items, ok := orders[p.OrderId]
+		if !ok {
+			items = make(map[string]Item)
+			orders[p.OrderId] = items
+		}
+		if _, exists := items[key]; !exists {
+			items[key] = p.Item
+			weights, ok := userWeights[p.UserId]
+			if !ok {
+				weights = make(map[string]float64)
+				userWeights[p.UserId] = weights
+			}
+			weights[key]++
+		}
+	}
+
+	itemCount := make(map[string]float64)
+	pairCount := make(map[string]map[string]float64)
+	catalog := make(map[string]Item)
+	for _, items := range orders {
+		for a, itemA := range items {
+			catalog[a] = itemA
+			itemCount[a]++
+			for b := range items {
+				if a == b {
+					continue
+				}
+				pairs, ok := pairCount[a]
+				if !ok {
+					pairs = make(map[string]float64)
+					pairCount[a] = pairs
+				}
+				pairs[b]++
+			}
+		}
+	}
+
+	model := Model{
+		Related:     make(map[string][]ScoredItem, len(pairCount)),
+		Preferences: make(map[int][]ScoredItem, len(userWeights)),
+	}
+	for a, pairs := range pairCount {
+		related := make([]ScoredItem, 0, len(pairs))
+		for b, c := range pairs {
+			related = append(related, ScoredItem{
+				Item:  catalog[b],
+				Score: c / math.Sqrt(itemCount[a]*itemCount[b]),
+			})
+		}
+		model.Related[a] = Top(related, topN)
+	}
+	for userId, weights := range userWeights {
+		prefs := make([]ScoredItem, 0, len(weights))
+		for key, w := range weights {
+			prefs = append(prefs, ScoredItem{Item: catalog[key], Score: w})
+		}
+		model.Preferences[userId] = Top(prefs, topN)
+	}
+	return model
+}
+
+// Candidate 推荐候选及其来源
+type Candidate struct {
+	ScoredItem
+	Repeat bool // 用户买过/收藏过的物品
+}
+
+// Rank 以用户偏好物品为种子，沿共购关联扩散打分；种子本身按 repeatFactor 折扣后参与排序
+func Rank(seeds []ScoredItem, related func(key string) []ScoredItem, repeatFactor float64) []Candidate {
+	var maxSeed float64
+	for _, s := range seeds {
+		maxSeed = math.Max(maxSeed, s.Score)
+	}
+	if maxSeed == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(seeds))
+	for _, s := range seeds {
+		seen[s.Key()] = true
+	}
+	scores := make(map[string]*Candidate)
+	add := func(item Item, score float64, repeat bool) {
+		c, ok := scores[item.Key()]
+		if !ok {
+			c = &Candidate{ScoredItem: ScoredItem{Item: item}, Repeat: repeat}
+			scores[item.Key()] = c
+		}
+		c.Score += score
+	}
+	for _, s := range seeds {
+		w := s.Score / maxSeed
+		add(s.Item, w*repeatFactor, true)
+		for _, r := range related(s.Key()) {
+			if seen[r.Key()] {
+				continue
+			}
+			add(r.Item, w*r.Score, false)
+		}
+	}
+	res := make([]Candidate, 0, len(scores))
+	for _, c := range scores {
+		res = append(res, *c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		return res[i].Key() < res[j].Key()
+	})
+	return res
+}
+
+// Top 按得分降序取前 n 个
+func Top(items []ScoredItem, n int) []ScoredItem {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		return items[i].Key() < items[j].Key()
+	})
+	if n > 0 && len(items) > n {
+		items = items[:n]
+	}
+	return items
+}
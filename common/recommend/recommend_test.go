@@ -0,0 +1,59 @@
+package recommend
+
+import "testing"
+
+var (
+	rice  = Item{Type: TypeDish, Id: 1}
+	chick = Item{Type: TypeDish, Id: 2}
+	cola  = Item{Type: TypeDish, Id: 3}
+	combo = Item{Type: TypeSetmeal, Id: 4}
+)
+
+func testPurchases() []Purchase {
+	return []Purchase{
+		{OrderId: 1, UserId: 1, Item: rice, Number: 1},
+		{OrderId: 1, UserId: 1, Item: chick, Number: 1},
+		{OrderId: 2, UserId: 2, Item: rice, Number: 2},
+		{OrderId: 2, UserId: 2, Item: chick, Number: 1},
+		{OrderId: 2, UserId: 2, Item: cola, Number: 1},
+		{OrderId: 3, UserId: 2, Item: combo, Number: 1},
+		{OrderId: 3, UserId: 2, Item: cola, Number: 1},
+		{OrderId: 4, UserId: 3, Item: rice, Number: 1},
+	}
+}
+
+func TestBuildRelated(t *testing.T) {
+	m := Build(testPurchases(), 10)
+	related := m.Related[chick.Key()]
+	if len(related) != 2 {
+		t.Fatalf("got %v", related)
+	}
+	// 宫保鸡丁两次都和米饭一起购买，可乐只有一次
+	if related[0].Item != rice {
+		t.Errorf("got %s, want %s", related[0].Key(), rice.Key())
+	}
+	if _, ok := m.Related[Item{Type: TypeDish, Id: 99}.Key()]; ok {
+		t.Error("unexpected related items for unknown item")
+	}
+	prefs := m.Preferences[2]
+	if len(prefs) != 4 || prefs[0].Item != cola || prefs[0].Score != 2 {
+		t.Errorf("unexpected preferences %v", prefs)
+	}
+}
+
+func TestRank(t *testing.T) {
+	m := Build(testPurchases(), 10)
+	// 用户 3 只买过米饭，推荐与米饭共购的宫保鸡丁排在最前，米饭本身作为回购候选
+	got := Rank(m.Preferences[3], func(key string) []ScoredItem { return m.Related[key] }, 0.3)
+	if len(got) == 0 || got[0].Item != chick || got[0].Repeat {
+		t.Fatalf("unexpected ranking %v", got)
+	}
+	for _, c := range got {
+		if c.Item == rice && !c.Repeat {
+			t.Error("seed item should be marked as repeat")
+		}
+	}
+	if res := Rank(nil, func(string) []ScoredItem { return nil }, 0.3); res != nil {
+		t.Errorf("expected no candidates, got %v", res)
+	}
+}
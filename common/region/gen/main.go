@@ -0,0 +1,108 @@
+// gen 从公开的省市区三级联动数据生成内置区划数据集 divisions.json
+//
+// 数据来源为民政部发布的县以上行政区划代码整理成的 pca-code.json，
+// 直辖市的“市辖区”“县”使用直辖市名称，港澳台只收录省级区划
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultSource = "https://raw.githubusercontent.com/modood/Administrative-divisions-of-China/master/dist/pca-code.json"
+
+type node struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Children []node `json:"children"`
+}
+
+// 数据源不含港澳台
+var extra = map[string]string{
+	"710000": "台湾省",
+	"810000": "香港特别行政区",
+	"820000": "澳门特别行政区",
+}
+
+func main() {
+	src := flag.String("src", defaultSource, "pca-code.json 的地址或本地路径")
+	out := flag.String("o", "divisions.json", "输出文件")
+	flag.Parse()
+
+	data, err := read(*src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var provinces []node
+	if err = json.Unmarshal(data, &provinces); err != nil {
+		log.Fatalf("decode %s: %v", *src, err)
+	}
+
+	names := make(map[string]string, 4096)
+	for code, name := range extra {
+		names[code] = name
+	}
+	for _, p := range provinces {
+		names[pad(p.Code)] = p.Name
+		for _, c := range p.Children {
+			name := c.Name
+			if name == "市辖区" || name == "县" {
+				name = p.Name
+			}
+			names[pad(c.Code)] = name
+			for _, d := range c.Children {
+				names[pad(d.Code)] = d.Name
+			}
+		}
+	}
+	for code := range names {
+		if len(code) != 6 {
+			log.Fatalf("unexpected division code %q", code)
+		}
+	}
+
+	// map 的键按字典序输出，与编号顺序一致
+	buf, err := json.MarshalIndent(names, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(*out, append(buf, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %d divisions to %s", len(names), *out)
+}
+
+// pad 补齐为六位编号，省级两位、地级四位
+func pad(code string) string {
+	for len(code) < 6 {
+		code += "0"
+	}
+	return code
+}
+
+func read(src string) ([]byte, error) {
+	if !strings.HasPrefix(src, "http") {
+		return os.ReadFile(src)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s: %s", src, resp.Status)
+	}
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(resp.Body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,115 @@
+package region
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 地理编码服务
+const (
+	GeocoderOffline = "offline"
+	GeocoderAmap    = "amap"
+)
+
+// ErrNoLocation 地理编码没有结果，地址照常保存，只是没有坐标
+var ErrNoLocation = errors.New("no location for address")
+
+// Location 经纬度坐标，GCJ-02
+type Location struct {
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
+}
+
+// Geocoder 将结构化地址转换为坐标
+type Geocoder interface {
+	Geocode(ctx context.Context, city, address string) (Location, error)
+}
+
+// NewGeocoder 按配置创建地理编码服务，未配置时使用离线实现
+func NewGeocoder(name, key string) Geocoder {
+	switch name {
+	case GeocoderAmap:
+		return NewAmapGeocoder(key)
+	default:
+		return Offline{}
+	}
+}
+
+// Offline 离线实现，不请求外部服务，始终没有坐标
+type Offline struct{}
+
+func (Offline) Geocode(context.Context, string, string) (Location, error) {
+	return Location{}, ErrNoLocation
+}
+
+// AmapGeocoder 高德 Web 服务地理编码
+type AmapGeocoder struct {
+	endpoint string
+	key      string
+	client   *http.Client
+}
+
+func NewAmapGeocoder(key string) *AmapGeocoder {
+	return &AmapGeocoder{
+		endpoint: "https://restapi.amap.com/v3/geocode/geo",
+		key:      key,
+		client:   &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+func (g *AmapGeocoder) Geocode(ctx context.Context, city, address string) (Location, error) {
+	query := url.Values{"key": {g.key}, "address": {address}, "city": {city}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return Location{}, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return Location{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Location{}, fmt.Errorf("amap geocode: %s", resp.Status)
+	}
+	var result struct {
+		Status   string `json:"status"`
+		Info     string `json:"info"`
+		Geocodes []struct {
+			Location string `json:"location"`
+		} `json:"geocodes"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Location{}, fmt.Errorf("amap geocode: %w", err)
+	}
+	if result.Status != "1" {
+		return Location{}, fmt.Errorf("amap geocode: %s", result.Info)
+	}
+	if len(result.Geocodes) == 0 {
+		return Location{}, ErrNoLocation
+	}
+	return parseLocation(result.Geocodes[0].Location)
+}
+
+// parseLocation 解析 "经度,纬度"
+func parseLocation(s string) (Location, error) {
+	lng, lat, ok := strings.Cut(s, ",")
+	if !ok {
+		return Location{}, ErrNoLocation
+	}
+	longitude, err := strconv.ParseFloat(lng, 64)
+	if err != nil {
+		return Location{}, ErrNoLocation
+	}
+	latitude, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return Location{}, ErrNoLocation
+	}
+	return Location{Longitude: longitude, Latitude: latitude}, nil
+}
@@ -0,0 +1,180 @@
+package region
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidDivision 区划编号与名称不匹配或不存在
+var ErrInvalidDivision = errors.New("invalid administrative division")
+
+// 内置数据集为 GB/T 2260 六位区划编号 -> 名称，收录全部省、市、县三级区划，
+// 直辖市的市辖区使用直辖市名称。区划调整后运行 go generate 重新生成，也可通过配置文件路径替换，格式相同
+//
+//go:generate go run ./gen -o divisions.json
+//go:embed divisions.json
+var embedded []byte
+
+// Division 一个行政区划
+type Division struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// Set 行政区划数据集，上下级关系由编号前缀推出
+type Set struct {
+	names    map[string]string
+	children map[string][]Division
+}
+
+// Load 读取 编号 -> 名称 的 JSON 数据集
+func Load(r io.Reader) (*Set, error) {
+	var names map[string]string
+	if err := json.NewDecoder(r).Decode(&names); err != nil {
+		return nil, fmt.Errorf("decode divisions: %w", err)
+	}
+	set := &Set{names: make(map[string]string, len(names)), children: make(map[string][]Division)}
+	for code, name := range names {
+		if !validCode(code) || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("%w: %s %s", ErrInvalidDivision, code, name)
+		}
+		set.names[code] = strings.TrimSpace(name)
+	}
+	for code, name := range set.names {
+		parent := Parent(code)
+		set.children[parent] = append(set.children[parent], Division{Code: code, Name: name})
+	}
+	for _, list := range set.children {
+		sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	}
+	return set, nil
+}
+
+var (
+	defaultOnce sync.Once
+	defaultSet  *Set
+)
+
+// Default 内置数据集
+func Default() *Set {
+	defaultOnce.Do(func() {
+		set, err := Load(bytes.NewReader(embedded))
+		if err != nil {
+			panic(err)
+		}
+		defaultSet = set
+	})
+	return defaultSet
+}
+
+// Open 读取数据集文件，路径为空时使用内置数据集
+func Open(path string) (*Set, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Name 区划名称
+func (s *Set) Name(code string) (string, bool) {
+	name, ok := s.names[code]
+	return name, ok
+}
+
+// Children 下级区划，code 为空时返回全部省级区划
+func (s *Set) Children(code string) []Division {
+	return s.children[code]
+}
+
+// Parent 上级区划编号，省级区划返回空
+func Parent(code string) string {
+	switch {
+	case !validCode(code) || strings.HasSuffix(code, "0000"):
+		return ""
+	case strings.HasSuffix(code, "00"):
+		return code[:2] + "0000"
+	default:
+		return code[:4] + "00"
+	}
+}
+
+// Address 地址中的省、市、区三级区划
+type Address struct {
+	ProvinceCode string
+	ProvinceName string
+	CityCode     string
+	CityName     string
+	DistrictCode string
+	DistrictName string
+}
+
+// Normalize 校验三级区划的编号与名称一致并补全规范名称，只传名称时按名称查找编号。
+// 没有下级区划的区划（如不设区的地级市）只校验编号的上下级关系
+func (s *Set) Normalize(a Address) (Address, error) {
+	var err error
+	if a.ProvinceCode, a.ProvinceName, err = s.resolve("", "省份", a.ProvinceCode, a.ProvinceName); err != nil {
+		return a, err
+	}
+	if a.CityCode, a.CityName, err = s.resolve(a.ProvinceCode, "城市", a.CityCode, a.CityName); err != nil {
+		return a, err
+	}
+	if a.DistrictCode, a.DistrictName, err = s.resolve(a.CityCode, "区县", a.DistrictCode, a.DistrictName); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+func (s *Set) resolve(parent, level, code, name string) (string, string, error) {
+	code, name = strings.TrimSpace(code), strings.TrimSpace(name)
+	known := s.children[parent]
+	if code == "" {
+		for _, d := range known {
+			if d.Name == name {
+				return d.Code, d.Name, nil
+			}
+		}
+		return "", "", fmt.Errorf("%w: 未知的%s %s", ErrInvalidDivision, level, name)
+	}
+	if !validCode(code) || Parent(code) != parent {
+		return "", "", fmt.Errorf("%w: %s编号 %s 错误", ErrInvalidDivision, level, code)
+	}
+	if len(known) == 0 {
+		if name == "" {
+			return "", "", fmt.Errorf("%w: 缺少%s名称", ErrInvalidDivision, level)
+		}
+		return code, name, nil
+	}
+	canonical, ok := s.names[code]
+	if !ok {
+		return "", "", fmt.Errorf("%w: 未知的%s编号 %s", ErrInvalidDivision, level, code)
+	}
+	if name != "" && name != canonical {
+		return "", "", fmt.Errorf("%w: %s编号 %s 与名称 %s 不一致", ErrInvalidDivision, level, code, name)
+	}
+	return code, canonical, nil
+}
+
+func validCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
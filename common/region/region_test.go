@@ -0,0 +1,94 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	set := Default()
+	if got := len(set.Children("")); got != 34 {
+		t.Fatalf("provinces = %d", got)
+	}
+	if name, ok := set.Name("110105"); !ok || name != "朝阳区" {
+		t.Fatalf("Name(110105) = %q, %v", name, ok)
+	}
+	for code, want := range map[string]string{"110105": "110100", "110100": "110000", "110000": "", "bad": ""} {
+		if got := Parent(code); got != want {
+			t.Fatalf("Parent(%s) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	set, err := Load(strings.NewReader(`{"110000":"北京市","110100":"北京市","110105":"朝阳区","110108":"海淀区","130000":"河北省"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 编号补全名称，只传名称时查找编号
+	got, err := set.Normalize(Address{ProvinceCode: "110000", CityCode: "110100", DistrictName: " 海淀区 "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Address{"110000", "北京市", "110100", "北京市", "110108", "海淀区"}
+	if got != want {
+		t.Fatalf("Normalize = %+v", got)
+	}
+	// 数据集未收录的下级区划只校验编号关系
+	if got, err = set.Normalize(Address{ProvinceCode: "130000", CityCode: "130200", CityName: "唐山市", DistrictCode: "130202", DistrictName: "路南区"}); err != nil || got.ProvinceName != "河北省" {
+		t.Fatalf("uncovered Normalize = %+v, %v", got, err)
+	}
+
+	// 完整数据集中未收录的编号即使带名称也不接受
+	if _, err = set.Normalize(Address{ProvinceCode: "110000", CityCode: "110100", DistrictCode: "110199", DistrictName: "某区"}); !errors.Is(err, ErrInvalidDivision) {
+		t.Fatalf("unknown district err = %v", err)
+	}
+
+	for _, bad := range []Address{
+		{ProvinceCode: "110000", CityCode: "110100", DistrictCode: "110105", DistrictName: "海淀区"},
+		{ProvinceCode: "110000", CityCode: "110100", DistrictCode: "110199"},
+		{ProvinceCode: "110000", CityCode: "120100", DistrictCode: "120101"},
+		{ProvinceCode: "130000", CityCode: "130200", DistrictCode: "130202"},
+		{ProvinceName: "北京", CityCode: "110100", DistrictCode: "110105"},
+	} {
+		if _, err = set.Normalize(bad); !errors.Is(err, ErrInvalidDivision) {
+			t.Fatalf("Normalize(%+v) err = %v", bad, err)
+		}
+	}
+}
+
+func TestOffline(t *testing.T) {
+	g := NewGeocoder("", "")
+	if _, err := g.Geocode(context.Background(), "北京市", "朝阳区望京街道"); !errors.Is(err, ErrNoLocation) {
+		t.Fatalf("offline err = %v", err)
+	}
+	if loc, err := parseLocation("116.481488,39.990464"); err != nil || loc.Longitude != 116.481488 || loc.Latitude != 39.990464 {
+		t.Fatalf("parseLocation = %+v, %v", loc, err)
+	}
+}
+
+// 内置数据集收录全部地级与县级区划，未收录的编号即使带名称也不接受
+func TestDefaultComplete(t *testing.T) {
+	set := Default()
+	got, err := set.Normalize(Address{ProvinceCode: "130000", CityCode: "130600", DistrictCode: "130602"})
+	if err != nil || got.ProvinceName != "河北省" || got.CityName != "保定市" || got.DistrictName != "竞秀区" {
+		t.Fatalf("Normalize = %+v, %v", got, err)
+	}
+	for _, bad := range []Address{
+		{ProvinceCode: "130000", CityCode: "130600", CityName: "保定市", DistrictCode: "130699", DistrictName: "某区"},
+		{ProvinceCode: "130000", CityCode: "130700", CityName: "保定市"},
+		{ProvinceCode: "130000", CityCode: "139900", CityName: "某市"},
+		{ProvinceCode: "990000", ProvinceName: "某省"},
+	} {
+		if _, err = set.Normalize(bad); !errors.Is(err, ErrInvalidDivision) {
+			t.Fatalf("Normalize(%+v) err = %v", bad, err)
+		}
+	}
+	for _, province := range set.Children("") {
+		if province.Code < "710000" && len(set.Children(province.Code)) == 0 {
+			t.Errorf("%s %s 没有地级区划", province.Code, province.Name)
+		}
+	}
+}
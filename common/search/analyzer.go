@@ -0,0 +1,189 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// 索引词前缀，用于区分不同来源的词项
+const (
+	termHan      = "h:" // 汉字单字/双字
+	termPinyin   = "p:" // 全拼
+	termInitials = "i:" // 拼音首字母
+)
+
+var pinyinArgs = pinyin.NewArgs()
+
+// term 索引词及其权重
+type term struct {
+	text   string
+	weight float64
+}
+
+// normalize 统一转小写并去除首尾空白
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// isHan 是否为汉字
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
+
+// hasHan 文本是否包含汉字
+func hasHan(s string) bool {
+	for _, r := range s {
+		if isHan(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// syllables 将文本拆分为音节：汉字转为拼音，连续字母数字视为一个音节
+func syllables(s string) []string {
+	var (
+		res  []string
+		word strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			res = append(res, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range normalize(s) {
+		switch {
+		case isHan(r):
+			flush()
+			if py := pinyin.LazyPinyin(string(r), pinyinArgs); len(py) > 0 {
+				res = append(res, py[0])
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return res
+}
+
+// hanGrams 提取汉字单字与双字词，非汉字字符作为分隔
+func hanGrams(s string) (unigrams, bigrams []string) {
+	var run []rune
+	flush := func() {
+		for i := range run {
+			unigrams = append(unigrams, string(run[i]))
+			if i+1 < len(run) {
+				bigrams = append(bigrams, string(run[i:i+2]))
+			}
+		}
+		run = run[:0]
+	}
+	for _, r := range normalize(s) {
+		if isHan(r) {
+			run = append(run, r)
+		} else {
+			flush()
+		}
+	}
+	flush()
+	return unigrams, bigrams
+}
+
+// analyzeName 名称分词：汉字单字/双字、从每个音节开始的全拼及首字母后缀，支持中间片段的拼音匹配
+func analyzeName(name string) []term {
+	var terms []term
+	unigrams, bigrams := hanGrams(name)
+	for _, u := range unigrams {
+		terms = append(terms, term{termHan + u, 1})
+	}
+	for _, b := range bigrams {
+		terms = append(terms, term{termHan + b, 2})
+	}
+	syl := syllables(name)
+	for i := range syl {
+		weight := 2.0
+		if i == 0 {
+			weight = 3
+		}
+		var full, initials strings.Builder
+		for _, s := range syl[i:] {
+			full.WriteString(s)
+			initials.WriteByte(s[0])
+		}
+		terms = append(terms, term{termPinyin + full.String(), weight})
+		terms = append(terms, term{termInitials + initials.String(), weight - 1})
+	}
+	return terms
+}
+
+// analyzeDescription 描述分词：只取汉字双字词，权重较低
+func analyzeDescription(desc string) []term {
+	_, bigrams := hanGrams(desc)
+	terms := make([]term, 0, len(bigrams))
+	for _, b := range bigrams {
+		terms = append(terms, term{termHan + b, 0.5})
+	}
+	return terms
+}
+
+// toPinyin 将查询转换为连续全拼
+func toPinyin(s string) string {
+	return strings.Join(syllables(s), "")
+}
+
+// maxEdits 根据查询长度确定允许的拼写错误数
+func maxEdits(n int) int {
+	switch {
+	case n < 3:
+		return 0
+	case n < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance 计算两个字符串的编辑距离（Damerau 相邻交换计为一次）
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// prefixDistance 查询与词项前缀的最小编辑距离，容忍长度相差一位
+func prefixDistance(query, text string) int {
+	best := len(query)
+	for _, n := range []int{len(query) - 1, len(query), len(query) + 1} {
+		if n <= 0 || n > len(text) {
+			continue
+		}
+		if d := editDistance(query, text[:n]); d < best {
+			best = d
+		}
+	}
+	return best
+}
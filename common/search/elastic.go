@@ -0,0 +1,192 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ElasticIndex 外部 Elasticsearch 适配器
+// 拼音与首字母在写入前计算好，服务端无需安装拼音分词插件
+type ElasticIndex struct {
+	endpoint string
+	name     string
+	client   *http.Client
+}
+
+func NewElasticIndex(endpoint, name string) *ElasticIndex {
+	if name == "" {
+		name = "takeout_menu"
+	}
+	return &ElasticIndex{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		name:     name,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// elasticDoc 写入 Elasticsearch 的文档结构
+type elasticDoc struct {
+	Document
+	Pinyin   []string `json:"pinyin"`
+	Initials []string `json:"initials"`
+}
+
+// Rebuild 删除并重建索引，通过 _bulk 批量写入
+func (e *ElasticIndex) Rebuild(ctx context.Context, docs []Document) error {
+	if err := e.do(ctx, http.MethodDelete, "/"+e.name, nil, nil); err != nil && !strings.Contains(err.Error(), "404") {
+		return err
+	}
+	mapping := map[string]any{
+		"mappings": map[string]any{
+			"properties": map[string]any{
+				"name":        map[string]any{"type": "text"},
+				"description": map[string]any{"type": "text"},
+				"pinyin":      map[string]any{"type": "keyword"},
+				"initials":    map[string]any{"type": "keyword"},
+				"sales":       map[string]any{"type": "long"},
+			},
+		},
+	}
+	if err := e.do(ctx, http.MethodPut, "/"+e.name, mapping, nil); err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, doc := range docs {
+		ed := elasticDoc{Document: doc}
+		for _, t := range analyzeName(doc.Name) {
+			switch {
+			case strings.HasPrefix(t.text, termPinyin):
+				ed.Pinyin = append(ed.Pinyin, strings.TrimPrefix(t.text, termPinyin))
+			case strings.HasPrefix(t.text, termInitials):
+				ed.Initials = append(ed.Initials, strings.TrimPrefix(t.text, termInitials))
+			}
+		}
+		if err := enc.Encode(map[string]any{"index": map[string]any{"_index": e.name, "_id": doc.Key()}}); err != nil {
+			return err
+		}
+		if err := enc.Encode(ed); err != nil {
+			return err
+		}
+	}
+	return e.doRaw(ctx, http.MethodPost, "/_bulk?refresh=true", "application/x-ndjson", &body, nil)
+}
+
+// Search 名称/描述模糊匹配与拼音前缀匹配，按销量加权
+func (e *ElasticIndex) Search(ctx context.Context, query string, limit int) ([]Hit, error) {
+	query = normalize(query)
+	if query == "" {
+		return []Hit{}, nil
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	py := toPinyin(query)
+	should := []any{
+		map[string]any{"multi_match": map[string]any{
+			"query":     query,
+			"fields":    []string{"name^3", "description"},
+			"fuzziness": "AUTO",
+		}},
+	}
+	if py != "" {
+		should = append(should,
+			map[string]any{"prefix": map[string]any{"pinyin": map[string]any{"value": py, "boost": 2}}},
+			map[string]any{"prefix": map[string]any{"initials": map[string]any{"value": py}}},
+			map[string]any{"fuzzy": map[string]any{"pinyin": map[string]any{"value": py, "fuzziness": "AUTO"}}},
+		)
+	}
+	req := map[string]any{
+		"size": limit,
+		"query": map[string]any{"function_score": map[string]any{
+			"query": map[string]any{"bool": map[string]any{"should": should, "minimum_should_match": 1}},
+			"field_value_factor": map[string]any{
+				"field":    "sales",
+				"modifier": "log1p",
+				"missing":  0,
+			},
+			"boost_mode": "sum",
+		}},
+	}
+	var resp struct {
+		Hits struct {
+			Hits []struct {
+				Score  float64  `json:"_score"`
+				Source Document `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := e.do(ctx, http.MethodPost, "/"+e.name+"/_search", req, &resp); err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, 0, len(resp.Hits.Hits))
+	for _, h := range resp.Hits.Hits {
+		hits = append(hits, Hit{Document: h.Source, Score: h.Score})
+	}
+	return hits, nil
+}
+
+// Suggest 复用检索结果返回去重后的名称
+func (e *ElasticIndex) Suggest(ctx context.Context, prefix string, limit int) ([]string, error) {
+	hits, err := e.Search(ctx, prefix, limit*2)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	res := make([]string, 0, limit)
+	for _, hit := range hits {
+		if seen[hit.Name] {
+			continue
+		}
+		seen[hit.Name] = true
+		res = append(res, hit.Name)
+		if len(res) >= limit {
+			break
+		}
+	}
+	return res, nil
+}
+
+// do 发送 JSON 请求并解析响应
+func (e *ElasticIndex) do(ctx context.Context, method, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	return e.doRaw(ctx, method, path, "application/json", reader, out)
+}
+
+func (e *ElasticIndex) doRaw(ctx context.Context, method, path, contentType string, body io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, e.endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("elasticsearch request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("elasticsearch %s %s: %d %s", method, path, resp.StatusCode, msg)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,61 @@
+package search
+
+import (
+	"context"
+	"strconv"
+)
+
+// 文档类型
+const (
+	TypeDish    = "dish"
+	TypeSetmeal = "setmeal"
+)
+
+// 索引引擎
+const (
+	EngineMemory  = "memory"
+	EngineElastic = "elasticsearch"
+)
+
+// Document 可被检索的菜品/套餐文档
+type Document struct {
+	Type        string  `json:"type"`
+	RefId       uint64  `json:"refId"`
+	CategoryId  uint64  `json:"categoryId"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Image       string  `json:"image"`
+	Price       float64 `json:"price"`
+	Sales       int64   `json:"sales"` // 销量，来源于已完成订单的 order_detail
+}
+
+// Key 文档唯一标识，如 dish:12
+func (d Document) Key() string {
+	return d.Type + ":" + strconv.FormatUint(d.RefId, 10)
+}
+
+// Hit 检索命中结果
+type Hit struct {
+	Document
+	Score float64 `json:"score"`
+}
+
+// Index 检索索引，内存倒排索引与外部搜索引擎均实现该接口
+type Index interface {
+	// Rebuild 使用全量文档重建索引
+	Rebuild(ctx context.Context, docs []Document) error
+	// Search 关键字检索，按相关度与销量排序
+	Search(ctx context.Context, query string, limit int) ([]Hit, error)
+	// Suggest 根据输入前缀返回搜索建议
+	Suggest(ctx context.Context, prefix string, limit int) ([]string, error)
+}
+
+// NewIndex 根据配置创建索引，未知引擎回退到内存索引
+func NewIndex(engine, endpoint, name string) Index {
+	switch engine {
+	case EngineElastic:
+		return NewElasticIndex(endpoint, name)
+	default:
+		return NewMemoryIndex()
+	}
+}
@@ -0,0 +1,201 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 模糊匹配命中的得分折扣
+const fuzzyFactor = 0.6
+
+// MemoryIndex 进程内倒排索引
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     []Document
+	postings map[string]map[int]float64 // 词项 -> 文档下标 -> 权重
+	terms    []string                   // 排序后的拼音/首字母词表，用于前缀与模糊匹配
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{postings: make(map[string]map[int]float64)}
+}
+
+// Rebuild 全量重建索引，构建完成后整体替换
+func (m *MemoryIndex) Rebuild(ctx context.Context, docs []Document) error {
+	postings := make(map[string]map[int]float64)
+	add := func(doc int, t term) {
+		p, ok := postings[t.text]
+		if !ok {
+			p = make(map[int]float64)
+			postings[t.text] = p
+		}
+		if t.weight > p[doc] {
+			p[doc] = t.weight
+		}
+	}
+	for i, doc := range docs {
+		for _, t := range analyzeName(doc.Name) {
+			add(i, t)
+		}
+		for _, t := range analyzeDescription(doc.Description) {
+			add(i, t)
+		}
+	}
+	terms := make([]string, 0, len(postings))
+	for t := range postings {
+		if !strings.HasPrefix(t, termHan) {
+			terms = append(terms, t)
+		}
+	}
+	sort.Strings(terms)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.docs = append([]Document(nil), docs...)
+	m.postings = postings
+	m.terms = terms
+	return nil
+}
+
+// Search 检索：汉字按双字词覆盖率打分，拼音/首字母按前缀匹配并容忍拼写错误，最终结合销量排序
+func (m *MemoryIndex) Search(ctx context.Context, query string, limit int) ([]Hit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	scores := m.score(normalize(query))
+	hits := make([]Hit, 0, len(scores))
+	for doc, relevance := range scores {
+		d := m.docs[doc]
+		hits = append(hits, Hit{
+			Document: d,
+			Score:    relevance * (1 + math.Log1p(float64(d.Sales))/10),
+		})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Sales != hits[j].Sales {
+			return hits[i].Sales > hits[j].Sales
+		}
+		return hits[i].Key() < hits[j].Key()
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// Suggest 返回命中文档的名称作为搜索建议，已按相关度与销量排序并去重
+func (m *MemoryIndex) Suggest(ctx context.Context, prefix string, limit int) ([]string, error) {
+	hits, err := m.Search(ctx, prefix, 0)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	res := make([]string, 0, limit)
+	for _, hit := range hits {
+		if seen[hit.Name] {
+			continue
+		}
+		seen[hit.Name] = true
+		res = append(res, hit.Name)
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+	}
+	return res, nil
+}
+
+// score 计算每个文档的相关度，取汉字匹配与拼音匹配的较高者
+func (m *MemoryIndex) score(query string) map[int]float64 {
+	scores := make(map[int]float64)
+	if query == "" {
+		return scores
+	}
+	merge := func(part map[int]float64) {
+		for doc, s := range part {
+			if s > scores[doc] {
+				scores[doc] = s
+			}
+		}
+	}
+	merge(m.scoreHan(query))
+	py := toPinyin(query)
+	if py == "" {
+		return scores
+	}
+	pinyinScores := m.scorePinyin(py)
+	if hasHan(query) {
+		// 汉字查询同样转为拼音匹配以容忍同音错别字（如“宫爆鸡丁”），但得分低于汉字直接命中
+		for doc := range pinyinScores {
+			pinyinScores[doc] *= fuzzyFactor
+		}
+	}
+	merge(pinyinScores)
+	return scores
+}
+
+// scoreHan 汉字匹配，至少覆盖一半查询词
+func (m *MemoryIndex) scoreHan(query string) map[int]float64 {
+	unigrams, bigrams := hanGrams(query)
+	tokens := bigrams
+	if len(tokens) == 0 {
+		tokens = unigrams
+	}
+	scores := make(map[int]float64)
+	if len(tokens) == 0 {
+		return scores
+	}
+	matched := make(map[int]int)
+	for _, tok := range tokens {
+		for doc, w := range m.postings[termHan+tok] {
+			scores[doc] += w
+			matched[doc]++
+		}
+	}
+	for doc := range scores {
+		if matched[doc]*2 < len(tokens) {
+			delete(scores, doc)
+			continue
+		}
+		scores[doc] /= float64(len(tokens))
+		// 汉字命中优先于拼音命中
+		scores[doc] += 1
+	}
+	return scores
+}
+
+// scorePinyin 拼音/首字母前缀匹配，未精确命中时按编辑距离模糊匹配
+func (m *MemoryIndex) scorePinyin(py string) map[int]float64 {
+	scores := make(map[int]float64)
+	add := func(t string, factor float64) {
+		for doc, w := range m.postings[t] {
+			if s := w * factor; s > scores[doc] {
+				scores[doc] = s
+			}
+		}
+	}
+	for _, kind := range []string{termPinyin, termInitials} {
+		prefix := kind + py
+		for i := sort.SearchStrings(m.terms, prefix); i < len(m.terms) && strings.HasPrefix(m.terms[i], prefix); i++ {
+			add(m.terms[i], 1)
+		}
+	}
+	edits := maxEdits(len(py))
+	if edits == 0 {
+		return scores
+	}
+	for _, t := range m.terms {
+		if !strings.HasPrefix(t, termPinyin) || strings.HasPrefix(t, termPinyin+py) {
+			continue
+		}
+		if prefixDistance(py, strings.TrimPrefix(t, termPinyin)) <= edits {
+			add(t, fuzzyFactor)
+		}
+	}
+	return scores
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"context"
+	"testing"
+)
+
+var testDocs = []Document{
+	{Type: TypeDish, RefId: 1, Name: "宫保鸡丁", Description: "经典川菜，鸡肉花生", Sales: 10},
+	{Type: TypeDish, RefId: 2, Name: "鱼香肉丝", Description: "酸甜微辣", Sales: 50},
+	{Type: TypeDish, RefId: 3, Name: "可口可乐", Sales: 200},
+	{Type: TypeSetmeal, RefId: 4, Name: "鸡丁套餐", Description: "宫保鸡丁加米饭", Sales: 5},
+	{Type: TypeDish, RefId: 5, Name: "辣子鸡", Sales: 80},
+}
+
+func newTestIndex(t *testing.T) *MemoryIndex {
+	idx := NewMemoryIndex()
+	if err := idx.Rebuild(context.Background(), testDocs); err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+func firstKey(t *testing.T, idx *MemoryIndex, query string) string {
+	hits, err := idx.Search(context.Background(), query, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) == 0 {
+		t.Fatalf("query %q: no hits", query)
+	}
+	return hits[0].Key()
+}
+
+func TestMemoryIndexSearch(t *testing.T) {
+	idx := newTestIndex(t)
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"宫保鸡丁", "dish:1"},    // 汉字精确
+		{"宫爆鸡丁", "dish:1"},    // 同音错别字
+		{"gongbao", "dish:1"}, // 全拼前缀
+		{"gbjd", "dish:1"},    // 首字母
+		{"yuxiang", "dish:2"}, // 全拼
+		{"yuxinag", "dish:2"}, // 拼写错误
+		{"kele", "dish:3"},    // 中间片段拼音
+		{"可乐", "dish:3"},      // 中间片段汉字
+		{"套餐", "setmeal:4"},   // 套餐
+	}
+	for _, c := range cases {
+		if got := firstKey(t, idx, c.query); got != c.want {
+			t.Errorf("query %q: got %s, want %s", c.query, got, c.want)
+		}
+	}
+}
+
+func TestMemoryIndexRankBySales(t *testing.T) {
+	idx := newTestIndex(t)
+	// “鸡”命中宫保鸡丁、鸡丁套餐与辣子鸡，销量最高的辣子鸡排在最前
+	if got := firstKey(t, idx, "鸡"); got != "dish:5" {
+		t.Errorf("got %s, want dish:5", got)
+	}
+}
+
+func TestMemoryIndexSuggest(t *testing.T) {
+	idx := newTestIndex(t)
+	got, err := idx.Suggest(context.Background(), "ji", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 || len(got) > 3 {
+		t.Fatalf("unexpected suggestions %v", got)
+	}
+	hits, _ := idx.Search(context.Background(), "不存在的菜", 10)
+	if len(hits) != 0 {
+		t.Errorf("expected no hits, got %v", hits)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"yuxiang", "yuxiang", 0},
+		{"yuxinag", "yuxiang", 1},
+		{"gongbo", "gongbao", 1},
+		{"kele", "kale", 1},
+	}
+	for _, c := range cases {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("editDistance(%q,%q)=%d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package slot
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidHours = errors.New("invalid business hours")
+
+// Window 一天中的营业时段，以距零点的时长表示，End 可超过 24h 表示营业到次日凌晨
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Hours 每周营业时间，下标为 time.Weekday，空切片表示当天休息
+type Hours [7][]Window
+
+// Slot 一个可预约的送达时段
+type Slot struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Label 如 12:00-12:30
+func (s Slot) Label() string {
+	return s.Start.Format("15:04") + "-" + s.End.Format("15:04")
+}
+
+// ParseWindows 解析逗号分隔的时段，如 "10:00-14:00,17:00-21:30"，空串表示休息
+func ParseWindows(s string) ([]Window, error) {
+	var windows []Window
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidHours, part)
+		}
+		start, err := parseClock(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(to)
+		if err != nil {
+			return nil, err
+		}
+		// 结束早于开始视为跨零点
+		if end <= start {
+			end += 24 * time.Hour
+		}
+		windows = append(windows, Window{Start: start, End: end})
+	}
+	slices.SortFunc(windows, func(a, b Window) int { return int(a.Start - b.Start) })
+	for i := 1; i < len(windows); i++ {
+		if windows[i].Start < windows[i-1].End {
+			return nil, fmt.Errorf("%w: overlapping %q", ErrInvalidHours, s)
+		}
+	}
+	return windows, nil
+}
+
+// ParseHours 解析默认营业时间，days 按星期覆盖（0 为周日），值为空串表示当天休息
+func ParseHours(def string, days map[int]string) (Hours, error) {
+	var hours Hours
+	windows, err := ParseWindows(def)
+	if err != nil {
+		return hours, err
+	}
+	for d := range hours {
+		hours[d] = windows
+	}
+	for d, s := range days {
+		if d < 0 || d > 6 {
+			return hours, fmt.Errorf("%w: weekday %d", ErrInvalidHours, d)
+		}
+		if hours[d], err = ParseWindows(s); err != nil {
+			return hours, err
+		}
+	}
+	return hours, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidHours, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Day 按 step 切分某天的营业时段，不足一个 step 的尾段丢弃
+func (h Hours) Day(day time.Time, step time.Duration) []Slot {
+	if step <= 0 {
+		return nil
+	}
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	var slots []Slot
+	for _, w := range h[midnight.Weekday()] {
+		for offset := w.Start; offset+step <= w.End; offset += step {
+			// 按日历时间计算，夏令时切换当天时段仍对齐整点，超过 24 时自动进位到次日
+			start := time.Date(midnight.Year(), midnight.Month(), midnight.Day(),
+				int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, midnight.Location())
+			slots = append(slots, Slot{Start: start, End: start.Add(step)})
+		}
+	}
+	return slots
+}
+
+// Between 返回开始时间在 [from, to) 内的全部时段，按开始时间排序
+func (h Hours) Between(from, to time.Time, step time.Duration) []Slot {
+	var slots []Slot
+	// 从前一天开始，包含跨零点营业延续到 from 当天的时段
+	day := from.AddDate(0, 0, -1)
+	for !day.After(to) {
+		for _, s := range h.Day(day, step) {
+			if !s.Start.Before(from) && s.Start.Before(to) {
+				slots = append(slots, s)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return slots
+}
+
+// Find 查找开始时间为 start 的时段
+func (h Hours) Find(start time.Time, step time.Duration) (Slot, bool) {
+	for _, s := range h.Between(start, start.Add(time.Second), step) {
+		if s.Start.Equal(start) {
+			return s, true
+		}
+	}
+	return Slot{}, false
+}
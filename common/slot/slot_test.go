@@ -0,0 +1,80 @@
+package slot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseWindows(t *testing.T) {
+	windows, err := ParseWindows("17:00-21:30, 10:00-14:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 2 || windows[0].Start != 10*time.Hour || windows[1].End != 21*time.Hour+30*time.Minute {
+		t.Fatalf("windows = %v", windows)
+	}
+	if w, _ := ParseWindows("22:00-02:00"); len(w) != 1 || w[0].End != 26*time.Hour {
+		t.Fatalf("overnight window = %v", w)
+	}
+	for _, bad := range []string{"10:00", "25:00-26:00", "10:00-14:00,13:00-15:00"} {
+		if _, err := ParseWindows(bad); !errors.Is(err, ErrInvalidHours) {
+			t.Fatalf("ParseWindows(%q) err = %v", bad, err)
+		}
+	}
+}
+
+func TestSlots(t *testing.T) {
+	// 周日休息，周六只营业晚市
+	hours, err := ParseHours("10:00-11:00,17:00-18:10", map[int]string{0: "", 6: "17:00-18:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	friday := time.Date(2026, 10, 16, 8, 0, 0, 0, time.Local)
+	slots := hours.Day(friday, 30*time.Minute)
+	var labels []string
+	for _, s := range slots {
+		labels = append(labels, s.Label())
+	}
+	want := []string{"10:00-10:30", "10:30-11:00", "17:00-17:30", "17:30-18:00"}
+	if len(labels) != len(want) {
+		t.Fatalf("slots = %v, want %v", labels, want)
+	}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Fatalf("slots = %v, want %v", labels, want)
+		}
+	}
+	if n := len(hours.Day(friday.AddDate(0, 0, 2), 30*time.Minute)); n != 0 {
+		t.Fatalf("sunday slots = %d, want 0", n)
+	}
+
+	// 周五 10:15 之后到周六结束：周五剩 3 个，周六 2 个
+	from := time.Date(2026, 10, 16, 10, 15, 0, 0, time.Local)
+	if n := len(hours.Between(from, from.AddDate(0, 0, 1).Add(14*time.Hour), 30*time.Minute)); n != 5 {
+		t.Fatalf("between = %d, want 5", n)
+	}
+	if _, ok := hours.Find(time.Date(2026, 10, 16, 17, 30, 0, 0, time.Local), 30*time.Minute); !ok {
+		t.Fatal("17:30 should be a slot")
+	}
+	if _, ok := hours.Find(time.Date(2026, 10, 16, 17, 45, 0, 0, time.Local), 30*time.Minute); ok {
+		t.Fatal("17:45 is not aligned to a slot")
+	}
+}
+
+func TestOvernightSlots(t *testing.T) {
+	hours, err := ParseHours("22:00-01:00", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 凌晨 0 点之后仍可预约前一天营业延续的时段
+	from := time.Date(2026, 10, 17, 0, 10, 0, 0, time.Local)
+	slots := hours.Between(from, from.Add(2*time.Hour), time.Hour)
+	if len(slots) != 0 {
+		t.Fatalf("slots = %v, want none (00:00 slot already started)", slots)
+	}
+	slot, ok := hours.Find(time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local), time.Hour)
+	if !ok || slot.End.Hour() != 1 {
+		t.Fatalf("midnight slot = %v, %v", slot, ok)
+	}
+}
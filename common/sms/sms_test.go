@@ -0,0 +1,66 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFake(t *testing.T) {
+	dir := t.TempDir()
+	fake := New("", "", "", dir).(*Fake)
+	ctx := context.Background()
+	if err := fake.Send(ctx, "13800138000", "验证码 111111"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.Send(ctx, "13800138000", "验证码 222222"); err != nil {
+		t.Fatal(err)
+	}
+	if msg, ok := fake.Last("13800138000"); !ok || msg.Text != "验证码 222222" {
+		t.Fatalf("Last = %+v, %v", msg, ok)
+	}
+	if _, ok := fake.Last("13900139000"); ok {
+		t.Fatal("unexpected message")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "sms.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Fatalf("sms.log lines = %d", lines)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got map[string]string
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Takeout-Signature")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	if err := New(ProviderWebhook, server.URL, "secret", "").Send(context.Background(), "13800138000", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if got["phone"] != "13800138000" || got["text"] != "hi" || !strings.HasPrefix(signature, "sha256=") {
+		t.Fatalf("payload = %v, signature = %q", got, signature)
+	}
+}
+
+func TestNewCode(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code, err := NewCode(6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != 6 || strings.Trim(code, "0123456789") != "" {
+			t.Fatalf("code = %q", code)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"bytes"
+	"golang.org/x/image/draw"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+
+	// 注册 webp 解码器
+	_ "golang.org/x/image/webp"
+)
+
+const jpegQuality = 85
+
+// Variant 图片缩放规格，按长边等比缩小，原图不超过该尺寸时不生成
+type Variant struct {
+	Name    string
+	MaxSide int
+}
+
+// resize 将图片长边缩小到 maxSide，透明图片输出 png，其余输出 jpeg
+func resize(src image.Image, maxSide int) ([]byte, string, error) {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w >= h {
+		w, h = maxSide, max(1, h*maxSide/w)
+	} else {
+		w, h = max(1, w*maxSide/h), maxSide
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if !dst.Opaque() {
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrivatePrefix 本地存储中只能通过签名地址访问的目录
+const PrivatePrefix = "private/"
+
+// Local 本地磁盘存储，文件由 /static 静态路由 Handler 访问，PrivatePrefix 下的文件必须带签名
+type Local struct {
+	Dir     string
+	BaseURL string
+	Secret  string // 签名地址的密钥，配合 Verify 用于需要鉴权的下载
+}
+
+func NewLocal(dir, baseURL, secret string) *Local {
+	if dir == "" {
+		dir = "./static"
+	}
+	return &Local{Dir: dir, BaseURL: baseURL, Secret: secret}
+}
+
+// Put 先写临时文件再重命名，避免并发读取到写了一半的文件
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("local put: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("local put: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("local put: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("local put: %w", err)
+	}
+	if err = os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("local put: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (l *Local) Exists(_ context.Context, key string) (bool, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return false, err
+	}
+	if _, err = os.Stat(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) URL(key string) string {
+	return joinURL(l.BaseURL, key)
+}
+
+// SignedURL 在公开地址后附加过期时间与 HMAC 签名
+func (l *Local) SignedURL(key string, ttl time.Duration) (string, error) {
+	if _, err := cleanKey(key); err != nil {
+		return "", err
+	}
+	if l.Secret == "" {
+		return "", ErrNoSecret
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {l.sign(key, expires)}}
+	return l.URL(key) + "?" + query.Encode(), nil
+}
+
+// Verify 校验 SignedURL 生成的签名与过期时间
+func (l *Local) Verify(key, expires, signature string) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix || l.Secret == "" {
+		return ErrBadSignature
+	}
+	if subtle.ConstantTimeCompare([]byte(l.sign(key, expires)), []byte(signature)) != 1 {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Handler 本地文件的静态访问，带签名的请求校验签名与过期时间，PrivatePrefix 下的文件没有有效签名时返回 403
+func (l *Local) Handler() http.Handler {
+	files := http.FileServer(http.Dir(l.Dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		query := r.URL.Query()
+		if query.Has("signature") || strings.HasPrefix(key+"/", PrivatePrefix) {
+			if err := l.Verify(key, query.Get("expires"), query.Get("signature")); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+func (l *Local) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, []byte(l.Secret))
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (l *Local) path(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.Dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"io"
+	"strings"
+	"time"
+)
+
+// OSS 阿里云对象存储
+type OSS struct {
+	bucket    *oss.Bucket
+	publicURL string
+}
+
+func NewOSS(endpoint, bucket, accessKey, secretKey, publicURL string) (*OSS, error) {
+	client, err := oss.New(endpoint, accessKey, secretKey)
+	if err != nil {
+		return nil, fmt.Errorf("oss client: %w", err)
+	}
+	b, err := client.Bucket(bucket)
+	if err != nil {
+		return nil, fmt.Errorf("oss bucket: %w", err)
+	}
+	if publicURL == "" {
+		host := strings.TrimPrefix(strings.TrimPrefix(endpoint, "https://"), "http://")
+		publicURL = "https://" + bucket + "." + host
+	}
+	return &OSS{bucket: b, publicURL: publicURL}, nil
+}
+
+func (o *OSS) Put(ctx context.Context, key string, r io.Reader, _ int64, contentType string) error {
+	if _, err := cleanKey(key); err != nil {
+		return err
+	}
+	if err := o.bucket.PutObject(key, r, oss.ContentType(contentType), oss.WithContext(ctx)); err != nil {
+		return fmt.Errorf("oss put: %w", err)
+	}
+	return nil
+}
+
+func (o *OSS) Exists(ctx context.Context, key string) (bool, error) {
+	if _, err := cleanKey(key); err != nil {
+		return false, err
+	}
+	ok, err := o.bucket.IsObjectExist(key, oss.WithContext(ctx))
+	if err != nil {
+		return false, fmt.Errorf("oss head: %w", err)
+	}
+	return ok, nil
+}
+
+func (o *OSS) Delete(ctx context.Context, key string) error {
+	if _, err := cleanKey(key); err != nil {
+		return err
+	}
+	if err := o.bucket.DeleteObject(key, oss.WithContext(ctx)); err != nil {
+		return fmt.Errorf("oss delete: %w", err)
+	}
+	return nil
+}
+
+func (o *OSS) URL(key string) string {
+	return joinURL(o.publicURL, key)
+}
+
+func (o *OSS) SignedURL(key string, ttl time.Duration) (string, error) {
+	if _, err := cleanKey(key); err != nil {
+		return "", err
+	}
+	return o.bucket.SignURL(key, oss.HTTPGet, int64(ttl/time.Second))
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3DefaultRegion = "us-east-1"
+	s3MaxPresign    = 7 * 24 * time.Hour
+	s3UnsignedBody  = "UNSIGNED-PAYLOAD"
+)
+
+// S3 S3 兼容对象存储，请求使用 AWS Signature V4 签名，本地可以用 MinIO 测试
+type S3 struct {
+	scheme    string
+	host      string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	publicURL string
+	Client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3 endpoint 可以带协议，不带时使用 https
+func NewS3(endpoint, region, bucket, accessKey, secretKey string, pathStyle bool, publicURL string) (*S3, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if region == "" {
+		region = s3DefaultRegion
+	}
+	return &S3{
+		scheme:    u.Scheme,
+		host:      u.Host,
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		pathStyle: pathStyle,
+		publicURL: publicURL,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+		now:       time.Now,
+	}, nil
+}
+
+// Put 对请求体计算 SHA-256 后签名，非 TLS 的 MinIO 不接受 UNSIGNED-PAYLOAD 上传
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, _ int64, contentType string) error {
+	if _, err := cleanKey(key); err != nil {
+		return err
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("s3 put: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	sum := sha256.Sum256(body)
+	resp, err := s.do(req, hex.EncodeToString(sum[:]))
+	if err != nil {
+		return fmt.Errorf("s3 put: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return statusError("s3 put", resp)
+	}
+	return nil
+}
+
+func (s *S3) Exists(ctx context.Context, key string) (bool, error) {
+	if _, err := cleanKey(key); err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(key), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := s.do(req, s3UnsignedBody)
+	if err != nil {
+		return false, fmt.Errorf("s3 head: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode/100 == 2:
+		return true, nil
+	default:
+		return false, statusError("s3 head", resp)
+	}
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if _, err := cleanKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, s3UnsignedBody)
+	if err != nil {
+		return fmt.Errorf("s3 delete: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return statusError("s3 delete", resp)
+	}
+	return nil
+}
+
+func (s *S3) URL(key string) string {
+	if s.publicURL != "" {
+		return joinURL(s.publicURL, key)
+	}
+	return s.objectURL(key)
+}
+
+// SignedURL 预签名的 GET 地址，有效期最长 7 天
+func (s *S3) SignedURL(key string, ttl time.Duration) (string, error) {
+	if _, err := cleanKey(key); err != nil {
+		return "", err
+	}
+	if ttl <= 0 || ttl > s3MaxPresign {
+		ttl = s3MaxPresign
+	}
+	now := s.now().UTC()
+	amzDate, scope := now.Format("20060102T150405Z"), s.scope(now)
+	query := url.Values{
+		"X-Amz-Algorithm":     {s3Algorithm},
+		"X-Amz-Credential":    {s.accessKey + "/" + scope},
+		"X-Amz-Date":          {amzDate},
+		"X-Amz-Expires":       {strconv.Itoa(int(ttl / time.Second))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	host, path := s.hostPath(key)
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		path,
+		canonicalQuery(query),
+		"host:" + host + "\n",
+		"host",
+		s3UnsignedBody,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(now, amzDate, canonical))
+	return s.scheme + "://" + host + path + "?" + canonicalQuery(query), nil
+}
+
+// do 以请求头方式签名后发送
+func (s *S3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := map[string]string{"host": req.URL.Host, "x-amz-content-sha256": payloadHash, "x-amz-date": amzDate}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		signed = append(signed, "content-type")
+		values["content-type"] = ct
+	}
+	sort.Strings(signed)
+	var headers strings.Builder
+	for _, name := range signed {
+		headers.WriteString(name + ":" + strings.TrimSpace(values[name]) + "\n")
+	}
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		headers.String(),
+		strings.Join(signed, ";"),
+		payloadHash,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKey, s.scope(now), strings.Join(signed, ";"), s.signature(now, amzDate, canonical)))
+	return s.Client.Do(req)
+}
+
+func (s *S3) signature(now time.Time, amzDate, canonical string) string {
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := strings.Join([]string{s3Algorithm, amzDate, s.scope(now), hex.EncodeToString(hash[:])}, "\n")
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, toSign))
+}
+
+func (s *S3) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+func (s *S3) objectURL(key string) string {
+	host, path := s.hostPath(key)
+	return s.scheme + "://" + host + path
+}
+
+// hostPath 返回请求的 host 与已编码的路径
+func (s *S3) hostPath(key string) (string, string) {
+	if s.pathStyle {
+		return s.host, "/" + s.bucket + "/" + awsEscape(key, false)
+	}
+	return s.bucket + "." + s.host, "/" + awsEscape(key, false)
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, awsEscape(k, true)+"="+awsEscape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscape 按 SigV4 规则编码，只保留 A-Z a-z 0-9 - _ . ~
+func awsEscape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
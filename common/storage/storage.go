@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 存储驱动
+const (
+	DriverLocal = "local" // 本地磁盘，通过 /static 访问
+	DriverS3    = "s3"    // S3 兼容对象存储，如 MinIO
+	DriverOSS   = "oss"   // 阿里云 OSS
+)
+
+var (
+	ErrUnknownDriver = errors.New("unknown storage driver")
+	ErrInvalidKey    = errors.New("invalid storage key")
+	ErrBadSignature  = errors.New("invalid or expired signature")
+	ErrNoSecret      = errors.New("sign secret is not configured")
+)
+
+// Storage 对象存储，key 为以 / 分隔的相对路径
+type Storage interface {
+	// Put 写入对象，同名对象直接覆盖
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+	// URL 公开访问地址
+	URL(key string) string
+	// SignedURL 带签名的限时访问地址
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// Options 各驱动的连接参数，只需填写所选驱动的部分
+type Options struct {
+	Driver string
+
+	// 本地磁盘
+	LocalDir   string
+	LocalURL   string
+	SignSecret string
+
+	// S3 兼容存储与阿里云 OSS
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool   // 使用 endpoint/bucket/key 形式的地址，MinIO 需要开启
+	PublicURL string // 公开访问地址前缀，如 CDN 域名，为空时由 endpoint 与 bucket 拼接
+}
+
+// New 按驱动名创建存储，未配置驱动时使用本地磁盘
+func New(opts Options) (Storage, error) {
+	switch opts.Driver {
+	case DriverLocal, "":
+		return NewLocal(opts.LocalDir, opts.LocalURL, opts.SignSecret), nil
+	case DriverS3:
+		return NewS3(opts.Endpoint, opts.Region, opts.Bucket, opts.AccessKey, opts.SecretKey, opts.PathStyle, opts.PublicURL)
+	case DriverOSS:
+		return NewOSS(opts.Endpoint, opts.Bucket, opts.AccessKey, opts.SecretKey, opts.PublicURL)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, opts.Driver)
+	}
+}
+
+// cleanKey 校验 key，拒绝绝对路径与 .. 等越出存储根目录的写法
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return key, nil
+}
+
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + key
+}
+
+// statusError 将对象存储的非 2xx 响应转为错误，附带响应体开头便于排查
+func statusError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s: %s %s", op, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// References 按文件名索引引用地址，统计对象被引用的次数时不依赖地址前缀，更换 CDN 域名后仍能匹配
+type References map[string][]string
+
+func NewReferences(refs []string) References {
+	index := References{}
+	for _, ref := range refs {
+		p := ref
+		if u, err := url.Parse(ref); err == nil {
+			p = u.Path
+		}
+		if p = strings.TrimRight(p, "/"); p == "" {
+			continue
+		}
+		name := p[strings.LastIndex(p, "/")+1:]
+		index[name] = append(index[name], p)
+	}
+	return index
+}
+
+// Count 以 key 结尾的引用个数
+func (r References) Count(key string) int {
+	var n int
+	for _, p := range r[key[strings.LastIndex(key, "/")+1:]] {
+		if p == key || strings.HasSuffix(p, "/"+key) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxPixels 解码前按图片头校验像素数，防止小文件解压出超大图片
+const maxPixels = 40_000_000
+
+var (
+	ErrTooLarge        = errors.New("file too large")
+	ErrEmptyFile       = errors.New("empty file")
+	ErrUnsupportedType = errors.New("unsupported file type")
+)
+
+// ImageTypes 允许上传的图片类型及保存的扩展名，类型按文件内容判断而不是文件名
+var ImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// Object 上传结果，相同内容的文件使用同一个 key
+type Object struct {
+	Key         string            `json:"key"`
+	URL         string            `json:"url"`
+	ContentType string            `json:"contentType"`
+	Size        int64             `json:"size"`
+	Hash        string            `json:"hash"` // 内容的 SHA-256
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Variants    map[string]string `json:"-"`        // 规格名 -> key
+	VariantURLs map[string]string `json:"variants"` // 规格名 -> 访问地址
+	Existed     bool              `json:"existed"`  // 相同内容已上传过，未重复写入
+}
+
+// Uploader 校验类型与大小，按内容哈希去重写入存储，并生成缩略图等规格
+type Uploader struct {
+	Storage  Storage
+	MaxSize  int64
+	Types    map[string]string
+	Variants []Variant
+}
+
+// Upload 读取并保存文件，key 为 prefix/哈希前两位/哈希+扩展名
+func (u *Uploader) Upload(ctx context.Context, r io.Reader, prefix string) (*Object, error) {
+	data, err := io.ReadAll(io.LimitReader(r, u.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptyFile
+	}
+	if int64(len(data)) > u.MaxSize {
+		return nil, fmt.Errorf("%w: limit %d bytes", ErrTooLarge, u.MaxSize)
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := u.Types[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	base := strings.Trim(prefix, "/") + "/" + hash[:2] + "/" + hash
+	obj := &Object{
+		Key:         base + ext,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Hash:        hash,
+		Variants:    map[string]string{},
+		VariantURLs: map[string]string{},
+	}
+	if obj.Existed, err = u.putOnce(ctx, obj.Key, data, contentType); err != nil {
+		return nil, err
+	}
+	obj.URL = u.Storage.URL(obj.Key)
+
+	if strings.HasPrefix(contentType, "image/") {
+		if err = u.variants(ctx, obj, base, data); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// variants 记录图片尺寸并生成比原图小的各个规格
+func (u *Uploader) variants(ctx context.Context, obj *Object, base string, data []byte) error {
+	conf, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	obj.Width, obj.Height = conf.Width, conf.Height
+	if conf.Width*conf.Height > maxPixels {
+		return fmt.Errorf("%w: %dx%d pixels", ErrTooLarge, conf.Width, conf.Height)
+	}
+	var src image.Image
+	for _, v := range u.Variants {
+		if v.MaxSide <= 0 || max(conf.Width, conf.Height) <= v.MaxSide {
+			continue
+		}
+		if src == nil {
+			if src, _, err = image.Decode(bytes.NewReader(data)); err != nil {
+				return fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+			}
+		}
+		out, contentType, err := resize(src, v.MaxSide)
+		if err != nil {
+			return err
+		}
+		key := base + "_" + v.Name + ImageTypes[contentType]
+		if _, err = u.putOnce(ctx, key, out, contentType); err != nil {
+			return err
+		}
+		obj.Variants[v.Name] = key
+		obj.VariantURLs[v.Name] = u.Storage.URL(key)
+	}
+	return nil
+}
+
+// putOnce 对象已存在时跳过写入，返回是否已存在
+func (u *Uploader) putOnce(ctx context.Context, key string, data []byte, contentType string) (bool, error) {
+	exists, err := u.Storage.Exists(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return true, nil
+	}
+	return false, u.Storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+}
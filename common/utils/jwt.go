@@ -3,9 +3,18 @@ package utils
 import (
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
+	"strconv"
+	"takeout/global"
 	"time"
 )
 
+const (
+	// RevokedUserKey 令牌已作废的用户，后接用户id，保留到已签发的令牌全部过期
+	RevokedUserKey = "user:revoked:"
+	// revokeTTL 与 GenerateToken 签发的令牌有效期一致
+	revokeTTL = 24 * time.Hour
+)
+
 // CustomPayload 自定义载荷继承原有接口并附带自己的字段
 type CustomPayload struct {
 	UserId     uint64
@@ -43,3 +52,14 @@ func ParseToken(token string, secret string) (*CustomPayload, error) {
 	}
 	return nil, errors.New("invalid token")
 }
+
+// RevokeUserTokens 作废用户已签发的令牌，用于账号被合并或注销后
+func RevokeUserTokens(userId uint64) error {
+	return global.RedisClient.Set(RevokedUserKey+strconv.FormatUint(userId, 10), 1, revokeTTL).Err()
+}
+
+// UserTokensRevoked 用户的令牌是否已作废，Redis 不可用时不拦截
+func UserTokensRevoked(userId uint64) bool {
+	n, err := global.RedisClient.Exists(RevokedUserKey + strconv.FormatUint(userId, 10)).Result()
+	return err == nil && n > 0
+}
@@ -1,14 +1,53 @@
 package utils
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"takeout/global"
 )
 
+// ErrWxDecrypt 微信加密数据解密失败，通常是 session_key 已过期
+var ErrWxDecrypt = errors.New("failed to decrypt wechat data")
+
+// WxSession 微信登录凭证校验结果
+type WxSession struct {
+	OpenID     string `json:"openid"`
+	SessionKey string `json:"session_key"`
+	UnionID    string `json:"unionid"`
+	ErrCode    int    `json:"errcode"`
+	ErrMsg     string `json:"errmsg"`
+}
+
+// WxPhone 微信手机号加密数据的明文
+type WxPhone struct {
+	PhoneNumber     string `json:"phoneNumber"`     // 带区号的手机号，境外手机号会有区号
+	PurePhoneNumber string `json:"purePhoneNumber"` // 没有区号的手机号
+	CountryCode     string `json:"countryCode"`
+	Watermark       struct {
+		AppId     string `json:"appid"`
+		Timestamp int64  `json:"timestamp"`
+	} `json:"watermark"`
+}
+
 // GetOpenID 调用微信接口服务，获取微信用户的openid
 func GetOpenID(code string) string {
+	session, err := Code2Session(code)
+	if err != nil {
+		global.Log.Error("GetOpenID 调用微信接口服务失败：", err.Error())
+		return ""
+	}
+	return session.OpenID
+}
+
+// Code2Session 调用微信接口服务，获取微信用户的openid与会话密钥
+func Code2Session(code string) (WxSession, error) {
 	var (
 		resultJSON string
+		session    WxSession
 		err        error
 	)
 	const WxLogin = "https://api.weixin.qq.com/sns/jscode2session"
@@ -21,16 +60,62 @@ func GetOpenID(code string) string {
 	}
 	// HTTP调用微信接口获取用户数据
 	if resultJSON, err = DoGET(WxLogin, values); err != nil {
-		global.Log.Error("GetOpenID 调用微信接口服务失败：", err.Error())
-		return ""
+		return WxSession{}, err
 	}
-	wxLoginResponse := struct {
-		SessionKey string `json:"session_key"`
-		OpenID     string `json:"openid"`
-	}{}
-	if err = json.Unmarshal([]byte(resultJSON), &wxLoginResponse); err != nil {
-		global.Log.Error("wxLoginResponse 不匹配：", err.Error())
-		return ""
+	if err = json.Unmarshal([]byte(resultJSON), &session); err != nil {
+		return WxSession{}, fmt.Errorf("wxLoginResponse 不匹配：%w", err)
+	}
+	if session.ErrCode != 0 {
+		return WxSession{}, fmt.Errorf("jscode2session: %d %s", session.ErrCode, session.ErrMsg)
+	}
+	return session, nil
+}
+
+// DecryptWxPhone 使用会话密钥解密 getPhoneNumber 返回的加密数据，并校验数据属于当前小程序
+func DecryptWxPhone(sessionKey, encryptedData, iv, appId string) (WxPhone, error) {
+	plain, err := DecryptWxData(sessionKey, encryptedData, iv)
+	if err != nil {
+		return WxPhone{}, err
+	}
+	var phone WxPhone
+	if err = json.Unmarshal(plain, &phone); err != nil {
+		return WxPhone{}, fmt.Errorf("%w: %v", ErrWxDecrypt, err)
+	}
+	if phone.Watermark.AppId != appId {
+		return WxPhone{}, fmt.Errorf("%w: appid mismatch", ErrWxDecrypt)
+	}
+	return phone, nil
+}
+
+// DecryptWxData 微信开放数据解密，AES-128-CBC，PKCS#7 填充，参数均为 base64
+func DecryptWxData(sessionKey, encryptedData, iv string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(sessionKey)
+	if err != nil || len(key) != 16 {
+		return nil, fmt.Errorf("%w: invalid session key", ErrWxDecrypt)
+	}
+	ivBytes, err := base64.StdEncoding.DecodeString(iv)
+	if err != nil || len(ivBytes) != aes.BlockSize {
+		return nil, fmt.Errorf("%w: invalid iv", ErrWxDecrypt)
+	}
+	data, err := base64.StdEncoding.DecodeString(encryptedData)
+	if err != nil || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("%w: invalid data", ErrWxDecrypt)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWxDecrypt, err)
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, ivBytes).CryptBlocks(plain, data)
+	// 去除 PKCS#7 填充，填充错误说明密钥不匹配
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize {
+		return nil, fmt.Errorf("%w: invalid padding", ErrWxDecrypt)
+	}
+	for _, b := range plain[len(plain)-pad:] {
+		if int(b) != pad {
+			return nil, fmt.Errorf("%w: invalid padding", ErrWxDecrypt)
+		}
 	}
-	return wxLoginResponse.OpenID
+	return plain[:len(plain)-pad], nil
 }
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+// encryptWxData 按微信的方式加密，用于构造测试数据
+func encryptWxData(key, iv, plain []byte) string {
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	plain = append(plain, bytes.Repeat([]byte{byte(pad)}, pad)...)
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, plain)
+	return base64.StdEncoding.EncodeToString(out)
+}
+
+func TestDecryptWxPhone(t *testing.T) {
+	key, iv := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+	data := encryptWxData(key, iv, []byte(`{"phoneNumber":"13800138000","purePhoneNumber":"13800138000","countryCode":"86","watermark":{"appid":"wx123","timestamp":1700000000}}`))
+	sessionKey, ivText := base64.StdEncoding.EncodeToString(key), base64.StdEncoding.EncodeToString(iv)
+
+	phone, err := DecryptWxPhone(sessionKey, data, ivText, "wx123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if phone.PurePhoneNumber != "13800138000" || phone.CountryCode != "86" {
+		t.Fatalf("phone = %+v", phone)
+	}
+	if _, err = DecryptWxPhone(sessionKey, data, ivText, "wx456"); !errors.Is(err, ErrWxDecrypt) {
+		t.Fatalf("other appid err = %v", err)
+	}
+	otherKey := base64.StdEncoding.EncodeToString([]byte("ffffffffffffffff"))
+	if _, err = DecryptWxPhone(otherKey, data, ivText, "wx123"); !errors.Is(err, ErrWxDecrypt) {
+		t.Fatalf("wrong key err = %v", err)
+	}
+	if _, err = DecryptWxData(sessionKey, "not base64", ivText); !errors.Is(err, ErrWxDecrypt) {
+		t.Fatalf("bad data err = %v", err)
+	}
+}
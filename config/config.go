@@ -18,6 +18,18 @@ type AllConfig struct {
 	AliOss     AliOss
 	Path       Path
 	Wechat     Wechat
+	Search     Search
+	Export     Export
+	Notify     Notify
+	Print      Print
+	Dine       Dine
+	Schedule   Schedule
+	Group      Group
+	Cart       Cart
+	AfterSale  AfterSale
+	Address    Address
+	Sms        Sms
+	Storage    Storage
 }
 
 type Path struct {
@@ -74,8 +86,147 @@ type Redis struct {
 }
 
 type Wechat struct {
-	AppId     string `mapstructure:"appid"`
-	AppSecret string `mapstructure:"secret"`
+	AppId      string `mapstructure:"appid"`
+	AppSecret  string `mapstructure:"secret"`
+	SessionTTL string `mapstructure:"session_ttl"` // 登录会话密钥的保存时间
+}
+
+type Search struct {
+	Engine   string `mapstructure:"engine"`   // memory | elasticsearch
+	Endpoint string `mapstructure:"endpoint"` // 外部搜索引擎地址
+	Index    string `mapstructure:"index"`    // 外部搜索引擎索引名
+}
+
+type Export struct {
+	Dir         string `mapstructure:"dir"`          // 异步导出文件目录
+	TemplateDir string `mapstructure:"template_dir"` // xlsx 模板目录
+	AsyncDays   int    `mapstructure:"async_days"`   // 超过该天数自动转为异步导出
+	Retention   string `mapstructure:"retention"`    // 导出文件保留时长，如 24h
+}
+
+// Notify 报表推送渠道配置
+type Notify struct {
+	DryRun  bool   `mapstructure:"dry_run"`  // 为 true 时所有渠道改为写入 FileDir，便于调试
+	FileDir string `mapstructure:"file_dir"` // file 渠道的输出目录
+	Timeout string `mapstructure:"timeout"`  // 单次推送超时，如 30s
+	SMTP    SMTP   `mapstructure:"smtp"`
+	Webhook struct {
+		Secret string `mapstructure:"secret"` // 回调签名密钥，为空时不签名
+	} `mapstructure:"webhook"`
+	WeCom struct {
+		BaseURL string `mapstructure:"base_url"` // 企业微信机器人接口地址
+	} `mapstructure:"wecom"`
+}
+
+type SMTP struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+// Print 小票打印配置
+type Print struct {
+	DryRun      bool   `mapstructure:"dry_run"`      // 为 true 时所有打印机改为写入 FileDir，便于调试
+	FileDir     string `mapstructure:"file_dir"`     // file 驱动的输出目录
+	Timeout     string `mapstructure:"timeout"`      // 单次打印超时，如 10s
+	Interval    string `mapstructure:"interval"`     // 打印队列轮询间隔
+	RetryDelay  string `mapstructure:"retry_delay"`  // 首次重试间隔，之后每次翻倍
+	MaxAttempts int    `mapstructure:"max_attempts"` // 最多尝试次数，用尽后任务标记为失败
+}
+
+// Dine 堂食与自取配置
+type Dine struct {
+	ShopId    int    `mapstructure:"shop_id"`     // 门店编号，写入桌台二维码
+	QRBaseURL string `mapstructure:"qr_base_url"` // 扫码点餐页面地址，二维码内容为该地址加门店、桌台参数
+}
+
+// Schedule 预约订单配置
+type Schedule struct {
+	Hours        string         `mapstructure:"hours"`         // 营业时间，如 10:00-14:00,17:00-21:30
+	Days         map[int]string `mapstructure:"days"`          // 按星期覆盖营业时间，0 为周日，空串表示休息
+	Slot         string         `mapstructure:"slot"`          // 每个送达时段的长度，如 30m
+	Capacity     int            `mapstructure:"capacity"`      // 每个时段后厨可承接的预约订单数
+	MinAdvance   string         `mapstructure:"min_advance"`   // 可预约的最早时段距下单时间，含出餐与配送时间
+	AheadDays    int            `mapstructure:"ahead_days"`    // 最多可预约未来几天
+	LeadTime     string         `mapstructure:"lead_time"`     // 时段开始前多久推送给商家
+	RemindBefore string         `mapstructure:"remind_before"` // 时段开始前多久仍未接单时提醒
+	Reminders    []Recipient    `mapstructure:"reminders"`     // 预约订单推送与提醒的接收方
+}
+
+// Recipient 通知接收方，channel 取值见 common/notify
+type Recipient struct {
+	Channel string `mapstructure:"channel"`
+	Target  string `mapstructure:"target"`
+}
+
+// Group 拼单配置
+type Group struct {
+	TTL           string `mapstructure:"ttl"`             // 拼单有效期，过期后不能再加入或修改
+	MaxMembers    int    `mapstructure:"max_members"`     // 每个拼单最多成员数，含发起人
+	InviteBaseURL string `mapstructure:"invite_base_url"` // 邀请页面地址，邀请链接为该地址加 code 参数
+	PayTimeout    string `mapstructure:"pay_timeout"`     // AA 支付时限，下单后超时仍有成员未支付则取消订单并退还已付份额
+}
+
+// Cart 购物车配置，购物车存放在 Redis 中并定时写回 MySQL
+type Cart struct {
+	TTL           string         `mapstructure:"ttl"`            // 购物车闲置过期时间，每次修改后重新计时
+	FlushInterval string         `mapstructure:"flush_interval"` // 写回 MySQL 的间隔
+	FlushBatch    int            `mapstructure:"flush_batch"`    // 每次最多写回的用户数
+	MinAmount     float64        `mapstructure:"min_amount"`     // 外卖起送金额，0 表示不限
+	MaxQuantity   int            `mapstructure:"max_quantity"`   // 每个菜品或套餐最多购买数量，不同口味合计
+	Limits        map[string]int `mapstructure:"limits"`         // 单独限购的菜品或套餐，如 dish:1: 2
+}
+
+// AfterSale 售后配置
+type AfterSale struct {
+	Window      string      `mapstructure:"window"`      // 订单完成后可申请售后的期限
+	SLA         string      `mapstructure:"sla"`         // 商家处理时限，超时后升级提醒并重新计时
+	Reasons     []string    `mapstructure:"reasons"`     // 可选的问题类型
+	MaxImages   int         `mapstructure:"max_images"`  // 每个售后单最多图片数
+	Escalations []Recipient `mapstructure:"escalations"` // 超时升级提醒的接收方
+}
+
+// Address 地址簿配置
+type Address struct {
+	Divisions   string `mapstructure:"divisions"`    // 行政区划数据集文件，为空时使用内置数据集
+	Geocoder    string `mapstructure:"geocoder"`     // offline | amap
+	GeocoderKey string `mapstructure:"geocoder_key"` // 地理编码服务的 key
+	MaxPerUser  int    `mapstructure:"max_per_user"` // 每个用户最多保存的地址数
+}
+
+// Sms 短信验证码配置
+type Sms struct {
+	Provider    string `mapstructure:"provider"` // fake | webhook
+	Endpoint    string `mapstructure:"endpoint"` // webhook 短信网关地址
+	Secret      string `mapstructure:"secret"`   // webhook 签名密钥
+	Dir         string `mapstructure:"dir"`      // fake 写入的目录
+	CodeTTL     string `mapstructure:"code_ttl"`
+	MaxAttempts int    `mapstructure:"max_attempts"`
+	Interval    string `mapstructure:"interval"`    // 同一手机号的发送间隔
+	DailyLimit  int    `mapstructure:"daily_limit"` // 同一手机号每日最多发送次数
+}
+
+type Storage struct {
+	Driver    string    `mapstructure:"driver"`     // local | s3 | oss，local 使用 path 配置，oss 使用 alioss 配置
+	MaxSize   int64     `mapstructure:"max_size"`   // 单个文件上限，字节
+	Thumbnail int       `mapstructure:"thumbnail"`  // 缩略图长边像素
+	Medium    int       `mapstructure:"medium"`     // 中图长边像素
+	SignTTL   string    `mapstructure:"sign_ttl"`   // 签名地址有效期
+	Secret    string    `mapstructure:"secret"`     // 本地存储签名地址的密钥
+	PublicURL string    `mapstructure:"public_url"` // s3/oss 的 CDN 等公开访问地址前缀
+	GcGrace   string    `mapstructure:"gc_grace"`   // 文件无引用超过该时长后删除
+	S3        S3Storage `mapstructure:"s3"`
+}
+
+type S3Storage struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	PathStyle bool   `mapstructure:"path_style"` // MinIO 使用路径形式的地址
 }
 
 func InitLoadConfig() *AllConfig {
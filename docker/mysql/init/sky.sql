@@ -19,7 +19,10 @@ CREATE TABLE `address_book` (
   `detail` varchar(200) CHARACTER SET utf8mb4  DEFAULT NULL COMMENT '详细地址',
   `label` varchar(100) CHARACTER SET utf8mb4  DEFAULT NULL COMMENT '标签',
   `is_default` tinyint(1) NOT NULL DEFAULT '0' COMMENT '默认 0 否 1是',
-  PRIMARY KEY (`id`)
+  `longitude` decimal(10,6) NOT NULL DEFAULT '0.000000' COMMENT '经度，0 表示未知',
+  `latitude` decimal(10,6) NOT NULL DEFAULT '0.000000' COMMENT '纬度，0 表示未知',
+  PRIMARY KEY (`id`),
+  KEY `idx_user` (`user_id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=2 DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='地址簿';
 
 DROP TABLE IF EXISTS `category`;
@@ -184,7 +187,17 @@ CREATE TABLE `orders` (
   `pack_amount` int DEFAULT NULL COMMENT '打包费',
   `tableware_number` int DEFAULT NULL COMMENT '餐具数量',
   `tableware_status` tinyint(1) NOT NULL DEFAULT '1' COMMENT '餐具数量状态  1按餐量提供  0选择具体数量',
-  PRIMARY KEY (`id`)
+  `order_type` int NOT NULL DEFAULT '1' COMMENT '订单类型 1外卖 2自取 3堂食',
+  `table_id` bigint DEFAULT NULL COMMENT '堂食桌台id',
+  `table_label` varchar(64) CHARACTER SET utf8mb4 DEFAULT NULL COMMENT '堂食区域与桌台名称',
+  `pickup_code` varchar(8) COLLATE utf8_bin DEFAULT NULL COMMENT '取餐码',
+  `ready_time` datetime DEFAULT NULL COMMENT '出餐通知时间',
+  `release_time` datetime DEFAULT NULL COMMENT '预约订单推送给商家的时间',
+  `remind_time` datetime DEFAULT NULL COMMENT '预约订单未接单提醒时间',
+  `remake_of` bigint NOT NULL DEFAULT '0' COMMENT '售后重做的原订单id，重做订单不计入营业统计',
+  PRIMARY KEY (`id`),
+  KEY `idx_orders_pickup_code` (`pickup_code`),
+  KEY `idx_orders_schedule` (`status`,`estimated_delivery_time`)
 ) ENGINE=InnoDB AUTO_INCREMENT=4 DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='订单表';
 
 DROP TABLE IF EXISTS `setmeal`;
@@ -240,5 +253,312 @@ CREATE TABLE `user` (
   `id_number` varchar(18) COLLATE utf8_bin DEFAULT NULL COMMENT '身份证号',
   `avatar` varchar(500) COLLATE utf8_bin DEFAULT NULL COMMENT '头像',
   `create_time` datetime DEFAULT NULL,
+  `unionid` varchar(64) COLLATE utf8_bin DEFAULT NULL COMMENT '微信开放平台 unionid',
+  `delete_time` datetime DEFAULT NULL COMMENT '注销或被合并的时间',
+  `merged_into` bigint DEFAULT NULL COMMENT '被合并到的用户id',
+  PRIMARY KEY (`id`),
+  KEY `idx_user_phone` (`phone`),
+  KEY `idx_user_merged_into` (`merged_into`)
+) ENGINE=InnoDB AUTO_INCREMENT=4 DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='用户信息';
+
+DROP TABLE IF EXISTS `review`;
+CREATE TABLE `review` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `order_id` bigint NOT NULL COMMENT '订单id',
+  `order_detail_id` bigint NOT NULL DEFAULT '0' COMMENT '订单明细id 0表示整单评价',
+  `user_id` bigint NOT NULL COMMENT '评价用户',
+  `dish_id` bigint DEFAULT NULL COMMENT '菜品id',
+  `setmeal_id` bigint DEFAULT NULL COMMENT '套餐id',
+  `rating` tinyint NOT NULL COMMENT '星级 1-5',
+  `content` varchar(500) CHARACTER SET utf8mb4 DEFAULT NULL COMMENT '评价内容',
+  `images` varchar(1000) COLLATE utf8_bin DEFAULT NULL COMMENT '图片，逗号分隔',
+  `reply` varchar(500) CHARACTER SET utf8mb4 DEFAULT NULL COMMENT '商家回复',
+  `reply_time` datetime DEFAULT NULL COMMENT '回复时间',
+  `status` int NOT NULL DEFAULT '1' COMMENT '1展示 0隐藏',
+  `flagged` tinyint NOT NULL DEFAULT '0' COMMENT '1被举报待审核',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_review_order_detail` (`order_id`,`order_detail_id`),
+  KEY `idx_review_dish` (`dish_id`),
+  KEY `idx_review_setmeal` (`setmeal_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='订单评价';
+
+DROP TABLE IF EXISTS `favorite`;
+CREATE TABLE `favorite` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `user_id` bigint NOT NULL COMMENT '用户id',
+  `dish_id` bigint NOT NULL DEFAULT '0' COMMENT '菜品id',
+  `setmeal_id` bigint NOT NULL DEFAULT '0' COMMENT '套餐id',
+  `create_time` datetime DEFAULT NULL COMMENT '收藏时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_favorite_user_item` (`user_id`,`dish_id`,`setmeal_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='用户收藏';
+
+DROP TABLE IF EXISTS `daily_business_stats`;
+CREATE TABLE `daily_business_stats` (
+  `stat_date` date NOT NULL COMMENT '统计日期（下单日期）',
+  `turnover` decimal(12,2) NOT NULL DEFAULT '0.00' COMMENT '营业额',
+  `order_count` int NOT NULL DEFAULT '0' COMMENT '订单总数',
+  `valid_order_count` int NOT NULL DEFAULT '0' COMMENT '有效订单数',
+  `new_users` int NOT NULL DEFAULT '0' COMMENT '新增用户数',
+  `update_time` datetime DEFAULT NULL COMMENT '更新时间',
+  PRIMARY KEY (`stat_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='每日营业数据';
+
+DROP TABLE IF EXISTS `report_schedule`;
+CREATE TABLE `report_schedule` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `name` varchar(64) CHARACTER SET utf8mb4 NOT NULL COMMENT '计划名称',
+  `frequency` varchar(16) COLLATE utf8_bin NOT NULL DEFAULT 'daily' COMMENT 'daily 前一天 weekly 上一周',
+  `send_time` char(5) COLLATE utf8_bin NOT NULL DEFAULT '08:00' COMMENT '推送时间 HH:MM',
+  `weekday` tinyint NOT NULL DEFAULT '1' COMMENT '周报推送星期 1-7，周一为1',
+  `time_zone` varchar(64) COLLATE utf8_bin DEFAULT NULL COMMENT 'IANA 时区，为空使用服务器时区',
+  `status` int NOT NULL DEFAULT '1' COMMENT '1启用 0停用',
+  `last_run_time` datetime DEFAULT NULL COMMENT '最近推送时间',
+  `last_status` varchar(16) COLLATE utf8_bin DEFAULT NULL COMMENT '最近推送结果 success/partial/failed',
+  `last_error` varchar(500) CHARACTER SET utf8mb4 DEFAULT NULL COMMENT '最近推送错误',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  `update_time` datetime DEFAULT NULL COMMENT '更新时间',
   PRIMARY KEY (`id`)
-) ENGINE=InnoDB AUTO_INCREMENT=4 DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='用户信息';
\ No newline at end of file
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='营业报表推送计划';
+
+DROP TABLE IF EXISTS `report_recipient`;
+CREATE TABLE `report_recipient` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `schedule_id` bigint NOT NULL COMMENT '推送计划id',
+  `name` varchar(64) CHARACTER SET utf8mb4 DEFAULT NULL COMMENT '接收人',
+  `channel` varchar(16) COLLATE utf8_bin NOT NULL COMMENT 'email webhook wecom file',
+  `target` varchar(500) COLLATE utf8_bin NOT NULL COMMENT '邮箱、回调地址或机器人key',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_report_recipient_schedule` (`schedule_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='营业报表接收人';
+
+DROP TABLE IF EXISTS `kitchen_station`;
+CREATE TABLE `kitchen_station` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `name` varchar(32) CHARACTER SET utf8mb4 NOT NULL COMMENT '工位名称',
+  `sort` int NOT NULL DEFAULT '0' COMMENT '排序',
+  `status` int NOT NULL DEFAULT '1' COMMENT '1启用 0停用',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  `update_time` datetime DEFAULT NULL COMMENT '更新时间',
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='后厨工位';
+
+DROP TABLE IF EXISTS `kitchen_station_category`;
+CREATE TABLE `kitchen_station_category` (
+  `category_id` bigint NOT NULL COMMENT '分类id',
+  `station_id` bigint NOT NULL COMMENT '工位id',
+  PRIMARY KEY (`category_id`),
+  KEY `idx_station_category_station` (`station_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='分类所属后厨工位';
+
+DROP TABLE IF EXISTS `kitchen_order`;
+CREATE TABLE `kitchen_order` (
+  `order_id` bigint NOT NULL COMMENT '订单id',
+  `order_number` varchar(50) COLLATE utf8_bin DEFAULT NULL COMMENT '订单号',
+  `remark` varchar(100) COLLATE utf8_bin DEFAULT NULL COMMENT '订单备注',
+  `status` int NOT NULL DEFAULT '0' COMMENT '0待制作 1制作中 2已出品待派送',
+  `create_time` datetime DEFAULT NULL COMMENT '进入后厨时间',
+  `ready_time` datetime DEFAULT NULL COMMENT '全部出品时间',
+  PRIMARY KEY (`order_id`),
+  KEY `idx_kitchen_order_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='后厨单';
+
+DROP TABLE IF EXISTS `kitchen_item`;
+CREATE TABLE `kitchen_item` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `order_id` bigint NOT NULL COMMENT '订单id',
+  `order_detail_id` bigint NOT NULL COMMENT '订单明细id',
+  `station_id` bigint NOT NULL DEFAULT '0' COMMENT '工位id 0未分配',
+  `dish_id` bigint DEFAULT NULL COMMENT '菜品id',
+  `name` varchar(32) COLLATE utf8_bin DEFAULT NULL COMMENT '菜品名称',
+  `setmeal_name` varchar(32) COLLATE utf8_bin DEFAULT NULL COMMENT '所属套餐名称',
+  `dish_flavor` varchar(50) COLLATE utf8_bin DEFAULT NULL COMMENT '口味',
+  `number` int NOT NULL DEFAULT '1' COMMENT '数量',
+  `status` int NOT NULL DEFAULT '0' COMMENT '0待制作 1制作中 2已出品',
+  `start_time` datetime DEFAULT NULL COMMENT '开始制作时间',
+  `done_time` datetime DEFAULT NULL COMMENT '出品时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_kitchen_item_order` (`order_id`),
+  KEY `idx_kitchen_item_station_status` (`station_id`,`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='后厨出品项';
+
+DROP TABLE IF EXISTS `printer`;
+CREATE TABLE `printer` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `name` varchar(64) CHARACTER SET utf8mb4 NOT NULL COMMENT '打印机名称',
+  `driver` varchar(16) COLLATE utf8_bin NOT NULL DEFAULT 'network' COMMENT 'network 网口打印机 file 写入文件',
+  `address` varchar(255) COLLATE utf8_bin NOT NULL COMMENT '打印机地址 host:port 或目录名',
+  `format` varchar(16) COLLATE utf8_bin NOT NULL DEFAULT 'escpos' COMMENT 'escpos text html',
+  `width` int NOT NULL DEFAULT '32' COMMENT '每行字符数 58mm为32 80mm为48',
+  `encoding` varchar(16) COLLATE utf8_bin NOT NULL DEFAULT 'gbk' COMMENT 'escpos 文字编码 gbk utf-8',
+  `cut` tinyint(1) NOT NULL DEFAULT '1' COMMENT '打印后切纸',
+  `copies` int NOT NULL DEFAULT '1' COMMENT '打印份数',
+  `kinds` varchar(32) COLLATE utf8_bin NOT NULL DEFAULT 'customer' COMMENT '打印的小票类型 kitchen,customer',
+  `auto_print` int NOT NULL DEFAULT '1' COMMENT '接单时自动打印 1是 0否',
+  `title` varchar(64) CHARACTER SET utf8mb4 DEFAULT NULL COMMENT '小票抬头（门店名称）',
+  `footer` varchar(255) CHARACTER SET utf8mb4 DEFAULT NULL COMMENT '小票页脚',
+  `template` text CHARACTER SET utf8mb4 COMMENT 'text/html 自定义模板',
+  `status` int NOT NULL DEFAULT '1' COMMENT '1启用 0停用',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  `update_time` datetime DEFAULT NULL COMMENT '更新时间',
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='小票打印机';
+
+DROP TABLE IF EXISTS `print_job`;
+CREATE TABLE `print_job` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `printer_id` bigint NOT NULL COMMENT '打印机id',
+  `order_id` bigint NOT NULL COMMENT '订单id',
+  `order_number` varchar(50) COLLATE utf8_bin DEFAULT NULL COMMENT '订单号',
+  `kind` varchar(16) COLLATE utf8_bin NOT NULL COMMENT 'kitchen 后厨单 customer 顾客联',
+  `reprint` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否补打',
+  `status` int NOT NULL DEFAULT '0' COMMENT '0待打印 1打印中 2已打印 3失败',
+  `attempts` int NOT NULL DEFAULT '0' COMMENT '已尝试次数',
+  `next_time` datetime NOT NULL COMMENT '下次尝试时间，打印中时为租约到期时间',
+  `last_error` varchar(500) CHARACTER SET utf8mb4 DEFAULT NULL COMMENT '最近一次失败原因',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  `print_time` datetime DEFAULT NULL COMMENT '打印完成时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_print_job_status_next` (`status`,`next_time`),
+  KEY `idx_print_job_order` (`order_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='小票打印任务';
+
+DROP TABLE IF EXISTS `dining_table`;
+CREATE TABLE `dining_table` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `name` varchar(32) CHARACTER SET utf8mb4 NOT NULL COMMENT '桌台名称',
+  `area` varchar(32) CHARACTER SET utf8mb4 DEFAULT NULL COMMENT '区域',
+  `seats` int NOT NULL DEFAULT '4' COMMENT '座位数',
+  `code` char(8) COLLATE utf8_bin NOT NULL COMMENT '二维码校验码，重新生成后旧二维码失效',
+  `status` int NOT NULL DEFAULT '1' COMMENT '1启用 0停用',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  `update_time` datetime DEFAULT NULL COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_dining_table_name` (`area`,`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='堂食桌台';
+
+DROP TABLE IF EXISTS `group_cart`;
+CREATE TABLE `group_cart` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `code` char(12) COLLATE utf8_bin NOT NULL COMMENT '邀请码',
+  `owner_id` bigint NOT NULL COMMENT '发起人',
+  `name` varchar(64) CHARACTER SET utf8mb4 DEFAULT NULL COMMENT '拼单名称',
+  `status` int NOT NULL DEFAULT '0' COMMENT '0进行中 1已锁定 2已下单 3已取消',
+  `split_bill` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否按成员分别支付',
+  `order_id` bigint DEFAULT NULL COMMENT '下单后的订单id',
+  `expire_time` datetime NOT NULL COMMENT '过期时间，过期后不能再加入或修改',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  `update_time` datetime DEFAULT NULL COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_group_cart_code` (`code`),
+  KEY `idx_group_cart_owner` (`owner_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='拼单购物车';
+
+DROP TABLE IF EXISTS `group_cart_member`;
+CREATE TABLE `group_cart_member` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `group_id` bigint NOT NULL COMMENT '拼单id',
+  `user_id` bigint NOT NULL COMMENT '成员',
+  `nickname` varchar(32) CHARACTER SET utf8mb4 DEFAULT NULL COMMENT '拼单中显示的昵称',
+  `join_time` datetime DEFAULT NULL COMMENT '加入时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_group_member` (`group_id`,`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='拼单成员';
+
+DROP TABLE IF EXISTS `group_cart_item`;
+CREATE TABLE `group_cart_item` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `group_id` bigint NOT NULL COMMENT '拼单id',
+  `user_id` bigint NOT NULL COMMENT '添加的成员',
+  `name` varchar(32) COLLATE utf8_bin DEFAULT NULL COMMENT '商品名称',
+  `image` varchar(255) COLLATE utf8_bin DEFAULT NULL COMMENT '图片',
+  `dish_id` bigint DEFAULT NULL COMMENT '菜品id',
+  `setmeal_id` bigint DEFAULT NULL COMMENT '套餐id',
+  `dish_flavor` varchar(50) COLLATE utf8_bin DEFAULT NULL COMMENT '口味',
+  `number` int NOT NULL DEFAULT '1' COMMENT '数量',
+  `amount` decimal(10,2) NOT NULL COMMENT '单价',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_group_item` (`group_id`,`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='拼单购物车明细';
+
+DROP TABLE IF EXISTS `group_payment`;
+CREATE TABLE `group_payment` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `group_id` bigint NOT NULL COMMENT '拼单id',
+  `order_id` bigint NOT NULL COMMENT '订单id',
+  `user_id` bigint NOT NULL COMMENT '付款成员',
+  `amount` decimal(10,2) NOT NULL COMMENT '应付金额',
+  `pay_status` tinyint NOT NULL DEFAULT '0' COMMENT '0未支付 1已支付 2已退款',
+  `pay_time` datetime DEFAULT NULL COMMENT '支付时间',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_group_payment` (`group_id`,`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='拼单分账支付';
+
+DROP TABLE IF EXISTS `after_sale`;
+CREATE TABLE `after_sale` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `order_id` bigint NOT NULL COMMENT '订单id',
+  `order_number` varchar(50) COLLATE utf8_bin DEFAULT NULL COMMENT '订单号',
+  `user_id` bigint NOT NULL COMMENT '用户id',
+  `type` tinyint NOT NULL COMMENT '用户诉求 1退款 2重做',
+  `reason` varchar(32) COLLATE utf8_bin NOT NULL COMMENT '问题类型',
+  `description` varchar(500) COLLATE utf8_bin DEFAULT NULL COMMENT '问题描述',
+  `images` varchar(2000) COLLATE utf8_bin DEFAULT NULL COMMENT '图片地址，多个以逗号分隔',
+  `amount` decimal(10,2) NOT NULL COMMENT '申请售后的商品金额',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '0待处理 1已同意 2已拒绝 3已撤销',
+  `resolution` tinyint NOT NULL DEFAULT '0' COMMENT '处理方式 1部分退款 2重做',
+  `refund_amount` decimal(10,2) NOT NULL DEFAULT '0.00' COMMENT '退款金额',
+  `remake_order_id` bigint NOT NULL DEFAULT '0' COMMENT '重做订单id',
+  `reply` varchar(500) COLLATE utf8_bin DEFAULT NULL COMMENT '商家处理说明',
+  `handle_user` bigint DEFAULT NULL COMMENT '处理人',
+  `handle_time` datetime DEFAULT NULL COMMENT '处理时间',
+  `due_time` datetime NOT NULL COMMENT '处理时限，超时后升级',
+  `escalate_level` int NOT NULL DEFAULT '0' COMMENT '超时升级次数',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  `update_time` datetime DEFAULT NULL COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_after_sale_order` (`order_id`),
+  KEY `idx_after_sale_user` (`user_id`),
+  KEY `idx_after_sale_due` (`status`,`due_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='售后单';
+
+DROP TABLE IF EXISTS `after_sale_item`;
+CREATE TABLE `after_sale_item` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `after_sale_id` bigint NOT NULL COMMENT '售后单id',
+  `order_detail_id` bigint NOT NULL COMMENT '订单明细id',
+  `name` varchar(32) COLLATE utf8_bin DEFAULT NULL COMMENT '名字',
+  `image` varchar(255) COLLATE utf8_bin DEFAULT NULL COMMENT '图片',
+  `dish_id` bigint DEFAULT NULL COMMENT '菜品id',
+  `setmeal_id` bigint DEFAULT NULL COMMENT '套餐id',
+  `dish_flavor` varchar(50) COLLATE utf8_bin DEFAULT NULL COMMENT '口味',
+  `number` int NOT NULL COMMENT '售后数量',
+  `amount` decimal(10,2) NOT NULL COMMENT '单价',
+  PRIMARY KEY (`id`),
+  KEY `idx_after_sale_item` (`after_sale_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='售后商品';
+
+DROP TABLE IF EXISTS `media`;
+CREATE TABLE `media` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `category` varchar(32) COLLATE utf8_bin NOT NULL COMMENT '上传目录 images后台 reviews用户 avatar头像',
+  `object_key` varchar(255) COLLATE utf8_bin NOT NULL COMMENT '存储key',
+  `url` varchar(500) COLLATE utf8_bin NOT NULL COMMENT '访问地址',
+  `hash` char(64) COLLATE utf8_bin NOT NULL COMMENT '内容SHA-256',
+  `content_type` varchar(64) COLLATE utf8_bin NOT NULL COMMENT '文件类型',
+  `size` bigint NOT NULL DEFAULT '0' COMMENT '字节数',
+  `width` int NOT NULL DEFAULT '0' COMMENT '图片宽度',
+  `height` int NOT NULL DEFAULT '0' COMMENT '图片高度',
+  `variants` varchar(1000) COLLATE utf8_bin DEFAULT NULL COMMENT '缩放规格 规格名->key',
+  `ref_count` int NOT NULL DEFAULT '0' COMMENT '引用次数',
+  `unused_since` datetime DEFAULT NULL COMMENT '引用数变为0的时间',
+  `create_time` datetime DEFAULT NULL COMMENT '上传时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_media_key` (`object_key`),
+  KEY `idx_media_category` (`category`,`create_time`),
+  KEY `idx_media_unused` (`ref_count`,`unused_since`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8_bin COMMENT='媒体文件';
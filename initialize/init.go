@@ -2,6 +2,7 @@ package initialize
 
 import (
 	"github.com/gin-gonic/gin"
+	"takeout/common/cache"
 	"takeout/config"
 	"takeout/global"
 	"takeout/logger"
@@ -17,6 +18,8 @@ func GlobalInit() *gin.Engine {
 	global.DB = InitDatabase(global.Config.DataSource.Dsn())
 	// Redis初始化
 	global.RedisClient = InitRedis()
+	// 订阅缓存失效广播，清理本实例的一级缓存
+	cache.Listen(global.RedisClient)
 	// Router初始化
 	router := routerInit()
 	return router
@@ -5,7 +5,9 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"net/http"
+	"takeout/common/storage"
 	_ "takeout/docs" // 导入生成的 Swagger 文档
+	"takeout/global"
 	"takeout/internal/router"
 	"takeout/internal/router/websocket"
 )
@@ -14,7 +16,10 @@ func routerInit() *gin.Engine {
 	r := gin.Default()
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler)) // 接口文档
-	r.StaticFS("/static", http.Dir("static"))                            //本地存储
+	// 本地存储，签名地址在这里校验
+	static := http.StripPrefix("/static", storage.NewLocal(global.Config.Path.LocalPath, "", global.Config.Storage.Secret).Handler())
+	r.GET("/static/*filepath", gin.WrapH(static))
+	r.HEAD("/static/*filepath", gin.WrapH(static))
 
 	allRouter := router.AllRouter
 
@@ -33,6 +38,16 @@ func routerInit() *gin.Engine {
 		allRouter.OrderRouter.InitApiRouter(admin)     // 注册订单路由
 		allRouter.ReportRouter.InitApiRouter(admin)    // 注册报表路由
 		allRouter.WorkSpaceRouter.InitApiRouter(admin) // 注册工作台路由
+		allRouter.ReviewRouter.InitApiRouter(admin)    // 注册评价管理路由
+		allRouter.CacheRouter.InitApiRouter(admin)     // 注册缓存指标路由
+		allRouter.SalesRouter.InitApiRouter(admin)     // 注册销售分析路由
+		allRouter.CustomerRouter.InitApiRouter(admin)  // 注册客户分析路由
+		allRouter.DeliveryRouter.InitApiRouter(admin)  // 注册报表推送路由
+		allRouter.KitchenRouter.InitApiRouter(admin)   // 注册后厨看板路由
+		allRouter.PrintRouter.InitApiRouter(admin)     // 注册小票打印路由
+		allRouter.TableRouter.InitApiRouter(admin)     // 注册桌台路由
+		allRouter.AfterSaleRouter.InitApiRouter(admin) // 注册售后路由
+		allRouter.MediaRouter.InitApiRouter(admin)     // 注册媒体库路由
 	}
 	// user
 	user := r.Group("/user")
@@ -45,6 +60,14 @@ func routerInit() *gin.Engine {
 		allRouter.UserAddressBook.InitApiRouter(user)  // 注册地址簿路由
 		allRouter.UserShoppingCart.InitApiRouter(user) // 注册购物车路由
 		allRouter.UserOrder.InitApiRouter(user)        // 注册订单路由
+		allRouter.UserSearch.InitApiRouter(user)       // 注册搜索路由
+		allRouter.UserReview.InitApiRouter(user)       // 注册评价路由
+		allRouter.UserCommon.InitApiRouter(user)       // 注册文件上传路由
+		allRouter.UserFavorite.InitApiRouter(user)     // 注册收藏路由
+		allRouter.UserRecommend.InitApiRouter(user)    // 注册推荐路由
+		allRouter.UserTable.InitApiRouter(user)        // 注册扫码点餐路由
+		allRouter.UserGroup.InitApiRouter(user)        // 注册拼单路由
+		allRouter.UserAfterSale.InitApiRouter(user)    // 注册售后路由
 	}
 	return r
 }
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/internal/model"
+	"takeout/internal/service"
+)
+
+type AfterSaleController struct {
+	service service.IAfterSaleService
+}
+
+func NewAfterSaleController(service service.IAfterSaleService) *AfterSaleController {
+	return &AfterSaleController{service: service}
+}
+
+// PageQuery @PageQuery 售后单分页查询
+// @Tags AfterSale
+// @Security JWTAuth
+// @Produce json
+// @Param dto query request.AfterSalePageQueryDTO true "查询参数"
+// @Success 200 {object} common.Result{Data=common.PageResult} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Router /admin/afterSale/page [get]
+func (c AfterSaleController) PageQuery(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.AfterSalePageQueryDTO
+		data *common.PageResult
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("AfterSale PageQuery bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.PageQuery(ctx, dto); err != nil {
+		afterSaleFailed(ctx, "AfterSale PageQuery", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Detail @Detail 查询售后单详情
+// @Tags AfterSale
+// @Security JWTAuth
+// @Produce json
+// @Param id path int true "售后单id"
+// @Success 200 {object} common.Result{Data=model.AfterSale} "success"
+// @Failure 404 {object} common.Result "售后单不存在"
+// @Router /admin/afterSale/{id} [get]
+func (c AfterSaleController) Detail(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data *model.AfterSale
+		err  error
+	)
+	id, _ := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if data, err = c.service.Detail(ctx, id); err != nil {
+		afterSaleFailed(ctx, "AfterSale Detail", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Approve @Approve 同意售后，部分退款或重做
+// @Tags AfterSale
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AfterSaleApproveDTO true "处理方式"
+// @Success 200 {object} common.Result{Data=model.AfterSale} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 409 {object} common.Result "售后单已处理"
+// @Router /admin/afterSale/approve [put]
+func (c AfterSaleController) Approve(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.AfterSaleApproveDTO
+		data *model.AfterSale
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("AfterSale Approve bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.Approve(ctx, dto); err != nil {
+		afterSaleFailed(ctx, "AfterSale Approve", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Reject @Reject 拒绝售后
+// @Tags AfterSale
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AfterSaleRejectDTO true "拒绝说明"
+// @Success 200 {object} common.Result "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 409 {object} common.Result "售后单已处理"
+// @Router /admin/afterSale/reject [put]
+func (c AfterSaleController) Reject(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.AfterSaleRejectDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("AfterSale Reject bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.Reject(ctx, dto); err != nil {
+		afterSaleFailed(ctx, "AfterSale Reject", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// Report @Report 售后统计
+// @Tags AfterSale
+// @Security JWTAuth
+// @Produce json
+// @Param dto query request.AfterSaleReportDTO true "统计区间"
+// @Success 200 {object} common.Result{Data=response.AfterSaleSummaryVO} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Router /admin/afterSale/report [get]
+func (c AfterSaleController) Report(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.AfterSaleReportDTO
+		data response.AfterSaleSummaryVO
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("AfterSale Report bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.Report(ctx, dto); err != nil {
+		afterSaleFailed(ctx, "AfterSale Report", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// afterSaleFailed 售后业务错误按类型返回 4xx，其余记录日志后返回 500
+func afterSaleFailed(ctx *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, e.Error_AFTER_SALE_NOT_FOUND), errors.Is(err, e.Error_ORDER_NOT_FOUND):
+		ctx.JSON(http.StatusNotFound, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_AFTER_SALE_HANDLED):
+		ctx.JSON(http.StatusConflict, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_AFTER_SALE_INVALID):
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Warn(name+" Error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+}
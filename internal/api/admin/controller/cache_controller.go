@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"takeout/common"
+	"takeout/common/cache"
+	"takeout/common/e"
+)
+
+type CacheController struct {
+}
+
+// Stats 缓存命中指标
+// @Tags Cache
+// @Security JWTAuth
+// @Produce json
+// @Success 200 {object} common.Result{Data=[]cache.Stats} "success"
+// @Router /admin/cache/stats [get]
+func (c *CacheController) Stats(ctx *gin.Context) {
+	code := e.SUCCESS
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: cache.AllStats(), Msg: e.GetMsg(code)})
+}
@@ -1,43 +1,79 @@
 package controller
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"net/http"
 	"takeout/common"
 	"takeout/common/e"
-	"takeout/common/utils"
 	"takeout/global"
+	"takeout/internal/service"
 )
 
 type CommonController struct {
+	service service.IUploadService
+	prefix  string // 上传图片的存储目录，也是媒体库中的分类
+}
+
+func NewCommonController(service service.IUploadService, prefix string) *CommonController {
+	return &CommonController{service: service, prefix: prefix}
 }
 
 // Upload 上传文件
 // @Summary 上传文件到服务器
-// @Description 接收一个文件，并将其上传到本地存储或云存储（如阿里云OSS）
+// @Description 接收一张图片，按配置保存到本地、S3 兼容存储或阿里云OSS，返回原图地址；同时生成缩略图与中图
 // @Tags FileUpload
 // @Accept multipart/form-data
 // @Security JWTAuth
 // @Produce json
 // @Param file formData file true "文件"  // file 参数表示文件
 // @Success 200 {object} common.Result{code=int, data=string, msg=string} "上传成功"
-// @Failure 400 {object} common.Result{code=int, data=string, msg=string} "上传失败"
+// @Failure 400 {object} common.Result{code=int, data=string, msg=string} "文件类型不支持"
+// @Failure 413 {object} common.Result{code=int, data=string, msg=string} "文件超过大小限制"
 // @Router /admin/common/upload [post]
 func (c *CommonController) Upload(ctx *gin.Context) {
 	code := e.SUCCESS
 	file, err := ctx.FormFile("file")
 	if err != nil {
+		global.Log.Debug("Upload bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	obj, err := c.service.Upload(ctx, file, c.prefix)
+	if err != nil {
+		uploadFailed(ctx, "Upload", err)
 		return
 	}
-	uuid := uuid.New()
-	imageName := uuid.String() + file.Filename
-	imagePath, err := utils.LocalOss(imageName, file)
-	//imagePath, err := utils.AliyunOss(imageName, file)
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: obj.URL, Msg: e.GetMsg(code)})
+}
+
+// SignURL 生成文件的限时访问地址
+// @Tags FileUpload
+// @Security JWTAuth
+// @Produce json
+// @Param key query string true "文件key"
+// @Success 200 {object} common.Result{code=int, data=string, msg=string} "签名地址"
+// @Router /admin/common/sign [get]
+func (c *CommonController) SignURL(ctx *gin.Context) {
+	code := e.SUCCESS
+	url, err := c.service.SignURL(ctx, ctx.Query("key"))
 	if err != nil {
-		code = e.ERROR
-		global.Log.Warn("AliyunOss upload failed", "err", err.Error())
+		uploadFailed(ctx, "SignURL", err)
+		return
 	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: url, Msg: e.GetMsg(code)})
+}
 
-	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: imagePath, Msg: e.GetMsg(code)})
+// uploadFailed 文件校验错误返回 4xx，其余记录日志后返回 500
+func uploadFailed(ctx *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, e.Error_FILE_TYPE_INVALID), errors.Is(err, e.Error_FILE_KEY_INVALID):
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_FILE_TOO_LARGE):
+		ctx.JSON(http.StatusRequestEntityTooLarge, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Warn(name+" Error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.Error_UPLOAD_FAILED.Error()})
 }
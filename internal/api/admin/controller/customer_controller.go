@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/internal/service"
+)
+
+type CustomerController struct {
+	service service.ICustomerAnalyticsService
+}
+
+func NewCustomerController(service service.ICustomerAnalyticsService) *CustomerController {
+	return &CustomerController{service: service}
+}
+
+// Cohorts @Cohorts 群组留存表
+// @Tags Customer
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param granularity query string false "week（默认）/month"
+// @Param timeZone query string false "IANA 时区"
+// @Router /admin/report/customers/cohorts [get]
+func (c *CustomerController) Cohorts(ctx *gin.Context) {
+	var (
+		dto  request.ReportQueryDTO
+		data response.CohortReportVO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.Cohorts(ctx, dto); err != nil {
+		reportFailed(ctx, "Cohorts", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Summary @Summary 复购率、下单频次与客户生命周期价值
+// @Tags Customer
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param timeZone query string false "IANA 时区"
+// @Router /admin/report/customers/summary [get]
+func (c *CustomerController) Summary(ctx *gin.Context) {
+	var (
+		dto  request.ReportQueryDTO
+		data response.CustomerSummaryVO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.Summary(ctx, dto); err != nil {
+		reportFailed(ctx, "CustomerSummary", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Churned @Churned 流失客户列表
+// @Tags Customer
+// @Security JWTAuth
+// @Param days query int false "超过多少天未下单，默认 60"
+// @Param page query int false "页码"
+// @Param pageSize query int false "每页条数"
+// @Router /admin/report/customers/churned [get]
+func (c *CustomerController) Churned(ctx *gin.Context) {
+	var (
+		dto  request.ChurnedQueryDTO
+		data *common.PageResult
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.Churned(ctx, dto); err != nil {
+		reportFailed(ctx, "Churned", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
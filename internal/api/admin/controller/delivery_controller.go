@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/internal/model"
+	"takeout/internal/service"
+)
+
+type DeliveryController struct {
+	service service.IReportDeliveryService
+}
+
+func NewDeliveryController(service service.IReportDeliveryService) *DeliveryController {
+	return &DeliveryController{service: service}
+}
+
+// List @List 营业报表推送计划列表
+// @Tags ReportSchedule
+// @Security JWTAuth
+// @Produce json
+// @Success 200 {object} common.Result{Data=[]model.ReportSchedule} "success"
+// @Router /admin/report/schedule/list [get]
+func (c *DeliveryController) List(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data []model.ReportSchedule
+		err  error
+	)
+	if data, err = c.service.ListSchedules(ctx); err != nil {
+		scheduleFailed(ctx, "ListSchedules", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Get @Get 推送计划详情
+// @Tags ReportSchedule
+// @Security JWTAuth
+// @Produce json
+// @Param id path int true "计划id"
+// @Success 200 {object} common.Result{Data=model.ReportSchedule} "success"
+// @Failure 404 {object} common.Result "推送计划不存在"
+// @Router /admin/report/schedule/{id} [get]
+func (c *DeliveryController) Get(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data *model.ReportSchedule
+		err  error
+	)
+	id, _ := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if data, err = c.service.GetSchedule(ctx, id); err != nil {
+		scheduleFailed(ctx, "GetSchedule", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Add @Add 新增推送计划
+// @Tags ReportSchedule
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.ReportScheduleDTO true "推送计划及接收方"
+// @Success 200 {object} common.Result{Data=model.ReportSchedule} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Router /admin/report/schedule [post]
+func (c *DeliveryController) Add(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.ReportScheduleDTO
+		data *model.ReportSchedule
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("ReportSchedule Add bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.AddSchedule(ctx, dto); err != nil {
+		scheduleFailed(ctx, "AddSchedule", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Edit @Edit 修改推送计划，不修改接收方
+// @Tags ReportSchedule
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.ReportScheduleDTO true "推送计划"
+// @Success 200 {object} common.Result "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Router /admin/report/schedule [put]
+func (c *DeliveryController) Edit(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.ReportScheduleDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("ReportSchedule Edit bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.EditSchedule(ctx, dto); err != nil {
+		scheduleFailed(ctx, "EditSchedule", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// Delete @Delete 删除推送计划及其接收方
+// @Tags ReportSchedule
+// @Security JWTAuth
+// @Produce json
+// @Param id query int true "计划id"
+// @Success 200 {object} common.Result "success"
+// @Router /admin/report/schedule [delete]
+func (c *DeliveryController) Delete(ctx *gin.Context) {
+	code := e.SUCCESS
+	id, _ := strconv.ParseUint(ctx.Query("id"), 10, 64)
+	if err := c.service.DeleteSchedule(ctx, id); err != nil {
+		scheduleFailed(ctx, "DeleteSchedule", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// SetStatus @SetStatus 启用或停用推送计划
+// @Tags ReportSchedule
+// @Security JWTAuth
+// @Produce json
+// @Param status path int true "1 启用 0 停用"
+// @Param id query int true "计划id"
+// @Success 200 {object} common.Result "success"
+// @Router /admin/report/schedule/status/{status} [post]
+func (c *DeliveryController) SetStatus(ctx *gin.Context) {
+	code := e.SUCCESS
+	id, _ := strconv.ParseUint(ctx.Query("id"), 10, 64)
+	status, _ := strconv.Atoi(ctx.Param("status"))
+	if err := c.service.SetStatus(ctx, id, status); err != nil {
+		scheduleFailed(ctx, "SetScheduleStatus", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// AddRecipient @AddRecipient 添加接收方
+// @Tags ReportSchedule
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.ReportRecipientDTO true "接收方"
+// @Success 200 {object} common.Result{Data=model.ReportRecipient} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Router /admin/report/schedule/recipient [post]
+func (c *DeliveryController) AddRecipient(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.ReportRecipientDTO
+		data *model.ReportRecipient
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("ReportSchedule AddRecipient bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.AddRecipient(ctx, dto); err != nil {
+		scheduleFailed(ctx, "AddRecipient", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// DeleteRecipient @DeleteRecipient 删除接收方
+// @Tags ReportSchedule
+// @Security JWTAuth
+// @Produce json
+// @Param id query int true "接收方id"
+// @Success 200 {object} common.Result "success"
+// @Router /admin/report/schedule/recipient [delete]
+func (c *DeliveryController) DeleteRecipient(ctx *gin.Context) {
+	code := e.SUCCESS
+	id, _ := strconv.ParseUint(ctx.Query("id"), 10, 64)
+	if err := c.service.DeleteRecipient(ctx, id); err != nil {
+		scheduleFailed(ctx, "DeleteRecipient", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// Run @Run 立即推送一次
+// @Tags ReportSchedule
+// @Security JWTAuth
+// @Produce json
+// @Param id path int true "计划id"
+// @Success 200 {object} common.Result{Data=response.DeliveryResultVO} "success"
+// @Router /admin/report/schedule/{id}/run [post]
+func (c *DeliveryController) Run(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data response.DeliveryResultVO
+		err  error
+	)
+	id, _ := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if data, err = c.service.RunNow(ctx, id); err != nil {
+		scheduleFailed(ctx, "RunSchedule", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Preview @Preview 预览推送内容（HTML）
+// @Tags ReportSchedule
+// @Security JWTAuth
+// @Produce html
+// @Param id path int true "计划id"
+// @Router /admin/report/schedule/{id}/preview [get]
+func (c *DeliveryController) Preview(ctx *gin.Context) {
+	id, _ := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	html, err := c.service.Preview(ctx, id)
+	if err != nil {
+		scheduleFailed(ctx, "PreviewSchedule", err)
+		return
+	}
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+}
+
+// scheduleFailed 参数错误返回 400，计划不存在返回 404，其余返回 500
+func scheduleFailed(ctx *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, e.Error_SCHEDULE_INVALID):
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_SCHEDULE_NOT_FOUND):
+		ctx.JSON(http.StatusNotFound, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Warn(name+" Error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+}
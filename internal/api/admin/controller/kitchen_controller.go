@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/internal/model"
+	"takeout/internal/service"
+)
+
+type KitchenController struct {
+	service service.IKitchenService
+}
+
+func NewKitchenController(service service.IKitchenService) *KitchenController {
+	return &KitchenController{service: service}
+}
+
+// ListStations @ListStations 后厨工位列表
+// @Tags Kitchen
+// @Security JWTAuth
+// @Produce json
+// @Success 200 {object} common.Result{Data=[]model.KitchenStation} "success"
+// @Router /admin/kds/station/list [get]
+func (c *KitchenController) ListStations(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data []model.KitchenStation
+		err  error
+	)
+	if data, err = c.service.ListStations(ctx); err != nil {
+		kitchenFailed(ctx, "ListStations", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// AddStation @AddStation 新增工位
+// @Tags Kitchen
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.KitchenStationDTO true "工位信息"
+// @Success 200 {object} common.Result{Data=model.KitchenStation} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Router /admin/kds/station [post]
+func (c *KitchenController) AddStation(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.KitchenStationDTO
+		data *model.KitchenStation
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Kitchen AddStation bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.AddStation(ctx, dto); err != nil {
+		kitchenFailed(ctx, "AddStation", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// EditStation @EditStation 修改工位
+// @Tags Kitchen
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.KitchenStationDTO true "工位信息"
+// @Success 200 {object} common.Result "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Router /admin/kds/station [put]
+func (c *KitchenController) EditStation(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.KitchenStationDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Kitchen EditStation bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.EditStation(ctx, dto); err != nil {
+		kitchenFailed(ctx, "EditStation", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// DeleteStation @DeleteStation 删除工位
+// @Tags Kitchen
+// @Security JWTAuth
+// @Produce json
+// @Param id query int true "工位id"
+// @Success 200 {object} common.Result "success"
+// @Failure 409 {object} common.Result "工位还有未出品的菜品"
+// @Router /admin/kds/station [delete]
+func (c *KitchenController) DeleteStation(ctx *gin.Context) {
+	code := e.SUCCESS
+	id, _ := strconv.ParseUint(ctx.Query("id"), 10, 64)
+	if err := c.service.DeleteStation(ctx, id); err != nil {
+		kitchenFailed(ctx, "DeleteStation", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// SetStationCategories @SetStationCategories 为工位分配分类
+// @Tags Kitchen
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.StationCategoriesDTO true "工位及分类"
+// @Success 200 {object} common.Result "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Router /admin/kds/station/categories [put]
+func (c *KitchenController) SetStationCategories(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.StationCategoriesDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Kitchen SetStationCategories bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.SetStationCategories(ctx, dto); err != nil {
+		kitchenFailed(ctx, "SetStationCategories", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// Board @Board 后厨看板
+// @Tags Kitchen
+// @Security JWTAuth
+// @Produce json
+// @Param stationId query int false "工位id，不传为全部工位"
+// @Success 200 {object} common.Result{Data=response.KitchenBoardVO} "success"
+// @Router /admin/kds/board [get]
+func (c *KitchenController) Board(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.KitchenBoardDTO
+		data response.KitchenBoardVO
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("Kitchen Board bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.Board(ctx, dto); err != nil {
+		kitchenFailed(ctx, "KitchenBoard", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// StartItems @StartItems 出品项开始制作
+// @Tags Kitchen
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.KitchenItemsDTO true "出品项id"
+// @Success 200 {object} common.Result "success"
+// @Router /admin/kds/item/start [post]
+func (c *KitchenController) StartItems(ctx *gin.Context) {
+	c.markItems(ctx, "StartItems", c.service.StartItems)
+}
+
+// DoneItems @DoneItems 出品项已出品
+// @Tags Kitchen
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.KitchenItemsDTO true "出品项id"
+// @Success 200 {object} common.Result "success"
+// @Router /admin/kds/item/done [post]
+func (c *KitchenController) DoneItems(ctx *gin.Context) {
+	c.markItems(ctx, "DoneItems", c.service.DoneItems)
+}
+
+func (c *KitchenController) markItems(ctx *gin.Context, name string, mark func(context.Context, []uint64) error) {
+	var (
+		code = e.SUCCESS
+		dto  request.KitchenItemsDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Kitchen "+name+" bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = mark(ctx, dto.Ids); err != nil {
+		kitchenFailed(ctx, name, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// Bump @Bump 划单，工位或整单出品
+// @Tags Kitchen
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.KitchenBumpDTO true "订单及工位"
+// @Success 200 {object} common.Result "success"
+// @Failure 404 {object} common.Result "后厨单不存在"
+// @Router /admin/kds/bump [post]
+func (c *KitchenController) Bump(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.KitchenBumpDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Kitchen Bump bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.Bump(ctx, dto); err != nil {
+		kitchenFailed(ctx, "Bump", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// kitchenFailed 工位、出品项或后厨单不存在返回 404，工位占用返回 409，其余返回 500
+func kitchenFailed(ctx *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, e.Error_STATION_NOT_FOUND),
+		errors.Is(err, e.Error_KITCHEN_ITEM_NOT_FOUND),
+		errors.Is(err, e.Error_KITCHEN_ORDER_NOT_FOUND):
+		ctx.JSON(http.StatusNotFound, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_STATION_IN_USE):
+		ctx.JSON(http.StatusConflict, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Warn(name+" Error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/service"
+)
+
+type MediaController struct {
+	service service.IMediaService
+}
+
+func NewMediaController(service service.IMediaService) *MediaController {
+	return &MediaController{service: service}
+}
+
+// PageQuery @PageQuery 媒体库分页查询
+// @Tags Media
+// @Security JWTAuth
+// @Produce json
+// @Param dto query request.MediaPageQueryDTO true "查询参数"
+// @Success 200 {object} common.Result{Data=common.PageResult} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Router /admin/media/page [get]
+func (c MediaController) PageQuery(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.MediaPageQueryDTO
+		data *common.PageResult
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("Media PageQuery bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.PageQuery(ctx, dto); err != nil {
+		global.Log.Warn("Media PageQuery Error:", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
@@ -187,6 +188,64 @@ func (c *OrderController) OrderComplete(ctx *gin.Context) {
 
 }
 
+// OrderReady @OrderReady 自取与堂食订单出餐通知
+// @Tags Order
+// @Security JWTAuth
+// @Produce json
+// @Param id path int true "订单id"
+// @Success 200 {object} common.Result "success"
+// @Failure 400 {object} common.Result "订单不是已接单的自取或堂食订单"
+// @Router /admin/order/ready/{id} [put]
+func (c *OrderController) OrderReady(ctx *gin.Context) {
+	code := e.SUCCESS
+	if err := c.service.OrderReady(ctx, ctx.Param("id")); err != nil {
+		orderFailed(ctx, "OrderReady", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// OrderPickup @OrderPickup 核销取餐码并完成订单
+// @Tags Order
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.OrderPickupDTO true "取餐码"
+// @Success 200 {object} common.Result "success"
+// @Failure 404 {object} common.Result "取餐码无效"
+// @Router /admin/order/pickup [put]
+func (c *OrderController) OrderPickup(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.OrderPickupDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Order OrderPickup bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.OrderPickup(ctx, dto); err != nil {
+		orderFailed(ctx, "OrderPickup", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// orderFailed 订单状态不符返回 400，订单或取餐码不存在返回 404，其余返回 500
+func orderFailed(ctx *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, e.Error_ORDER_STATUS_ERROR):
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_ORDER_NOT_FOUND), errors.Is(err, e.Error_PICKUP_CODE_NOT_FOUND):
+		ctx.JSON(http.StatusNotFound, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Warn(name+" Error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+}
+
 // OrderConditionSearch 订单搜索
 func (c *OrderController) OrderConditionSearch(ctx *gin.Context) {
 	var (
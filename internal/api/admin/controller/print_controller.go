@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/model"
+	"takeout/internal/service"
+)
+
+type PrintController struct {
+	service service.IPrintService
+}
+
+func NewPrintController(service service.IPrintService) *PrintController {
+	return &PrintController{service: service}
+}
+
+// ListPrinters @ListPrinters 打印机列表
+// @Tags Print
+// @Security JWTAuth
+// @Produce json
+// @Success 200 {object} common.Result{Data=[]model.Printer} "success"
+// @Router /admin/print/printer/list [get]
+func (c *PrintController) ListPrinters(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data []model.Printer
+		err  error
+	)
+	if data, err = c.service.ListPrinters(ctx); err != nil {
+		printFailed(ctx, "ListPrinters", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// AddPrinter @AddPrinter 新增打印机
+// @Tags Print
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.PrinterDTO true "打印机配置"
+// @Success 200 {object} common.Result{Data=model.Printer} "success"
+// @Failure 400 {object} common.Result "打印机参数错误"
+// @Router /admin/print/printer [post]
+func (c *PrintController) AddPrinter(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.PrinterDTO
+		data *model.Printer
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Print AddPrinter bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.AddPrinter(ctx, dto); err != nil {
+		printFailed(ctx, "AddPrinter", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// EditPrinter @EditPrinter 修改打印机
+// @Tags Print
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.PrinterDTO true "打印机配置"
+// @Success 200 {object} common.Result "success"
+// @Failure 400 {object} common.Result "打印机参数错误"
+// @Router /admin/print/printer [put]
+func (c *PrintController) EditPrinter(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.PrinterDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Print EditPrinter bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.EditPrinter(ctx, dto); err != nil {
+		printFailed(ctx, "EditPrinter", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// DeletePrinter @DeletePrinter 删除打印机
+// @Tags Print
+// @Security JWTAuth
+// @Produce json
+// @Param id query int true "打印机id"
+// @Success 200 {object} common.Result "success"
+// @Router /admin/print/printer [delete]
+func (c *PrintController) DeletePrinter(ctx *gin.Context) {
+	code := e.SUCCESS
+	id, _ := strconv.ParseUint(ctx.Query("id"), 10, 64)
+	if err := c.service.DeletePrinter(ctx, id); err != nil {
+		printFailed(ctx, "DeletePrinter", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// TestPrinter @TestPrinter 打印测试页
+// @Tags Print
+// @Security JWTAuth
+// @Produce json
+// @Param id path int true "打印机id"
+// @Success 200 {object} common.Result "success"
+// @Failure 502 {object} common.Result "打印失败"
+// @Router /admin/print/printer/{id}/test [post]
+func (c *PrintController) TestPrinter(ctx *gin.Context) {
+	code := e.SUCCESS
+	id, _ := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err := c.service.TestPrinter(ctx, id); err != nil {
+		printFailed(ctx, "TestPrinter", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// Preview @Preview 预览订单小票
+// @Tags Print
+// @Security JWTAuth
+// @Produce plain,html,octet-stream
+// @Param orderId query int true "订单id"
+// @Param kind query string false "kitchen 后厨单 customer 顾客联（默认）"
+// @Param format query string false "escpos text html，默认使用打印机的格式或 text"
+// @Param printerId query int false "按该打印机的配置排版"
+// @Router /admin/print/preview [get]
+func (c *PrintController) Preview(ctx *gin.Context) {
+	var dto request.ReceiptPreviewDTO
+	if err := ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("Print Preview bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	contentType, data, err := c.service.Preview(ctx, dto)
+	if err != nil {
+		printFailed(ctx, "PreviewReceipt", err)
+		return
+	}
+	ctx.Data(http.StatusOK, contentType, data)
+}
+
+// Reprint @Reprint 补打小票
+// @Tags Print
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.ReprintDTO true "订单、打印机与小票类型"
+// @Success 200 {object} common.Result{Data=[]model.PrintJob} "success"
+// @Router /admin/print/reprint [post]
+func (c *PrintController) Reprint(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.ReprintDTO
+		data []model.PrintJob
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Print Reprint bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.Reprint(ctx, dto); err != nil {
+		printFailed(ctx, "Reprint", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// PageJobs @PageJobs 打印任务分页查询
+// @Tags Print
+// @Security JWTAuth
+// @Produce json
+// @Param data query request.PrintJobPageQueryDTO true "分页与筛选条件"
+// @Success 200 {object} common.Result{Data=common.PageResult} "success"
+// @Router /admin/print/job/page [get]
+func (c *PrintController) PageJobs(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.PrintJobPageQueryDTO
+		data *common.PageResult
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("Print PageJobs bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.PageJobs(ctx, dto); err != nil {
+		printFailed(ctx, "PagePrintJobs", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// RetryJob @RetryJob 失败的打印任务重新打印
+// @Tags Print
+// @Security JWTAuth
+// @Produce json
+// @Param id path int true "任务id"
+// @Success 200 {object} common.Result "success"
+// @Failure 404 {object} common.Result "打印任务不存在或不是失败状态"
+// @Router /admin/print/job/{id}/retry [post]
+func (c *PrintController) RetryJob(ctx *gin.Context) {
+	code := e.SUCCESS
+	id, _ := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err := c.service.RetryJob(ctx, id); err != nil {
+		printFailed(ctx, "RetryPrintJob", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// printFailed 参数错误返回 400，打印机、任务或订单不存在返回 404，打印机无法连接返回 502，其余返回 500
+func printFailed(ctx *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, e.Error_PRINTER_INVALID):
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_PRINTER_NOT_FOUND),
+		errors.Is(err, e.Error_PRINT_JOB_NOT_FOUND),
+		errors.Is(err, e.Error_ORDER_NOT_FOUND):
+		ctx.JSON(http.StatusNotFound, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_PRINT_FAILED):
+		ctx.JSON(http.StatusBadGateway, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Warn(name+" Error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+}
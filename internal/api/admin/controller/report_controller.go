@@ -1,12 +1,14 @@
 package controller
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
 	"takeout/common"
 	"takeout/common/e"
 	"takeout/global"
+	"takeout/internal/api/admin/request"
 	"takeout/internal/api/admin/response"
 	"takeout/internal/service"
 )
@@ -33,12 +35,7 @@ func (c *ReportController) TurnoverStatistics(ctx *gin.Context) {
 
 	// 调用service层进行处理
 	if turnover, err = c.service.TurnoverStatistics(begin, end); err != nil {
-		code = e.ERROR
-		global.Log.Warn("TurnoverStatistics Error:", err.Error())
-		ctx.JSON(http.StatusInternalServerError, common.Result{
-			Code: code,
-			Msg:  e.GetMsg(code),
-		})
+		reportFailed(ctx, "TurnoverStatistics", err)
 		return
 	}
 	// 日志打印
@@ -65,12 +62,7 @@ func (c *ReportController) UserStatistics(ctx *gin.Context) {
 
 	// 调用service层进行处理
 	if userStatistics, err = c.service.UserStatistics(begin, end); err != nil {
-		code = e.ERROR
-		global.Log.Warn("UserStatistics Error:", err.Error())
-		ctx.JSON(http.StatusInternalServerError, common.Result{
-			Code: code,
-			Msg:  e.GetMsg(code),
-		})
+		reportFailed(ctx, "UserStatistics", err)
 		return
 	}
 	// 日志打印
@@ -97,12 +89,7 @@ func (c *ReportController) ReportOrderStatistics(ctx *gin.Context) {
 
 	// 调用service层进行处理
 	if data, err = c.service.ReportOrderStatistics(begin, end); err != nil {
-		code = e.ERROR
-		global.Log.Warn("ReportOrderStatistics Error:", err.Error())
-		ctx.JSON(http.StatusInternalServerError, common.Result{
-			Code: code,
-			Msg:  e.GetMsg(code),
-		})
+		reportFailed(ctx, "ReportOrderStatistics", err)
 		return
 	}
 	// 日志打印
@@ -129,12 +116,7 @@ func (c *ReportController) Top10Statistics(ctx *gin.Context) {
 
 	// 调用service层进行处理
 	if data, err = c.service.Top10Statistics(begin, end); err != nil {
-		code = e.ERROR
-		global.Log.Warn("Top10Statistics Error:", err.Error())
-		ctx.JSON(http.StatusInternalServerError, common.Result{
-			Code: code,
-			Msg:  e.GetMsg(code),
-		})
+		reportFailed(ctx, "Top10Statistics", err)
 		return
 	}
 	// 日志打印
@@ -151,7 +133,210 @@ func (c *ReportController) Top10Statistics(ctx *gin.Context) {
 // ExportExcel 导出运营数据Excel报表
 func (c *ReportController) ExportExcel(ctx *gin.Context) {
 	// 调用service层进行处理
-	c.service.ExportExcel(ctx)
+	if err := c.service.ExportExcel(ctx); err != nil {
+		exportFailed(ctx, "ExportExcel", err)
+		return
+	}
 	// 日志打印
 	log.Printf("导出运营数据Excel报表")
 }
+
+// TurnoverStatisticsV2 @TurnoverStatisticsV2 营业额统计（支持粒度、时区与同比/环比）
+// @Tags Report
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param granularity query string false "hour/day/week/month"
+// @Param timeZone query string false "IANA 时区"
+// @Param compare query []string false "previous/yoy"
+// @Router /admin/report/v2/turnoverStatistics [get]
+func (c *ReportController) TurnoverStatisticsV2(ctx *gin.Context) {
+	var (
+		dto  request.ReportQueryDTO
+		data response.TurnoverReportV2VO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.TurnoverStatisticsV2(dto); err != nil {
+		reportFailed(ctx, "TurnoverStatisticsV2", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// UserStatisticsV2 @UserStatisticsV2 用户统计（支持粒度、时区与同比/环比）
+// @Tags Report
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param granularity query string false "hour/day/week/month"
+// @Param timeZone query string false "IANA 时区"
+// @Param compare query []string false "previous/yoy"
+// @Router /admin/report/v2/userStatistics [get]
+func (c *ReportController) UserStatisticsV2(ctx *gin.Context) {
+	var (
+		dto  request.ReportQueryDTO
+		data response.UserReportV2VO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.UserStatisticsV2(dto); err != nil {
+		reportFailed(ctx, "UserStatisticsV2", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// OrderStatisticsV2 @OrderStatisticsV2 订单统计（支持粒度、时区与同比/环比）
+// @Tags Report
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param granularity query string false "hour/day/week/month"
+// @Param timeZone query string false "IANA 时区"
+// @Param compare query []string false "previous/yoy"
+// @Router /admin/report/v2/ordersStatistics [get]
+func (c *ReportController) OrderStatisticsV2(ctx *gin.Context) {
+	var (
+		dto  request.ReportQueryDTO
+		data response.OrderReportV2VO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.OrderStatisticsV2(dto); err != nil {
+		reportFailed(ctx, "OrderStatisticsV2", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Top10StatisticsV2 @Top10StatisticsV2 销量排名（数组返回，支持时区）
+// @Tags Report
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param timeZone query string false "IANA 时区"
+// @Router /admin/report/v2/top10 [get]
+func (c *ReportController) Top10StatisticsV2(ctx *gin.Context) {
+	var (
+		dto  request.ReportQueryDTO
+		data response.SalesTop10ReportV2VO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.Top10StatisticsV2(dto); err != nil {
+		reportFailed(ctx, "Top10StatisticsV2", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// ExportReport @ExportReport 按导出定义导出报表，长区间或 async=true 时返回异步任务
+// @Tags Report
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param granularity query string false "hour/day/week/month"
+// @Param timeZone query string false "IANA 时区"
+// @Param dimension query string false "time/goods"
+// @Param metrics query []string false "导出指标"
+// @Param format query string false "xlsx/csv/pdf"
+// @Param template query string false "xlsx 模板名"
+// @Param async query bool false "异步生成"
+// @Router /admin/report/v2/export [get]
+func (c *ReportController) ExportReport(ctx *gin.Context) {
+	var (
+		dto  request.ReportExportDTO
+		job  *response.ExportJobVO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if job, err = c.service.ExportReport(ctx, dto); err != nil {
+		exportFailed(ctx, "ExportReport", err)
+		return
+	}
+	if job != nil {
+		ctx.JSON(http.StatusAccepted, common.Result{Code: code, Data: job, Msg: e.GetMsg(code)})
+	}
+}
+
+// GetExportJob @GetExportJob 查询异步导出任务
+// @Tags Report
+// @Security JWTAuth
+// @Param id path string true "任务id"
+// @Router /admin/report/export/jobs/{id} [get]
+func (c *ReportController) GetExportJob(ctx *gin.Context) {
+	code := e.SUCCESS
+	job, err := c.service.GetExportJob(ctx.Param("id"))
+	if err != nil {
+		reportFailed(ctx, "GetExportJob", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: job, Msg: e.GetMsg(code)})
+}
+
+// DownloadExport @DownloadExport 下载异步导出文件
+// @Tags Report
+// @Security JWTAuth
+// @Param id path string true "任务id"
+// @Router /admin/report/export/jobs/{id}/download [get]
+func (c *ReportController) DownloadExport(ctx *gin.Context) {
+	path, fileName, err := c.service.ExportFile(ctx.Param("id"))
+	if err != nil {
+		reportFailed(ctx, "DownloadExport", err)
+		return
+	}
+	ctx.FileAttachment(path, fileName)
+}
+
+// exportFailed 文件已开始输出时只能中断连接，否则按 reportFailed 返回 JSON 错误
+func exportFailed(ctx *gin.Context, name string, err error) {
+	if ctx.Writer.Written() {
+		global.Log.Warn(name+" aborted:", err.Error())
+		ctx.Abort()
+		return
+	}
+	reportFailed(ctx, name, err)
+}
+
+// reportFailed 参数错误返回 400 及具体原因，导出任务不存在 404、未完成 409，其余返回 500
+func reportFailed(ctx *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, e.Error_REPORT_QUERY_INVALID):
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_EXPORT_JOB_NOT_FOUND):
+		ctx.JSON(http.StatusNotFound, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_EXPORT_NOT_READY):
+		ctx.JSON(http.StatusConflict, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Warn(name+" Error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/service"
+)
+
+type ReviewController struct {
+	service service.IReviewService
+}
+
+func NewReviewController(service service.IReviewService) *ReviewController {
+	return &ReviewController{service: service}
+}
+
+// PageQuery @PageQuery 评价管理分页查询
+// @Tags Review
+// @Security JWTAuth
+// @Produce json
+// @Param dto query request.ReviewPageQueryDTO true "查询参数"
+// @Success 200 {object} common.Result{Data=common.PageResult} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /admin/review/page [get]
+func (c *ReviewController) PageQuery(ctx *gin.Context) {
+	var (
+		code       = e.SUCCESS
+		dto        request.ReviewPageQueryDTO
+		pageResult *common.PageResult
+		err        error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("Review PageQuery bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{
+			Code: e.ERROR,
+			Msg:  "Invalid request payload",
+		})
+		return
+	}
+
+	if pageResult, err = c.service.PageQuery(ctx, dto); err != nil {
+		code = e.ERROR
+		global.Log.Warn("Review PageQuery failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  e.GetMsg(code),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Data: pageResult,
+		Msg:  e.GetMsg(code),
+	})
+}
+
+// Reply @Reply 商家回复评价
+// @Tags Review
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.ReviewReplyDTO true "回复内容"
+// @Success 200 {object} common.Result "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /admin/review/reply [put]
+func (c *ReviewController) Reply(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.ReviewReplyDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Review Reply bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{
+			Code: e.ERROR,
+			Msg:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err = c.service.Reply(ctx, dto); err != nil {
+		code = e.ERROR
+		global.Log.Warn("Review Reply failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Msg:  e.GetMsg(code),
+	})
+}
+
+// SetStatus @SetStatus 展示或隐藏评价
+// @Tags Review
+// @Security JWTAuth
+// @Produce json
+// @Param status path int true "1 展示 0 隐藏"
+// @Param id query int true "评价id"
+// @Success 200 {object} common.Result "success"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /admin/review/status/{status} [post]
+func (c *ReviewController) SetStatus(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		err  error
+	)
+	id, _ := strconv.ParseUint(ctx.Query("id"), 10, 64)
+	status, _ := strconv.Atoi(ctx.Param("status"))
+	if err = c.service.SetStatus(ctx, id, status); err != nil {
+		code = e.ERROR
+		global.Log.Warn("Review SetStatus failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Msg:  e.GetMsg(code),
+	})
+}
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/internal/service"
+)
+
+type SalesController struct {
+	service service.ISalesAnalyticsService
+}
+
+func NewSalesController(service service.ISalesAnalyticsService) *SalesController {
+	return &SalesController{service: service}
+}
+
+// ItemSales @ItemSales 菜品/套餐销售排行
+// @Tags Sales
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param timeZone query string false "IANA 时区"
+// @Router /admin/report/sales/items [get]
+func (c *SalesController) ItemSales(ctx *gin.Context) {
+	var (
+		dto  request.SalesQueryDTO
+		data []response.ItemSalesVO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.ItemSales(ctx, dto); err != nil {
+		reportFailed(ctx, "ItemSales", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// CategorySales @CategorySales 分类销售占比
+// @Tags Sales
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param timeZone query string false "IANA 时区"
+// @Router /admin/report/sales/categories [get]
+func (c *SalesController) CategorySales(ctx *gin.Context) {
+	var (
+		dto  request.SalesQueryDTO
+		data []response.CategorySalesVO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.CategorySales(ctx, dto); err != nil {
+		reportFailed(ctx, "CategorySales", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// FlavorAttach @FlavorAttach 口味附加率
+// @Tags Sales
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param timeZone query string false "IANA 时区"
+// @Router /admin/report/sales/flavors [get]
+func (c *SalesController) FlavorAttach(ctx *gin.Context) {
+	var (
+		dto  request.SalesQueryDTO
+		data response.FlavorReportVO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.FlavorAttach(ctx, dto); err != nil {
+		reportFailed(ctx, "FlavorAttach", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Heatmap @Heatmap 按星期与小时的下单热力图
+// @Tags Sales
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param timeZone query string false "IANA 时区"
+// @Router /admin/report/sales/heatmap [get]
+func (c *SalesController) Heatmap(ctx *gin.Context) {
+	var (
+		dto  request.SalesQueryDTO
+		data response.HeatmapVO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.Heatmap(ctx, dto); err != nil {
+		reportFailed(ctx, "Heatmap", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// SlowMovers @SlowMovers 滞销商品
+// @Tags Sales
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param timeZone query string false "IANA 时区"
+// @Router /admin/report/sales/slow [get]
+func (c *SalesController) SlowMovers(ctx *gin.Context) {
+	var (
+		dto  request.SalesQueryDTO
+		data []response.SlowItemVO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.SlowMovers(ctx, dto); err != nil {
+		reportFailed(ctx, "SlowMovers", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Basket @Basket 平均订单构成
+// @Tags Sales
+// @Security JWTAuth
+// @Param begin query string true "开始日期 yyyy-MM-dd"
+// @Param end query string true "结束日期 yyyy-MM-dd"
+// @Param timeZone query string false "IANA 时区"
+// @Router /admin/report/sales/basket [get]
+func (c *SalesController) Basket(ctx *gin.Context) {
+	var (
+		dto  request.SalesQueryDTO
+		data response.BasketVO
+		code = e.SUCCESS
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		code = e.ERROR
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.Basket(ctx, dto); err != nil {
+		reportFailed(ctx, "Basket", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/model"
+	"takeout/internal/service"
+)
+
+type TableController struct {
+	service service.ITableService
+}
+
+func NewTableController(service service.ITableService) *TableController {
+	return &TableController{service: service}
+}
+
+// ListTables @ListTables 桌台列表
+// @Tags Table
+// @Security JWTAuth
+// @Produce json
+// @Success 200 {object} common.Result{Data=[]model.DiningTable} "success"
+// @Router /admin/table/list [get]
+func (c *TableController) ListTables(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data []model.DiningTable
+		err  error
+	)
+	if data, err = c.service.ListTables(ctx); err != nil {
+		tableFailed(ctx, "ListTables", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// AddTable @AddTable 新增桌台
+// @Tags Table
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.TableDTO true "桌台信息"
+// @Success 200 {object} common.Result{Data=model.DiningTable} "success"
+// @Router /admin/table [post]
+func (c *TableController) AddTable(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.TableDTO
+		data *model.DiningTable
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Table AddTable bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.AddTable(ctx, dto); err != nil {
+		tableFailed(ctx, "AddTable", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// EditTable @EditTable 修改桌台
+// @Tags Table
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.TableDTO true "桌台信息"
+// @Success 200 {object} common.Result "success"
+// @Failure 404 {object} common.Result "桌台不存在"
+// @Router /admin/table [put]
+func (c *TableController) EditTable(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.TableDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Table EditTable bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.EditTable(ctx, dto); err != nil {
+		tableFailed(ctx, "EditTable", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// DeleteTable @DeleteTable 删除桌台
+// @Tags Table
+// @Security JWTAuth
+// @Produce json
+// @Param id query int true "桌台id"
+// @Success 200 {object} common.Result "success"
+// @Failure 404 {object} common.Result "桌台不存在"
+// @Router /admin/table [delete]
+func (c *TableController) DeleteTable(ctx *gin.Context) {
+	code := e.SUCCESS
+	id, _ := strconv.ParseUint(ctx.Query("id"), 10, 64)
+	if err := c.service.DeleteTable(ctx, id); err != nil {
+		tableFailed(ctx, "DeleteTable", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// RotateCode @RotateCode 重新生成桌台二维码，旧二维码失效
+// @Tags Table
+// @Security JWTAuth
+// @Produce json
+// @Param id path int true "桌台id"
+// @Success 200 {object} common.Result{Data=model.DiningTable} "success"
+// @Failure 404 {object} common.Result "桌台不存在"
+// @Router /admin/table/{id}/rotate [post]
+func (c *TableController) RotateCode(ctx *gin.Context) {
+	code := e.SUCCESS
+	id, _ := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	data, err := c.service.RotateCode(ctx, id)
+	if err != nil {
+		tableFailed(ctx, "RotateTableCode", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// QRCode @QRCode 下载桌台二维码
+// @Tags Table
+// @Security JWTAuth
+// @Produce png
+// @Param id path int true "桌台id"
+// @Param scale query int false "每个模块的像素数，默认 8"
+// @Success 200 {file} binary "二维码图片"
+// @Failure 404 {object} common.Result "桌台不存在"
+// @Router /admin/table/{id}/qrcode [get]
+func (c *TableController) QRCode(ctx *gin.Context) {
+	id, _ := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	scale, _ := strconv.Atoi(ctx.Query("scale"))
+	data, err := c.service.QRCode(ctx, id, scale)
+	if err != nil {
+		tableFailed(ctx, "TableQRCode", err)
+		return
+	}
+	ctx.Header("Content-Disposition", "inline; filename=table-"+strconv.FormatUint(id, 10)+".png")
+	ctx.Data(http.StatusOK, "image/png", data)
+}
+
+// tableFailed 桌台不存在返回 404，其余返回 500
+func tableFailed(ctx *gin.Context, name string, err error) {
+	if errors.Is(err, e.Error_TABLE_NOT_FOUND) {
+		ctx.JSON(http.StatusNotFound, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Warn(name+" Error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+}
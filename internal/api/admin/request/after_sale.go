@@ -0,0 +1,33 @@
+package request
+
+// AfterSalePageQueryDTO 售后单分页查询参数
+type AfterSalePageQueryDTO struct {
+	Page        int    `form:"page"`
+	PageSize    int    `form:"pageSize"`
+	Status      string `form:"status"`      // 0 待处理 1 已同意 2 已拒绝 3 已撤销
+	Type        int    `form:"type"`        // 1 退款 2 重做
+	OrderNumber string `form:"orderNumber"` // 订单号
+	Escalated   string `form:"escalated"`   // 1 超时升级过
+	BeginTime   string `form:"beginTime"`
+	EndTime     string `form:"endTime"`
+}
+
+// AfterSaleApproveDTO 同意售后，部分退款或重做
+type AfterSaleApproveDTO struct {
+	Id           uint64  `json:"id" binding:"required"`
+	Resolution   int     `json:"resolution" binding:"required"` // 1 部分退款 2 重做
+	RefundAmount float64 `json:"refundAmount"`                  // 部分退款的金额
+	Reply        string  `json:"reply"`
+}
+
+// AfterSaleRejectDTO 拒绝售后
+type AfterSaleRejectDTO struct {
+	Id    uint64 `json:"id" binding:"required"`
+	Reply string `json:"reply" binding:"required"`
+}
+
+// AfterSaleReportDTO 售后统计区间，日期格式 2006-01-02
+type AfterSaleReportDTO struct {
+	Begin string `form:"begin" binding:"required"`
+	End   string `form:"end" binding:"required"`
+}
@@ -0,0 +1,19 @@
+package request
+
+import "time"
+
+// ChurnedQueryDTO 流失客户分页查询参数
+type ChurnedQueryDTO struct {
+	Days     int `form:"days"` // 超过多少天未下单视为流失，默认 60
+	Page     int `form:"page"`
+	PageSize int `form:"pageSize"`
+}
+
+// CustomerLifetimeDTO 客户截至某时刻的累计下单情况
+type CustomerLifetimeDTO struct {
+	UserId     int
+	Orders     int
+	Amount     float64
+	FirstOrder time.Time
+	LastOrder  time.Time
+}
@@ -0,0 +1,31 @@
+package request
+
+// KitchenStationDTO 新增或修改后厨工位
+type KitchenStationDTO struct {
+	Id     uint64 `json:"id"`
+	Name   string `json:"name" binding:"required"`
+	Sort   int    `json:"sort"`
+	Status *int   `json:"status"` // 1 启用（默认）0 停用
+}
+
+// StationCategoriesDTO 把分类分配给工位，覆盖该工位原有的分类
+type StationCategoriesDTO struct {
+	StationId   uint64   `json:"stationId" binding:"required"`
+	CategoryIds []uint64 `json:"categoryIds"`
+}
+
+// KitchenBoardDTO 后厨看板查询，stationId 为空或 0 时为出餐口视图（全部工位）
+type KitchenBoardDTO struct {
+	StationId uint64 `form:"stationId"`
+}
+
+// KitchenItemsDTO 批量标记出品项
+type KitchenItemsDTO struct {
+	Ids []uint64 `json:"ids" binding:"required"`
+}
+
+// KitchenBumpDTO 划单：把订单在工位上的出品项全部标记为已出品，stationId 为 0 时为整单
+type KitchenBumpDTO struct {
+	OrderId   int    `json:"orderId" binding:"required"`
+	StationId uint64 `json:"stationId"`
+}
@@ -0,0 +1,9 @@
+package request
+
+// MediaPageQueryDTO 媒体库分页查询
+type MediaPageQueryDTO struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"pageSize"`
+	Category string `form:"category"` // images 后台上传 reviews 用户上传 avatar 头像
+	Unused   string `form:"unused"`   // 1 只看未被引用的
+}
@@ -0,0 +1,44 @@
+package request
+
+// PrinterDTO 新增或修改打印机
+type PrinterDTO struct {
+	Id        uint64   `json:"id"`
+	Name      string   `json:"name" binding:"required"`
+	Driver    string   `json:"driver"` // network（默认）| file
+	Address   string   `json:"address" binding:"required"`
+	Format    string   `json:"format"`   // escpos（默认）| text | html
+	Width     int      `json:"width"`    // 每行字符数，默认 32（58mm）
+	Encoding  string   `json:"encoding"` // 默认 gbk
+	Cut       *bool    `json:"cut"`      // 默认切纸
+	Copies    int      `json:"copies"`   // 默认 1 份
+	Kinds     []string `json:"kinds"`    // kitchen/customer，默认 customer
+	AutoPrint *int     `json:"autoPrint"`
+	Title     string   `json:"title"`
+	Footer    string   `json:"footer"`
+	Template  string   `json:"template"`
+	Status    *int     `json:"status"`
+}
+
+// ReceiptPreviewDTO 预览订单小票，printerId 非 0 时按该打印机的配置排版
+type ReceiptPreviewDTO struct {
+	OrderId   int    `form:"orderId" binding:"required"`
+	Kind      string `form:"kind"`   // 默认 customer
+	Format    string `form:"format"` // 默认 text，为空且指定打印机时使用打印机的格式
+	PrinterId uint64 `form:"printerId"`
+}
+
+// ReprintDTO 补打，printerId 为 0 时发送到全部启用的打印机，kind 为空时按打印机配置的类型
+type ReprintDTO struct {
+	OrderId   int    `json:"orderId" binding:"required"`
+	Kind      string `json:"kind"`
+	PrinterId uint64 `json:"printerId"`
+}
+
+// PrintJobPageQueryDTO 打印任务分页查询
+type PrintJobPageQueryDTO struct {
+	Page      int    `form:"page"`
+	PageSize  int    `form:"pageSize"`
+	Status    string `form:"status"` // 0 待打印 1 打印中 2 已打印 3 失败
+	OrderId   int    `form:"orderId"`
+	PrinterId uint64 `form:"printerId"`
+}
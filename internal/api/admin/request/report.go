@@ -0,0 +1,20 @@
+package request
+
+// ReportQueryDTO v2 报表查询参数
+type ReportQueryDTO struct {
+	Begin       string   `form:"begin"`       // 开始日期 yyyy-MM-dd
+	End         string   `form:"end"`         // 结束日期 yyyy-MM-dd
+	Granularity string   `form:"granularity"` // hour/day/week/month，默认 day
+	TimeZone    string   `form:"timeZone"`    // IANA 时区，如 Asia/Shanghai，默认服务器时区
+	Compare     []string `form:"compare"`     // previous 上一周期 yoy 去年同期，可多选
+}
+
+// ReportExportDTO 报表导出定义
+type ReportExportDTO struct {
+	ReportQueryDTO
+	Dimension string   `form:"dimension"` // time 按时间（默认） goods 按商品
+	Metrics   []string `form:"metrics"`   // 导出的指标，可多选或逗号分隔，默认全部
+	Format    string   `form:"format"`    // xlsx（默认）/csv/pdf
+	Template  string   `form:"template"`  // xlsx 模板名，如 operation
+	Async     bool     `form:"async"`     // 异步生成，完成后通过下载链接获取
+}
@@ -0,0 +1,21 @@
+package request
+
+// ReportScheduleDTO 新增或修改营业报表推送计划，Recipients 仅在新增时写入
+type ReportScheduleDTO struct {
+	Id         uint64               `json:"id"`
+	Name       string               `json:"name" binding:"required"`
+	Frequency  string               `json:"frequency"` // daily（默认）| weekly
+	SendTime   string               `json:"sendTime"`  // HH:MM，默认 08:00
+	Weekday    int                  `json:"weekday"`   // 周报推送星期 1~7，默认周一
+	TimeZone   string               `json:"timeZone"`  // IANA 时区
+	Status     *int                 `json:"status"`    // 新增时 1 启用（默认）0 停用，修改时忽略，由启停接口修改
+	Recipients []ReportRecipientDTO `json:"recipients"`
+}
+
+// ReportRecipientDTO 推送计划接收方
+type ReportRecipientDTO struct {
+	ScheduleId uint64 `json:"scheduleId"`
+	Name       string `json:"name"`
+	Channel    string `json:"channel" binding:"required"` // email | webhook | wecom | file
+	Target     string `json:"target" binding:"required"`  // 邮箱、回调地址或机器人 key
+}
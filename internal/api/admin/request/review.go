@@ -0,0 +1,20 @@
+package request
+
+// ReviewPageQueryDTO 评价管理分页查询参数
+type ReviewPageQueryDTO struct {
+	Page      int    `form:"page"`
+	PageSize  int    `form:"pageSize"`
+	Rating    int    `form:"rating"`    // 星级
+	Status    string `form:"status"`    // 1 展示 0 隐藏
+	Flagged   string `form:"flagged"`   // 1 被举报
+	Replied   string `form:"replied"`   // 1 已回复 0 未回复
+	DishId    int    `form:"dishId"`    // 菜品id
+	SetmealId int    `form:"setmealId"` // 套餐id
+	OrderId   int    `form:"orderId"`   // 订单id
+}
+
+// ReviewReplyDTO 商家回复评价参数
+type ReviewReplyDTO struct {
+	Id    uint64 `json:"id" binding:"required"`
+	Reply string `json:"reply" binding:"required"`
+}
@@ -0,0 +1,30 @@
+package request
+
+// SalesQueryDTO 销售分析查询参数
+type SalesQueryDTO struct {
+	Begin      string `form:"begin"`      // 开始日期 yyyy-MM-dd
+	End        string `form:"end"`        // 结束日期 yyyy-MM-dd
+	TimeZone   string `form:"timeZone"`   // IANA 时区，默认服务器时区
+	Type       string `form:"type"`       // dish/setmeal，为空时不区分
+	CategoryId int    `form:"categoryId"` // 分类id
+	Sort       string `form:"sort"`       // amount（默认）/number
+	Limit      int    `form:"limit"`
+}
+
+// FlavorSalesDTO 菜品某口味组合的销量
+type FlavorSalesDTO struct {
+	DishId       int
+	Name         string
+	DishFlavor   string
+	Number       int
+	Configurable bool // 菜品是否配置了口味
+}
+
+// BasketSummaryDTO 区间内已完成订单的明细汇总
+type BasketSummaryDTO struct {
+	OrderCount    int
+	Amount        float64
+	Items         int
+	Lines         int
+	SetmealOrders int
+}
@@ -0,0 +1,10 @@
+package request
+
+// TableDTO 新增或修改堂食桌台
+type TableDTO struct {
+	Id     uint64 `json:"id"`
+	Name   string `json:"name" binding:"required"`
+	Area   string `json:"area"`
+	Seats  int    `json:"seats"` // 默认 4 座
+	Status *int   `json:"status"`
+}
@@ -0,0 +1,30 @@
+package response
+
+// AfterSaleSummaryVO 售后统计，按申请时间统计
+type AfterSaleSummaryVO struct {
+	Tickets      int     `json:"tickets"` // 售后单数
+	Pending      int     `json:"pending"`
+	Approved     int     `json:"approved"`
+	Rejected     int     `json:"rejected"`
+	Cancelled    int     `json:"cancelled"`
+	Refunds      int     `json:"refunds"`      // 部分退款单数
+	RefundAmount float64 `json:"refundAmount"` // 退款金额
+	Remakes      int     `json:"remakes"`      // 重做单数
+	Escalated    int     `json:"escalated"`    // 超时升级过的售后单数
+	// AvgHandleMinutes 已处理售后单从申请到处理的平均分钟数
+	AvgHandleMinutes float64             `json:"avgHandleMinutes"`
+	Reasons          []AfterSaleReasonVO `json:"reasons"`
+	Goods            []AfterSaleGoodsVO  `json:"goods"` // 售后最多的商品
+}
+
+// AfterSaleReasonVO 各问题类型的售后单数
+type AfterSaleReasonVO struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+// AfterSaleGoodsVO 商品的售后数量
+type AfterSaleGoodsVO struct {
+	Name   string `json:"name"`
+	Number int    `json:"number"`
+}
@@ -0,0 +1,50 @@
+package response
+
+import "takeout/internal/model"
+
+// CohortPeriodVO 群组在首单后第 Offset 个周期的留存
+type CohortPeriodVO struct {
+	Offset int     `json:"offset"`
+	Users  int     `json:"users"`
+	Rate   float64 `json:"rate"`
+}
+
+// CohortRowVO 首单在同一周期的客户群组
+type CohortRowVO struct {
+	Cohort  string           `json:"cohort"`
+	Size    int              `json:"size"`
+	Periods []CohortPeriodVO `json:"periods"`
+}
+
+// CohortReportVO 群组留存表
+type CohortReportVO struct {
+	ReportMetaVO
+	Rows []CohortRowVO `json:"rows"`
+}
+
+// CustomerSummaryVO 区间内下单客户的复购、频次与生命周期价值
+type CustomerSummaryVO struct {
+	Period             ReportPeriodVO `json:"period"`
+	Customers          int            `json:"customers"`          // 下单客户数
+	NewCustomers       int            `json:"newCustomers"`       // 首单在区间内的客户数
+	ReturningCustomers int            `json:"returningCustomers"` // 区间前已下过单的客户数
+	Orders             int            `json:"orders"`
+	RepeatRate         float64        `json:"repeatRate"`        // 区间内下单 2 次及以上的客户占比
+	AvgOrders          float64        `json:"avgOrders"`         // 平均每客下单次数
+	AvgOrderValue      float64        `json:"avgOrderValue"`     // 客单价
+	AvgGapDays         float64        `json:"avgGapDays"`        // 相邻两单平均间隔天数
+	AvgLifetimeValue   float64        `json:"avgLifetimeValue"`  // 截至期末的平均累计消费额（历史 CLV）
+	AvgLifetimeOrders  float64        `json:"avgLifetimeOrders"` // 截至期末的平均累计下单次数
+	AvgLifespanDays    float64        `json:"avgLifespanDays"`   // 首单到最近一单的平均天数
+}
+
+// ChurnedCustomerVO 流失客户
+type ChurnedCustomerVO struct {
+	UserId        int             `json:"userId"`
+	Name          string          `json:"name"`
+	Phone         string          `json:"phone"`
+	Orders        int             `json:"orders"`
+	Amount        float64         `json:"amount"`
+	LastOrderTime model.LocalTime `json:"lastOrderTime"`
+	DaysSince     int             `json:"daysSince"` // 距最近一单的天数
+}
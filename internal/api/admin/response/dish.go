@@ -42,4 +42,6 @@ type DishListVo struct {
 	UpdateTime  time.Time `json:"updateTime"`
 	CreateUser  uint64    `json:"createUser"`
 	UpdateUser  uint64    `json:"updateUser"`
+	AvgRating   float64   `json:"avgRating"`   // 平均评分
+	RatingCount int64     `json:"ratingCount"` // 评价数
 }
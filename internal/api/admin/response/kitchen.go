@@ -0,0 +1,20 @@
+package response
+
+import "takeout/internal/model"
+
+// KitchenTicketVO 后厨看板上的一张单
+type KitchenTicketVO struct {
+	OrderId     int                 `json:"orderId"`
+	OrderNumber string              `json:"orderNumber"`
+	Remark      string              `json:"remark"`
+	Status      int                 `json:"status"`      // 0 待制作 1 制作中 2 已出品待派送
+	CreateTime  model.LocalTime     `json:"createTime"`  // 进入后厨时间
+	WaitSeconds int                 `json:"waitSeconds"` // 已等待时长
+	Items       []model.KitchenItem `json:"items"`
+}
+
+// KitchenBoardVO 后厨看板
+type KitchenBoardVO struct {
+	StationId uint64            `json:"stationId"`
+	Tickets   []KitchenTicketVO `json:"tickets"`
+}
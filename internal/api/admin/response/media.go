@@ -0,0 +1,19 @@
+package response
+
+import "time"
+
+// MediaVO 媒体库中的文件，Variants 为各缩放规格的访问地址
+type MediaVO struct {
+	Id          uint64            `json:"id"`
+	Category    string            `json:"category"`
+	Key         string            `json:"key"`
+	Url         string            `json:"url"`
+	Variants    map[string]string `json:"variants"`
+	ContentType string            `json:"contentType"`
+	Size        int64             `json:"size"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	RefCount    int               `json:"refCount"`
+	UnusedSince *time.Time        `json:"unusedSince"`
+	CreateTime  time.Time         `json:"createTime"`
+}
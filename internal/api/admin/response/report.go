@@ -28,3 +28,121 @@ type SalesTop10ReportVO struct {
 	NameList   string `json:"nameList"`
 	NumberList string `json:"numberList"`
 }
+
+// ReportPeriodVO 统计区间
+type ReportPeriodVO struct {
+	Begin string `json:"begin"`
+	End   string `json:"end"`
+}
+
+// ReportMetaVO v2 报表公共信息
+type ReportMetaVO struct {
+	Granularity string         `json:"granularity"`
+	TimeZone    string         `json:"timeZone"`
+	Period      ReportPeriodVO `json:"period"`
+}
+
+// CompareMetricVO 单项指标与对比区间的比较
+type CompareMetricVO struct {
+	Current    float64  `json:"current"`
+	Compare    float64  `json:"compare"`
+	Change     float64  `json:"change"`
+	ChangeRate *float64 `json:"changeRate"` // 对比值为 0 时为 null
+}
+
+// ReportCompareVO 对比区间及汇总指标的变化
+type ReportCompareVO struct {
+	Type    string                     `json:"type"` // previous 上一周期 yoy 去年同期
+	Period  ReportPeriodVO             `json:"period"`
+	Metrics map[string]CompareMetricVO `json:"metrics"`
+}
+
+// TurnoverPointVO 营业额时间桶
+type TurnoverPointVO struct {
+	Time     string  `json:"time"`
+	Turnover float64 `json:"turnover"`
+}
+
+// TurnoverCompareVO 营业额对比区间，Points 与当前区间按下标对齐
+type TurnoverCompareVO struct {
+	ReportCompareVO
+	Points []TurnoverPointVO `json:"points"`
+}
+
+// TurnoverReportV2VO 营业额统计 v2
+type TurnoverReportV2VO struct {
+	ReportMetaVO
+	Total   float64             `json:"total"`
+	Points  []TurnoverPointVO   `json:"points"`
+	Compare []TurnoverCompareVO `json:"compare,omitempty"`
+}
+
+// UserPointVO 用户时间桶
+type UserPointVO struct {
+	Time       string `json:"time"`
+	NewUsers   int    `json:"newUsers"`
+	TotalUsers int    `json:"totalUsers"`
+}
+
+// UserCompareVO 用户对比区间
+type UserCompareVO struct {
+	ReportCompareVO
+	Points []UserPointVO `json:"points"`
+}
+
+// UserReportV2VO 用户统计 v2
+type UserReportV2VO struct {
+	ReportMetaVO
+	NewUsers   int             `json:"newUsers"`
+	TotalUsers int             `json:"totalUsers"`
+	Points     []UserPointVO   `json:"points"`
+	Compare    []UserCompareVO `json:"compare,omitempty"`
+}
+
+// OrderPointVO 订单时间桶
+type OrderPointVO struct {
+	Time            string `json:"time"`
+	OrderCount      int    `json:"orderCount"`
+	ValidOrderCount int    `json:"validOrderCount"`
+}
+
+// OrderCompareVO 订单对比区间
+type OrderCompareVO struct {
+	ReportCompareVO
+	Points []OrderPointVO `json:"points"`
+}
+
+// OrderReportV2VO 订单统计 v2
+type OrderReportV2VO struct {
+	ReportMetaVO
+	TotalOrderCount     int              `json:"totalOrderCount"`
+	ValidOrderCount     int              `json:"validOrderCount"`
+	OrderCompletionRate float64          `json:"orderCompletionRate"`
+	Points              []OrderPointVO   `json:"points"`
+	Compare             []OrderCompareVO `json:"compare,omitempty"`
+}
+
+// SalesItemVO 商品销量
+type SalesItemVO struct {
+	Name   string `json:"name"`
+	Number int    `json:"number"`
+}
+
+// SalesTop10ReportV2VO 销量排名 v2
+type SalesTop10ReportV2VO struct {
+	TimeZone string         `json:"timeZone"`
+	Period   ReportPeriodVO `json:"period"`
+	Items    []SalesItemVO  `json:"items"`
+}
+
+// ExportJobVO 异步导出任务
+type ExportJobVO struct {
+	Id          string `json:"id"`
+	Status      string `json:"status"` // pending 排队中 running 生成中 done 已完成 failed 失败
+	Format      string `json:"format"`
+	FileName    string `json:"fileName"`
+	DownloadUrl string `json:"downloadUrl,omitempty"`
+	Error       string `json:"error,omitempty"`
+	CreateTime  string `json:"createTime"`
+	FinishTime  string `json:"finishTime,omitempty"`
+}
@@ -0,0 +1,18 @@
+package response
+
+// DeliveryRecipientVO 单个接收方的推送结果
+type DeliveryRecipientVO struct {
+	RecipientId uint64 `json:"recipientId"`
+	Channel     string `json:"channel"`
+	Target      string `json:"target"`
+	Success     bool   `json:"success"`
+	Error       string `json:"error,omitempty"`
+}
+
+// DeliveryResultVO 一次报表推送的结果
+type DeliveryResultVO struct {
+	ScheduleId uint64                `json:"scheduleId"`
+	Status     string                `json:"status"` // success | partial | failed | skipped
+	Period     ReportPeriodVO        `json:"period"`
+	Results    []DeliveryRecipientVO `json:"results"`
+}
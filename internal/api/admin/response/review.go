@@ -0,0 +1,8 @@
+package response
+
+// RatingStatVO 菜品/套餐评分汇总
+type RatingStatVO struct {
+	TargetId    uint64  `json:"targetId"`
+	AvgRating   float64 `json:"avgRating"`
+	RatingCount int64   `json:"ratingCount"`
+}
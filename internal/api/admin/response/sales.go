@@ -0,0 +1,108 @@
+package response
+
+import "takeout/internal/model"
+
+// ItemSalesVO 单个菜品/套餐的销售情况，按 ID 汇总，改名前后的销量合并计算
+type ItemSalesVO struct {
+	Type         string  `json:"type"` // dish 菜品 setmeal 套餐
+	Id           int     `json:"id"`
+	Name         string  `json:"name"`
+	CategoryId   int     `json:"categoryId"`
+	CategoryName string  `json:"categoryName"`
+	Number       int     `json:"number"`      // 销量
+	Amount       float64 `json:"amount"`      // 销售额
+	OrderCount   int     `json:"orderCount"`  // 包含该商品的订单数
+	AmountShare  float64 `json:"amountShare"` // 占区间销售额的比例
+}
+
+// CategorySalesVO 分类销售情况
+type CategorySalesVO struct {
+	CategoryId   int     `json:"categoryId"`
+	CategoryName string  `json:"categoryName"`
+	CategoryType int     `json:"categoryType"` // 1 菜品分类 2 套餐分类
+	Number       int     `json:"number"`
+	Amount       float64 `json:"amount"`
+	OrderCount   int     `json:"orderCount"`
+	AmountShare  float64 `json:"amountShare"`
+}
+
+// FlavorValueVO 口味选项的选择次数
+type FlavorValueVO struct {
+	Value  string  `json:"value"`
+	Number int     `json:"number"`
+	Share  float64 `json:"share"` // 占该菜品销量的比例
+}
+
+// DishFlavorVO 菜品口味附加率
+type DishFlavorVO struct {
+	DishId     int             `json:"dishId"`
+	Name       string          `json:"name"`
+	Number     int             `json:"number"`     // 销量
+	Flavored   int             `json:"flavored"`   // 选择了口味的份数
+	AttachRate float64         `json:"attachRate"` // 口味附加率
+	Values     []FlavorValueVO `json:"values"`
+}
+
+// FlavorReportVO 口味附加率统计
+type FlavorReportVO struct {
+	Number     int            `json:"number"`
+	Flavored   int            `json:"flavored"`
+	AttachRate float64        `json:"attachRate"`
+	Dishes     []DishFlavorVO `json:"dishes"`
+}
+
+// HeatCellVO 热力图单元：某星期某小时的订单量
+type HeatCellVO struct {
+	Weekday     int     `json:"weekday"` // 1 周一 ... 7 周日
+	Hour        int     `json:"hour"`
+	OrderCount  int     `json:"orderCount"`
+	ValidOrders int     `json:"validOrders"`
+	Turnover    float64 `json:"turnover"`
+}
+
+// HeatmapVO 按星期与小时统计的订单热力图
+type HeatmapVO struct {
+	TimeZone string         `json:"timeZone"`
+	Period   ReportPeriodVO `json:"period"`
+	Max      int            `json:"max"` // 单元格最大订单量，便于前端着色
+	Cells    []HeatCellVO   `json:"cells"`
+}
+
+// SlowItemVO 滞销商品：起售中但区间内销量最低的菜品/套餐
+type SlowItemVO struct {
+	Type          string           `json:"type"`
+	Id            int              `json:"id"`
+	Name          string           `json:"name"`
+	CategoryId    int              `json:"categoryId"`
+	CategoryName  string           `json:"categoryName"`
+	Number        int              `json:"number"`
+	Amount        float64          `json:"amount"`
+	LastOrderTime *model.LocalTime `json:"lastOrderTime"` // 区间内最后一次售出时间，未售出为 null
+}
+
+// BasketSizeVO 按商品件数分布的订单数，5 表示 5 件及以上
+type BasketSizeVO struct {
+	Items      int     `json:"items"`
+	OrderCount int     `json:"orderCount"`
+	Share      float64 `json:"share"`
+}
+
+// BasketCategoryVO 平均每单中某分类的构成
+type BasketCategoryVO struct {
+	CategoryId   int     `json:"categoryId"`
+	CategoryName string  `json:"categoryName"`
+	AvgNumber    float64 `json:"avgNumber"`   // 平均每单件数
+	AvgAmount    float64 `json:"avgAmount"`   // 平均每单金额
+	Penetration  float64 `json:"penetration"` // 包含该分类的订单占比
+}
+
+// BasketVO 平均订单构成
+type BasketVO struct {
+	OrderCount   int                `json:"orderCount"`
+	AvgAmount    float64            `json:"avgAmount"`    // 平均客单价
+	AvgItems     float64            `json:"avgItems"`     // 平均每单件数
+	AvgLines     float64            `json:"avgLines"`     // 平均每单商品种数
+	SetmealShare float64            `json:"setmealShare"` // 包含套餐的订单占比
+	Sizes        []BasketSizeVO     `json:"sizes"`
+	Categories   []BasketCategoryVO `json:"categories"`
+}
@@ -31,4 +31,6 @@ type SetMealWithDishByIdVo struct {
 	SetmealDishes []model.SetMealDish `json:"setmealDishes"`
 	Status        int                 `json:"status"`
 	UpdateTime    time.Time           `json:"updateTime"`
+	AvgRating     float64             `json:"avgRating"`   // 平均评分
+	RatingCount   int64               `json:"ratingCount"` // 评价数
 }
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
@@ -43,12 +44,7 @@ func (ac *AddressBookController) Create(ctx *gin.Context) {
 	}
 
 	if err = ac.service.CreateAddressBook(ctx, address); err != nil {
-		code = e.ERROR
-		global.Log.Debug("CreateAddressBook error:", err.Error())
-		ctx.JSON(http.StatusInternalServerError, common.Result{
-			Code: code,
-			Msg:  err.Error(),
-		})
+		addressFailed(ctx, "CreateAddressBook", err)
 		return
 	}
 
@@ -82,12 +78,7 @@ func (ac *AddressBookController) Update(ctx *gin.Context) {
 	}
 
 	if err = ac.service.UpdateAddressBook(ctx, address); err != nil {
-		code = e.ERROR
-		global.Log.Debug("UpdateAddressBook error:", err.Error())
-		ctx.JSON(http.StatusInternalServerError, common.Result{
-			Code: code,
-			Msg:  err.Error(),
-		})
+		addressFailed(ctx, "UpdateAddressBook", err)
 		return
 	}
 
@@ -119,12 +110,7 @@ func (ac *AddressBookController) DeleteById(ctx *gin.Context) {
 	}
 
 	if err = ac.service.DeleteAddressBook(ctx, id); err != nil {
-		code = e.ERROR
-		global.Log.Debug("DeleteAddressBook error:", err.Error())
-		ctx.JSON(http.StatusInternalServerError, common.Result{
-			Code: code,
-			Msg:  err.Error(),
-		})
+		addressFailed(ctx, "DeleteAddressBook", err)
 		return
 	}
 
@@ -157,12 +143,7 @@ func (ac *AddressBookController) GetById(ctx *gin.Context) {
 	}
 
 	if address, err = ac.service.GetAddressBook(ctx, id); err != nil {
-		code = e.ERROR
-		global.Log.Debug("GetAddressBook error:", err.Error())
-		ctx.JSON(http.StatusInternalServerError, common.Result{
-			Code: code,
-			Msg:  err.Error(),
-		})
+		addressFailed(ctx, "GetAddressBook", err)
 		return
 	}
 
@@ -263,12 +244,7 @@ func (ac *AddressBookController) SetDefaultAddress(ctx *gin.Context) {
 	}
 
 	if err = ac.service.SetDefaultAddressBook(ctx, requestId.ID); err != nil {
-		code = e.ERROR
-		global.Log.Debug("SetDefaultAddressBook error:", err.Error())
-		ctx.JSON(http.StatusInternalServerError, common.Result{
-			Code: code,
-			Msg:  err.Error(),
-		})
+		addressFailed(ctx, "SetDefaultAddressBook", err)
 		return
 	}
 
@@ -277,3 +253,20 @@ func (ac *AddressBookController) SetDefaultAddress(ctx *gin.Context) {
 		Msg:  e.GetMsg(code),
 	})
 }
+
+// addressFailed 地址业务错误按类型返回 4xx，其余错误沿用 500
+func addressFailed(ctx *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, e.Error_ADDRESS_NOT_FOUND):
+		ctx.JSON(http.StatusNotFound, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_ADDRESS_LIMIT):
+		ctx.JSON(http.StatusConflict, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_ADDRESS_INVALID), errors.Is(err, e.Error_ADDRESS_PHONE_INVALID):
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Debug(name+" error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: err.Error()})
+}
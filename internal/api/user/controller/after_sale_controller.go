@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/model"
+	"takeout/internal/service"
+)
+
+type AfterSaleController struct {
+	service service.IAfterSaleService
+}
+
+func NewAfterSaleController(service service.IAfterSaleService) *AfterSaleController {
+	return &AfterSaleController{service: service}
+}
+
+// Submit @Submit 申请售后
+// @Tags UserAfterSale
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AfterSaleSubmitDTO true "售后信息"
+// @Success 200 {object} common.Result{Data=model.AfterSale} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 409 {object} common.Result "该订单已有处理中的售后单"
+// @Router /user/afterSale [post]
+func (c AfterSaleController) Submit(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.AfterSaleSubmitDTO
+		data *model.AfterSale
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("SubmitAfterSale bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.Submit(ctx, dto); err != nil {
+		afterSaleFailed(ctx, "SubmitAfterSale", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// List @List 查询我的售后单
+// @Tags UserAfterSale
+// @Security JWTAuth
+// @Produce json
+// @Success 200 {object} common.Result{Data=[]model.AfterSale} "success"
+// @Router /user/afterSale/list [get]
+func (c AfterSaleController) List(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data []model.AfterSale
+		err  error
+	)
+	if data, err = c.service.ListMine(ctx); err != nil {
+		afterSaleFailed(ctx, "ListAfterSale", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Get @Get 查询售后单详情
+// @Tags UserAfterSale
+// @Security JWTAuth
+// @Produce json
+// @Param id path int true "售后单id"
+// @Success 200 {object} common.Result{Data=model.AfterSale} "success"
+// @Failure 404 {object} common.Result "售后单不存在"
+// @Router /user/afterSale/{id} [get]
+func (c AfterSaleController) Get(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data *model.AfterSale
+		err  error
+	)
+	id, _ := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if data, err = c.service.GetMine(ctx, id); err != nil {
+		afterSaleFailed(ctx, "GetAfterSale", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Cancel @Cancel 撤销待处理的售后单
+// @Tags UserAfterSale
+// @Security JWTAuth
+// @Produce json
+// @Param id path int true "售后单id"
+// @Success 200 {object} common.Result "success"
+// @Failure 409 {object} common.Result "售后单已处理"
+// @Router /user/afterSale/cancel/{id} [put]
+func (c AfterSaleController) Cancel(ctx *gin.Context) {
+	code := e.SUCCESS
+	id, _ := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err := c.service.Cancel(ctx, id); err != nil {
+		afterSaleFailed(ctx, "CancelAfterSale", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// afterSaleFailed 售后业务错误按类型返回 4xx，其余记录日志后返回 500
+func afterSaleFailed(ctx *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, e.Error_AFTER_SALE_NOT_FOUND), errors.Is(err, e.Error_ORDER_NOT_FOUND):
+		ctx.JSON(http.StatusNotFound, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_AFTER_SALE_EXISTS), errors.Is(err, e.Error_AFTER_SALE_HANDLED):
+		ctx.JSON(http.StatusConflict, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_AFTER_SALE_INVALID), errors.Is(err, e.Error_AFTER_SALE_EXPIRED):
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Warn(name+" Error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+}
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/internal/service"
+)
+
+type FavoriteController struct {
+	service service.IFavoriteService
+}
+
+func NewFavoriteController(service service.IFavoriteService) *FavoriteController {
+	return &FavoriteController{service: service}
+}
+
+// Add @Add 收藏菜品或套餐
+// @Tags UserFavorite
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.FavoriteDTO true "dishId 与 setmealId 二选一"
+// @Success 200 {object} common.Result "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/favorite [post]
+func (c FavoriteController) Add(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.FavoriteDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Add favorite bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{
+			Code: e.ERROR,
+			Msg:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err = c.service.AddFavorite(ctx, dto); err != nil {
+		code = e.ERROR
+		global.Log.Warn("AddFavorite failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Msg:  e.GetMsg(code),
+	})
+}
+
+// Delete @Delete 取消收藏
+// @Tags UserFavorite
+// @Security JWTAuth
+// @Produce json
+// @Param dishId query int false "菜品id"
+// @Param setmealId query int false "套餐id"
+// @Success 200 {object} common.Result "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/favorite [delete]
+func (c FavoriteController) Delete(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.FavoriteDTO
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("Delete favorite bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{
+			Code: e.ERROR,
+			Msg:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err = c.service.DeleteFavorite(ctx, dto); err != nil {
+		code = e.ERROR
+		global.Log.Warn("DeleteFavorite failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Msg:  e.GetMsg(code),
+	})
+}
+
+// Status @Status 查询是否已收藏
+// @Tags UserFavorite
+// @Security JWTAuth
+// @Produce json
+// @Param dishId query int false "菜品id"
+// @Param setmealId query int false "套餐id"
+// @Success 200 {object} common.Result{Data=bool} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/favorite/status [get]
+func (c FavoriteController) Status(ctx *gin.Context) {
+	var (
+		code   = e.SUCCESS
+		dto    request.FavoriteDTO
+		exists bool
+		err    error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("Favorite status bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{
+			Code: e.ERROR,
+			Msg:  "Invalid request payload",
+		})
+		return
+	}
+
+	if exists, err = c.service.IsFavorite(ctx, dto); err != nil {
+		code = e.ERROR
+		global.Log.Warn("IsFavorite failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Data: exists,
+		Msg:  e.GetMsg(code),
+	})
+}
+
+// List @List 我的收藏
+// @Tags UserFavorite
+// @Security JWTAuth
+// @Produce json
+// @Success 200 {object} common.Result{Data=[]response.FavoriteVO} "success"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/favorite/list [get]
+func (c FavoriteController) List(ctx *gin.Context) {
+	var (
+		code      = e.SUCCESS
+		favorites []response.FavoriteVO
+		err       error
+	)
+	if favorites, err = c.service.ListFavorite(ctx); err != nil {
+		code = e.ERROR
+		global.Log.Warn("ListFavorite failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  e.GetMsg(code),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Data: favorites,
+		Msg:  e.GetMsg(code),
+	})
+}
@@ -0,0 +1,322 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/internal/model"
+	"takeout/internal/service"
+)
+
+type GroupController struct {
+	service service.IGroupService
+}
+
+func NewGroupController(service service.IGroupService) *GroupController {
+	return &GroupController{service: service}
+}
+
+// Create @Create 发起拼单
+// @Tags UserGroup
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.GroupCreateDTO true "拼单信息"
+// @Success 200 {object} common.Result{Data=response.GroupCartVO} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Router /user/group [post]
+func (c GroupController) Create(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.GroupCreateDTO
+		data *response.GroupCartVO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("CreateGroup bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.CreateGroup(ctx, dto); err != nil {
+		groupFailed(ctx, "CreateGroup", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// List @List 查询参与中的拼单
+// @Tags UserGroup
+// @Security JWTAuth
+// @Produce json
+// @Success 200 {object} common.Result{Data=[]model.GroupCart} "success"
+// @Router /user/group/list [get]
+func (c GroupController) List(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data []model.GroupCart
+		err  error
+	)
+	if data, err = c.service.ListGroups(ctx); err != nil {
+		groupFailed(ctx, "ListGroups", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Get @Get 查询拼单详情
+// @Tags UserGroup
+// @Security JWTAuth
+// @Produce json
+// @Param code path string true "邀请码"
+// @Success 200 {object} common.Result{Data=response.GroupCartVO} "success"
+// @Failure 404 {object} common.Result "拼单不存在或已过期"
+// @Router /user/group/{code} [get]
+func (c GroupController) Get(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data *response.GroupCartVO
+		err  error
+	)
+	if data, err = c.service.GetGroup(ctx, ctx.Param("code")); err != nil {
+		groupFailed(ctx, "GetGroup", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Join @Join 通过邀请码加入拼单
+// @Tags UserGroup
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param code path string true "邀请码"
+// @Param data body request.GroupJoinDTO false "昵称"
+// @Success 200 {object} common.Result{Data=response.GroupCartVO} "success"
+// @Failure 409 {object} common.Result "拼单人数已满"
+// @Router /user/group/{code}/join [post]
+func (c GroupController) Join(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.GroupJoinDTO
+		data *response.GroupCartVO
+		err  error
+	)
+	if ctx.Request.ContentLength > 0 {
+		if err = ctx.ShouldBindJSON(&dto); err != nil {
+			global.Log.Debug("JoinGroup bind param error:", err.Error())
+			ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+			return
+		}
+	}
+	if data, err = c.service.JoinGroup(ctx, ctx.Param("code"), dto); err != nil {
+		groupFailed(ctx, "JoinGroup", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Leave @Leave 退出拼单
+// @Tags UserGroup
+// @Security JWTAuth
+// @Produce json
+// @Param code path string true "邀请码"
+// @Success 200 {object} common.Result "success"
+// @Router /user/group/{code}/leave [delete]
+func (c GroupController) Leave(ctx *gin.Context) {
+	if err := c.service.LeaveGroup(ctx, ctx.Param("code")); err != nil {
+		groupFailed(ctx, "LeaveGroup", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: e.SUCCESS, Msg: e.GetMsg(e.SUCCESS)})
+}
+
+// RemoveMember @RemoveMember 发起人移除成员
+// @Tags UserGroup
+// @Security JWTAuth
+// @Produce json
+// @Param code path string true "邀请码"
+// @Param userId query int true "成员用户id"
+// @Success 200 {object} common.Result "success"
+// @Failure 403 {object} common.Result "仅发起人可操作"
+// @Router /user/group/{code}/member [delete]
+func (c GroupController) RemoveMember(ctx *gin.Context) {
+	userId, err := strconv.Atoi(ctx.Query("userId"))
+	if err != nil || userId <= 0 {
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.RemoveMember(ctx, ctx.Param("code"), userId); err != nil {
+		groupFailed(ctx, "RemoveGroupMember", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: e.SUCCESS, Msg: e.GetMsg(e.SUCCESS)})
+}
+
+// AddItem @AddItem 向拼单添加菜品或套餐
+// @Tags UserGroup
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param code path string true "邀请码"
+// @Param data body request.ShoppingCartDTO true "菜品或套餐"
+// @Success 200 {object} common.Result "success"
+// @Router /user/group/{code}/add [post]
+func (c GroupController) AddItem(ctx *gin.Context) {
+	var (
+		dto request.ShoppingCartDTO
+		err error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("AddGroupItem bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.AddItem(ctx, ctx.Param("code"), dto); err != nil {
+		groupFailed(ctx, "AddGroupItem", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: e.SUCCESS, Msg: e.GetMsg(e.SUCCESS)})
+}
+
+// SubItem @SubItem 减少自己在拼单中的菜品或套餐
+// @Tags UserGroup
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param code path string true "邀请码"
+// @Param data body request.ShoppingCartDTO true "菜品或套餐"
+// @Success 200 {object} common.Result "success"
+// @Router /user/group/{code}/sub [post]
+func (c GroupController) SubItem(ctx *gin.Context) {
+	var (
+		dto request.ShoppingCartDTO
+		err error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("SubGroupItem bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.SubItem(ctx, ctx.Param("code"), dto); err != nil {
+		groupFailed(ctx, "SubGroupItem", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: e.SUCCESS, Msg: e.GetMsg(e.SUCCESS)})
+}
+
+// Lock @Lock 锁定拼单
+// @Tags UserGroup
+// @Security JWTAuth
+// @Produce json
+// @Param code path string true "邀请码"
+// @Success 200 {object} common.Result "success"
+// @Router /user/group/{code}/lock [put]
+func (c GroupController) Lock(ctx *gin.Context) {
+	if err := c.service.Lock(ctx, ctx.Param("code")); err != nil {
+		groupFailed(ctx, "LockGroup", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: e.SUCCESS, Msg: e.GetMsg(e.SUCCESS)})
+}
+
+// Unlock @Unlock 解锁拼单
+// @Tags UserGroup
+// @Security JWTAuth
+// @Produce json
+// @Param code path string true "邀请码"
+// @Success 200 {object} common.Result "success"
+// @Router /user/group/{code}/unlock [put]
+func (c GroupController) Unlock(ctx *gin.Context) {
+	if err := c.service.Unlock(ctx, ctx.Param("code")); err != nil {
+		groupFailed(ctx, "UnlockGroup", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: e.SUCCESS, Msg: e.GetMsg(e.SUCCESS)})
+}
+
+// Cancel @Cancel 取消拼单
+// @Tags UserGroup
+// @Security JWTAuth
+// @Produce json
+// @Param code path string true "邀请码"
+// @Success 200 {object} common.Result "success"
+// @Router /user/group/{code} [delete]
+func (c GroupController) Cancel(ctx *gin.Context) {
+	if err := c.service.Cancel(ctx, ctx.Param("code")); err != nil {
+		groupFailed(ctx, "CancelGroup", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: e.SUCCESS, Msg: e.GetMsg(e.SUCCESS)})
+}
+
+// Checkout @Checkout 发起人对已锁定的拼单下单
+// @Tags UserGroup
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param code path string true "邀请码"
+// @Param data body request.GroupCheckoutDTO true "下单信息"
+// @Success 200 {object} common.Result{Data=response.GroupCheckoutVO} "success"
+// @Failure 400 {object} common.Result "拼单未锁定或为空"
+// @Router /user/group/{code}/checkout [post]
+func (c GroupController) Checkout(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.GroupCheckoutDTO
+		data *response.GroupCheckoutVO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("GroupCheckout bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.Checkout(ctx, ctx.Param("code"), dto); err != nil {
+		groupFailed(ctx, "GroupCheckout", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Pay @Pay AA 支付自己的份额
+// @Tags UserGroup
+// @Security JWTAuth
+// @Produce json
+// @Param code path string true "邀请码"
+// @Success 200 {object} common.Result "success"
+// @Router /user/group/{code}/pay [put]
+func (c GroupController) Pay(ctx *gin.Context) {
+	if err := c.service.PayShare(ctx, ctx.Param("code")); err != nil {
+		groupFailed(ctx, "PayGroupShare", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: e.SUCCESS, Msg: e.GetMsg(e.SUCCESS)})
+}
+
+func groupFailed(ctx *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, e.Error_GROUP_NOT_FOUND):
+		ctx.JSON(http.StatusNotFound, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_GROUP_NOT_MEMBER), errors.Is(err, e.Error_GROUP_NOT_OWNER):
+		ctx.JSON(http.StatusForbidden, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_GROUP_FULL):
+		ctx.JSON(http.StatusConflict, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_GROUP_INVALID),
+		errors.Is(err, e.Error_GROUP_CLOSED),
+		errors.Is(err, e.Error_ORDER_TYPE_INVALID),
+		errors.Is(err, e.Error_ORDER_STATUS_ERROR):
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Warn(name+" Error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
@@ -42,6 +43,19 @@ func (c OrderController) OrderSubmit(ctx *gin.Context) {
 	// 调用service层进行处理
 	if orderVO, err = c.service.OrderSubmit(ctx, data); err != nil {
 		code = e.ERROR
+		// 订单类型、桌台二维码或购物车错误时提示用户
+		if errors.Is(err, e.Error_ORDER_TYPE_INVALID) || errors.Is(err, e.Error_TABLE_NOT_FOUND) ||
+			errors.Is(err, e.Error_TABLE_CODE_INVALID) || errors.Is(err, e.Error_SLOT_UNAVAILABLE) ||
+			errors.Is(err, e.Error_SHOPPING_CART_IS_NULL) || errors.Is(err, e.Error_CART_ITEM_UNAVAILABLE) ||
+			errors.Is(err, e.Error_CART_QUANTITY_EXCEEDED) || errors.Is(err, e.Error_CART_BELOW_MINIMUM) {
+			ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: err.Error()})
+			return
+		}
+		// 时段约满或商品变价时需要用户重新确认
+		if errors.Is(err, e.Error_SLOT_FULL) || errors.Is(err, e.Error_CART_REPRICED) {
+			ctx.JSON(http.StatusConflict, common.Result{Code: code, Msg: err.Error()})
+			return
+		}
 		global.Log.Debug("OrderSubmit error: ", err.Error())
 		ctx.JSON(http.StatusInternalServerError, common.Result{
 			Code: code,
@@ -58,6 +72,28 @@ func (c OrderController) OrderSubmit(ctx *gin.Context) {
 	})
 }
 
+// OrderSlots @OrderSlots 可预约的送达时段
+// @Tags UserOrder
+// @Security JWTAuth
+// @Produce json
+// @Success 200 {object} common.Result{Data=[]response.SlotDayVO} "success"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/order/slots [get]
+func (c OrderController) OrderSlots(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data []response.SlotDayVO
+		err  error
+	)
+	if data, err = c.service.OrderSlots(ctx); err != nil {
+		code = e.ERROR
+		global.Log.Warn("OrderSlots failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{Code: code, Msg: e.GetMsg(code)})
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
 // OrderPayment 订单支付
 func (c OrderController) OrderPayment(ctx *gin.Context) {
 	var (
@@ -76,7 +112,20 @@ func (c OrderController) OrderPayment(ctx *gin.Context) {
 	global.Log.Info("订单支付: ", data)
 
 	// 调用service层进行处理
-	paymentVO = c.service.OrderPayment(ctx, data)
+	if paymentVO, err = c.service.OrderPayment(ctx, data); err != nil {
+		code = e.ERROR
+		if errors.Is(err, e.Error_ORDER_NOT_FOUND) {
+			ctx.JSON(http.StatusNotFound, common.Result{Code: code, Msg: err.Error()})
+			return
+		}
+		if errors.Is(err, e.Error_ORDER_STATUS_ERROR) || errors.Is(err, e.Error_ORDER_SPLIT_BILL) {
+			ctx.JSON(http.StatusConflict, common.Result{Code: code, Msg: err.Error()})
+			return
+		}
+		global.Log.Warn("OrderPayment Error:", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{Code: code, Msg: e.GetMsg(code)})
+		return
+	}
 
 	ctx.JSON(http.StatusOK, common.Result{
 		Code: code,
@@ -191,6 +240,7 @@ func (c OrderController) RepetitionOrder(ctx *gin.Context) {
 
 	var (
 		code = e.SUCCESS
+		data *response.RepetitionVO
 		err  error
 	)
 	orderId := ctx.Param("id")
@@ -199,8 +249,12 @@ func (c OrderController) RepetitionOrder(ctx *gin.Context) {
 	log.Printf("再来一单: [%v]", orderId)
 
 	// 调用service层进行处理
-	if err = c.service.RepetitionOrder(ctx, orderId); err != nil {
+	if data, err = c.service.RepetitionOrder(ctx, orderId); err != nil {
 		code = e.ERROR
+		if errors.Is(err, e.Error_ORDER_NOT_FOUND) {
+			ctx.JSON(http.StatusNotFound, common.Result{Code: code, Msg: err.Error()})
+			return
+		}
 		global.Log.Debug("RepetitionOrder error: ", err.Error())
 		ctx.JSON(http.StatusInternalServerError, common.Result{
 			Code: code,
@@ -211,6 +265,7 @@ func (c OrderController) RepetitionOrder(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, common.Result{
 		Code: code,
+		Data: data,
 		Msg:  e.GetMsg(code),
 	})
 
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/internal/service"
+)
+
+type RecommendController struct {
+	service service.IRecommendService
+}
+
+func NewRecommendController(service service.IRecommendService) *RecommendController {
+	return &RecommendController{service: service}
+}
+
+// Recommend @Recommend 猜你喜欢
+// @Tags UserRecommend
+// @Security JWTAuth
+// @Produce json
+// @Param limit query int false "返回条数"
+// @Success 200 {object} common.Result{Data=[]response.RecommendItemVO} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/recommend [get]
+func (c RecommendController) Recommend(ctx *gin.Context) {
+	var (
+		code  = e.SUCCESS
+		dto   request.RecommendDTO
+		items []response.RecommendItemVO
+		err   error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("Recommend bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{})
+		return
+	}
+
+	if items, err = c.service.Recommend(ctx, dto); err != nil {
+		code = e.ERROR
+		global.Log.Warn("Recommend failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  e.GetMsg(code),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Data: items,
+		Msg:  e.GetMsg(code),
+	})
+}
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/internal/service"
+)
+
+type ReviewController struct {
+	service service.IReviewService
+}
+
+func NewReviewController(service service.IReviewService) *ReviewController {
+	return &ReviewController{service: service}
+}
+
+// Submit @Submit 评价已完成的订单
+// @Tags UserReview
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.ReviewSubmitDTO true "评价信息"
+// @Success 200 {object} common.Result "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/review [post]
+func (c ReviewController) Submit(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.ReviewSubmitDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("Submit review bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{
+			Code: e.ERROR,
+			Msg:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err = c.service.SubmitReview(ctx, dto); err != nil {
+		code = e.ERROR
+		global.Log.Warn("SubmitReview failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Msg:  e.GetMsg(code),
+	})
+}
+
+// GetOrderReview @GetOrderReview 查询订单评价
+// @Tags UserReview
+// @Security JWTAuth
+// @Produce json
+// @Param id path int true "订单id"
+// @Success 200 {object} common.Result{Data=response.OrderReviewVO} "success"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/review/order/{id} [get]
+func (c ReviewController) GetOrderReview(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		vo   response.OrderReviewVO
+		err  error
+	)
+	orderId, _ := strconv.Atoi(ctx.Param("id"))
+	if vo, err = c.service.GetOrderReview(ctx, orderId); err != nil {
+		code = e.ERROR
+		global.Log.Warn("GetOrderReview failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Data: vo,
+		Msg:  e.GetMsg(code),
+	})
+}
+
+// PageByTarget @PageByTarget 分页查询菜品/套餐评价
+// @Tags UserReview
+// @Security JWTAuth
+// @Produce json
+// @Param dto query request.ReviewPageQueryDTO true "查询参数"
+// @Success 200 {object} common.Result{Data=common.PageResult} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/review/page [get]
+func (c ReviewController) PageByTarget(ctx *gin.Context) {
+	var (
+		code       = e.SUCCESS
+		dto        request.ReviewPageQueryDTO
+		pageResult *common.PageResult
+		err        error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("PageByTarget bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{
+			Code: e.ERROR,
+			Msg:  "Invalid request payload",
+		})
+		return
+	}
+
+	if pageResult, err = c.service.PageByTarget(ctx, dto); err != nil {
+		code = e.ERROR
+		global.Log.Warn("PageByTarget failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Data: pageResult,
+		Msg:  e.GetMsg(code),
+	})
+}
+
+// Flag @Flag 举报评价
+// @Tags UserReview
+// @Security JWTAuth
+// @Produce json
+// @Param id path int true "评价id"
+// @Success 200 {object} common.Result "success"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/review/flag/{id} [post]
+func (c ReviewController) Flag(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		err  error
+	)
+	id, _ := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err = c.service.FlagReview(ctx, id); err != nil {
+		code = e.ERROR
+		global.Log.Warn("FlagReview failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Msg:  e.GetMsg(code),
+	})
+}
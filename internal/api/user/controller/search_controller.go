@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/internal/service"
+)
+
+type SearchController struct {
+	service service.ISearchService
+}
+
+func NewSearchController(service service.ISearchService) *SearchController {
+	return &SearchController{service: service}
+}
+
+// Search @Search 搜索菜品与套餐
+// @Tags UserSearch
+// @Security JWTAuth
+// @Produce json
+// @Param keyword query string true "关键字，支持汉字、全拼与拼音首字母"
+// @Param limit query int false "返回条数"
+// @Success 200 {object} common.Result{Data=[]response.SearchItemVO} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/search [get]
+func (c SearchController) Search(ctx *gin.Context) {
+	var (
+		code  = e.SUCCESS
+		dto   request.SearchDTO
+		items []response.SearchItemVO
+		err   error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("Search bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{})
+		return
+	}
+
+	if items, err = c.service.Search(ctx, dto); err != nil {
+		code = e.ERROR
+		global.Log.Warn("Search failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  e.GetMsg(code),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Data: items,
+		Msg:  e.GetMsg(code),
+	})
+}
+
+// Suggest @Suggest 搜索建议
+// @Tags UserSearch
+// @Security JWTAuth
+// @Produce json
+// @Param keyword query string true "输入中的关键字"
+// @Success 200 {object} common.Result{Data=[]string} "success"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/search/suggest [get]
+func (c SearchController) Suggest(ctx *gin.Context) {
+	var (
+		code        = e.SUCCESS
+		suggestions []string
+		err         error
+	)
+
+	if suggestions, err = c.service.Suggest(ctx, ctx.Query("keyword")); err != nil {
+		code = e.ERROR
+		global.Log.Warn("Suggest failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{
+			Code: code,
+			Msg:  e.GetMsg(code),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.Result{
+		Code: code,
+		Data: suggestions,
+		Msg:  e.GetMsg(code),
+	})
+}
@@ -8,7 +8,6 @@ import (
 	"takeout/common/e"
 	"takeout/global"
 	userResponse "takeout/internal/api/user/response"
-	"takeout/internal/model"
 	"takeout/internal/service"
 )
 
@@ -67,7 +66,7 @@ func (sc *SetMealController) List(ctx *gin.Context) {
 	var (
 		code       = e.SUCCESS
 		categoryId string
-		setmeals   []model.SetMeal
+		setmeals   []userResponse.SetmealVO
 		err        error
 	)
 	categoryId = ctx.Query("categoryId")
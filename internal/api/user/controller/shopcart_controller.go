@@ -1,12 +1,14 @@
 package controller
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"takeout/common"
 	"takeout/common/e"
 	"takeout/global"
 	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
 	"takeout/internal/model"
 	"takeout/internal/service"
 )
@@ -43,6 +45,10 @@ func (s *ShoppingCartController) AddShoppingCart(ctx *gin.Context) {
 
 	if err = s.service.AddShoppingCart(ctx, dto); err != nil {
 		code = e.ERROR
+		if errors.Is(err, e.Error_CART_QUANTITY_EXCEEDED) {
+			ctx.JSON(http.StatusBadRequest, common.Result{Code: code, Msg: err.Error()})
+			return
+		}
 		global.Log.Debug("AddShoppingCart error:", err.Error())
 		ctx.JSON(http.StatusInternalServerError, common.Result{
 			Code: code,
@@ -164,3 +170,33 @@ func (s *ShoppingCartController) SubShoppingCart(ctx *gin.Context) {
 		Msg:  e.GetMsg(code),
 	})
 }
+
+// ValidateShoppingCart @ValidateShoppingCart 校验购物车，按当前价格更新变价商品
+// @Tags ShoppingCart
+// @Security JWTAuth
+// @Produce json
+// @Param orderType query int false "订单类型 1外卖 2自取 3堂食，外卖需满足起送金额"
+// @Success 200 {object} common.Result{Data=response.CartValidateVO} "success"
+// @Failure 400 {object} common.Result "Invalid request payload"
+// @Failure 500 {object} common.Result "Internal Server Faliure"
+// @Router /user/shoppingCart/validate [get]
+func (s *ShoppingCartController) ValidateShoppingCart(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.CartValidateDTO
+		data *response.CartValidateVO
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("C端-购物车接口 ValidateShoppingCart param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{})
+		return
+	}
+	if data, err = s.service.ValidateShoppingCart(ctx, dto); err != nil {
+		code = e.ERROR
+		global.Log.Warn("ValidateShoppingCart error:", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{Code: code, Msg: e.GetMsg(code)})
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/internal/service"
+)
+
+type TableController struct {
+	service service.ITableService
+}
+
+func NewTableController(service service.ITableService) *TableController {
+	return &TableController{service: service}
+}
+
+// Scan @Scan 扫描桌台二维码
+// @Tags UserTable
+// @Produce json
+// @Param shop query int false "门店编号"
+// @Param table query int true "桌台id"
+// @Param code query string true "校验码"
+// @Success 200 {object} common.Result{Data=response.TableScanVO} "success"
+// @Failure 400 {object} common.Result "二维码无效"
+// @Router /user/table/scan [get]
+func (c TableController) Scan(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.TableScanDTO
+		data *response.TableScanVO
+		err  error
+	)
+	if err = ctx.ShouldBindQuery(&dto); err != nil {
+		global.Log.Debug("TableScan bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{})
+		return
+	}
+	if data, err = c.service.Scan(ctx, dto); err != nil {
+		if errors.Is(err, e.Error_TABLE_NOT_FOUND) || errors.Is(err, e.Error_TABLE_CODE_INVALID) {
+			ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: err.Error()})
+			return
+		}
+		global.Log.Warn("TableScan failed : ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"takeout/common"
@@ -72,3 +74,263 @@ func (c *WxUserController) Logout(ctx *gin.Context) {
 		Msg:  e.GetMsg(code),
 	})
 }
+
+// SendPhoneCode @SendPhoneCode 发送绑定手机号的短信验证码
+// @Tags WxUser
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.PhoneCodeDTO true "手机号"
+// @Success 200 {object} common.Result "success"
+// @Failure 400 {object} common.Result "手机号格式错误"
+// @Failure 429 {object} common.Result "验证码发送过于频繁"
+// @Router /user/user/phone/code [post]
+func (c *WxUserController) SendPhoneCode(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.PhoneCodeDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("SendPhoneCode bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.SendPhoneCode(ctx, dto); err != nil {
+		phoneFailed(ctx, "SendPhoneCode", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// BindPhoneBySms @BindPhoneBySms 通过短信验证码绑定手机号，原账号提供合并码时合并到当前账号，否则只解除其绑定
+// @Tags WxUser
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.PhoneSmsBindDTO true "手机号与验证码"
+// @Success 200 {object} common.Result{Data=response.PhoneBindVO} "success"
+// @Failure 400 {object} common.Result "验证码错误或已过期"
+// @Router /user/user/phone/sms [post]
+func (c *WxUserController) BindPhoneBySms(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.PhoneSmsBindDTO
+		data response.PhoneBindVO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("BindPhoneBySms bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.BindPhoneBySms(ctx, dto); err != nil {
+		phoneFailed(ctx, "BindPhoneBySms", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// BindPhoneByWechat @BindPhoneByWechat 通过微信手机号授权绑定手机号，unionid 相同或提供合并码的原账号合并到当前账号
+// @Tags WxUser
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.PhoneWechatBindDTO true "getPhoneNumber 返回的加密数据"
+// @Success 200 {object} common.Result{Data=response.PhoneBindVO} "success"
+// @Failure 401 {object} common.Result "微信登录已过期"
+// @Router /user/user/phone/wechat [post]
+func (c *WxUserController) BindPhoneByWechat(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.PhoneWechatBindDTO
+		data response.PhoneBindVO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("BindPhoneByWechat bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.BindPhoneByWechat(ctx, dto); err != nil {
+		phoneFailed(ctx, "BindPhoneByWechat", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// MergeCode @MergeCode 生成合并码，在另一个账号绑定同一手机号时填写，确认将本账号合并过去
+// @Tags WxUser
+// @Security JWTAuth
+// @Produce json
+// @Success 200 {object} common.Result{Data=response.MergeCodeVO} "success"
+// @Failure 400 {object} common.Result "未绑定手机号"
+// @Router /user/user/merge/code [post]
+func (c *WxUserController) MergeCode(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data response.MergeCodeVO
+		err  error
+	)
+	if data, err = c.service.MergeCode(ctx); err != nil {
+		phoneFailed(ctx, "MergeCode", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// Profile @Profile 查询个人资料
+// @Tags WxUser
+// @Security JWTAuth
+// @Produce json
+// @Success 200 {object} common.Result{Data=response.UserProfileVO} "success"
+// @Router /user/user/profile [get]
+func (c *WxUserController) Profile(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data response.UserProfileVO
+		err  error
+	)
+	if data, err = c.service.Profile(ctx); err != nil {
+		profileFailed(ctx, "Profile", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// UpdateProfile @UpdateProfile 修改昵称与性别
+// @Tags WxUser
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.UserProfileDTO true "个人资料"
+// @Success 200 {object} common.Result{Data=response.UserProfileVO} "success"
+// @Failure 400 {object} common.Result "个人资料填写错误"
+// @Router /user/user/profile [put]
+func (c *WxUserController) UpdateProfile(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.UserProfileDTO
+		data response.UserProfileVO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("UpdateProfile bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.UpdateProfile(ctx, dto); err != nil {
+		profileFailed(ctx, "UpdateProfile", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// UploadAvatar @UploadAvatar 上传头像，返回头像地址
+// @Tags WxUser
+// @Security JWTAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "头像图片"
+// @Success 200 {object} common.Result{Data=string} "success"
+// @Failure 400 {object} common.Result "图片格式或大小不符合要求"
+// @Router /user/user/avatar [post]
+func (c *WxUserController) UploadAvatar(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		data string
+		err  error
+	)
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		global.Log.Debug("UploadAvatar bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if data, err = c.service.UploadAvatar(ctx, file); err != nil {
+		profileFailed(ctx, "UploadAvatar", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Data: data, Msg: e.GetMsg(code)})
+}
+
+// DeleteAccount @DeleteAccount 注销账号，个人信息匿名化，订单金额等财务记录保留
+// @Tags WxUser
+// @Security JWTAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AccountDeleteDTO true "已绑定手机号时的短信验证码"
+// @Success 200 {object} common.Result "success"
+// @Failure 409 {object} common.Result "存在进行中的订单或售后"
+// @Router /user/user/account [delete]
+func (c *WxUserController) DeleteAccount(ctx *gin.Context) {
+	var (
+		code = e.SUCCESS
+		dto  request.AccountDeleteDTO
+		err  error
+	)
+	if err = ctx.ShouldBindJSON(&dto); err != nil {
+		global.Log.Debug("DeleteAccount bind param error:", err.Error())
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: "Invalid request payload"})
+		return
+	}
+	if err = c.service.DeleteAccount(ctx, dto); err != nil {
+		profileFailed(ctx, "DeleteAccount", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, common.Result{Code: code, Msg: e.GetMsg(code)})
+}
+
+// ExportData @ExportData 以 JSON 文件导出个人数据
+// @Tags WxUser
+// @Security JWTAuth
+// @Produce json
+// @Success 200 {object} response.PersonalDataVO "个人数据"
+// @Router /user/user/export [get]
+func (c *WxUserController) ExportData(ctx *gin.Context) {
+	data, err := c.service.ExportData(ctx)
+	if err != nil {
+		profileFailed(ctx, "ExportData", err)
+		return
+	}
+	fileName := fmt.Sprintf("personal-data-%d-%s.json", data.Profile.Id, data.ExportTime.Format("20060102150405"))
+	ctx.Header("Content-Disposition", "attachment; filename="+fileName)
+	ctx.IndentedJSON(http.StatusOK, data)
+}
+
+// profileFailed 个人资料与注销的业务错误按类型返回 4xx，其余记录日志后返回 500
+func profileFailed(ctx *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, e.Error_PROFILE_INVALID), errors.Is(err, e.Error_AVATAR_INVALID), errors.Is(err, e.Error_SMS_CODE_INVALID):
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_ACCOUNT_NOT_FOUND):
+		ctx.JSON(http.StatusNotFound, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_ACCOUNT_IN_USE):
+		ctx.JSON(http.StatusConflict, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Warn(name+" Error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+}
+
+// phoneFailed 绑定手机号的业务错误按类型返回 4xx，其余记录日志后返回 500
+func phoneFailed(ctx *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, e.Error_PHONE_INVALID), errors.Is(err, e.Error_SMS_CODE_INVALID), errors.Is(err, e.Error_MERGE_CODE_INVALID):
+		ctx.JSON(http.StatusBadRequest, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_WX_SESSION_EXPIRED):
+		ctx.JSON(http.StatusUnauthorized, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_ACCOUNT_NOT_FOUND):
+		ctx.JSON(http.StatusNotFound, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	case errors.Is(err, e.Error_SMS_TOO_FREQUENT), errors.Is(err, e.Error_SMS_DAILY_LIMIT):
+		ctx.JSON(http.StatusTooManyRequests, common.Result{Code: e.ERROR, Msg: err.Error()})
+		return
+	}
+	global.Log.Warn(name+" Error:", err.Error())
+	ctx.JSON(http.StatusInternalServerError, common.Result{Code: e.ERROR, Msg: e.GetMsg(e.ERROR)})
+}
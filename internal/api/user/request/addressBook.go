@@ -29,4 +29,7 @@ type AddressBookDTO struct {
 	Label int `json:"label"`
 	// 是否默认 0否 1是
 	IsDefault int `json:"isDefault"`
+	// 客户端地图选点的经纬度，为空时按地址进行地理编码
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
 }
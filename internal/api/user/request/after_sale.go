@@ -0,0 +1,17 @@
+package request
+
+// AfterSaleSubmitDTO 申请售后
+type AfterSaleSubmitDTO struct {
+	OrderId     int                `json:"orderId" binding:"required"`
+	Type        int                `json:"type" binding:"required"` // 1 退款 2 重做
+	Reason      string             `json:"reason" binding:"required"`
+	Description string             `json:"description"`
+	Images      []string           `json:"images"` // 通过 /user/common/upload 上传后的地址
+	Items       []AfterSaleItemDTO `json:"items" binding:"required"`
+}
+
+// AfterSaleItemDTO 申请售后的订单明细及数量
+type AfterSaleItemDTO struct {
+	OrderDetailId int `json:"orderDetailId" binding:"required"`
+	Number        int `json:"number" binding:"required"`
+}
@@ -0,0 +1,12 @@
+package request
+
+// FavoriteDTO 收藏/取消收藏参数，DishId 与 SetmealId 二选一
+type FavoriteDTO struct {
+	DishId    uint64 `json:"dishId" form:"dishId"`
+	SetmealId uint64 `json:"setmealId" form:"setmealId"`
+}
+
+// RecommendDTO 推荐查询参数
+type RecommendDTO struct {
+	Limit int `form:"limit"`
+}
@@ -0,0 +1,25 @@
+package request
+
+// GroupCreateDTO 发起拼单
+type GroupCreateDTO struct {
+	Name      string `json:"name"`
+	Nickname  string `json:"nickname"`  // 发起人在拼单中显示的昵称
+	SplitBill bool   `json:"splitBill"` // 下单后按成员分别支付
+}
+
+// GroupJoinDTO 加入拼单
+type GroupJoinDTO struct {
+	Nickname string `json:"nickname"`
+}
+
+// GroupCheckoutDTO 发起人锁定拼单后下单，拼单不支持预约与堂食
+type GroupCheckoutDTO struct {
+	OrderType       int     `json:"orderType"` // 1外卖（默认） 2自取
+	AddressBookId   int     `json:"addressBookId"`
+	PackAmount      float64 `json:"packAmount"`
+	PayMethod       int     `json:"payMethod"`
+	Remark          string  `json:"remark"`
+	TablewareNumber int     `json:"tablewareNumber"`
+	TablewareStatus int     `json:"tablewareStatus"`
+	SplitBill       *bool   `json:"splitBill"` // 为空时沿用发起时的设置
+}
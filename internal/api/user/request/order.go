@@ -15,6 +15,12 @@ type OrderSubmitDTO struct {
 	Remark                string          `json:"remark"`
 	TablewareNumber       int             `json:"tablewareNumber"`
 	TablewareStatus       int             `json:"tablewareStatus"`
+	// 订单类型 1外卖（默认） 2自取 3堂食，自取与堂食无需收货地址
+	OrderType  int    `json:"orderType"`
+	TableId    uint64 `json:"tableId"`   // 堂食扫码得到的桌台
+	TableCode  string `json:"tableCode"` // 桌台二维码校验码
+	TableLabel string `json:"-"`         // 以下由服务端填写
+	PickupCode string `json:"-"`
 }
 
 // OrderRejectionDTO 拒单接收数据模型
@@ -37,6 +43,7 @@ type OrderPageQueryDTO struct {
 	Number    string `form:"number"`
 	Phone     string `form:"phone"`
 	Status    int    `form:"status"`
+	OrderType int    `form:"orderType"`
 	BeginTime string `form:"beginTime"`
 	EndTime   string `form:"endTime"`
 }
@@ -58,3 +65,8 @@ type PageQueryOrderDTO struct {
 	PageSize string `form:"pageSize"`
 	Status   string `form:"status"`
 }
+
+// OrderPickupDTO 核销取餐码
+type OrderPickupDTO struct {
+	PickupCode string `json:"pickupCode" binding:"required"`
+}
@@ -0,0 +1,26 @@
+package request
+
+// ReviewSubmitDTO 评价订单参数，Items 为对订单中菜品/套餐的单独评价
+type ReviewSubmitDTO struct {
+	OrderId int             `json:"orderId" binding:"required"`
+	Rating  int             `json:"rating" binding:"required"`
+	Content string          `json:"content"`
+	Images  []string        `json:"images"` // 通过 /user/common/upload 上传后得到的地址
+	Items   []ReviewItemDTO `json:"items"`
+}
+
+// ReviewItemDTO 订单明细评价参数
+type ReviewItemDTO struct {
+	OrderDetailId int      `json:"orderDetailId" binding:"required"`
+	Rating        int      `json:"rating" binding:"required"`
+	Content       string   `json:"content"`
+	Images        []string `json:"images"`
+}
+
+// ReviewPageQueryDTO 菜品/套餐评价分页查询参数
+type ReviewPageQueryDTO struct {
+	Page      int `form:"page"`
+	PageSize  int `form:"pageSize"`
+	DishId    int `form:"dishId"`
+	SetmealId int `form:"setmealId"`
+}
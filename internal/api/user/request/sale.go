@@ -4,4 +4,5 @@ package request
 type GoodsSalesDTO struct {
 	Name   string
 	Number int
+	Amount float64
 }
@@ -0,0 +1,7 @@
+package request
+
+// SearchDTO 菜品/套餐搜索参数
+type SearchDTO struct {
+	Keyword string `form:"keyword" binding:"required"` // 关键字，支持汉字、全拼与拼音首字母
+	Limit   int    `form:"limit"`                      // 返回条数，默认20
+}
@@ -6,3 +6,8 @@ type ShoppingCartDTO struct {
 	SetmealId  int    `json:"setmealId"`
 	DishFlavor string `json:"dishFlavor"`
 }
+
+// CartValidateDTO 校验购物车，订单类型决定是否需要满足起送金额
+type CartValidateDTO struct {
+	OrderType int `form:"orderType"`
+}
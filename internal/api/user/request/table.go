@@ -0,0 +1,8 @@
+package request
+
+// TableScanDTO 扫描桌台二维码得到的参数
+type TableScanDTO struct {
+	Shop  int    `form:"shop"`
+	Table uint64 `form:"table" binding:"required"`
+	Code  string `form:"code" binding:"required"`
+}
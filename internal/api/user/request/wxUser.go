@@ -3,3 +3,34 @@ package request
 type WxUserLoginDTO struct {
 	Code string `json:"code" binding:"required` // 微信授权码
 }
+
+// PhoneCodeDTO 发送短信验证码
+type PhoneCodeDTO struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+// PhoneSmsBindDTO 通过短信验证码绑定手机号
+type PhoneSmsBindDTO struct {
+	Phone string `json:"phone" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+	// MergeCode 该手机号原账号生成的合并码，提供时将原账号合并到当前账号
+	MergeCode string `json:"mergeCode"`
+}
+
+// PhoneWechatBindDTO 通过微信 getPhoneNumber 返回的加密数据绑定手机号
+type PhoneWechatBindDTO struct {
+	EncryptedData string `json:"encryptedData" binding:"required"`
+	Iv            string `json:"iv" binding:"required"`
+	MergeCode     string `json:"mergeCode"`
+}
+
+// UserProfileDTO 修改个人资料
+type UserProfileDTO struct {
+	Name string `json:"name"`
+	Sex  string `json:"sex"` // 0 女 1 男，为空表示不填写
+}
+
+// AccountDeleteDTO 注销账号，已绑定手机号时需要短信验证码
+type AccountDeleteDTO struct {
+	Code string `json:"code"`
+}
@@ -0,0 +1,27 @@
+package response
+
+import "time"
+
+// FavoriteVO 收藏列表返回数据模型
+type FavoriteVO struct {
+	Id         uint64    `json:"id"`
+	Type       string    `json:"type"`   // dish 菜品 setmeal 套餐
+	ItemId     uint64    `json:"itemId"` // 菜品/套餐id
+	Name       string    `json:"name"`
+	Image      string    `json:"image"`
+	Price      float64   `json:"price"`
+	Status     int       `json:"status"` // 1 起售 0 停售
+	CreateTime time.Time `json:"createTime"`
+}
+
+// RecommendItemVO 推荐结果返回数据模型
+type RecommendItemVO struct {
+	Type        string  `json:"type"` // dish 菜品 setmeal 套餐
+	Id          uint64  `json:"id"`
+	CategoryId  uint64  `json:"categoryId"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Image       string  `json:"image"`
+	Price       float64 `json:"price"`
+	Source      string  `json:"source"` // personal 猜你喜欢 hot 热销
+}
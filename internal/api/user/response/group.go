@@ -0,0 +1,37 @@
+package response
+
+import "takeout/internal/model"
+
+// GroupCartVO 拼单详情，菜品按成员分组
+type GroupCartVO struct {
+	Code         string               `json:"code"`
+	Name         string               `json:"name"`
+	OwnerId      int                  `json:"ownerId"`
+	IsOwner      bool                 `json:"isOwner"`
+	IsMember     bool                 `json:"isMember"`
+	Status       int                  `json:"status"`
+	SplitBill    bool                 `json:"splitBill"`
+	OrderId      int                  `json:"orderId"`
+	ExpireTime   model.LocalTime      `json:"expireTime"`
+	InviteURL    string               `json:"inviteUrl"`
+	Participants []GroupParticipantVO `json:"participants"`
+	Number       int                  `json:"number"`
+	Total        float64              `json:"total"`
+	Payments     []model.GroupPayment `json:"payments"`
+}
+
+// GroupParticipantVO 成员及其菜品小计
+type GroupParticipantVO struct {
+	UserId   int                   `json:"userId"`
+	Nickname string                `json:"nickname"`
+	IsOwner  bool                  `json:"isOwner"`
+	Lines    []model.GroupCartItem `json:"lines"`
+	Number   int                   `json:"number"`
+	Subtotal float64               `json:"subtotal"`
+}
+
+// GroupCheckoutVO 拼单下单结果，AA 支付时附带每个成员的应付金额
+type GroupCheckoutVO struct {
+	OrderSubmitVO
+	Payments []model.GroupPayment `json:"payments"`
+}
@@ -42,6 +42,7 @@ type OrderStatisticsVO struct {
 	ToBeConfirmed      int `json:"toBeConfirmed"`
 	Confirmed          int `json:"confirmed"`
 	DeliveryInProgress int `json:"deliveryInProgress"`
+	Scheduled          int `json:"scheduled"` // 尚未推送的预约订单
 }
 
 // OrderSubmitVO 用户下单接口返回结果
@@ -58,3 +59,40 @@ type OrderVO struct {
 	OrderDishes     string              `json:"orderDishes"`
 	OrderDetailList []model.OrderDetail `json:"orderDetailList"`
 }
+
+// SlotVO 预约送达时段
+type SlotVO struct {
+	Start     model.LocalTime `json:"start"`
+	End       model.LocalTime `json:"end"`
+	Label     string          `json:"label"`
+	Remaining int             `json:"remaining"`
+	Available bool            `json:"available"`
+}
+
+// SlotDayVO 某天的预约送达时段
+type SlotDayVO struct {
+	Date  string   `json:"date"`
+	Slots []SlotVO `json:"slots"`
+}
+
+// RepetitionVO 再来一单结果，商品按当前价格合并到购物车
+type RepetitionVO struct {
+	Added   []RepetitionItemVO `json:"added"`   // 按原样加入
+	Changed []RepetitionItemVO `json:"changed"` // 变价、替换为重新上架的同名商品、口味调整或受限购减少数量后加入
+	Skipped []RepetitionItemVO `json:"skipped"` // 未加入
+	Number  int                `json:"number"`  // 加入后的购物车商品数量
+	Total   float64            `json:"total"`   // 加入后的购物车合计
+}
+
+// RepetitionItemVO 原订单中的一项商品
+type RepetitionItemVO struct {
+	Name       string   `json:"name"`
+	DishId     int      `json:"dishId"`
+	SetmealId  int      `json:"setmealId"`
+	DishFlavor string   `json:"dishFlavor"`
+	Requested  int      `json:"requested"` // 原订单数量
+	Number     int      `json:"number"`    // 实际加入数量
+	OldAmount  float64  `json:"oldAmount"` // 原订单单价
+	Amount     float64  `json:"amount"`    // 当前单价
+	Reasons    []string `json:"reasons,omitempty"`
+}
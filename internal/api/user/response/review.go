@@ -0,0 +1,16 @@
+package response
+
+import "takeout/internal/model"
+
+// ReviewVO 评价返回数据模型，图片拆分为数组
+type ReviewVO struct {
+	model.Review
+	Images []string `json:"images"`
+	Name   string   `json:"name"` // 评价的菜品/套餐名称
+}
+
+// OrderReviewVO 订单评价详情
+type OrderReviewVO struct {
+	Order ReviewVO   `json:"order"`
+	Items []ReviewVO `json:"items"`
+}
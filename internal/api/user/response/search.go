@@ -0,0 +1,13 @@
+package response
+
+// SearchItemVO 搜索结果
+type SearchItemVO struct {
+	Type        string  `json:"type"` // dish | setmeal
+	Id          uint64  `json:"id"`
+	CategoryId  uint64  `json:"categoryId"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Image       string  `json:"image"`
+	Price       float64 `json:"price"`
+	Sales       int64   `json:"sales"`
+}
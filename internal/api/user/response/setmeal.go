@@ -1,8 +1,17 @@
 package response
 
+import "takeout/internal/model"
+
 type DishItemVO struct {
 	Copies      int    `json:"copies"`
 	Description string `json:"description"`
 	Image       string `json:"image"`
 	Name        string `json:"name"`
 }
+
+// SetmealVO 套餐列表返回数据模型，附带评分汇总
+type SetmealVO struct {
+	model.SetMeal
+	AvgRating   float64 `json:"avgRating"`   // 平均评分
+	RatingCount int64   `json:"ratingCount"` // 评价数
+}
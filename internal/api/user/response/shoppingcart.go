@@ -0,0 +1,24 @@
+package response
+
+import "takeout/internal/model"
+
+// CartValidateVO 购物车校验结果，变价的商品已按当前价格更新
+type CartValidateVO struct {
+	Valid     bool         `json:"valid"` // 可以直接下单
+	Lines     []CartLineVO `json:"lines"`
+	Number    int          `json:"number"`    // 可下单商品数量
+	Total     float64      `json:"total"`     // 可下单商品合计
+	MinAmount float64      `json:"minAmount"` // 起送金额
+	Shortfall float64      `json:"shortfall"` // 距起送金额还差
+	Repriced  bool         `json:"repriced"`  // 有商品变价
+}
+
+// CartLineVO 购物车行校验结果
+type CartLineVO struct {
+	model.ShoppingCart
+	Status      string  `json:"status"` // ok 正常 unavailable 已下架或口味失效 repriced 已变价 exceeded 超过限购
+	Reason      string  `json:"reason,omitempty"`
+	OldAmount   float64 `json:"oldAmount,omitempty"` // 变价前单价
+	Diff        float64 `json:"diff,omitempty"`      // 单价变化，涨价为正
+	MaxQuantity int     `json:"maxQuantity,omitempty"`
+}
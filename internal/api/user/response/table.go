@@ -0,0 +1,9 @@
+package response
+
+// TableScanVO 扫码点餐返回的桌台信息，下单时带上 tableId 与 code
+type TableScanVO struct {
+	TableId   uint64 `json:"tableId"`
+	TableName string `json:"tableName"`
+	Seats     int    `json:"seats"`
+	Code      string `json:"code"`
+}
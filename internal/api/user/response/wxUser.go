@@ -1,7 +1,55 @@
 package response
 
+import (
+	"takeout/internal/model"
+	"time"
+)
+
 type WxUserVO struct {
 	Id     int64  `json:"id"`     // 用户id
 	Openid string `json:"openid"` // 微信用户openid
 	Token  string `json:"token"`  // jwt令牌
+	Phone  string `json:"phone"`  // 已绑定的手机号，为空时需要绑定
+}
+
+// PhoneBindVO 绑定手机号结果
+type PhoneBindVO struct {
+	Phone string `json:"phone"`
+	// Merged 合并到当前账号的重复账号数，这些账号的订单、地址等已转移到当前账号
+	Merged int `json:"merged"`
+	// Unbound 未确认合并的原账号数，这些账号只解除手机号绑定，数据保持不变
+	Unbound int `json:"unbound"`
+}
+
+// MergeCodeVO 合并码，在另一个账号绑定同一手机号时填写，确认将本账号合并过去
+type MergeCodeVO struct {
+	Code     string `json:"code"`
+	ExpireIn int    `json:"expireIn"` // 有效秒数
+}
+
+// UserProfileVO 个人资料
+type UserProfileVO struct {
+	Id         int       `json:"id"`
+	Name       string    `json:"name"`
+	Phone      string    `json:"phone"`
+	Sex        string    `json:"sex"`
+	Avatar     string    `json:"avatar"`
+	CreateTime time.Time `json:"createTime"`
+}
+
+// PersonalDataVO 个人数据导出
+type PersonalDataVO struct {
+	ExportTime time.Time           `json:"exportTime"`
+	Profile    UserProfileVO       `json:"profile"`
+	Addresses  []model.AddressBook `json:"addresses"`
+	Orders     []PersonalOrderVO   `json:"orders"`
+	Reviews    []model.Review      `json:"reviews"`
+	Favorites  []model.Favorite    `json:"favorites"`
+	AfterSales []model.AfterSale   `json:"afterSales"`
+}
+
+// PersonalOrderVO 导出的订单及明细
+type PersonalOrderVO struct {
+	model.Order
+	Details []model.OrderDetail `json:"details"`
 }
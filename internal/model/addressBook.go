@@ -29,6 +29,9 @@ type AddressBook struct {
 	Label string `json:"label"`
 	// 是否默认 0否 1是
 	IsDefault int `json:"isDefault"`
+	// 经纬度，地理编码没有结果时为 0
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
 }
 
 // TableName 指定表名
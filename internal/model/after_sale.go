@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// AfterSale 售后单，用户针对已完成订单中的菜品/套餐发起，商家同意后部分退款或重做
+type AfterSale struct {
+	Id            uint64          `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	OrderId       int             `json:"orderId"`
+	OrderNumber   string          `json:"orderNumber"`
+	UserId        int             `json:"userId"`
+	Type          int             `json:"type"`   // 用户诉求 1 退款 2 重做
+	Reason        string          `json:"reason"` // 问题类型
+	Description   string          `json:"description"`
+	Images        string          `json:"images"` // 图片地址，多个以逗号分隔
+	Amount        float64         `json:"amount"` // 申请售后的商品金额
+	Status        int             `json:"status"`
+	Resolution    int             `json:"resolution"` // 处理方式 1 部分退款 2 重做
+	RefundAmount  float64         `json:"refundAmount"`
+	RemakeOrderId int             `json:"remakeOrderId"`
+	Reply         string          `json:"reply"` // 商家处理说明
+	HandleUser    uint64          `json:"handleUser"`
+	HandleTime    LocalTime       `json:"handleTime"`
+	DueTime       time.Time       `json:"dueTime"`       // 处理时限
+	EscalateLevel int             `json:"escalateLevel"` // 超时升级次数
+	CreateTime    time.Time       `json:"createTime" gorm:"autoCreateTime"`
+	UpdateTime    time.Time       `json:"updateTime" gorm:"autoUpdateTime"`
+	Items         []AfterSaleItem `json:"items" gorm:"foreignKey:AfterSaleId"`
+}
+
+func (a *AfterSale) TableName() string {
+	return "after_sale"
+}
+
+// AfterSaleItem 售后商品，对应订单明细中的部分或全部数量
+type AfterSaleItem struct {
+	Id            uint64  `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	AfterSaleId   uint64  `json:"afterSaleId"`
+	OrderDetailId int     `json:"orderDetailId"`
+	Name          string  `json:"name"`
+	Image         string  `json:"image"`
+	DishId        int     `json:"dishId"`
+	SetmealId     int     `json:"setmealId"`
+	DishFlavor    string  `json:"dishFlavor"`
+	Number        int     `json:"number"`
+	Amount        float64 `json:"amount"` // 单价
+}
+
+func (a *AfterSaleItem) TableName() string {
+	return "after_sale_item"
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// DailyBusinessStats 每日营业数据预聚合，按下单日期统计
+type DailyBusinessStats struct {
+	StatDate        time.Time `json:"statDate" gorm:"primaryKey;type:date"`
+	Turnover        float64   `json:"turnover"`        // 营业额（已完成订单金额）
+	OrderCount      int       `json:"orderCount"`      // 订单总数
+	ValidOrderCount int       `json:"validOrderCount"` // 有效订单数（已完成）
+	NewUsers        int       `json:"newUsers"`        // 新增用户数
+	UpdateTime      time.Time `json:"updateTime" gorm:"autoUpdateTime"`
+}
+
+func (s *DailyBusinessStats) TableName() string {
+	return "daily_business_stats"
+}
+
+// HourlyBusinessStats 按下单小时实时聚合的营业数据，不落表
+type HourlyBusinessStats struct {
+	Hour            time.Time // 服务器本地时区的整点
+	Turnover        float64
+	OrderCount      int
+	ValidOrderCount int
+	NewUsers        int
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Favorite 用户收藏，DishId 与 SetmealId 二选一
+type Favorite struct {
+	Id         uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	UserId     int       `json:"userId"`
+	DishId     uint64    `json:"dishId"`
+	SetmealId  uint64    `json:"setmealId"`
+	CreateTime time.Time `json:"createTime"`
+}
+
+func (f *Favorite) BeforeCreate(tx *gorm.DB) error {
+	f.CreateTime = time.Now()
+	return nil
+}
+
+func (f *Favorite) TableName() string {
+	return "favorite"
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// GroupCart 多人拼单的共享购物车，成员通过邀请码加入并各自添加菜品，由发起人锁定后统一下单
+type GroupCart struct {
+	Id         uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	Code       string    `json:"code"` // 邀请码
+	OwnerId    int       `json:"ownerId"`
+	Name       string    `json:"name"`
+	Status     int       `json:"status"`    // 0 进行中 1 已锁定 2 已下单 3 已取消
+	SplitBill  bool      `json:"splitBill"` // 下单后按成员分别支付
+	OrderId    int       `json:"orderId"`
+	ExpireTime time.Time `json:"expireTime"`
+	CreateTime time.Time `json:"createTime"`
+	UpdateTime time.Time `json:"updateTime"`
+}
+
+func (g *GroupCart) BeforeCreate(tx *gorm.DB) error {
+	g.CreateTime = time.Now()
+	g.UpdateTime = time.Now()
+	return nil
+}
+
+func (g *GroupCart) BeforeUpdate(tx *gorm.DB) error {
+	g.UpdateTime = time.Now()
+	return nil
+}
+
+func (g *GroupCart) TableName() string {
+	return "group_cart"
+}
+
+// GroupMember 拼单成员
+type GroupMember struct {
+	Id       uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	GroupId  uint64    `json:"groupId"`
+	UserId   int       `json:"userId"`
+	Nickname string    `json:"nickname"`
+	JoinTime time.Time `json:"joinTime"`
+}
+
+func (m *GroupMember) BeforeCreate(tx *gorm.DB) error {
+	m.JoinTime = time.Now()
+	return nil
+}
+
+func (m *GroupMember) TableName() string {
+	return "group_cart_member"
+}
+
+// GroupCartItem 拼单中某个成员添加的菜品或套餐，Amount 为单价
+type GroupCartItem struct {
+	Id         uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	GroupId    uint64    `json:"groupId"`
+	UserId     int       `json:"userId"`
+	Name       string    `json:"name"`
+	Image      string    `json:"image"`
+	DishId     int       `json:"dishId"`
+	SetmealId  int       `json:"setmealId"`
+	DishFlavor string    `json:"dishFlavor"`
+	Number     int       `json:"number"`
+	Amount     float64   `json:"amount"`
+	CreateTime time.Time `json:"createTime" gorm:"autoCreateTime"`
+}
+
+func (i *GroupCartItem) TableName() string {
+	return "group_cart_item"
+}
+
+// GroupPayment AA 支付时每个成员应付的份额，全部支付后订单视为已支付
+type GroupPayment struct {
+	Id         uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	GroupId    uint64    `json:"groupId"`
+	OrderId    int       `json:"orderId"`
+	UserId     int       `json:"userId"`
+	Amount     float64   `json:"amount"`
+	PayStatus  int       `json:"payStatus"` // 0 未支付 1 已支付 2 已退款
+	PayTime    LocalTime `json:"payTime"`
+	CreateTime LocalTime `json:"createTime"`
+}
+
+func (p *GroupPayment) BeforeCreate(tx *gorm.DB) error {
+	p.CreateTime = LocalTime(time.Now())
+	return nil
+}
+
+func (p *GroupPayment) TableName() string {
+	return "group_payment"
+}
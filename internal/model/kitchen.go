@@ -0,0 +1,82 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// KitchenStation 后厨工位，如热菜、凉菜、饮品
+type KitchenStation struct {
+	Id         uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	Name       string    `json:"name"`
+	Sort       int       `json:"sort"`
+	Status     int       `json:"status"` // 1 启用 0 停用
+	CreateTime time.Time `json:"createTime"`
+	UpdateTime time.Time `json:"updateTime"`
+	// 分配到该工位的分类
+	CategoryIds []uint64 `json:"categoryIds" gorm:"-"`
+}
+
+func (s *KitchenStation) BeforeCreate(tx *gorm.DB) error {
+	s.CreateTime = time.Now()
+	s.UpdateTime = time.Now()
+	return nil
+}
+
+func (s *KitchenStation) BeforeUpdate(tx *gorm.DB) error {
+	s.UpdateTime = time.Now()
+	return nil
+}
+
+func (s *KitchenStation) TableName() string {
+	return "kitchen_station"
+}
+
+// KitchenStationCategory 分类 -> 工位，一个分类只属于一个工位
+type KitchenStationCategory struct {
+	CategoryId uint64 `json:"categoryId" gorm:"primaryKey"`
+	StationId  uint64 `json:"stationId"`
+}
+
+func (c *KitchenStationCategory) TableName() string {
+	return "kitchen_station_category"
+}
+
+// KitchenOrder 已接单订单的后厨单，Status 为全部出品项的汇总状态
+type KitchenOrder struct {
+	OrderId     int       `json:"orderId" gorm:"primaryKey"`
+	OrderNumber string    `json:"orderNumber"`
+	Remark      string    `json:"remark"`
+	Status      int       `json:"status"` // 0 待制作 1 制作中 2 已出品待派送
+	CreateTime  time.Time `json:"createTime"`
+	ReadyTime   LocalTime `json:"readyTime"`
+}
+
+func (o *KitchenOrder) BeforeCreate(tx *gorm.DB) error {
+	o.CreateTime = time.Now()
+	return nil
+}
+
+func (o *KitchenOrder) TableName() string {
+	return "kitchen_order"
+}
+
+// KitchenItem 后厨出品项：菜品明细直接对应，套餐明细按套餐内菜品拆分
+type KitchenItem struct {
+	Id            uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	OrderId       int       `json:"orderId"`
+	OrderDetailId int       `json:"orderDetailId"`
+	StationId     uint64    `json:"stationId"` // 0 表示所属分类未分配工位
+	DishId        uint64    `json:"dishId"`
+	Name          string    `json:"name"`
+	SetmealName   string    `json:"setmealName"` // 来自套餐时为套餐名称
+	DishFlavor    string    `json:"dishFlavor"`
+	Number        int       `json:"number"`
+	Status        int       `json:"status"` // 0 待制作 1 制作中 2 已出品
+	StartTime     LocalTime `json:"startTime"`
+	DoneTime      LocalTime `json:"doneTime"`
+}
+
+func (i *KitchenItem) TableName() string {
+	return "kitchen_item"
+}
@@ -51,6 +51,10 @@ func (t LocalTime) Value() (driver.Value, error) {
 
 // Scan 实现在数据查询出来之前对数据进⾏相关操作
 func (t *LocalTime) Scan(v interface{}) error {
+	if v == nil {
+		*t = LocalTime{}
+		return nil
+	}
 	if value, ok := v.(time.Time); ok {
 		*t = LocalTime(value)
 		return nil
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Media 已上传的文件，同一个 key 只有一条记录
+// RefCount 由清理任务统计菜品、套餐、评价、售后、头像以及订单明细等图片快照中的引用得到
+type Media struct {
+	Id          uint64     `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	Category    string     `json:"category"` // 上传目录 images 后台 reviews 用户 avatar 头像，legacy 为补录的旧文件
+	ObjectKey   string     `json:"key"`
+	Url         string     `json:"url"`
+	Hash        string     `json:"hash"`
+	ContentType string     `json:"contentType"`
+	Size        int64      `json:"size"`
+	Width       int        `json:"width"`
+	Height      int        `json:"height"`
+	Variants    string     `json:"-"` // 缩放规格，JSON 格式的 规格名 -> key
+	RefCount    int        `json:"refCount"`
+	UnusedSince *time.Time `json:"unusedSince"` // 引用数变为 0 的时间，超过保留期后删除
+	CreateTime  time.Time  `json:"createTime"`
+}
+
+func (m *Media) BeforeCreate(tx *gorm.DB) error {
+	m.CreateTime = time.Now()
+	return nil
+}
+
+func (m *Media) TableName() string {
+	return "media"
+}
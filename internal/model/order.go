@@ -1,5 +1,10 @@
 package model
 
+import (
+	"takeout/common/enum"
+	"time"
+)
+
 // Order 订单数据模型
 type Order struct {
 	Id            int    `json:"id" gorm:"primary_key;AUTO_INCREMENT"`
@@ -27,6 +32,27 @@ type Order struct {
 	PackAmount            float64   `json:"packAmount"`
 	TablewareNumber       int       `json:"tablewareNumber"`
 	TablewareStatus       int       `json:"tablewareStatus"`
+	// 订单类型 1外卖 2自取 3堂食，历史订单为 0 按外卖处理
+	OrderType  int       `json:"orderType"`
+	TableId    uint64    `json:"tableId"`    // 堂食桌台
+	TableLabel string    `json:"tableLabel"` // 下单时的区域与桌台名称
+	PickupCode string    `json:"pickupCode"` // 自取与堂食的取餐码，每日重新编号
+	ReadyTime  LocalTime `json:"readyTime"`  // 出餐通知时间
+	// 预约订单推送给商家与未接单提醒的时间
+	ReleaseTime LocalTime `json:"releaseTime"`
+	RemindTime  LocalTime `json:"remindTime"`
+	// 售后重做订单对应的原订单，重做订单为零元订单，不计入营业统计
+	RemakeOf int `json:"remakeOf"`
+}
+
+// IsScheduled 是否为预约订单
+func (o *Order) IsScheduled() bool {
+	return o.DeliveryStatus == enum.DeliverAtTime && !time.Time(o.EstimatedDeliveryTime).IsZero()
+}
+
+// Delivers 是否需要派送，堂食与自取订单不经过派送中
+func (o *Order) Delivers() bool {
+	return o.OrderType != enum.OrderTypePickup && o.OrderType != enum.OrderTypeDineIn
 }
 
 // TableName 指定表名
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// Printer 小票打印机配置，每台打印机打印 Kinds 中的小票类型
+type Printer struct {
+	Id         uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	Name       string    `json:"name"`
+	Driver     string    `json:"driver"`   // network | file
+	Address    string    `json:"address"`  // host:port 或 file 驱动的子目录名
+	Format     string    `json:"format"`   // escpos | text | html
+	Width      int       `json:"width"`    // 每行字符数，58mm 为 32，80mm 为 48
+	Encoding   string    `json:"encoding"` // escpos 文字编码
+	Cut        bool      `json:"cut"`
+	Copies     int       `json:"copies"`
+	Kinds      string    `json:"kinds"`     // 逗号分隔：kitchen,customer
+	AutoPrint  int       `json:"autoPrint"` // 1 接单时自动打印
+	Title      string    `json:"title"`     // 顾客联抬头，一般为门店名称
+	Footer     string    `json:"footer"`
+	Template   string    `json:"template"` // text/html 自定义模板，为空使用内置模板
+	Status     int       `json:"status"`   // 1 启用 0 停用
+	CreateTime time.Time `json:"createTime"`
+	UpdateTime time.Time `json:"updateTime"`
+}
+
+func (p *Printer) BeforeCreate(tx *gorm.DB) error {
+	p.CreateTime = time.Now()
+	p.UpdateTime = time.Now()
+	return nil
+}
+
+func (p *Printer) BeforeUpdate(tx *gorm.DB) error {
+	p.UpdateTime = time.Now()
+	return nil
+}
+
+func (p *Printer) TableName() string {
+	return "printer"
+}
+
+// Prints 是否打印该类型的小票
+func (p *Printer) Prints(kind string) bool {
+	for _, k := range strings.Split(p.Kinds, ",") {
+		if strings.TrimSpace(k) == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintJob 打印任务，由打印队列按 NextTime 取出并在失败时退避重试
+type PrintJob struct {
+	Id          uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	PrinterId   uint64    `json:"printerId"`
+	OrderId     int       `json:"orderId"`
+	OrderNumber string    `json:"orderNumber"`
+	Kind        string    `json:"kind"` // kitchen | customer
+	Reprint     bool      `json:"reprint"`
+	Status      int       `json:"status"` // 0 待打印 1 打印中 2 已打印 3 失败
+	Attempts    int       `json:"attempts"`
+	NextTime    LocalTime `json:"nextTime"` // 下次尝试时间，打印中时为租约到期时间
+	LastError   string    `json:"lastError"`
+	CreateTime  LocalTime `json:"createTime"`
+	PrintTime   LocalTime `json:"printTime"`
+}
+
+func (j *PrintJob) BeforeCreate(tx *gorm.DB) error {
+	j.CreateTime = LocalTime(time.Now())
+	return nil
+}
+
+func (j *PrintJob) TableName() string {
+	return "print_job"
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// ReportSchedule 营业报表定时推送计划
+type ReportSchedule struct {
+	Id          uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	Name        string    `json:"name"`
+	Frequency   string    `json:"frequency"` // daily 推送前一天 weekly 推送上一周
+	SendTime    string    `json:"sendTime"`  // 推送时间 HH:MM
+	Weekday     int       `json:"weekday"`   // 周报推送星期 1~7，周一为 1
+	TimeZone    string    `json:"timeZone"`  // IANA 时区，为空使用服务器时区
+	Status      int       `json:"status"`    // 1 启用 0 停用
+	LastRunTime LocalTime `json:"lastRunTime"`
+	LastStatus  string    `json:"lastStatus"` // success | partial | failed
+	LastError   string    `json:"lastError"`
+	CreateTime  time.Time `json:"createTime"`
+	UpdateTime  time.Time `json:"updateTime"`
+	// 一对多
+	Recipients []ReportRecipient `json:"recipients" gorm:"foreignKey:ScheduleId"`
+}
+
+func (s *ReportSchedule) BeforeCreate(tx *gorm.DB) error {
+	s.CreateTime = time.Now()
+	s.UpdateTime = time.Now()
+	return nil
+}
+
+func (s *ReportSchedule) BeforeUpdate(tx *gorm.DB) error {
+	s.UpdateTime = time.Now()
+	return nil
+}
+
+func (s *ReportSchedule) TableName() string {
+	return "report_schedule"
+}
+
+// ReportRecipient 推送计划的接收方
+type ReportRecipient struct {
+	Id         uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	ScheduleId uint64    `json:"scheduleId"`
+	Name       string    `json:"name"`
+	Channel    string    `json:"channel"` // email | webhook | wecom | file
+	Target     string    `json:"target"`  // 邮箱、回调地址或机器人 key
+	CreateTime time.Time `json:"createTime"`
+}
+
+func (r *ReportRecipient) BeforeCreate(tx *gorm.DB) error {
+	r.CreateTime = time.Now()
+	return nil
+}
+
+func (r *ReportRecipient) TableName() string {
+	return "report_recipient"
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Review 评价数据模型
+// OrderDetailId 为 0 表示对整笔订单的评价，否则为对订单中某个菜品/套餐的评价
+type Review struct {
+	Id            uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	OrderId       int       `json:"orderId"`
+	OrderDetailId int       `json:"orderDetailId"`
+	UserId        int       `json:"userId"`
+	DishId        int       `json:"dishId"`
+	SetmealId     int       `json:"setmealId"`
+	Rating        int       `json:"rating"`  // 星级 1~5
+	Content       string    `json:"content"` // 评价内容
+	Images        string    `json:"images"`  // 图片地址，多个以逗号分隔
+	Reply         string    `json:"reply"`   // 商家回复
+	ReplyTime     LocalTime `json:"replyTime"`
+	Status        int       `json:"status"`  // 1 展示 0 隐藏
+	Flagged       int       `json:"flagged"` // 1 被举报待审核
+	CreateTime    time.Time `json:"createTime"`
+}
+
+func (r *Review) BeforeCreate(tx *gorm.DB) error {
+	r.CreateTime = time.Now()
+	return nil
+}
+
+func (r *Review) TableName() string {
+	return "review"
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// DiningTable 堂食桌台，二维码中带有 Code，重新生成 Code 后旧二维码失效
+type DiningTable struct {
+	Id         uint64    `json:"id" gorm:"primaryKey;AUTO_INCREMENT"`
+	Name       string    `json:"name"`
+	Area       string    `json:"area"`
+	Seats      int       `json:"seats"`
+	Code       string    `json:"code"`
+	Status     int       `json:"status"` // 1 启用 0 停用
+	CreateTime time.Time `json:"createTime"`
+	UpdateTime time.Time `json:"updateTime"`
+}
+
+func (t *DiningTable) BeforeCreate(tx *gorm.DB) error {
+	t.CreateTime = time.Now()
+	t.UpdateTime = time.Now()
+	return nil
+}
+
+func (t *DiningTable) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdateTime = time.Now()
+	return nil
+}
+
+func (t *DiningTable) TableName() string {
+	return "dining_table"
+}
+
+// Label 区域加桌台名称，用于小票与订单展示
+func (t *DiningTable) Label() string {
+	if t.Area == "" {
+		return t.Name
+	}
+	return t.Area + t.Name
+}
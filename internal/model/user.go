@@ -14,6 +14,12 @@ type User struct {
 	IdNumber   string    `json:"idNumber"`
 	Avatar     string    `json:"avatar"`
 	CreateTime time.Time `json:"createTime" gorm:"column:create_time;type:datetime;not null"`
+	// 微信开放平台的 unionid，同一主体下的多个应用相同，用于识别重复账号
+	UnionId string `json:"-" gorm:"column:unionid"`
+	// 注销时间，注销后个人信息已匿名化；账号被合并时同样记录
+	DeleteTime *time.Time `json:"-" gorm:"column:delete_time"`
+	// 被合并到的账号，保留 openid 使原微信登录后进入合并后的账号
+	MergedInto *int `json:"-" gorm:"column:merged_into"`
 }
 
 func (u *User) TableName() string {
@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/admin/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type AfterSaleRouter struct {
+	service service.IAfterSaleService
+}
+
+func (ar *AfterSaleRouter) InitApiRouter(router *gin.RouterGroup) {
+	privateRouter := router.Group("afterSale")
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTAdmin())
+	// 依赖注入
+	ar.service = service.NewAfterSaleService(dao.NewAfterSaleDao(global.DB), dao.NewOrderDao(), dao.NewReportDao(global.DB))
+	afterSaleCtl := controller.NewAfterSaleController(ar.service)
+	{
+		// 售后单分页查询
+		privateRouter.GET("page", afterSaleCtl.PageQuery)
+		// 售后统计
+		privateRouter.GET("report", afterSaleCtl.Report)
+		// 查询售后单详情
+		privateRouter.GET(":id", afterSaleCtl.Detail)
+		// 同意售后
+		privateRouter.PUT("approve", afterSaleCtl.Approve)
+		// 拒绝售后
+		privateRouter.PUT("reject", afterSaleCtl.Reject)
+	}
+}
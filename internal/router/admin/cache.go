@@ -0,0 +1,20 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/internal/api/admin/controller"
+	"takeout/middle"
+)
+
+type CacheRouter struct{}
+
+func (cr *CacheRouter) InitApiRouter(parent *gin.RouterGroup) {
+	privateRouter := parent.Group("cache")
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTAdmin())
+	cacheCtrl := new(controller.CacheController)
+	{
+		// 缓存命中指标
+		privateRouter.GET("stats", cacheCtrl.Stats)
+	}
+}
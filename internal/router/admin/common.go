@@ -2,18 +2,26 @@ package admin
 
 import (
 	"github.com/gin-gonic/gin"
+	"takeout/global"
 	"takeout/internal/api/admin/controller"
+	"takeout/internal/service"
 	"takeout/middle"
+	"takeout/repository/dao"
 )
 
-type CommonRouter struct{}
+type CommonRouter struct {
+	service service.IUploadService
+}
 
-func (dr *CommonRouter) InitApiRouter(parent *gin.RouterGroup) {
+func (cr *CommonRouter) InitApiRouter(parent *gin.RouterGroup) {
 	privateRouter := parent.Group("common")
 	// 私有路由使用jwt验证
 	privateRouter.Use(middle.VerifiyJWTAdmin())
-	commCtrl := new(controller.CommonController)
+	// 依赖注入
+	cr.service = service.NewUploadService(dao.NewMediaDao(global.DB))
+	commCtrl := controller.NewCommonController(cr.service, "images")
 	{
 		privateRouter.POST("/upload", commCtrl.Upload)
+		privateRouter.GET("/sign", commCtrl.SignURL)
 	}
 }
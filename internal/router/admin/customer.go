@@ -0,0 +1,34 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/admin/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type CustomerRouter struct {
+	service service.ICustomerAnalyticsService
+}
+
+func (cr *CustomerRouter) InitApiRouter(router *gin.RouterGroup) {
+	// /admin/report/customers
+	privateRouter := router.Group("report/customers")
+
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTAdmin())
+
+	// 依赖注入
+	cr.service = service.NewCustomerAnalyticsService(dao.NewCustomerDao(global.DB))
+	customerCtl := controller.NewCustomerController(cr.service)
+	{
+		// 群组留存表
+		privateRouter.GET("cohorts", customerCtl.Cohorts)
+		// 复购率、下单频次与生命周期价值
+		privateRouter.GET("summary", customerCtl.Summary)
+		// 流失客户
+		privateRouter.GET("churned", customerCtl.Churned)
+	}
+}
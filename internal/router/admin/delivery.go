@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/admin/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type DeliveryRouter struct {
+	service service.IReportDeliveryService
+}
+
+func (rr *DeliveryRouter) InitApiRouter(router *gin.RouterGroup) {
+	// /admin/report/schedule
+	privateRouter := router.Group("report/schedule")
+
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTAdmin())
+
+	// 依赖注入
+	rr.service = service.NewReportDeliveryService(dao.NewReportScheduleDao(global.DB), dao.NewReportDao(global.DB), service.NewNotifiers())
+	scheduleCtl := controller.NewDeliveryController(rr.service)
+	{
+		// 推送计划增删改查
+		privateRouter.GET("list", scheduleCtl.List)
+		privateRouter.GET(":id", scheduleCtl.Get)
+		privateRouter.POST("", scheduleCtl.Add)
+		privateRouter.PUT("", scheduleCtl.Edit)
+		privateRouter.DELETE("", scheduleCtl.Delete)
+		privateRouter.POST("status/:status", scheduleCtl.SetStatus)
+		// 接收方
+		privateRouter.POST("recipient", scheduleCtl.AddRecipient)
+		privateRouter.DELETE("recipient", scheduleCtl.DeleteRecipient)
+		// 立即推送与预览
+		privateRouter.POST(":id/run", scheduleCtl.Run)
+		privateRouter.GET(":id/preview", scheduleCtl.Preview)
+	}
+}
@@ -18,7 +18,7 @@ func (dr *DishRouter) InitApiRouter(parent *gin.RouterGroup) {
 	privateRouter.Use(middle.VerifiyJWTAdmin())
 	// 依赖注入
 	dishCtrl := controller.NewDishController(
-		service.NewDishService(dao.NewDishRepo(global.DB), dao.NewDishFlavorDao()),
+		service.NewDishService(dao.NewDishRepo(global.DB), dao.NewDishFlavorDao(), dao.NewReviewDao(global.DB)),
 	)
 	{
 		privateRouter.POST("", dishCtrl.AddDish)
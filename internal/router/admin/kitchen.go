@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/admin/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type KitchenRouter struct {
+	service service.IKitchenService
+}
+
+func (kr *KitchenRouter) InitApiRouter(router *gin.RouterGroup) {
+	// /admin/kds
+	privateRouter := router.Group("kds")
+
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTAdmin())
+
+	// 依赖注入
+	kr.service = service.NewKitchenService(dao.NewKitchenDao(global.DB))
+	kitchenCtl := controller.NewKitchenController(kr.service)
+	{
+		// 工位管理
+		privateRouter.GET("station/list", kitchenCtl.ListStations)
+		privateRouter.POST("station", kitchenCtl.AddStation)
+		privateRouter.PUT("station", kitchenCtl.EditStation)
+		privateRouter.DELETE("station", kitchenCtl.DeleteStation)
+		// 为工位分配分类
+		privateRouter.PUT("station/categories", kitchenCtl.SetStationCategories)
+		// 后厨看板，实时变更通过 /ws/:id?topics=kds 或 kds:<工位id> 推送
+		privateRouter.GET("board", kitchenCtl.Board)
+		// 出品项开始制作、已出品
+		privateRouter.POST("item/start", kitchenCtl.StartItems)
+		privateRouter.POST("item/done", kitchenCtl.DoneItems)
+		// 划单
+		privateRouter.POST("bump", kitchenCtl.Bump)
+	}
+}
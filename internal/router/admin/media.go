@@ -0,0 +1,27 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/admin/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type MediaRouter struct {
+	service service.IMediaService
+}
+
+func (mr *MediaRouter) InitApiRouter(router *gin.RouterGroup) {
+	privateRouter := router.Group("media")
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTAdmin())
+	// 依赖注入
+	mr.service = service.NewMediaService(dao.NewMediaDao(global.DB))
+	mediaCtl := controller.NewMediaController(mr.service)
+	{
+		// 媒体库分页查询
+		privateRouter.GET("page", mediaCtl.PageQuery)
+	}
+}
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"github.com/gin-gonic/gin"
+	"takeout/global"
 	"takeout/internal/api/admin/controller"
 	"takeout/internal/service"
 	"takeout/middle"
@@ -21,7 +22,7 @@ func (er *OrderRouter) InitApiRouter(router *gin.RouterGroup) {
 	privateRouter.Use(middle.VerifiyJWTAdmin())
 
 	// 依赖注入
-	er.service = service.NewOrderService(dao.NewOrderDao())
+	er.service = service.NewOrderService(dao.NewOrderDao(), dao.NewReportDao(global.DB), dao.NewKitchenDao(global.DB), dao.NewPrintDao(global.DB), dao.NewTableDao(global.DB), dao.NewShoppingCartDao(global.DB))
 	orderCtl := controller.NewOrderController(er.service)
 	{
 		// 接单
@@ -34,6 +35,10 @@ func (er *OrderRouter) InitApiRouter(router *gin.RouterGroup) {
 		privateRouter.PUT("delivery/:id", orderCtl.OrderDelivery)
 		// 完成订单
 		privateRouter.PUT("complete/:id", orderCtl.OrderComplete)
+		// 自取与堂食订单出餐通知
+		privateRouter.PUT("ready/:id", orderCtl.OrderReady)
+		// 核销取餐码
+		privateRouter.PUT("pickup", orderCtl.OrderPickup)
 		// 订单搜索
 		privateRouter.GET("conditionSearch", orderCtl.OrderConditionSearch)
 		// 查询订单详情
@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/admin/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type PrintRouter struct {
+	service service.IPrintService
+}
+
+func (pr *PrintRouter) InitApiRouter(router *gin.RouterGroup) {
+	// /admin/print
+	privateRouter := router.Group("print")
+
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTAdmin())
+
+	// 依赖注入
+	pr.service = service.NewPrintService(dao.NewPrintDao(global.DB), dao.NewOrderDao())
+	printCtl := controller.NewPrintController(pr.service)
+	{
+		// 打印机管理
+		privateRouter.GET("printer/list", printCtl.ListPrinters)
+		privateRouter.POST("printer", printCtl.AddPrinter)
+		privateRouter.PUT("printer", printCtl.EditPrinter)
+		privateRouter.DELETE("printer", printCtl.DeletePrinter)
+		privateRouter.POST("printer/:id/test", printCtl.TestPrinter)
+		// 小票预览与补打
+		privateRouter.GET("preview", printCtl.Preview)
+		privateRouter.POST("reprint", printCtl.Reprint)
+		// 打印任务
+		privateRouter.GET("job/page", printCtl.PageJobs)
+		privateRouter.POST("job/:id/retry", printCtl.RetryJob)
+	}
+}
@@ -34,5 +34,16 @@ func (er *ReportRouter) InitApiRouter(router *gin.RouterGroup) {
 		privateRouter.GET("top10", reportCtl.Top10Statistics)
 		// 导出运营数据Excel报表
 		privateRouter.GET("export", reportCtl.ExportExcel)
+
+		// v2：数组返回，支持统计粒度、时区与同比/环比
+		privateRouter.GET("v2/turnoverStatistics", reportCtl.TurnoverStatisticsV2)
+		privateRouter.GET("v2/userStatistics", reportCtl.UserStatisticsV2)
+		privateRouter.GET("v2/ordersStatistics", reportCtl.OrderStatisticsV2)
+		privateRouter.GET("v2/top10", reportCtl.Top10StatisticsV2)
+		// 按导出定义导出 xlsx/csv/pdf，长区间转为异步任务
+		privateRouter.GET("v2/export", reportCtl.ExportReport)
+		// 异步导出任务状态与下载
+		privateRouter.GET("export/jobs/:id", reportCtl.GetExportJob)
+		privateRouter.GET("export/jobs/:id/download", reportCtl.DownloadExport)
 	}
 }
@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/admin/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type ReviewRouter struct {
+	service service.IReviewService
+}
+
+func (rr *ReviewRouter) InitApiRouter(router *gin.RouterGroup) {
+	privateRouter := router.Group("review")
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTAdmin())
+	// 依赖注入
+	rr.service = service.NewReviewService(dao.NewReviewDao(global.DB), dao.NewOrderDao())
+	reviewCtl := controller.NewReviewController(rr.service)
+	{
+		// 评价分页查询
+		privateRouter.GET("page", reviewCtl.PageQuery)
+		// 回复评价
+		privateRouter.PUT("reply", reviewCtl.Reply)
+		// 展示或隐藏评价
+		privateRouter.POST("status/:status", reviewCtl.SetStatus)
+	}
+}
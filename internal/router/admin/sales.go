@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/admin/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type SalesRouter struct {
+	service service.ISalesAnalyticsService
+}
+
+func (sr *SalesRouter) InitApiRouter(router *gin.RouterGroup) {
+	// /admin/report/sales
+	privateRouter := router.Group("report/sales")
+
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTAdmin())
+
+	// 依赖注入
+	sr.service = service.NewSalesAnalyticsService(dao.NewSalesDao(global.DB), dao.NewReportDao(global.DB))
+	salesCtl := controller.NewSalesController(sr.service)
+	{
+		// 菜品/套餐销售排行
+		privateRouter.GET("items", salesCtl.ItemSales)
+		// 分类销售占比
+		privateRouter.GET("categories", salesCtl.CategorySales)
+		// 口味附加率
+		privateRouter.GET("flavors", salesCtl.FlavorAttach)
+		// 下单热力图
+		privateRouter.GET("heatmap", salesCtl.Heatmap)
+		// 滞销商品
+		privateRouter.GET("slow", salesCtl.SlowMovers)
+		// 平均订单构成
+		privateRouter.GET("basket", salesCtl.Basket)
+	}
+}
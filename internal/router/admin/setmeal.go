@@ -19,7 +19,7 @@ func (er *SetMealRouter) InitApiRouter(router *gin.RouterGroup) {
 	// 私有路由使用jwt验证
 	privateRouter.Use(middle.VerifiyJWTAdmin())
 	// 依赖注入
-	er.service = service.NewSetMealService(dao.NewSetMealDao(global.DB), dao.NewSetMealDishDao())
+	er.service = service.NewSetMealService(dao.NewSetMealDao(global.DB), dao.NewSetMealDishDao(), dao.NewReviewDao(global.DB))
 	setmealCtrl := controller.NewSetMealController(er.service)
 	{
 		privateRouter.POST("", setmealCtrl.SaveWithDish)
@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/admin/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type TableRouter struct {
+	service service.ITableService
+}
+
+func (tr *TableRouter) InitApiRouter(router *gin.RouterGroup) {
+	// /admin/table
+	privateRouter := router.Group("table")
+
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTAdmin())
+
+	// 依赖注入
+	tr.service = service.NewTableService(dao.NewTableDao(global.DB))
+	tableCtl := controller.NewTableController(tr.service)
+	{
+		// 桌台管理
+		privateRouter.GET("list", tableCtl.ListTables)
+		privateRouter.POST("", tableCtl.AddTable)
+		privateRouter.PUT("", tableCtl.EditTable)
+		privateRouter.DELETE("", tableCtl.DeleteTable)
+		// 桌台二维码
+		privateRouter.POST(":id/rotate", tableCtl.RotateCode)
+		privateRouter.GET(":id/qrcode", tableCtl.QRCode)
+	}
+}
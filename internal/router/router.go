@@ -16,6 +16,16 @@ type RouterGroup struct {
 	admin.OrderRouter
 	admin.ReportRouter
 	admin.WorkSpaceRouter
+	admin.ReviewRouter
+	admin.CacheRouter
+	admin.SalesRouter
+	admin.CustomerRouter
+	admin.DeliveryRouter
+	admin.KitchenRouter
+	admin.PrintRouter
+	admin.TableRouter
+	admin.AfterSaleRouter
+	admin.MediaRouter
 	websocket.Server
 	UserWxUserRouter user.WxUserRouter
 	UserShop         user.ShopRouter
@@ -25,6 +35,14 @@ type RouterGroup struct {
 	UserShoppingCart user.ShoppingCartRouter
 	UserAddressBook  user.AddressBookRouter
 	UserOrder        user.OrderRouter
+	UserSearch       user.SearchRouter
+	UserReview       user.ReviewRouter
+	UserCommon       user.CommonRouter
+	UserFavorite     user.FavoriteRouter
+	UserRecommend    user.RecommendRouter
+	UserTable        user.TableRouter
+	UserGroup        user.GroupRouter
+	UserAfterSale    user.AfterSaleRouter
 }
 
 var AllRouter = new(RouterGroup)
@@ -2,6 +2,7 @@ package user
 
 import (
 	"github.com/gin-gonic/gin"
+	"takeout/common/region"
 	"takeout/global"
 	"takeout/internal/api/user/controller"
 	"takeout/internal/service"
@@ -15,8 +16,9 @@ func (dr *AddressBookRouter) InitApiRouter(parent *gin.RouterGroup) {
 	privateRouter := parent.Group("addressBook") // 私有路由使用jwt验证
 	privateRouter.Use(middle.VerifiyJWTUser())
 	// 依赖注入
+	conf := global.Config.Address
 	addressCtrl := controller.NewAddressBookController(
-		service.NewAddressBookService(dao.NewAddressBookDao(global.DB)),
+		service.NewAddressBookService(dao.NewAddressBookDao(global.DB), region.NewGeocoder(conf.Geocoder, conf.GeocoderKey)),
 	)
 	{
 		privateRouter.POST("/", addressCtrl.Create)
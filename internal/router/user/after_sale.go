@@ -0,0 +1,32 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/user/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type AfterSaleRouter struct{}
+
+func (ar *AfterSaleRouter) InitApiRouter(parent *gin.RouterGroup) {
+	privateRouter := parent.Group("afterSale")
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTUser())
+	// 依赖注入
+	afterSaleCtrl := controller.NewAfterSaleController(
+		service.NewAfterSaleService(dao.NewAfterSaleDao(global.DB), dao.NewOrderDao(), dao.NewReportDao(global.DB)),
+	)
+	{
+		// 申请售后
+		privateRouter.POST("", afterSaleCtrl.Submit)
+		// 查询我的售后单
+		privateRouter.GET("list", afterSaleCtrl.List)
+		// 查询售后单详情
+		privateRouter.GET(":id", afterSaleCtrl.Get)
+		// 撤销售后单
+		privateRouter.PUT("cancel/:id", afterSaleCtrl.Cancel)
+	}
+}
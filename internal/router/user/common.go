@@ -0,0 +1,27 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/admin/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type CommonRouter struct {
+	service service.IUploadService
+}
+
+func (cr *CommonRouter) InitApiRouter(parent *gin.RouterGroup) {
+	privateRouter := parent.Group("common")
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTUser())
+	// 依赖注入
+	cr.service = service.NewUploadService(dao.NewMediaDao(global.DB))
+	commCtrl := controller.NewCommonController(cr.service, "reviews")
+	{
+		// 评价图片上传
+		privateRouter.POST("/upload", commCtrl.Upload)
+	}
+}
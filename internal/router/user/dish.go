@@ -17,7 +17,7 @@ func (dr *DishRouter) InitApiRouter(parent *gin.RouterGroup) {
 	privateRouter.Use(middle.VerifiyJWTUser())
 	// 依赖注入
 	dishCtrl := controller.NewDishController(
-		service.NewDishService(dao.NewDishRepo(global.DB), dao.NewDishFlavorDao()),
+		service.NewDishService(dao.NewDishRepo(global.DB), dao.NewDishFlavorDao(), dao.NewReviewDao(global.DB)),
 	)
 	{
 		privateRouter.GET("/list", dishCtrl.List)
@@ -0,0 +1,30 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/user/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type FavoriteRouter struct{}
+
+func (fr *FavoriteRouter) InitApiRouter(parent *gin.RouterGroup) {
+	privateRouter := parent.Group("favorite")
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTUser())
+	// 依赖注入
+	favoriteCtrl := controller.NewFavoriteController(service.NewFavoriteService(dao.NewFavoriteDao(global.DB)))
+	{
+		// 收藏
+		privateRouter.POST("", favoriteCtrl.Add)
+		// 取消收藏
+		privateRouter.DELETE("", favoriteCtrl.Delete)
+		// 是否已收藏
+		privateRouter.GET("status", favoriteCtrl.Status)
+		// 我的收藏
+		privateRouter.GET("list", favoriteCtrl.List)
+	}
+}
@@ -0,0 +1,46 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/user/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type GroupRouter struct{}
+
+func (gr *GroupRouter) InitApiRouter(parent *gin.RouterGroup) {
+	privateRouter := parent.Group("group")
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTUser())
+	// 依赖注入
+	groupCtrl := controller.NewGroupController(service.NewGroupService(
+		dao.NewGroupCartDao(global.DB),
+		dao.NewOrderDao(),
+		dao.NewAddressBookDao(global.DB),
+	))
+	{
+		// 发起拼单
+		privateRouter.POST("", groupCtrl.Create)
+		// 参与中的拼单
+		privateRouter.GET("list", groupCtrl.List)
+		// 拼单详情
+		privateRouter.GET(":code", groupCtrl.Get)
+		// 加入、退出拼单，发起人移除成员
+		privateRouter.POST(":code/join", groupCtrl.Join)
+		privateRouter.DELETE(":code/leave", groupCtrl.Leave)
+		privateRouter.DELETE(":code/member", groupCtrl.RemoveMember)
+		// 添加、减少菜品
+		privateRouter.POST(":code/add", groupCtrl.AddItem)
+		privateRouter.POST(":code/sub", groupCtrl.SubItem)
+		// 锁定、解锁、取消拼单
+		privateRouter.PUT(":code/lock", groupCtrl.Lock)
+		privateRouter.PUT(":code/unlock", groupCtrl.Unlock)
+		privateRouter.DELETE(":code", groupCtrl.Cancel)
+		// 下单与 AA 支付
+		privateRouter.POST(":code/checkout", groupCtrl.Checkout)
+		privateRouter.PUT(":code/pay", groupCtrl.Pay)
+	}
+}
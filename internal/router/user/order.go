@@ -2,6 +2,7 @@ package user
 
 import (
 	"github.com/gin-gonic/gin"
+	"takeout/global"
 	"takeout/internal/api/user/controller"
 	"takeout/internal/service"
 	"takeout/middle"
@@ -15,11 +16,13 @@ func (dr *OrderRouter) InitApiRouter(parent *gin.RouterGroup) {
 	// 私有路由使用jwt验证
 	privateRouter.Use(middle.VerifiyJWTUser())
 	orderCtrl := controller.NewOrderController(
-		service.NewOrderService(dao.NewOrderDao()),
+		service.NewOrderService(dao.NewOrderDao(), dao.NewReportDao(global.DB), dao.NewKitchenDao(global.DB), dao.NewPrintDao(global.DB), dao.NewTableDao(global.DB), dao.NewShoppingCartDao(global.DB)),
 	)
 	{
 		// 用户下单
 		privateRouter.POST("submit", orderCtrl.OrderSubmit)
+		// 可预约的送达时段
+		privateRouter.GET("slots", orderCtrl.OrderSlots)
 		// 订单支付
 		privateRouter.PUT("payment", orderCtrl.OrderPayment)
 		// 根据订单id查询订单详情
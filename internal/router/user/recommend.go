@@ -0,0 +1,29 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/user/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type RecommendRouter struct{}
+
+func (rr *RecommendRouter) InitApiRouter(parent *gin.RouterGroup) {
+	privateRouter := parent.Group("recommend")
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTUser())
+	// 依赖注入
+	recommendCtrl := controller.NewRecommendController(service.NewRecommendService(
+		dao.NewRecommendDao(global.DB),
+		dao.NewFavoriteDao(global.DB),
+		dao.NewSearchDao(global.DB),
+		dao.NewReportDao(global.DB),
+	))
+	{
+		// 猜你喜欢
+		privateRouter.GET("", recommendCtrl.Recommend)
+	}
+}
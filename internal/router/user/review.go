@@ -0,0 +1,32 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/user/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type ReviewRouter struct{}
+
+func (rr *ReviewRouter) InitApiRouter(parent *gin.RouterGroup) {
+	privateRouter := parent.Group("review")
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTUser())
+	// 依赖注入
+	reviewCtrl := controller.NewReviewController(
+		service.NewReviewService(dao.NewReviewDao(global.DB), dao.NewOrderDao()),
+	)
+	{
+		// 评价订单
+		privateRouter.POST("", reviewCtrl.Submit)
+		// 查询订单评价
+		privateRouter.GET("order/:id", reviewCtrl.GetOrderReview)
+		// 分页查询菜品/套餐评价
+		privateRouter.GET("page", reviewCtrl.PageByTarget)
+		// 举报评价
+		privateRouter.POST("flag/:id", reviewCtrl.Flag)
+	}
+}
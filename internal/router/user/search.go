@@ -0,0 +1,30 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/common/search"
+	"takeout/global"
+	"takeout/internal/api/user/controller"
+	"takeout/internal/service"
+	"takeout/middle"
+	"takeout/repository/dao"
+)
+
+type SearchRouter struct{}
+
+func (sr *SearchRouter) InitApiRouter(parent *gin.RouterGroup) {
+	privateRouter := parent.Group("search")
+	// 私有路由使用jwt验证
+	privateRouter.Use(middle.VerifiyJWTUser())
+	// 依赖注入
+	conf := global.Config.Search
+	searchCtrl := controller.NewSearchController(
+		service.NewSearchService(dao.NewSearchDao(global.DB), search.NewIndex(conf.Engine, conf.Endpoint, conf.Index)),
+	)
+	{
+		// 搜索菜品与套餐
+		privateRouter.GET("", searchCtrl.Search)
+		// 搜索建议
+		privateRouter.GET("suggest", searchCtrl.Suggest)
+	}
+}
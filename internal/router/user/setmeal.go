@@ -17,7 +17,7 @@ func (dr *SetmealRouter) InitApiRouter(parent *gin.RouterGroup) {
 	privateRouter.Use(middle.VerifiyJWTUser())
 	// 依赖注入
 	dishCtrl := controller.NewSetMealController(
-		service.NewSetMealService(dao.NewSetMealDao(global.DB), dao.NewSetMealDishDao()),
+		service.NewSetMealService(dao.NewSetMealDao(global.DB), dao.NewSetMealDishDao(), dao.NewReviewDao(global.DB)),
 	)
 	{
 		privateRouter.GET("/dish/:id", dishCtrl.GetDishByCategoryId)
@@ -32,5 +32,7 @@ func (dr *ShoppingCartRouter) InitApiRouter(parent *gin.RouterGroup) {
 		privateRouter.DELETE("clean", shoppingCartCtrl.CleanShoppingCart)
 		// 减少购物车某项
 		privateRouter.POST("sub", shoppingCartCtrl.SubShoppingCart)
+		// 校验购物车并更新变价商品
+		privateRouter.GET("validate", shoppingCartCtrl.ValidateShoppingCart)
 	}
 }
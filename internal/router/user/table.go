@@ -0,0 +1,22 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"takeout/global"
+	"takeout/internal/api/user/controller"
+	"takeout/internal/service"
+	"takeout/repository/dao"
+)
+
+type TableRouter struct{}
+
+func (tr *TableRouter) InitApiRouter(parent *gin.RouterGroup) {
+	// 扫码时用户可能尚未登录，校验码由二维码提供
+	publicRouter := parent.Group("table")
+	// 依赖注入
+	tableCtrl := controller.NewTableController(service.NewTableService(dao.NewTableDao(global.DB)))
+	{
+		// 扫描桌台二维码
+		publicRouter.GET("scan", tableCtrl.Scan)
+	}
+}
@@ -21,11 +21,27 @@ func (cr *WxUserRouter) InitApiRouter(router *gin.RouterGroup) {
 	privateRouter.Use(middle.VerifiyJWTUser()) // 私有路由使用jwt验证
 
 	//依赖注入
-	cr.service = service.NewWxUserService(dao.NewWxUserDao(global.DB))
+	cr.service = service.NewWxUserService(dao.NewWxUserDao(global.DB), dao.NewShoppingCartDao(global.DB), dao.NewMediaDao(global.DB))
 	wxCtrl := controller.NewWxUserController(cr.service)
 
 	{
 		publicRouter.POST("login", wxCtrl.Login)
 		privateRouter.POST("logout", wxCtrl.Logout)
+		// 发送绑定手机号的短信验证码
+		privateRouter.POST("phone/code", wxCtrl.SendPhoneCode)
+		// 通过短信验证码绑定手机号
+		privateRouter.POST("phone/sms", wxCtrl.BindPhoneBySms)
+		// 通过微信手机号授权绑定手机号
+		privateRouter.POST("phone/wechat", wxCtrl.BindPhoneByWechat)
+		// 生成合并码，确认将本账号合并到绑定同一手机号的账号
+		privateRouter.POST("merge/code", wxCtrl.MergeCode)
+		// 个人资料
+		privateRouter.GET("profile", wxCtrl.Profile)
+		privateRouter.PUT("profile", wxCtrl.UpdateProfile)
+		privateRouter.POST("avatar", wxCtrl.UploadAvatar)
+		// 导出个人数据
+		privateRouter.GET("export", wxCtrl.ExportData)
+		// 注销账号
+		privateRouter.DELETE("account", wxCtrl.DeleteAccount)
 	}
 }
@@ -5,6 +5,8 @@ import (
 	"github.com/gorilla/websocket"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
 )
 
 // 创建WebSocket升级器并初始化
@@ -19,7 +21,7 @@ var upGrader = websocket.Upgrader{
 
 // WSServer 创建websocketServer并初始化
 var WSServer = Server{
-	conns: make([]*websocket.Conn, 0),
+	conns: make(map[*websocket.Conn]map[string]bool),
 }
 
 // WSRouter 注册WebSocket路由
@@ -28,39 +30,84 @@ func WSRouter(r *gin.Engine) {
 }
 
 // WebSocket处理器
+// 客户端可通过 ?topics=kds,kds:1 订阅主题，未订阅任何主题的客户端只接收广播消息
 func websocketHandler(ctx *gin.Context) {
 	// 升级HTTP连接到WebSocket连接
 	conn, err := upGrader.Upgrade(ctx.Writer, ctx.Request, nil)
 	if err != nil {
-		log.Println("client连接失败:[%v]", err)
+		log.Printf("client连接失败:[%v]", err)
 		return
 	}
 	clientId := ctx.Param("id")
 	log.Printf("client[%v]连接成功", clientId)
-	WSServer.conns = append(WSServer.conns, conn)
+	topics := make(map[string]bool)
+	for _, topic := range strings.Split(ctx.Query("topics"), ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics[topic] = true
+		}
+	}
+	WSServer.add(conn, topics)
+	// 持续读取以处理控制帧，读取出错即视为断开
+	go func() {
+		defer WSServer.remove(conn)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
 }
 
 // Server websocketServer
 type Server struct {
-	conns []*websocket.Conn
+	mu    sync.Mutex
+	conns map[*websocket.Conn]map[string]bool // 连接 -> 订阅的主题
+}
+
+func (s *Server) add(conn *websocket.Conn, topics map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conns[conn] = topics
+}
+
+func (s *Server) remove(conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.conns[conn]; ok {
+		delete(s.conns, conn)
+		conn.Close()
+	}
 }
 
 // SendToAllClients 发送消息给所有客户端
-func (s Server) SendToAllClients(jsonMsg any) {
-	for i := 0; i < len(s.conns); i++ {
-		if err := s.conns[i].WriteJSON(jsonMsg); err != nil {
-			if websocket.IsCloseError(err, websocket.CloseNormalClosure) ||
-				websocket.IsCloseError(err, websocket.CloseGoingAway) ||
-				websocket.IsCloseError(err, websocket.CloseAbnormalClosure) ||
-				websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway) ||
-				websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) ||
-				websocket.IsUnexpectedCloseError(err, websocket.CloseAbnormalClosure) {
-				// 移除无效conn
-				s.conns = append(s.conns[:i], s.conns[i+1:]...)
-				continue
+func (s *Server) SendToAllClients(jsonMsg any) {
+	s.send(jsonMsg, func(map[string]bool) bool { return true })
+}
+
+// Publish 发送消息给订阅了任一主题的客户端
+func (s *Server) Publish(jsonMsg any, topics ...string) {
+	s.send(jsonMsg, func(subscribed map[string]bool) bool {
+		for _, topic := range topics {
+			if subscribed[topic] {
+				return true
 			}
+		}
+		return false
+	})
+}
+
+func (s *Server) send(jsonMsg any, match func(map[string]bool) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn, topics := range s.conns {
+		if !match(topics) {
+			continue
+		}
+		if err := conn.WriteJSON(jsonMsg); err != nil {
+			// 写入失败的连接已不可用，移除
 			log.Println("webSocket send message 错误", err)
-			return
+			delete(s.conns, conn)
+			conn.Close()
 		}
 	}
 }
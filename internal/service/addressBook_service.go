@@ -1,14 +1,24 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/ulule/deepcopier"
+	"math"
+	"regexp"
 	"strconv"
+	"strings"
+	"takeout/common/e"
 	"takeout/common/enum"
+	"takeout/common/region"
+	"takeout/global"
 	"takeout/internal/api/user/request"
 	"takeout/internal/model"
 	"takeout/repository"
+	"time"
+	"unicode/utf8"
 )
 
 type IAddressBookService interface {
@@ -21,47 +31,91 @@ type IAddressBookService interface {
 	SetDefaultAddressBook(ctx *gin.Context, id uint64) error
 }
 
+const (
+	addressDefaultMaxPerUser = 20
+	addressMaxConsignee      = 50
+	addressMaxDetail         = 200
+	addressGeocodeTimeout    = 3 * time.Second
+)
+
+// mobilePattern 中国大陆手机号
+var mobilePattern = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
 type AddressBookService struct {
-	repo repository.AddressBookRepo
+	repo      repository.AddressBookRepo
+	divisions *region.Set
+	geocoder  region.Geocoder
 }
 
-func NewAddressBookService(repo repository.AddressBookRepo) IAddressBookService {
+func NewAddressBookService(repo repository.AddressBookRepo, geocoder region.Geocoder) IAddressBookService {
+	divisions, err := region.Open(global.Config.Address.Divisions)
+	if err != nil {
+		global.Log.Warn("Load administrative divisions failed, using embedded dataset", "error", err)
+		divisions = region.Default()
+	}
 	return &AddressBookService{
-		repo: repo,
+		repo:      repo,
+		divisions: divisions,
+		geocoder:  geocoder,
 	}
 }
 
 func (as *AddressBookService) CreateAddressBook(ctx *gin.Context, address request.AddressBookDTO) error {
-	var data model.AddressBook
 	userId := uint64(0)
 	if CurrentId, ok := ctx.Get(enum.CurrentId); ok {
 		userId = CurrentId.(uint64)
 	}
-	if err := deepcopier.Copy(address).To(&data); err != nil {
+	data, err := as.normalize(ctx, address)
+	if err != nil {
 		return err
 	}
-	data.UserId = int(userId)
-	data.Label = strconv.Itoa(address.Label)
-	return as.repo.CreateAddress(ctx, data)
+	data.Id, data.UserId = 0, int(userId)
+	// 数量校验与新增在同一事务中，并发新增不会超过上限
+	ok, err := as.repo.CreateAddress(ctx, data, addressMaxPerUser())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: 最多保存 %d 个地址", e.Error_ADDRESS_LIMIT, addressMaxPerUser())
+	}
+	return nil
 }
 func (as *AddressBookService) UpdateAddressBook(ctx *gin.Context, address request.AddressBookDTO) error {
-	var data model.AddressBook
 	userId := uint64(0)
 	if CurrentId, ok := ctx.Get(enum.CurrentId); ok {
 		userId = CurrentId.(uint64)
 	}
-	if err := deepcopier.Copy(address).To(&data); err != nil {
+	if _, err := as.GetAddressBook(ctx, uint64(address.Id)); err != nil {
 		return err
 	}
-	data.UserId = int(userId)
-	data.Label = strconv.Itoa(address.Label)
-	return as.repo.UpdateAddressById(ctx, data)
+	data, err := as.normalize(ctx, address)
+	if err != nil {
+		return err
+	}
+	data.Id, data.UserId = address.Id, int(userId)
+	if err = as.repo.UpdateAddressById(ctx, data); err != nil {
+		return err
+	}
+	if address.IsDefault == 1 {
+		return as.SetDefaultAddressBook(ctx, uint64(address.Id))
+	}
+	return nil
 }
 func (as *AddressBookService) DeleteAddressBook(ctx *gin.Context, id uint64) error {
+	if _, err := as.GetAddressBook(ctx, id); err != nil {
+		return err
+	}
 	return as.repo.DeleteById(ctx, id)
 }
 func (as *AddressBookService) GetAddressBook(ctx *gin.Context, id uint64) (model.AddressBook, error) {
-	return as.repo.GetAddressById(ctx, id)
+	address, err := as.repo.GetAddressById(ctx, id)
+	if err != nil {
+		return model.AddressBook{}, err
+	}
+	if CurrentId, ok := ctx.Get(enum.CurrentId); !ok || address.Id == 0 || uint64(address.UserId) != CurrentId.(uint64) {
+		return model.AddressBook{}, e.Error_ADDRESS_NOT_FOUND
+	}
+	return address, nil
 }
 func (as *AddressBookService) GetCurAddressBook(ctx *gin.Context) ([]model.AddressBook, error) {
 	var (
@@ -100,5 +154,72 @@ func (as *AddressBookService) SetDefaultAddressBook(ctx *gin.Context, id uint64)
 	if CurrentId, ok := ctx.Get(enum.CurrentId); ok {
 		userId = CurrentId.(uint64)
 	}
-	return as.repo.SetDefaultAddressBook(ctx, userId, id)
+	ok, err := as.repo.SetDefaultAddressBook(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return e.Error_ADDRESS_NOT_FOUND
+	}
+	return nil
+}
+
+// normalize 校验收货人、手机号与省市区，补全规范的区划名称，未传坐标时进行地理编码
+func (as *AddressBookService) normalize(ctx context.Context, address request.AddressBookDTO) (model.AddressBook, error) {
+	var data model.AddressBook
+	if err := deepcopier.Copy(address).To(&data); err != nil {
+		return data, err
+	}
+	data.Label = strconv.Itoa(address.Label)
+	data.Consignee, data.Detail = strings.TrimSpace(address.Consignee), strings.TrimSpace(address.Detail)
+	switch {
+	case data.Consignee == "" || utf8.RuneCountInString(data.Consignee) > addressMaxConsignee:
+		return data, fmt.Errorf("%w: 收货人不能为空且不超过 %d 个字", e.Error_ADDRESS_INVALID, addressMaxConsignee)
+	case data.Detail == "" || utf8.RuneCountInString(data.Detail) > addressMaxDetail:
+		return data, fmt.Errorf("%w: 详细地址不能为空且不超过 %d 个字", e.Error_ADDRESS_INVALID, addressMaxDetail)
+	}
+	if data.Phone = strings.TrimSpace(address.Phone); !mobilePattern.MatchString(data.Phone) {
+		return data, e.Error_ADDRESS_PHONE_INVALID
+	}
+
+	division, err := as.divisions.Normalize(region.Address{
+		ProvinceCode: address.ProvinceCode,
+		ProvinceName: address.ProvinceName,
+		CityCode:     address.CityCode,
+		CityName:     address.CityName,
+		DistrictCode: address.DistrictCode,
+		DistrictName: address.DistrictName,
+	})
+	if err != nil {
+		return data, fmt.Errorf("%w: %s", e.Error_ADDRESS_INVALID, strings.TrimPrefix(err.Error(), region.ErrInvalidDivision.Error()+": "))
+	}
+	data.ProvinceCode, data.ProvinceName = division.ProvinceCode, division.ProvinceName
+	data.CityCode, data.CityName = division.CityCode, division.CityName
+	data.DistrictCode, data.DistrictName = division.DistrictCode, division.DistrictName
+
+	if address.Longitude != 0 || address.Latitude != 0 {
+		if math.Abs(address.Longitude) > 180 || math.Abs(address.Latitude) > 90 {
+			return data, fmt.Errorf("%w: 经纬度错误", e.Error_ADDRESS_INVALID)
+		}
+		return data, nil
+	}
+	// 地理编码失败不影响保存地址
+	geoCtx, cancel := context.WithTimeout(ctx, addressGeocodeTimeout)
+	defer cancel()
+	location, err := as.geocoder.Geocode(geoCtx, data.CityName, data.ProvinceName+data.CityName+data.DistrictName+data.Detail)
+	if err != nil {
+		if !errors.Is(err, region.ErrNoLocation) {
+			global.Log.Warn("Geocode address failed", "error", err)
+		}
+		return data, nil
+	}
+	data.Longitude, data.Latitude = location.Longitude, location.Latitude
+	return data, nil
+}
+
+func addressMaxPerUser() int {
+	if n := global.Config.Address.MaxPerUser; n > 0 {
+		return n
+	}
+	return addressDefaultMaxPerUser
 }
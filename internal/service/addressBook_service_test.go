@@ -0,0 +1,60 @@
+package service
+
+import (
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"strings"
+	"takeout/common/e"
+	"takeout/common/region"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/repository/dao"
+	"testing"
+)
+
+func TestCreateAddressBookLimit(t *testing.T) {
+	rec := useRecordedDB(t)
+	saved := int64(0)
+	rec.row = func(query string) ([]string, []driver.Value) {
+		switch {
+		case strings.Contains(query, "FROM `user`"):
+			return []string{"id"}, []driver.Value{int64(7)}
+		case strings.Contains(query, "count(*)"):
+			return []string{"count(*)"}, []driver.Value{saved}
+		}
+		return nil, nil
+	}
+	svc := &AddressBookService{repo: dao.NewAddressBookDao(global.DB), divisions: region.Default(), geocoder: region.Offline{}}
+	address := request.AddressBookDTO{
+		Consignee: "张三", Phone: "13800138000", Detail: "望京街道 1 号",
+		ProvinceCode: "110000", CityCode: "110100", DistrictCode: "110105",
+	}
+
+	// 第一个地址设为默认地址，用户行与地址行都在事务中加锁
+	if err := svc.CreateAddressBook(userCtx(7), address); err != nil {
+		t.Fatal(err)
+	}
+	var locked []string
+	for _, query := range rec.queries {
+		if strings.HasSuffix(query, "FOR UPDATE") {
+			locked = append(locked, query)
+		}
+	}
+	if len(locked) != 2 || !strings.Contains(locked[0], "FROM `user`") || !strings.Contains(locked[1], "FROM `address_book`") {
+		t.Fatalf("locking queries = %q", locked)
+	}
+	inserts := rec.inserts("address_book")
+	if len(inserts) != 1 || !slices.Contains(inserts[0], any("朝阳区")) || !slices.Contains(inserts[0], any(int64(1))) {
+		t.Fatalf("address inserts = %v", inserts)
+	}
+
+	// 已达上限时不插入
+	saved = int64(addressMaxPerUser())
+	if err := svc.CreateAddressBook(userCtx(7), address); !errors.Is(err, e.Error_ADDRESS_LIMIT) {
+		t.Fatalf("over limit err = %v", err)
+	}
+	if inserts = rec.inserts("address_book"); len(inserts) != 1 {
+		t.Fatalf("address inserts after limit = %d", len(inserts))
+	}
+}
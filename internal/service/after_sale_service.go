@@ -0,0 +1,471 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/common/notify"
+	"takeout/global"
+	adminRequest "takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/internal/api/user/request"
+	"takeout/internal/model"
+	"takeout/internal/router/websocket"
+	"takeout/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	afterSaleDefaultWindow    = 48 * time.Hour
+	afterSaleDefaultSLA       = 2 * time.Hour
+	afterSaleDefaultMaxImages = 9
+)
+
+type IAfterSaleService interface {
+	// user
+	Submit(ctx *gin.Context, dto request.AfterSaleSubmitDTO) (*model.AfterSale, error)
+	ListMine(ctx *gin.Context) ([]model.AfterSale, error)
+	GetMine(ctx *gin.Context, id uint64) (*model.AfterSale, error)
+	Cancel(ctx *gin.Context, id uint64) error
+
+	// admin
+	PageQuery(ctx context.Context, dto adminRequest.AfterSalePageQueryDTO) (*common.PageResult, error)
+	Detail(ctx context.Context, id uint64) (*model.AfterSale, error)
+	Approve(ctx *gin.Context, dto adminRequest.AfterSaleApproveDTO) (*model.AfterSale, error)
+	Reject(ctx *gin.Context, dto adminRequest.AfterSaleRejectDTO) error
+	Report(ctx context.Context, dto adminRequest.AfterSaleReportDTO) (response.AfterSaleSummaryVO, error)
+}
+
+type AfterSaleService struct {
+	repo       repository.AfterSaleRepo
+	orderRepo  repository.OrderRepo
+	reportRepo repository.ReportRepo
+	notifiers  notify.Registry
+}
+
+func NewAfterSaleService(repo repository.AfterSaleRepo, orderRepo repository.OrderRepo, reportRepo repository.ReportRepo) IAfterSaleService {
+	service := &AfterSaleService{repo: repo, orderRepo: orderRepo, reportRepo: reportRepo, notifiers: NewNotifiers()}
+	// 每分钟升级超过处理时限的售后单，按升级次数条件更新，多个实例同时执行时只有一个生效
+	timerTask := cron.New(cron.WithSeconds())
+	if _, err := timerTask.AddFunc("15 * * * * ?", service.escalateOverdue); err != nil {
+		global.Log.Warn("TimerTaskError")
+	}
+	timerTask.Start()
+	return service
+}
+
+// Submit 针对已完成订单中的明细申请售后，同一订单同时只能有一个待处理的售后单
+func (s *AfterSaleService) Submit(ctx *gin.Context, dto request.AfterSaleSubmitDTO) (*model.AfterSale, error) {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	order, err := s.orderRepo.GetOrderById(strconv.Itoa(dto.OrderId))
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.Id == 0 || order.UserId != userId {
+		return nil, e.Error_ORDER_NOT_FOUND
+	}
+	if order.Status != enum.Completed {
+		return nil, fmt.Errorf("%w: 订单完成后才能申请售后", e.Error_AFTER_SALE_INVALID)
+	}
+	if time.Since(completedAt(order)) > afterSaleWindow() {
+		return nil, e.Error_AFTER_SALE_EXPIRED
+	}
+	if dto.Type != enum.AfterSaleRefund && dto.Type != enum.AfterSaleRemake {
+		return nil, fmt.Errorf("%w: 售后类型错误", e.Error_AFTER_SALE_INVALID)
+	}
+	if reasons := global.Config.AfterSale.Reasons; len(reasons) > 0 && !slices.Contains(reasons, dto.Reason) {
+		return nil, fmt.Errorf("%w: 问题类型错误", e.Error_AFTER_SALE_INVALID)
+	}
+	images, err := afterSaleImages(dto.Images)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每个明细可申请的数量扣除其他未撤销、未拒绝的售后单
+	history, err := s.repo.ListByOrder(ctx, order.Id)
+	if err != nil {
+		return nil, err
+	}
+	claimed := make(map[int]int)
+	for _, sale := range history {
+		if sale.Status == enum.AfterSalePending {
+			return nil, e.Error_AFTER_SALE_EXISTS
+		}
+		if sale.Status == enum.AfterSaleApproved {
+			for _, item := range sale.Items {
+				claimed[item.OrderDetailId] += item.Number
+			}
+		}
+	}
+	details, err := s.orderRepo.GetOrderDetailByOrderId(strconv.Itoa(order.Id))
+	if err != nil {
+		return nil, err
+	}
+	detailMap := make(map[int]model.OrderDetail, len(details))
+	for _, detail := range details {
+		detailMap[detail.Id] = detail
+	}
+
+	sale := &model.AfterSale{
+		OrderId:     order.Id,
+		OrderNumber: order.Number,
+		UserId:      userId,
+		Type:        dto.Type,
+		Reason:      dto.Reason,
+		Description: strings.TrimSpace(dto.Description),
+		Images:      images,
+		Status:      enum.AfterSalePending,
+		DueTime:     time.Now().Add(afterSaleSLA()),
+	}
+	for _, item := range dto.Items {
+		detail, ok := detailMap[item.OrderDetailId]
+		if !ok {
+			return nil, fmt.Errorf("%w: 订单明细不存在", e.Error_AFTER_SALE_INVALID)
+		}
+		if item.Number <= 0 || claimed[detail.Id]+item.Number > detail.Number {
+			return nil, fmt.Errorf("%w: %s 最多可申请 %d 份", e.Error_AFTER_SALE_INVALID, detail.Name, max(detail.Number-claimed[detail.Id], 0))
+		}
+		claimed[detail.Id] += item.Number
+		sale.Items = append(sale.Items, model.AfterSaleItem{
+			OrderDetailId: detail.Id,
+			Name:          detail.Name,
+			Image:         detail.Image,
+			DishId:        detail.DishId,
+			SetmealId:     detail.SetmealId,
+			DishFlavor:    detail.DishFlavor,
+			Number:        item.Number,
+			Amount:        detail.Amount,
+		})
+		sale.Amount = roundCent(sale.Amount + detail.Amount*float64(item.Number))
+	}
+	if err = s.repo.Create(ctx, sale); err != nil {
+		return nil, err
+	}
+	return sale, nil
+}
+
+// ListMine 查询当前用户的售后单
+func (s *AfterSaleService) ListMine(ctx *gin.Context) ([]model.AfterSale, error) {
+	return s.repo.ListByUser(ctx, int(ctx.MustGet(enum.CurrentId).(uint64)))
+}
+
+// GetMine 查询当前用户的售后单详情
+func (s *AfterSaleService) GetMine(ctx *gin.Context, id uint64) (*model.AfterSale, error) {
+	sale, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sale == nil || sale.UserId != int(ctx.MustGet(enum.CurrentId).(uint64)) {
+		return nil, e.Error_AFTER_SALE_NOT_FOUND
+	}
+	return sale, nil
+}
+
+// Cancel 撤销待处理的售后单
+func (s *AfterSaleService) Cancel(ctx *gin.Context, id uint64) error {
+	sale, err := s.GetMine(ctx, id)
+	if err != nil {
+		return err
+	}
+	ok, err := s.repo.Cancel(ctx, sale.Id, sale.UserId)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return e.Error_AFTER_SALE_HANDLED
+	}
+	return nil
+}
+
+// PageQuery 售后管理分页查询
+func (s *AfterSaleService) PageQuery(ctx context.Context, dto adminRequest.AfterSalePageQueryDTO) (*common.PageResult, error) {
+	return s.repo.PageQuery(ctx, dto)
+}
+
+// Detail 查询售后单详情
+func (s *AfterSaleService) Detail(ctx context.Context, id uint64) (*model.AfterSale, error) {
+	sale, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sale == nil {
+		return nil, e.Error_AFTER_SALE_NOT_FOUND
+	}
+	return sale, nil
+}
+
+// Approve 同意售后：部分退款不超过申请金额及订单剩余可退金额，重做则按售后商品生成一张免费的新订单
+func (s *AfterSaleService) Approve(ctx *gin.Context, dto adminRequest.AfterSaleApproveDTO) (*model.AfterSale, error) {
+	sale, order, err := s.pending(ctx, dto.Id)
+	if err != nil {
+		return nil, err
+	}
+	sale.Status, sale.Resolution, sale.Reply = enum.AfterSaleApproved, dto.Resolution, strings.TrimSpace(dto.Reply)
+	sale.HandleUser = ctx.MustGet(enum.CurrentId).(uint64)
+	sale.HandleTime = model.LocalTime(time.Now())
+
+	var (
+		remake  *model.Order
+		details []model.OrderDetail
+	)
+	switch dto.Resolution {
+	case enum.AfterSaleRefund:
+		refundable, err := s.refundable(ctx, order)
+		if err != nil {
+			return nil, err
+		}
+		refund := roundCent(dto.RefundAmount)
+		if refund <= 0 || refund > sale.Amount || refund > refundable {
+			return nil, fmt.Errorf("%w: 退款金额需大于 0 且不超过 %.2f 元", e.Error_AFTER_SALE_INVALID, min(sale.Amount, refundable))
+		}
+		sale.RefundAmount = refund
+	case enum.AfterSaleRemake:
+		if remake, details, err = remakeOrder(order, sale); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: 处理方式错误", e.Error_AFTER_SALE_INVALID)
+	}
+
+	ok, err := s.repo.Resolve(ctx, sale, remake, details)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, e.Error_AFTER_SALE_HANDLED
+	}
+	// 退款从原订单下单日期的营业额中扣除，失败时由夜间任务重算修正
+	if sale.RefundAmount > 0 {
+		if err = s.reportRepo.IncrDailyStats(time.Time(order.OrderTime), -sale.RefundAmount, 0); err != nil {
+			global.Log.Warn("Incr daily business stats failed", "orderId", order.Id, "error", err)
+		}
+	}
+	if remake != nil {
+		// 重做订单与新订单一样提醒商家接单
+		websocket.WSServer.SendToAllClients(map[string]any{
+			"type":    1,
+			"orderId": remake.Id,
+			"content": "售后重做订单号: " + remake.Number + "，原订单号: " + order.Number,
+		})
+	}
+	return sale, nil
+}
+
+// Reject 拒绝售后
+func (s *AfterSaleService) Reject(ctx *gin.Context, dto adminRequest.AfterSaleRejectDTO) error {
+	sale, _, err := s.pending(ctx, dto.Id)
+	if err != nil {
+		return err
+	}
+	sale.Status, sale.Reply = enum.AfterSaleRejected, strings.TrimSpace(dto.Reply)
+	sale.HandleUser = ctx.MustGet(enum.CurrentId).(uint64)
+	sale.HandleTime = model.LocalTime(time.Now())
+	ok, err := s.repo.Resolve(ctx, sale, nil, nil)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return e.Error_AFTER_SALE_HANDLED
+	}
+	return nil
+}
+
+// Report 按申请时间统计售后单
+func (s *AfterSaleService) Report(ctx context.Context, dto adminRequest.AfterSaleReportDTO) (response.AfterSaleSummaryVO, error) {
+	begin, err := time.ParseInLocation(time.DateOnly, dto.Begin, time.Local)
+	if err != nil {
+		return response.AfterSaleSummaryVO{}, err
+	}
+	end, err := time.ParseInLocation(time.DateOnly, dto.End, time.Local)
+	if err != nil {
+		return response.AfterSaleSummaryVO{}, err
+	}
+	summary, err := s.reportRepo.GetAfterSaleSummary(begin, end.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil {
+		return response.AfterSaleSummaryVO{}, err
+	}
+	summary.RefundAmount = round2(summary.RefundAmount)
+	summary.AvgHandleMinutes = round2(summary.AvgHandleMinutes)
+	return summary, nil
+}
+
+// pending 查询待处理的售后单及其订单
+func (s *AfterSaleService) pending(ctx context.Context, id uint64) (*model.AfterSale, *model.Order, error) {
+	sale, err := s.Detail(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sale.Status != enum.AfterSalePending {
+		return nil, nil, e.Error_AFTER_SALE_HANDLED
+	}
+	order, err := s.orderRepo.GetOrderById(strconv.Itoa(sale.OrderId))
+	if err != nil {
+		return nil, nil, err
+	}
+	if order == nil || order.Id == 0 {
+		return nil, nil, e.Error_ORDER_NOT_FOUND
+	}
+	return sale, order, nil
+}
+
+// refundable 订单实付金额扣除已同意的退款
+func (s *AfterSaleService) refundable(ctx context.Context, order *model.Order) (float64, error) {
+	history, err := s.repo.ListByOrder(ctx, order.Id)
+	if err != nil {
+		return 0, err
+	}
+	refunded := 0.0
+	for _, sale := range history {
+		if sale.Status == enum.AfterSaleApproved {
+			refunded += sale.RefundAmount
+		}
+	}
+	return max(roundCent(order.Amount-refunded), 0), nil
+}
+
+// remakeOrder 按售后商品生成已支付、待接单的零元订单，收货信息沿用原订单
+func remakeOrder(order *model.Order, sale *model.AfterSale) (*model.Order, []model.OrderDetail, error) {
+	remake := &model.Order{
+		Number:         strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Status:         enum.ToBeConfirmed,
+		UserId:         order.UserId,
+		AddressBookId:  order.AddressBookId,
+		CheckoutTime:   model.LocalTime(time.Now()),
+		PayMethod:      order.PayMethod,
+		PayStatus:      enum.Paid,
+		Remark:         "售后重做：原订单 " + order.Number,
+		RemakeOf:       order.Id,
+		Username:       order.Username,
+		Phone:          order.Phone,
+		Address:        order.Address,
+		Consignee:      order.Consignee,
+		DeliveryStatus: enum.DeliverNow,
+		OrderType:      order.OrderType,
+		TableId:        order.TableId,
+		TableLabel:     order.TableLabel,
+	}
+	if !remake.Delivers() {
+		code, err := nextPickupCode(time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		remake.PickupCode = code
+	}
+	details := make([]model.OrderDetail, 0, len(sale.Items))
+	for _, item := range sale.Items {
+		details = append(details, model.OrderDetail{
+			Name:       item.Name,
+			DishId:     item.DishId,
+			SetmealId:  item.SetmealId,
+			DishFlavor: item.DishFlavor,
+			Number:     item.Number,
+			Image:      item.Image,
+		})
+	}
+	return remake, details, nil
+}
+
+// escalateOverdue 超时未处理的售后单升级一次并重新计时，提醒配置的接收方与商家端
+func (s *AfterSaleService) escalateOverdue() {
+	ctx := context.Background()
+	now := time.Now()
+	sales, err := s.repo.ListOverdue(ctx, now)
+	if err != nil {
+		global.Log.Warn("List overdue after sales failed", "error", err)
+		return
+	}
+	for _, sale := range sales {
+		level := sale.EscalateLevel + 1
+		ok, err := s.repo.Escalate(ctx, sale.Id, level, now.Add(afterSaleSLA()))
+		if err != nil {
+			global.Log.Warn("Escalate after sale failed", "id", sale.Id, "error", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		text := fmt.Sprintf("订单 %s 的售后申请（%s）已超时未处理，第 %d 次提醒。", sale.OrderNumber, sale.Reason, level)
+		websocket.WSServer.SendToAllClients(map[string]any{
+			"type":        wsAfterSale,
+			"afterSaleId": sale.Id,
+			"level":       level,
+			"content":     text,
+		})
+		s.notifyEscalation(ctx, "售后超时提醒", text)
+	}
+}
+
+// notifyEscalation 发送给配置的全部接收方，单个接收方失败不影响其他接收方
+func (s *AfterSaleService) notifyEscalation(ctx context.Context, subject, text string) {
+	recipients := global.Config.AfterSale.Escalations
+	if len(recipients) == 0 {
+		return
+	}
+	msg := &notify.Message{Subject: subject, Text: text, HTML: "<p>" + html.EscapeString(text) + "</p>"}
+	for _, r := range recipients {
+		sendCtx, cancel := context.WithTimeout(ctx, deliveryTimeout())
+		if err := s.notifiers.Send(sendCtx, r.Channel, r.Target, msg); err != nil {
+			global.Log.Warn("Send after sale escalation failed", "channel", r.Channel, "error", err)
+		}
+		cancel()
+	}
+}
+
+// completedAt 订单完成时间，早期订单没有送达时间时依次取支付时间、下单时间
+func completedAt(order *model.Order) time.Time {
+	for _, t := range []model.LocalTime{order.DeliveryTime, order.CheckoutTime, order.OrderTime} {
+		if !time.Time(t).IsZero() {
+			return time.Time(t)
+		}
+	}
+	return time.Time{}
+}
+
+// afterSaleImages 校验图片数量，以逗号拼接保存
+func afterSaleImages(images []string) (string, error) {
+	kept := make([]string, 0, len(images))
+	for _, image := range images {
+		image = strings.TrimSpace(image)
+		if image == "" {
+			continue
+		}
+		if strings.Contains(image, ",") {
+			return "", fmt.Errorf("%w: 图片地址错误", e.Error_AFTER_SALE_INVALID)
+		}
+		kept = append(kept, image)
+	}
+	if limit := afterSaleMaxImages(); len(kept) > limit {
+		return "", fmt.Errorf("%w: 最多上传 %d 张图片", e.Error_AFTER_SALE_INVALID, limit)
+	}
+	return strings.Join(kept, ","), nil
+}
+
+func afterSaleWindow() time.Duration {
+	if d, err := time.ParseDuration(global.Config.AfterSale.Window); err == nil && d > 0 {
+		return d
+	}
+	return afterSaleDefaultWindow
+}
+
+func afterSaleSLA() time.Duration {
+	if d, err := time.ParseDuration(global.Config.AfterSale.SLA); err == nil && d > 0 {
+		return d
+	}
+	return afterSaleDefaultSLA
+}
+
+func afterSaleMaxImages() int {
+	if n := global.Config.AfterSale.MaxImages; n > 0 {
+		return n
+	}
+	return afterSaleDefaultMaxImages
+}
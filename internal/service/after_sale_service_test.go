@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/common/notify"
+	"takeout/config"
+	"takeout/global"
+	adminRequest "takeout/internal/api/admin/request"
+	"takeout/internal/api/user/request"
+	"takeout/internal/model"
+	"takeout/repository"
+	"testing"
+	"time"
+)
+
+// fakeAfterSaleRepo 内存中的售后单，Resolve 与 Escalate 按状态条件更新
+type fakeAfterSaleRepo struct {
+	sales    []model.AfterSale
+	remakes  []model.Order
+	escalate bool // Escalate 返回 false，模拟其他实例已升级
+}
+
+func (r *fakeAfterSaleRepo) Create(_ context.Context, sale *model.AfterSale) error {
+	sale.Id = uint64(len(r.sales) + 1)
+	sale.CreateTime = time.Now()
+	r.sales = append(r.sales, *sale)
+	return nil
+}
+
+func (r *fakeAfterSaleRepo) GetById(_ context.Context, id uint64) (*model.AfterSale, error) {
+	for _, sale := range r.sales {
+		if sale.Id == id {
+			return &sale, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeAfterSaleRepo) ListByUser(_ context.Context, userId int) ([]model.AfterSale, error) {
+	var sales []model.AfterSale
+	for _, sale := range r.sales {
+		if sale.UserId == userId {
+			sales = append(sales, sale)
+		}
+	}
+	return sales, nil
+}
+
+func (r *fakeAfterSaleRepo) ListByOrder(_ context.Context, orderId int) ([]model.AfterSale, error) {
+	var sales []model.AfterSale
+	for _, sale := range r.sales {
+		if sale.OrderId == orderId {
+			sales = append(sales, sale)
+		}
+	}
+	return sales, nil
+}
+
+func (r *fakeAfterSaleRepo) PageQuery(context.Context, adminRequest.AfterSalePageQueryDTO) (*common.PageResult, error) {
+	return &common.PageResult{}, nil
+}
+
+func (r *fakeAfterSaleRepo) Cancel(_ context.Context, id uint64, userId int) (bool, error) {
+	for i := range r.sales {
+		if r.sales[i].Id == id && r.sales[i].UserId == userId && r.sales[i].Status == enum.AfterSalePending {
+			r.sales[i].Status = enum.AfterSaleCancelled
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeAfterSaleRepo) Resolve(_ context.Context, sale *model.AfterSale, remake *model.Order, _ []model.OrderDetail) (bool, error) {
+	for i := range r.sales {
+		if r.sales[i].Id != sale.Id || r.sales[i].Status != enum.AfterSalePending {
+			continue
+		}
+		if remake != nil {
+			remake.Id = 100 + len(r.remakes)
+			sale.RemakeOrderId = remake.Id
+			r.remakes = append(r.remakes, *remake)
+		}
+		r.sales[i] = *sale
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *fakeAfterSaleRepo) ListOverdue(_ context.Context, now time.Time) ([]model.AfterSale, error) {
+	var sales []model.AfterSale
+	for _, sale := range r.sales {
+		if sale.Status == enum.AfterSalePending && sale.DueTime.Before(now) {
+			sales = append(sales, sale)
+		}
+	}
+	return sales, nil
+}
+
+func (r *fakeAfterSaleRepo) Escalate(_ context.Context, id uint64, level int, due time.Time) (bool, error) {
+	if r.escalate {
+		return false, nil
+	}
+	for i := range r.sales {
+		s := &r.sales[i]
+		if s.Id == id && s.Status == enum.AfterSalePending && s.EscalateLevel == level-1 {
+			s.EscalateLevel, s.DueTime = level, due
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeReportRepo 只记录营业额增量
+type fakeReportRepo struct {
+	repository.ReportRepo
+	turnover map[string]float64
+}
+
+func (r fakeReportRepo) IncrDailyStats(date time.Time, turnover float64, _ int) error {
+	r.turnover[date.Format(time.DateOnly)] += turnover
+	return nil
+}
+
+type fakeNotifier struct {
+	sent []string
+}
+
+func (n *fakeNotifier) Send(_ context.Context, target string, msg *notify.Message) error {
+	n.sent = append(n.sent, target+": "+msg.Text)
+	return nil
+}
+
+// newAfterSaleFixture 用户 7 的订单 1 已于一小时前完成，实付 50 元：菜品 A 两份共 30 元，菜品 B 一份 18 元，打包费 2 元
+func newAfterSaleFixture() (*AfterSaleService, *fakeAfterSaleRepo, fakeReportRepo) {
+	orderTime := time.Now().Add(-2 * time.Hour)
+	orders := map[int]*model.Order{1: {
+		Id: 1, Number: "1001", UserId: 7, Status: enum.Completed, Amount: 50, PackAmount: 2,
+		OrderType: enum.OrderTypeDelivery, OrderTime: model.LocalTime(orderTime),
+		DeliveryTime: model.LocalTime(time.Now().Add(-time.Hour)),
+	}}
+	details := []model.OrderDetail{
+		{Id: 11, OrderId: 1, Name: "A", DishId: 5, Number: 2, Amount: 15},
+		{Id: 12, OrderId: 1, Name: "B", DishId: 6, Number: 1, Amount: 18},
+	}
+	repo := &fakeAfterSaleRepo{}
+	reports := fakeReportRepo{turnover: make(map[string]float64)}
+	s := &AfterSaleService{
+		repo:       repo,
+		orderRepo:  fakeOrderRepo{orders: orders, details: details},
+		reportRepo: reports,
+		notifiers:  notify.Registry{},
+	}
+	return s, repo, reports
+}
+
+func submitDTO(items ...request.AfterSaleItemDTO) request.AfterSaleSubmitDTO {
+	return request.AfterSaleSubmitDTO{OrderId: 1, Type: enum.AfterSaleRefund, Reason: "少送", Items: items}
+}
+
+func TestAfterSaleSubmit(t *testing.T) {
+	s, repo, _ := newAfterSaleFixture()
+	if _, err := s.Submit(userCtx(8), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 11, Number: 1})); !errors.Is(err, e.Error_ORDER_NOT_FOUND) {
+		t.Fatalf("other user's order err = %v", err)
+	}
+	if _, err := s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 11, Number: 3})); !errors.Is(err, e.Error_AFTER_SALE_INVALID) {
+		t.Fatalf("more than ordered err = %v", err)
+	}
+	if _, err := s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 99, Number: 1})); !errors.Is(err, e.Error_AFTER_SALE_INVALID) {
+		t.Fatalf("unknown detail err = %v", err)
+	}
+	sale, err := s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 11, Number: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sale.Amount != 15 || sale.Status != enum.AfterSalePending || time.Until(sale.DueTime) < afterSaleDefaultSLA-time.Minute {
+		t.Fatalf("sale = %+v", sale)
+	}
+	// 同一订单同时只能有一个待处理的售后单
+	if _, err = s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 12, Number: 1})); !errors.Is(err, e.Error_AFTER_SALE_EXISTS) {
+		t.Fatalf("second pending sale err = %v", err)
+	}
+
+	// 已同意的数量从可申请数量中扣除，已拒绝的不扣除
+	repo.sales[0].Status = enum.AfterSaleApproved
+	if _, err = s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 11, Number: 2})); !errors.Is(err, e.Error_AFTER_SALE_INVALID) {
+		t.Fatalf("claim beyond remaining err = %v", err)
+	}
+	if _, err = s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 11, Number: 1})); err != nil {
+		t.Fatal(err)
+	}
+	repo.sales[1].Status = enum.AfterSaleRejected
+	if _, err = s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 11, Number: 1})); err != nil {
+		t.Fatalf("claim after rejection err = %v", err)
+	}
+}
+
+func TestAfterSaleSubmitWindow(t *testing.T) {
+	s, _, _ := newAfterSaleFixture()
+	orders := s.orderRepo.(fakeOrderRepo).orders
+	orders[1].DeliveryTime = model.LocalTime(time.Now().Add(-afterSaleDefaultWindow - time.Minute))
+	if _, err := s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 11, Number: 1})); !errors.Is(err, e.Error_AFTER_SALE_EXPIRED) {
+		t.Fatalf("expired window err = %v", err)
+	}
+	orders[1].Status = enum.DeliveryInProgress
+	if _, err := s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 11, Number: 1})); !errors.Is(err, e.Error_AFTER_SALE_INVALID) {
+		t.Fatalf("uncompleted order err = %v", err)
+	}
+}
+
+func TestAfterSaleCancel(t *testing.T) {
+	s, repo, _ := newAfterSaleFixture()
+	sale, err := s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 11, Number: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Cancel(userCtx(8), sale.Id); !errors.Is(err, e.Error_AFTER_SALE_NOT_FOUND) {
+		t.Fatalf("cancel other user's sale err = %v", err)
+	}
+	if err = s.Cancel(userCtx(7), sale.Id); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Cancel(userCtx(7), sale.Id); !errors.Is(err, e.Error_AFTER_SALE_HANDLED) {
+		t.Fatalf("cancel twice err = %v", err)
+	}
+	if _, err = s.Approve(userCtx(1), adminRequest.AfterSaleApproveDTO{Id: sale.Id, Resolution: enum.AfterSaleRefund, RefundAmount: 1}); !errors.Is(err, e.Error_AFTER_SALE_HANDLED) {
+		t.Fatalf("approve cancelled sale err = %v", err)
+	}
+	if repo.sales[0].Status != enum.AfterSaleCancelled {
+		t.Errorf("status = %d, want cancelled", repo.sales[0].Status)
+	}
+}
+
+func TestAfterSaleApproveRefund(t *testing.T) {
+	s, repo, reports := newAfterSaleFixture()
+	first, err := s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 11, Number: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	approve := adminRequest.AfterSaleApproveDTO{Id: first.Id, Resolution: enum.AfterSaleRefund, RefundAmount: 30.01}
+	if _, err = s.Approve(userCtx(1), approve); !errors.Is(err, e.Error_AFTER_SALE_INVALID) {
+		t.Fatalf("refund above claimed amount err = %v", err)
+	}
+	approve.RefundAmount = 0
+	if _, err = s.Approve(userCtx(1), approve); !errors.Is(err, e.Error_AFTER_SALE_INVALID) {
+		t.Fatalf("zero refund err = %v", err)
+	}
+	approve.RefundAmount = 30
+	sale, err := s.Approve(userCtx(1), approve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sale.Status != enum.AfterSaleApproved || sale.RefundAmount != 30 || sale.HandleUser != 1 {
+		t.Fatalf("approved sale = %+v", sale)
+	}
+	// 退款从下单日期的营业额中扣除
+	orderDate := time.Time(s.orderRepo.(fakeOrderRepo).orders[1].OrderTime).Format(time.DateOnly)
+	if got := reports.turnover[orderDate]; got != -30 {
+		t.Errorf("turnover change = %v, want -30", got)
+	}
+	if _, err = s.Approve(userCtx(1), approve); !errors.Is(err, e.Error_AFTER_SALE_HANDLED) {
+		t.Fatalf("approve twice err = %v", err)
+	}
+
+	// 第二次退款不能超过订单剩余可退金额 20 元
+	second, err := s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 12, Number: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.sales[1].Amount = 25
+	if _, err = s.Approve(userCtx(1), adminRequest.AfterSaleApproveDTO{Id: second.Id, Resolution: enum.AfterSaleRefund, RefundAmount: 20.01}); !errors.Is(err, e.Error_AFTER_SALE_INVALID) {
+		t.Fatalf("refund above remaining err = %v", err)
+	}
+	if _, err = s.Approve(userCtx(1), adminRequest.AfterSaleApproveDTO{Id: second.Id, Resolution: enum.AfterSaleRefund, RefundAmount: 20}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAfterSaleApproveRemake(t *testing.T) {
+	s, repo, reports := newAfterSaleFixture()
+	sale, err := s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 12, Number: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sale, err = s.Approve(userCtx(1), adminRequest.AfterSaleApproveDTO{Id: sale.Id, Resolution: enum.AfterSaleRemake})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.remakes) != 1 || sale.RemakeOrderId != repo.remakes[0].Id {
+		t.Fatalf("remakes = %+v, sale = %+v", repo.remakes, sale)
+	}
+	remake := repo.remakes[0]
+	if remake.RemakeOf != 1 || remake.Amount != 0 || remake.PayStatus != enum.Paid || remake.Status != enum.ToBeConfirmed || remake.UserId != 7 {
+		t.Errorf("remake order = %+v", remake)
+	}
+	// 重做不影响营业额
+	if len(reports.turnover) != 0 {
+		t.Errorf("turnover changed by remake: %v", reports.turnover)
+	}
+}
+
+func TestAfterSaleRemakePickupCode(t *testing.T) {
+	useMiniredis(t)
+	s, repo, _ := newAfterSaleFixture()
+	s.orderRepo.(fakeOrderRepo).orders[1].OrderType = enum.OrderTypePickup
+	sale, err := s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 12, Number: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Approve(userCtx(1), adminRequest.AfterSaleApproveDTO{Id: sale.Id, Resolution: enum.AfterSaleRemake}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.remakes[0].PickupCode == "" {
+		t.Error("pickup remake without pickup code")
+	}
+}
+
+func TestAfterSaleReject(t *testing.T) {
+	s, repo, _ := newAfterSaleFixture()
+	sale, err := s.Submit(userCtx(7), submitDTO(request.AfterSaleItemDTO{OrderDetailId: 11, Number: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Reject(userCtx(1), adminRequest.AfterSaleRejectDTO{Id: sale.Id, Reply: " 图片无法证明问题 "}); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.sales[0]; got.Status != enum.AfterSaleRejected || got.Reply != "图片无法证明问题" {
+		t.Errorf("rejected sale = %+v", got)
+	}
+	if err = s.Reject(userCtx(1), adminRequest.AfterSaleRejectDTO{Id: sale.Id, Reply: "x"}); !errors.Is(err, e.Error_AFTER_SALE_HANDLED) {
+		t.Errorf("reject twice err = %v", err)
+	}
+	if err = s.Reject(userCtx(1), adminRequest.AfterSaleRejectDTO{Id: 99, Reply: "x"}); !errors.Is(err, e.Error_AFTER_SALE_NOT_FOUND) {
+		t.Errorf("reject unknown sale err = %v", err)
+	}
+}
+
+func TestAfterSaleEscalation(t *testing.T) {
+	s, repo, _ := newAfterSaleFixture()
+	notifier := &fakeNotifier{}
+	s.notifiers = notify.Registry{"test": notifier}
+	global.Config.AfterSale.Escalations = []config.Recipient{{Channel: "test", Target: "店长"}, {Channel: "missing", Target: "x"}}
+	t.Cleanup(func() { global.Config.AfterSale.Escalations = nil })
+
+	now := time.Now()
+	repo.sales = []model.AfterSale{
+		{Id: 1, OrderNumber: "1001", Reason: "少送", Status: enum.AfterSalePending, DueTime: now.Add(-time.Minute)},
+		{Id: 2, OrderNumber: "1002", Reason: "错送", Status: enum.AfterSalePending, DueTime: now.Add(time.Hour)},
+		{Id: 3, OrderNumber: "1003", Reason: "少送", Status: enum.AfterSaleApproved, DueTime: now.Add(-time.Hour)},
+	}
+	s.escalateOverdue()
+	if got := repo.sales[0]; got.EscalateLevel != 1 || time.Until(got.DueTime) < afterSaleDefaultSLA-time.Minute {
+		t.Fatalf("overdue sale = %+v, want level 1 and a new deadline", got)
+	}
+	if repo.sales[1].EscalateLevel != 0 || repo.sales[2].EscalateLevel != 0 {
+		t.Errorf("sales within SLA or handled were escalated: %+v", repo.sales)
+	}
+	// 未知渠道发送失败不影响其他接收方
+	if len(notifier.sent) != 1 || !slices.Contains(notifier.sent, "店长: 订单 1001 的售后申请（少送）已超时未处理，第 1 次提醒。") {
+		t.Fatalf("sent = %v", notifier.sent)
+	}
+
+	// 重新计时前不再重复提醒
+	s.escalateOverdue()
+	if len(notifier.sent) != 1 {
+		t.Errorf("sent again before the new deadline: %v", notifier.sent)
+	}
+	repo.sales[0].DueTime = now.Add(-time.Minute)
+	s.escalateOverdue()
+	if repo.sales[0].EscalateLevel != 2 || len(notifier.sent) != 2 {
+		t.Errorf("second escalation = %+v, sent = %v", repo.sales[0], notifier.sent)
+	}
+
+	// 其他实例已升级时不发送提醒
+	repo.escalate = true
+	repo.sales[0].DueTime = now.Add(-time.Minute)
+	s.escalateOverdue()
+	if len(notifier.sent) != 2 {
+		t.Errorf("sent after another instance escalated: %v", notifier.sent)
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	cartLineOK          = "ok"
+	cartLineUnavailable = "unavailable"
+	cartLineRepriced    = "repriced"
+	cartLineExceeded    = "exceeded"
+
+	cartDefaultMaxQuantity = 99
+)
+
+// ValidateShoppingCart 按当前菜品、套餐与口味校验购物车，变价的商品按当前价格更新并返回差价
+func (s ShoppingCartService) ValidateShoppingCart(ctx *gin.Context, dto request.CartValidateDTO) (*response.CartValidateVO, error) {
+	return s.validate(ctx, int(ctx.MustGet(enum.CurrentId).(uint64)), dto.OrderType)
+}
+
+// checkout 下单前校验购物车，有下架、超限或变价的商品以及未达起送金额时不能下单
+func (s ShoppingCartService) checkout(ctx context.Context, userId, orderType int) error {
+	vo, err := s.validate(ctx, userId, orderType)
+	if err != nil {
+		return err
+	}
+	if len(vo.Lines) == 0 {
+		return e.Error_SHOPPING_CART_IS_NULL
+	}
+	for _, line := range vo.Lines {
+		switch line.Status {
+		case cartLineUnavailable:
+			return fmt.Errorf("%w: %s", e.Error_CART_ITEM_UNAVAILABLE, line.Name)
+		case cartLineExceeded:
+			return fmt.Errorf("%w: %s 最多 %d 份", e.Error_CART_QUANTITY_EXCEEDED, line.Name, line.MaxQuantity)
+		}
+	}
+	if vo.Repriced {
+		return e.Error_CART_REPRICED
+	}
+	if vo.Shortfall > 0 {
+		return fmt.Errorf("%w: 还差 %.2f 元", e.Error_CART_BELOW_MINIMUM, vo.Shortfall)
+	}
+	return nil
+}
+
+func (s ShoppingCartService) validate(ctx context.Context, userId, orderType int) (*response.CartValidateVO, error) {
+	lines, err := s.repo.List(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	var dishIds, setmealIds []int
+	for _, line := range lines {
+		if line.DishId != 0 {
+			dishIds = append(dishIds, line.DishId)
+		} else {
+			setmealIds = append(setmealIds, line.SetmealId)
+		}
+	}
+	dishList, err := s.repo.ListDishes(ctx, dishIds)
+	if err != nil {
+		return nil, err
+	}
+	setmealList, err := s.repo.ListSetmeals(ctx, setmealIds)
+	if err != nil {
+		return nil, err
+	}
+	dishes := make(map[int]model.Dish, len(dishList))
+	for _, dish := range dishList {
+		dishes[int(dish.Id)] = dish
+	}
+	setmeals := make(map[int]model.SetMeal, len(setmealList))
+	for _, setmeal := range setmealList {
+		setmeals[int(setmeal.Id)] = setmeal
+	}
+
+	vo := &response.CartValidateVO{Lines: make([]response.CartLineVO, 0, len(lines))}
+	quantities := make(map[string]int)
+	for _, line := range lines {
+		res := response.CartLineVO{ShoppingCart: line, Status: cartLineOK}
+		name, image, price, reason := cartCurrent(line, dishes, setmeals)
+		if reason != "" {
+			res.Status, res.Reason = cartLineUnavailable, reason
+			vo.Lines = append(vo.Lines, res)
+			continue
+		}
+		if name != line.Name || image != line.Image || price != line.Amount {
+			fresh := line
+			fresh.Name, fresh.Image, fresh.Amount = name, image, price
+			if _, err = s.repo.UpdateLine(ctx, fresh); err != nil {
+				return nil, err
+			}
+			res.ShoppingCart = fresh
+			if price != line.Amount {
+				res.Status = cartLineRepriced
+				res.OldAmount = line.Amount
+				res.Diff = roundCent(price - line.Amount)
+				vo.Repriced = true
+			}
+		}
+		quantities[cartItemKey(line)] += line.Number
+		vo.Lines = append(vo.Lines, res)
+	}
+
+	for i := range vo.Lines {
+		res := &vo.Lines[i]
+		if res.Status == cartLineUnavailable {
+			continue
+		}
+		if limit := cartLimit(cartItemKey(res.ShoppingCart)); quantities[cartItemKey(res.ShoppingCart)] > limit {
+			res.Status, res.MaxQuantity = cartLineExceeded, limit
+			res.Reason = fmt.Sprintf("每单限购 %d 份", limit)
+		}
+		vo.Number += res.Number
+		vo.Total = roundCent(vo.Total + res.Amount*float64(res.Number))
+	}
+	if orderType == 0 || orderType == enum.OrderTypeDelivery {
+		vo.MinAmount = global.Config.Cart.MinAmount
+		vo.Shortfall = max(roundCent(vo.MinAmount-vo.Total), 0)
+	}
+	vo.Valid = len(vo.Lines) > 0 && vo.Shortfall == 0
+	for _, res := range vo.Lines {
+		if res.Status == cartLineUnavailable || res.Status == cartLineExceeded {
+			vo.Valid = false
+		}
+	}
+	return vo, nil
+}
+
+// cartCurrent 购物车行对应商品的当前名称、图片与单价，不可购买时返回原因
+func cartCurrent(line model.ShoppingCart, dishes map[int]model.Dish, setmeals map[int]model.SetMeal) (string, string, float64, string) {
+	if line.DishId == 0 {
+		setmeal, ok := setmeals[line.SetmealId]
+		if !ok || setmeal.Status != int(enum.ENABLE) {
+			return "", "", 0, "套餐已下架"
+		}
+		return setmeal.Name, setmeal.Image, setmeal.Price, ""
+	}
+	dish, ok := dishes[line.DishId]
+	if !ok || dish.Status != int(enum.ENABLE) {
+		return "", "", 0, "菜品已下架"
+	}
+	if !flavorAvailable(dish, line.DishFlavor) {
+		return "", "", 0, "口味已调整，请重新选择"
+	}
+	return dish.Name, dish.Image, dish.Price, ""
+}
+
+// flavorAvailable 所选口味均在菜品当前的口味选项中
+func flavorAvailable(dish model.Dish, flavor string) bool {
+	selected := splitFlavor(flavor)
+	if len(selected) == 0 {
+		return true
+	}
+	options := dishFlavorOptions(dish)
+	for _, v := range selected {
+		if !options[v] {
+			return false
+		}
+	}
+	return true
+}
+
+// cartItemKey 限购按菜品或套餐计算，不区分口味
+func cartItemKey(line model.ShoppingCart) string {
+	if line.DishId != 0 {
+		return fmt.Sprintf("dish:%d", line.DishId)
+	}
+	return fmt.Sprintf("setmeal:%d", line.SetmealId)
+}
+
+func cartLimit(key string) int {
+	if n, ok := global.Config.Cart.Limits[key]; ok && n > 0 {
+		return n
+	}
+	if n := global.Config.Cart.MaxQuantity; n > 0 {
+		return n
+	}
+	return cartDefaultMaxQuantity
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/internal/model"
+	"testing"
+)
+
+func TestCheckout(t *testing.T) {
+	repo := &fakeCartRepo{
+		lines: []model.ShoppingCart{
+			{Name: "宫保鸡丁", DishId: 1, Number: 2, Amount: 28},
+			{Name: "双人套餐", SetmealId: 3, Number: 1, Amount: 58.5},
+		},
+		dishes:   []model.Dish{{Id: 1, Name: "宫保鸡丁", Price: 28, Status: int(enum.ENABLE)}},
+		setmeals: []model.SetMeal{{Id: 3, Name: "双人套餐", Price: 58.5, Status: int(enum.ENABLE)}},
+	}
+	if err := (ShoppingCartService{repo: repo}).checkout(context.Background(), 7, enum.OrderTypePickup); err != nil {
+		t.Fatalf("checkout err = %v", err)
+	}
+
+	// 变价后需要用户重新确认，不按页面上的旧价格下单
+	repo.dishes[0].Price = 30
+	if err := (ShoppingCartService{repo: repo}).checkout(context.Background(), 7, enum.OrderTypePickup); !errors.Is(err, e.Error_CART_REPRICED) {
+		t.Fatalf("checkout after reprice err = %v", err)
+	}
+	if repo.lines[0].Amount != 30 {
+		t.Errorf("repriced line amount = %v, want 30", repo.lines[0].Amount)
+	}
+
+	// 下架的商品不能下单
+	repo.setmeals[0].Status = int(enum.DISABLE)
+	if err := (ShoppingCartService{repo: repo}).checkout(context.Background(), 7, enum.OrderTypePickup); !errors.Is(err, e.Error_CART_ITEM_UNAVAILABLE) {
+		t.Fatalf("checkout with unavailable setmeal err = %v", err)
+	}
+}
@@ -5,12 +5,20 @@ import (
 	"errors"
 	"strconv"
 	"takeout/common"
+	"takeout/common/cache"
 	"takeout/common/enum"
 	"takeout/internal/api/admin/request"
 	"takeout/internal/model"
 	"takeout/repository"
+	"time"
 )
 
+// CategoryCacheKey redis key 分类缓存key
+const CategoryCacheKey = "categoryCache::"
+
+// categoryCache 按分类类型缓存分类列表，分类数量少，变更时整体失效
+var categoryCache = cache.New(CategoryCacheKey, cache.WithTTL(time.Hour), cache.WithLocal(64, 5*time.Minute))
+
 type ICategoryService interface {
 	AddCategory(ctx context.Context, dto request.CategoryDTO) error
 	PageQuery(ctx context.Context, dto request.CategoryPageQueryDTO) (*common.PageResult, error)
@@ -38,12 +46,16 @@ func (c *CategoryImpl) AddCategory(ctx context.Context, dto request.CategoryDTO)
 		return errors.New("category name cannot be empty")
 	}
 
-	return c.repo.Insert(ctx, model.Category{
+	if err = c.repo.Insert(ctx, model.Category{
 		Type:   typeInStr,
 		Name:   dto.Name,
 		Sort:   sortInStr,
 		Status: enum.DISABLE,
-	})
+	}); err != nil {
+		return err
+	}
+	categoryCache.InvalidateAll()
+	return nil
 }
 
 func (c *CategoryImpl) PageQuery(ctx context.Context, dto request.CategoryPageQueryDTO) (*common.PageResult, error) {
@@ -51,14 +63,20 @@ func (c *CategoryImpl) PageQuery(ctx context.Context, dto request.CategoryPageQu
 }
 
 func (c *CategoryImpl) List(ctx context.Context, cate int) ([]model.Category, error) {
-	return c.repo.List(ctx, cate)
+	return cache.Fetch(ctx, categoryCache, strconv.Itoa(cate), func(ctx context.Context) ([]model.Category, error) {
+		return c.repo.List(ctx, cate)
+	}, nil)
 }
 
 func (c *CategoryImpl) DeleteById(ctx context.Context, id uint64) error {
 	if id == 0 {
 		return errors.New("invalid category ID")
 	}
-	return c.repo.DeleteById(ctx, id)
+	if err := c.repo.DeleteById(ctx, id); err != nil {
+		return err
+	}
+	categoryCache.InvalidateAll()
+	return nil
 }
 
 func (c *CategoryImpl) Update(ctx context.Context, dto request.CategoryDTO) error {
@@ -73,22 +91,30 @@ func (c *CategoryImpl) Update(ctx context.Context, dto request.CategoryDTO) erro
 	if err != nil {
 		return errors.New("invalid category type")
 	}
-	return c.repo.Update(ctx, model.Category{
+	if err = c.repo.Update(ctx, model.Category{
 		Id:   dto.Id,
 		Name: dto.Name,
 		Sort: sort,
 		Type: type_,
-	})
+	}); err != nil {
+		return err
+	}
+	categoryCache.InvalidateAll()
+	return nil
 }
 
 func (c *CategoryImpl) SetStatus(ctx context.Context, id uint64, status int) error {
 	if status != 0 && status != 1 {
 		return errors.New("invalid status, it must be 0 or 1")
 	}
-	return c.repo.SetStatus(ctx, model.Category{
+	if err := c.repo.SetStatus(ctx, model.Category{
 		Id:     id,
 		Status: status,
-	})
+	}); err != nil {
+		return err
+	}
+	categoryCache.InvalidateAll()
+	return nil
 }
 
 func NewCategoryService(repo repository.CategoryRepo) ICategoryService {
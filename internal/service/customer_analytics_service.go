@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"takeout/common"
+	"takeout/common/cohort"
+	"takeout/common/period"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/repository"
+	"time"
+)
+
+const (
+	churnDefaultDays = 60
+	churnMaxDays     = 3650
+)
+
+type ICustomerAnalyticsService interface {
+	Cohorts(ctx context.Context, dto request.ReportQueryDTO) (response.CohortReportVO, error)
+	Summary(ctx context.Context, dto request.ReportQueryDTO) (response.CustomerSummaryVO, error)
+	Churned(ctx context.Context, dto request.ChurnedQueryDTO) (*common.PageResult, error)
+}
+
+type CustomerAnalyticsService struct {
+	repo repository.CustomerRepo
+}
+
+func NewCustomerAnalyticsService(repo repository.CustomerRepo) ICustomerAnalyticsService {
+	return &CustomerAnalyticsService{repo: repo}
+}
+
+// Cohorts 群组留存：按首单所在周（或月）分组，统计之后每个周期仍有下单的客户占比
+func (s *CustomerAnalyticsService) Cohorts(ctx context.Context, dto request.ReportQueryDTO) (response.CohortReportVO, error) {
+	if dto.Granularity == "" {
+		dto.Granularity = string(period.Week)
+	}
+	if dto.Granularity != string(period.Week) && dto.Granularity != string(period.Month) {
+		return response.CohortReportVO{}, reportInvalid("群组留存的统计粒度须为 week/month")
+	}
+	r, err := period.Parse(dto.Begin, dto.End, dto.Granularity, dto.TimeZone)
+	if err != nil {
+		return response.CohortReportVO{}, err
+	}
+	begin, end := r.Begin.In(time.Local), r.End.In(time.Local)
+	orders, err := s.repo.ListOrders(ctx, begin, end)
+	if err != nil {
+		return response.CohortReportVO{}, err
+	}
+	lifetimes, err := s.repo.Lifetimes(ctx, begin, end)
+	if err != nil {
+		return response.CohortReportVO{}, err
+	}
+	first := make(map[int]time.Time, len(lifetimes))
+	for _, lifetime := range lifetimes {
+		first[lifetime.UserId] = lifetime.FirstOrder
+	}
+
+	rows := cohort.Retention(r, first, orders)
+	res := response.CohortReportVO{ReportMetaVO: reportMeta(r), Rows: make([]response.CohortRowVO, len(rows))}
+	for i, row := range rows {
+		periods := make([]response.CohortPeriodVO, len(row.Active))
+		for j, users := range row.Active {
+			periods[j] = response.CohortPeriodVO{Offset: j, Users: users, Rate: ratio(users, row.Size)}
+		}
+		res.Rows[i] = response.CohortRowVO{Cohort: r.Label(row.Cohort), Size: row.Size, Periods: periods}
+	}
+	return res, nil
+}
+
+// Summary 区间内下单客户的新老构成、复购率、下单频次与生命周期价值
+func (s *CustomerAnalyticsService) Summary(ctx context.Context, dto request.ReportQueryDTO) (response.CustomerSummaryVO, error) {
+	r, err := period.Parse(dto.Begin, dto.End, "", dto.TimeZone)
+	if err != nil {
+		return response.CustomerSummaryVO{}, err
+	}
+	begin, end := r.Begin.In(time.Local), r.End.In(time.Local)
+	orders, err := s.repo.ListOrders(ctx, begin, end)
+	if err != nil {
+		return response.CustomerSummaryVO{}, err
+	}
+	lifetimes, err := s.repo.Lifetimes(ctx, begin, end)
+	if err != nil {
+		return response.CustomerSummaryVO{}, err
+	}
+
+	stats := cohort.Summarize(orders)
+	res := response.CustomerSummaryVO{
+		Period:     reportPeriod(r),
+		Customers:  stats.Customers,
+		Orders:     stats.Orders,
+		RepeatRate: ratio(stats.Repeaters, stats.Customers),
+		AvgGapDays: round2(stats.AvgGapDays),
+	}
+	if stats.Customers > 0 {
+		res.AvgOrders = round2(float64(stats.Orders) / float64(stats.Customers))
+		res.AvgOrderValue = round2(stats.Amount / float64(stats.Orders))
+	}
+	var amount, lifespan float64
+	var lifetimeOrders int
+	for _, lifetime := range lifetimes {
+		if lifetime.FirstOrder.Before(begin) {
+			res.ReturningCustomers++
+		} else {
+			res.NewCustomers++
+		}
+		amount += lifetime.Amount
+		lifetimeOrders += lifetime.Orders
+		lifespan += lifetime.LastOrder.Sub(lifetime.FirstOrder).Hours() / 24
+	}
+	if n := float64(len(lifetimes)); n > 0 {
+		res.AvgLifetimeValue = round2(amount / n)
+		res.AvgLifetimeOrders = round2(float64(lifetimeOrders) / n)
+		res.AvgLifespanDays = round2(lifespan / n)
+	}
+	return res, nil
+}
+
+// Churned 超过指定天数未下单的客户，按累计消费额降序
+func (s *CustomerAnalyticsService) Churned(ctx context.Context, dto request.ChurnedQueryDTO) (*common.PageResult, error) {
+	if dto.Days == 0 {
+		dto.Days = churnDefaultDays
+	}
+	if dto.Days < 0 || dto.Days > churnMaxDays {
+		return nil, reportInvalid("days 须在 1 到 %d 之间", churnMaxDays)
+	}
+	now := time.Now()
+	pageResult, err := s.repo.PageChurned(ctx, now.AddDate(0, 0, -dto.Days), dto)
+	if err != nil {
+		return nil, err
+	}
+	customers := pageResult.Records.([]response.ChurnedCustomerVO)
+	for i := range customers {
+		customers[i].Amount = round2(customers[i].Amount)
+		customers[i].DaysSince = int(now.Sub(time.Time(customers[i].LastOrderTime)).Hours() / 24)
+	}
+	return pageResult, nil
+}
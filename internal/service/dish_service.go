@@ -2,27 +2,24 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"strconv"
 	"strings"
 	"takeout/common"
+	"takeout/common/cache"
 	"takeout/common/enum"
-	"takeout/common/utils"
 	"takeout/global"
 	"takeout/internal/api/admin/request"
 	"takeout/internal/api/admin/response"
 	"takeout/internal/model"
 	"takeout/repository"
+	"time"
 )
 
 // DishCacheKey redis key 菜品缓存key
 const DishCacheKey = "dishCache::"
 
-var bf *utils.RedisBloom
-
-func init() {
-	bf = utils.NewRedisBloomFilter(DishCacheKey)
-}
+// dishCache 按分类缓存菜品列表，一级缓存在各实例进程内，写操作通过 Redis 广播失效，列表中每个菜品打上 dish:{id} 标签
+var dishCache = cache.New(DishCacheKey, cache.WithTTL(30*time.Minute), cache.WithLocal(512, 5*time.Minute))
 
 type IDishService interface {
 	AddDishWithFlavors(ctx context.Context, dto request.DishDTO) error
@@ -37,6 +34,7 @@ type IDishService interface {
 type DishServiceImpl struct {
 	repo           repository.DishRepo
 	dishFlavorRepo repository.DishFlavorRepo
+	reviewRepo     repository.ReviewRepo
 }
 
 func (d DishServiceImpl) AddDishWithFlavors(ctx context.Context, dto request.DishDTO) error {
@@ -88,11 +86,9 @@ func (d DishServiceImpl) AddDishWithFlavors(ctx context.Context, dto request.Dis
 		return err
 	}
 
-	// 清理缓存
-	utils.CleanCache(DishCacheKey + "*")
-	// 添加布隆过滤器
-	_ = bf.AddDish(strconv.FormatUint(dto.Id, 10))
-	global.Log.Info("Cache cleared for dish data")
+	// 清理所属分类的缓存
+	dishCache.Invalidate(categoryKey(dto.CategoryId))
+	global.Log.Info("Cache cleared for dish data", "categoryId", dto.CategoryId)
 
 	return nil
 }
@@ -138,43 +134,33 @@ func (d DishServiceImpl) GetByIdWithFlavors(ctx context.Context, id uint64) (res
 }
 
 func (d DishServiceImpl) List(ctx context.Context, categoryId uint64) ([]response.DishListVo, error) {
-	var (
-		dishes    []model.Dish
-		cacheData string
-		dishList  []response.DishListVo
-		err       error
-	)
-
-	// 查询 Redis 缓存
-	cacheData, err = global.RedisClient.Get(DishCacheKey + strconv.Itoa(int(categoryId))).Result()
-
-	if err == nil {
-		global.Log.Info("Redis cache hit for dishes", "categoryId", categoryId)
-		if err = json.Unmarshal([]byte(cacheData), &dishList); err == nil {
-			return dishList, nil
-		} else {
-			global.Log.Warn("Failed to unmarshal cached data", "categoryId", categoryId, "error", err)
-		}
-	} else {
-		global.Log.Info("Cache miss for dishes", "categoryId", categoryId)
-		// 布隆过滤器
-		has, _ := bf.HasDish(strconv.FormatUint(categoryId, 10))
-		if !has {
-			global.Log.Info("Bad Request,No Data in Mysql")
-			return make([]response.DishListVo, 0), nil
-		}
-	}
+	return cache.Fetch(ctx, dishCache, categoryKey(categoryId), func(ctx context.Context) ([]response.DishListVo, error) {
+		return d.list(ctx, categoryId)
+	}, dishTags)
+}
 
-	// 查询数据库
-	dishes, err = d.repo.List(ctx, categoryId)
+// list 从数据库查询分类下的菜品及评分汇总
+func (d DishServiceImpl) list(ctx context.Context, categoryId uint64) ([]response.DishListVo, error) {
+	dishes, err := d.repo.List(ctx, categoryId)
 	if err != nil {
 		global.Log.Error("Failed to fetch dishes from DB", "categoryId", categoryId, "error", err)
 		return nil, err
 	}
 
+	// 查询评分汇总
+	dishIds := make([]uint64, len(dishes))
+	for i := range dishes {
+		dishIds[i] = dishes[i].Id
+	}
+	ratings, err := d.reviewRepo.GetDishRatings(ctx, dishIds)
+	if err != nil {
+		global.Log.Error("Failed to fetch dish ratings", "categoryId", categoryId, "error", err)
+		return nil, err
+	}
+
 	// 转换为响应数据格式
 	count := len(dishes)
-	dishList = make([]response.DishListVo, count)
+	dishList := make([]response.DishListVo, count)
 	for i := 0; i < count; i++ {
 		dishList[i] = response.DishListVo{
 			Id:          dishes[i].Id,
@@ -188,27 +174,19 @@ func (d DishServiceImpl) List(ctx context.Context, categoryId uint64) ([]respons
 			UpdateTime:  dishes[i].UpdateTime,
 			CreateUser:  dishes[i].CreateUser,
 			UpdateUser:  dishes[i].UpdateUser,
+			AvgRating:   ratings[dishes[i].Id].AvgRating,
+			RatingCount: ratings[dishes[i].Id].RatingCount,
 		}
 	}
-
-	// 设置 Redis 缓存
-	if dishVoJSON, err := json.Marshal(dishList); err == nil {
-		//global.RedisClient.HSet()
-		if err = global.RedisClient.Set(DishCacheKey+strconv.Itoa(int(categoryId)), dishVoJSON, 0).Err(); err != nil {
-			global.Log.Warn("Failed to set Redis cache", "categoryId", categoryId, "error", err)
-		} else {
-			global.Log.Info("Successfully cached dishes in Redis", "categoryId", categoryId)
-		}
-	}
-
-	_ = bf.AddDish(strconv.FormatUint(categoryId, 10))
-
 	return dishList, nil
 }
 
 func (d DishServiceImpl) OnOrClose(ctx context.Context, id uint64, status int) error {
-	utils.CleanCache(DishCacheKey + "*")
-	return d.repo.OnOrClose(ctx, id, status)
+	if err := d.repo.OnOrClose(ctx, id, status); err != nil {
+		return err
+	}
+	dishCache.InvalidateTags(dishTag(id))
+	return nil
 }
 
 func (d DishServiceImpl) Update(ctx context.Context, dto request.DishUpdateDTO) error {
@@ -254,9 +232,9 @@ func (d DishServiceImpl) Update(ctx context.Context, dto request.DishUpdateDTO)
 	if err = transaction.Commit().Error; err != nil {
 		return err // 这里会直接返回错误，defer 中的回滚会执行一次
 	}
-	utils.CleanCache(DishCacheKey + "*")
-	// 添加布隆过滤器
-	_ = bf.AddDish(strconv.FormatUint(dto.Id, 10))
+	// 清理原分类（包含该菜品的列表）与新分类的缓存
+	dishCache.InvalidateTags(dishTag(dto.Id))
+	dishCache.Invalidate(categoryKey(dto.CategoryId))
 	return nil
 }
 
@@ -298,15 +276,29 @@ func (d *DishServiceImpl) Delete(ctx context.Context, ids string) error {
 
 		// 记录删除成功
 		global.Log.Info("Successfully deleted dish", "dishId", dishId)
+		dishCache.InvalidateTags(dishTag(dishId))
 	}
-
-	// 清理缓存
-	utils.CleanCache(DishCacheKey + "*")
 	global.Log.Info("Cache cleared after deletion")
 
 	return nil
 }
 
-func NewDishService(repo repository.DishRepo, dishFlavorRepo repository.DishFlavorRepo) IDishService {
-	return &DishServiceImpl{repo: repo, dishFlavorRepo: dishFlavorRepo}
+func NewDishService(repo repository.DishRepo, dishFlavorRepo repository.DishFlavorRepo, reviewRepo repository.ReviewRepo) IDishService {
+	return &DishServiceImpl{repo: repo, dishFlavorRepo: dishFlavorRepo, reviewRepo: reviewRepo}
+}
+
+func categoryKey(categoryId uint64) string {
+	return strconv.FormatUint(categoryId, 10)
+}
+
+func dishTag(dishId uint64) string {
+	return "dish:" + strconv.FormatUint(dishId, 10)
+}
+
+func dishTags(dishes []response.DishListVo) []string {
+	tags := make([]string, len(dishes))
+	for i := range dishes {
+		tags[i] = dishTag(dishes[i].Id)
+	}
+	return tags
 }
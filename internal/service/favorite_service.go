@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"takeout/common/enum"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/internal/model"
+	"takeout/repository"
+)
+
+type IFavoriteService interface {
+	AddFavorite(ctx *gin.Context, dto request.FavoriteDTO) error
+	DeleteFavorite(ctx *gin.Context, dto request.FavoriteDTO) error
+	IsFavorite(ctx *gin.Context, dto request.FavoriteDTO) (bool, error)
+	ListFavorite(ctx *gin.Context) ([]response.FavoriteVO, error)
+}
+
+type FavoriteService struct {
+	repo repository.FavoriteRepo
+}
+
+func NewFavoriteService(repo repository.FavoriteRepo) IFavoriteService {
+	return &FavoriteService{repo: repo}
+}
+
+// AddFavorite 收藏菜品或套餐
+func (s *FavoriteService) AddFavorite(ctx *gin.Context, dto request.FavoriteDTO) error {
+	if err := validFavorite(dto); err != nil {
+		return err
+	}
+	return s.repo.Add(ctx, model.Favorite{
+		UserId:    int(ctx.MustGet(enum.CurrentId).(uint64)),
+		DishId:    dto.DishId,
+		SetmealId: dto.SetmealId,
+	})
+}
+
+// DeleteFavorite 取消收藏
+func (s *FavoriteService) DeleteFavorite(ctx *gin.Context, dto request.FavoriteDTO) error {
+	if err := validFavorite(dto); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, int(ctx.MustGet(enum.CurrentId).(uint64)), dto.DishId, dto.SetmealId)
+}
+
+// IsFavorite 查询是否已收藏
+func (s *FavoriteService) IsFavorite(ctx *gin.Context, dto request.FavoriteDTO) (bool, error) {
+	if err := validFavorite(dto); err != nil {
+		return false, err
+	}
+	return s.repo.Exists(ctx, int(ctx.MustGet(enum.CurrentId).(uint64)), dto.DishId, dto.SetmealId)
+}
+
+// ListFavorite 查询我的收藏
+func (s *FavoriteService) ListFavorite(ctx *gin.Context) ([]response.FavoriteVO, error) {
+	return s.repo.List(ctx, int(ctx.MustGet(enum.CurrentId).(uint64)))
+}
+
+func validFavorite(dto request.FavoriteDTO) error {
+	if (dto.DishId == 0) == (dto.SetmealId == 0) {
+		return errors.New("dishId 与 setmealId 必须且只能传一个")
+	}
+	return nil
+}
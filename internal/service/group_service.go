@@ -0,0 +1,638 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/internal/model"
+	"takeout/internal/router/websocket"
+	"takeout/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	groupDefaultTTL     = 2 * time.Hour
+	groupDefaultMembers = 20
+	groupMaxNickname    = 16
+	groupDefaultPay     = 30 * time.Minute
+)
+
+type IGroupService interface {
+	CreateGroup(ctx *gin.Context, dto request.GroupCreateDTO) (*response.GroupCartVO, error)
+	// ListGroups 当前用户参与的进行中拼单
+	ListGroups(ctx *gin.Context) ([]model.GroupCart, error)
+	// GetGroup 持有邀请码即可查看，便于决定是否加入
+	GetGroup(ctx *gin.Context, code string) (*response.GroupCartVO, error)
+	JoinGroup(ctx *gin.Context, code string, dto request.GroupJoinDTO) (*response.GroupCartVO, error)
+	// LeaveGroup 成员退出，其添加的菜品一并移除，发起人不能退出
+	LeaveGroup(ctx *gin.Context, code string) error
+	// RemoveMember 发起人移除成员
+	RemoveMember(ctx *gin.Context, code string, userId int) error
+
+	AddItem(ctx *gin.Context, code string, dto request.ShoppingCartDTO) error
+	SubItem(ctx *gin.Context, code string, dto request.ShoppingCartDTO) error
+
+	// Lock 发起人锁定后成员不能再修改，解锁后可继续修改
+	Lock(ctx *gin.Context, code string) error
+	Unlock(ctx *gin.Context, code string) error
+	Cancel(ctx *gin.Context, code string) error
+	// Checkout 发起人对已锁定的拼单下单，AA 支付时按成员菜品小计分摊打包费
+	Checkout(ctx *gin.Context, code string, dto request.GroupCheckoutDTO) (*response.GroupCheckoutVO, error)
+	// PayShare AA 支付时成员支付自己的份额，全部支付后订单进入待接单
+	PayShare(ctx *gin.Context, code string) error
+}
+
+type GroupService struct {
+	repo        repository.GroupCartRepo
+	orderRepo   repository.OrderRepo
+	addressRepo repository.AddressBookRepo
+}
+
+func NewGroupService(repo repository.GroupCartRepo, orderRepo repository.OrderRepo, addressRepo repository.AddressBookRepo) IGroupService {
+	service := &GroupService{repo: repo, orderRepo: orderRepo, addressRepo: addressRepo}
+	// 每分钟取消超过支付时限的 AA 订单，按订单状态条件更新，多个实例同时执行时只有一个生效
+	timerTask := cron.New(cron.WithSeconds())
+	if _, err := timerTask.AddFunc("30 * * * * ?", service.cancelUnpaidSplitOrders); err != nil {
+		global.Log.Warn("TimerTaskError")
+	}
+	timerTask.Start()
+	return service
+}
+
+// CreateGroup 发起拼单
+func (s *GroupService) CreateGroup(ctx *gin.Context, dto request.GroupCreateDTO) (*response.GroupCartVO, error) {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	code, err := newGroupCode()
+	if err != nil {
+		return nil, err
+	}
+	group := &model.GroupCart{
+		Code:       code,
+		OwnerId:    userId,
+		Name:       truncateNickname(dto.Name, "拼单"),
+		Status:     enum.GroupOpen,
+		SplitBill:  dto.SplitBill,
+		ExpireTime: time.Now().Add(groupTTL()),
+	}
+	owner := &model.GroupMember{UserId: userId, Nickname: truncateNickname(dto.Nickname, "发起人")}
+	if err = s.repo.CreateGroup(ctx, group, owner); err != nil {
+		return nil, err
+	}
+	return s.groupVO(ctx, group, userId)
+}
+
+// ListGroups 当前用户参与的拼单
+func (s *GroupService) ListGroups(ctx *gin.Context) ([]model.GroupCart, error) {
+	return s.repo.ListUserGroups(ctx, int(ctx.MustGet(enum.CurrentId).(uint64)), time.Now())
+}
+
+// GetGroup 拼单详情
+func (s *GroupService) GetGroup(ctx *gin.Context, code string) (*response.GroupCartVO, error) {
+	group, err := s.getGroup(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.groupVO(ctx, group, int(ctx.MustGet(enum.CurrentId).(uint64)))
+}
+
+// JoinGroup 加入拼单，已加入时更新昵称
+func (s *GroupService) JoinGroup(ctx *gin.Context, code string, dto request.GroupJoinDTO) (*response.GroupCartVO, error) {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	group, err := s.openGroup(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repo.ListMembers(ctx, group.Id)
+	if err != nil {
+		return nil, err
+	}
+	joined := slices.ContainsFunc(members, func(m model.GroupMember) bool { return m.UserId == userId })
+	if !joined && len(members) >= groupMaxMembers() {
+		return nil, e.Error_GROUP_FULL
+	}
+	member := &model.GroupMember{
+		GroupId:  group.Id,
+		UserId:   userId,
+		Nickname: truncateNickname(dto.Nickname, "成员"+strconv.Itoa(len(members)+1)),
+	}
+	if err = s.repo.AddMember(ctx, member); err != nil {
+		return nil, err
+	}
+	publishGroup(group.Code, "join", userId)
+	return s.groupVO(ctx, group, userId)
+}
+
+// LeaveGroup 退出拼单
+func (s *GroupService) LeaveGroup(ctx *gin.Context, code string) error {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	group, err := s.openGroup(ctx, code)
+	if err != nil {
+		return err
+	}
+	if group.OwnerId == userId {
+		return fmt.Errorf("%w: 发起人请取消拼单", e.Error_GROUP_INVALID)
+	}
+	if err = s.repo.RemoveMember(ctx, group.Id, userId); err != nil {
+		return err
+	}
+	publishGroup(group.Code, "leave", userId)
+	return nil
+}
+
+// RemoveMember 发起人移除成员
+func (s *GroupService) RemoveMember(ctx *gin.Context, code string, userId int) error {
+	group, err := s.ownedGroup(ctx, code)
+	if err != nil {
+		return err
+	}
+	if group.Status != enum.GroupOpen {
+		return e.Error_GROUP_CLOSED
+	}
+	if userId == group.OwnerId {
+		return fmt.Errorf("%w: 不能移除发起人", e.Error_GROUP_INVALID)
+	}
+	if err = s.repo.RemoveMember(ctx, group.Id, userId); err != nil {
+		return err
+	}
+	publishGroup(group.Code, "leave", userId)
+	return nil
+}
+
+// AddItem 成员添加菜品或套餐
+func (s *GroupService) AddItem(ctx *gin.Context, code string, dto request.ShoppingCartDTO) error {
+	group, userId, err := s.memberGroup(ctx, code)
+	if err != nil {
+		return err
+	}
+	if (dto.DishId == 0) == (dto.SetmealId == 0) {
+		return fmt.Errorf("%w: 请选择一个菜品或套餐", e.Error_GROUP_INVALID)
+	}
+	ok, err := s.repo.AddItem(ctx, groupItem(group, userId, dto))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: 菜品或套餐不存在或已停售", e.Error_GROUP_INVALID)
+	}
+	publishGroup(group.Code, "item", userId)
+	return nil
+}
+
+// SubItem 成员减少自己的菜品
+func (s *GroupService) SubItem(ctx *gin.Context, code string, dto request.ShoppingCartDTO) error {
+	group, userId, err := s.memberGroup(ctx, code)
+	if err != nil {
+		return err
+	}
+	ok, err := s.repo.SubtractItem(ctx, groupItem(group, userId, dto))
+	if err != nil || !ok {
+		return err
+	}
+	publishGroup(group.Code, "item", userId)
+	return nil
+}
+
+// Lock 锁定拼单
+func (s *GroupService) Lock(ctx *gin.Context, code string) error {
+	return s.transition(ctx, code, enum.GroupOpen, enum.GroupLocked, "lock")
+}
+
+// Unlock 解锁拼单
+func (s *GroupService) Unlock(ctx *gin.Context, code string) error {
+	return s.transition(ctx, code, enum.GroupLocked, enum.GroupOpen, "unlock")
+}
+
+// Cancel 取消进行中或已锁定的拼单
+func (s *GroupService) Cancel(ctx *gin.Context, code string) error {
+	group, err := s.ownedGroup(ctx, code)
+	if err != nil {
+		return err
+	}
+	if group.Status != enum.GroupOpen && group.Status != enum.GroupLocked {
+		return e.Error_GROUP_CLOSED
+	}
+	ok, err := s.repo.SetStatus(ctx, group.Id, group.Status, enum.GroupCancelled)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return e.Error_GROUP_CLOSED
+	}
+	publishGroup(group.Code, "cancel", group.OwnerId)
+	return nil
+}
+
+func (s *GroupService) transition(ctx *gin.Context, code string, from, to int, event string) error {
+	group, err := s.ownedGroup(ctx, code)
+	if err != nil {
+		return err
+	}
+	ok, err := s.repo.SetStatus(ctx, group.Id, from, to)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return e.Error_GROUP_CLOSED
+	}
+	publishGroup(group.Code, event, group.OwnerId)
+	return nil
+}
+
+// Checkout 拼单下单，同一菜品与口味合并为一行订单明细
+func (s *GroupService) Checkout(ctx *gin.Context, code string, dto request.GroupCheckoutDTO) (*response.GroupCheckoutVO, error) {
+	group, err := s.ownedGroup(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if group.Status != enum.GroupLocked {
+		return nil, fmt.Errorf("%w: 请先锁定拼单", e.Error_GROUP_CLOSED)
+	}
+	items, err := s.repo.ListItems(ctx, group.Id)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: 拼单中还没有菜品", e.Error_GROUP_INVALID)
+	}
+	if dto.PackAmount < 0 {
+		return nil, fmt.Errorf("%w: 打包费不能为负数", e.Error_GROUP_INVALID)
+	}
+
+	order := &model.Order{
+		Number:          strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Status:          enum.PendingPayment,
+		UserId:          group.OwnerId,
+		PayMethod:       dto.PayMethod,
+		PayStatus:       enum.UnPaid,
+		Remark:          strings.TrimSpace("拼单「" + group.Name + "」 " + dto.Remark),
+		DeliveryStatus:  enum.DeliverNow,
+		PackAmount:      dto.PackAmount,
+		TablewareNumber: dto.TablewareNumber,
+		TablewareStatus: dto.TablewareStatus,
+		OrderType:       dto.OrderType,
+	}
+	switch dto.OrderType {
+	case 0, enum.OrderTypeDelivery:
+		order.OrderType = enum.OrderTypeDelivery
+		address, err := s.addressRepo.GetAddressById(ctx, uint64(dto.AddressBookId))
+		if err != nil {
+			return nil, err
+		}
+		if address.Id == 0 || address.UserId != group.OwnerId {
+			return nil, fmt.Errorf("%w: 请选择收货地址", e.Error_ORDER_TYPE_INVALID)
+		}
+		order.AddressBookId = address.Id
+		order.Phone, order.Address, order.Consignee = address.Phone, address.Detail, address.Consignee
+	case enum.OrderTypePickup:
+		if order.PickupCode, err = nextPickupCode(time.Now()); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: 拼单仅支持外卖与自取", e.Error_ORDER_TYPE_INVALID)
+	}
+
+	details, subtotals, total := mergeGroupItems(items)
+	order.Amount = roundCent(total + dto.PackAmount)
+	splitBill := group.SplitBill
+	if dto.SplitBill != nil {
+		splitBill = *dto.SplitBill
+	}
+	var payments []model.GroupPayment
+	if splitBill {
+		payments = splitShares(subtotals, total, order.Amount)
+	}
+	ok, err := s.repo.Checkout(ctx, group, order, details, payments)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, e.Error_GROUP_CLOSED
+	}
+	publishGroup(group.Code, "checkout", group.OwnerId)
+	return &response.GroupCheckoutVO{
+		OrderSubmitVO: response.OrderSubmitVO{
+			OrderId:     order.Id,
+			OrderNumber: order.Number,
+			OrderAmount: order.Amount,
+			OrderTime:   order.OrderTime,
+		},
+		Payments: payments,
+	}, nil
+}
+
+// PayShare 模拟支付成员份额，最后一个成员支付后订单视为已支付
+func (s *GroupService) PayShare(ctx *gin.Context, code string) error {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	group, err := s.getGroup(ctx, code)
+	if err != nil {
+		return err
+	}
+	if group.Status != enum.GroupOrdered {
+		return fmt.Errorf("%w: 拼单尚未下单", e.Error_GROUP_INVALID)
+	}
+	order, err := s.orderRepo.GetOrderById(strconv.Itoa(group.OrderId))
+	if err != nil {
+		return err
+	}
+	if order == nil || order.Id == 0 || order.Status != enum.PendingPayment {
+		return e.Error_ORDER_STATUS_ERROR
+	}
+	// 预约订单在推送时间之前不提醒商家，由定时任务到期推送
+	now := time.Now()
+	status := enum.ToBeConfirmed
+	if holdScheduled(order, now) {
+		status = enum.Scheduled
+	}
+	paid, unpaid, err := s.repo.PayShare(ctx, group.Id, order.Id, userId, status, now)
+	if err != nil {
+		return err
+	}
+	if !paid {
+		return fmt.Errorf("%w: 没有待支付的份额或订单已取消", e.Error_GROUP_INVALID)
+	}
+	global.Log.Info("拼单成员支付", "group", group.Code, "userId", userId)
+	publishGroup(group.Code, "paid", userId)
+	if unpaid == 0 && status != enum.Scheduled {
+		notifyNewOrder(order)
+	}
+	return nil
+}
+
+// cancelUnpaidSplitOrders 取消超过支付时限仍有成员未支付的 AA 订单，已支付的份额原路退还
+func (s *GroupService) cancelUnpaidSplitOrders() {
+	ctx := context.Background()
+	now := time.Now()
+	orders, err := s.repo.ListUnpaidSplitOrders(ctx, now.Add(-groupPayTimeout()))
+	if err != nil {
+		global.Log.Warn("List unpaid split orders failed", "error", err)
+		return
+	}
+	for _, order := range orders {
+		refunds, ok, err := s.repo.CancelSplitOrder(ctx, order.Id, "AA 支付超时，自动取消", now)
+		if err != nil {
+			global.Log.Warn("Cancel split order failed", "orderId", order.Id, "error", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		for _, share := range refunds {
+			// 调用微信退款接口，此处进行模拟
+			log.Printf("AA 订单支付超时取消, 退还成员 %d 份额: [%v￥]", share.UserId, share.Amount)
+		}
+	}
+}
+
+// getGroup 查询拼单，进行中或已锁定的拼单过期后视为不存在
+func (s *GroupService) getGroup(ctx *gin.Context, code string) (*model.GroupCart, error) {
+	group, err := s.repo.GetGroupByCode(ctx, strings.TrimSpace(code))
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, e.Error_GROUP_NOT_FOUND
+	}
+	if (group.Status == enum.GroupOpen || group.Status == enum.GroupLocked) && time.Now().After(group.ExpireTime) {
+		return nil, e.Error_GROUP_NOT_FOUND
+	}
+	return group, nil
+}
+
+// openGroup 可加入与修改的拼单
+func (s *GroupService) openGroup(ctx *gin.Context, code string) (*model.GroupCart, error) {
+	group, err := s.getGroup(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if group.Status != enum.GroupOpen {
+		return nil, e.Error_GROUP_CLOSED
+	}
+	return group, nil
+}
+
+// memberGroup 当前用户已加入的进行中拼单
+func (s *GroupService) memberGroup(ctx *gin.Context, code string) (*model.GroupCart, int, error) {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	group, err := s.openGroup(ctx, code)
+	if err != nil {
+		return nil, 0, err
+	}
+	members, err := s.repo.ListMembers(ctx, group.Id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !slices.ContainsFunc(members, func(m model.GroupMember) bool { return m.UserId == userId }) {
+		return nil, 0, e.Error_GROUP_NOT_MEMBER
+	}
+	return group, userId, nil
+}
+
+// ownedGroup 当前用户发起的拼单
+func (s *GroupService) ownedGroup(ctx *gin.Context, code string) (*model.GroupCart, error) {
+	group, err := s.getGroup(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if group.OwnerId != int(ctx.MustGet(enum.CurrentId).(uint64)) {
+		return nil, e.Error_GROUP_NOT_OWNER
+	}
+	return group, nil
+}
+
+// groupVO 按成员加入顺序汇总菜品与小计
+func (s *GroupService) groupVO(ctx *gin.Context, group *model.GroupCart, userId int) (*response.GroupCartVO, error) {
+	members, err := s.repo.ListMembers(ctx, group.Id)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ListItems(ctx, group.Id)
+	if err != nil {
+		return nil, err
+	}
+	vo := &response.GroupCartVO{
+		Code:         group.Code,
+		Name:         group.Name,
+		OwnerId:      group.OwnerId,
+		IsOwner:      group.OwnerId == userId,
+		Status:       group.Status,
+		SplitBill:    group.SplitBill,
+		OrderId:      group.OrderId,
+		ExpireTime:   model.LocalTime(group.ExpireTime),
+		InviteURL:    groupInviteURL(group.Code),
+		Participants: make([]response.GroupParticipantVO, 0, len(members)),
+	}
+	index := make(map[int]int, len(members))
+	for _, m := range members {
+		index[m.UserId] = len(vo.Participants)
+		vo.IsMember = vo.IsMember || m.UserId == userId
+		vo.Participants = append(vo.Participants, response.GroupParticipantVO{
+			UserId:   m.UserId,
+			Nickname: m.Nickname,
+			IsOwner:  m.UserId == group.OwnerId,
+			Lines:    []model.GroupCartItem{},
+		})
+	}
+	for _, item := range items {
+		i, ok := index[item.UserId]
+		if !ok {
+			continue
+		}
+		p := &vo.Participants[i]
+		p.Lines = append(p.Lines, item)
+		p.Number += item.Number
+		p.Subtotal = roundCent(p.Subtotal + item.Amount*float64(item.Number))
+		vo.Number += item.Number
+		vo.Total = roundCent(vo.Total + item.Amount*float64(item.Number))
+	}
+	if group.Status == enum.GroupOrdered && group.SplitBill {
+		if vo.Payments, err = s.repo.ListPayments(ctx, group.Id); err != nil {
+			return nil, err
+		}
+	}
+	return vo, nil
+}
+
+// mergeGroupItems 合并相同菜品与口味为订单明细，同时返回各成员的菜品小计与总额
+func mergeGroupItems(items []model.GroupCartItem) ([]model.OrderDetail, map[int]float64, float64) {
+	var (
+		details   []model.OrderDetail
+		index     = make(map[string]int)
+		subtotals = make(map[int]float64)
+		total     float64
+	)
+	for _, item := range items {
+		amount := item.Amount * float64(item.Number)
+		subtotals[item.UserId] += amount
+		total += amount
+		key := fmt.Sprintf("%d:%d:%s", item.DishId, item.SetmealId, item.DishFlavor)
+		if i, ok := index[key]; ok {
+			details[i].Number += item.Number
+			continue
+		}
+		index[key] = len(details)
+		details = append(details, model.OrderDetail{
+			Name:       item.Name,
+			DishId:     item.DishId,
+			SetmealId:  item.SetmealId,
+			DishFlavor: item.DishFlavor,
+			Number:     item.Number,
+			Amount:     item.Amount,
+			Image:      item.Image,
+		})
+	}
+	return details, subtotals, total
+}
+
+// splitShares 按菜品小计比例分摊订单金额（含打包费），精确到分，尾差计入最后一位成员
+func splitShares(subtotals map[int]float64, total, amount float64) []model.GroupPayment {
+	userIds := make([]int, 0, len(subtotals))
+	for userId, subtotal := range subtotals {
+		if subtotal > 0 {
+			userIds = append(userIds, userId)
+		}
+	}
+	slices.Sort(userIds)
+	payments := make([]model.GroupPayment, 0, len(userIds))
+	remaining := amount
+	for i, userId := range userIds {
+		share := remaining
+		if i < len(userIds)-1 {
+			share = roundCent(amount * subtotals[userId] / total)
+			remaining = roundCent(remaining - share)
+		}
+		payments = append(payments, model.GroupPayment{UserId: userId, Amount: share, PayStatus: enum.UnPaid})
+	}
+	return payments
+}
+
+func groupItem(group *model.GroupCart, userId int, dto request.ShoppingCartDTO) model.GroupCartItem {
+	return model.GroupCartItem{
+		GroupId:    group.Id,
+		UserId:     userId,
+		DishId:     dto.DishId,
+		SetmealId:  dto.SetmealId,
+		DishFlavor: dto.DishFlavor,
+	}
+}
+
+// publishGroup 通知订阅了该拼单的客户端重新拉取拼单详情
+func publishGroup(code, event string, userId int) {
+	websocket.WSServer.Publish(map[string]any{
+		"type":   wsGroupCart,
+		"event":  event,
+		"code":   code,
+		"userId": userId,
+	}, "group:"+code)
+}
+
+func groupInviteURL(code string) string {
+	base := global.Config.Group.InviteBaseURL
+	if base == "" {
+		return ""
+	}
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + "code=" + url.QueryEscape(code)
+}
+
+// newGroupCode 12 位十六进制邀请码
+func newGroupCode() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate group code: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func truncateNickname(name, def string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return def
+	}
+	if runes := []rune(name); len(runes) > groupMaxNickname {
+		return string(runes[:groupMaxNickname])
+	}
+	return name
+}
+
+func roundCent(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func groupTTL() time.Duration {
+	if d, err := time.ParseDuration(global.Config.Group.TTL); err == nil && d > 0 {
+		return d
+	}
+	return groupDefaultTTL
+}
+
+func groupPayTimeout() time.Duration {
+	if d, err := time.ParseDuration(global.Config.Group.PayTimeout); err == nil && d > 0 {
+		return d
+	}
+	return groupDefaultPay
+}
+
+func groupMaxMembers() int {
+	if n := global.Config.Group.MaxMembers; n > 0 {
+		return n
+	}
+	return groupDefaultMembers
+}
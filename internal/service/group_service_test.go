@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/model"
+	"testing"
+	"time"
+)
+
+// fakeGroupRepo 内存中的拼单，orders 同时作为 fakeOrderRepo 的数据
+type fakeGroupRepo struct {
+	groups   []*model.GroupCart
+	members  []model.GroupMember
+	items    []model.GroupCartItem
+	payments []model.GroupPayment
+	orders   map[int]*model.Order
+	before   time.Time // 最近一次 ListUnpaidSplitOrders 的截止时间
+}
+
+func (r *fakeGroupRepo) CreateGroup(_ context.Context, group *model.GroupCart, owner *model.GroupMember) error {
+	group.Id = uint64(len(r.groups) + 1)
+	r.groups = append(r.groups, group)
+	owner.GroupId = group.Id
+	r.members = append(r.members, *owner)
+	return nil
+}
+
+func (r *fakeGroupRepo) GetGroupByCode(_ context.Context, code string) (*model.GroupCart, error) {
+	for _, group := range r.groups {
+		if group.Code == code {
+			g := *group
+			return &g, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeGroupRepo) ListUserGroups(context.Context, int, time.Time) ([]model.GroupCart, error) {
+	return nil, nil
+}
+
+func (r *fakeGroupRepo) SetStatus(_ context.Context, id uint64, from, to int) (bool, error) {
+	for _, group := range r.groups {
+		if group.Id == id && group.Status == from {
+			group.Status = to
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeGroupRepo) ListMembers(_ context.Context, groupId uint64) ([]model.GroupMember, error) {
+	var members []model.GroupMember
+	for _, m := range r.members {
+		if m.GroupId == groupId {
+			members = append(members, m)
+		}
+	}
+	return members, nil
+}
+
+func (r *fakeGroupRepo) AddMember(_ context.Context, member *model.GroupMember) error {
+	for i := range r.members {
+		if r.members[i].GroupId == member.GroupId && r.members[i].UserId == member.UserId {
+			r.members[i].Nickname = member.Nickname
+			return nil
+		}
+	}
+	r.members = append(r.members, *member)
+	return nil
+}
+
+func (r *fakeGroupRepo) RemoveMember(_ context.Context, groupId uint64, userId int) error {
+	r.members = slices.DeleteFunc(r.members, func(m model.GroupMember) bool { return m.GroupId == groupId && m.UserId == userId })
+	r.items = slices.DeleteFunc(r.items, func(i model.GroupCartItem) bool { return i.GroupId == groupId && i.UserId == userId })
+	return nil
+}
+
+func (r *fakeGroupRepo) ListItems(_ context.Context, groupId uint64) ([]model.GroupCartItem, error) {
+	var items []model.GroupCartItem
+	for _, item := range r.items {
+		if item.GroupId == groupId {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (r *fakeGroupRepo) AddItem(_ context.Context, item model.GroupCartItem) (bool, error) {
+	item.Number, item.Name, item.Amount = 1, "菜品", 10
+	r.items = append(r.items, item)
+	return true, nil
+}
+
+func (r *fakeGroupRepo) SubtractItem(context.Context, model.GroupCartItem) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeGroupRepo) Checkout(_ context.Context, group *model.GroupCart, order *model.Order, _ []model.OrderDetail, payments []model.GroupPayment) (bool, error) {
+	stored, _ := r.GetGroupByCode(context.Background(), group.Code)
+	if stored == nil || stored.Status != enum.GroupLocked {
+		return false, nil
+	}
+	order.Id = len(r.orders) + 1
+	order.OrderTime = model.LocalTime(time.Now())
+	o := *order
+	r.orders[order.Id] = &o
+	for _, p := range payments {
+		p.GroupId, p.OrderId = group.Id, order.Id
+		r.payments = append(r.payments, p)
+	}
+	for _, g := range r.groups {
+		if g.Id == group.Id {
+			g.Status, g.OrderId = enum.GroupOrdered, order.Id
+		}
+	}
+	group.Status, group.OrderId = enum.GroupOrdered, order.Id
+	return true, nil
+}
+
+func (r *fakeGroupRepo) ListPayments(_ context.Context, groupId uint64) ([]model.GroupPayment, error) {
+	var payments []model.GroupPayment
+	for _, p := range r.payments {
+		if p.GroupId == groupId {
+			payments = append(payments, p)
+		}
+	}
+	return payments, nil
+}
+
+func (r *fakeGroupRepo) PayShare(_ context.Context, groupId uint64, orderId int, userId int, status int, now time.Time) (bool, int64, error) {
+	order := r.orders[orderId]
+	if order == nil || order.Status != enum.PendingPayment {
+		return false, 0, nil
+	}
+	paid := false
+	var unpaid int64
+	for i := range r.payments {
+		p := &r.payments[i]
+		if p.GroupId != groupId || p.OrderId != orderId {
+			continue
+		}
+		if p.UserId == userId && p.PayStatus == enum.UnPaid {
+			p.PayStatus, p.PayTime = enum.Paid, model.LocalTime(now)
+			paid = true
+		}
+		if p.PayStatus == enum.UnPaid {
+			unpaid++
+		}
+	}
+	if paid && unpaid == 0 {
+		order.Status, order.PayStatus, order.CheckoutTime = status, enum.Paid, model.LocalTime(now)
+	}
+	return paid, unpaid, nil
+}
+
+func (r *fakeGroupRepo) ListUnpaidSplitOrders(_ context.Context, before time.Time) ([]model.Order, error) {
+	r.before = before
+	var orders []model.Order
+	for _, order := range r.orders {
+		if order.Status == enum.PendingPayment && time.Time(order.OrderTime).Before(before) &&
+			slices.ContainsFunc(r.payments, func(p model.GroupPayment) bool { return p.OrderId == order.Id }) {
+			orders = append(orders, *order)
+		}
+	}
+	return orders, nil
+}
+
+func (r *fakeGroupRepo) CancelSplitOrder(_ context.Context, orderId int, reason string, now time.Time) ([]model.GroupPayment, bool, error) {
+	order := r.orders[orderId]
+	if order == nil || order.Status != enum.PendingPayment {
+		return nil, false, nil
+	}
+	order.Status, order.CancelReason, order.CancelTime = enum.Cancelled, reason, model.LocalTime(now)
+	var refunds []model.GroupPayment
+	for i := range r.payments {
+		if r.payments[i].OrderId == orderId && r.payments[i].PayStatus == enum.Paid {
+			refunds = append(refunds, r.payments[i])
+			r.payments[i].PayStatus = enum.Refund
+		}
+	}
+	return refunds, true, nil
+}
+
+// newGroupFixture 用户 1 发起的拼单 g1，用户 2 已加入
+func newGroupFixture(status int, splitBill bool) (*GroupService, *fakeGroupRepo) {
+	repo := &fakeGroupRepo{orders: make(map[int]*model.Order)}
+	repo.groups = []*model.GroupCart{{
+		Id: 1, Code: "g1", OwnerId: 1, Status: status, SplitBill: splitBill, ExpireTime: time.Now().Add(time.Hour),
+	}}
+	repo.members = []model.GroupMember{{GroupId: 1, UserId: 1, Nickname: "发起人"}, {GroupId: 1, UserId: 2, Nickname: "成员2"}}
+	return &GroupService{repo: repo, orderRepo: fakeOrderRepo{orders: repo.orders}}, repo
+}
+
+func TestSplitShares(t *testing.T) {
+	// 打包费按菜品小计比例分摊，尾差计入最后一位成员
+	shares := splitShares(map[int]float64{3: 10, 1: 10, 2: 10, 4: 0}, 30, 31)
+	var sum float64
+	var users []int
+	for _, share := range shares {
+		sum = roundCent(sum + share.Amount)
+		users = append(users, share.UserId)
+		if share.PayStatus != enum.UnPaid {
+			t.Errorf("share %+v not unpaid", share)
+		}
+	}
+	if !slices.Equal(users, []int{1, 2, 3}) || sum != 31 {
+		t.Fatalf("shares = %+v, want users 1..3 summing to 31", shares)
+	}
+	if shares[0].Amount != 10.33 || shares[2].Amount != 10.34 {
+		t.Errorf("shares = %+v, want 10.33 10.33 10.34", shares)
+	}
+}
+
+func TestMergeGroupItems(t *testing.T) {
+	details, subtotals, total := mergeGroupItems([]model.GroupCartItem{
+		{UserId: 1, DishId: 5, DishFlavor: "辣", Number: 1, Amount: 12},
+		{UserId: 2, DishId: 5, DishFlavor: "辣", Number: 2, Amount: 12},
+		{UserId: 2, DishId: 5, DishFlavor: "不辣", Number: 1, Amount: 12},
+		{UserId: 2, SetmealId: 3, Number: 1, Amount: 30},
+	})
+	if len(details) != 3 || details[0].Number != 3 {
+		t.Fatalf("details = %+v, want same dish and flavor merged", details)
+	}
+	if subtotals[1] != 12 || subtotals[2] != 66 || total != 78 {
+		t.Errorf("subtotals = %v, total = %v", subtotals, total)
+	}
+}
+
+func TestJoinGroup(t *testing.T) {
+	s, repo := newGroupFixture(enum.GroupOpen, false)
+	global.Config.Group.MaxMembers = 3
+	t.Cleanup(func() { global.Config.Group.MaxMembers = 0 })
+
+	vo, err := s.JoinGroup(userCtx(3), "g1", request.GroupJoinDTO{Nickname: "  "})
+	if err != nil || len(vo.Participants) != 3 || vo.Participants[2].Nickname != "成员3" {
+		t.Fatalf("join = %+v, %v", vo, err)
+	}
+	if _, err = s.JoinGroup(userCtx(4), "g1", request.GroupJoinDTO{}); !errors.Is(err, e.Error_GROUP_FULL) {
+		t.Fatalf("join full group err = %v", err)
+	}
+	// 已加入的成员再次加入只更新昵称
+	if _, err = s.JoinGroup(userCtx(3), "g1", request.GroupJoinDTO{Nickname: "小王"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.members) != 3 || repo.members[2].Nickname != "小王" {
+		t.Errorf("members = %+v", repo.members)
+	}
+
+	repo.groups[0].Status = enum.GroupLocked
+	if _, err = s.JoinGroup(userCtx(5), "g1", request.GroupJoinDTO{}); !errors.Is(err, e.Error_GROUP_CLOSED) {
+		t.Errorf("join locked group err = %v", err)
+	}
+	repo.groups[0].Status, repo.groups[0].ExpireTime = enum.GroupOpen, time.Now().Add(-time.Minute)
+	if _, err = s.JoinGroup(userCtx(5), "g1", request.GroupJoinDTO{}); !errors.Is(err, e.Error_GROUP_NOT_FOUND) {
+		t.Errorf("join expired group err = %v", err)
+	}
+}
+
+func TestGroupMemberPermissions(t *testing.T) {
+	s, _ := newGroupFixture(enum.GroupOpen, false)
+	item := request.ShoppingCartDTO{DishId: 5}
+	if err := s.AddItem(userCtx(9), "g1", item); !errors.Is(err, e.Error_GROUP_NOT_MEMBER) {
+		t.Errorf("non-member add item err = %v", err)
+	}
+	if err := s.AddItem(userCtx(2), "g1", request.ShoppingCartDTO{DishId: 5, SetmealId: 3}); !errors.Is(err, e.Error_GROUP_INVALID) {
+		t.Errorf("add dish and setmeal together err = %v", err)
+	}
+	if err := s.Lock(userCtx(2), "g1"); !errors.Is(err, e.Error_GROUP_NOT_OWNER) {
+		t.Errorf("member lock err = %v", err)
+	}
+	if err := s.LeaveGroup(userCtx(1), "g1"); !errors.Is(err, e.Error_GROUP_INVALID) {
+		t.Errorf("owner leave err = %v", err)
+	}
+	if err := s.Lock(userCtx(1), "g1"); err != nil {
+		t.Fatal(err)
+	}
+	// 锁定后成员不能再修改菜品
+	if err := s.AddItem(userCtx(2), "g1", item); !errors.Is(err, e.Error_GROUP_CLOSED) {
+		t.Errorf("add item to locked group err = %v", err)
+	}
+}
+
+func TestGroupSplitBillPayment(t *testing.T) {
+	useMiniredis(t)
+	s, repo := newGroupFixture(enum.GroupLocked, true)
+	repo.items = []model.GroupCartItem{
+		{GroupId: 1, UserId: 1, DishId: 5, Number: 1, Amount: 10},
+		{GroupId: 1, UserId: 2, DishId: 6, Number: 2, Amount: 10},
+	}
+	vo, err := s.Checkout(userCtx(1), "g1", request.GroupCheckoutDTO{OrderType: enum.OrderTypePickup, PackAmount: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vo.OrderAmount != 33 || len(vo.Payments) != 2 || vo.Payments[0].Amount != 11 || vo.Payments[1].Amount != 22 {
+		t.Fatalf("checkout = %+v", vo)
+	}
+	order := repo.orders[vo.OrderId]
+	if order.PickupCode == "" || order.Status != enum.PendingPayment {
+		t.Fatalf("order = %+v", order)
+	}
+	if _, err = s.Checkout(userCtx(1), "g1", request.GroupCheckoutDTO{OrderType: enum.OrderTypePickup}); !errors.Is(err, e.Error_GROUP_CLOSED) {
+		t.Fatalf("second checkout err = %v", err)
+	}
+
+	if err = s.PayShare(userCtx(2), "g1"); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != enum.PendingPayment || order.PayStatus != enum.UnPaid {
+		t.Fatalf("order paid before every share: %+v", order)
+	}
+	if err = s.PayShare(userCtx(2), "g1"); !errors.Is(err, e.Error_GROUP_INVALID) {
+		t.Errorf("paying twice err = %v", err)
+	}
+	if err = s.PayShare(userCtx(3), "g1"); !errors.Is(err, e.Error_GROUP_INVALID) {
+		t.Errorf("paying without a share err = %v", err)
+	}
+	if err = s.PayShare(userCtx(1), "g1"); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != enum.ToBeConfirmed || order.PayStatus != enum.Paid {
+		t.Errorf("order after last share = %+v", order)
+	}
+	if err = s.PayShare(userCtx(1), "g1"); !errors.Is(err, e.Error_ORDER_STATUS_ERROR) {
+		t.Errorf("paying a paid order err = %v", err)
+	}
+}
+
+func TestCancelUnpaidSplitOrders(t *testing.T) {
+	useMiniredis(t)
+	s, repo := newGroupFixture(enum.GroupLocked, true)
+	repo.items = []model.GroupCartItem{
+		{GroupId: 1, UserId: 1, DishId: 5, Number: 1, Amount: 10},
+		{GroupId: 1, UserId: 2, DishId: 6, Number: 1, Amount: 10},
+	}
+	vo, err := s.Checkout(userCtx(1), "g1", request.GroupCheckoutDTO{OrderType: enum.OrderTypePickup})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.PayShare(userCtx(1), "g1"); err != nil {
+		t.Fatal(err)
+	}
+	order := repo.orders[vo.OrderId]
+
+	// 未超过支付时限时不取消
+	s.cancelUnpaidSplitOrders()
+	if order.Status != enum.PendingPayment {
+		t.Fatalf("order cancelled before the timeout: %+v", order)
+	}
+	if d := time.Since(repo.before); d < groupDefaultPay || d > groupDefaultPay+time.Minute {
+		t.Errorf("cutoff = %v ago, want %v", d, groupDefaultPay)
+	}
+
+	order.OrderTime = model.LocalTime(time.Now().Add(-groupDefaultPay - time.Minute))
+	s.cancelUnpaidSplitOrders()
+	if order.Status != enum.Cancelled {
+		t.Fatalf("order after timeout = %+v", order)
+	}
+	if repo.payments[0].PayStatus != enum.Refund || repo.payments[1].PayStatus != enum.UnPaid {
+		t.Errorf("payments after cancel = %+v, want paid share refunded", repo.payments)
+	}
+	// 取消后剩余成员不能再支付
+	if err = s.PayShare(userCtx(2), "g1"); !errors.Is(err, e.Error_ORDER_STATUS_ERROR) {
+		t.Errorf("paying a cancelled order err = %v", err)
+	}
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/internal/model"
+	"takeout/internal/router/websocket"
+	"takeout/repository"
+	"time"
+)
+
+// WebSocket 消息类型，1 来单与 2 催单见订单服务
+const (
+	wsKitchenUpdate = 3 // 后厨看板变更，仅推送给订阅了 kds 主题的客户端
+	wsOrderReady    = 4 // 整单出品待派送，推送给全部客户端
+	wsPickupReady   = 5 // 自取与堂食订单出餐，推送取餐码给全部客户端
+	wsGroupCart     = 6 // 拼单变更，仅推送给订阅了 group:邀请码 主题的客户端
+	wsAfterSale     = 7 // 售后单超时升级，推送给全部客户端
+)
+
+// 后厨看板的 WebSocket 主题：kds 为全部工位，kds:<工位id> 为单个工位
+const kitchenTopic = "kds"
+
+type IKitchenService interface {
+	ListStations(ctx context.Context) ([]model.KitchenStation, error)
+	AddStation(ctx context.Context, dto request.KitchenStationDTO) (*model.KitchenStation, error)
+	EditStation(ctx context.Context, dto request.KitchenStationDTO) error
+	DeleteStation(ctx context.Context, id uint64) error
+	SetStationCategories(ctx context.Context, dto request.StationCategoriesDTO) error
+
+	Board(ctx context.Context, dto request.KitchenBoardDTO) (response.KitchenBoardVO, error)
+	StartItems(ctx context.Context, ids []uint64) error
+	DoneItems(ctx context.Context, ids []uint64) error
+	Bump(ctx context.Context, dto request.KitchenBumpDTO) error
+}
+
+type KitchenService struct {
+	repo repository.KitchenRepo
+}
+
+func NewKitchenService(repo repository.KitchenRepo) IKitchenService {
+	return &KitchenService{repo: repo}
+}
+
+// ListStations 工位列表
+func (s *KitchenService) ListStations(ctx context.Context) ([]model.KitchenStation, error) {
+	return s.repo.ListStations(ctx)
+}
+
+// AddStation 新增工位
+func (s *KitchenService) AddStation(ctx context.Context, dto request.KitchenStationDTO) (*model.KitchenStation, error) {
+	station := model.KitchenStation{Name: strings.TrimSpace(dto.Name), Sort: dto.Sort, Status: enum.ENABLE}
+	if dto.Status != nil {
+		station.Status = *dto.Status
+	}
+	if err := s.repo.InsertStation(ctx, &station); err != nil {
+		return nil, err
+	}
+	station.CategoryIds = []uint64{}
+	return &station, nil
+}
+
+// EditStation 修改工位
+func (s *KitchenService) EditStation(ctx context.Context, dto request.KitchenStationDTO) error {
+	station, err := s.getStation(ctx, dto.Id)
+	if err != nil {
+		return err
+	}
+	station.Name, station.Sort = strings.TrimSpace(dto.Name), dto.Sort
+	if dto.Status != nil {
+		station.Status = *dto.Status
+	}
+	return s.repo.UpdateStation(ctx, station)
+}
+
+// DeleteStation 删除工位，还有未出品的菜品时不能删除
+func (s *KitchenService) DeleteStation(ctx context.Context, id uint64) error {
+	if _, err := s.getStation(ctx, id); err != nil {
+		return err
+	}
+	count, err := s.repo.CountOpenItems(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return e.Error_STATION_IN_USE
+	}
+	return s.repo.DeleteStation(ctx, id)
+}
+
+// SetStationCategories 为工位分配分类，之后接单的订单按新分配生成出品项
+func (s *KitchenService) SetStationCategories(ctx context.Context, dto request.StationCategoriesDTO) error {
+	if _, err := s.getStation(ctx, dto.StationId); err != nil {
+		return err
+	}
+	ids := slices.Clone(dto.CategoryIds)
+	slices.Sort(ids)
+	return s.repo.SetStationCategories(ctx, dto.StationId, slices.Compact(ids))
+}
+
+func (s *KitchenService) getStation(ctx context.Context, id uint64) (*model.KitchenStation, error) {
+	station, err := s.repo.GetStation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if station == nil {
+		return nil, e.Error_STATION_NOT_FOUND
+	}
+	return station, nil
+}
+
+// Board 后厨看板：按进入后厨的先后排列，工位视图只显示该工位的出品项
+func (s *KitchenService) Board(ctx context.Context, dto request.KitchenBoardDTO) (response.KitchenBoardVO, error) {
+	s.dispatchPending(ctx)
+	board := response.KitchenBoardVO{StationId: dto.StationId, Tickets: []response.KitchenTicketVO{}}
+	orders, err := s.repo.ListOpenOrders(ctx, dto.StationId)
+	if err != nil {
+		return board, err
+	}
+	orderIds := make([]int, len(orders))
+	for i, order := range orders {
+		orderIds[i] = order.OrderId
+	}
+	items, err := s.repo.ListItems(ctx, orderIds, dto.StationId)
+	if err != nil {
+		return board, err
+	}
+	grouped := groupKitchenItems(items)
+	now := time.Now()
+	for _, order := range orders {
+		board.Tickets = append(board.Tickets, response.KitchenTicketVO{
+			OrderId:     order.OrderId,
+			OrderNumber: order.OrderNumber,
+			Remark:      order.Remark,
+			Status:      order.Status,
+			CreateTime:  model.LocalTime(order.CreateTime),
+			WaitSeconds: int(now.Sub(order.CreateTime).Seconds()),
+			Items:       append([]model.KitchenItem{}, grouped[order.OrderId]...),
+		})
+	}
+	return board, nil
+}
+
+// StartItems 标记出品项开始制作
+func (s *KitchenService) StartItems(ctx context.Context, ids []uint64) error {
+	return s.markItems(ctx, ids, enum.KitchenStarted)
+}
+
+// DoneItems 标记出品项已出品
+func (s *KitchenService) DoneItems(ctx context.Context, ids []uint64) error {
+	return s.markItems(ctx, ids, enum.KitchenDone)
+}
+
+func (s *KitchenService) markItems(ctx context.Context, ids []uint64, status int) error {
+	items, err := s.repo.GetItems(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return e.Error_KITCHEN_ITEM_NOT_FOUND
+	}
+	found := make([]uint64, len(items))
+	var orderIds []int
+	for i, item := range items {
+		found[i] = item.Id
+		if !slices.Contains(orderIds, item.OrderId) {
+			orderIds = append(orderIds, item.OrderId)
+		}
+	}
+	n, err := s.repo.UpdateItemStatus(ctx, found, status, time.Now())
+	if err != nil || n == 0 {
+		return err
+	}
+	return s.refreshOrders(ctx, orderIds)
+}
+
+// Bump 划单：工位（或整单）剩余出品项全部标记为已出品
+func (s *KitchenService) Bump(ctx context.Context, dto request.KitchenBumpDTO) error {
+	orders, err := s.repo.GetKitchenOrders(ctx, []int{dto.OrderId})
+	if err != nil {
+		return err
+	}
+	if len(orders) == 0 {
+		return e.Error_KITCHEN_ORDER_NOT_FOUND
+	}
+	items, err := s.repo.ListItems(ctx, []int{dto.OrderId}, dto.StationId)
+	if err != nil {
+		return err
+	}
+	var ids []uint64
+	for _, item := range items {
+		if item.Status != enum.KitchenDone {
+			ids = append(ids, item.Id)
+		}
+	}
+	if _, err = s.repo.UpdateItemStatus(ctx, ids, enum.KitchenDone, time.Now()); err != nil {
+		return err
+	}
+	return s.refreshOrders(ctx, []int{dto.OrderId})
+}
+
+// dispatch 为已接单订单生成后厨单并推送到各工位看板，重复调用不会重复生成
+func (s *KitchenService) dispatch(ctx context.Context, order *model.Order) error {
+	items, err := s.repo.BuildItems(ctx, order.Id)
+	if err != nil {
+		return err
+	}
+	ticket := &model.KitchenOrder{
+		OrderId:     order.Id,
+		OrderNumber: order.Number,
+		Remark:      order.Remark,
+		Status:      kitchenStatus(items),
+	}
+	if ticket.Status == enum.KitchenDone {
+		ticket.ReadyTime = model.LocalTime(time.Now())
+	}
+	created, err := s.repo.CreateTicket(ctx, ticket, items)
+	if err != nil || !created {
+		return err
+	}
+	publishKitchen("ticket", order.Id, items)
+	if ticket.Status == enum.KitchenDone {
+		publishReady(ticket)
+	}
+	return nil
+}
+
+// dispatchPending 补生成接单时未能生成的后厨单
+func (s *KitchenService) dispatchPending(ctx context.Context) {
+	orders, err := s.repo.ListUndispatched(ctx)
+	if err != nil {
+		global.Log.Warn("List undispatched orders failed", "error", err)
+		return
+	}
+	for i := range orders {
+		if err = s.dispatch(ctx, &orders[i]); err != nil {
+			global.Log.Warn("Dispatch kitchen ticket failed", "orderId", orders[i].Id, "error", err)
+		}
+	}
+}
+
+// remove 订单取消或开始派送后从看板移除
+func (s *KitchenService) remove(ctx context.Context, orderId int, reason string) {
+	items, err := s.repo.ListItems(ctx, []int{orderId}, 0)
+	if err != nil || len(items) == 0 {
+		return
+	}
+	publishKitchen(reason, orderId, items)
+}
+
+// refreshOrders 按出品项汇总后厨单状态，整单出品时通知待派送
+func (s *KitchenService) refreshOrders(ctx context.Context, orderIds []int) error {
+	orders, err := s.repo.GetKitchenOrders(ctx, orderIds)
+	if err != nil {
+		return err
+	}
+	items, err := s.repo.ListItems(ctx, orderIds, 0)
+	if err != nil {
+		return err
+	}
+	grouped := groupKitchenItems(items)
+	now := time.Now()
+	for i := range orders {
+		order := &orders[i]
+		orderItems := grouped[order.OrderId]
+		publishKitchen("item", order.OrderId, orderItems)
+		status := kitchenStatus(orderItems)
+		if status == order.Status {
+			continue
+		}
+		if err = s.repo.SetOrderStatus(ctx, order.OrderId, status, now); err != nil {
+			return err
+		}
+		if status == enum.KitchenDone {
+			order.ReadyTime = model.LocalTime(now)
+			publishReady(order)
+		}
+	}
+	return nil
+}
+
+// kitchenStatus 全部出品为已出品，有任一项开始即为制作中
+func kitchenStatus(items []model.KitchenItem) int {
+	done, started := 0, false
+	for _, item := range items {
+		switch item.Status {
+		case enum.KitchenDone:
+			done++
+		case enum.KitchenStarted:
+			started = true
+		}
+	}
+	switch {
+	case done == len(items):
+		return enum.KitchenDone
+	case started || done > 0:
+		return enum.KitchenStarted
+	}
+	return enum.KitchenWaiting
+}
+
+func groupKitchenItems(items []model.KitchenItem) map[int][]model.KitchenItem {
+	grouped := make(map[int][]model.KitchenItem)
+	for _, item := range items {
+		grouped[item.OrderId] = append(grouped[item.OrderId], item)
+	}
+	return grouped
+}
+
+// publishKitchen 推送给全部工位视图及涉及的各工位
+func publishKitchen(event string, orderId int, items []model.KitchenItem) {
+	topics := []string{kitchenTopic}
+	for _, item := range items {
+		topic := kitchenTopic + ":" + strconv.FormatUint(item.StationId, 10)
+		if !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
+		}
+	}
+	websocket.WSServer.Publish(map[string]any{
+		"type":    wsKitchenUpdate,
+		"event":   event,
+		"orderId": orderId,
+		"items":   items,
+	}, topics...)
+}
+
+func publishReady(order *model.KitchenOrder) {
+	websocket.WSServer.SendToAllClients(map[string]any{
+		"type":    wsOrderReady,
+		"orderId": order.OrderId,
+		"content": "订单号: " + order.OrderNumber + " 已出餐，待派送",
+	})
+}
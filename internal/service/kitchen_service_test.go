@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/model"
+	"testing"
+	"time"
+)
+
+// fakeKitchenRepo 内存中的后厨数据，BuildItems 返回 build 中该订单的出品项
+type fakeKitchenRepo struct {
+	stations   []model.KitchenStation
+	categories map[uint64][]uint64
+	build      map[int][]model.KitchenItem
+	orders     []model.KitchenOrder
+	items      []model.KitchenItem
+	nextId     uint64
+}
+
+func (r *fakeKitchenRepo) ListStations(context.Context) ([]model.KitchenStation, error) {
+	return r.stations, nil
+}
+
+func (r *fakeKitchenRepo) GetStation(_ context.Context, id uint64) (*model.KitchenStation, error) {
+	for _, station := range r.stations {
+		if station.Id == id {
+			return &station, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeKitchenRepo) InsertStation(_ context.Context, station *model.KitchenStation) error {
+	station.Id = uint64(len(r.stations) + 1)
+	r.stations = append(r.stations, *station)
+	return nil
+}
+
+func (r *fakeKitchenRepo) UpdateStation(_ context.Context, station *model.KitchenStation) error {
+	for i := range r.stations {
+		if r.stations[i].Id == station.Id {
+			r.stations[i] = *station
+		}
+	}
+	return nil
+}
+
+func (r *fakeKitchenRepo) DeleteStation(_ context.Context, id uint64) error {
+	r.stations = slices.DeleteFunc(r.stations, func(s model.KitchenStation) bool { return s.Id == id })
+	return nil
+}
+
+func (r *fakeKitchenRepo) SetStationCategories(_ context.Context, stationId uint64, categoryIds []uint64) error {
+	if r.categories == nil {
+		r.categories = make(map[uint64][]uint64)
+	}
+	r.categories[stationId] = categoryIds
+	return nil
+}
+
+func (r *fakeKitchenRepo) CountOpenItems(_ context.Context, stationId uint64) (int64, error) {
+	var n int64
+	for _, item := range r.items {
+		if item.StationId == stationId && item.Status != enum.KitchenDone {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeKitchenRepo) BuildItems(_ context.Context, orderId int) ([]model.KitchenItem, error) {
+	return slices.Clone(r.build[orderId]), nil
+}
+
+func (r *fakeKitchenRepo) CreateTicket(_ context.Context, order *model.KitchenOrder, items []model.KitchenItem) (bool, error) {
+	for _, o := range r.orders {
+		if o.OrderId == order.OrderId {
+			return false, nil
+		}
+	}
+	order.CreateTime = time.Now()
+	r.orders = append(r.orders, *order)
+	for _, item := range items {
+		r.nextId++
+		item.Id = r.nextId
+		r.items = append(r.items, item)
+	}
+	return true, nil
+}
+
+func (r *fakeKitchenRepo) ListUndispatched(context.Context) ([]model.Order, error) {
+	return nil, nil
+}
+
+func (r *fakeKitchenRepo) ListOpenOrders(_ context.Context, stationId uint64) ([]model.KitchenOrder, error) {
+	var orders []model.KitchenOrder
+	for _, order := range r.orders {
+		items, _ := r.ListItems(context.Background(), []int{order.OrderId}, stationId)
+		if stationId == 0 || slices.ContainsFunc(items, func(i model.KitchenItem) bool { return i.Status != enum.KitchenDone }) {
+			orders = append(orders, order)
+		}
+	}
+	return orders, nil
+}
+
+func (r *fakeKitchenRepo) GetKitchenOrders(_ context.Context, orderIds []int) ([]model.KitchenOrder, error) {
+	var orders []model.KitchenOrder
+	for _, order := range r.orders {
+		if slices.Contains(orderIds, order.OrderId) {
+			orders = append(orders, order)
+		}
+	}
+	return orders, nil
+}
+
+func (r *fakeKitchenRepo) SetOrderStatus(_ context.Context, orderId int, status int, readyTime time.Time) error {
+	for i := range r.orders {
+		if r.orders[i].OrderId == orderId {
+			r.orders[i].Status = status
+			if status == enum.KitchenDone {
+				r.orders[i].ReadyTime = model.LocalTime(readyTime)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeKitchenRepo) ListItems(_ context.Context, orderIds []int, stationId uint64) ([]model.KitchenItem, error) {
+	var items []model.KitchenItem
+	for _, item := range r.items {
+		if slices.Contains(orderIds, item.OrderId) && (stationId == 0 || item.StationId == stationId) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (r *fakeKitchenRepo) GetItems(_ context.Context, ids []uint64) ([]model.KitchenItem, error) {
+	var items []model.KitchenItem
+	for _, item := range r.items {
+		if slices.Contains(ids, item.Id) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (r *fakeKitchenRepo) UpdateItemStatus(_ context.Context, ids []uint64, status int, _ time.Time) (int64, error) {
+	var n int64
+	for i := range r.items {
+		item := &r.items[i]
+		if !slices.Contains(ids, item.Id) || item.Status >= status {
+			continue
+		}
+		if status == enum.KitchenStarted && item.Status != enum.KitchenWaiting {
+			continue
+		}
+		item.Status = status
+		n++
+	}
+	return n, nil
+}
+
+func (r *fakeKitchenRepo) order(t *testing.T, orderId int) model.KitchenOrder {
+	t.Helper()
+	for _, order := range r.orders {
+		if order.OrderId == orderId {
+			return order
+		}
+	}
+	t.Fatalf("kitchen order %d not found", orderId)
+	return model.KitchenOrder{}
+}
+
+// newKitchenFixture 订单 1 有热菜工位的两个出品项和凉菜工位的一个出品项
+func newKitchenFixture(t *testing.T) (*KitchenService, *fakeKitchenRepo) {
+	t.Helper()
+	repo := &fakeKitchenRepo{
+		stations: []model.KitchenStation{{Id: 1, Name: "热菜"}, {Id: 2, Name: "凉菜"}},
+		build: map[int][]model.KitchenItem{1: {
+			{OrderId: 1, StationId: 1, Name: "宫保鸡丁", Number: 1},
+			{OrderId: 1, StationId: 1, Name: "鱼香肉丝", Number: 2},
+			{OrderId: 1, StationId: 2, Name: "拍黄瓜", Number: 1},
+		}},
+	}
+	s := &KitchenService{repo: repo}
+	if err := s.dispatch(context.Background(), &model.Order{Id: 1, Number: "1001"}); err != nil {
+		t.Fatal(err)
+	}
+	return s, repo
+}
+
+func TestKitchenStatus(t *testing.T) {
+	items := func(statuses ...int) []model.KitchenItem {
+		res := make([]model.KitchenItem, len(statuses))
+		for i, status := range statuses {
+			res[i].Status = status
+		}
+		return res
+	}
+	tests := []struct {
+		name  string
+		items []model.KitchenItem
+		want  int
+	}{
+		{"all waiting", items(enum.KitchenWaiting, enum.KitchenWaiting), enum.KitchenWaiting},
+		{"one started", items(enum.KitchenStarted, enum.KitchenWaiting), enum.KitchenStarted},
+		{"partly done", items(enum.KitchenDone, enum.KitchenWaiting), enum.KitchenStarted},
+		{"all done", items(enum.KitchenDone, enum.KitchenDone), enum.KitchenDone},
+	}
+	for _, tt := range tests {
+		if got := kitchenStatus(tt.items); got != tt.want {
+			t.Errorf("%s: kitchenStatus = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestKitchenDispatchOnce(t *testing.T) {
+	s, repo := newKitchenFixture(t)
+	if err := s.dispatch(context.Background(), &model.Order{Id: 1, Number: "1001"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.orders) != 1 || len(repo.items) != 3 {
+		t.Fatalf("orders = %d, items = %d after dispatching twice", len(repo.orders), len(repo.items))
+	}
+	if got := repo.order(t, 1).Status; got != enum.KitchenWaiting {
+		t.Errorf("status = %d, want waiting", got)
+	}
+
+	// 没有需要制作的出品项时直接出品待派送
+	repo.build[2] = nil
+	if err := s.dispatch(context.Background(), &model.Order{Id: 2, Number: "1002"}); err != nil {
+		t.Fatal(err)
+	}
+	if order := repo.order(t, 2); order.Status != enum.KitchenDone || time.Time(order.ReadyTime).IsZero() {
+		t.Errorf("empty ticket = %+v, want done with ready time", order)
+	}
+}
+
+func TestKitchenItemProgress(t *testing.T) {
+	s, repo := newKitchenFixture(t)
+	ctx := context.Background()
+	if err := s.StartItems(ctx, []uint64{1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.order(t, 1).Status; got != enum.KitchenStarted {
+		t.Fatalf("status after start = %d, want started", got)
+	}
+	if err := s.DoneItems(ctx, []uint64{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.order(t, 1).Status; got != enum.KitchenStarted {
+		t.Fatalf("status with cold dish pending = %d, want started", got)
+	}
+	// 已出品的项不会退回制作中
+	if err := s.StartItems(ctx, []uint64{1}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.items[0].Status != enum.KitchenDone {
+		t.Errorf("done item moved back to %d", repo.items[0].Status)
+	}
+	if err := s.DoneItems(ctx, []uint64{3}); err != nil {
+		t.Fatal(err)
+	}
+	if order := repo.order(t, 1); order.Status != enum.KitchenDone || time.Time(order.ReadyTime).IsZero() {
+		t.Errorf("order = %+v, want done with ready time", order)
+	}
+	if err := s.DoneItems(ctx, []uint64{99}); !errors.Is(err, e.Error_KITCHEN_ITEM_NOT_FOUND) {
+		t.Errorf("unknown item err = %v", err)
+	}
+}
+
+func TestKitchenBump(t *testing.T) {
+	s, repo := newKitchenFixture(t)
+	ctx := context.Background()
+	if err := s.Bump(ctx, request.KitchenBumpDTO{OrderId: 1, StationId: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.items[0].Status != enum.KitchenDone || repo.items[1].Status != enum.KitchenDone || repo.items[2].Status != enum.KitchenWaiting {
+		t.Fatalf("items after station bump = %+v", repo.items)
+	}
+	board, err := s.Board(ctx, request.KitchenBoardDTO{StationId: 1})
+	if err != nil || len(board.Tickets) != 0 {
+		t.Fatalf("hot station board = %+v, %v, want empty", board, err)
+	}
+	board, err = s.Board(ctx, request.KitchenBoardDTO{StationId: 2})
+	if err != nil || len(board.Tickets) != 1 || len(board.Tickets[0].Items) != 1 {
+		t.Fatalf("cold station board = %+v, %v", board, err)
+	}
+
+	if err = s.Bump(ctx, request.KitchenBumpDTO{OrderId: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.order(t, 1).Status; got != enum.KitchenDone {
+		t.Errorf("status after bumping the whole order = %d, want done", got)
+	}
+	if err = s.Bump(ctx, request.KitchenBumpDTO{OrderId: 9}); !errors.Is(err, e.Error_KITCHEN_ORDER_NOT_FOUND) {
+		t.Errorf("unknown order err = %v", err)
+	}
+}
+
+func TestKitchenStations(t *testing.T) {
+	s, repo := newKitchenFixture(t)
+	ctx := context.Background()
+	if err := s.DeleteStation(ctx, 2); !errors.Is(err, e.Error_STATION_IN_USE) {
+		t.Fatalf("delete station with open items err = %v", err)
+	}
+	if err := s.DeleteStation(ctx, 9); !errors.Is(err, e.Error_STATION_NOT_FOUND) {
+		t.Fatalf("delete unknown station err = %v", err)
+	}
+	if err := s.SetStationCategories(ctx, request.StationCategoriesDTO{StationId: 2, CategoryIds: []uint64{5, 3, 5}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.categories[2]; !slices.Equal(got, []uint64{3, 5}) {
+		t.Errorf("categories = %v, want [3 5]", got)
+	}
+	if err := s.DoneItems(ctx, []uint64{3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteStation(ctx, 2); err != nil {
+		t.Fatalf("delete idle station err = %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/robfig/cron/v3"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"takeout/common"
+	"takeout/common/storage"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/internal/model"
+	"takeout/repository"
+	"time"
+)
+
+const (
+	mediaDefaultGcGrace = 7 * 24 * time.Hour
+	mediaLegacy         = "legacy" // 媒体库上线前上传、由清理任务补录的文件
+)
+
+type IMediaService interface {
+	// PageQuery 媒体库分页查询，可以从中选择已上传的图片复用
+	PageQuery(ctx context.Context, dto request.MediaPageQueryDTO) (*common.PageResult, error)
+}
+
+type MediaService struct {
+	repo    repository.MediaRepo
+	storage storage.Storage
+}
+
+func NewMediaService(repo repository.MediaRepo) IMediaService {
+	service := &MediaService{repo: repo, storage: NewStorage()}
+	// 每天凌晨统计引用并清理无引用超过保留期的文件，删除时按引用数与未引用时间条件删除，多个实例同时执行时只有一个生效
+	timerTask := cron.New(cron.WithSeconds())
+	if _, err := timerTask.AddFunc("0 30 3 * * ?", service.collectGarbage); err != nil {
+		global.Log.Warn("TimerTaskError")
+	}
+	timerTask.Start()
+	return service
+}
+
+func (s *MediaService) PageQuery(ctx context.Context, dto request.MediaPageQueryDTO) (*common.PageResult, error) {
+	page, err := s.repo.PageQuery(ctx, dto)
+	if err != nil {
+		return nil, err
+	}
+	medias, _ := page.Records.([]model.Media)
+	records := make([]response.MediaVO, 0, len(medias))
+	for _, m := range medias {
+		vo := response.MediaVO{
+			Id:          m.Id,
+			Category:    m.Category,
+			Key:         m.ObjectKey,
+			Url:         m.Url,
+			Variants:    map[string]string{},
+			ContentType: m.ContentType,
+			Size:        m.Size,
+			Width:       m.Width,
+			Height:      m.Height,
+			RefCount:    m.RefCount,
+			UnusedSince: m.UnusedSince,
+			CreateTime:  m.CreateTime,
+		}
+		for name, key := range mediaVariants(m) {
+			vo.Variants[name] = s.storage.URL(key)
+		}
+		records = append(records, vo)
+	}
+	page.Records = records
+	return page, nil
+}
+
+// collectGarbage 重新统计每个文件及其缩放规格被引用的次数，删除无引用超过保留期的记录与文件
+func (s *MediaService) collectGarbage() {
+	ctx := context.Background()
+	refs, err := s.repo.ListReferences(ctx)
+	if err != nil {
+		global.Log.Warn("List media references failed", "error", err)
+		return
+	}
+	medias, err := s.repo.ListAll(ctx)
+	if err != nil {
+		global.Log.Warn("List media failed", "error", err)
+		return
+	}
+	if s.backfill(ctx, refs, medias) > 0 {
+		if medias, err = s.repo.ListAll(ctx); err != nil {
+			global.Log.Warn("List media failed", "error", err)
+			return
+		}
+	}
+	index := storage.NewReferences(refs)
+	now := time.Now()
+	before := now.Add(-mediaGcGrace())
+	var deleted int
+	for _, m := range medias {
+		variants := mediaVariants(m)
+		count := index.Count(m.ObjectKey)
+		for _, key := range variants {
+			count += index.Count(key)
+		}
+		if count != m.RefCount || (count == 0) != (m.UnusedSince != nil) {
+			if err = s.repo.UpdateRefCount(ctx, m.Id, count, now); err != nil {
+				global.Log.Warn("Update media ref count failed", "id", m.Id, "error", err)
+				continue
+			}
+			if count == 0 && m.UnusedSince == nil {
+				m.UnusedSince = &now
+			}
+		}
+		if count > 0 || m.UnusedSince == nil || m.UnusedSince.After(before) {
+			continue
+		}
+		ok, err := s.repo.DeleteUnused(ctx, m.Id, before)
+		if err != nil {
+			global.Log.Warn("Delete media failed", "id", m.Id, "error", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		// 记录已删除，文件删除失败只会留下无人引用的文件
+		keys := []string{m.ObjectKey}
+		for _, key := range variants {
+			keys = append(keys, key)
+		}
+		for _, key := range keys {
+			if err = s.storage.Delete(ctx, key); err != nil {
+				global.Log.Warn("Delete media object failed", "key", key, "error", err)
+			}
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		global.Log.Info("Media garbage collected", "deleted", deleted, "total", len(medias))
+	}
+}
+
+// backfill 为仍被引用、但在媒体库上线前上传而没有记录的文件补录记录，之后按引用数正常清理
+// 只补录被引用的文件，存储中无人引用的旧文件无法与目录中的其他文件区分，保持不动
+func (s *MediaService) backfill(ctx context.Context, refs []string, medias []model.Media) int {
+	known := map[string]bool{}
+	for _, m := range medias {
+		known[m.ObjectKey] = true
+		for _, key := range mediaVariants(m) {
+			known[key] = true
+		}
+	}
+	base := s.storage.URL("")
+	var added int
+	for _, ref := range refs {
+		key, ok := strings.CutPrefix(ref, base)
+		if !ok {
+			continue
+		}
+		key, _, _ = strings.Cut(key, "?")
+		if unescaped, err := url.PathUnescape(key); err == nil {
+			key = unescaped
+		}
+		if known[key] {
+			continue
+		}
+		known[key] = true
+		exists, err := s.storage.Exists(ctx, key)
+		if err != nil {
+			if !errors.Is(err, storage.ErrInvalidKey) {
+				global.Log.Warn("Check legacy media failed", "key", key, "error", err)
+			}
+			continue
+		}
+		if !exists {
+			continue
+		}
+		media := &model.Media{
+			Category:    mediaLegacy,
+			ObjectKey:   key,
+			Url:         s.storage.URL(key),
+			ContentType: mime.TypeByExtension(path.Ext(key)),
+		}
+		if err = s.repo.Save(ctx, media); err != nil {
+			global.Log.Warn("Backfill legacy media failed", "key", key, "error", err)
+			continue
+		}
+		added++
+	}
+	if added > 0 {
+		global.Log.Info("Legacy media backfilled", "added", added)
+	}
+	return added
+}
+
+// mediaVariants 解析缩放规格，规格名 -> key
+func mediaVariants(m model.Media) map[string]string {
+	variants := map[string]string{}
+	if m.Variants != "" {
+		if err := json.Unmarshal([]byte(m.Variants), &variants); err != nil {
+			global.Log.Warn("Invalid media variants", "id", m.Id, "error", err)
+		}
+	}
+	return variants
+}
+
+func mediaGcGrace() time.Duration {
+	if d, err := time.ParseDuration(global.Config.Storage.GcGrace); err == nil && d > 0 {
+		return d
+	}
+	return mediaDefaultGcGrace
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"io"
+	"takeout/common"
+	"takeout/common/storage"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/model"
+	"testing"
+	"time"
+)
+
+type fakeMediaRepo struct {
+	medias []model.Media
+	refs   []string
+	nextId uint64
+}
+
+func (r *fakeMediaRepo) Save(_ context.Context, media *model.Media) error {
+	for i := range r.medias {
+		if r.medias[i].ObjectKey == media.ObjectKey {
+			r.medias[i].UnusedSince = nil
+			return nil
+		}
+	}
+	r.nextId++
+	media.Id = r.nextId
+	r.medias = append(r.medias, *media)
+	return nil
+}
+
+func (r *fakeMediaRepo) PageQuery(context.Context, request.MediaPageQueryDTO) (*common.PageResult, error) {
+	return &common.PageResult{}, nil
+}
+
+func (r *fakeMediaRepo) ListAll(context.Context) ([]model.Media, error) {
+	return append([]model.Media(nil), r.medias...), nil
+}
+
+func (r *fakeMediaRepo) ListReferences(context.Context) ([]string, error) {
+	return r.refs, nil
+}
+
+func (r *fakeMediaRepo) UpdateRefCount(_ context.Context, id uint64, count int, now time.Time) error {
+	m := r.get(id)
+	m.RefCount = count
+	if count > 0 {
+		m.UnusedSince = nil
+	} else if m.UnusedSince == nil {
+		m.UnusedSince = &now
+	}
+	return nil
+}
+
+func (r *fakeMediaRepo) DeleteUnused(_ context.Context, id uint64, before time.Time) (bool, error) {
+	for i, m := range r.medias {
+		if m.Id == id && m.RefCount == 0 && m.UnusedSince != nil && !m.UnusedSince.After(before) {
+			r.medias = append(r.medias[:i], r.medias[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeMediaRepo) get(id uint64) *model.Media {
+	for i := range r.medias {
+		if r.medias[i].Id == id {
+			return &r.medias[i]
+		}
+	}
+	return nil
+}
+
+func (r *fakeMediaRepo) getByKey(key string) *model.Media {
+	for i := range r.medias {
+		if r.medias[i].ObjectKey == key {
+			return &r.medias[i]
+		}
+	}
+	return nil
+}
+
+// fakeStorage 只记录对象是否存在
+type fakeStorage map[string]bool
+
+func (s fakeStorage) Put(_ context.Context, key string, _ io.Reader, _ int64, _ string) error {
+	s[key] = true
+	return nil
+}
+
+func (s fakeStorage) Exists(_ context.Context, key string) (bool, error) {
+	return s[key], nil
+}
+
+func (s fakeStorage) Delete(_ context.Context, key string) error {
+	delete(s, key)
+	return nil
+}
+
+func (s fakeStorage) URL(key string) string {
+	return "http://localhost/static/" + key
+}
+
+func (s fakeStorage) SignedURL(key string, _ time.Duration) (string, error) {
+	return s.URL(key), nil
+}
+
+var _ storage.Storage = fakeStorage{}
+
+func TestCollectGarbage(t *testing.T) {
+	now := time.Now()
+	expired, recent := now.Add(-8*24*time.Hour), now.Add(-24*time.Hour)
+	repo := &fakeMediaRepo{nextId: 10, medias: []model.Media{
+		{Id: 1, ObjectKey: "images/aa/dish.png"},
+		{Id: 2, ObjectKey: "avatar/bb/face.png", Variants: `{"thumb":"avatar/bb/face_thumb.jpg"}`},
+		{Id: 3, ObjectKey: "images/cc/old.png", Variants: `{"thumb":"images/cc/old_thumb.jpg"}`, UnusedSince: &expired},
+		{Id: 4, ObjectKey: "images/dd/new.png", RefCount: 2},
+		{Id: 5, ObjectKey: "images/ee/kept.png", UnusedSince: &recent},
+		{Id: 6, ObjectKey: "images/ff/snapshot.png", UnusedSince: &expired},
+	}}
+	repo.refs = []string{
+		"http://localhost/static/images/aa/dish.png",
+		"https://cdn.example.com/images/aa/dish.png",
+		"http://localhost/static/avatar/bb/face_thumb.jpg",
+		// 菜品已换图，历史订单明细仍引用旧图
+		"http://localhost/static/images/ff/snapshot.png",
+		// 媒体库上线前上传的文件
+		"http://localhost/static/1736405955252377200_a%20b.jpg",
+		"http://localhost/static/missing.jpg",
+		"https://other.example.com/x.png",
+	}
+	store := fakeStorage{
+		"images/aa/dish.png": true, "avatar/bb/face.png": true, "avatar/bb/face_thumb.jpg": true,
+		"images/cc/old.png": true, "images/cc/old_thumb.jpg": true, "images/dd/new.png": true,
+		"images/ee/kept.png": true, "images/ff/snapshot.png": true, "1736405955252377200_a b.jpg": true,
+	}
+	s := &MediaService{repo: repo, storage: store}
+	s.collectGarbage()
+
+	for key, want := range map[string]int{
+		"images/aa/dish.png":          2,
+		"avatar/bb/face.png":          1,
+		"images/dd/new.png":           0,
+		"images/ee/kept.png":          0,
+		"images/ff/snapshot.png":      1,
+		"1736405955252377200_a b.jpg": 1,
+	} {
+		m := repo.getByKey(key)
+		if m == nil {
+			t.Fatalf("%s was deleted", key)
+		}
+		if m.RefCount != want || (want == 0) != (m.UnusedSince != nil) {
+			t.Errorf("%s: refCount = %d, unusedSince = %v, want %d", key, m.RefCount, m.UnusedSince, want)
+		}
+	}
+	if m := repo.getByKey("1736405955252377200_a b.jpg"); m.Category != mediaLegacy || m.ContentType != "image/jpeg" {
+		t.Errorf("legacy media = %+v", m)
+	}
+	if repo.getByKey("missing.jpg") != nil {
+		t.Error("missing object should not be backfilled")
+	}
+	// 无引用超过保留期的记录与文件、缩放规格一起删除
+	if repo.getByKey("images/cc/old.png") != nil || store["images/cc/old.png"] || store["images/cc/old_thumb.jpg"] {
+		t.Errorf("expired media not collected: %v", store)
+	}
+	if !store["images/dd/new.png"] || !store["images/ee/kept.png"] {
+		t.Error("media within grace period should be kept")
+	}
+	if len(repo.medias) != 6 {
+		t.Errorf("medias = %d, want 6", len(repo.medias))
+	}
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/internal/api/user/response"
+	"takeout/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RepetitionOrder 再来一单：原订单商品按当前菜单与价格合并到购物车，已下架的商品优先替换为重新上架的同名商品
+func (s *OrderService) RepetitionOrder(ctx *gin.Context, orderId string) (*response.RepetitionVO, error) {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	order, err := s.repo.GetOrderById(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.Id == 0 || order.UserId != userId {
+		return nil, e.Error_ORDER_NOT_FOUND
+	}
+	details, err := s.repo.GetOrderDetailByOrderId(orderId)
+	if err != nil {
+		return nil, err
+	}
+	menu, err := s.loadRepetitionMenu(ctx, details)
+	if err != nil {
+		return nil, err
+	}
+	cart := s.carts.repo
+	current, err := cart.List(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]model.ShoppingCart, len(current))
+	quantities := make(map[string]int)
+	for _, line := range current {
+		existing[repetitionLineKey(line)] = line
+		quantities[cartItemKey(line)] += line.Number
+	}
+
+	vo := &response.RepetitionVO{
+		Added:   []response.RepetitionItemVO{},
+		Changed: []response.RepetitionItemVO{},
+		Skipped: []response.RepetitionItemVO{},
+	}
+	var lines []model.ShoppingCart
+	for _, detail := range details {
+		item := response.RepetitionItemVO{
+			Name:       detail.Name,
+			DishId:     detail.DishId,
+			SetmealId:  detail.SetmealId,
+			DishFlavor: detail.DishFlavor,
+			Requested:  detail.Number,
+			OldAmount:  detail.Amount,
+		}
+		line, reasons, ok := menu.resolve(detail)
+		if !ok {
+			item.Reasons = reasons
+			vo.Skipped = append(vo.Skipped, item)
+			continue
+		}
+		item.DishId, item.SetmealId, item.DishFlavor, item.Amount = line.DishId, line.SetmealId, line.DishFlavor, line.Amount
+		// 已在购物车中的数量计入限购
+		key := cartItemKey(line)
+		number := min(detail.Number, cartLimit(key)-quantities[key])
+		if number <= 0 {
+			item.Reasons = append(reasons, "已达限购数量")
+			vo.Skipped = append(vo.Skipped, item)
+			continue
+		}
+		if number < detail.Number {
+			reasons = append(reasons, fmt.Sprintf("限购 %d 份，数量已调整", cartLimit(key)))
+		}
+		quantities[key] += number
+		line.UserId, line.Number = userId, number
+		lines = append(lines, line)
+		item.Number, item.Reasons = number, reasons
+		if len(reasons) == 0 {
+			vo.Added = append(vo.Added, item)
+		} else {
+			vo.Changed = append(vo.Changed, item)
+		}
+	}
+
+	if err = cart.InsertBatchShoppingCart(ctx, lines); err != nil {
+		return nil, err
+	}
+	// 合并到购物车已有的行时，该行也按当前价格更新
+	for _, line := range lines {
+		if old, ok := existing[repetitionLineKey(line)]; ok &&
+			(old.Name != line.Name || old.Image != line.Image || old.Amount != line.Amount) {
+			if _, err = cart.UpdateLine(ctx, line); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if current, err = cart.List(ctx, userId); err != nil {
+		return nil, err
+	}
+	for _, line := range current {
+		vo.Number += line.Number
+		vo.Total = roundCent(vo.Total + line.Amount*float64(line.Number))
+	}
+	return vo, nil
+}
+
+// repetitionMenu 原订单商品对应的当前菜品与套餐，以及可替换的同名在售商品
+type repetitionMenu struct {
+	dishes       map[int]model.Dish
+	setmeals     map[int]model.SetMeal
+	dishNames    map[string]model.Dish
+	setmealNames map[string]model.SetMeal
+}
+
+func (s *OrderService) loadRepetitionMenu(ctx context.Context, details []model.OrderDetail) (*repetitionMenu, error) {
+	var dishIds, setmealIds []int
+	for _, detail := range details {
+		if detail.DishId != 0 {
+			dishIds = append(dishIds, detail.DishId)
+		} else {
+			setmealIds = append(setmealIds, detail.SetmealId)
+		}
+	}
+	cart := s.carts.repo
+	dishList, err := cart.ListDishes(ctx, dishIds)
+	if err != nil {
+		return nil, err
+	}
+	setmealList, err := cart.ListSetmeals(ctx, setmealIds)
+	if err != nil {
+		return nil, err
+	}
+	menu := &repetitionMenu{
+		dishes:       make(map[int]model.Dish, len(dishList)),
+		setmeals:     make(map[int]model.SetMeal, len(setmealList)),
+		dishNames:    make(map[string]model.Dish),
+		setmealNames: make(map[string]model.SetMeal),
+	}
+	for _, dish := range dishList {
+		menu.dishes[int(dish.Id)] = dish
+	}
+	for _, setmeal := range setmealList {
+		menu.setmeals[int(setmeal.Id)] = setmeal
+	}
+
+	// 已删除或停售的商品按名称查找重新上架的商品
+	var names []string
+	for _, detail := range details {
+		if detail.DishId != 0 {
+			if dish, ok := menu.dishes[detail.DishId]; !ok || dish.Status != int(enum.ENABLE) {
+				names = append(names, detail.Name)
+			}
+		} else if setmeal, ok := menu.setmeals[detail.SetmealId]; !ok || setmeal.Status != int(enum.ENABLE) {
+			names = append(names, detail.Name)
+		}
+	}
+	dishSubs, setmealSubs, err := cart.FindOnSaleByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	// 同名商品有多个时取最新上架的
+	for _, dish := range dishSubs {
+		if _, ok := menu.dishNames[dish.Name]; !ok {
+			menu.dishNames[dish.Name] = dish
+		}
+	}
+	for _, setmeal := range setmealSubs {
+		if _, ok := menu.setmealNames[setmeal.Name]; !ok {
+			menu.setmealNames[setmeal.Name] = setmeal
+		}
+	}
+	return menu, nil
+}
+
+// resolve 将原订单明细转换为按当前菜单加入购物车的行，不能加入时返回 false 与原因
+func (m *repetitionMenu) resolve(detail model.OrderDetail) (model.ShoppingCart, []string, bool) {
+	var reasons []string
+	if detail.DishId == 0 {
+		setmeal, ok := m.setmeals[detail.SetmealId]
+		if !ok || setmeal.Status != int(enum.ENABLE) {
+			if setmeal, ok = m.setmealNames[detail.Name]; !ok {
+				return model.ShoppingCart{}, []string{"套餐已下架"}, false
+			}
+			reasons = append(reasons, "原套餐已下架，已替换为同名套餐")
+		}
+		if setmeal.Price != detail.Amount {
+			reasons = append(reasons, "价格已调整")
+		}
+		return model.ShoppingCart{
+			Name:      setmeal.Name,
+			Image:     setmeal.Image,
+			SetmealId: int(setmeal.Id),
+			Amount:    setmeal.Price,
+		}, reasons, true
+	}
+
+	dish, ok := m.dishes[detail.DishId]
+	if !ok || dish.Status != int(enum.ENABLE) {
+		if dish, ok = m.dishNames[detail.Name]; !ok {
+			return model.ShoppingCart{}, []string{"菜品已下架"}, false
+		}
+		reasons = append(reasons, "原菜品已下架，已替换为同名菜品")
+	}
+	// 保留仍可选择的口味
+	selected := splitFlavor(detail.DishFlavor)
+	kept := make([]string, 0, len(selected))
+	options := dishFlavorOptions(dish)
+	for _, flavor := range selected {
+		if options[flavor] {
+			kept = append(kept, flavor)
+		}
+	}
+	if len(kept) < len(selected) {
+		reasons = append(reasons, "部分口味已下线")
+	}
+	if dish.Price != detail.Amount {
+		reasons = append(reasons, "价格已调整")
+	}
+	return model.ShoppingCart{
+		Name:       dish.Name,
+		Image:      dish.Image,
+		DishId:     int(dish.Id),
+		DishFlavor: strings.Join(kept, ","),
+		Amount:     dish.Price,
+	}, reasons, true
+}
+
+// dishFlavorOptions 菜品当前全部口味选项
+func dishFlavorOptions(dish model.Dish) map[string]bool {
+	options := make(map[string]bool)
+	for _, f := range dish.Flavors {
+		var values []string
+		if err := json.Unmarshal([]byte(f.Value), &values); err != nil {
+			continue
+		}
+		for _, v := range values {
+			options[v] = true
+		}
+	}
+	return options
+}
+
+func repetitionLineKey(line model.ShoppingCart) string {
+	return fmt.Sprintf("%d:%d:%s", line.DishId, line.SetmealId, line.DishFlavor)
+}
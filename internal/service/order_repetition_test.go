@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/internal/model"
+	"testing"
+)
+
+// fakeCartRepo 内存中的购物车与菜单
+type fakeCartRepo struct {
+	lines       []model.ShoppingCart
+	dishes      []model.Dish
+	setmeals    []model.SetMeal
+	subDishes   []model.Dish    // 按名称查找到的在售菜品
+	subSetmeals []model.SetMeal // 按名称查找到的在售套餐
+}
+
+func (r *fakeCartRepo) Add(context.Context, model.ShoppingCart) error { return nil }
+
+func (r *fakeCartRepo) List(context.Context, int) ([]model.ShoppingCart, error) {
+	return append([]model.ShoppingCart(nil), r.lines...), nil
+}
+
+func (r *fakeCartRepo) Clean(context.Context, int) error { return nil }
+
+func (r *fakeCartRepo) Subtract(context.Context, request.ShoppingCartDTO, int) error { return nil }
+
+// InsertBatchShoppingCart 同一商品与口味的行合并数量
+func (r *fakeCartRepo) InsertBatchShoppingCart(_ context.Context, lines []model.ShoppingCart) error {
+next:
+	for _, line := range lines {
+		for i := range r.lines {
+			if r.lines[i].DishId == line.DishId && r.lines[i].SetmealId == line.SetmealId && r.lines[i].DishFlavor == line.DishFlavor {
+				r.lines[i].Number += line.Number
+				continue next
+			}
+		}
+		r.lines = append(r.lines, line)
+	}
+	return nil
+}
+
+func (r *fakeCartRepo) UpdateLine(_ context.Context, line model.ShoppingCart) (bool, error) {
+	for i := range r.lines {
+		if r.lines[i].DishId == line.DishId && r.lines[i].SetmealId == line.SetmealId && r.lines[i].DishFlavor == line.DishFlavor {
+			r.lines[i].Name, r.lines[i].Image, r.lines[i].Amount = line.Name, line.Image, line.Amount
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeCartRepo) ListDishes(context.Context, []int) ([]model.Dish, error) {
+	return r.dishes, nil
+}
+
+func (r *fakeCartRepo) ListSetmeals(context.Context, []int) ([]model.SetMeal, error) {
+	return r.setmeals, nil
+}
+
+func (r *fakeCartRepo) FindOnSaleByNames(context.Context, []string) ([]model.Dish, []model.SetMeal, error) {
+	return r.subDishes, r.subSetmeals, nil
+}
+
+func newRepetitionService(cart *fakeCartRepo) *OrderService {
+	return &OrderService{
+		repo: fakeOrderRepo{
+			orders: map[int]*model.Order{1: {Id: 1, UserId: 7}},
+			details: []model.OrderDetail{
+				{OrderId: 1, Name: "宫保鸡丁", DishId: 1, DishFlavor: "微辣,加葱", Number: 2, Amount: 28},
+				{OrderId: 1, Name: "鱼香肉丝", DishId: 2, Number: 1, Amount: 20},
+				{OrderId: 1, Name: "双人套餐", SetmealId: 3, Number: 1, Amount: 58},
+				{OrderId: 1, Name: "拍黄瓜", DishId: 4, Number: 1, Amount: 12},
+				{OrderId: 1, Name: "可乐", DishId: 5, Number: 5, Amount: 5},
+			},
+		},
+		carts: &ShoppingCartService{repo: cart},
+	}
+}
+
+func findRepetitionItem(t *testing.T, items []response.RepetitionItemVO, name string) response.RepetitionItemVO {
+	t.Helper()
+	for _, item := range items {
+		if item.Name == name {
+			return item
+		}
+	}
+	t.Fatalf("%s not found in %+v", name, items)
+	return response.RepetitionItemVO{}
+}
+
+func TestRepetitionOrder(t *testing.T) {
+	old := global.Config.Cart.Limits
+	global.Config.Cart.Limits = map[string]int{"dish:5": 3}
+	t.Cleanup(func() { global.Config.Cart.Limits = old })
+
+	cart := &fakeCartRepo{
+		lines: []model.ShoppingCart{
+			{UserId: 7, Name: "宫保鸡丁", DishId: 1, DishFlavor: "微辣", Number: 1, Amount: 28},
+			{UserId: 7, Name: "可乐", DishId: 5, Number: 1, Amount: 5},
+		},
+		dishes: []model.Dish{
+			{Id: 1, Name: "宫保鸡丁", Price: 30, Status: int(enum.ENABLE), Flavors: []model.DishFlavor{{Name: "辣度", Value: `["微辣","中辣"]`}}},
+			{Id: 2, Name: "鱼香肉丝", Price: 20, Status: int(enum.DISABLE)},
+			{Id: 5, Name: "可乐", Price: 5, Status: int(enum.ENABLE)},
+		},
+		setmeals:  []model.SetMeal{{Id: 3, Name: "双人套餐", Price: 58, Status: int(enum.ENABLE)}},
+		subDishes: []model.Dish{{Id: 9, Name: "鱼香肉丝", Price: 20, Status: int(enum.ENABLE)}},
+	}
+	vo, err := newRepetitionService(cart).RepetitionOrder(userCtx(7), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vo.Added) != 1 || vo.Added[0].Name != "双人套餐" || vo.Added[0].Number != 1 {
+		t.Errorf("added = %+v", vo.Added)
+	}
+	// 下线的口味去掉，按当前价格加入
+	kungPao := findRepetitionItem(t, vo.Changed, "宫保鸡丁")
+	if kungPao.DishFlavor != "微辣" || kungPao.Amount != 30 || kungPao.OldAmount != 28 ||
+		!slices.Equal(kungPao.Reasons, []string{"部分口味已下线", "价格已调整"}) {
+		t.Errorf("宫保鸡丁 = %+v", kungPao)
+	}
+	// 停售的菜品替换为重新上架的同名菜品
+	if fish := findRepetitionItem(t, vo.Changed, "鱼香肉丝"); fish.DishId != 9 ||
+		!slices.Equal(fish.Reasons, []string{"原菜品已下架，已替换为同名菜品"}) {
+		t.Errorf("鱼香肉丝 = %+v", fish)
+	}
+	// 购物车中已有的数量计入限购
+	if cola := findRepetitionItem(t, vo.Changed, "可乐"); cola.Requested != 5 || cola.Number != 2 ||
+		!slices.Equal(cola.Reasons, []string{"限购 3 份，数量已调整"}) {
+		t.Errorf("可乐 = %+v", cola)
+	}
+	if skipped := findRepetitionItem(t, vo.Skipped, "拍黄瓜"); !slices.Equal(skipped.Reasons, []string{"菜品已下架"}) {
+		t.Errorf("拍黄瓜 = %+v", skipped)
+	}
+
+	// 合并到已有的行，旧价格的行也更新为当前价格
+	for _, line := range cart.lines {
+		if line.DishId == 1 && (line.Number != 3 || line.Amount != 30) {
+			t.Errorf("merged line = %+v", line)
+		}
+	}
+	// 宫保鸡丁 3*30 + 鱼香肉丝 20 + 双人套餐 58 + 可乐 3*5
+	if vo.Number != 8 || vo.Total != 183 {
+		t.Errorf("cart number = %d, total = %v, want 8, 183", vo.Number, vo.Total)
+	}
+}
+
+func TestRepetitionOrderLimitReached(t *testing.T) {
+	old := global.Config.Cart.MaxQuantity
+	global.Config.Cart.MaxQuantity = 5
+	t.Cleanup(func() { global.Config.Cart.MaxQuantity = old })
+
+	cart := &fakeCartRepo{
+		lines:  []model.ShoppingCart{{UserId: 7, Name: "可乐", DishId: 5, Number: 5, Amount: 5}},
+		dishes: []model.Dish{{Id: 5, Name: "可乐", Price: 5, Status: int(enum.ENABLE)}},
+	}
+	svc := newRepetitionService(cart)
+	svc.repo = fakeOrderRepo{
+		orders:  map[int]*model.Order{1: {Id: 1, UserId: 7}},
+		details: []model.OrderDetail{{OrderId: 1, Name: "可乐", DishId: 5, Number: 2, Amount: 5}},
+	}
+	vo, err := svc.RepetitionOrder(userCtx(7), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cola := findRepetitionItem(t, vo.Skipped, "可乐"); !slices.Equal(cola.Reasons, []string{"已达限购数量"}) {
+		t.Errorf("可乐 = %+v", cola)
+	}
+	if vo.Number != 5 || cart.lines[0].Number != 5 {
+		t.Errorf("cart number = %d, line = %+v", vo.Number, cart.lines[0])
+	}
+}
+
+func TestRepetitionOrderOtherUser(t *testing.T) {
+	cart := &fakeCartRepo{}
+	for _, orderId := range []string{"1", "404"} {
+		if _, err := newRepetitionService(cart).RepetitionOrder(userCtx(8), orderId); !errors.Is(err, e.Error_ORDER_NOT_FOUND) {
+			t.Errorf("order %s err = %v", orderId, err)
+		}
+	}
+	if len(cart.lines) != 0 {
+		t.Errorf("cart = %+v", cart.lines)
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/common/notify"
+	"takeout/common/slot"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/internal/model"
+	"takeout/internal/router/websocket"
+	"time"
+)
+
+// SlotReserveKey 正在提交中的预约订单数，后接时段开始时间的 Unix 秒，防止并发下单超出时段容量
+const SlotReserveKey = "order:slot:reserve:"
+
+const (
+	slotDefaultStep     = 30 * time.Minute
+	slotDefaultAdvance  = 45 * time.Minute
+	slotDefaultLead     = 40 * time.Minute
+	slotDefaultRemind   = 20 * time.Minute
+	slotDefaultCapacity = 10
+	slotDefaultDays     = 3
+	slotReserveTTL      = 30 * time.Second
+)
+
+// OrderSlots 未来几天的预约送达时段及剩余名额，营业时间未配置时返回空
+func (s *OrderService) OrderSlots(ctx context.Context) ([]response.SlotDayVO, error) {
+	hours, err := slotHours()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	from, to := slotWindow(now)
+	slots := hours.Between(from, to, slotStep())
+	if len(slots) == 0 {
+		return []response.SlotDayVO{}, nil
+	}
+	booked, err := s.repo.CountSlotOrders(ctx, slots[0].Start, slots[len(slots)-1].Start.Add(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	capacity := slotCapacity()
+	days := make([]response.SlotDayVO, 0, slotAheadDays())
+	for _, sl := range slots {
+		date := sl.Start.Format(time.DateOnly)
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, response.SlotDayVO{Date: date})
+		}
+		remaining := max(capacity-booked[sl.Start.Unix()], 0)
+		day := &days[len(days)-1]
+		day.Slots = append(day.Slots, response.SlotVO{
+			Start:     model.LocalTime(sl.Start),
+			End:       model.LocalTime(sl.End),
+			Label:     sl.Label(),
+			Remaining: remaining,
+			Available: remaining > 0,
+		})
+	}
+	return days, nil
+}
+
+// reserveSlot 校验预约时段并占用一个提交中名额，返回的函数在下单结束后释放名额
+// 立即送出的订单不占用名额，未选择时间的预约视为立即送出
+func (s *OrderService) reserveSlot(ctx context.Context, data *request.OrderSubmitDTO) (func(), error) {
+	estimated := time.Time(data.EstimatedDeliveryTime)
+	if data.DeliveryStatus != enum.DeliverAtTime || estimated.IsZero() {
+		data.DeliveryStatus = enum.DeliverNow
+		return func() {}, nil
+	}
+	if data.OrderType == enum.OrderTypeDineIn {
+		return nil, fmt.Errorf("%w: 堂食订单不支持预约", e.Error_ORDER_TYPE_INVALID)
+	}
+	hours, err := slotHours()
+	if err != nil {
+		return nil, err
+	}
+	// 前端传入的时间不带时区，按服务器本地时间解释
+	start := time.Date(estimated.Year(), estimated.Month(), estimated.Day(),
+		estimated.Hour(), estimated.Minute(), estimated.Second(), 0, time.Local)
+	sl, ok := hours.Find(start, slotStep())
+	if from, to := slotWindow(time.Now()); !ok || sl.Start.Before(from) || !sl.Start.Before(to) {
+		return nil, e.Error_SLOT_UNAVAILABLE
+	}
+	data.EstimatedDeliveryTime = model.LocalTime(sl.Start)
+
+	key := SlotReserveKey + strconv.FormatInt(sl.Start.Unix(), 10)
+	pending, err := global.RedisClient.Incr(key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve slot: %w", err)
+	}
+	global.RedisClient.Expire(key, slotReserveTTL)
+	release := func() { global.RedisClient.Decr(key) }
+	booked, err := s.repo.CountSlotOrders(ctx, sl.Start, sl.Start.Add(time.Second))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if booked[sl.Start.Unix()]+int(pending) > slotCapacity() {
+		release()
+		return nil, e.Error_SLOT_FULL
+	}
+	return release, nil
+}
+
+// holdScheduled 支付成功的预约订单在推送时间之前保持预约状态，不提醒商家
+func holdScheduled(order *model.Order, now time.Time) bool {
+	return order.IsScheduled() && now.Before(time.Time(order.EstimatedDeliveryTime).Add(-slotLeadTime()))
+}
+
+// processScheduledOrders 推送到期的预约订单，并提醒临近时段仍未接单的预约订单
+// 多个实例同时执行时按状态条件更新，只有一个实例发送通知
+func (s *OrderService) processScheduledOrders() {
+	ctx := context.Background()
+	now := time.Now()
+	due, err := s.repo.ListDueScheduled(ctx, now.Add(slotLeadTime()))
+	if err != nil {
+		global.Log.Warn("List due scheduled orders failed", "error", err)
+	}
+	for _, order := range due {
+		if ok, err := s.repo.ReleaseScheduled(ctx, order.Id, now); err != nil || !ok {
+			continue
+		}
+		label := time.Time(order.EstimatedDeliveryTime).Format("01-02 15:04")
+		websocket.WSServer.SendToAllClients(map[string]any{
+			"type":    1,
+			"orderId": order.Id,
+			"content": "预约订单号: " + order.Number + "，送达时间 " + label,
+		})
+		s.notifySlot(ctx, "预约订单待接单", fmt.Sprintf("预约订单 %s 将于 %s 送达，请及时接单。", order.Number, label))
+	}
+
+	unconfirmed, err := s.repo.ListUnconfirmedScheduled(ctx, now.Add(slotRemindBefore()))
+	if err != nil {
+		global.Log.Warn("List unconfirmed scheduled orders failed", "error", err)
+	}
+	for _, order := range unconfirmed {
+		if ok, err := s.repo.MarkReminded(ctx, order.Id, now); err != nil || !ok {
+			continue
+		}
+		label := time.Time(order.EstimatedDeliveryTime).Format("01-02 15:04")
+		websocket.WSServer.SendToAllClients(map[string]any{
+			"type":    2,
+			"orderId": order.Id,
+			"content": "预约订单号: " + order.Number + " 仍未接单，送达时间 " + label,
+		})
+		s.notifySlot(ctx, "预约订单未接单提醒", fmt.Sprintf("预约订单 %s 将于 %s 送达，目前仍未接单。", order.Number, label))
+	}
+}
+
+// notifySlot 发送给配置的全部接收方，单个接收方失败不影响其他接收方
+func (s *OrderService) notifySlot(ctx context.Context, subject, text string) {
+	recipients := global.Config.Schedule.Reminders
+	if len(recipients) == 0 {
+		return
+	}
+	msg := &notify.Message{Subject: subject, Text: text, HTML: "<p>" + html.EscapeString(text) + "</p>"}
+	for _, r := range recipients {
+		sendCtx, cancel := context.WithTimeout(ctx, deliveryTimeout())
+		if err := s.notifiers.Send(sendCtx, r.Channel, r.Target, msg); err != nil {
+			global.Log.Warn("Send scheduled order notice failed", "channel", r.Channel, "error", err)
+		}
+		cancel()
+	}
+}
+
+// slotWindow 可预约的时段范围：最早提前量之后，到 ahead_days 天后的零点为止
+func slotWindow(now time.Time) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return now.Add(slotDuration(global.Config.Schedule.MinAdvance, slotDefaultAdvance)), today.AddDate(0, 0, slotAheadDays())
+}
+
+func slotHours() (slot.Hours, error) {
+	cfg := global.Config.Schedule
+	hours, err := slot.ParseHours(cfg.Hours, cfg.Days)
+	if err != nil {
+		return hours, fmt.Errorf("failed to parse business hours: %w", err)
+	}
+	return hours, nil
+}
+
+func slotStep() time.Duration {
+	return slotDuration(global.Config.Schedule.Slot, slotDefaultStep)
+}
+
+func slotLeadTime() time.Duration {
+	return slotDuration(global.Config.Schedule.LeadTime, slotDefaultLead)
+}
+
+func slotRemindBefore() time.Duration {
+	return slotDuration(global.Config.Schedule.RemindBefore, slotDefaultRemind)
+}
+
+func slotCapacity() int {
+	if n := global.Config.Schedule.Capacity; n > 0 {
+		return n
+	}
+	return slotDefaultCapacity
+}
+
+func slotAheadDays() int {
+	if n := global.Config.Schedule.AheadDays; n > 0 {
+		return n
+	}
+	return slotDefaultDays
+}
+
+func slotDuration(value string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
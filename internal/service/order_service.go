@@ -1,15 +1,20 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	iUtils "github.com/iWyh2/go-myUtils/utils"
 	"github.com/robfig/cron/v3"
 	"github.com/ulule/deepcopier"
 	"log"
 	"strconv"
+	"strings"
 	"takeout/common"
+	"takeout/common/e"
 	"takeout/common/enum"
+	"takeout/common/notify"
 	"takeout/global"
 	"takeout/internal/api/user/request"
 	"takeout/internal/api/user/response"
@@ -19,13 +24,20 @@ import (
 	"time"
 )
 
+// PickupCodeKey 取餐码每日计数，后接日期
+const PickupCodeKey = "order:pickup:"
+
 type IOrderService interface {
 	OrderReminder(ctx *gin.Context, orderId string) error
-	RepetitionOrder(ctx *gin.Context, orderId string) error
+	// RepetitionOrder 再来一单，返回加入、调整与跳过的商品
+	RepetitionOrder(ctx *gin.Context, orderId string) (*response.RepetitionVO, error)
 	OrderSubmit(ctx *gin.Context, data request.OrderSubmitDTO) (response.OrderSubmitVO, error)
-	OrderPayment(ctx *gin.Context, orderData request.OrderPaymentDTO) response.OrderPaymentVO
+	// OrderPayment 订单支付，AA 支付的拼单订单由成员分别支付
+	OrderPayment(ctx *gin.Context, orderData request.OrderPaymentDTO) (response.OrderPaymentVO, error)
 	CancelOrder(ctx *gin.Context, orderId string) error
 	HistoryOrders(ctx *gin.Context, dto request.PageQueryOrderDTO) (*common.PageResult, error)
+	// OrderSlots 可预约的送达时段
+	OrderSlots(ctx context.Context) ([]response.SlotDayVO, error)
 
 	OrderDetail(ctx *gin.Context, orderId string) (response.OrderVO, error)
 
@@ -33,16 +45,34 @@ type IOrderService interface {
 	OrderRejection(ctx *gin.Context, data request.OrderRejectionDTO) error
 	OrderDelivery(ctx *gin.Context, orderId string) error
 	OrderComplete(ctx *gin.Context, orderId string) error
+	// OrderReady 自取与堂食订单出餐，通知顾客取餐
+	OrderReady(ctx *gin.Context, orderId string) error
+	// OrderPickup 核销当天的取餐码并完成订单
+	OrderPickup(ctx *gin.Context, data request.OrderPickupDTO) error
 	CancelOrderByBusiness(ctx *gin.Context, data request.OrderCancelDTO) error
 	OrderConditionSearch(ctx *gin.Context, data request.OrderPageQueryDTO) (*common.PageResult, error)
 	OrderStatistics(ctx *gin.Context) (response.OrderStatisticsVO, error)
 }
 type OrderService struct {
-	repo repository.OrderRepo
+	repo       repository.OrderRepo
+	reportRepo repository.ReportRepo
+	kitchen    *KitchenService
+	printer    *PrintService
+	tables     *TableService
+	notifiers  notify.Registry
+	carts      *ShoppingCartService
 }
 
-func NewOrderService(repo repository.OrderRepo) IOrderService {
-	service := &OrderService{repo: repo}
+func NewOrderService(repo repository.OrderRepo, reportRepo repository.ReportRepo, kitchenRepo repository.KitchenRepo, printRepo repository.PrintRepo, tableRepo repository.TableRepo, cartRepo repository.ShoppingCartRepo) IOrderService {
+	service := &OrderService{
+		repo:       repo,
+		reportRepo: reportRepo,
+		kitchen:    &KitchenService{repo: kitchenRepo},
+		printer:    &PrintService{repo: printRepo, orderRepo: repo},
+		tables:     &TableService{repo: tableRepo},
+		notifiers:  NewNotifiers(),
+		carts:      &ShoppingCartService{repo: cartRepo},
+	}
 	defer func() {
 		global.Log.Info("启动定时器: [%s]", time.Now().Format("2006-01-02 15:04:05"))
 		// 获得定时器
@@ -51,6 +81,10 @@ func NewOrderService(repo repository.OrderRepo) IOrderService {
 		if _, err := timerTask.AddFunc("0 * * * * ?", service.processTimeoutOrder); err != nil {
 			global.Log.Warn("TimerTaskError")
 		}
+		// 每分钟推送到期的预约订单并提醒未接单的预约订单
+		if _, err := timerTask.AddFunc("30 * * * * ?", service.processScheduledOrders); err != nil {
+			global.Log.Warn("TimerTaskError")
+		}
 		////每天凌晨1点 派送中订单自动完成
 		//if _, err := timerTask.AddFunc("0 0 1 * * ?", service.processDeliveryOrder); err != nil {
 		//	panic(errs.TimerTaskError)
@@ -67,6 +101,17 @@ func (s *OrderService) OrderSubmit(ctx *gin.Context, data request.OrderSubmitDTO
 	if !ok {
 		return response.OrderSubmitVO{}, errors.New("未查找到用户")
 	}
+	if err := s.prepareOrderType(ctx, &data); err != nil {
+		return response.OrderSubmitVO{}, err
+	}
+	if err := s.carts.checkout(ctx, int(userId.(uint64)), data.OrderType); err != nil {
+		return response.OrderSubmitVO{}, err
+	}
+	release, err := s.reserveSlot(ctx, &data)
+	if err != nil {
+		return response.OrderSubmitVO{}, err
+	}
+	defer release()
 	OrderVo, err := s.repo.OrderSubmit(ctx, data, int(userId.(uint64)))
 	if err != nil {
 		return response.OrderSubmitVO{}, err
@@ -74,8 +119,69 @@ func (s *OrderService) OrderSubmit(ctx *gin.Context, data request.OrderSubmitDTO
 	return OrderVo, nil
 }
 
+// prepareOrderType 校验订单类型，堂食订单校验扫码得到的桌台，自取与堂食订单分配取餐码
+func (s *OrderService) prepareOrderType(ctx context.Context, data *request.OrderSubmitDTO) error {
+	switch data.OrderType {
+	case 0, enum.OrderTypeDelivery:
+		data.OrderType = enum.OrderTypeDelivery
+		if data.AddressBookId == 0 {
+			return fmt.Errorf("%w: 外卖订单需要选择收货地址", e.Error_ORDER_TYPE_INVALID)
+		}
+		return nil
+	case enum.OrderTypePickup:
+		data.TableId, data.TableLabel = 0, ""
+	case enum.OrderTypeDineIn:
+		table, err := s.tables.checkTable(ctx, data.TableId, data.TableCode)
+		if err != nil {
+			return err
+		}
+		data.TableLabel = table.Label()
+	default:
+		return fmt.Errorf("%w: %d", e.Error_ORDER_TYPE_INVALID, data.OrderType)
+	}
+	data.AddressBookId = 0
+	code, err := nextPickupCode(time.Now())
+	if err != nil {
+		return err
+	}
+	data.PickupCode = code
+	return nil
+}
+
+// nextPickupCode 当天递增的 4 位取餐码，计数键保留两天
+func nextPickupCode(now time.Time) (string, error) {
+	key := PickupCodeKey + now.Format("20060102")
+	n, err := global.RedisClient.Incr(key).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate pickup code: %w", err)
+	}
+	if n == 1 {
+		global.RedisClient.Expire(key, 48*time.Hour)
+	}
+	return fmt.Sprintf("%04d", n%10000), nil
+}
+
 // OrderPayment 订单支付
-func (s *OrderService) OrderPayment(ctx *gin.Context, orderData request.OrderPaymentDTO) response.OrderPaymentVO {
+func (s *OrderService) OrderPayment(ctx *gin.Context, orderData request.OrderPaymentDTO) (response.OrderPaymentVO, error) {
+	userId := ctx.MustGet(enum.CurrentId).(uint64)
+	order, err := s.repo.GetOrderByNumberAndUserId(orderData.OrderNumber, strconv.FormatUint(userId, 10))
+	if err != nil {
+		return response.OrderPaymentVO{}, err
+	}
+	if order == nil || order.Id == 0 {
+		return response.OrderPaymentVO{}, e.Error_ORDER_NOT_FOUND
+	}
+	if order.Status != enum.PendingPayment {
+		return response.OrderPaymentVO{}, e.Error_ORDER_STATUS_ERROR
+	}
+	// AA 支付的订单只能由成员支付各自的份额，发起人不能整单支付
+	split, err := s.repo.IsSplitBill(ctx, order.Id)
+	if err != nil {
+		return response.OrderPaymentVO{}, err
+	}
+	if split {
+		return response.OrderPaymentVO{}, e.Error_ORDER_SPLIT_BILL
+	}
 	// 调用微信支付接口，生成预支付交易单，此处进行模拟
 	log.Printf("调用微信支付接口: [%v]", orderData)
 	// 模拟支付成功，修改订单状态
@@ -86,7 +192,7 @@ func (s *OrderService) OrderPayment(ctx *gin.Context, orderData request.OrderPay
 		SignType:   "111",
 		PackageStr: iUtils.UUID(),
 		TimeStamp:  strconv.FormatInt(time.Now().UnixMilli(), 10),
-	}
+	}, nil
 }
 
 // OrderDetail 根据订单id查询订单详情
@@ -121,13 +227,13 @@ func (s *OrderService) OrderDetail(ctx *gin.Context, orderId string) (response.O
 func (s *OrderService) CancelOrder(ctx *gin.Context, orderId string) error {
 	// 根据id查询订单
 	order, _ := s.repo.GetOrderById(orderId)
-	// 校验订单是否存在 ,校验订单状态
-	if order == nil || order.Status > enum.ToBeConfirmed {
+	// 校验订单是否存在 ,校验订单状态，尚未推送给商家的预约订单同样可以取消
+	if order == nil || (order.Status > enum.ToBeConfirmed && order.Status != enum.Scheduled) {
 		return errors.New("订单错误")
 	}
 
-	// 订单处于待接单状态下取消，需要进行退款
-	if order.Status == enum.ToBeConfirmed {
+	// 订单处于待接单或预约状态下取消，需要进行退款
+	if order.Status == enum.ToBeConfirmed || order.Status == enum.Scheduled {
 		// 模拟微信退款
 		log.Printf("待接单订单取消, 退款: [%v￥]", order.Amount)
 		// 支付状态修改为 退款
@@ -145,14 +251,6 @@ func (s *OrderService) CancelOrder(ctx *gin.Context, orderId string) error {
 	return nil
 }
 
-// RepetitionOrder 再来一单
-func (s *OrderService) RepetitionOrder(ctx *gin.Context, orderId string) error {
-	// 查询当前用户id
-	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
-	// 根据订单id查询当前订单详情
-	return s.repo.RepetitionOrder(ctx, orderId, userId)
-}
-
 // OrderReminder 用户催单
 func (s *OrderService) OrderReminder(ctx *gin.Context, orderId string) error {
 	// 查询订单是否存在
@@ -183,21 +281,36 @@ func (s *OrderService) paySuccess(ctx *gin.Context, orderNumber string) {
 	userId, _ := ctx.Get(enum.CurrentId)
 	// 根据订单号查询当前用户的订单
 	order, _ := s.repo.GetOrderByNumberAndUserId(orderNumber, strconv.FormatUint(userId.(uint64), 10))
-	// 根据订单id更新订单的状态、支付方式、支付状态、结账时间
-	err := s.repo.UpdateOrder(&model.Order{
+	_ = markOrderPaid(s.repo, order)
+}
+
+// markOrderPaid 更新订单的状态、支付状态、结账时间并提醒商家来单
+// 预约订单在推送时间之前不提醒商家，由定时任务到期推送
+func markOrderPaid(repo repository.OrderRepo, order *model.Order) error {
+	status := enum.ToBeConfirmed
+	if holdScheduled(order, time.Now()) {
+		status = enum.Scheduled
+	}
+	err := repo.UpdateOrder(&model.Order{
 		Id:           order.Id,
-		Status:       enum.ToBeConfirmed,
+		Status:       status,
 		PayStatus:    enum.Paid,
 		CheckoutTime: model.LocalTime(time.Now()),
 	})
-	if err != nil {
-		return
+	if err != nil || status == enum.Scheduled {
+		return err
 	}
+	notifyNewOrder(order)
+	return nil
+}
+
+// notifyNewOrder 提醒商家来单
+func notifyNewOrder(order *model.Order) {
 	// 基于WebSocket提醒商家来单了
 	jsonMap := map[string]any{
 		"type":    1,
 		"orderId": order.Id,
-		"content": "订单号: " + orderNumber,
+		"content": "订单号: " + order.Number,
 	}
 	websocket.WSServer.SendToAllClients(jsonMap)
 }
@@ -229,9 +342,32 @@ func (s *OrderService) OrderConfirm(ctx *gin.Context, data request.OrderConfirmD
 	if err != nil {
 		return err
 	}
+	// 生成后厨单，失败时由后厨看板补生成
+	if order, err := s.repo.GetOrderById(confirmOrderId(data.OrderId)); err == nil && order != nil && order.Id != 0 {
+		if err = s.kitchen.dispatch(ctx, order); err != nil {
+			global.Log.Warn("Dispatch kitchen ticket failed", "orderId", order.Id, "error", err)
+		}
+		// 生成打印任务，由打印队列异步打印
+		if err = s.printer.enqueue(ctx, order); err != nil {
+			global.Log.Warn("Enqueue print jobs failed", "orderId", order.Id, "error", err)
+		}
+	}
 	return nil
 }
 
+// confirmOrderId 接单参数中的订单id可能是数字或字符串
+func confirmOrderId(id any) string {
+	switch v := id.(type) {
+	case float64:
+		return strconv.FormatInt(int64(v), 10)
+	case int:
+		return strconv.Itoa(v)
+	case string:
+		return v
+	}
+	return ""
+}
+
 // OrderRejection 拒绝订单
 func (s *OrderService) OrderRejection(ctx *gin.Context, data request.OrderRejectionDTO) error {
 	err := s.repo.OrderRejection(ctx, data)
@@ -260,6 +396,7 @@ func (s *OrderService) CancelOrderByBusiness(ctx *gin.Context, data request.Orde
 	if err != nil {
 		return err
 	}
+	s.kitchen.remove(ctx, order.Id, "cancel")
 	return nil
 }
 
@@ -269,6 +406,9 @@ func (s *OrderService) OrderDelivery(ctx *gin.Context, orderId string) error {
 	if err != nil {
 		return err
 	}
+	if id, err := strconv.Atoi(orderId); err == nil {
+		s.kitchen.remove(ctx, id, "delivery")
+	}
 	return nil
 }
 
@@ -278,9 +418,63 @@ func (s *OrderService) OrderComplete(ctx *gin.Context, orderId string) error {
 	if err != nil {
 		return err
 	}
+	// 增量更新下单日期的营业数据，失败时由夜间任务重算修正，售后重做订单不计入
+	if order, err := s.repo.GetOrderById(orderId); err == nil && order != nil {
+		if order.RemakeOf == 0 {
+			if err = s.reportRepo.IncrDailyStats(time.Time(order.OrderTime), order.Amount, 1); err != nil {
+				global.Log.Warn("Incr daily business stats failed", "orderId", orderId, "error", err)
+			}
+		}
+		// 自取与堂食订单不经过派送，完成时从后厨看板移除
+		if !order.Delivers() {
+			s.kitchen.remove(ctx, order.Id, "complete")
+		}
+	}
 	return nil
 }
 
+// OrderReady 出餐通知，仅用于已接单的自取与堂食订单，可重复通知
+func (s *OrderService) OrderReady(ctx *gin.Context, orderId string) error {
+	order, err := s.repo.GetOrderById(orderId)
+	if err != nil {
+		return err
+	}
+	if order == nil || order.Id == 0 {
+		return e.Error_ORDER_NOT_FOUND
+	}
+	if order.Delivers() || order.Status != enum.Confirmed {
+		return e.Error_ORDER_STATUS_ERROR
+	}
+	if err = s.repo.UpdateOrder(&model.Order{Id: order.Id, ReadyTime: model.LocalTime(time.Now())}); err != nil {
+		return err
+	}
+	content := "取餐码 " + order.PickupCode + " 已出餐，请取餐"
+	if order.OrderType == enum.OrderTypeDineIn {
+		content = order.TableLabel + " 取餐码 " + order.PickupCode + " 已出餐"
+	}
+	websocket.WSServer.SendToAllClients(map[string]any{
+		"type":       wsPickupReady,
+		"orderId":    order.Id,
+		"orderType":  order.OrderType,
+		"pickupCode": order.PickupCode,
+		"tableLabel": order.TableLabel,
+		"content":    content,
+	})
+	return nil
+}
+
+// OrderPickup 核销取餐码，取餐码每日重新编号，只查找近一天内的订单
+func (s *OrderService) OrderPickup(ctx *gin.Context, data request.OrderPickupDTO) error {
+	order, err := s.repo.GetOrderByPickupCode(ctx, strings.TrimSpace(data.PickupCode), time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return e.Error_PICKUP_CODE_NOT_FOUND
+	}
+	return s.OrderComplete(ctx, strconv.Itoa(order.Id))
+}
+
 func (s *OrderService) OrderConditionSearch(ctx *gin.Context, data request.OrderPageQueryDTO) (*common.PageResult, error) {
 	return s.repo.OrderConditionSearch(ctx, data)
 }
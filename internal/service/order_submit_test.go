@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"takeout/common/enum"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/model"
+	"takeout/repository/dao"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// sqlRecorder 不连接数据库的驱动，记录执行的语句，插入返回递增的自增id，
+// 查询返回 row 给出的单行结果，未设置 row 时返回空结果
+type sqlRecorder struct {
+	mu      sync.Mutex
+	execs   []recordedExec
+	queries []string
+	lastId  int64
+	row     func(query string) (columns []string, values []driver.Value)
+}
+
+type recordedExec struct {
+	query string
+	args  []any
+}
+
+func (r *sqlRecorder) Connect(context.Context) (driver.Conn, error) { return recorderConn{r}, nil }
+func (r *sqlRecorder) Driver() driver.Driver                        { return nil }
+
+// inserts 插入 table 的语句参数
+func (r *sqlRecorder) inserts(table string) [][]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res [][]any
+	for _, exec := range r.execs {
+		if strings.HasPrefix(exec.query, "INSERT INTO `"+table+"`") {
+			res = append(res, exec.args)
+		}
+	}
+	return res
+}
+
+type recorderConn struct{ r *sqlRecorder }
+
+func (c recorderConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c recorderConn) Close() error                        { return nil }
+func (c recorderConn) Begin() (driver.Tx, error)           { return c, nil }
+func (c recorderConn) Commit() error                       { return nil }
+func (c recorderConn) Rollback() error                     { return nil }
+
+func (c recorderConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	exec := recordedExec{query: query}
+	for _, arg := range args {
+		exec.args = append(exec.args, arg.Value)
+	}
+	c.r.execs = append(c.r.execs, exec)
+	c.r.lastId++
+	return recordedResult(c.r.lastId), nil
+}
+
+func (c recorderConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.r.queries = append(c.r.queries, query)
+	if c.r.row != nil {
+		if columns, values := c.r.row(query); columns != nil {
+			return &singleRow{columns: columns, values: values}, nil
+		}
+	}
+	return emptyRows{}, nil
+}
+
+type recordedResult int64
+
+func (r recordedResult) LastInsertId() (int64, error) { return int64(r), nil }
+func (r recordedResult) RowsAffected() (int64, error) { return 1, nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return nil }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+type singleRow struct {
+	columns []string
+	values  []driver.Value
+	done    bool
+}
+
+func (r *singleRow) Columns() []string { return r.columns }
+func (r *singleRow) Close() error      { return nil }
+func (r *singleRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+// useRecordedDB 测试期间将 global.DB 替换为记录语句的 MySQL 方言连接
+func useRecordedDB(t *testing.T) *sqlRecorder {
+	t.Helper()
+	rec := &sqlRecorder{}
+	sqlDB := sql.OpenDB(rec)
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger:         logger.Discard,
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := global.DB
+	global.DB = db
+	t.Cleanup(func() {
+		global.DB = old
+		_ = sqlDB.Close()
+	})
+	return rec
+}
+
+func TestOrderDaoSubmitFromCart(t *testing.T) {
+	useMiniredis(t)
+	rec := useRecordedDB(t)
+	ctx := userCtx(7)
+	carts := dao.NewShoppingCartDao(global.DB)
+	if err := carts.InsertBatchShoppingCart(ctx, []model.ShoppingCart{
+		{UserId: 7, Name: "宫保鸡丁", DishId: 1, DishFlavor: "微辣", Number: 2, Amount: 28},
+		{UserId: 7, Name: "双人套餐", SetmealId: 3, Number: 1, Amount: 58},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// OrderDao 内嵌的购物车与购物车接口读写同一份 Redis 数据
+	vo, err := dao.NewOrderDao().OrderSubmit(ctx, request.OrderSubmitDTO{OrderType: enum.OrderTypePickup, Amount: 114}, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vo.OrderId == 0 || vo.OrderAmount != 114 {
+		t.Fatalf("submit = %+v", vo)
+	}
+	if orders := rec.inserts("orders"); len(orders) != 1 {
+		t.Fatalf("order inserts = %v", orders)
+	}
+	details := rec.inserts("order_detail")
+	if len(details) != 1 || !slices.Contains(details[0], any("宫保鸡丁")) || !slices.Contains(details[0], any("双人套餐")) {
+		t.Fatalf("order detail inserts = %v, want both cart lines", details)
+	}
+	if lines, err := carts.List(ctx, 7); err != nil || len(lines) != 0 {
+		t.Errorf("cart after submit = %v, %v, want empty", lines, err)
+	}
+}
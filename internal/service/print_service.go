@@ -0,0 +1,566 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/common/receipt"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	userResponse "takeout/internal/api/user/response"
+	"takeout/internal/model"
+	"takeout/repository"
+	"time"
+)
+
+const (
+	printBatch          = 20
+	printDefaultWait    = 10 * time.Second
+	printDefaultRetry   = 10 * time.Second
+	printDefaultTick    = 15 * time.Second
+	printDefaultAttempt = 5
+	printMaxCopies      = 5
+	printMaxBackoff     = 10 * time.Minute
+)
+
+// printQueue 有新任务时唤醒打印队列，多次唤醒合并为一次
+var printQueue = make(chan struct{}, 1)
+
+func wakePrintQueue() {
+	select {
+	case printQueue <- struct{}{}:
+	default:
+	}
+}
+
+type IPrintService interface {
+	ListPrinters(ctx context.Context) ([]model.Printer, error)
+	AddPrinter(ctx context.Context, dto request.PrinterDTO) (*model.Printer, error)
+	EditPrinter(ctx context.Context, dto request.PrinterDTO) error
+	DeletePrinter(ctx context.Context, id uint64) error
+	TestPrinter(ctx context.Context, id uint64) error
+
+	Preview(ctx context.Context, dto request.ReceiptPreviewDTO) (contentType string, data []byte, err error)
+	Reprint(ctx context.Context, dto request.ReprintDTO) ([]model.PrintJob, error)
+	PageJobs(ctx context.Context, dto request.PrintJobPageQueryDTO) (*common.PageResult, error)
+	RetryJob(ctx context.Context, id uint64) error
+}
+
+type PrintService struct {
+	repo      repository.PrintRepo
+	orderRepo repository.OrderRepo
+	drivers   receipt.Drivers
+}
+
+// NewPrintService 创建打印服务并启动打印队列
+func NewPrintService(repo repository.PrintRepo, orderRepo repository.OrderRepo) IPrintService {
+	service := &PrintService{repo: repo, orderRepo: orderRepo, drivers: NewPrintDrivers()}
+	go service.run()
+	return service
+}
+
+// NewPrintDrivers 按配置创建打印驱动，dry_run 时全部写入文件
+func NewPrintDrivers() receipt.Drivers {
+	cfg := global.Config.Print
+	dir := cfg.FileDir
+	if dir == "" {
+		dir = "./export/print"
+	}
+	file := &receipt.File{Dir: dir}
+	if cfg.DryRun {
+		return receipt.Drivers{receipt.DriverNetwork: file, receipt.DriverFile: file}
+	}
+	return receipt.Drivers{
+		receipt.DriverNetwork: &receipt.Network{Timeout: printDuration(cfg.Timeout, printDefaultWait)},
+		receipt.DriverFile:    file,
+	}
+}
+
+// ListPrinters 打印机列表
+func (s *PrintService) ListPrinters(ctx context.Context) ([]model.Printer, error) {
+	return s.repo.ListPrinters(ctx, false)
+}
+
+// AddPrinter 新增打印机
+func (s *PrintService) AddPrinter(ctx context.Context, dto request.PrinterDTO) (*model.Printer, error) {
+	printer := &model.Printer{Cut: true, AutoPrint: enum.ENABLE, Status: enum.ENABLE}
+	if err := applyPrinterDTO(printer, dto); err != nil {
+		return nil, err
+	}
+	if err := s.repo.InsertPrinter(ctx, printer); err != nil {
+		return nil, err
+	}
+	return printer, nil
+}
+
+// EditPrinter 修改打印机，未传的开关保持原值
+func (s *PrintService) EditPrinter(ctx context.Context, dto request.PrinterDTO) error {
+	printer, err := s.getPrinter(ctx, dto.Id)
+	if err != nil {
+		return err
+	}
+	if err = applyPrinterDTO(printer, dto); err != nil {
+		return err
+	}
+	return s.repo.UpdatePrinter(ctx, printer)
+}
+
+// DeletePrinter 删除打印机，其未完成的任务一并取消
+func (s *PrintService) DeletePrinter(ctx context.Context, id uint64) error {
+	if _, err := s.getPrinter(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeletePrinter(ctx, id)
+}
+
+// TestPrinter 立即打印示例小票，不经过打印队列
+func (s *PrintService) TestPrinter(ctx context.Context, id uint64) error {
+	printer, err := s.getPrinter(ctx, id)
+	if err != nil {
+		return err
+	}
+	kind := receipt.KindCustomer
+	if !printer.Prints(kind) {
+		kind = receipt.KindKitchen
+	}
+	sample := receipt.Sample(kind)
+	if printer.Title != "" {
+		sample.Title = printer.Title
+	}
+	if err = s.send(ctx, printer, sample, "test_"+kind); err != nil {
+		return fmt.Errorf("%w: %v", e.Error_PRINT_FAILED, err)
+	}
+	return nil
+}
+
+func (s *PrintService) getPrinter(ctx context.Context, id uint64) (*model.Printer, error) {
+	printer, err := s.repo.GetPrinter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if printer == nil {
+		return nil, e.Error_PRINTER_NOT_FOUND
+	}
+	return printer, nil
+}
+
+// Preview 按打印机配置（未指定时按 58mm 纸）渲染订单小票
+func (s *PrintService) Preview(ctx context.Context, dto request.ReceiptPreviewDTO) (string, []byte, error) {
+	printer := &model.Printer{Format: receipt.FormatText}
+	if dto.PrinterId != 0 {
+		var err error
+		if printer, err = s.getPrinter(ctx, dto.PrinterId); err != nil {
+			return "", nil, err
+		}
+	}
+	if dto.Format != "" {
+		preview := *printer
+		preview.Format = dto.Format
+		if preview.Format != printer.Format {
+			preview.Template = ""
+		}
+		printer = &preview
+	}
+	kind := dto.Kind
+	if kind == "" {
+		kind = receipt.KindCustomer
+	}
+	if err := receipt.ValidateKind(kind); err != nil {
+		return "", nil, printerInvalid("%v", err)
+	}
+	order, err := s.orderVO(dto.OrderId)
+	if err != nil {
+		return "", nil, err
+	}
+	renderer, err := receipt.NewRenderer(printer.Format)
+	if err != nil {
+		return "", nil, printerInvalid("%v", err)
+	}
+	data, err := renderer.Render(orderReceipt(order, printer, kind, false), printerProfile(printer))
+	if err != nil {
+		return "", nil, printerInvalid("%v", err)
+	}
+	return renderer.ContentType(), data, nil
+}
+
+// Reprint 补打订单小票，生成的任务进入打印队列
+func (s *PrintService) Reprint(ctx context.Context, dto request.ReprintDTO) ([]model.PrintJob, error) {
+	if dto.Kind != "" {
+		if err := receipt.ValidateKind(dto.Kind); err != nil {
+			return nil, printerInvalid("%v", err)
+		}
+	}
+	order, err := s.orderRepo.GetOrderById(strconv.Itoa(dto.OrderId))
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.Id == 0 {
+		return nil, e.Error_ORDER_NOT_FOUND
+	}
+	var printers []model.Printer
+	if dto.PrinterId != 0 {
+		printer, err := s.getPrinter(ctx, dto.PrinterId)
+		if err != nil {
+			return nil, err
+		}
+		// 指定了打印机与类型时不受打印机配置的类型限制
+		if dto.Kind != "" {
+			printer.Kinds = dto.Kind
+		}
+		printers = []model.Printer{*printer}
+	} else if printers, err = s.repo.ListPrinters(ctx, true); err != nil {
+		return nil, err
+	}
+	jobs := newPrintJobs(order, printers, dto.Kind, true)
+	if len(jobs) == 0 {
+		return nil, printerInvalid("没有可打印该小票的打印机")
+	}
+	if err = s.repo.InsertJobs(ctx, jobs); err != nil {
+		return nil, err
+	}
+	wakePrintQueue()
+	return jobs, nil
+}
+
+// PageJobs 打印任务分页查询
+func (s *PrintService) PageJobs(ctx context.Context, dto request.PrintJobPageQueryDTO) (*common.PageResult, error) {
+	return s.repo.PageJobs(ctx, dto)
+}
+
+// RetryJob 失败的任务重新排队
+func (s *PrintService) RetryJob(ctx context.Context, id uint64) error {
+	ok, err := s.repo.ResetJob(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return e.Error_PRINT_JOB_NOT_FOUND
+	}
+	wakePrintQueue()
+	return nil
+}
+
+// enqueue 接单后为开启自动打印的打印机生成任务，重复接单不会重复生成
+func (s *PrintService) enqueue(ctx context.Context, order *model.Order) error {
+	exists, err := s.repo.HasAutoJobs(ctx, order.Id)
+	if err != nil || exists {
+		return err
+	}
+	printers, err := s.repo.ListPrinters(ctx, true)
+	if err != nil {
+		return err
+	}
+	auto := printers[:0]
+	for _, printer := range printers {
+		if printer.AutoPrint == enum.ENABLE {
+			auto = append(auto, printer)
+		}
+	}
+	if err = s.repo.InsertJobs(ctx, newPrintJobs(order, auto, "", false)); err != nil {
+		return err
+	}
+	wakePrintQueue()
+	return nil
+}
+
+// newPrintJobs 每台打印机按其配置的类型各生成一个任务，kind 非空时只生成该类型
+func newPrintJobs(order *model.Order, printers []model.Printer, kind string, reprint bool) []model.PrintJob {
+	var jobs []model.PrintJob
+	now := model.LocalTime(time.Now())
+	for _, printer := range printers {
+		for _, k := range []string{receipt.KindKitchen, receipt.KindCustomer} {
+			if (kind != "" && k != kind) || !printer.Prints(k) {
+				continue
+			}
+			jobs = append(jobs, model.PrintJob{
+				PrinterId:   printer.Id,
+				OrderId:     order.Id,
+				OrderNumber: order.Number,
+				Kind:        k,
+				Reprint:     reprint,
+				Status:      enum.PrintPending,
+				NextTime:    now,
+			})
+		}
+	}
+	return jobs
+}
+
+// run 打印队列：定时轮询，有新任务时立即处理
+func (s *PrintService) run() {
+	ticker := time.NewTicker(printDuration(global.Config.Print.Interval, printDefaultTick))
+	defer ticker.Stop()
+	for {
+		s.processQueue(context.Background())
+		select {
+		case <-ticker.C:
+		case <-printQueue:
+		}
+	}
+}
+
+func (s *PrintService) processQueue(ctx context.Context) {
+	for {
+		jobs, err := s.repo.ListDueJobs(ctx, time.Now(), printBatch)
+		if err != nil {
+			global.Log.Warn("List print jobs failed", "error", err)
+			return
+		}
+		printers := make(map[uint64]*model.Printer)
+		for i := range jobs {
+			s.process(ctx, &jobs[i], printers)
+		}
+		if len(jobs) < printBatch {
+			return
+		}
+	}
+}
+
+// process 领取并打印一个任务，失败时按退避时间重新排队，重试次数用尽或无法重试的错误标记为失败
+func (s *PrintService) process(ctx context.Context, job *model.PrintJob, printers map[uint64]*model.Printer) {
+	timeout := printDuration(global.Config.Print.Timeout, printDefaultWait)
+	now := time.Now()
+	ok, err := s.repo.ClaimJob(ctx, job.Id, now, now.Add(3*timeout))
+	if err != nil {
+		global.Log.Warn("Claim print job failed", "jobId", job.Id, "error", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	job.Attempts++
+	printCtx, cancel := context.WithTimeout(ctx, timeout)
+	err = s.printJob(printCtx, job, printers)
+	cancel()
+
+	job.LastError = ""
+	switch {
+	case err == nil:
+		job.Status, job.PrintTime = enum.PrintDone, model.LocalTime(time.Now())
+	case job.Attempts >= printMaxAttempts() ||
+		errors.Is(err, e.Error_PRINTER_NOT_FOUND) ||
+		errors.Is(err, e.Error_PRINTER_INVALID) ||
+		errors.Is(err, e.Error_ORDER_NOT_FOUND):
+		job.Status = enum.PrintFailed
+	default:
+		job.Status = enum.PrintPending
+		job.NextTime = model.LocalTime(time.Now().Add(printBackoff(job.Attempts)))
+	}
+	if err != nil {
+		job.LastError = err.Error()
+		if len([]rune(job.LastError)) > 500 {
+			job.LastError = string([]rune(job.LastError)[:500])
+		}
+		global.Log.Warn("Print job failed", "jobId", job.Id, "attempts", job.Attempts, "error", err)
+	}
+	if err = s.repo.FinishJob(ctx, job); err != nil {
+		global.Log.Warn("Save print job failed", "jobId", job.Id, "error", err)
+	}
+}
+
+func (s *PrintService) printJob(ctx context.Context, job *model.PrintJob, printers map[uint64]*model.Printer) error {
+	printer, ok := printers[job.PrinterId]
+	if !ok {
+		var err error
+		if printer, err = s.repo.GetPrinter(ctx, job.PrinterId); err != nil {
+			return err
+		}
+		printers[job.PrinterId] = printer
+	}
+	if printer == nil {
+		return e.Error_PRINTER_NOT_FOUND
+	}
+	if printer.Status != enum.ENABLE {
+		return printerInvalid("打印机已停用")
+	}
+	order, err := s.orderVO(job.OrderId)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, printer, orderReceipt(order, printer, job.Kind, job.Reprint), job.OrderNumber+"_"+job.Kind)
+}
+
+// send 按打印机配置渲染并发送，份数通过重复数据实现
+func (s *PrintService) send(ctx context.Context, printer *model.Printer, r *receipt.Receipt, name string) error {
+	renderer, err := receipt.NewRenderer(printer.Format)
+	if err != nil {
+		return printerInvalid("%v", err)
+	}
+	data, err := renderer.Render(r, printerProfile(printer))
+	if err != nil {
+		return printerInvalid("%v", err)
+	}
+	data = bytes.Repeat(data, min(max(printer.Copies, 1), printMaxCopies))
+	return s.drivers.Print(ctx, printer.Driver, printer.Address, name+renderer.Ext(), data)
+}
+
+func (s *PrintService) orderVO(orderId int) (userResponse.OrderVO, error) {
+	id := strconv.Itoa(orderId)
+	order, err := s.orderRepo.GetOrderById(id)
+	if err != nil {
+		return userResponse.OrderVO{}, err
+	}
+	if order == nil || order.Id == 0 {
+		return userResponse.OrderVO{}, e.Error_ORDER_NOT_FOUND
+	}
+	details, err := s.orderRepo.GetOrderDetailByOrderId(id)
+	if err != nil {
+		return userResponse.OrderVO{}, err
+	}
+	return userResponse.OrderVO{Order: *order, OrderDetailList: details}, nil
+}
+
+// orderReceipt 订单转为小票内容，抬头与页脚来自打印机配置
+func orderReceipt(order userResponse.OrderVO, printer *model.Printer, kind string, reprint bool) *receipt.Receipt {
+	r := &receipt.Receipt{
+		Kind:        kind,
+		Title:       printer.Title,
+		Footer:      printer.Footer,
+		Reprint:     reprint,
+		Tag:         orderTag(order.Order),
+		OrderNumber: order.Number,
+		OrderTime:   time.Time(order.OrderTime),
+		Remark:      order.Remark,
+		Tableware:   tablewareText(order.Order),
+		PackAmount:  order.PackAmount,
+		Amount:      order.Amount,
+		Consignee:   order.Consignee,
+		Phone:       order.Phone,
+		Address:     order.Address,
+	}
+	for _, detail := range order.OrderDetailList {
+		r.Lines = append(r.Lines, receipt.Line{
+			Name:   detail.Name,
+			Flavor: detail.DishFlavor,
+			Number: detail.Number,
+			Price:  detail.Amount,
+		})
+	}
+	return r
+}
+
+// orderTag 自取与堂食订单的小票标注，外卖订单不标注
+func orderTag(order model.Order) string {
+	switch order.OrderType {
+	case enum.OrderTypePickup:
+		return "自取 取餐码 " + order.PickupCode
+	case enum.OrderTypeDineIn:
+		return strings.TrimSpace("堂食 " + order.TableLabel + " 取餐码 " + order.PickupCode)
+	}
+	return ""
+}
+
+// tablewareText 餐具状态 1 按餐量提供，0 为选择的具体数量
+func tablewareText(order model.Order) string {
+	switch {
+	case order.TablewareStatus == 1:
+		return "按餐量提供"
+	case order.TablewareNumber > 0:
+		return strconv.Itoa(order.TablewareNumber) + "份"
+	}
+	return "无需餐具"
+}
+
+func printerProfile(printer *model.Printer) receipt.Profile {
+	return receipt.Profile{
+		Width:    printer.Width,
+		Encoding: printer.Encoding,
+		Cut:      printer.Cut,
+		Template: printer.Template,
+	}
+}
+
+// applyPrinterDTO 校验并填充打印机配置，用示例小票试渲染以检查编码与模板
+func applyPrinterDTO(printer *model.Printer, dto request.PrinterDTO) error {
+	printer.Name = strings.TrimSpace(dto.Name)
+	printer.Driver = orDefault(dto.Driver, receipt.DriverNetwork)
+	printer.Address = strings.TrimSpace(dto.Address)
+	if err := receipt.ValidateAddress(printer.Driver, printer.Address); err != nil {
+		return printerInvalid("%v", err)
+	}
+	printer.Format = orDefault(dto.Format, receipt.FormatESCPOS)
+	printer.Width = dto.Width
+	if printer.Width == 0 {
+		printer.Width = receipt.Width58
+	}
+	if printer.Width < 16 || printer.Width > 96 {
+		return printerInvalid("每行字符数须在 16 到 96 之间")
+	}
+	printer.Encoding = orDefault(dto.Encoding, "gbk")
+	if dto.Cut != nil {
+		printer.Cut = *dto.Cut
+	}
+	printer.Copies = max(dto.Copies, 1)
+	if printer.Copies > printMaxCopies {
+		return printerInvalid("打印份数不能超过 %d", printMaxCopies)
+	}
+	kinds := dto.Kinds
+	if len(kinds) == 0 {
+		kinds = []string{receipt.KindCustomer}
+	}
+	for _, kind := range kinds {
+		if err := receipt.ValidateKind(kind); err != nil {
+			return printerInvalid("%v", err)
+		}
+	}
+	printer.Kinds = strings.Join(kinds, ",")
+	if dto.AutoPrint != nil {
+		printer.AutoPrint = *dto.AutoPrint
+	}
+	printer.Title, printer.Footer = strings.TrimSpace(dto.Title), strings.TrimSpace(dto.Footer)
+	printer.Template = dto.Template
+	if err := receipt.ValidateTemplate(printer.Format, printer.Template); err != nil {
+		return printerInvalid("%v", err)
+	}
+	if dto.Status != nil {
+		printer.Status = *dto.Status
+	}
+	renderer, err := receipt.NewRenderer(printer.Format)
+	if err != nil {
+		return printerInvalid("%v", err)
+	}
+	if _, err = renderer.Render(receipt.Sample(receipt.KindCustomer), printerProfile(printer)); err != nil {
+		return printerInvalid("%v", err)
+	}
+	return nil
+}
+
+func orDefault(value, def string) string {
+	if value = strings.TrimSpace(value); value != "" {
+		return value
+	}
+	return def
+}
+
+// printBackoff 第 n 次失败后的等待时间，按首次间隔翻倍，最长 10 分钟
+func printBackoff(attempts int) time.Duration {
+	delay := printDuration(global.Config.Print.RetryDelay, printDefaultRetry)
+	for i := 1; i < attempts && delay < printMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, printMaxBackoff)
+}
+
+func printMaxAttempts() int {
+	if n := global.Config.Print.MaxAttempts; n > 0 {
+		return n
+	}
+	return printDefaultAttempt
+}
+
+func printDuration(value string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+func printerInvalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", e.Error_PRINTER_INVALID, fmt.Sprintf(format, args...))
+}
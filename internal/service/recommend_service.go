@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/robfig/cron/v3"
+	"strconv"
+	"takeout/common/enum"
+	"takeout/common/recommend"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/repository"
+	"time"
+)
+
+const (
+	RecommendRelatedKey = "recommend:related:" // 共购关联缓存，后接物品标识如 dish:1
+	RecommendUserKey    = "recommend:user:"    // 用户偏好缓存，后接用户id
+	RecommendUpdatedKey = "recommend:updated"  // 最近一次离线计算时间
+
+	recommendWindow       = 90 * 24 * time.Hour // 参与计算的订单时间范围
+	recommendHotWindow    = 30 * 24 * time.Hour // 热销兜底的统计范围
+	recommendExpire       = 48 * time.Hour      // 离线结果过期时间，两次计算失败后自动退化为热销
+	recommendTopN         = 20
+	recommendDefaultLimit = 10
+	recommendMaxLimit     = 30
+	recommendRepeatFactor = 0.3 // 回购候选的得分折扣
+
+	recommendSourcePersonal = "personal"
+	recommendSourceHot      = "hot"
+)
+
+type IRecommendService interface {
+	Recommend(ctx *gin.Context, dto request.RecommendDTO) ([]response.RecommendItemVO, error)
+	Rebuild(ctx context.Context) error
+}
+
+type RecommendService struct {
+	repo         repository.RecommendRepo
+	favoriteRepo repository.FavoriteRepo
+	catalogRepo  repository.SearchRepo
+	reportRepo   repository.ReportRepo
+}
+
+func NewRecommendService(repo repository.RecommendRepo, favoriteRepo repository.FavoriteRepo,
+	catalogRepo repository.SearchRepo, reportRepo repository.ReportRepo) IRecommendService {
+	service := &RecommendService{
+		repo:         repo,
+		favoriteRepo: favoriteRepo,
+		catalogRepo:  catalogRepo,
+		reportRepo:   reportRepo,
+	}
+	// 每天凌晨离线计算共购关联与用户偏好
+	timerTask := cron.New(cron.WithSeconds())
+	if _, err := timerTask.AddFunc("0 0 3 * * ?", service.rebuildTask); err != nil {
+		global.Log.Warn("TimerTaskError")
+	}
+	timerTask.Start()
+	// 首次部署或缓存丢失时立即计算一次
+	if n, err := global.RedisClient.Exists(RecommendUpdatedKey).Result(); err == nil && n == 0 {
+		go service.rebuildTask()
+	}
+	return service
+}
+
+// Recommend 猜你喜欢：以用户购买过与收藏的物品为种子，沿共购关联扩散；不足部分以近期热销补齐
+func (s *RecommendService) Recommend(ctx *gin.Context, dto request.RecommendDTO) ([]response.RecommendItemVO, error) {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	limit := dto.Limit
+	switch {
+	case limit <= 0:
+		limit = recommendDefaultLimit
+	case limit > recommendMaxLimit:
+		limit = recommendMaxLimit
+	}
+
+	catalog, err := s.loadCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]response.RecommendItemVO, 0, limit)
+	picked := make(map[string]bool)
+	pick := func(key, source string) {
+		if vo, ok := catalog[key]; ok && !picked[key] && len(res) < limit {
+			picked[key] = true
+			vo.Source = source
+			res = append(res, vo)
+		}
+	}
+
+	seeds, err := s.userSeeds(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range recommend.Rank(seeds, s.relatedLoader(seeds), recommendRepeatFactor) {
+		pick(c.Key(), recommendSourcePersonal)
+	}
+	if len(res) < limit {
+		// 新用户或候选不足时以近期热销补齐
+		end := time.Now()
+		hot, err := s.reportRepo.GetSalesTop10(end.Add(-recommendHotWindow), end)
+		if err != nil {
+			return nil, err
+		}
+		byName := make(map[string]string, len(catalog))
+		for key, vo := range catalog {
+			if _, ok := byName[vo.Name]; !ok || vo.Type == recommend.TypeDish {
+				byName[vo.Name] = key
+			}
+		}
+		for _, goods := range hot {
+			pick(byName[goods.Name], recommendSourceHot)
+		}
+	}
+	return res, nil
+}
+
+// Rebuild 离线计算共购关联与用户偏好并写入 Redis
+func (s *RecommendService) Rebuild(ctx context.Context) error {
+	purchases, err := s.repo.ListPurchases(ctx, time.Now().Add(-recommendWindow))
+	if err != nil {
+		return err
+	}
+	result := recommend.Build(purchases, recommendTopN)
+
+	pipe := global.RedisClient.Pipeline()
+	defer pipe.Close()
+	for key, related := range result.Related {
+		data, err := json.Marshal(related)
+		if err != nil {
+			return err
+		}
+		pipe.Set(RecommendRelatedKey+key, data, recommendExpire)
+	}
+	for userId, prefs := range result.Preferences {
+		data, err := json.Marshal(prefs)
+		if err != nil {
+			return err
+		}
+		pipe.Set(RecommendUserKey+strconv.Itoa(userId), data, recommendExpire)
+	}
+	pipe.Set(RecommendUpdatedKey, time.Now().Format(time.DateTime), recommendExpire)
+	if _, err = pipe.Exec(); err != nil {
+		return err
+	}
+	global.Log.Info("Recommendation rebuilt", "purchases", len(purchases), "items", len(result.Related), "users", len(result.Preferences))
+	return nil
+}
+
+func (s *RecommendService) rebuildTask() {
+	if err := s.Rebuild(context.Background()); err != nil {
+		global.Log.Warn("Rebuild recommendation failed", "error", err)
+	}
+}
+
+// userSeeds 用户偏好（离线结果）与收藏合并为推荐种子，收藏按最高偏好权重计
+func (s *RecommendService) userSeeds(ctx context.Context, userId int) ([]recommend.ScoredItem, error) {
+	var seeds []recommend.ScoredItem
+	if data, err := global.RedisClient.Get(RecommendUserKey + strconv.Itoa(userId)).Bytes(); err == nil {
+		if err = json.Unmarshal(data, &seeds); err != nil {
+			global.Log.Warn("Unmarshal user preference failed", "userId", userId, "error", err)
+			seeds = nil
+		}
+	}
+	favorites, err := s.favoriteRepo.List(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	weight := 1.0
+	index := make(map[string]int, len(seeds))
+	for i, seed := range seeds {
+		weight = max(weight, seed.Score)
+		index[seed.Key()] = i
+	}
+	for _, f := range favorites {
+		item := recommend.Item{Type: f.Type, Id: f.ItemId}
+		if i, ok := index[item.Key()]; ok {
+			seeds[i].Score = weight
+			continue
+		}
+		seeds = append(seeds, recommend.ScoredItem{Item: item, Score: weight})
+	}
+	return seeds, nil
+}
+
+// relatedLoader 批量读取种子物品的共购关联
+func (s *RecommendService) relatedLoader(seeds []recommend.ScoredItem) func(key string) []recommend.ScoredItem {
+	related := make(map[string][]recommend.ScoredItem, len(seeds))
+	if len(seeds) > 0 {
+		keys := make([]string, len(seeds))
+		for i, seed := range seeds {
+			keys[i] = RecommendRelatedKey + seed.Key()
+		}
+		values, err := global.RedisClient.MGet(keys...).Result()
+		if err != nil {
+			global.Log.Warn("Load related items failed", "error", err)
+		}
+		for i, v := range values {
+			str, ok := v.(string)
+			if !ok {
+				continue
+			}
+			var items []recommend.ScoredItem
+			if err = json.Unmarshal([]byte(str), &items); err == nil {
+				related[seeds[i].Key()] = items
+			}
+		}
+	}
+	return func(key string) []recommend.ScoredItem {
+		return related[key]
+	}
+}
+
+// loadCatalog 起售中的菜品与套餐，只推荐可购买的物品
+func (s *RecommendService) loadCatalog(ctx context.Context) (map[string]response.RecommendItemVO, error) {
+	dishes, err := s.catalogRepo.ListOnSaleDishes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	setmeals, err := s.catalogRepo.ListOnSaleSetmeals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	catalog := make(map[string]response.RecommendItemVO, len(dishes)+len(setmeals))
+	for _, dish := range dishes {
+		item := recommend.Item{Type: recommend.TypeDish, Id: dish.Id}
+		catalog[item.Key()] = response.RecommendItemVO{
+			Type:        item.Type,
+			Id:          dish.Id,
+			CategoryId:  dish.CategoryId,
+			Name:        dish.Name,
+			Description: dish.Description,
+			Image:       dish.Image,
+			Price:       dish.Price,
+		}
+	}
+	for _, setmeal := range setmeals {
+		item := recommend.Item{Type: recommend.TypeSetmeal, Id: setmeal.Id}
+		catalog[item.Key()] = response.RecommendItemVO{
+			Type:        item.Type,
+			Id:          setmeal.Id,
+			CategoryId:  setmeal.CategoryId,
+			Name:        setmeal.Name,
+			Description: setmeal.Description,
+			Image:       setmeal.Image,
+			Price:       setmeal.Price,
+		}
+	}
+	return catalog, nil
+}
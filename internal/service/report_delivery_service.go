@@ -0,0 +1,462 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"takeout/common/e"
+	"takeout/common/notify"
+	"takeout/common/period"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/internal/model"
+	"takeout/repository"
+	"time"
+)
+
+const (
+	ReportDeliveryLockKey = "report:delivery:lock:"
+	deliveryLockTTL       = 48 * time.Hour
+	deliveryDefaultWait   = 30 * time.Second
+	deliveryDaily         = "daily"
+	deliveryWeekly        = "weekly"
+	deliverySuccess       = "success"
+	deliveryPartial       = "partial"
+	deliveryFailed        = "failed"
+	deliverySkipped       = "skipped"
+)
+
+type IReportDeliveryService interface {
+	ListSchedules(ctx context.Context) ([]model.ReportSchedule, error)
+	GetSchedule(ctx context.Context, id uint64) (*model.ReportSchedule, error)
+	AddSchedule(ctx context.Context, dto request.ReportScheduleDTO) (*model.ReportSchedule, error)
+	EditSchedule(ctx context.Context, dto request.ReportScheduleDTO) error
+	DeleteSchedule(ctx context.Context, id uint64) error
+	SetStatus(ctx context.Context, id uint64, status int) error
+	AddRecipient(ctx context.Context, dto request.ReportRecipientDTO) (*model.ReportRecipient, error)
+	DeleteRecipient(ctx context.Context, id uint64) error
+	// RunNow 立即推送一次，不受多实例去重限制
+	RunNow(ctx context.Context, id uint64) (response.DeliveryResultVO, error)
+	// Preview 生成将要推送的 HTML 摘要，不发送
+	Preview(ctx context.Context, id uint64) (string, error)
+}
+
+type ReportDeliveryService struct {
+	repo      repository.ReportScheduleRepo
+	report    *ReportService
+	notifiers notify.Registry
+	cron      *cron.Cron
+
+	mu      sync.Mutex
+	entries map[uint64]cron.EntryID
+}
+
+// NewReportDeliveryService 启动时按已启用的推送计划注册定时任务，计划变更后即时重新注册
+func NewReportDeliveryService(repo repository.ReportScheduleRepo, reportRepo repository.ReportRepo, notifiers notify.Registry) IReportDeliveryService {
+	s := &ReportDeliveryService{
+		repo:      repo,
+		report:    &ReportService{repo: reportRepo},
+		notifiers: notifiers,
+		cron:      cron.New(cron.WithSeconds()),
+		entries:   make(map[uint64]cron.EntryID),
+	}
+	s.cron.Start()
+	schedules, err := repo.List(context.Background(), true)
+	if err != nil {
+		global.Log.Warn("Load report schedules failed", "error", err)
+		return s
+	}
+	for _, schedule := range schedules {
+		s.register(schedule)
+	}
+	return s
+}
+
+// NewNotifiers 按配置创建各推送渠道，dry_run 时全部渠道写入本地目录
+func NewNotifiers() notify.Registry {
+	cfg := global.Config.Notify
+	dir := cfg.FileDir
+	if dir == "" {
+		dir = "./export/notify"
+	}
+	file := &notify.File{Dir: dir}
+	if cfg.DryRun {
+		return notify.Registry{
+			notify.ChannelEmail:   file,
+			notify.ChannelWebhook: file,
+			notify.ChannelWeCom:   file,
+			notify.ChannelFile:    file,
+		}
+	}
+	client := &http.Client{Timeout: deliveryTimeout()}
+	return notify.Registry{
+		notify.ChannelEmail: &notify.SMTP{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+		},
+		notify.ChannelWebhook: &notify.Webhook{Client: client, Secret: cfg.Webhook.Secret},
+		notify.ChannelWeCom:   &notify.WeCom{Client: client, BaseURL: cfg.WeCom.BaseURL},
+		notify.ChannelFile:    file,
+	}
+}
+
+// ListSchedules 推送计划列表
+func (s *ReportDeliveryService) ListSchedules(ctx context.Context) ([]model.ReportSchedule, error) {
+	return s.repo.List(ctx, false)
+}
+
+// GetSchedule 推送计划详情
+func (s *ReportDeliveryService) GetSchedule(ctx context.Context, id uint64) (*model.ReportSchedule, error) {
+	schedule, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return nil, e.Error_SCHEDULE_NOT_FOUND
+	}
+	return schedule, nil
+}
+
+// AddSchedule 新增推送计划及其接收方
+func (s *ReportDeliveryService) AddSchedule(ctx context.Context, dto request.ReportScheduleDTO) (*model.ReportSchedule, error) {
+	schedule, err := scheduleFromDTO(dto)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range dto.Recipients {
+		recipient, err := recipientFromDTO(item)
+		if err != nil {
+			return nil, err
+		}
+		schedule.Recipients = append(schedule.Recipients, recipient)
+	}
+	if err = s.repo.Insert(ctx, &schedule); err != nil {
+		return nil, err
+	}
+	s.register(schedule)
+	return &schedule, nil
+}
+
+// EditSchedule 修改推送计划并重新注册定时任务，启停状态保持不变，只由 SetStatus 修改
+func (s *ReportDeliveryService) EditSchedule(ctx context.Context, dto request.ReportScheduleDTO) error {
+	stored, err := s.GetSchedule(ctx, dto.Id)
+	if err != nil {
+		return err
+	}
+	schedule, err := scheduleFromDTO(dto)
+	if err != nil {
+		return err
+	}
+	schedule.Id, schedule.Status = dto.Id, stored.Status
+	if err = s.repo.Update(ctx, &schedule); err != nil {
+		return err
+	}
+	s.register(schedule)
+	return nil
+}
+
+// DeleteSchedule 删除推送计划
+func (s *ReportDeliveryService) DeleteSchedule(ctx context.Context, id uint64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.unregister(id)
+	return nil
+}
+
+// SetStatus 启用或停用推送计划
+func (s *ReportDeliveryService) SetStatus(ctx context.Context, id uint64, status int) error {
+	if status != 0 && status != 1 {
+		return scheduleInvalid("status 须为 0 或 1")
+	}
+	schedule, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err = s.repo.SetStatus(ctx, id, status); err != nil {
+		return err
+	}
+	schedule.Status = status
+	s.register(*schedule)
+	return nil
+}
+
+// AddRecipient 为推送计划添加接收方
+func (s *ReportDeliveryService) AddRecipient(ctx context.Context, dto request.ReportRecipientDTO) (*model.ReportRecipient, error) {
+	if _, err := s.GetSchedule(ctx, dto.ScheduleId); err != nil {
+		return nil, err
+	}
+	recipient, err := recipientFromDTO(dto)
+	if err != nil {
+		return nil, err
+	}
+	recipient.ScheduleId = dto.ScheduleId
+	if err = s.repo.InsertRecipient(ctx, &recipient); err != nil {
+		return nil, err
+	}
+	return &recipient, nil
+}
+
+// DeleteRecipient 删除接收方
+func (s *ReportDeliveryService) DeleteRecipient(ctx context.Context, id uint64) error {
+	return s.repo.DeleteRecipient(ctx, id)
+}
+
+// RunNow 立即推送一次
+func (s *ReportDeliveryService) RunNow(ctx context.Context, id uint64) (response.DeliveryResultVO, error) {
+	schedule, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return response.DeliveryResultVO{}, err
+	}
+	r, err := deliveryRange(*schedule, time.Now())
+	if err != nil {
+		return response.DeliveryResultVO{}, err
+	}
+	return s.deliver(ctx, *schedule, r)
+}
+
+// Preview 生成推送计划当前对应区间的 HTML 摘要
+func (s *ReportDeliveryService) Preview(ctx context.Context, id uint64) (string, error) {
+	schedule, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	r, err := deliveryRange(*schedule, time.Now())
+	if err != nil {
+		return "", err
+	}
+	digest, err := s.buildDigest(*schedule, r)
+	if err != nil {
+		return "", err
+	}
+	return digest.html()
+}
+
+// register 按计划重新注册定时任务，停用的计划只移除
+func (s *ReportDeliveryService) register(schedule model.ReportSchedule) {
+	s.unregister(schedule.Id)
+	if schedule.Status != 1 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := schedule.Id
+	entry, err := s.cron.AddFunc(cronSpec(schedule), func() { s.runScheduled(id) })
+	if err != nil {
+		global.Log.Warn("Register report schedule failed", "id", id, "error", err)
+		return
+	}
+	s.entries[id] = entry
+}
+
+func (s *ReportDeliveryService) unregister(id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[id]; ok {
+		s.cron.Remove(entry)
+		delete(s.entries, id)
+	}
+}
+
+// runScheduled 定时触发：重新读取计划，同一区间在多实例间只推送一次
+func (s *ReportDeliveryService) runScheduled(id uint64) {
+	defer func() {
+		if r := recover(); r != nil {
+			global.Log.Error("Report delivery panic", "id", id, "panic", r)
+		}
+	}()
+	ctx := context.Background()
+	schedule, err := s.repo.GetById(ctx, id)
+	if err != nil || schedule == nil || schedule.Status != 1 {
+		if err != nil {
+			global.Log.Warn("Load report schedule failed", "id", id, "error", err)
+		}
+		return
+	}
+	r, err := deliveryRange(*schedule, time.Now())
+	if err != nil {
+		global.Log.Warn("Report schedule invalid", "id", id, "error", err)
+		return
+	}
+	lockKey := ReportDeliveryLockKey + strconv.FormatUint(id, 10) + ":" + r.Begin.Format(time.DateOnly)
+	ok, err := global.RedisClient.SetNX(lockKey, 1, deliveryLockTTL).Result()
+	if err != nil {
+		// Redis 不可用时宁可重复推送也不漏发
+		global.Log.Warn("Acquire report delivery lock failed", "id", id, "error", err)
+	} else if !ok {
+		return
+	}
+	result, err := s.deliver(ctx, *schedule, r)
+	if err != nil {
+		global.Log.Warn("Report delivery failed", "id", id, "error", err)
+		return
+	}
+	global.Log.Info("Report delivered", "id", id, "status", result.Status)
+}
+
+// deliver 生成报表并逐个推送给接收方，记录推送结果
+func (s *ReportDeliveryService) deliver(ctx context.Context, schedule model.ReportSchedule, r period.Range) (response.DeliveryResultVO, error) {
+	result := response.DeliveryResultVO{ScheduleId: schedule.Id, Period: reportPeriod(r), Results: []response.DeliveryRecipientVO{}}
+	if len(schedule.Recipients) == 0 {
+		result.Status = deliverySkipped
+		return result, nil
+	}
+	digest, err := s.buildDigest(schedule, r)
+	if err != nil {
+		s.saveRunResult(schedule.Id, deliveryFailed, err.Error())
+		return result, err
+	}
+	msg, err := digest.message()
+	if err != nil {
+		s.saveRunResult(schedule.Id, deliveryFailed, err.Error())
+		return result, err
+	}
+
+	var failures []string
+	for _, recipient := range schedule.Recipients {
+		sendCtx, cancel := context.WithTimeout(ctx, deliveryTimeout())
+		err := s.notifiers.Send(sendCtx, recipient.Channel, recipient.Target, msg)
+		cancel()
+		item := response.DeliveryRecipientVO{
+			RecipientId: recipient.Id,
+			Channel:     recipient.Channel,
+			Target:      recipient.Target,
+			Success:     err == nil,
+		}
+		if err != nil {
+			item.Error = err.Error()
+			failures = append(failures, fmt.Sprintf("%s %s: %s", recipient.Channel, recipient.Target, err))
+			global.Log.Warn("Report notify failed", "schedule", schedule.Id, "recipient", recipient.Id, "error", err)
+		}
+		result.Results = append(result.Results, item)
+	}
+	switch len(failures) {
+	case 0:
+		result.Status = deliverySuccess
+	case len(schedule.Recipients):
+		result.Status = deliveryFailed
+	default:
+		result.Status = deliveryPartial
+	}
+	s.saveRunResult(schedule.Id, result.Status, strings.Join(failures, "; "))
+	return result, nil
+}
+
+func (s *ReportDeliveryService) saveRunResult(id uint64, status, errMsg string) {
+	if len([]rune(errMsg)) > 500 {
+		errMsg = string([]rune(errMsg)[:500])
+	}
+	if err := s.repo.SaveRunResult(context.Background(), id, time.Now(), status, errMsg); err != nil {
+		global.Log.Warn("Save report delivery result failed", "id", id, "error", err)
+	}
+}
+
+// scheduleFromDTO 校验并填充默认值：每日 08:00、周报周一、服务器时区
+func scheduleFromDTO(dto request.ReportScheduleDTO) (model.ReportSchedule, error) {
+	schedule := model.ReportSchedule{
+		Name:      strings.TrimSpace(dto.Name),
+		Frequency: dto.Frequency,
+		SendTime:  dto.SendTime,
+		Weekday:   dto.Weekday,
+		TimeZone:  dto.TimeZone,
+		Status:    1,
+	}
+	if dto.Status != nil {
+		schedule.Status = *dto.Status
+	}
+	if schedule.Name == "" {
+		return schedule, scheduleInvalid("计划名称不能为空")
+	}
+	if schedule.Frequency == "" {
+		schedule.Frequency = deliveryDaily
+	}
+	if schedule.Frequency != deliveryDaily && schedule.Frequency != deliveryWeekly {
+		return schedule, scheduleInvalid("frequency 须为 daily/weekly")
+	}
+	if schedule.SendTime == "" {
+		schedule.SendTime = "08:00"
+	}
+	if _, err := time.Parse("15:04", schedule.SendTime); err != nil {
+		return schedule, scheduleInvalid("sendTime 须为 HH:MM 格式")
+	}
+	if schedule.Weekday == 0 {
+		schedule.Weekday = 1
+	}
+	if schedule.Weekday < 1 || schedule.Weekday > 7 {
+		return schedule, scheduleInvalid("weekday 须在 1 到 7 之间")
+	}
+	if schedule.TimeZone != "" {
+		if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+			return schedule, scheduleInvalid("未知的时区 %q", schedule.TimeZone)
+		}
+	}
+	if schedule.Status != 0 && schedule.Status != 1 {
+		return schedule, scheduleInvalid("status 须为 0 或 1")
+	}
+	return schedule, nil
+}
+
+func recipientFromDTO(dto request.ReportRecipientDTO) (model.ReportRecipient, error) {
+	recipient := model.ReportRecipient{
+		Name:    strings.TrimSpace(dto.Name),
+		Channel: dto.Channel,
+		Target:  strings.TrimSpace(dto.Target),
+	}
+	if err := notify.ValidateTarget(recipient.Channel, recipient.Target); err != nil {
+		return recipient, scheduleInvalid("接收方 %s", err)
+	}
+	return recipient, nil
+}
+
+// cronSpec 计划时区下的 cron 表达式，周报的星期按 cron 约定周日为 0
+func cronSpec(schedule model.ReportSchedule) string {
+	sendTime, _ := time.Parse("15:04", schedule.SendTime)
+	dow := "*"
+	if schedule.Frequency == deliveryWeekly {
+		dow = strconv.Itoa(schedule.Weekday % 7)
+	}
+	spec := fmt.Sprintf("0 %d %d * * %s", sendTime.Minute(), sendTime.Hour(), dow)
+	if schedule.TimeZone != "" {
+		spec = "CRON_TZ=" + schedule.TimeZone + " " + spec
+	}
+	return spec
+}
+
+// deliveryRange 计划时区下的前一天（按小时明细）或上一个自然周（按天明细）
+func deliveryRange(schedule model.ReportSchedule, now time.Time) (period.Range, error) {
+	loc := time.Local
+	if schedule.TimeZone != "" {
+		l, err := time.LoadLocation(schedule.TimeZone)
+		if err != nil {
+			return period.Range{}, scheduleInvalid("未知的时区 %q", schedule.TimeZone)
+		}
+		loc = l
+	}
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	begin, end, granularity := today.AddDate(0, 0, -1), today.AddDate(0, 0, -1), period.Hour
+	if schedule.Frequency == deliveryWeekly {
+		monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		begin, end, granularity = monday.AddDate(0, 0, -7), monday.AddDate(0, 0, -1), period.Day
+	}
+	return period.Parse(begin.Format(time.DateOnly), end.Format(time.DateOnly), string(granularity), schedule.TimeZone)
+}
+
+func deliveryTimeout() time.Duration {
+	if d, err := time.ParseDuration(global.Config.Notify.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return deliveryDefaultWait
+}
+
+func scheduleInvalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", e.Error_SCHEDULE_INVALID, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"takeout/common/export"
+	"takeout/common/notify"
+	"takeout/common/period"
+	"takeout/internal/api/admin/response"
+	userRequest "takeout/internal/api/user/request"
+	"takeout/internal/model"
+	"time"
+)
+
+// reportDigest 一次推送的报表内容，与工作台营业数据口径一致
+type reportDigest struct {
+	Title    string
+	Period   string
+	Compare  string // 对比区间的称呼：前一日、上一周
+	Range    period.Range
+	Current  response.BusinessDataVO
+	Previous response.BusinessDataVO
+	// AfterSale 售后统计，与营业数据同区间对比
+	AfterSale     response.AfterSaleSummaryVO
+	PrevAfterSale response.AfterSaleSummaryVO
+	Stats         []model.DailyBusinessStats
+	Top           []userRequest.GoodsSalesDTO
+}
+
+type digestMetric struct {
+	Label  string
+	Value  string
+	Change string
+	Up     bool
+}
+
+type digestRow struct {
+	Label           string
+	Turnover        string
+	OrderCount      int
+	ValidOrderCount int
+	NewUsers        int
+}
+
+// buildDigest 查询区间及上一区间的营业数据、明细与销量排行
+func (s *ReportDeliveryService) buildDigest(schedule model.ReportSchedule, r period.Range) (*reportDigest, error) {
+	digest := &reportDigest{Title: "营业日报", Compare: "前一日", Range: r, Period: r.Begin.Format(time.DateOnly)}
+	if schedule.Frequency == deliveryWeekly {
+		digest.Title, digest.Compare = "营业周报", "上一周"
+		digest.Period += "至" + r.End.Format(time.DateOnly)
+	}
+	if r.Location != time.Local {
+		digest.Period += "（" + r.Location.String() + "）"
+	}
+	repo := s.report.repo
+	var err error
+	if digest.Current, err = repo.GetBusinessData(r.Begin.In(time.Local), r.End.In(time.Local)); err != nil {
+		return nil, err
+	}
+	prev := r.Previous()
+	if digest.Previous, err = repo.GetBusinessData(prev.Begin.In(time.Local), prev.End.In(time.Local)); err != nil {
+		return nil, err
+	}
+	if digest.AfterSale, err = repo.GetAfterSaleSummary(r.Begin.In(time.Local), r.End.In(time.Local)); err != nil {
+		return nil, err
+	}
+	if digest.PrevAfterSale, err = repo.GetAfterSaleSummary(prev.Begin.In(time.Local), prev.End.In(time.Local)); err != nil {
+		return nil, err
+	}
+	if digest.Stats, err = s.report.bucketStats(r); err != nil {
+		return nil, err
+	}
+	if digest.Top, err = repo.GetSalesTop10(r.Begin.In(time.Local), r.End.In(time.Local)); err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+func (d *reportDigest) metrics() []digestMetric {
+	metric := func(label, value string, current, previous float64) digestMetric {
+		m := digestMetric{Label: label, Value: value, Change: "-"}
+		if c := compareMetric(current, previous); c.ChangeRate != nil {
+			m.Change = fmt.Sprintf("%+.2f%%", *c.ChangeRate*100)
+			m.Up = *c.ChangeRate > 0
+		}
+		return m
+	}
+	cur, prev := d.Current, d.Previous
+	return []digestMetric{
+		metric("营业额", fmt.Sprintf("%.2f", cur.Turnover), cur.Turnover, prev.Turnover),
+		metric("有效订单数", fmt.Sprint(cur.ValidOrderCount), float64(cur.ValidOrderCount), float64(prev.ValidOrderCount)),
+		metric("订单完成率", fmt.Sprintf("%.2f%%", cur.OrderCompletionRate*100), cur.OrderCompletionRate, prev.OrderCompletionRate),
+		metric("平均客单价", fmt.Sprintf("%.2f", cur.UnitPrice), cur.UnitPrice, prev.UnitPrice),
+		metric("新增用户数", fmt.Sprint(cur.NewUsers), float64(cur.NewUsers), float64(prev.NewUsers)),
+		metric("售后单数", fmt.Sprint(d.AfterSale.Tickets), float64(d.AfterSale.Tickets), float64(d.PrevAfterSale.Tickets)),
+		metric("售后退款", fmt.Sprintf("%.2f", d.AfterSale.RefundAmount), d.AfterSale.RefundAmount, d.PrevAfterSale.RefundAmount),
+	}
+}
+
+func (d *reportDigest) rows() []digestRow {
+	rows := make([]digestRow, len(d.Stats))
+	for i, stat := range d.Stats {
+		rows[i] = digestRow{
+			Label:           d.Range.Label(stat.StatDate),
+			Turnover:        fmt.Sprintf("%.2f", stat.Turnover),
+			OrderCount:      stat.OrderCount,
+			ValidOrderCount: stat.ValidOrderCount,
+			NewUsers:        stat.NewUsers,
+		}
+	}
+	return rows
+}
+
+var digestTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="font-family:sans-serif;color:#333">
+<h2>{{.Title}}</h2>
+<p>统计区间：{{.Period}}</p>
+<table cellpadding="6" cellspacing="0" border="1" style="border-collapse:collapse">
+<tr style="background:#f5f5f5"><th>指标</th><th>数值</th><th>较{{.Compare}}</th></tr>
+{{range .Metrics}}<tr><td>{{.Label}}</td><td align="right">{{.Value}}</td><td align="right" style="color:{{if .Up}}#d4380d{{else}}#389e0d{{end}}">{{.Change}}</td></tr>
+{{end}}</table>
+<h3>明细</h3>
+<table cellpadding="6" cellspacing="0" border="1" style="border-collapse:collapse">
+<tr style="background:#f5f5f5"><th>时间</th><th>营业额</th><th>订单数</th><th>有效订单数</th><th>新增用户数</th></tr>
+{{range .Rows}}<tr><td>{{.Label}}</td><td align="right">{{.Turnover}}</td><td align="right">{{.OrderCount}}</td><td align="right">{{.ValidOrderCount}}</td><td align="right">{{.NewUsers}}</td></tr>
+{{end}}</table>
+{{if .Top}}<h3>销量排行</h3>
+<ol>{{range .Top}}<li>{{.Name}} × {{.Number}}</li>{{end}}</ol>{{end}}
+<p style="color:#999;font-size:12px">完整数据见附件。</p>
+</body></html>
+`))
+
+func (d *reportDigest) html() (string, error) {
+	var buf bytes.Buffer
+	err := digestTemplate.Execute(&buf, map[string]any{
+		"Title":   d.Title,
+		"Period":  d.Period,
+		"Compare": d.Compare,
+		"Metrics": d.metrics(),
+		"Rows":    d.rows(),
+		"Top":     d.Top,
+	})
+	return buf.String(), err
+}
+
+// text markdown 摘要，供企业微信等不支持 HTML 的渠道使用
+func (d *reportDigest) text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** %s\n", d.Title, d.Period)
+	for _, m := range d.metrics() {
+		fmt.Fprintf(&b, "> %s：%s（较%s %s）\n", m.Label, m.Value, d.Compare, m.Change)
+	}
+	for i, goods := range d.Top {
+		if i == 3 {
+			break
+		}
+		fmt.Fprintf(&b, "%d. %s × %d\n", i+1, goods.Name, goods.Number)
+	}
+	return b.String()
+}
+
+// xlsx 与报表导出相同的明细格式
+func (d *reportDigest) xlsx() ([]byte, error) {
+	columns := exportColumns[exportDimTime]
+	report := &export.Report{
+		Title:   d.Title,
+		Period:  d.Period,
+		Summary: businessSummary(d.Current),
+		Columns: columns,
+		Rows:    timeRows(d.Range, d.Stats, columns),
+	}
+	var buf bytes.Buffer
+	renderer := &export.XLSX{}
+	if err := renderer.Render(&buf, report); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *reportDigest) message() (*notify.Message, error) {
+	html, err := d.html()
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.xlsx()
+	if err != nil {
+		return nil, err
+	}
+	name := d.Title + "_" + d.Range.Begin.Format(time.DateOnly)
+	if d.Range.Days() > 1 {
+		name += "_" + d.Range.End.Format(time.DateOnly)
+	}
+	return &notify.Message{
+		Subject: "【" + d.Title + "】" + d.Period,
+		HTML:    html,
+		Text:    d.text(),
+		Attachments: []notify.Attachment{{
+			Name:        name + ".xlsx",
+			ContentType: (&export.XLSX{}).ContentType(),
+			Data:        data,
+		}},
+	}, nil
+}
@@ -0,0 +1,441 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"takeout/common/e"
+	"takeout/common/export"
+	"takeout/common/period"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	userRequest "takeout/internal/api/user/request"
+	"takeout/internal/model"
+	"time"
+)
+
+const (
+	ExportJobKey       = "report:export:job:"
+	exportMaxRunning   = 2 // 同时生成的异步导出任务数
+	exportDefaultDays  = 92
+	exportDefaultKeep  = 24 * time.Hour
+	exportDimTime      = "time"
+	exportDimGoods     = "goods"
+	exportStatusPend   = "pending"
+	exportStatusRun    = "running"
+	exportStatusDone   = "done"
+	exportStatusFailed = "failed"
+)
+
+var exportSlots = make(chan struct{}, exportMaxRunning)
+
+// exportTemplates 可选的 xlsx 模板，Path 相对于模板目录
+var exportTemplates = map[string]export.Template{
+	// 运营数据报表：B2 写统计区间，第 4、5 行为概览，第 8 行起为逐日明细
+	"operation": {
+		Path:      "运营数据报表模板.xlsx",
+		Sheet:     "Sheet1",
+		TitleCell: "B2",
+		Cells: map[string]string{
+			"turnover":            "C4",
+			"orderCompletionRate": "E4",
+			"newUsers":            "G4",
+			"validOrderCount":     "C5",
+			"unitPrice":           "E5",
+		},
+		DetailRow: 8,
+		DetailCol: "B",
+		Columns:   []string{"time", "turnover", "validOrderCount", "orderCompletionRate", "unitPrice", "newUsers"},
+	},
+}
+
+// exportColumns 各维度可导出的明细列，首列为维度本身，始终导出
+var exportColumns = map[string][]export.Column{
+	exportDimTime: {
+		{Key: "time", Title: "时间"},
+		{Key: "turnover", Title: "营业额", Kind: export.KindMoney},
+		{Key: "orderCount", Title: "订单数", Kind: export.KindInt},
+		{Key: "validOrderCount", Title: "有效订单数", Kind: export.KindInt},
+		{Key: "orderCompletionRate", Title: "订单完成率", Kind: export.KindPercent},
+		{Key: "unitPrice", Title: "平均客单价", Kind: export.KindMoney},
+		{Key: "newUsers", Title: "新增用户数", Kind: export.KindInt},
+	},
+	exportDimGoods: {
+		{Key: "name", Title: "商品名称"},
+		{Key: "number", Title: "销量", Kind: export.KindInt},
+		{Key: "amount", Title: "销售额", Kind: export.KindMoney},
+	},
+}
+
+// exportTask 校验通过的导出定义，报表数据在 build 时才查询，异步任务在后台执行
+type exportTask struct {
+	renderer export.Renderer
+	fileName string
+	days     int
+	build    func() (*export.Report, error)
+}
+
+// ExportReport 按导出定义生成报表：短区间直接流式写入响应，长区间或指定 async 时转为异步任务并返回任务信息
+func (s *ReportService) ExportReport(ctx *gin.Context, dto request.ReportExportDTO) (*response.ExportJobVO, error) {
+	task, err := s.prepareExport(dto)
+	if err != nil {
+		return nil, err
+	}
+	if dto.Async || task.days > exportAsyncDays() {
+		return s.submitExport(task)
+	}
+	report, err := task.build()
+	if err != nil {
+		return nil, err
+	}
+	w := &attachmentWriter{ctx: ctx, contentType: task.renderer.ContentType(), fileName: task.fileName}
+	return nil, task.renderer.Render(w, report)
+}
+
+// ExportExcel 按运营数据模板导出最近 30 天（截至昨天）的数据
+func (s *ReportService) ExportExcel(ctx *gin.Context) error {
+	now := time.Now()
+	_, err := s.ExportReport(ctx, request.ReportExportDTO{
+		ReportQueryDTO: request.ReportQueryDTO{
+			Begin: now.AddDate(0, 0, -30).Format(time.DateOnly),
+			End:   now.AddDate(0, 0, -1).Format(time.DateOnly),
+		},
+		Format:   export.FormatXLSX,
+		Template: "operation",
+	})
+	return err
+}
+
+// GetExportJob 查询异步导出任务
+func (s *ReportService) GetExportJob(id string) (response.ExportJobVO, error) {
+	var job response.ExportJobVO
+	data, err := global.RedisClient.Get(ExportJobKey + id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return job, e.Error_EXPORT_JOB_NOT_FOUND
+	}
+	if err != nil {
+		return job, err
+	}
+	if err = json.Unmarshal(data, &job); err != nil {
+		return job, fmt.Errorf("failed to decode export job: %w", err)
+	}
+	return job, nil
+}
+
+// ExportFile 返回已完成任务的文件路径与下载文件名
+func (s *ReportService) ExportFile(id string) (string, string, error) {
+	job, err := s.GetExportJob(id)
+	if err != nil {
+		return "", "", err
+	}
+	if job.Status != exportStatusDone {
+		return "", "", e.Error_EXPORT_NOT_READY
+	}
+	return exportFilePath(job), job.FileName, nil
+}
+
+// prepareExport 校验导出定义：区间、维度、指标、格式与模板
+func (s *ReportService) prepareExport(dto request.ReportExportDTO) (*exportTask, error) {
+	r, err := period.Parse(dto.Begin, dto.End, dto.Granularity, dto.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	dimension := dto.Dimension
+	if dimension == "" {
+		dimension = exportDimTime
+	}
+	all, ok := exportColumns[dimension]
+	if !ok {
+		return nil, reportInvalid("导出维度须为 time/goods")
+	}
+	columns, err := selectColumns(all, dto.Metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	var tpl *export.Template
+	if dto.Template != "" {
+		t, ok := exportTemplates[dto.Template]
+		if !ok {
+			return nil, reportInvalid("未知的导出模板 %q", dto.Template)
+		}
+		if dto.Format != "" && dto.Format != export.FormatXLSX {
+			return nil, reportInvalid("导出模板仅支持 xlsx 格式")
+		}
+		t.Path = filepath.Join(exportTemplateDir(), t.Path)
+		tpl = &t
+	}
+	renderer, err := export.NewRenderer(dto.Format, tpl)
+	if errors.Is(err, export.ErrUnsupportedFormat) {
+		return nil, reportInvalid("导出格式须为 xlsx/csv/pdf")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	title := "运营数据报表"
+	if dimension == exportDimGoods {
+		title = "商品销量报表"
+	}
+	begin, end := r.Begin.Format(time.DateOnly), r.End.Format(time.DateOnly)
+	task := &exportTask{
+		renderer: renderer,
+		fileName: fmt.Sprintf("%s_%s_%s.%s", title, begin, end, renderer.Format()),
+		days:     r.Days(),
+	}
+	report := &export.Report{Title: title, Period: begin + "至" + end, Columns: columns}
+	if r.Location != time.Local {
+		report.Period += "（" + r.Location.String() + "）"
+	}
+	task.build = func() (*export.Report, error) {
+		if dimension == exportDimGoods {
+			report.Rows = s.goodsRows(r, columns)
+			return report, nil
+		}
+		stats, err := s.bucketStats(r)
+		if err != nil {
+			return nil, err
+		}
+		report.Summary = businessSummary(summarize(stats))
+		report.Rows = timeRows(r, stats, columns)
+		return report, nil
+	}
+	return task, nil
+}
+
+// selectColumns 按指标筛选明细列，保持预定义的列顺序
+func selectColumns(all []export.Column, metrics []string) ([]export.Column, error) {
+	selected := make(map[string]bool)
+	for _, item := range metrics {
+		for _, m := range strings.Split(item, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				selected[m] = true
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return all, nil
+	}
+	columns := []export.Column{all[0]}
+	for _, col := range all[1:] {
+		if selected[col.Key] {
+			columns = append(columns, col)
+			delete(selected, col.Key)
+		}
+	}
+	for m := range selected {
+		return nil, reportInvalid("未知的导出指标 %q", m)
+	}
+	return columns, nil
+}
+
+func businessSummary(data response.BusinessDataVO) []export.Field {
+	return []export.Field{
+		{Key: "turnover", Label: "营业额", Kind: export.KindMoney, Value: round2(data.Turnover)},
+		{Key: "validOrderCount", Label: "有效订单数", Kind: export.KindInt, Value: data.ValidOrderCount},
+		{Key: "orderCompletionRate", Label: "订单完成率", Kind: export.KindPercent, Value: data.OrderCompletionRate},
+		{Key: "unitPrice", Label: "平均客单价", Kind: export.KindMoney, Value: round2(data.UnitPrice)},
+		{Key: "newUsers", Label: "新增用户数", Kind: export.KindInt, Value: data.NewUsers},
+	}
+}
+
+func timeRows(r period.Range, stats []model.DailyBusinessStats, columns []export.Column) export.RowFunc {
+	return func(emit func(row []any) error) error {
+		for i, stat := range stats {
+			data := summarize(stats[i : i+1])
+			values := map[string]any{
+				"time":                r.Label(stat.StatDate),
+				"turnover":            round2(stat.Turnover),
+				"orderCount":          stat.OrderCount,
+				"validOrderCount":     stat.ValidOrderCount,
+				"orderCompletionRate": data.OrderCompletionRate,
+				"unitPrice":           round2(data.UnitPrice),
+				"newUsers":            stat.NewUsers,
+			}
+			if err := emit(pick(values, columns)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (s *ReportService) goodsRows(r period.Range, columns []export.Column) export.RowFunc {
+	return func(emit func(row []any) error) error {
+		return s.repo.EachGoodsSales(r.Begin.In(time.Local), r.End.In(time.Local), func(goods userRequest.GoodsSalesDTO) error {
+			return emit(pick(map[string]any{
+				"name":   goods.Name,
+				"number": goods.Number,
+				"amount": round2(goods.Amount),
+			}, columns))
+		})
+	}
+}
+
+func pick(values map[string]any, columns []export.Column) []any {
+	row := make([]any, len(columns))
+	for i, col := range columns {
+		row[i] = values[col.Key]
+	}
+	return row
+}
+
+// submitExport 登记异步导出任务并在后台生成文件
+func (s *ReportService) submitExport(task *exportTask) (*response.ExportJobVO, error) {
+	job := response.ExportJobVO{
+		Id:         uuid.NewString(),
+		Status:     exportStatusPend,
+		Format:     task.renderer.Format(),
+		FileName:   task.fileName,
+		CreateTime: time.Now().Format(time.DateTime),
+	}
+	if err := saveExportJob(job); err != nil {
+		return nil, err
+	}
+	go s.runExport(task, job)
+	return &job, nil
+}
+
+func (s *ReportService) runExport(task *exportTask, job response.ExportJobVO) {
+	exportSlots <- struct{}{}
+	defer func() { <-exportSlots }()
+	defer func() {
+		if r := recover(); r != nil {
+			global.Log.Error("Export job panic", "id", job.Id, "panic", r)
+			job.Status, job.Error = exportStatusFailed, "导出失败"
+			_ = saveExportJob(job)
+		}
+	}()
+
+	job.Status = exportStatusRun
+	if err := saveExportJob(job); err != nil {
+		global.Log.Warn("Save export job failed", "id", job.Id, "error", err)
+	}
+	err := writeExportFile(task, exportFilePath(job))
+	job.FinishTime = time.Now().Format(time.DateTime)
+	if err != nil {
+		global.Log.Warn("Export job failed", "id", job.Id, "error", err)
+		job.Status, job.Error = exportStatusFailed, err.Error()
+	} else {
+		job.Status = exportStatusDone
+		job.DownloadUrl = "/admin/report/export/jobs/" + job.Id + "/download"
+	}
+	if err = saveExportJob(job); err != nil {
+		global.Log.Warn("Save export job failed", "id", job.Id, "error", err)
+	}
+}
+
+// writeExportFile 先写临时文件，完整生成后再改名，避免下载到半截文件
+func writeExportFile(task *exportTask, path string) error {
+	report, err := task.build()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create export dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	if err = task.renderer.Render(f, report); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func saveExportJob(job response.ExportJobVO) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return global.RedisClient.Set(ExportJobKey+job.Id, data, exportRetention()).Err()
+}
+
+func exportFilePath(job response.ExportJobVO) string {
+	return filepath.Join(exportDir(), job.Id+"."+job.Format)
+}
+
+// cleanExportFiles 删除超过保留时长的导出文件
+func cleanExportFiles() {
+	entries, err := os.ReadDir(exportDir())
+	if err != nil {
+		return
+	}
+	deadline := time.Now().Add(-exportRetention())
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || info.ModTime().After(deadline) {
+			continue
+		}
+		if err = os.Remove(filepath.Join(exportDir(), entry.Name())); err != nil {
+			global.Log.Warn("Remove export file failed", "file", entry.Name(), "error", err)
+		}
+	}
+}
+
+func exportDir() string {
+	if dir := global.Config.Export.Dir; dir != "" {
+		return dir
+	}
+	return "./export"
+}
+
+func exportTemplateDir() string {
+	if dir := global.Config.Export.TemplateDir; dir != "" {
+		return dir
+	}
+	return "./template"
+}
+
+func exportAsyncDays() int {
+	if days := global.Config.Export.AsyncDays; days > 0 {
+		return days
+	}
+	return exportDefaultDays
+}
+
+func exportRetention() time.Duration {
+	if d, err := time.ParseDuration(global.Config.Export.Retention); err == nil && d > 0 {
+		return d
+	}
+	return exportDefaultKeep
+}
+
+func reportInvalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", e.Error_REPORT_QUERY_INVALID, fmt.Sprintf(format, args...))
+}
+
+// attachmentWriter 首次写入时才设置下载响应头，渲染在输出前失败时仍可返回 JSON 错误
+type attachmentWriter struct {
+	ctx         *gin.Context
+	contentType string
+	fileName    string
+	started     bool
+}
+
+func (w *attachmentWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.ctx.Header("Content-Type", w.contentType)
+		w.ctx.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(w.fileName))
+		w.ctx.Status(http.StatusOK)
+	}
+	return w.ctx.Writer.Write(b)
+}
@@ -1,63 +1,75 @@
 package service
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/xuri/excelize/v2"
+	"github.com/robfig/cron/v3"
+	"math"
 	"strconv"
 	"strings"
-	"takeout/common/enum"
+	"takeout/common/period"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
 	"takeout/internal/api/admin/response"
+	"takeout/internal/model"
 	"takeout/repository"
 	"time"
 )
 
+// reportRebuildDays 夜间任务重算最近几天的预聚合数据，覆盖跨天完成的订单
+const reportRebuildDays = 3
+
 type IReportService interface {
 	TurnoverStatistics(begin, end string) (response.TurnoverReportVO, error)
 	UserStatistics(begin, end string) (response.UserReportVO, error)
 	ReportOrderStatistics(begin, end string) (response.OrderReportVO, error)
 	Top10Statistics(begin, end string) (response.SalesTop10ReportVO, error)
-	ExportExcel(ctx *gin.Context)
+	ExportExcel(ctx *gin.Context) error
+
+	// v2：支持统计粒度、时区与同比/环比
+	TurnoverStatisticsV2(dto request.ReportQueryDTO) (response.TurnoverReportV2VO, error)
+	UserStatisticsV2(dto request.ReportQueryDTO) (response.UserReportV2VO, error)
+	OrderStatisticsV2(dto request.ReportQueryDTO) (response.OrderReportV2VO, error)
+	Top10StatisticsV2(dto request.ReportQueryDTO) (response.SalesTop10ReportV2VO, error)
+
+	// 导出：同步流式下载或异步生成
+	ExportReport(ctx *gin.Context, dto request.ReportExportDTO) (*response.ExportJobVO, error)
+	GetExportJob(id string) (response.ExportJobVO, error)
+	ExportFile(id string) (path string, fileName string, err error)
 }
 type ReportService struct {
 	repo repository.ReportRepo
 }
 
 func NewReportService(repo repository.ReportRepo) IReportService {
-	return &ReportService{repo: repo}
+	service := &ReportService{repo: repo}
+	// 每天凌晨重算最近几天的每日营业数据
+	timerTask := cron.New(cron.WithSeconds())
+	if _, err := timerTask.AddFunc("0 10 0 * * ?", service.rebuildDailyStats); err != nil {
+		global.Log.Warn("TimerTaskError")
+	}
+	// 每小时清理过期的导出文件
+	if _, err := timerTask.AddFunc("0 0 * * * ?", cleanExportFiles); err != nil {
+		global.Log.Warn("TimerTaskError")
+	}
+	timerTask.Start()
+	return service
 }
 
 // TurnoverStatistics 营业额数据统计
 func (s *ReportService) TurnoverStatistics(begin, end string) (response.TurnoverReportVO, error) {
-	// 创建数据容器
-	dateList := make([]string, 0)
-	turnoverList := make([]string, 0)
-	// 解析时间区间
-	beginTime, _ := time.Parse(time.DateOnly, begin)
-	endTime, _ := time.Parse(time.DateOnly, end)
-	// 从起始时间遍历至终止时间
-	for !beginTime.After(endTime) {
-		// 解析这一天最小时间 00:00:00
-		beginTIME := time.Date(beginTime.Year(),
-			beginTime.Month(), beginTime.Day(), 0, 0, 0, 0, time.Local)
-		// 解析这一天最大时间 23:59:59.999999999
-		endTIME := time.Date(beginTime.Year(),
-			beginTime.Month(), beginTime.Day(), 23, 59, 59, 999999999, time.Local)
-		// 获取每日营业额
-		turnover, err := s.repo.GetDailyTurnover(beginTIME, endTIME)
-		if err != nil {
-			return response.TurnoverReportVO{}, err
-		}
-		// 添加日期
-		dateList = append(dateList, beginTime.String())
-		// 添加营业额
-		turnoverList = append(turnoverList, strconv.FormatFloat(turnover, 'f', 2, 64))
-		// 日期+1
-		beginTime = beginTime.AddDate(0, 0, 1)
+	r, err := period.Parse(begin, end, "", "")
+	if err != nil {
+		return response.TurnoverReportVO{}, err
 	}
-	// 处理数据
-	for i := 0; i < len(dateList); i++ {
-		dateList[i] = strings.Split(dateList[i], " ")[0]
+	stats, err := s.dailyStats(r.Begin, r.End)
+	if err != nil {
+		return response.TurnoverReportVO{}, err
+	}
+	dateList := make([]string, len(stats))
+	turnoverList := make([]string, len(stats))
+	for i, stat := range stats {
+		dateList[i] = stat.StatDate.Format(time.DateOnly)
+		turnoverList[i] = strconv.FormatFloat(stat.Turnover, 'f', 2, 64)
 	}
 	return response.TurnoverReportVO{
 		DateList:     strings.Join(dateList, ","),
@@ -67,42 +79,27 @@ func (s *ReportService) TurnoverStatistics(begin, end string) (response.Turnover
 
 // UserStatistics 用户统计
 func (s *ReportService) UserStatistics(begin, end string) (response.UserReportVO, error) {
-	// 创建数据容器
-	dateList := make([]string, 0)
-	totalUserList := make([]string, 0)
-	newUserList := make([]string, 0)
-	// 解析时间区间
-	beginTime, _ := time.Parse(time.DateOnly, begin)
-	endTime, _ := time.Parse(time.DateOnly, end)
-	// 从起始时间遍历至终止时间
-	for !beginTime.After(endTime) {
-		// 解析这一天最小时间 00:00:00
-		beginTIME := time.Date(beginTime.Year(),
-			beginTime.Month(), beginTime.Day(), 0, 0, 0, 0, time.Local)
-		// 解析这一天最大时间 23:59:59.999999999
-		endTIME := time.Date(beginTime.Year(),
-			beginTime.Month(), beginTime.Day(), 23, 59, 59, 999999999, time.Local)
-		// 获取总用户数
-		totalUser, err := s.repo.GetUserCount(time.Time{}, endTIME)
-		if err != nil {
-			return response.UserReportVO{}, err
-		}
-		// 获取新增用户数
-		newUser, err_ := s.repo.GetUserCount(beginTIME, endTIME)
-		if err_ != nil {
-			return response.UserReportVO{}, err
-		}
-		// 添加日期
-		dateList = append(dateList, beginTime.String())
-		// 添加该日新增用户数 / 总用户数
-		totalUserList = append(totalUserList, strconv.Itoa(totalUser))
-		newUserList = append(newUserList, strconv.Itoa(newUser))
-		// 日期+1
-		beginTime = beginTime.AddDate(0, 0, 1)
+	r, err := period.Parse(begin, end, "", "")
+	if err != nil {
+		return response.UserReportVO{}, err
 	}
-	// 处理数据
-	for i := 0; i < len(dateList); i++ {
-		dateList[i] = strings.Split(dateList[i], " ")[0]
+	stats, err := s.dailyStats(r.Begin, r.End)
+	if err != nil {
+		return response.UserReportVO{}, err
+	}
+	// 起始日之前的用户总数，之后逐日累加新增用户
+	totalUser, err := s.repo.GetUserCount(time.Time{}, r.Begin.Add(-time.Second))
+	if err != nil {
+		return response.UserReportVO{}, err
+	}
+	dateList := make([]string, len(stats))
+	totalUserList := make([]string, len(stats))
+	newUserList := make([]string, len(stats))
+	for i, stat := range stats {
+		totalUser += stat.NewUsers
+		dateList[i] = stat.StatDate.Format(time.DateOnly)
+		totalUserList[i] = strconv.Itoa(totalUser)
+		newUserList[i] = strconv.Itoa(stat.NewUsers)
 	}
 	return response.UserReportVO{
 		DateList:      strings.Join(dateList, ","),
@@ -113,51 +110,27 @@ func (s *ReportService) UserStatistics(begin, end string) (response.UserReportVO
 
 // ReportOrderStatistics 订单统计
 func (s *ReportService) ReportOrderStatistics(begin, end string) (response.OrderReportVO, error) {
-	// 创建数据容器
-	dateList := make([]string, 0)
-	orderCountList := make([]string, 0)
-	validOrderCountList := make([]string, 0)
-	totalCount, validCount := 0, 0
-	// 解析时间区间
-	beginTime, _ := time.Parse(time.DateOnly, begin)
-	endTime, _ := time.Parse(time.DateOnly, end)
-	// 从起始时间遍历至终止时间
-	for !beginTime.After(endTime) {
-		// 解析这一天最小时间 00:00:00
-		beginTIME := time.Date(beginTime.Year(),
-			beginTime.Month(), beginTime.Day(), 0, 0, 0, 0, time.Local)
-		// 解析这一天最大时间 23:59:59.999999999
-		endTIME := time.Date(beginTime.Year(),
-			beginTime.Month(), beginTime.Day(), 23, 59, 59, 999999999, time.Local)
-		// 查询每天的总订单数
-		orderCount, err := s.repo.GetDailyOrderCount(beginTIME, endTIME, 0)
-		if err != nil {
-			return response.OrderReportVO{}, err
-		}
-		totalCount += orderCount
-		// 查询每天的有效订单数
-		var validOrderCount int
-		validOrderCount, err = s.repo.GetDailyOrderCount(beginTIME, endTIME, enum.Completed)
-		if err != nil {
-			return response.OrderReportVO{}, err
-		}
-		validCount += validOrderCount
-		// 添加日期
-		dateList = append(dateList, beginTime.String())
-		// 添加该日总订单数 / 有效订单数
-		orderCountList = append(orderCountList, strconv.Itoa(orderCount))
-		validOrderCountList = append(validOrderCountList, strconv.Itoa(validOrderCount))
-		// 日期+1
-		beginTime = beginTime.AddDate(0, 0, 1)
+	r, err := period.Parse(begin, end, "", "")
+	if err != nil {
+		return response.OrderReportVO{}, err
 	}
-	// 处理数据
-	for i := 0; i < len(dateList); i++ {
-		dateList[i] = strings.Split(dateList[i], " ")[0]
+	stats, err := s.dailyStats(r.Begin, r.End)
+	if err != nil {
+		return response.OrderReportVO{}, err
+	}
+	dateList := make([]string, len(stats))
+	orderCountList := make([]string, len(stats))
+	validOrderCountList := make([]string, len(stats))
+	totalCount, validCount := 0, 0
+	for i, stat := range stats {
+		totalCount += stat.OrderCount
+		validCount += stat.ValidOrderCount
+		dateList[i] = stat.StatDate.Format(time.DateOnly)
+		orderCountList[i] = strconv.Itoa(stat.OrderCount)
+		validOrderCountList[i] = strconv.Itoa(stat.ValidOrderCount)
 	}
 	var orderCompletionRate float64
-	if totalCount == 0 {
-		orderCompletionRate = 0
-	} else {
+	if totalCount != 0 {
 		orderCompletionRate = float64(validCount) / float64(totalCount)
 	}
 	return response.OrderReportVO{
@@ -176,10 +149,12 @@ func (s *ReportService) Top10Statistics(begin, end string) (response.SalesTop10R
 	nameList := make([]string, 0)
 	numberList := make([]string, 0)
 	// 解析时间区间
-	beginTime, _ := time.Parse(time.DateOnly, begin)
-	endTime, _ := time.Parse(time.DateOnly, end)
+	r, err := period.Parse(begin, end, "", "")
+	if err != nil {
+		return response.SalesTop10ReportVO{}, err
+	}
 	// 查询前十商品
-	top10Goods, err := s.repo.GetSalesTop10(beginTime, endTime)
+	top10Goods, err := s.repo.GetSalesTop10(r.Begin, r.End)
 	if err != nil {
 		return response.SalesTop10ReportVO{}, err
 	}
@@ -194,90 +169,397 @@ func (s *ReportService) Top10Statistics(begin, end string) (response.SalesTop10R
 	}, nil
 }
 
-// ExportExcel 导出运营数据Excel报表
-func (s *ReportService) ExportExcel(ctx *gin.Context) {
-	//log.Printf("error happen")
-	// 查询概览运营数据，提供给Excel模板文件
-	begin := time.Now().AddDate(0, 0, -30)
-	beginTime := time.Date(begin.Year(), begin.Month(), begin.Day(),
-		0, 0, 0, 0, time.Local)
-	endTime := time.Date(time.Now().AddDate(0, 0, -1).Year(),
-		time.Now().AddDate(0, 0, -1).Month(),
-		time.Now().AddDate(0, 0, -1).Day(),
-		23, 59, 59, 999999999, time.Local)
-	businessData, _ := s.repo.GetBusinessData(beginTime, endTime)
-	// 基于提供好的模板文件创建一个新的Excel表格对象
-	excel, err := excelize.OpenFile("./template/运营数据报表模板.xlsx")
+// TurnoverStatisticsV2 营业额统计，按粒度分桶并可与上一周期/去年同期对比
+func (s *ReportService) TurnoverStatisticsV2(dto request.ReportQueryDTO) (response.TurnoverReportV2VO, error) {
+	r, compare, err := parseReportQuery(dto)
+	if err != nil {
+		return response.TurnoverReportV2VO{}, err
+	}
+	stats, err := s.bucketStats(r)
+	if err != nil {
+		return response.TurnoverReportV2VO{}, err
+	}
+	res := response.TurnoverReportV2VO{
+		ReportMetaVO: reportMeta(r),
+		Total:        round2(summarize(stats).Turnover),
+		Points:       turnoverPoints(r, stats),
+	}
+	for _, c := range compare {
+		cr := r.Shift(c)
+		cStats, err := s.bucketStats(cr)
+		if err != nil {
+			return response.TurnoverReportV2VO{}, err
+		}
+		total := round2(summarize(cStats).Turnover)
+		res.Compare = append(res.Compare, response.TurnoverCompareVO{
+			ReportCompareVO: compareVO(c, cr, map[string]response.CompareMetricVO{
+				"turnover": compareMetric(res.Total, total),
+			}),
+			Points: turnoverPoints(cr, cStats),
+		})
+	}
+	return res, nil
+}
+
+// UserStatisticsV2 用户统计，按粒度分桶并可与上一周期/去年同期对比
+func (s *ReportService) UserStatisticsV2(dto request.ReportQueryDTO) (response.UserReportV2VO, error) {
+	r, compare, err := parseReportQuery(dto)
+	if err != nil {
+		return response.UserReportV2VO{}, err
+	}
+	points, newUsers, totalUsers, err := s.userPoints(r)
 	if err != nil {
+		return response.UserReportV2VO{}, err
+	}
+	res := response.UserReportV2VO{
+		ReportMetaVO: reportMeta(r),
+		NewUsers:     newUsers,
+		TotalUsers:   totalUsers,
+		Points:       points,
+	}
+	for _, c := range compare {
+		cr := r.Shift(c)
+		cPoints, cNewUsers, cTotalUsers, err := s.userPoints(cr)
+		if err != nil {
+			return response.UserReportV2VO{}, err
+		}
+		res.Compare = append(res.Compare, response.UserCompareVO{
+			ReportCompareVO: compareVO(c, cr, map[string]response.CompareMetricVO{
+				"newUsers":   compareMetric(float64(newUsers), float64(cNewUsers)),
+				"totalUsers": compareMetric(float64(totalUsers), float64(cTotalUsers)),
+			}),
+			Points: cPoints,
+		})
+	}
+	return res, nil
+}
 
-		return
+// OrderStatisticsV2 订单统计，按粒度分桶并可与上一周期/去年同期对比
+func (s *ReportService) OrderStatisticsV2(dto request.ReportQueryDTO) (response.OrderReportV2VO, error) {
+	r, compare, err := parseReportQuery(dto)
+	if err != nil {
+		return response.OrderReportV2VO{}, err
 	}
-	// 关闭文件流
-	defer excel.Close()
-	// 获得第2行，填写时间
-	if err := excel.SetCellValue("Sheet1", "B2",
-		beginTime.Format("2006-01-02")+"至"+
-			endTime.Format("2006-01-02")); err != nil {
-		return
+	stats, err := s.bucketStats(r)
+	if err != nil {
+		return response.OrderReportV2VO{}, err
 	}
-	// 创建样式：水平居中 + 垂直居中
-	styleID, err := excel.NewStyle(&excelize.Style{
-		Alignment: &excelize.Alignment{
-			Horizontal: "center",
-			Vertical:   "center",
-		},
-	})
-	// 应用样式到单元格 B2
-	if err := excel.SetCellStyle("Sheet1", "B2",
-		"B2", styleID); err != nil {
-		return
+	res := response.OrderReportV2VO{ReportMetaVO: reportMeta(r)}
+	res.Points, res.TotalOrderCount, res.ValidOrderCount, res.OrderCompletionRate = orderPoints(r, stats)
+	for _, c := range compare {
+		cr := r.Shift(c)
+		cStats, err := s.bucketStats(cr)
+		if err != nil {
+			return response.OrderReportV2VO{}, err
+		}
+		cPoints, cTotal, cValid, cRate := orderPoints(cr, cStats)
+		res.Compare = append(res.Compare, response.OrderCompareVO{
+			ReportCompareVO: compareVO(c, cr, map[string]response.CompareMetricVO{
+				"totalOrderCount":     compareMetric(float64(res.TotalOrderCount), float64(cTotal)),
+				"validOrderCount":     compareMetric(float64(res.ValidOrderCount), float64(cValid)),
+				"orderCompletionRate": compareMetric(res.OrderCompletionRate, cRate),
+			}),
+			Points: cPoints,
+		})
 	}
-	// 获得第4行，填写数据
-	if err := excel.SetCellValue("Sheet1", "C4",
-		businessData.Turnover); err != nil {
-		return
+	return res, nil
+}
+
+// Top10StatisticsV2 销量排名，按时区解析起止日期
+func (s *ReportService) Top10StatisticsV2(dto request.ReportQueryDTO) (response.SalesTop10ReportV2VO, error) {
+	r, err := period.Parse(dto.Begin, dto.End, "", dto.TimeZone)
+	if err != nil {
+		return response.SalesTop10ReportV2VO{}, err
 	}
-	if err := excel.SetCellValue("Sheet1", "E4",
-		businessData.OrderCompletionRate); err != nil {
-		return
+	goods, err := s.repo.GetSalesTop10(r.Begin.In(time.Local), r.End.In(time.Local))
+	if err != nil {
+		return response.SalesTop10ReportV2VO{}, err
 	}
-	if err := excel.SetCellValue("Sheet1", "G4",
-		businessData.NewUsers); err != nil {
-		return
+	items := make([]response.SalesItemVO, 0, len(goods))
+	for _, g := range goods {
+		items = append(items, response.SalesItemVO{Name: g.Name, Number: g.Number})
 	}
-	// 获得第5行，填写数据
-	if err := excel.SetCellValue("Sheet1", "C5",
-		businessData.ValidOrderCount); err != nil {
-		return
+	meta := reportMeta(r)
+	return response.SalesTop10ReportV2VO{TimeZone: meta.TimeZone, Period: meta.Period, Items: items}, nil
+}
+
+// dailyStats 返回 [begin, end] 内逐日的营业数据，缺失日期补零
+// 已预聚合的历史日期直接读表；未预聚合的日期（含今天）一次性分组聚合，历史部分顺带回填
+func (s *ReportService) dailyStats(begin, end time.Time) ([]model.DailyBusinessStats, error) {
+	today := startOfDay(time.Now())
+	stored := make(map[string]model.DailyBusinessStats)
+	if begin.Before(today) {
+		yesterday := today.AddDate(0, 0, -1)
+		if end.Before(yesterday) {
+			yesterday = end
+		}
+		rows, err := s.repo.ListDailyStats(begin, yesterday)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			stored[row.StatDate.Format(time.DateOnly)] = row
+		}
 	}
-	if err := excel.SetCellValue("Sheet1", "E5",
-		businessData.UnitPrice); err != nil {
-		return
+
+	var missFrom, missTo time.Time
+	for day := begin; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if _, ok := stored[day.Format(time.DateOnly)]; !ok {
+			if missFrom.IsZero() {
+				missFrom = day
+			}
+			missTo = day
+		}
+	}
+	if !missFrom.IsZero() {
+		live, err := s.aggregate(missFrom, missTo)
+		if err != nil {
+			return nil, err
+		}
+		backfill := make([]model.DailyBusinessStats, 0, len(live))
+		for _, stat := range live {
+			key := stat.StatDate.Format(time.DateOnly)
+			if _, ok := stored[key]; ok {
+				continue
+			}
+			stored[key] = stat
+			if stat.StatDate.Before(today) {
+				backfill = append(backfill, stat)
+			}
+		}
+		if err = s.repo.SaveDailyStats(backfill); err != nil {
+			global.Log.Warn("Backfill daily business stats failed", "error", err)
+		}
+	}
+
+	res := make([]model.DailyBusinessStats, 0, len(stored))
+	for day := begin; !day.After(end); day = day.AddDate(0, 0, 1) {
+		stat := stored[day.Format(time.DateOnly)]
+		stat.StatDate = day
+		res = append(res, stat)
+	}
+	return res, nil
+}
+
+// aggregate 对 [begin, end] 按日分组实时聚合，每项指标一次查询
+func (s *ReportService) aggregate(begin, end time.Time) ([]model.DailyBusinessStats, error) {
+	endTime := endOfDay(end)
+	turnover, err := s.repo.GetTurnoverSeries(begin, endTime)
+	if err != nil {
+		return nil, err
+	}
+	total, valid, err := s.repo.GetOrderCountSeries(begin, endTime)
+	if err != nil {
+		return nil, err
+	}
+	newUsers, err := s.repo.GetNewUserSeries(begin, endTime)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.DailyBusinessStats, 0)
+	for day := begin; !day.After(end); day = day.AddDate(0, 0, 1) {
+		key := day.Format(time.DateOnly)
+		res = append(res, model.DailyBusinessStats{
+			StatDate:        day,
+			Turnover:        turnover[key],
+			OrderCount:      total[key],
+			ValidOrderCount: valid[key],
+			NewUsers:        newUsers[key],
+		})
+	}
+	return res, nil
+}
+
+// bucketStats 按区间的粒度与时区返回逐桶营业数据，StatDate 为时间桶起点
+func (s *ReportService) bucketStats(r period.Range) ([]model.DailyBusinessStats, error) {
+	buckets := r.Buckets()
+	res := make([]model.DailyBusinessStats, len(buckets))
+	index := make(map[int64]int, len(buckets))
+	for i, bucket := range buckets {
+		res[i].StatDate = bucket
+		index[bucket.Unix()] = i
+	}
+	add := func(t time.Time, stat model.DailyBusinessStats) {
+		i, ok := index[r.Truncate(t).Unix()]
+		if !ok {
+			return
+		}
+		res[i].Turnover += stat.Turnover
+		res[i].OrderCount += stat.OrderCount
+		res[i].ValidOrderCount += stat.ValidOrderCount
+		res[i].NewUsers += stat.NewUsers
+	}
+
+	if r.Granularity != period.Hour && r.SameZone(time.Local) {
+		// 与服务器时区一致时直接汇总每日预聚合数据
+		daily, err := s.dailyStats(startOfDay(r.Begin.In(time.Local)), startOfDay(r.End.In(time.Local)))
+		if err != nil {
+			return nil, err
+		}
+		for _, stat := range daily {
+			add(stat.StatDate, stat)
+		}
+		return res, nil
+	}
+	// 其余情况按小时聚合后换算到目标时区重新分桶，非整点偏移的时区按所在整点近似
+	hourly, err := s.repo.GetHourlyStats(r.Begin.In(time.Local), r.End.In(time.Local))
+	if err != nil {
+		return nil, err
+	}
+	for _, stat := range hourly {
+		add(stat.Hour, model.DailyBusinessStats{
+			Turnover:        stat.Turnover,
+			OrderCount:      stat.OrderCount,
+			ValidOrderCount: stat.ValidOrderCount,
+			NewUsers:        stat.NewUsers,
+		})
+	}
+	return res, nil
+}
+
+// userPoints 逐桶的新增用户与累计用户
+func (s *ReportService) userPoints(r period.Range) ([]response.UserPointVO, int, int, error) {
+	stats, err := s.bucketStats(r)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	totalUsers, err := s.repo.GetUserCount(time.Time{}, r.Begin.In(time.Local).Add(-time.Second))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	newUsers := 0
+	points := make([]response.UserPointVO, len(stats))
+	for i, stat := range stats {
+		newUsers += stat.NewUsers
+		totalUsers += stat.NewUsers
+		points[i] = response.UserPointVO{
+			Time:       r.Label(stat.StatDate),
+			NewUsers:   stat.NewUsers,
+			TotalUsers: totalUsers,
+		}
+	}
+	return points, newUsers, totalUsers, nil
+}
+
+func turnoverPoints(r period.Range, stats []model.DailyBusinessStats) []response.TurnoverPointVO {
+	points := make([]response.TurnoverPointVO, len(stats))
+	for i, stat := range stats {
+		points[i] = response.TurnoverPointVO{Time: r.Label(stat.StatDate), Turnover: round2(stat.Turnover)}
+	}
+	return points
+}
+
+func orderPoints(r period.Range, stats []model.DailyBusinessStats) ([]response.OrderPointVO, int, int, float64) {
+	points := make([]response.OrderPointVO, len(stats))
+	total, valid := 0, 0
+	for i, stat := range stats {
+		total += stat.OrderCount
+		valid += stat.ValidOrderCount
+		points[i] = response.OrderPointVO{
+			Time:            r.Label(stat.StatDate),
+			OrderCount:      stat.OrderCount,
+			ValidOrderCount: stat.ValidOrderCount,
+		}
+	}
+	var rate float64
+	if total != 0 {
+		rate = float64(valid) / float64(total)
+	}
+	return points, total, valid, rate
+}
+
+// parseReportQuery 校验 v2 报表查询参数
+func parseReportQuery(dto request.ReportQueryDTO) (period.Range, []string, error) {
+	r, err := period.Parse(dto.Begin, dto.End, dto.Granularity, dto.TimeZone)
+	if err != nil {
+		return period.Range{}, nil, err
+	}
+	compare, err := period.ParseCompare(dto.Compare)
+	if err != nil {
+		return period.Range{}, nil, err
+	}
+	return r, compare, nil
+}
+
+func reportMeta(r period.Range) response.ReportMetaVO {
+	return response.ReportMetaVO{
+		Granularity: string(r.Granularity),
+		TimeZone:    r.Location.String(),
+		Period:      reportPeriod(r),
+	}
+}
+
+func reportPeriod(r period.Range) response.ReportPeriodVO {
+	return response.ReportPeriodVO{
+		Begin: r.Begin.Format(time.DateOnly),
+		End:   r.End.Format(time.DateOnly),
+	}
+}
+
+func compareVO(typ string, r period.Range, metrics map[string]response.CompareMetricVO) response.ReportCompareVO {
+	return response.ReportCompareVO{Type: typ, Period: reportPeriod(r), Metrics: metrics}
+}
+
+// compareMetric 计算变化量与变化率，对比值为 0 时变化率无意义
+func compareMetric(current, compare float64) response.CompareMetricVO {
+	res := response.CompareMetricVO{
+		Current: current,
+		Compare: compare,
+		Change:  round2(current - compare),
+	}
+	if compare != 0 {
+		rate := round4((current - compare) / compare)
+		res.ChangeRate = &rate
+	}
+	return res
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// round4 比例保留四位小数
+func round4(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
+
+// rebuildDailyStats 重算最近几天的每日营业数据
+func (s *ReportService) rebuildDailyStats() {
+	today := startOfDay(time.Now())
+	stats, err := s.aggregate(today.AddDate(0, 0, -reportRebuildDays), today.AddDate(0, 0, -1))
+	if err == nil {
+		err = s.repo.SaveDailyStats(stats)
 	}
-	// 填写详细数据
-	for i := 0; i < 30; i++ {
-		// 准备明细数据
-		date := begin.AddDate(0, 0, i)
-		beginTime = time.Date(date.Year(), date.Month(), date.Day(),
-			0, 0, 0, 0, time.Local)
-		endTime = time.Date(date.Year(), date.Month(),
-			date.Day(), 23, 59, 59, 999999999, time.Local)
-		data, _ := s.repo.GetBusinessData(beginTime, endTime)
-		// 从第 8 行开始写入
-		rowNum := 8 + i
-		// 填写数据
-		_ = excel.SetCellValue("Sheet1", fmt.Sprintf("B%d", rowNum), date.Format("2006-01-02"))
-		_ = excel.SetCellValue("Sheet1", fmt.Sprintf("C%d", rowNum), data.Turnover)
-		_ = excel.SetCellValue("Sheet1", fmt.Sprintf("D%d", rowNum), data.ValidOrderCount)
-		_ = excel.SetCellValue("Sheet1", fmt.Sprintf("E%d", rowNum), data.OrderCompletionRate)
-		_ = excel.SetCellValue("Sheet1", fmt.Sprintf("F%d", rowNum), data.UnitPrice)
-		_ = excel.SetCellValue("Sheet1", fmt.Sprintf("G%d", rowNum), data.NewUsers)
-	}
-	// 传输到浏览器让管理员下载
-	// 前端控制了下载名称时，后端只需要设置 Content-Type
-	ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-	// 将 Excel 数据直接写入响应流
-	if err := excel.Write(ctx.Writer); err != nil {
+	if err != nil {
+		global.Log.Warn("Rebuild daily business stats failed", "error", err)
 		return
 	}
+	global.Log.Info("Daily business stats rebuilt", "days", len(stats))
+}
+
+// summarize 汇总区间内的营业数据
+func summarize(stats []model.DailyBusinessStats) response.BusinessDataVO {
+	var (
+		res        response.BusinessDataVO
+		orderCount int
+	)
+	for _, stat := range stats {
+		res.Turnover += stat.Turnover
+		res.ValidOrderCount += stat.ValidOrderCount
+		res.NewUsers += stat.NewUsers
+		orderCount += stat.OrderCount
+	}
+	if orderCount != 0 && res.ValidOrderCount != 0 {
+		res.OrderCompletionRate = float64(res.ValidOrderCount) / float64(orderCount)
+		res.UnitPrice = res.Turnover / float64(res.ValidOrderCount)
+	}
+	return res
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 999999999, time.Local)
 }
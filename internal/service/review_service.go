@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+	"takeout/common"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/global"
+	adminRequest "takeout/internal/api/admin/request"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/internal/model"
+	"takeout/repository"
+)
+
+type IReviewService interface {
+	// user
+	SubmitReview(ctx *gin.Context, dto request.ReviewSubmitDTO) error
+	GetOrderReview(ctx *gin.Context, orderId int) (response.OrderReviewVO, error)
+	PageByTarget(ctx context.Context, dto request.ReviewPageQueryDTO) (*common.PageResult, error)
+	FlagReview(ctx context.Context, id uint64) error
+
+	// admin
+	PageQuery(ctx context.Context, dto adminRequest.ReviewPageQueryDTO) (*common.PageResult, error)
+	Reply(ctx context.Context, dto adminRequest.ReviewReplyDTO) error
+	SetStatus(ctx context.Context, id uint64, status int) error
+}
+
+type ReviewService struct {
+	repo      repository.ReviewRepo
+	orderRepo repository.OrderRepo
+}
+
+func NewReviewService(repo repository.ReviewRepo, orderRepo repository.OrderRepo) IReviewService {
+	return &ReviewService{repo: repo, orderRepo: orderRepo}
+}
+
+// SubmitReview 评价已完成的订单及其中的菜品/套餐
+func (s *ReviewService) SubmitReview(ctx *gin.Context, dto request.ReviewSubmitDTO) error {
+	var err error
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+
+	order, err := s.orderRepo.GetOrderById(strconv.Itoa(dto.OrderId))
+	if err != nil {
+		return err
+	}
+	if order == nil || order.Id == 0 || order.UserId != userId {
+		return e.Error_ORDER_NOT_FOUND
+	}
+	if order.Status != enum.Completed {
+		return e.Error_ORDER_NOT_COMPLETED
+	}
+	exists, err := s.repo.GetByOrderId(ctx, order.Id)
+	if err != nil {
+		return err
+	}
+	if len(exists) > 0 {
+		return e.Error_REVIEW_ALREADY_EXISTS
+	}
+	details, err := s.orderRepo.GetOrderDetailByOrderId(strconv.Itoa(order.Id))
+	if err != nil {
+		return err
+	}
+	detailMap := make(map[int]model.OrderDetail, len(details))
+	for _, detail := range details {
+		detailMap[detail.Id] = detail
+	}
+
+	if !validRating(dto.Rating) {
+		return e.Error_REVIEW_RATING_INVALID
+	}
+	reviews := []model.Review{{
+		OrderId: order.Id,
+		UserId:  userId,
+		Rating:  dto.Rating,
+		Content: dto.Content,
+		Images:  strings.Join(dto.Images, ","),
+		Status:  enum.ENABLE,
+	}}
+	for _, item := range dto.Items {
+		detail, ok := detailMap[item.OrderDetailId]
+		if !ok {
+			return errors.New("订单明细不存在")
+		}
+		if !validRating(item.Rating) {
+			return e.Error_REVIEW_RATING_INVALID
+		}
+		reviews = append(reviews, model.Review{
+			OrderId:       order.Id,
+			OrderDetailId: detail.Id,
+			UserId:        userId,
+			DishId:        detail.DishId,
+			SetmealId:     detail.SetmealId,
+			Rating:        item.Rating,
+			Content:       item.Content,
+			Images:        strings.Join(item.Images, ","),
+			Status:        enum.ENABLE,
+		})
+	}
+
+	transaction := s.repo.Transaction(ctx)
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		} else if err != nil {
+			transaction.Rollback()
+		}
+	}()
+	if err = s.repo.InsertBatch(transaction, reviews); err != nil {
+		return err
+	}
+	if err = transaction.Commit().Error; err != nil {
+		return err
+	}
+	// 评分汇总随菜品/套餐列表一起缓存，评价变化后清理
+	invalidateRatingCache(reviews...)
+	global.Log.Info("Review submitted", "orderId", order.Id, "items", len(dto.Items))
+	return nil
+}
+
+// GetOrderReview 查询当前用户某订单的评价
+func (s *ReviewService) GetOrderReview(ctx *gin.Context, orderId int) (response.OrderReviewVO, error) {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	reviews, err := s.repo.GetByOrderId(ctx, orderId)
+	if err != nil {
+		return response.OrderReviewVO{}, err
+	}
+	details, err := s.orderRepo.GetOrderDetailByOrderId(strconv.Itoa(orderId))
+	if err != nil {
+		return response.OrderReviewVO{}, err
+	}
+	names := make(map[int]string, len(details))
+	for _, detail := range details {
+		names[detail.Id] = detail.Name
+	}
+
+	res := response.OrderReviewVO{Items: make([]response.ReviewVO, 0)}
+	for _, review := range reviews {
+		if review.UserId != userId {
+			return response.OrderReviewVO{}, e.Error_REVIEW_NOT_FOUND
+		}
+		vo := toReviewVO(review)
+		if review.OrderDetailId == 0 {
+			res.Order = vo
+			continue
+		}
+		vo.Name = names[review.OrderDetailId]
+		res.Items = append(res.Items, vo)
+	}
+	return res, nil
+}
+
+// PageByTarget 分页查询菜品/套餐的公开评价
+func (s *ReviewService) PageByTarget(ctx context.Context, dto request.ReviewPageQueryDTO) (*common.PageResult, error) {
+	if dto.DishId == 0 && dto.SetmealId == 0 {
+		return nil, errors.New("dishId 与 setmealId 不能同时为空")
+	}
+	pageResult, err := s.repo.PageByTarget(ctx, dto)
+	if err != nil {
+		return nil, err
+	}
+	reviews := pageResult.Records.([]model.Review)
+	records := make([]response.ReviewVO, 0, len(reviews))
+	for _, review := range reviews {
+		records = append(records, toReviewVO(review))
+	}
+	pageResult.Records = records
+	return pageResult, nil
+}
+
+// FlagReview 用户举报评价，等待商家审核
+func (s *ReviewService) FlagReview(ctx context.Context, id uint64) error {
+	review, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if review == nil {
+		return e.Error_REVIEW_NOT_FOUND
+	}
+	return s.repo.SetFlagged(ctx, id, 1)
+}
+
+// PageQuery 评价管理分页查询
+func (s *ReviewService) PageQuery(ctx context.Context, dto adminRequest.ReviewPageQueryDTO) (*common.PageResult, error) {
+	return s.repo.PageQuery(ctx, dto)
+}
+
+// Reply 商家回复评价
+func (s *ReviewService) Reply(ctx context.Context, dto adminRequest.ReviewReplyDTO) error {
+	review, err := s.repo.GetById(ctx, dto.Id)
+	if err != nil {
+		return err
+	}
+	if review == nil {
+		return e.Error_REVIEW_NOT_FOUND
+	}
+	return s.repo.Reply(ctx, dto.Id, dto.Reply)
+}
+
+// SetStatus 展示或隐藏评价
+func (s *ReviewService) SetStatus(ctx context.Context, id uint64, status int) error {
+	if status != enum.ENABLE && status != enum.DISABLE {
+		return errors.New("invalid review status")
+	}
+	review, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if review == nil {
+		return e.Error_REVIEW_NOT_FOUND
+	}
+	if err = s.repo.SetStatus(ctx, id, status); err != nil {
+		return err
+	}
+	invalidateRatingCache(*review)
+	return nil
+}
+
+func validRating(rating int) bool {
+	return rating >= 1 && rating <= 5
+}
+
+func toReviewVO(review model.Review) response.ReviewVO {
+	images := make([]string, 0)
+	if review.Images != "" {
+		images = strings.Split(review.Images, ",")
+	}
+	return response.ReviewVO{Review: review, Images: images}
+}
+
+// invalidateRatingCache 清理包含被评价菜品/套餐的列表缓存
+func invalidateRatingCache(reviews ...model.Review) {
+	var dishes, setmeals []string
+	for _, review := range reviews {
+		if review.DishId != 0 {
+			dishes = append(dishes, dishTag(uint64(review.DishId)))
+		}
+		if review.SetmealId != 0 {
+			setmeals = append(setmeals, setmealTag(uint64(review.SetmealId)))
+		}
+	}
+	dishCache.InvalidateTags(dishes...)
+	setmealCache.InvalidateTags(setmeals...)
+}
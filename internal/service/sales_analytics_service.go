@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"takeout/common/period"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/repository"
+	"time"
+)
+
+const (
+	slowDefaultLimit = 20
+	salesMaxLimit    = 200
+	basketMaxItems   = 5 // 件数分布中 5 件及以上合并
+)
+
+type ISalesAnalyticsService interface {
+	ItemSales(ctx context.Context, dto request.SalesQueryDTO) ([]response.ItemSalesVO, error)
+	CategorySales(ctx context.Context, dto request.SalesQueryDTO) ([]response.CategorySalesVO, error)
+	FlavorAttach(ctx context.Context, dto request.SalesQueryDTO) (response.FlavorReportVO, error)
+	Heatmap(ctx context.Context, dto request.SalesQueryDTO) (response.HeatmapVO, error)
+	SlowMovers(ctx context.Context, dto request.SalesQueryDTO) ([]response.SlowItemVO, error)
+	Basket(ctx context.Context, dto request.SalesQueryDTO) (response.BasketVO, error)
+}
+
+type SalesAnalyticsService struct {
+	repo       repository.SalesRepo
+	reportRepo repository.ReportRepo
+}
+
+func NewSalesAnalyticsService(repo repository.SalesRepo, reportRepo repository.ReportRepo) ISalesAnalyticsService {
+	return &SalesAnalyticsService{repo: repo, reportRepo: reportRepo}
+}
+
+// ItemSales 菜品/套餐销售排行，可按类型、分类筛选，按销售额或销量排序
+func (s *SalesAnalyticsService) ItemSales(ctx context.Context, dto request.SalesQueryDTO) ([]response.ItemSalesVO, error) {
+	r, err := parseSalesQuery(dto)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ItemSales(ctx, r.Begin.In(time.Local), r.End.In(time.Local))
+	if err != nil {
+		return nil, err
+	}
+	// 占比按筛选后的商品计算
+	res := make([]response.ItemSalesVO, 0, len(items))
+	var total float64
+	for _, item := range items {
+		if (dto.Type != "" && item.Type != dto.Type) || (dto.CategoryId != 0 && item.CategoryId != dto.CategoryId) {
+			continue
+		}
+		total += item.Amount
+		res = append(res, item)
+	}
+	for i := range res {
+		if total > 0 {
+			res[i].AmountShare = round4(res[i].Amount / total)
+		}
+		res[i].Amount = round2(res[i].Amount)
+	}
+	if dto.Sort == "number" {
+		sort.SliceStable(res, func(i, j int) bool { return res[i].Number > res[j].Number })
+	}
+	return limitSales(res, dto.Limit, 0), nil
+}
+
+// CategorySales 分类销售占比
+func (s *SalesAnalyticsService) CategorySales(ctx context.Context, dto request.SalesQueryDTO) ([]response.CategorySalesVO, error) {
+	r, err := parseSalesQuery(dto)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.repo.CategorySales(ctx, r.Begin.In(time.Local), r.End.In(time.Local))
+	if err != nil {
+		return nil, err
+	}
+	var total float64
+	for _, category := range categories {
+		total += category.Amount
+	}
+	for i := range categories {
+		categories[i].Amount = round2(categories[i].Amount)
+		if total > 0 {
+			categories[i].AmountShare = round4(categories[i].Amount / total)
+		}
+	}
+	return categories, nil
+}
+
+// FlavorAttach 口味附加率：点单时选择了口味的份数占销量的比例，及各口味选项的分布
+func (s *SalesAnalyticsService) FlavorAttach(ctx context.Context, dto request.SalesQueryDTO) (response.FlavorReportVO, error) {
+	r, err := parseSalesQuery(dto)
+	if err != nil {
+		return response.FlavorReportVO{}, err
+	}
+	rows, err := s.repo.FlavorSales(ctx, r.Begin.In(time.Local), r.End.In(time.Local))
+	if err != nil {
+		return response.FlavorReportVO{}, err
+	}
+
+	dishes := make(map[int]*response.DishFlavorVO)
+	values := make(map[int]map[string]int)
+	configurable := make(map[int]bool)
+	for _, row := range rows {
+		dish, ok := dishes[row.DishId]
+		if !ok {
+			dish = &response.DishFlavorVO{DishId: row.DishId, Name: row.Name}
+			dishes[row.DishId] = dish
+			values[row.DishId] = make(map[string]int)
+		}
+		configurable[row.DishId] = configurable[row.DishId] || row.Configurable
+		dish.Number += row.Number
+		flavors := splitFlavor(row.DishFlavor)
+		if len(flavors) == 0 {
+			continue
+		}
+		dish.Flavored += row.Number
+		for _, flavor := range flavors {
+			values[row.DishId][flavor] += row.Number
+		}
+	}
+
+	res := response.FlavorReportVO{Dishes: make([]response.DishFlavorVO, 0, len(dishes))}
+	for id, dish := range dishes {
+		// 未配置口味且从未选择过口味的菜品不参与统计
+		if !configurable[id] && dish.Flavored == 0 {
+			continue
+		}
+		dish.AttachRate = ratio(dish.Flavored, dish.Number)
+		dish.Values = make([]response.FlavorValueVO, 0, len(values[id]))
+		for value, number := range values[id] {
+			dish.Values = append(dish.Values, response.FlavorValueVO{Value: value, Number: number, Share: ratio(number, dish.Number)})
+		}
+		sort.Slice(dish.Values, func(i, j int) bool {
+			if dish.Values[i].Number != dish.Values[j].Number {
+				return dish.Values[i].Number > dish.Values[j].Number
+			}
+			return dish.Values[i].Value < dish.Values[j].Value
+		})
+		res.Number += dish.Number
+		res.Flavored += dish.Flavored
+		res.Dishes = append(res.Dishes, *dish)
+	}
+	res.AttachRate = ratio(res.Flavored, res.Number)
+	sort.Slice(res.Dishes, func(i, j int) bool {
+		if res.Dishes[i].Number != res.Dishes[j].Number {
+			return res.Dishes[i].Number > res.Dishes[j].Number
+		}
+		return res.Dishes[i].DishId < res.Dishes[j].DishId
+	})
+	return res, nil
+}
+
+// Heatmap 按目标时区的星期与小时统计下单量
+func (s *SalesAnalyticsService) Heatmap(ctx context.Context, dto request.SalesQueryDTO) (response.HeatmapVO, error) {
+	r, err := parseSalesQuery(dto)
+	if err != nil {
+		return response.HeatmapVO{}, err
+	}
+	hourly, err := s.reportRepo.GetHourlyStats(r.Begin.In(time.Local), r.End.In(time.Local))
+	if err != nil {
+		return response.HeatmapVO{}, err
+	}
+	meta := reportMeta(r)
+	res := response.HeatmapVO{TimeZone: meta.TimeZone, Period: meta.Period, Cells: make([]response.HeatCellVO, 7*24)}
+	for i := range res.Cells {
+		res.Cells[i].Weekday, res.Cells[i].Hour = i/24+1, i%24
+	}
+	for _, stat := range hourly {
+		t := stat.Hour.In(r.Location)
+		cell := &res.Cells[(int(t.Weekday())+6)%7*24+t.Hour()]
+		cell.OrderCount += stat.OrderCount
+		cell.ValidOrders += stat.ValidOrderCount
+		cell.Turnover += stat.Turnover
+	}
+	for i := range res.Cells {
+		res.Cells[i].Turnover = round2(res.Cells[i].Turnover)
+		res.Max = max(res.Max, res.Cells[i].OrderCount)
+	}
+	return res, nil
+}
+
+// SlowMovers 起售中销量最低的商品，销量相同时最久未售出的排在前面
+func (s *SalesAnalyticsService) SlowMovers(ctx context.Context, dto request.SalesQueryDTO) ([]response.SlowItemVO, error) {
+	r, err := parseSalesQuery(dto)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.OnSaleItemSales(ctx, r.Begin.In(time.Local), r.End.In(time.Local))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]response.SlowItemVO, 0, len(items))
+	for _, item := range items {
+		if (dto.Type != "" && item.Type != dto.Type) || (dto.CategoryId != 0 && item.CategoryId != dto.CategoryId) {
+			continue
+		}
+		item.Amount = round2(item.Amount)
+		res = append(res, item)
+	}
+	lastSold := func(item response.SlowItemVO) time.Time {
+		if item.LastOrderTime == nil {
+			return time.Time{}
+		}
+		return time.Time(*item.LastOrderTime)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Number != res[j].Number {
+			return res[i].Number < res[j].Number
+		}
+		return lastSold(res[i]).Before(lastSold(res[j]))
+	})
+	return limitSales(res, dto.Limit, slowDefaultLimit), nil
+}
+
+// Basket 平均订单构成：客单价、件数、商品种数、套餐占比、件数分布与分类构成
+func (s *SalesAnalyticsService) Basket(ctx context.Context, dto request.SalesQueryDTO) (response.BasketVO, error) {
+	r, err := parseSalesQuery(dto)
+	if err != nil {
+		return response.BasketVO{}, err
+	}
+	begin, end := r.Begin.In(time.Local), r.End.In(time.Local)
+	summary, err := s.repo.BasketSummary(ctx, begin, end)
+	if err != nil {
+		return response.BasketVO{}, err
+	}
+	sizes, err := s.repo.BasketSizes(ctx, begin, end, basketMaxItems)
+	if err != nil {
+		return response.BasketVO{}, err
+	}
+	categories, err := s.repo.CategorySales(ctx, begin, end)
+	if err != nil {
+		return response.BasketVO{}, err
+	}
+
+	res := response.BasketVO{
+		OrderCount: summary.OrderCount,
+		Sizes:      make([]response.BasketSizeVO, 0, basketMaxItems),
+		Categories: make([]response.BasketCategoryVO, 0, len(categories)),
+	}
+	if summary.OrderCount == 0 {
+		return res, nil
+	}
+	orders := float64(summary.OrderCount)
+	res.AvgAmount = round2(summary.Amount / orders)
+	res.AvgItems = round2(float64(summary.Items) / orders)
+	res.AvgLines = round2(float64(summary.Lines) / orders)
+	res.SetmealShare = ratio(summary.SetmealOrders, summary.OrderCount)
+	for items := 1; items <= basketMaxItems; items++ {
+		res.Sizes = append(res.Sizes, response.BasketSizeVO{
+			Items:      items,
+			OrderCount: sizes[items],
+			Share:      ratio(sizes[items], summary.OrderCount),
+		})
+	}
+	for _, category := range categories {
+		res.Categories = append(res.Categories, response.BasketCategoryVO{
+			CategoryId:   category.CategoryId,
+			CategoryName: category.CategoryName,
+			AvgNumber:    round2(float64(category.Number) / orders),
+			AvgAmount:    round2(category.Amount / orders),
+			Penetration:  ratio(category.OrderCount, summary.OrderCount),
+		})
+	}
+	return res, nil
+}
+
+// parseSalesQuery 销售分析按自然日解析区间，不分桶
+func parseSalesQuery(dto request.SalesQueryDTO) (period.Range, error) {
+	if dto.Type != "" && dto.Type != "dish" && dto.Type != "setmeal" {
+		return period.Range{}, reportInvalid("type 须为 dish/setmeal")
+	}
+	if dto.Sort != "" && dto.Sort != "amount" && dto.Sort != "number" {
+		return period.Range{}, reportInvalid("sort 须为 amount/number")
+	}
+	return period.Parse(dto.Begin, dto.End, string(period.Day), dto.TimeZone)
+}
+
+// limitSales 截取前 limit 条，limit 未指定时使用默认值，默认值为 0 表示不限制
+func limitSales[T any](items []T, limit, defaultLimit int) []T {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	limit = min(limit, salesMaxLimit)
+	if limit > 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
+
+// splitFlavor 拆分下单时选择的口味，前端以逗号拼接
+func splitFlavor(flavor string) []string {
+	res := make([]string, 0)
+	for _, f := range strings.FieldsFunc(flavor, func(r rune) bool { return r == ',' || r == '，' }) {
+		if f = strings.TrimSpace(f); f != "" {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+func ratio(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return round4(float64(part) / float64(total))
+}
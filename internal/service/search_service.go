@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"github.com/robfig/cron/v3"
+	"sync"
+	"takeout/common/search"
+	"takeout/global"
+	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
+	"takeout/repository"
+)
+
+const (
+	searchDefaultLimit = 20
+	searchMaxLimit     = 50
+	suggestLimit       = 8
+)
+
+type ISearchService interface {
+	Search(ctx context.Context, dto request.SearchDTO) ([]response.SearchItemVO, error)
+	Suggest(ctx context.Context, keyword string) ([]string, error)
+	Rebuild(ctx context.Context) error
+}
+
+type SearchService struct {
+	repo  repository.SearchRepo
+	index search.Index
+	once  sync.Once
+}
+
+func NewSearchService(repo repository.SearchRepo, index search.Index) ISearchService {
+	service := &SearchService{repo: repo, index: index}
+	// 菜单与销量变化不频繁，定时全量重建索引
+	timerTask := cron.New(cron.WithSeconds())
+	if _, err := timerTask.AddFunc("0 */5 * * * ?", func() {
+		if err := service.Rebuild(context.Background()); err != nil {
+			global.Log.Warn("Rebuild search index failed", "error", err)
+		}
+	}); err != nil {
+		global.Log.Warn("TimerTaskError")
+	}
+	timerTask.Start()
+	return service
+}
+
+// Search 搜索菜品与套餐
+func (s *SearchService) Search(ctx context.Context, dto request.SearchDTO) ([]response.SearchItemVO, error) {
+	s.ensureIndex(ctx)
+	limit := dto.Limit
+	switch {
+	case limit <= 0:
+		limit = searchDefaultLimit
+	case limit > searchMaxLimit:
+		limit = searchMaxLimit
+	}
+	hits, err := s.index.Search(ctx, dto.Keyword, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]response.SearchItemVO, 0, len(hits))
+	for _, hit := range hits {
+		items = append(items, response.SearchItemVO{
+			Type:        hit.Type,
+			Id:          hit.RefId,
+			CategoryId:  hit.CategoryId,
+			Name:        hit.Name,
+			Description: hit.Description,
+			Image:       hit.Image,
+			Price:       hit.Price,
+			Sales:       hit.Sales,
+		})
+	}
+	return items, nil
+}
+
+// Suggest 搜索建议
+func (s *SearchService) Suggest(ctx context.Context, keyword string) ([]string, error) {
+	s.ensureIndex(ctx)
+	return s.index.Suggest(ctx, keyword, suggestLimit)
+}
+
+// Rebuild 从数据库加载起售中的菜品/套餐及销量并重建索引
+func (s *SearchService) Rebuild(ctx context.Context) error {
+	dishes, err := s.repo.ListOnSaleDishes(ctx)
+	if err != nil {
+		return err
+	}
+	setmeals, err := s.repo.ListOnSaleSetmeals(ctx)
+	if err != nil {
+		return err
+	}
+	dishSales, setmealSales, err := s.repo.GetSalesVolume(ctx)
+	if err != nil {
+		return err
+	}
+
+	docs := make([]search.Document, 0, len(dishes)+len(setmeals))
+	for _, dish := range dishes {
+		docs = append(docs, search.Document{
+			Type:        search.TypeDish,
+			RefId:       dish.Id,
+			CategoryId:  dish.CategoryId,
+			Name:        dish.Name,
+			Description: dish.Description,
+			Image:       dish.Image,
+			Price:       dish.Price,
+			Sales:       dishSales[dish.Id],
+		})
+	}
+	for _, setmeal := range setmeals {
+		docs = append(docs, search.Document{
+			Type:        search.TypeSetmeal,
+			RefId:       setmeal.Id,
+			CategoryId:  setmeal.CategoryId,
+			Name:        setmeal.Name,
+			Description: setmeal.Description,
+			Image:       setmeal.Image,
+			Price:       setmeal.Price,
+			Sales:       setmealSales[setmeal.Id],
+		})
+	}
+	if err = s.index.Rebuild(ctx, docs); err != nil {
+		return err
+	}
+	global.Log.Info("Search index rebuilt", "documents", len(docs))
+	return nil
+}
+
+// ensureIndex 首次访问时同步构建索引
+func (s *SearchService) ensureIndex(ctx context.Context) {
+	s.once.Do(func() {
+		if err := s.Rebuild(ctx); err != nil {
+			global.Log.Warn("Build search index failed", "error", err)
+		}
+	})
+}
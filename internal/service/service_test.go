@@ -0,0 +1,76 @@
+package service
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"takeout/common/enum"
+	"takeout/config"
+	"takeout/global"
+	"takeout/internal/model"
+	"takeout/repository"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+)
+
+type nopLog struct{}
+
+func (nopLog) Debug(...interface{}) {}
+func (nopLog) Info(...interface{})  {}
+func (nopLog) Warn(...interface{})  {}
+func (nopLog) Error(...interface{}) {}
+func (nopLog) Fatal(...interface{}) {}
+
+// 测试使用默认配置
+func init() {
+	global.Log = nopLog{}
+	global.Config = &config.AllConfig{}
+}
+
+// fakeOrderRepo 只实现按id查询订单及明细，其余方法未实现
+type fakeOrderRepo struct {
+	repository.OrderRepo
+	orders  map[int]*model.Order
+	details []model.OrderDetail
+}
+
+func (r fakeOrderRepo) GetOrderById(orderId string) (*model.Order, error) {
+	id, _ := strconv.Atoi(orderId)
+	if order, ok := r.orders[id]; ok {
+		o := *order
+		return &o, nil
+	}
+	return &model.Order{}, nil
+}
+
+func (r fakeOrderRepo) GetOrderDetailByOrderId(orderId string) ([]model.OrderDetail, error) {
+	id, _ := strconv.Atoi(orderId)
+	var details []model.OrderDetail
+	for _, detail := range r.details {
+		if detail.OrderId == id {
+			details = append(details, detail)
+		}
+	}
+	return details, nil
+}
+
+// userCtx 已登录用户的请求上下文
+func userCtx(userId int) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Set(enum.CurrentId, uint64(userId))
+	return ctx
+}
+
+// useMiniredis 取餐码等数据保存在 Redis，测试期间替换 global.RedisClient
+func useMiniredis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	old := global.RedisClient
+	global.RedisClient = client
+	t.Cleanup(func() {
+		global.RedisClient = old
+		_ = client.Close()
+	})
+}
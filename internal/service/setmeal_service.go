@@ -2,22 +2,24 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"strconv"
 	"strings"
 	"takeout/common"
-	"takeout/common/utils"
-	"takeout/global"
+	"takeout/common/cache"
 	"takeout/internal/api/admin/request"
 	"takeout/internal/api/admin/response"
 	userResponse "takeout/internal/api/user/response"
 	"takeout/internal/model"
 	"takeout/repository"
+	"time"
 )
 
 // SetmealCacheKey redis key 套餐缓存key
 const SetmealCacheKey = "setmealCache::"
 
+// setmealCache 按分类缓存套餐列表，列表中每个套餐打上 setmeal:{id} 标签
+var setmealCache = cache.New(SetmealCacheKey, cache.WithTTL(30*time.Minute), cache.WithLocal(512, 5*time.Minute))
+
 type ISetMealService interface {
 	SaveWithDish(ctx context.Context, dto request.SetMealDTO) error
 	PageQuery(ctx context.Context, dto request.SetMealPageQueryDTO) (*common.PageResult, error)
@@ -26,12 +28,13 @@ type ISetMealService interface {
 	Update(ctx context.Context, dto request.SetMealDTO) error
 	DeleteBatch(ctx context.Context, ids string) error
 	GetDishBySetmealId(ctx context.Context, setmealId uint64) ([]userResponse.DishItemVO, error)
-	List(ctx context.Context, categoryId string) ([]model.SetMeal, error)
+	List(ctx context.Context, categoryId string) ([]userResponse.SetmealVO, error)
 }
 
 type SetMealServiceImpl struct {
 	repo            repository.SetMealRepo
 	setMealDishRepo repository.SetMealDishRepo
+	reviewRepo      repository.ReviewRepo
 }
 
 func (s SetMealServiceImpl) Update(ctx context.Context, dto request.SetMealDTO) error {
@@ -78,7 +81,9 @@ func (s SetMealServiceImpl) Update(ctx context.Context, dto request.SetMealDTO)
 	if err = transaction.Commit().Error; err != nil {
 		return err
 	}
-	utils.CleanCache(SetmealCacheKey + "*") // 清除缓存
+	// 清除原分类（包含该套餐的列表）与新分类的缓存
+	setmealCache.InvalidateTags(setmealTag(dto.Id))
+	setmealCache.Invalidate(categoryKey(dto.CategoryId))
 
 	return nil
 }
@@ -113,10 +118,9 @@ func (s SetMealServiceImpl) DeleteBatch(ctx context.Context, ids string) error {
 		if err = transaction.Commit().Error; err != nil {
 			return err
 		}
+		setmealCache.InvalidateTags(setmealTag(deleteId)) // 清除缓存
 	}
 
-	utils.CleanCache(SetmealCacheKey + "*") // 清除缓存
-
 	return nil
 }
 
@@ -159,7 +163,7 @@ func (s SetMealServiceImpl) SaveWithDish(ctx context.Context, dto request.SetMea
 		return err
 	}
 
-	utils.CleanCache(SetmealCacheKey + "*") // 清除缓存
+	setmealCache.Invalidate(categoryKey(dto.CategoryId)) // 清除所属分类的缓存
 
 	return nil
 }
@@ -169,8 +173,11 @@ func (s SetMealServiceImpl) PageQuery(ctx context.Context, dto request.SetMealPa
 }
 
 func (s SetMealServiceImpl) OnOrClose(ctx context.Context, id uint64, status int) error {
-	utils.CleanCache(SetmealCacheKey + "*") // 清除缓存
-	return s.repo.SetStatus(ctx, id, status)
+	if err := s.repo.SetStatus(ctx, id, status); err != nil {
+		return err
+	}
+	setmealCache.InvalidateTags(setmealTag(id)) // 清除缓存
+	return nil
 }
 
 func (s SetMealServiceImpl) GetByIdWithDish(ctx context.Context, mealId uint64) (response.SetMealWithDishByIdVo, error) {
@@ -201,6 +208,10 @@ func (s SetMealServiceImpl) GetByIdWithDish(ctx context.Context, mealId uint64)
 	if err = transaction.Commit().Error; err != nil {
 		return res, err
 	}
+	ratings, err := s.reviewRepo.GetSetmealRatings(ctx, []uint64{mealId})
+	if err != nil {
+		return res, err
+	}
 	res = response.SetMealWithDishByIdVo{
 		Id:            setmeal.Id,
 		CategoryId:    setmeal.CategoryId,
@@ -212,6 +223,8 @@ func (s SetMealServiceImpl) GetByIdWithDish(ctx context.Context, mealId uint64)
 		UpdateTime:    setmeal.UpdateTime,
 		CategoryName:  setmeal.Name,
 		SetmealDishes: dishList,
+		AvgRating:     ratings[mealId].AvgRating,
+		RatingCount:   ratings[mealId].RatingCount,
 	}
 
 	return res, nil
@@ -223,41 +236,55 @@ func (s SetMealServiceImpl) GetDishBySetmealId(ctx context.Context, setmealId ui
 }
 
 // 根据分类id查询套餐
-func (s SetMealServiceImpl) List(ctx context.Context, categoryId string) ([]model.SetMeal, error) {
-	var (
-		meals     []model.SetMeal
-		err       error
-		mealsJSON []byte
-	)
-	// 优先命中Redis缓存
-	cacheData, err := global.RedisClient.Get(SetmealCacheKey + categoryId).Result()
-	if err == nil {
-		global.Log.Info("查询Redis套餐缓存数据: " + SetmealCacheKey + categoryId)
-		if err = json.Unmarshal([]byte(cacheData), &meals); err == nil {
-			return meals, nil
-		}
-	} else {
-		global.Log.Info("查询Redis套餐缓存数据失败")
-	}
+func (s SetMealServiceImpl) List(ctx context.Context, categoryId string) ([]userResponse.SetmealVO, error) {
+	return cache.Fetch(ctx, setmealCache, categoryId, func(ctx context.Context) ([]userResponse.SetmealVO, error) {
+		return s.list(ctx, categoryId)
+	}, setmealTags)
+}
 
+// list 从数据库查询分类下的套餐及评分汇总
+func (s SetMealServiceImpl) list(ctx context.Context, categoryId string) ([]userResponse.SetmealVO, error) {
 	id, _ := strconv.ParseUint(categoryId, 10, 64)
-	meals, err = s.repo.GetSetmealByCategoryId(ctx, id)
-
-	// 设置redis缓存
-	if err == nil {
-		if mealsJSON, err = json.Marshal(meals); err == nil {
-			// 设置缓存，过期时间为0表示不会过期
-			if err = global.RedisClient.Set(SetmealCacheKey+categoryId, mealsJSON, 0).Err(); err != nil {
-				global.Log.Warn("redis 缓存设置失败")
-			}
+	setmeals, err := s.repo.GetSetmealByCategoryId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// 查询评分汇总
+	setmealIds := make([]uint64, len(setmeals))
+	for i := range setmeals {
+		setmealIds[i] = setmeals[i].Id
+	}
+	ratings, err := s.reviewRepo.GetSetmealRatings(ctx, setmealIds)
+	if err != nil {
+		return nil, err
+	}
+	meals := make([]userResponse.SetmealVO, len(setmeals))
+	for i := range setmeals {
+		meals[i] = userResponse.SetmealVO{
+			SetMeal:     setmeals[i],
+			AvgRating:   ratings[setmeals[i].Id].AvgRating,
+			RatingCount: ratings[setmeals[i].Id].RatingCount,
 		}
 	}
-	return meals, err
+	return meals, nil
 }
 
-func NewSetMealService(repo repository.SetMealRepo, setMealDishRepo repository.SetMealDishRepo) ISetMealService {
+func NewSetMealService(repo repository.SetMealRepo, setMealDishRepo repository.SetMealDishRepo, reviewRepo repository.ReviewRepo) ISetMealService {
 	return &SetMealServiceImpl{
 		repo:            repo,
 		setMealDishRepo: setMealDishRepo,
+		reviewRepo:      reviewRepo,
+	}
+}
+
+func setmealTag(setmealId uint64) string {
+	return "setmeal:" + strconv.FormatUint(setmealId, 10)
+}
+
+func setmealTags(setmeals []userResponse.SetmealVO) []string {
+	tags := make([]string, len(setmeals))
+	for i := range setmeals {
+		tags[i] = setmealTag(setmeals[i].Id)
 	}
+	return tags
 }
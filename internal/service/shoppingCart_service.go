@@ -1,13 +1,20 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/robfig/cron/v3"
 	"github.com/ulule/deepcopier"
+	"takeout/common/e"
 	"takeout/common/enum"
+	"takeout/global"
 	"takeout/internal/api/user/request"
+	"takeout/internal/api/user/response"
 	"takeout/internal/model"
 	"takeout/repository"
+	"time"
 )
 
 type IShoppingCartService interface {
@@ -15,6 +22,8 @@ type IShoppingCartService interface {
 	QueryShoppingCart(ctx *gin.Context) ([]model.ShoppingCart, error)
 	CleanShoppingCart(ctx *gin.Context) error //清空
 	SubShoppingCart(ctx *gin.Context, ShoppingCartDTO request.ShoppingCartDTO) error
+	// ValidateShoppingCart 校验购物车并按当前价格更新
+	ValidateShoppingCart(ctx *gin.Context, dto request.CartValidateDTO) (*response.CartValidateVO, error)
 }
 
 type ShoppingCartService struct {
@@ -36,6 +45,20 @@ func (s ShoppingCartService) AddShoppingCart(ctx *gin.Context, dto request.Shopp
 		return errors.New("用户不存在")
 	}
 	shoppingCart.UserId = int(userId)
+	// 限购按同一菜品或套餐的各口味合计
+	lines, err := s.repo.List(ctx, shoppingCart.UserId)
+	if err != nil {
+		return err
+	}
+	number, key := 0, cartItemKey(shoppingCart)
+	for _, line := range lines {
+		if cartItemKey(line) == key {
+			number += line.Number
+		}
+	}
+	if limit := cartLimit(key); number >= limit {
+		return fmt.Errorf("%w: 最多 %d 份", e.Error_CART_QUANTITY_EXCEEDED, limit)
+	}
 	if err = s.repo.Add(ctx, shoppingCart); err != nil {
 		return err
 	}
@@ -66,6 +89,49 @@ func (s ShoppingCartService) SubShoppingCart(ctx *gin.Context, ShoppingCartDTO r
 	return s.repo.Subtract(ctx, ShoppingCartDTO, int(userId))
 }
 
+const (
+	cartDefaultFlushInterval = time.Minute
+	cartDefaultFlushBatch    = 500
+)
+
 func NewShoppingCartService(repo repository.ShoppingCartRepo) IShoppingCartService {
+	// 购物车存放在缓存中时，首次启动导入数据库中已有的购物车，并定时将有变更的购物车写回数据库
+	if syncer, ok := repo.(repository.ShoppingCartSyncer); ok {
+		if n, err := syncer.Migrate(context.Background()); err != nil {
+			global.Log.Warn("Migrate shopping cart failed", "error", err)
+		} else if n > 0 {
+			global.Log.Info("Migrated shopping carts", "users", n)
+		}
+		timerTask := cron.New(cron.WithSeconds())
+		if _, err := timerTask.AddFunc("@every "+cartFlushInterval().String(), func() { flushShoppingCart(syncer) }); err != nil {
+			global.Log.Warn("TimerTaskError")
+		}
+		timerTask.Start()
+	}
 	return &ShoppingCartService{repo: repo}
 }
+
+// flushShoppingCart 分批写回，直到没有待写回的购物车
+func flushShoppingCart(syncer repository.ShoppingCartSyncer) {
+	batch := global.Config.Cart.FlushBatch
+	if batch <= 0 {
+		batch = cartDefaultFlushBatch
+	}
+	for {
+		n, err := syncer.Flush(context.Background(), batch)
+		if err != nil {
+			global.Log.Warn("Flush shopping cart failed", "error", err)
+			return
+		}
+		if n < batch {
+			return
+		}
+	}
+}
+
+func cartFlushInterval() time.Duration {
+	if d, err := time.ParseDuration(global.Config.Cart.FlushInterval); err == nil && d > 0 {
+		return d
+	}
+	return cartDefaultFlushInterval
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/common/qrcode"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	userRequest "takeout/internal/api/user/request"
+	userResponse "takeout/internal/api/user/response"
+	"takeout/internal/model"
+	"takeout/repository"
+)
+
+const (
+	tableDefaultSeats = 4
+	tableQRScale      = 8
+	tableQRMaxScale   = 32
+)
+
+type ITableService interface {
+	ListTables(ctx context.Context) ([]model.DiningTable, error)
+	AddTable(ctx context.Context, dto request.TableDTO) (*model.DiningTable, error)
+	EditTable(ctx context.Context, dto request.TableDTO) error
+	DeleteTable(ctx context.Context, id uint64) error
+	// RotateCode 重新生成二维码校验码，旧二维码随即失效
+	RotateCode(ctx context.Context, id uint64) (*model.DiningTable, error)
+	// QRCode 桌台二维码 PNG，scale 为每个模块的像素数
+	QRCode(ctx context.Context, id uint64, scale int) ([]byte, error)
+
+	// Scan 用户扫码，校验门店与校验码后返回桌台信息
+	Scan(ctx context.Context, dto userRequest.TableScanDTO) (*userResponse.TableScanVO, error)
+}
+
+type TableService struct {
+	repo repository.TableRepo
+}
+
+func NewTableService(repo repository.TableRepo) ITableService {
+	return &TableService{repo: repo}
+}
+
+// ListTables 桌台列表
+func (s *TableService) ListTables(ctx context.Context) ([]model.DiningTable, error) {
+	return s.repo.ListTables(ctx)
+}
+
+// AddTable 新增桌台并生成二维码校验码
+func (s *TableService) AddTable(ctx context.Context, dto request.TableDTO) (*model.DiningTable, error) {
+	code, err := newTableCode()
+	if err != nil {
+		return nil, err
+	}
+	table := &model.DiningTable{Code: code, Status: enum.ENABLE}
+	applyTableDTO(table, dto)
+	if err = s.repo.InsertTable(ctx, table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// EditTable 修改桌台，未传状态时保持原值
+func (s *TableService) EditTable(ctx context.Context, dto request.TableDTO) error {
+	table, err := s.getTable(ctx, dto.Id)
+	if err != nil {
+		return err
+	}
+	applyTableDTO(table, dto)
+	return s.repo.UpdateTable(ctx, table)
+}
+
+// DeleteTable 删除桌台
+func (s *TableService) DeleteTable(ctx context.Context, id uint64) error {
+	if _, err := s.getTable(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteTable(ctx, id)
+}
+
+// RotateCode 重新生成校验码
+func (s *TableService) RotateCode(ctx context.Context, id uint64) (*model.DiningTable, error) {
+	table, err := s.getTable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if table.Code, err = newTableCode(); err != nil {
+		return nil, err
+	}
+	if err = s.repo.UpdateCode(ctx, id, table.Code); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// QRCode 生成桌台二维码，内容为扫码点餐地址加门店、桌台与校验码
+func (s *TableService) QRCode(ctx context.Context, id uint64, scale int) ([]byte, error) {
+	table, err := s.getTable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if scale <= 0 {
+		scale = tableQRScale
+	}
+	code, err := qrcode.Encode([]byte(tableURL(table)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode table qrcode: %w", err)
+	}
+	return code.PNG(min(scale, tableQRMaxScale), 4)
+}
+
+// Scan 校验二维码参数，停用的桌台视为不存在
+func (s *TableService) Scan(ctx context.Context, dto userRequest.TableScanDTO) (*userResponse.TableScanVO, error) {
+	if dto.Shop != 0 && dto.Shop != global.Config.Dine.ShopId {
+		return nil, e.Error_TABLE_CODE_INVALID
+	}
+	table, err := s.checkTable(ctx, dto.Table, dto.Code)
+	if err != nil {
+		return nil, err
+	}
+	return &userResponse.TableScanVO{
+		TableId:   table.Id,
+		TableName: table.Label(),
+		Seats:     table.Seats,
+		Code:      table.Code,
+	}, nil
+}
+
+// checkTable 下单与扫码时校验桌台状态与校验码
+func (s *TableService) checkTable(ctx context.Context, id uint64, code string) (*model.DiningTable, error) {
+	table, err := s.getTable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if table.Status != enum.ENABLE {
+		return nil, e.Error_TABLE_NOT_FOUND
+	}
+	if code != table.Code {
+		return nil, e.Error_TABLE_CODE_INVALID
+	}
+	return table, nil
+}
+
+func (s *TableService) getTable(ctx context.Context, id uint64) (*model.DiningTable, error) {
+	table, err := s.repo.GetTable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if table == nil {
+		return nil, e.Error_TABLE_NOT_FOUND
+	}
+	return table, nil
+}
+
+func applyTableDTO(table *model.DiningTable, dto request.TableDTO) {
+	table.Name = strings.TrimSpace(dto.Name)
+	table.Area = strings.TrimSpace(dto.Area)
+	table.Seats = dto.Seats
+	if table.Seats <= 0 {
+		table.Seats = tableDefaultSeats
+	}
+	if dto.Status != nil {
+		table.Status = *dto.Status
+	}
+}
+
+// tableURL 二维码内容，扫码点餐地址已带参数时追加
+func tableURL(table *model.DiningTable) string {
+	query := url.Values{}
+	query.Set("shop", strconv.Itoa(global.Config.Dine.ShopId))
+	query.Set("table", strconv.FormatUint(table.Id, 10))
+	query.Set("code", table.Code)
+	base := global.Config.Dine.QRBaseURL
+	if strings.Contains(base, "?") {
+		return base + "&" + query.Encode()
+	}
+	return base + "?" + query.Encode()
+}
+
+// newTableCode 8 位十六进制校验码
+func newTableCode() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate table code: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"mime/multipart"
+	"takeout/common/e"
+	"takeout/common/storage"
+	"takeout/global"
+	"takeout/internal/model"
+	"takeout/repository"
+	"time"
+)
+
+const (
+	uploadDefaultMaxSize = 5 << 20
+	uploadDefaultThumb   = 200
+	uploadDefaultMedium  = 800
+	uploadDefaultSignTTL = time.Hour
+)
+
+type IUploadService interface {
+	// Upload 上传图片，prefix 为存储目录
+	Upload(ctx *gin.Context, file *multipart.FileHeader, prefix string) (*storage.Object, error)
+	// SignURL 生成限时访问地址
+	SignURL(ctx *gin.Context, key string) (string, error)
+}
+
+type UploadService struct {
+	storage   storage.Storage
+	uploader  *storage.Uploader
+	mediaRepo repository.MediaRepo
+}
+
+func (u UploadService) Upload(ctx *gin.Context, file *multipart.FileHeader, prefix string) (*storage.Object, error) {
+	return uploadFile(ctx, u.uploader, u.mediaRepo, file, prefix)
+}
+
+func (u UploadService) SignURL(_ *gin.Context, key string) (string, error) {
+	url, err := u.storage.SignedURL(key, storageSignTTL())
+	if errors.Is(err, storage.ErrInvalidKey) {
+		return "", e.Error_FILE_KEY_INVALID
+	}
+	return url, err
+}
+
+// uploadFile 打开上传的文件交给 uploader 处理，并将校验错误转换为业务错误，保存成功后记录到媒体库
+func uploadFile(ctx context.Context, uploader *storage.Uploader, mediaRepo repository.MediaRepo, file *multipart.FileHeader, prefix string) (*storage.Object, error) {
+	if file.Size > uploader.MaxSize {
+		return nil, e.Error_FILE_TOO_LARGE
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	obj, err := uploader.Upload(ctx, f, prefix)
+	switch {
+	case errors.Is(err, storage.ErrTooLarge):
+		return nil, e.Error_FILE_TOO_LARGE
+	case errors.Is(err, storage.ErrUnsupportedType), errors.Is(err, storage.ErrEmptyFile):
+		return nil, e.Error_FILE_TYPE_INVALID
+	case err != nil:
+		return nil, err
+	}
+	variants, err := json.Marshal(obj.Variants)
+	if err != nil {
+		return nil, err
+	}
+	media := &model.Media{
+		Category:    prefix,
+		ObjectKey:   obj.Key,
+		Url:         obj.URL,
+		Hash:        obj.Hash,
+		ContentType: obj.ContentType,
+		Size:        obj.Size,
+		Width:       obj.Width,
+		Height:      obj.Height,
+		Variants:    string(variants),
+	}
+	if err = mediaRepo.Save(ctx, media); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func NewUploadService(mediaRepo repository.MediaRepo) IUploadService {
+	store := NewStorage()
+	return &UploadService{storage: store, uploader: NewUploader(store, storageMaxSize(), storage.ImageTypes), mediaRepo: mediaRepo}
+}
+
+// NewStorage 按配置创建存储，对象存储配置有误时记录日志并退回本地磁盘
+func NewStorage() storage.Storage {
+	conf := global.Config.Storage
+	opts := storage.Options{
+		Driver:     conf.Driver,
+		LocalDir:   global.Config.Path.LocalPath,
+		LocalURL:   global.Config.Path.VisitPath,
+		SignSecret: conf.Secret,
+		PublicURL:  conf.PublicURL,
+	}
+	switch conf.Driver {
+	case storage.DriverS3:
+		opts.Endpoint, opts.Region, opts.Bucket = conf.S3.Endpoint, conf.S3.Region, conf.S3.Bucket
+		opts.AccessKey, opts.SecretKey, opts.PathStyle = conf.S3.AccessKey, conf.S3.SecretKey, conf.S3.PathStyle
+	case storage.DriverOSS:
+		oss := global.Config.AliOss
+		opts.Endpoint, opts.Bucket, opts.AccessKey, opts.SecretKey = oss.EndPoint, oss.BucketName, oss.AccessKeyId, oss.AccessKeySecret
+	}
+	store, err := storage.New(opts)
+	if err != nil {
+		global.Log.Error("Init storage failed, fall back to local disk:", err.Error())
+		return storage.NewLocal(opts.LocalDir, opts.LocalURL, opts.SignSecret)
+	}
+	return store
+}
+
+// NewUploader 图片上传，生成缩略图与中图两个规格
+func NewUploader(store storage.Storage, maxSize int64, types map[string]string) *storage.Uploader {
+	conf := global.Config.Storage
+	thumb, medium := conf.Thumbnail, conf.Medium
+	if thumb <= 0 {
+		thumb = uploadDefaultThumb
+	}
+	if medium <= 0 {
+		medium = uploadDefaultMedium
+	}
+	return &storage.Uploader{
+		Storage:  store,
+		MaxSize:  maxSize,
+		Types:    types,
+		Variants: []storage.Variant{{Name: "thumb", MaxSide: thumb}, {Name: "medium", MaxSide: medium}},
+	}
+}
+
+func storageMaxSize() int64 {
+	if n := global.Config.Storage.MaxSize; n > 0 {
+		return n
+	}
+	return uploadDefaultMaxSize
+}
+
+func storageSignTTL() time.Duration {
+	if d, err := time.ParseDuration(global.Config.Storage.SignTTL); err == nil && d > 0 {
+		return d
+	}
+	return uploadDefaultSignTTL
+}
@@ -1,33 +1,94 @@
 package service
 
 import (
+	"context"
+	"crypto/subtle"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"mime/multipart"
+	"slices"
+	"strconv"
+	"strings"
+	"takeout/common/e"
+	"takeout/common/enum"
+	"takeout/common/sms"
+	"takeout/common/storage"
 	"takeout/common/utils"
 	"takeout/global"
 	"takeout/internal/api/user/request"
 	"takeout/internal/api/user/response"
 	"takeout/internal/model"
 	"takeout/repository"
+	"time"
+	"unicode/utf8"
 )
 
+const (
+	WxSessionKey = "wx:session:"
+	SmsCodeKey   = "sms:code:"
+	SmsLockKey   = "sms:lock:"
+	SmsDailyKey  = "sms:daily:"
+	MergeCodeKey = "user:merge:" // 合并码 -> 确认被合并的用户id
+
+	mergeCodeDigits = 8
+	mergeCodeTTL    = 10 * time.Minute
+	mergeMaxHops    = 5
+
+	wxDefaultSessionTTL = 72 * time.Hour
+	smsCodeDigits       = 6
+	smsDefaultCodeTTL   = 5 * time.Minute
+	smsDefaultAttempts  = 5
+	smsDefaultInterval  = time.Minute
+	smsDefaultDaily     = 10
+
+	profileNameMaxLen = 32
+	avatarMaxSize     = 2 << 20
+)
+
+// avatarTypes 允许上传的头像格式及保存的扩展名
+var avatarTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
 type IWxUserService interface {
 	Login(ctx *gin.Context, request request.WxUserLoginDTO) (response.WxUserVO, error)
+	SendPhoneCode(ctx *gin.Context, dto request.PhoneCodeDTO) error
+	BindPhoneBySms(ctx *gin.Context, dto request.PhoneSmsBindDTO) (response.PhoneBindVO, error)
+	BindPhoneByWechat(ctx *gin.Context, dto request.PhoneWechatBindDTO) (response.PhoneBindVO, error)
+	// MergeCode 生成合并码，另一个账号绑定本账号的手机号时填写，确认将本账号合并过去
+	MergeCode(ctx *gin.Context) (response.MergeCodeVO, error)
+	Profile(ctx *gin.Context) (response.UserProfileVO, error)
+	UpdateProfile(ctx *gin.Context, dto request.UserProfileDTO) (response.UserProfileVO, error)
+	UploadAvatar(ctx *gin.Context, file *multipart.FileHeader) (string, error)
+	DeleteAccount(ctx *gin.Context, dto request.AccountDeleteDTO) error
+	ExportData(ctx *gin.Context) (*response.PersonalDataVO, error)
 }
 
 type WxUserService struct {
-	repo repository.WxUserRepo
+	repo      repository.WxUserRepo
+	cartRepo  repository.ShoppingCartRepo
+	sms       sms.Provider
+	avatars   *storage.Uploader
+	mediaRepo repository.MediaRepo
 }
 
 func (ws WxUserService) Login(ctx *gin.Context, request request.WxUserLoginDTO) (response.WxUserVO, error) {
 	var (
-		openID    = utils.GetOpenID(request.Code)
 		user      model.User
 		err       error
 		existUser bool
 		jwtToken  string
 	)
-	// 获取微信openid
+	// 获取微信openid与会话密钥
+	session, err := utils.Code2Session(request.Code)
+	if err != nil {
+		global.Log.Error("Code2Session 调用微信接口服务失败：", err.Error())
+	}
+	openID := session.OpenID
 	if openID == "" {
 		return response.WxUserVO{}, errors.New("openId is empty")
 	}
@@ -38,11 +99,26 @@ func (ws WxUserService) Login(ctx *gin.Context, request request.WxUserLoginDTO)
 	//用户未注册
 	if !existUser {
 		user.OpenId = openID
-		if err = ws.repo.RegisterUser(ctx, user); err != nil {
+		user.UnionId = session.UnionID
+		if err = ws.repo.RegisterUser(ctx, &user); err != nil {
 			//注册失败
 			return response.WxUserVO{}, err
 		}
 	}
+	// 账号已被合并时进入合并后的账号
+	if user.MergedInto != nil {
+		if user, err = ws.resolveMerged(ctx, user); err != nil {
+			return response.WxUserVO{}, err
+		}
+	} else if session.UnionID != "" && user.UnionId != session.UnionID {
+		if err = ws.repo.UpdateUnionId(ctx, user.ID, session.UnionID); err != nil {
+			global.Log.Warn("Save wechat unionid failed", "error", err)
+		}
+	}
+	// 保存会话密钥用于解密手机号，不返回给客户端
+	if err = global.RedisClient.Set(WxSessionKey+strconv.Itoa(user.ID), session.SessionKey, wxSessionTTL()).Err(); err != nil {
+		global.Log.Warn("Save wechat session key failed", "error", err)
+	}
 	// 用户存在，分发jwt令牌
 	jwtConfig := global.Config.Jwt.User
 	if jwtToken, err = utils.GenerateToken(uint64(user.ID), jwtConfig.Name, jwtConfig.Secret); err != nil {
@@ -53,10 +129,408 @@ func (ws WxUserService) Login(ctx *gin.Context, request request.WxUserLoginDTO)
 		Id:     int64(user.ID),
 		Token:  jwtToken,
 		Openid: openID,
+		Phone:  user.Phone,
 	}
 	return res, nil
 }
 
-func NewWxUserService(repo repository.WxUserRepo) IWxUserService {
-	return &WxUserService{repo: repo}
+// SendPhoneCode 发送绑定手机号或注销账号使用的短信验证码，同一手机号限制发送间隔与每日次数
+func (ws WxUserService) SendPhoneCode(ctx *gin.Context, dto request.PhoneCodeDTO) error {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	if !mobilePattern.MatchString(dto.Phone) {
+		return e.Error_PHONE_INVALID
+	}
+	ok, err := global.RedisClient.SetNX(SmsLockKey+dto.Phone, 1, smsInterval()).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return e.Error_SMS_TOO_FREQUENT
+	}
+	dailyKey := SmsDailyKey + dto.Phone + ":" + time.Now().Format("20060102")
+	count, err := global.RedisClient.Incr(dailyKey).Result()
+	if err != nil {
+		return err
+	}
+	if count == 1 {
+		global.RedisClient.Expire(dailyKey, 24*time.Hour)
+	}
+	if int(count) > smsDailyLimit() {
+		return e.Error_SMS_DAILY_LIMIT
+	}
+
+	code, err := sms.NewCode(smsCodeDigits)
+	if err != nil {
+		return err
+	}
+	codeKey := SmsCodeKey + dto.Phone
+	pipe := global.RedisClient.TxPipeline()
+	pipe.HMSet(codeKey, map[string]interface{}{"code": code, "user": userId, "attempts": 0})
+	pipe.Expire(codeKey, smsCodeTTL())
+	if _, err = pipe.Exec(); err != nil {
+		return err
+	}
+	text := fmt.Sprintf("您的验证码为 %s，%d 分钟内有效，请勿泄露。", code, int(smsCodeTTL().Minutes()))
+	sendCtx, cancel := context.WithTimeout(ctx, deliveryTimeout())
+	defer cancel()
+	if err = ws.sms.Send(sendCtx, dto.Phone, text); err != nil {
+		// 发送失败时允许立即重试
+		global.RedisClient.Del(codeKey, SmsLockKey+dto.Phone)
+		return err
+	}
+	return nil
+}
+
+// BindPhoneBySms 校验短信验证码后绑定手机号
+func (ws WxUserService) BindPhoneBySms(ctx *gin.Context, dto request.PhoneSmsBindDTO) (response.PhoneBindVO, error) {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	if !mobilePattern.MatchString(dto.Phone) {
+		return response.PhoneBindVO{}, e.Error_PHONE_INVALID
+	}
+	if err := verifySmsCode(userId, dto.Phone, dto.Code); err != nil {
+		return response.PhoneBindVO{}, err
+	}
+	return ws.bindPhone(ctx, userId, dto.Phone, dto.MergeCode)
+}
+
+// BindPhoneByWechat 使用登录时保存的会话密钥解密微信手机号后绑定
+func (ws WxUserService) BindPhoneByWechat(ctx *gin.Context, dto request.PhoneWechatBindDTO) (response.PhoneBindVO, error) {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	sessionKey, err := global.RedisClient.Get(WxSessionKey + strconv.Itoa(userId)).Result()
+	if err != nil || sessionKey == "" {
+		return response.PhoneBindVO{}, e.Error_WX_SESSION_EXPIRED
+	}
+	phone, err := utils.DecryptWxPhone(sessionKey, dto.EncryptedData, dto.Iv, global.Config.Wechat.AppId)
+	if err != nil {
+		// 会话密钥已被新的登录刷新或已过期
+		global.Log.Debug("Decrypt wechat phone failed:", err.Error())
+		return response.PhoneBindVO{}, e.Error_WX_SESSION_EXPIRED
+	}
+	if phone.CountryCode != "86" || !mobilePattern.MatchString(phone.PurePhoneNumber) {
+		return response.PhoneBindVO{}, e.Error_PHONE_INVALID
+	}
+	return ws.bindPhone(ctx, userId, phone.PurePhoneNumber, dto.MergeCode)
+}
+
+// bindPhone 绑定手机号，已绑定该手机号的其他账号与当前账号的 unionid 相同，或用合并码确认过时合并到当前账号。
+// 手机号可能是运营商回收后重新放号的，未确认的原账号只解除手机号绑定，不转移其数据
+func (ws WxUserService) bindPhone(ctx context.Context, userId int, phone, mergeCode string) (response.PhoneBindVO, error) {
+	user, err := ws.repo.GetUserById(ctx, userId)
+	if err != nil {
+		return response.PhoneBindVO{}, err
+	}
+	if user == nil || user.DeleteTime != nil {
+		return response.PhoneBindVO{}, e.Error_ACCOUNT_NOT_FOUND
+	}
+	confirmed, err := mergeCodeUser(mergeCode)
+	if err != nil {
+		return response.PhoneBindVO{}, err
+	}
+	owners, err := ws.repo.ListByPhone(ctx, phone)
+	if err != nil {
+		return response.PhoneBindVO{}, err
+	}
+	var mergeIds, unbindIds []int
+	for _, owner := range owners {
+		switch {
+		case owner.ID == userId:
+		case owner.ID == confirmed, user.UnionId != "" && owner.UnionId == user.UnionId:
+			mergeIds = append(mergeIds, owner.ID)
+		default:
+			unbindIds = append(unbindIds, owner.ID)
+		}
+	}
+	// 合并码只能合并绑定了该手机号的账号
+	if confirmed != 0 && !slices.Contains(mergeIds, confirmed) {
+		return response.PhoneBindVO{}, e.Error_MERGE_CODE_INVALID
+	}
+	if err = ws.repo.BindPhone(ctx, userId, phone, mergeIds, unbindIds); err != nil {
+		return response.PhoneBindVO{}, err
+	}
+	if mergeCode != "" {
+		global.RedisClient.Del(MergeCodeKey + mergeCode)
+	}
+	// 购物车在缓存中，数据库事务提交后再合并
+	for _, id := range mergeIds {
+		if err = ws.mergeCart(ctx, id, userId); err != nil {
+			global.Log.Warn("Merge shopping cart failed", "from", id, "into", userId, "error", err)
+		}
+		global.RedisClient.Del(WxSessionKey + strconv.Itoa(id))
+		if err = utils.RevokeUserTokens(uint64(id)); err != nil {
+			global.Log.Warn("Revoke merged user tokens failed", "userId", id, "error", err)
+		}
+	}
+	return response.PhoneBindVO{Phone: phone, Merged: len(mergeIds), Unbound: len(unbindIds)}, nil
+}
+
+// MergeCode 由将被合并的账号生成，确认其数据可以转移到填写该合并码的账号
+func (ws WxUserService) MergeCode(ctx *gin.Context) (response.MergeCodeVO, error) {
+	user, err := ws.currentUser(ctx)
+	if err != nil {
+		return response.MergeCodeVO{}, err
+	}
+	if user.Phone == "" {
+		return response.MergeCodeVO{}, e.Error_PHONE_INVALID
+	}
+	for i := 0; i < 3; i++ {
+		code, err := sms.NewCode(mergeCodeDigits)
+		if err != nil {
+			return response.MergeCodeVO{}, err
+		}
+		ok, err := global.RedisClient.SetNX(MergeCodeKey+code, user.ID, mergeCodeTTL).Result()
+		if err != nil {
+			return response.MergeCodeVO{}, err
+		}
+		if ok {
+			return response.MergeCodeVO{Code: code, ExpireIn: int(mergeCodeTTL.Seconds())}, nil
+		}
+	}
+	return response.MergeCodeVO{}, errors.New("failed to generate merge code")
+}
+
+// mergeCodeUser 合并码对应的用户，未填写时返回 0
+func mergeCodeUser(code string) (int, error) {
+	if code == "" {
+		return 0, nil
+	}
+	value, err := global.RedisClient.Get(MergeCodeKey + code).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, e.Error_MERGE_CODE_INVALID
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// resolveMerged 沿合并记录找到最终的账号
+func (ws WxUserService) resolveMerged(ctx context.Context, user model.User) (model.User, error) {
+	for i := 0; i < mergeMaxHops && user.MergedInto != nil; i++ {
+		into, err := ws.repo.GetUserById(ctx, *user.MergedInto)
+		if err != nil {
+			return model.User{}, err
+		}
+		if into == nil {
+			return model.User{}, e.Error_ACCOUNT_NOT_FOUND
+		}
+		user = *into
+	}
+	if user.DeleteTime != nil {
+		return model.User{}, e.Error_ACCOUNT_NOT_FOUND
+	}
+	return user, nil
+}
+
+func (ws WxUserService) mergeCart(ctx context.Context, from, into int) error {
+	lines, err := ws.cartRepo.List(ctx, from)
+	if err != nil || len(lines) == 0 {
+		return err
+	}
+	for i := range lines {
+		lines[i].Id, lines[i].UserId = 0, into
+	}
+	if err = ws.cartRepo.InsertBatchShoppingCart(ctx, lines); err != nil {
+		return err
+	}
+	return ws.cartRepo.Clean(ctx, from)
+}
+
+// Profile 查询个人资料
+func (ws WxUserService) Profile(ctx *gin.Context) (response.UserProfileVO, error) {
+	user, err := ws.currentUser(ctx)
+	if err != nil {
+		return response.UserProfileVO{}, err
+	}
+	return profileVO(user), nil
+}
+
+// UpdateProfile 修改昵称与性别，手机号通过绑定接口修改
+func (ws WxUserService) UpdateProfile(ctx *gin.Context, dto request.UserProfileDTO) (response.UserProfileVO, error) {
+	user, err := ws.currentUser(ctx)
+	if err != nil {
+		return response.UserProfileVO{}, err
+	}
+	name := strings.TrimSpace(dto.Name)
+	if name == "" || utf8.RuneCountInString(name) > profileNameMaxLen {
+		return response.UserProfileVO{}, e.Error_PROFILE_INVALID
+	}
+	if dto.Sex != "" && dto.Sex != "0" && dto.Sex != "1" {
+		return response.UserProfileVO{}, e.Error_PROFILE_INVALID
+	}
+	if err = ws.repo.UpdateProfile(ctx, user.ID, name, dto.Sex); err != nil {
+		return response.UserProfileVO{}, err
+	}
+	user.Name, user.Sex = name, dto.Sex
+	return profileVO(user), nil
+}
+
+// UploadAvatar 上传头像，按文件内容校验格式
+func (ws WxUserService) UploadAvatar(ctx *gin.Context, file *multipart.FileHeader) (string, error) {
+	user, err := ws.currentUser(ctx)
+	if err != nil {
+		return "", err
+	}
+	obj, err := uploadFile(ctx, ws.avatars, ws.mediaRepo, file, "avatar")
+	if errors.Is(err, e.Error_FILE_TOO_LARGE) || errors.Is(err, e.Error_FILE_TYPE_INVALID) {
+		return "", e.Error_AVATAR_INVALID
+	}
+	if err != nil {
+		return "", err
+	}
+	// 头像展示尺寸小，优先使用缩略图
+	avatar := obj.URL
+	if thumb, ok := obj.VariantURLs["thumb"]; ok {
+		avatar = thumb
+	}
+	if err = ws.repo.UpdateAvatar(ctx, user.ID, avatar); err != nil {
+		return "", err
+	}
+	return avatar, nil
+}
+
+// DeleteAccount 注销账号，进行中的订单与售后处理完之前不能注销，已绑定手机号时需校验短信验证码
+func (ws WxUserService) DeleteAccount(ctx *gin.Context, dto request.AccountDeleteDTO) error {
+	user, err := ws.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	if user.Phone != "" {
+		if err = verifySmsCode(user.ID, user.Phone, dto.Code); err != nil {
+			return err
+		}
+	}
+	count, err := ws.repo.CountInProgress(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return e.Error_ACCOUNT_IN_USE
+	}
+	ok, err := ws.repo.DeleteAccount(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return e.Error_ACCOUNT_NOT_FOUND
+	}
+	if err = ws.cartRepo.Clean(ctx, user.ID); err != nil {
+		global.Log.Warn("Clean shopping cart failed", "user", user.ID, "error", err)
+	}
+	global.RedisClient.Del(WxSessionKey + strconv.Itoa(user.ID))
+	// 已签发的令牌不再可用，避免注销后继续下单或维护地址
+	if err = utils.RevokeUserTokens(uint64(user.ID)); err != nil {
+		global.Log.Warn("Revoke deleted user tokens failed", "userId", user.ID, "error", err)
+	}
+	return nil
+}
+
+// ExportData 导出个人数据
+func (ws WxUserService) ExportData(ctx *gin.Context) (*response.PersonalDataVO, error) {
+	user, err := ws.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ws.repo.ExportData(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, e.Error_ACCOUNT_NOT_FOUND
+	}
+	return data, nil
+}
+
+// currentUser 查询当前登录用户，已注销的账号视为不存在
+func (ws WxUserService) currentUser(ctx *gin.Context) (*model.User, error) {
+	userId := int(ctx.MustGet(enum.CurrentId).(uint64))
+	user, err := ws.repo.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.DeleteTime != nil {
+		return nil, e.Error_ACCOUNT_NOT_FOUND
+	}
+	return user, nil
+}
+
+func profileVO(user *model.User) response.UserProfileVO {
+	return response.UserProfileVO{
+		Id:         user.ID,
+		Name:       user.Name,
+		Phone:      user.Phone,
+		Sex:        user.Sex,
+		Avatar:     user.Avatar,
+		CreateTime: user.CreateTime,
+	}
+}
+
+// verifySmsCode 校验验证码，超过尝试次数后验证码失效，校验通过后删除
+func verifySmsCode(userId int, phone, code string) error {
+	key := SmsCodeKey + phone
+	saved, err := global.RedisClient.HGetAll(key).Result()
+	if err != nil {
+		return err
+	}
+	if len(saved) == 0 || saved["user"] != strconv.Itoa(userId) {
+		return e.Error_SMS_CODE_INVALID
+	}
+	attempts, err := global.RedisClient.HIncrBy(key, "attempts", 1).Result()
+	if err != nil {
+		return err
+	}
+	if int(attempts) > smsMaxAttempts() {
+		global.RedisClient.Del(key)
+		return e.Error_SMS_CODE_INVALID
+	}
+	if subtle.ConstantTimeCompare([]byte(saved["code"]), []byte(code)) != 1 {
+		return e.Error_SMS_CODE_INVALID
+	}
+	global.RedisClient.Del(key)
+	return nil
+}
+
+func NewWxUserService(repo repository.WxUserRepo, cartRepo repository.ShoppingCartRepo, mediaRepo repository.MediaRepo) IWxUserService {
+	conf := global.Config.Sms
+	return &WxUserService{
+		repo:      repo,
+		cartRepo:  cartRepo,
+		sms:       sms.New(conf.Provider, conf.Endpoint, conf.Secret, conf.Dir),
+		avatars:   NewUploader(NewStorage(), avatarMaxSize, avatarTypes),
+		mediaRepo: mediaRepo,
+	}
+}
+
+func wxSessionTTL() time.Duration {
+	if d, err := time.ParseDuration(global.Config.Wechat.SessionTTL); err == nil && d > 0 {
+		return d
+	}
+	return wxDefaultSessionTTL
+}
+
+func smsCodeTTL() time.Duration {
+	if d, err := time.ParseDuration(global.Config.Sms.CodeTTL); err == nil && d > 0 {
+		return d
+	}
+	return smsDefaultCodeTTL
+}
+
+func smsInterval() time.Duration {
+	if d, err := time.ParseDuration(global.Config.Sms.Interval); err == nil && d > 0 {
+		return d
+	}
+	return smsDefaultInterval
+}
+
+func smsMaxAttempts() int {
+	if n := global.Config.Sms.MaxAttempts; n > 0 {
+		return n
+	}
+	return smsDefaultAttempts
+}
+
+func smsDailyLimit() int {
+	if n := global.Config.Sms.DailyLimit; n > 0 {
+		return n
+	}
+	return smsDefaultDaily
 }
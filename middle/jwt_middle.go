@@ -33,6 +33,10 @@ func VerifiyJWTUser() gin.HandlerFunc {
 		token := c.Request.Header.Get(global.Config.Jwt.User.Name)
 		// 解析获取用户载荷信息
 		payLoad, err := utils.ParseToken(token, global.Config.Jwt.User.Secret)
+		// 账号被合并或注销后，已签发的令牌不再有效
+		if err == nil && utils.UserTokensRevoked(payLoad.UserId) {
+			err = e.Error_ACCOUNT_NOT_FOUND
+		}
 		if err != nil {
 			code := e.UNKNOW_IDENTITY
 			c.JSON(http.StatusUnauthorized, common.Result{Code: code})
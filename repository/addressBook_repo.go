@@ -6,11 +6,15 @@ import (
 )
 
 type AddressBookRepo interface {
-	CreateAddress(ctx context.Context, addressBook model.AddressBook) error
+	// CreateAddress 在锁定用户地址的事务中校验数量并新增地址，已达 limit 个时返回 false。
+	// 第一个地址设为默认地址，设为默认地址时取消其他默认地址
+	CreateAddress(ctx context.Context, addressBook model.AddressBook, limit int) (bool, error)
 	GetAddressById(ctx context.Context, id uint64) (model.AddressBook, error)
+	// UpdateAddressById 按id与用户修改地址，不修改默认状态
 	UpdateAddressById(ctx context.Context, addressBook model.AddressBook) error
 	DeleteById(ctx context.Context, id uint64) error
 	GetCurAddressBook(ctx context.Context, userId uint64) ([]model.AddressBook, error)
 	GetDefaultAddressBook(ctx context.Context, userId uint64) (model.AddressBook, error)
-	SetDefaultAddressBook(ctx context.Context, userId, id uint64) error
+	// SetDefaultAddressBook 在同一事务中切换默认地址，地址不属于该用户时返回 false
+	SetDefaultAddressBook(ctx context.Context, userId, id uint64) (bool, error)
 }
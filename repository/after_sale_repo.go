@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"takeout/common"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/model"
+	"time"
+)
+
+type AfterSaleRepo interface {
+	// Create 创建售后单及售后商品
+	Create(ctx context.Context, sale *model.AfterSale) error
+	// GetById 不存在时返回 nil
+	GetById(ctx context.Context, id uint64) (*model.AfterSale, error)
+	ListByUser(ctx context.Context, userId int) ([]model.AfterSale, error)
+	ListByOrder(ctx context.Context, orderId int) ([]model.AfterSale, error)
+	PageQuery(ctx context.Context, dto request.AfterSalePageQueryDTO) (*common.PageResult, error)
+
+	// Cancel 用户撤销待处理的售后单，已处理时返回 false
+	Cancel(ctx context.Context, id uint64, userId int) (bool, error)
+	// Resolve 保存处理结果，remake 不为空时在同一事务中创建重做订单及明细，售后单已处理时返回 false
+	Resolve(ctx context.Context, sale *model.AfterSale, remake *model.Order, details []model.OrderDetail) (bool, error)
+
+	// ListOverdue 超过处理时限仍待处理的售后单
+	ListOverdue(ctx context.Context, now time.Time) ([]model.AfterSale, error)
+	// Escalate 升级并顺延处理时限，售后单已处理或已被升级时返回 false
+	Escalate(ctx context.Context, id uint64, level int, due time.Time) (bool, error)
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"takeout/common"
+	"takeout/common/cohort"
+	"takeout/internal/api/admin/request"
+	"time"
+)
+
+// CustomerRepo 基于 orders.user_id 的客户分析
+type CustomerRepo interface {
+	// 区间内的已完成订单
+	ListOrders(ctx context.Context, begin, end time.Time) ([]cohort.Order, error)
+	// 区间内下过单的客户截至 end 的累计下单情况
+	Lifetimes(ctx context.Context, begin, end time.Time) ([]request.CustomerLifetimeDTO, error)
+	// 最近一次已完成订单早于 cutoff 的客户，按累计消费额降序
+	PageChurned(ctx context.Context, cutoff time.Time, dto request.ChurnedQueryDTO) (*common.PageResult, error)
+}
@@ -2,11 +2,16 @@ package dao
 
 import (
 	"context"
+	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"takeout/internal/model"
 	"takeout/repository"
 )
 
+// errAddressLimit 地址数已达上限，回滚新增地址的事务
+var errAddressLimit = errors.New("address limit reached")
+
 type AddressBookDao struct {
 	db *gorm.DB
 }
@@ -16,8 +21,38 @@ func NewAddressBookDao(db *gorm.DB) repository.AddressBookRepo {
 }
 
 // CreateAddress 新增地址簿
-func (a AddressBookDao) CreateAddress(ctx context.Context, addressBook model.AddressBook) error {
-	return a.db.WithContext(ctx).Create(&addressBook).Error
+func (a AddressBookDao) CreateAddress(ctx context.Context, addressBook model.AddressBook, limit int) (bool, error) {
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 用户还没有地址时没有可锁的地址行，先锁定用户行使同一用户的新增串行执行
+		if err := tx.Table("user").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id=?", addressBook.UserId).Select("id").Take(&struct{ Id int }{}).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Table("address_book").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id=?", addressBook.UserId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(limit) {
+			return errAddressLimit
+		}
+		if count == 0 {
+			addressBook.IsDefault = 1
+		}
+		if addressBook.IsDefault == 1 {
+			if err := tx.Table("address_book").Where("user_id=?", addressBook.UserId).Update("is_default", 0).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&addressBook).Error
+	})
+	if errors.Is(err, errAddressLimit) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // GetAddressById 根据id查询地址
@@ -34,7 +69,10 @@ func (a AddressBookDao) GetAddressById(ctx context.Context, id uint64) (model.Ad
 
 // UpdateAddressById 根据id修改地址
 func (a AddressBookDao) UpdateAddressById(ctx context.Context, addressBook model.AddressBook) error {
-	return a.db.WithContext(ctx).Table("address_book").Updates(&addressBook).Error
+	return a.db.WithContext(ctx).Table("address_book").
+		Where("id=? and user_id=?", addressBook.Id, addressBook.UserId).
+		Select("*").Omit("id", "user_id", "is_default").
+		Updates(&addressBook).Error
 }
 
 // DeleteById 根据id删除地址
@@ -67,17 +105,29 @@ func (a AddressBookDao) GetDefaultAddressBook(ctx context.Context, userId uint64
 }
 
 // SetDefaultAddressBook 设置默认地址
-func (a AddressBookDao) SetDefaultAddressBook(ctx context.Context, userId, id uint64) error {
-	var (
-		err error
-	)
-	// 设置所有地址为非默认地址
-	if err = a.db.WithContext(ctx).Table("address_book").Where("user_id=?", userId).Update("is_default", 0).Error; err != nil && err != gorm.ErrRecordNotFound {
-		return err
+func (a AddressBookDao) SetDefaultAddressBook(ctx context.Context, userId, id uint64) (bool, error) {
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 地址不属于该用户时不做修改，已是默认地址时更新行数为 0，因此先锁定校验
+		var count int64
+		if err := tx.Table("address_book").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id=? and user_id=?", id, userId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		// 设置其他地址为非默认地址
+		if err := tx.Table("address_book").Where("user_id=? and id<>?", userId, id).Update("is_default", 0).Error; err != nil {
+			return err
+		}
+		// 将当前地址改为默认地址
+		return tx.Table("address_book").Where("id=?", id).Update("is_default", 1).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
 	}
-	// 将当前地址改为默认地址
-	if err = a.db.WithContext(ctx).Table("address_book").Where("id=?", id).Update("is_default", 1).Error; err != nil && err != gorm.ErrRecordNotFound {
-		return err
+	if err != nil {
+		return false, err
 	}
-	return nil
+	return true, nil
 }
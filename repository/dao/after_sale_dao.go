@@ -0,0 +1,188 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"takeout/common"
+	"takeout/common/enum"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/model"
+	"takeout/repository"
+	"time"
+)
+
+// errAfterSaleHandled 事务内售后单已不是待处理状态，用于回滚重做订单
+var errAfterSaleHandled = errors.New("after sale already handled")
+
+type AfterSaleDao struct {
+	db *gorm.DB
+}
+
+func NewAfterSaleDao(db *gorm.DB) repository.AfterSaleRepo {
+	return &AfterSaleDao{db: db}
+}
+
+// Create 创建售后单，售后商品随售后单一并写入
+func (d *AfterSaleDao) Create(ctx context.Context, sale *model.AfterSale) error {
+	if err := d.db.WithContext(ctx).Create(sale).Error; err != nil {
+		return fmt.Errorf("failed to create after sale: %w", err)
+	}
+	return nil
+}
+
+// GetById 查询售后单及售后商品
+func (d *AfterSaleDao) GetById(ctx context.Context, id uint64) (*model.AfterSale, error) {
+	var sale model.AfterSale
+	if err := d.db.WithContext(ctx).Preload("Items").Where("id = ?", id).First(&sale).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get after sale: %w", err)
+	}
+	return &sale, nil
+}
+
+// ListByUser 查询用户的售后单，最新的在前
+func (d *AfterSaleDao) ListByUser(ctx context.Context, userId int) ([]model.AfterSale, error) {
+	var sales []model.AfterSale
+	if err := d.db.WithContext(ctx).Preload("Items").Where("user_id = ?", userId).
+		Order("create_time desc").Find(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to list user after sales: %w", err)
+	}
+	return sales, nil
+}
+
+// ListByOrder 查询订单的全部售后单
+func (d *AfterSaleDao) ListByOrder(ctx context.Context, orderId int) ([]model.AfterSale, error) {
+	var sales []model.AfterSale
+	if err := d.db.WithContext(ctx).Preload("Items").Where("order_id = ?", orderId).
+		Order("create_time asc").Find(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to list order after sales: %w", err)
+	}
+	return sales, nil
+}
+
+// PageQuery 售后管理分页查询
+func (d *AfterSaleDao) PageQuery(ctx context.Context, dto request.AfterSalePageQueryDTO) (*common.PageResult, error) {
+	var (
+		pageResult common.PageResult
+		sales      []model.AfterSale
+	)
+	query := d.db.WithContext(ctx).Model(&model.AfterSale{})
+	if dto.Status != "" {
+		query = query.Where("status = ?", dto.Status)
+	}
+	if dto.Type != 0 {
+		query = query.Where("type = ?", dto.Type)
+	}
+	if dto.OrderNumber != "" {
+		query = query.Where("order_number like ?", "%"+dto.OrderNumber+"%")
+	}
+	if dto.Escalated == "1" {
+		query = query.Where("escalate_level > 0")
+	}
+	if dto.BeginTime != "" {
+		beginTime, err := time.ParseInLocation("2006-01-02 15:04:05", dto.BeginTime, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("create_time >= ?", beginTime)
+	}
+	if dto.EndTime != "" {
+		endTime, err := time.ParseInLocation("2006-01-02 15:04:05", dto.EndTime, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("create_time <= ?", endTime)
+	}
+	if err := query.Count(&pageResult.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count after sales: %w", err)
+	}
+	if err := query.Scopes(pageResult.Paginate(&dto.Page, &dto.PageSize)).
+		Preload("Items").
+		Order("create_time desc").
+		Find(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to query after sales: %w", err)
+	}
+	pageResult.Records = sales
+	return &pageResult, nil
+}
+
+// Cancel 仅撤销本人待处理的售后单
+func (d *AfterSaleDao) Cancel(ctx context.Context, id uint64, userId int) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&model.AfterSale{}).
+		Where("id = ? and user_id = ? and status = ?", id, userId, enum.AfterSalePending).
+		Updates(map[string]any{"status": enum.AfterSaleCancelled, "update_time": time.Now()})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to cancel after sale: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Resolve 写入处理结果，重做订单与售后单状态在同一事务中提交
+func (d *AfterSaleDao) Resolve(ctx context.Context, sale *model.AfterSale, remake *model.Order, details []model.OrderDetail) (bool, error) {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if remake != nil {
+			if err := tx.Create(remake).Error; err != nil {
+				return fmt.Errorf("failed to create remake order: %w", err)
+			}
+			for i := range details {
+				details[i].OrderId = remake.Id
+			}
+			if err := tx.Create(&details).Error; err != nil {
+				return fmt.Errorf("failed to create remake order details: %w", err)
+			}
+			sale.RemakeOrderId = remake.Id
+		}
+		result := tx.Model(&model.AfterSale{}).
+			Where("id = ? and status = ?", sale.Id, enum.AfterSalePending).
+			Updates(map[string]any{
+				"status":          sale.Status,
+				"resolution":      sale.Resolution,
+				"refund_amount":   sale.RefundAmount,
+				"remake_order_id": sale.RemakeOrderId,
+				"reply":           sale.Reply,
+				"handle_user":     sale.HandleUser,
+				"handle_time":     sale.HandleTime,
+				"update_time":     time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to resolve after sale: %w", result.Error)
+		}
+		if result.RowsAffected != 1 {
+			return errAfterSaleHandled
+		}
+		return nil
+	})
+	if errors.Is(err, errAfterSaleHandled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListOverdue 查询已超过处理时限的待处理售后单
+func (d *AfterSaleDao) ListOverdue(ctx context.Context, now time.Time) ([]model.AfterSale, error) {
+	var sales []model.AfterSale
+	if err := d.db.WithContext(ctx).
+		Where("status = ? and due_time < ?", enum.AfterSalePending, now).
+		Order("due_time asc").Find(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to list overdue after sales: %w", err)
+	}
+	return sales, nil
+}
+
+// Escalate 按当前升级次数条件更新，避免重复升级
+func (d *AfterSaleDao) Escalate(ctx context.Context, id uint64, level int, due time.Time) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&model.AfterSale{}).
+		Where("id = ? and status = ? and escalate_level = ?", id, enum.AfterSalePending, level-1).
+		Updates(map[string]any{"escalate_level": level, "due_time": due, "update_time": time.Now()})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to escalate after sale: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
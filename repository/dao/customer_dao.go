@@ -0,0 +1,87 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"takeout/common"
+	"takeout/common/cohort"
+	"takeout/common/enum"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/repository"
+	"time"
+)
+
+type CustomerDao struct {
+	db *gorm.DB
+}
+
+func NewCustomerDao(db *gorm.DB) repository.CustomerRepo {
+	return &CustomerDao{db: db}
+}
+
+// ListOrders 查询区间内已完成订单的下单人、时间与金额
+func (d *CustomerDao) ListOrders(ctx context.Context, begin, end time.Time) ([]cohort.Order, error) {
+	orders := make([]cohort.Order, 0)
+	if err := d.db.WithContext(ctx).Table("orders").
+		Select("user_id, order_time as time, amount").
+		Where("status = ?", enum.Completed).
+		Where(notRemake).
+		Where("order_time >= ?", begin).
+		Where("order_time <= ?", end).
+		Scan(&orders).Error; err != nil {
+		return nil, fmt.Errorf("failed to list customer orders: %w", err)
+	}
+	return orders, nil
+}
+
+// Lifetimes 区间内下过单的客户截至 end 的累计订单数、消费额与首单/末单时间
+func (d *CustomerDao) Lifetimes(ctx context.Context, begin, end time.Time) ([]request.CustomerLifetimeDTO, error) {
+	active := d.db.Table("orders").
+		Select("distinct user_id").
+		Where("status = ?", enum.Completed).
+		Where(notRemake).
+		Where("order_time >= ?", begin).
+		Where("order_time <= ?", end)
+	lifetimes := make([]request.CustomerLifetimeDTO, 0)
+	if err := d.db.WithContext(ctx).Table("orders").
+		Select("user_id, count(*) as orders, ifnull(sum(amount),0) as amount, "+
+			"min(order_time) as first_order, max(order_time) as last_order").
+		Where("status = ?", enum.Completed).
+		Where(notRemake).
+		Where("order_time <= ?", end).
+		Where("user_id in (?)", active).
+		Group("user_id").
+		Scan(&lifetimes).Error; err != nil {
+		return nil, fmt.Errorf("failed to query customer lifetimes: %w", err)
+	}
+	return lifetimes, nil
+}
+
+// PageChurned 分页查询流失客户
+func (d *CustomerDao) PageChurned(ctx context.Context, cutoff time.Time, dto request.ChurnedQueryDTO) (*common.PageResult, error) {
+	var pageResult common.PageResult
+	sub := d.db.Table("orders").
+		Select("orders.user_id, count(*) as orders, ifnull(sum(orders.amount),0) as amount, "+
+			"max(orders.order_time) as last_order_time").
+		Where("orders.status = ?", enum.Completed).
+		Where(notRemake).
+		Group("orders.user_id").
+		Having("max(orders.order_time) < ?", cutoff)
+	if err := d.db.WithContext(ctx).Table("(?) as t", sub).Count(&pageResult.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count churned customers: %w", err)
+	}
+	customers := make([]response.ChurnedCustomerVO, 0)
+	if err := d.db.WithContext(ctx).Table("(?) as t", sub).
+		Select("t.user_id, ifnull(user.name,'') as name, ifnull(user.phone,'') as phone, " +
+			"t.orders, t.amount, t.last_order_time").
+		Joins("left join user on user.id = t.user_id").
+		Order("t.amount desc, t.user_id").
+		Scopes(pageResult.Paginate(&dto.Page, &dto.PageSize)).
+		Scan(&customers).Error; err != nil {
+		return nil, fmt.Errorf("failed to query churned customers: %w", err)
+	}
+	pageResult.Records = customers
+	return &pageResult, nil
+}
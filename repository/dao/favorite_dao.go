@@ -0,0 +1,89 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"takeout/common/recommend"
+	"takeout/internal/api/user/response"
+	"takeout/internal/model"
+	"takeout/repository"
+)
+
+type FavoriteDao struct {
+	db *gorm.DB
+}
+
+func NewFavoriteDao(db *gorm.DB) repository.FavoriteRepo {
+	return &FavoriteDao{db: db}
+}
+
+// Add 添加收藏，重复收藏忽略
+func (d FavoriteDao) Add(ctx context.Context, favorite model.Favorite) error {
+	if err := d.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&favorite).Error; err != nil {
+		return fmt.Errorf("failed to add favorite: %w", err)
+	}
+	return nil
+}
+
+// Delete 取消收藏
+func (d FavoriteDao) Delete(ctx context.Context, userId int, dishId, setmealId uint64) error {
+	if err := d.db.WithContext(ctx).
+		Where("user_id = ? and dish_id = ? and setmeal_id = ?", userId, dishId, setmealId).
+		Delete(&model.Favorite{}).Error; err != nil {
+		return fmt.Errorf("failed to delete favorite: %w", err)
+	}
+	return nil
+}
+
+// Exists 是否已收藏
+func (d FavoriteDao) Exists(ctx context.Context, userId int, dishId, setmealId uint64) (bool, error) {
+	var count int64
+	if err := d.db.WithContext(ctx).Model(&model.Favorite{}).
+		Where("user_id = ? and dish_id = ? and setmeal_id = ?", userId, dishId, setmealId).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to query favorite: %w", err)
+	}
+	return count > 0, nil
+}
+
+// List 查询用户收藏，关联菜品/套餐的名称、图片、价格与售卖状态
+func (d FavoriteDao) List(ctx context.Context, userId int) ([]response.FavoriteVO, error) {
+	var rows []struct {
+		model.Favorite
+		DishName      string
+		DishImage     string
+		DishPrice     float64
+		DishStatus    int
+		SetmealName   string
+		SetmealImage  string
+		SetmealPrice  float64
+		SetmealStatus int
+	}
+	if err := d.db.WithContext(ctx).Table("favorite").
+		Select("favorite.*, "+
+			"dish.name as dish_name, dish.image as dish_image, dish.price as dish_price, dish.status as dish_status, "+
+			"setmeal.name as setmeal_name, setmeal.image as setmeal_image, setmeal.price as setmeal_price, setmeal.status as setmeal_status").
+		Joins("left join dish on favorite.dish_id = dish.id").
+		Joins("left join setmeal on favorite.setmeal_id = setmeal.id").
+		Where("favorite.user_id = ?", userId).
+		Where("dish.id is not null or setmeal.id is not null").
+		Order("favorite.create_time desc").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list favorites: %w", err)
+	}
+	res := make([]response.FavoriteVO, 0, len(rows))
+	for _, row := range rows {
+		vo := response.FavoriteVO{Id: row.Id, CreateTime: row.CreateTime}
+		if row.SetmealId != 0 {
+			vo.Type, vo.ItemId = recommend.TypeSetmeal, row.SetmealId
+			vo.Name, vo.Image, vo.Price, vo.Status = row.SetmealName, row.SetmealImage, row.SetmealPrice, row.SetmealStatus
+		} else {
+			vo.Type, vo.ItemId = recommend.TypeDish, row.DishId
+			vo.Name, vo.Image, vo.Price, vo.Status = row.DishName, row.DishImage, row.DishPrice, row.DishStatus
+		}
+		res = append(res, vo)
+	}
+	return res, nil
+}
@@ -0,0 +1,330 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"takeout/common/enum"
+	"takeout/internal/model"
+	"takeout/repository"
+	"time"
+)
+
+type GroupCartDao struct {
+	db *gorm.DB
+}
+
+func NewGroupCartDao(db *gorm.DB) repository.GroupCartRepo {
+	return &GroupCartDao{db: db}
+}
+
+// CreateGroup 创建拼单与发起人成员
+func (d *GroupCartDao) CreateGroup(ctx context.Context, group *model.GroupCart, owner *model.GroupMember) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(group).Error; err != nil {
+			return fmt.Errorf("failed to create group cart: %w", err)
+		}
+		owner.GroupId = group.Id
+		if err := tx.Create(owner).Error; err != nil {
+			return fmt.Errorf("failed to add group owner: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetGroupByCode 根据邀请码查询拼单
+func (d *GroupCartDao) GetGroupByCode(ctx context.Context, code string) (*model.GroupCart, error) {
+	var group model.GroupCart
+	if err := d.db.WithContext(ctx).Where("code = ?", code).First(&group).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get group cart: %w", err)
+	}
+	return &group, nil
+}
+
+// ListUserGroups 查询用户参与的进行中或已锁定的拼单
+func (d *GroupCartDao) ListUserGroups(ctx context.Context, userId int, now time.Time) ([]model.GroupCart, error) {
+	var groups []model.GroupCart
+	err := d.db.WithContext(ctx).
+		Where("id in (?)", d.db.Model(&model.GroupMember{}).Select("group_id").Where("user_id = ?", userId)).
+		Where("status in ? and expire_time > ?", []int{enum.GroupOpen, enum.GroupLocked}, now).
+		Order("create_time desc").
+		Find(&groups).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user group carts: %w", err)
+	}
+	return groups, nil
+}
+
+// SetStatus 按原状态条件更新，避免并发操作覆盖
+func (d *GroupCartDao) SetStatus(ctx context.Context, id uint64, from, to int) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&model.GroupCart{}).
+		Where("id = ? and status = ?", id, from).
+		Updates(map[string]any{"status": to, "update_time": time.Now()})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update group cart status: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ListMembers 按加入顺序查询成员
+func (d *GroupCartDao) ListMembers(ctx context.Context, groupId uint64) ([]model.GroupMember, error) {
+	var members []model.GroupMember
+	if err := d.db.WithContext(ctx).Where("group_id = ?", groupId).Order("id asc").Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to list group members: %w", err)
+	}
+	return members, nil
+}
+
+// AddMember 加入拼单，重复加入时更新昵称
+func (d *GroupCartDao) AddMember(ctx context.Context, member *model.GroupMember) error {
+	err := d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"nickname"}),
+	}).Create(member).Error
+	if err != nil {
+		return fmt.Errorf("failed to add group member: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember 移除成员及其菜品
+func (d *GroupCartDao) RemoveMember(ctx context.Context, groupId uint64, userId int) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ? and user_id = ?", groupId, userId).Delete(&model.GroupCartItem{}).Error; err != nil {
+			return fmt.Errorf("failed to delete member items: %w", err)
+		}
+		if err := tx.Where("group_id = ? and user_id = ?", groupId, userId).Delete(&model.GroupMember{}).Error; err != nil {
+			return fmt.Errorf("failed to remove group member: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListItems 按成员与添加时间排序查询菜品
+func (d *GroupCartDao) ListItems(ctx context.Context, groupId uint64) ([]model.GroupCartItem, error) {
+	var items []model.GroupCartItem
+	if err := d.db.WithContext(ctx).Where("group_id = ?", groupId).Order("user_id asc, id asc").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to list group items: %w", err)
+	}
+	return items, nil
+}
+
+// AddItem 添加菜品，已有相同菜品与口味时数量加一
+func (d *GroupCartDao) AddItem(ctx context.Context, item model.GroupCartItem) (bool, error) {
+	var existing model.GroupCartItem
+	err := d.itemQuery(ctx, item).First(&existing).Error
+	if err == nil {
+		err = d.db.WithContext(ctx).Model(&existing).Update("number", gorm.Expr("number + 1")).Error
+		if err != nil {
+			return false, fmt.Errorf("failed to increase group item: %w", err)
+		}
+		return true, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, fmt.Errorf("failed to get group item: %w", err)
+	}
+	// 新增时按当前售价记录单价
+	if item.DishId != 0 {
+		var dish model.Dish
+		if err = d.db.WithContext(ctx).Where("id = ? and status = ?", item.DishId, enum.ENABLE).First(&dish).Error; err != nil {
+			return false, ignoreNotFound(err)
+		}
+		item.Name, item.Image, item.Amount = dish.Name, dish.Image, dish.Price
+	} else {
+		var setmeal model.SetMeal
+		if err = d.db.WithContext(ctx).Where("id = ? and status = ?", item.SetmealId, enum.ENABLE).First(&setmeal).Error; err != nil {
+			return false, ignoreNotFound(err)
+		}
+		item.Name, item.Image, item.Amount = setmeal.Name, setmeal.Image, setmeal.Price
+	}
+	item.Number = 1
+	if err = d.db.WithContext(ctx).Create(&item).Error; err != nil {
+		return false, fmt.Errorf("failed to insert group item: %w", err)
+	}
+	return true, nil
+}
+
+// SubtractItem 数量减一，为 1 时删除
+func (d *GroupCartDao) SubtractItem(ctx context.Context, item model.GroupCartItem) (bool, error) {
+	var existing model.GroupCartItem
+	if err := d.itemQuery(ctx, item).First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get group item: %w", err)
+	}
+	var err error
+	if existing.Number <= 1 {
+		err = d.db.WithContext(ctx).Delete(&existing).Error
+	} else {
+		err = d.db.WithContext(ctx).Model(&existing).Update("number", gorm.Expr("number - 1")).Error
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to decrease group item: %w", err)
+	}
+	return true, nil
+}
+
+func (d *GroupCartDao) itemQuery(ctx context.Context, item model.GroupCartItem) *gorm.DB {
+	return d.db.WithContext(ctx).
+		Where("group_id = ? and user_id = ?", item.GroupId, item.UserId).
+		Where("dish_id = ? and setmeal_id = ? and dish_flavor = ?", item.DishId, item.SetmealId, item.DishFlavor)
+}
+
+// errGroupNotLocked 下单时拼单已被解锁或取消，回滚事务
+var errGroupNotLocked = errors.New("group cart is no longer locked")
+
+// errGroupOrderClosed 取消 AA 订单时订单已支付或已取消，回滚事务
+var errGroupOrderClosed = errors.New("group order is no longer pending payment")
+
+// Checkout 创建订单并关闭拼单，拼单已不是锁定状态时回滚
+func (d *GroupCartDao) Checkout(ctx context.Context, group *model.GroupCart, order *model.Order, details []model.OrderDetail, payments []model.GroupPayment) (bool, error) {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(order).Error; err != nil {
+			return fmt.Errorf("failed to create group order: %w", err)
+		}
+		for i := range details {
+			details[i].OrderId = order.Id
+		}
+		if err := tx.Create(&details).Error; err != nil {
+			return fmt.Errorf("failed to create group order details: %w", err)
+		}
+		for i := range payments {
+			payments[i].GroupId, payments[i].OrderId = group.Id, order.Id
+		}
+		if len(payments) > 0 {
+			if err := tx.Create(&payments).Error; err != nil {
+				return fmt.Errorf("failed to create group payments: %w", err)
+			}
+		}
+		result := tx.Model(&model.GroupCart{}).
+			Where("id = ? and status = ?", group.Id, enum.GroupLocked).
+			Updates(map[string]any{"status": enum.GroupOrdered, "order_id": order.Id, "update_time": time.Now()})
+		if result.Error != nil {
+			return fmt.Errorf("failed to close group cart: %w", result.Error)
+		}
+		if result.RowsAffected != 1 {
+			return errGroupNotLocked
+		}
+		return nil
+	})
+	if errors.Is(err, errGroupNotLocked) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	group.Status, group.OrderId = enum.GroupOrdered, order.Id
+	return true, nil
+}
+
+// ListPayments 查询拼单的分账记录
+func (d *GroupCartDao) ListPayments(ctx context.Context, groupId uint64) ([]model.GroupPayment, error) {
+	var payments []model.GroupPayment
+	if err := d.db.WithContext(ctx).Where("group_id = ?", groupId).Order("id asc").Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to list group payments: %w", err)
+	}
+	return payments, nil
+}
+
+// PayShare 标记成员份额已支付并统计剩余未支付份数，全部支付后在同一事务内将订单改为 status
+// 先锁定订单行，同一订单的成员支付与超时取消依次执行，最后两个成员同时支付时后提交的一方能看到前者已支付
+func (d *GroupCartDao) PayShare(ctx context.Context, groupId uint64, orderId int, userId int, status int, now time.Time) (bool, int64, error) {
+	var (
+		paid   bool
+		unpaid int64
+	)
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order model.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").
+			Where("id = ?", orderId).First(&order).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return fmt.Errorf("failed to lock group order: %w", err)
+		}
+		if order.Status != enum.PendingPayment {
+			return nil
+		}
+		result := tx.Model(&model.GroupPayment{}).
+			Where("group_id = ? and order_id = ? and user_id = ? and pay_status = ?", groupId, orderId, userId, enum.UnPaid).
+			Updates(map[string]any{"pay_status": enum.Paid, "pay_time": model.LocalTime(now)})
+		if result.Error != nil {
+			return fmt.Errorf("failed to pay group share: %w", result.Error)
+		}
+		paid = result.RowsAffected == 1
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&model.GroupPayment{}).
+			Where("group_id = ? and pay_status = ?", groupId, enum.UnPaid).
+			Count(&unpaid).Error; err != nil {
+			return fmt.Errorf("failed to count unpaid shares: %w", err)
+		}
+		if !paid || unpaid > 0 {
+			return nil
+		}
+		if err := tx.Model(&model.Order{}).Where("id = ?", orderId).
+			Updates(map[string]any{"status": status, "pay_status": enum.Paid, "checkout_time": model.LocalTime(now)}).Error; err != nil {
+			return fmt.Errorf("failed to mark group order paid: %w", err)
+		}
+		return nil
+	})
+	return paid, unpaid, err
+}
+
+// ListUnpaidSplitOrders 下单时间早于 before 仍待支付的 AA 订单
+func (d *GroupCartDao) ListUnpaidSplitOrders(ctx context.Context, before time.Time) ([]model.Order, error) {
+	var orders []model.Order
+	if err := d.db.WithContext(ctx).
+		Where("status = ? and order_time < ?", enum.PendingPayment, model.LocalTime(before)).
+		Where("exists (select 1 from group_payment p where p.order_id = orders.id)").
+		Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("failed to list unpaid split orders: %w", err)
+	}
+	return orders, nil
+}
+
+// CancelSplitOrder 取消待支付的 AA 订单，并将已支付的份额标记为已退款
+func (d *GroupCartDao) CancelSplitOrder(ctx context.Context, orderId int, reason string, now time.Time) ([]model.GroupPayment, bool, error) {
+	var refunds []model.GroupPayment
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Order{}).
+			Where("id = ? and status = ?", orderId, enum.PendingPayment).
+			Updates(map[string]any{"status": enum.Cancelled, "cancel_reason": reason, "cancel_time": model.LocalTime(now)})
+		if result.Error != nil {
+			return fmt.Errorf("failed to cancel split order: %w", result.Error)
+		}
+		if result.RowsAffected != 1 {
+			return errGroupOrderClosed
+		}
+		if err := tx.Where("order_id = ? and pay_status = ?", orderId, enum.Paid).Find(&refunds).Error; err != nil {
+			return fmt.Errorf("failed to list paid shares: %w", err)
+		}
+		if len(refunds) == 0 {
+			return nil
+		}
+		if err := tx.Model(&model.GroupPayment{}).
+			Where("order_id = ? and pay_status = ?", orderId, enum.Paid).
+			Update("pay_status", enum.Refund).Error; err != nil {
+			return fmt.Errorf("failed to refund group shares: %w", err)
+		}
+		return nil
+	})
+	if errors.Is(err, errGroupOrderClosed) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return refunds, true, nil
+}
+
+// ignoreNotFound 记录不存在时返回 nil
+func ignoreNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,292 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"takeout/common/enum"
+	"takeout/internal/model"
+	"takeout/repository"
+	"time"
+)
+
+type KitchenDao struct {
+	db *gorm.DB
+}
+
+func NewKitchenDao(db *gorm.DB) repository.KitchenRepo {
+	return &KitchenDao{db: db}
+}
+
+// ListStations 查询全部工位及其分类
+func (d *KitchenDao) ListStations(ctx context.Context) ([]model.KitchenStation, error) {
+	var (
+		stations []model.KitchenStation
+		mappings []model.KitchenStationCategory
+	)
+	if err := d.db.WithContext(ctx).Order("sort asc, id asc").Find(&stations).Error; err != nil {
+		return nil, fmt.Errorf("failed to list kitchen stations: %w", err)
+	}
+	if err := d.db.WithContext(ctx).Order("category_id asc").Find(&mappings).Error; err != nil {
+		return nil, fmt.Errorf("failed to list station categories: %w", err)
+	}
+	index := make(map[uint64]int, len(stations))
+	for i := range stations {
+		stations[i].CategoryIds = []uint64{}
+		index[stations[i].Id] = i
+	}
+	for _, m := range mappings {
+		if i, ok := index[m.StationId]; ok {
+			stations[i].CategoryIds = append(stations[i].CategoryIds, m.CategoryId)
+		}
+	}
+	return stations, nil
+}
+
+// GetStation 根据id查询工位
+func (d *KitchenDao) GetStation(ctx context.Context, id uint64) (*model.KitchenStation, error) {
+	var station model.KitchenStation
+	if err := d.db.WithContext(ctx).First(&station, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get kitchen station: %w", err)
+	}
+	return &station, nil
+}
+
+// InsertStation 新增工位
+func (d *KitchenDao) InsertStation(ctx context.Context, station *model.KitchenStation) error {
+	if err := d.db.WithContext(ctx).Create(station).Error; err != nil {
+		return fmt.Errorf("failed to insert kitchen station: %w", err)
+	}
+	return nil
+}
+
+// UpdateStation 修改工位
+func (d *KitchenDao) UpdateStation(ctx context.Context, station *model.KitchenStation) error {
+	if err := d.db.WithContext(ctx).Model(station).
+		Select("name", "sort", "status", "update_time").Updates(station).Error; err != nil {
+		return fmt.Errorf("failed to update kitchen station: %w", err)
+	}
+	return nil
+}
+
+// DeleteStation 删除工位及其分类分配
+func (d *KitchenDao) DeleteStation(ctx context.Context, id uint64) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("station_id = ?", id).Delete(&model.KitchenStationCategory{}).Error; err != nil {
+			return fmt.Errorf("failed to delete station categories: %w", err)
+		}
+		if err := tx.Delete(&model.KitchenStation{}, id).Error; err != nil {
+			return fmt.Errorf("failed to delete kitchen station: %w", err)
+		}
+		return nil
+	})
+}
+
+// SetStationCategories 重新分配工位的分类
+func (d *KitchenDao) SetStationCategories(ctx context.Context, stationId uint64, categoryIds []uint64) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("station_id = ?", stationId).Delete(&model.KitchenStationCategory{}).Error; err != nil {
+			return fmt.Errorf("failed to clear station categories: %w", err)
+		}
+		if len(categoryIds) == 0 {
+			return nil
+		}
+		if err := tx.Where("category_id in ?", categoryIds).Delete(&model.KitchenStationCategory{}).Error; err != nil {
+			return fmt.Errorf("failed to release categories: %w", err)
+		}
+		mappings := make([]model.KitchenStationCategory, len(categoryIds))
+		for i, categoryId := range categoryIds {
+			mappings[i] = model.KitchenStationCategory{CategoryId: categoryId, StationId: stationId}
+		}
+		if err := tx.Create(&mappings).Error; err != nil {
+			return fmt.Errorf("failed to insert station categories: %w", err)
+		}
+		return nil
+	})
+}
+
+// CountOpenItems 统计工位上进行中订单的未出品数量
+func (d *KitchenDao) CountOpenItems(ctx context.Context, stationId uint64) (int64, error) {
+	var count int64
+	err := d.db.WithContext(ctx).Model(&model.KitchenItem{}).
+		Joins("join orders on orders.id = kitchen_item.order_id").
+		Where("kitchen_item.station_id = ? and kitchen_item.status <> ?", stationId, enum.KitchenDone).
+		Where("orders.status = ?", enum.Confirmed).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count open kitchen items: %w", err)
+	}
+	return count, nil
+}
+
+// BuildItems 菜品明细按菜品分类取工位，套餐明细拆为套餐内菜品并按份数展开数量
+func (d *KitchenDao) BuildItems(ctx context.Context, orderId int) ([]model.KitchenItem, error) {
+	var dishes, setmeals []model.KitchenItem
+	err := d.db.WithContext(ctx).Table("order_detail od").
+		Select("od.order_id, od.id as order_detail_id, od.dish_id, od.name, od.dish_flavor, od.number, "+
+			"coalesce(sc.station_id, 0) as station_id").
+		Joins("left join dish d on d.id = od.dish_id").
+		Joins("left join kitchen_station_category sc on sc.category_id = d.category_id").
+		Where("od.order_id = ? and od.dish_id is not null and od.dish_id <> 0", orderId).
+		Order("od.id asc").
+		Scan(&dishes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to build dish kitchen items: %w", err)
+	}
+	err = d.db.WithContext(ctx).Table("order_detail od").
+		Select("od.order_id, od.id as order_detail_id, sd.dish_id, sd.name, od.name as setmeal_name, "+
+			"od.number * sd.copies as number, coalesce(sc.station_id, 0) as station_id").
+		Joins("join setmeal_dish sd on sd.setmeal_id = od.setmeal_id").
+		Joins("left join dish d on d.id = sd.dish_id").
+		Joins("left join kitchen_station_category sc on sc.category_id = d.category_id").
+		Where("od.order_id = ? and od.setmeal_id is not null and od.setmeal_id <> 0", orderId).
+		Order("od.id asc, sd.id asc").
+		Scan(&setmeals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to build setmeal kitchen items: %w", err)
+	}
+	return append(dishes, setmeals...), nil
+}
+
+// CreateTicket 写入后厨单及出品项
+func (d *KitchenDao) CreateTicket(ctx context.Context, order *model.KitchenOrder, items []model.KitchenItem) (bool, error) {
+	created := false
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.KitchenOrder{}).Where("order_id = ?", order.OrderId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			if err := tx.Create(&items).Error; err != nil {
+				return err
+			}
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to create kitchen ticket: %w", err)
+	}
+	return created, nil
+}
+
+// ListUndispatched 查询已接单但没有后厨单的订单
+func (d *KitchenDao) ListUndispatched(ctx context.Context) ([]model.Order, error) {
+	var orders []model.Order
+	err := d.db.WithContext(ctx).
+		Where("status = ?", enum.Confirmed).
+		Where("not exists (select 1 from kitchen_order ko where ko.order_id = orders.id)").
+		Order("id asc").
+		Find(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list undispatched orders: %w", err)
+	}
+	return orders, nil
+}
+
+// ListOpenOrders 查询进行中的后厨单
+func (d *KitchenDao) ListOpenOrders(ctx context.Context, stationId uint64) ([]model.KitchenOrder, error) {
+	var orders []model.KitchenOrder
+	query := d.db.WithContext(ctx).Model(&model.KitchenOrder{}).
+		Joins("join orders on orders.id = kitchen_order.order_id").
+		Where("orders.status = ?", enum.Confirmed)
+	if stationId != 0 {
+		query = query.Where("exists (select 1 from kitchen_item ki where ki.order_id = kitchen_order.order_id "+
+			"and ki.station_id = ? and ki.status <> ?)", stationId, enum.KitchenDone)
+	}
+	if err := query.Order("kitchen_order.create_time asc").Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("failed to list open kitchen orders: %w", err)
+	}
+	return orders, nil
+}
+
+// GetKitchenOrders 根据订单id查询后厨单
+func (d *KitchenDao) GetKitchenOrders(ctx context.Context, orderIds []int) ([]model.KitchenOrder, error) {
+	var orders []model.KitchenOrder
+	if len(orderIds) == 0 {
+		return orders, nil
+	}
+	if err := d.db.WithContext(ctx).Where("order_id in ?", orderIds).Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("failed to get kitchen orders: %w", err)
+	}
+	return orders, nil
+}
+
+// SetOrderStatus 更新后厨单状态，全部出品时记录出品时间
+func (d *KitchenDao) SetOrderStatus(ctx context.Context, orderId int, status int, readyTime time.Time) error {
+	values := map[string]any{"status": status, "ready_time": nil}
+	if status == enum.KitchenDone {
+		values["ready_time"] = readyTime
+	}
+	if err := d.db.WithContext(ctx).Model(&model.KitchenOrder{}).
+		Where("order_id = ?", orderId).Updates(values).Error; err != nil {
+		return fmt.Errorf("failed to set kitchen order status: %w", err)
+	}
+	return nil
+}
+
+// ListItems 查询订单的出品项
+func (d *KitchenDao) ListItems(ctx context.Context, orderIds []int, stationId uint64) ([]model.KitchenItem, error) {
+	var items []model.KitchenItem
+	if len(orderIds) == 0 {
+		return items, nil
+	}
+	query := d.db.WithContext(ctx).Where("order_id in ?", orderIds)
+	if stationId != 0 {
+		query = query.Where("station_id = ?", stationId)
+	}
+	if err := query.Order("id asc").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to list kitchen items: %w", err)
+	}
+	return items, nil
+}
+
+// GetItems 根据id查询出品项
+func (d *KitchenDao) GetItems(ctx context.Context, ids []uint64) ([]model.KitchenItem, error) {
+	var items []model.KitchenItem
+	if len(ids) == 0 {
+		return items, nil
+	}
+	if err := d.db.WithContext(ctx).Where("id in ?", ids).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get kitchen items: %w", err)
+	}
+	return items, nil
+}
+
+// UpdateItemStatus 推进出品项状态
+func (d *KitchenDao) UpdateItemStatus(ctx context.Context, ids []uint64, status int, at time.Time) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	query := d.db.WithContext(ctx).Model(&model.KitchenItem{}).Where("id in ?", ids)
+	var values map[string]any
+	switch status {
+	case enum.KitchenStarted:
+		query = query.Where("status = ?", enum.KitchenWaiting)
+		values = map[string]any{"status": status, "start_time": at}
+	case enum.KitchenDone:
+		query = query.Where("status <> ?", enum.KitchenDone)
+		values = map[string]any{
+			"status":     status,
+			"done_time":  at,
+			"start_time": gorm.Expr("coalesce(start_time, ?)", at),
+		}
+	default:
+		return 0, fmt.Errorf("unsupported kitchen item status %d", status)
+	}
+	result := query.Updates(values)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to update kitchen items: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
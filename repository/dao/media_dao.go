@@ -0,0 +1,166 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"takeout/common"
+	"takeout/common/cart"
+	"takeout/global"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/model"
+	"time"
+)
+
+type MediaDao struct {
+	db *gorm.DB
+}
+
+// Save key 唯一，重复上传相同内容时只清除未引用时间
+func (d *MediaDao) Save(ctx context.Context, media *model.Media) error {
+	err := d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "object_key"}},
+		DoUpdates: clause.Assignments(map[string]any{"unused_since": nil}),
+	}).Create(media).Error
+	if err != nil {
+		return fmt.Errorf("failed to save media: %w", err)
+	}
+	return nil
+}
+
+// PageQuery 媒体库分页查询，最新上传的在前
+func (d *MediaDao) PageQuery(ctx context.Context, dto request.MediaPageQueryDTO) (*common.PageResult, error) {
+	var (
+		pageResult common.PageResult
+		medias     []model.Media
+	)
+	query := d.db.WithContext(ctx).Model(&model.Media{})
+	if dto.Category != "" {
+		query = query.Where("category = ?", dto.Category)
+	}
+	if dto.Unused == "1" {
+		query = query.Where("ref_count = 0")
+	}
+	if err := query.Count(&pageResult.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count media: %w", err)
+	}
+	if err := query.Scopes(pageResult.Paginate(&dto.Page, &dto.PageSize)).
+		Order("create_time desc, id desc").
+		Find(&medias).Error; err != nil {
+		return nil, fmt.Errorf("failed to query media: %w", err)
+	}
+	pageResult.Records = medias
+	return &pageResult, nil
+}
+
+func (d *MediaDao) ListAll(ctx context.Context) ([]model.Media, error) {
+	var medias []model.Media
+	if err := d.db.WithContext(ctx).Order("id asc").Find(&medias).Error; err != nil {
+		return nil, fmt.Errorf("failed to list media: %w", err)
+	}
+	return medias, nil
+}
+
+// ListReferences 评价与售后的图片以逗号分隔，拆分后返回
+// 订单明细、拼单、售后明细与购物车保存了下单时的图片快照，菜品换图后快照仍指向旧文件，同样计为引用
+func (d *MediaDao) ListReferences(ctx context.Context) ([]string, error) {
+	db := d.db.WithContext(ctx)
+	var refs []string
+	for table, column := range map[string]string{"dish": "image", "setmeal": "image", "user": "avatar"} {
+		var values []string
+		if err := db.Table(table).Where(column+" <> ''").Pluck(column, &values).Error; err != nil {
+			return nil, fmt.Errorf("failed to list %s references: %w", table, err)
+		}
+		refs = append(refs, values...)
+	}
+	// 快照表数据量大，同一图片只取一次
+	for _, table := range []string{"order_detail", "group_cart_item", "after_sale_item", "shopping_cart"} {
+		var values []string
+		if err := db.Table(table).Where("image <> ''").Distinct("image").Pluck("image", &values).Error; err != nil {
+			return nil, fmt.Errorf("failed to list %s references: %w", table, err)
+		}
+		refs = append(refs, values...)
+	}
+	for _, table := range []string{"review", "after_sale"} {
+		var values []string
+		if err := db.Table(table).Where("images <> ''").Pluck("images", &values).Error; err != nil {
+			return nil, fmt.Errorf("failed to list %s references: %w", table, err)
+		}
+		for _, images := range values {
+			for _, image := range strings.Split(images, ",") {
+				if image = strings.TrimSpace(image); image != "" {
+					refs = append(refs, image)
+				}
+			}
+		}
+	}
+	images, err := cartImages()
+	if err != nil {
+		return nil, err
+	}
+	return append(refs, images...), nil
+}
+
+// cartImages Redis 购物车中尚未写回 MySQL 的行图片
+func cartImages() ([]string, error) {
+	var (
+		images []string
+		cursor uint64
+	)
+	for {
+		keys, next, err := global.RedisClient.Scan(cursor, cart.UserKey+"*", 500).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shopping carts: %w", err)
+		}
+		for _, key := range keys {
+			values, err := global.RedisClient.HGetAll(key).Result()
+			if err != nil {
+				return nil, fmt.Errorf("failed to list shopping cart references: %w", err)
+			}
+			for field, value := range values {
+				if !strings.HasPrefix(field, cart.MetaPrefix) {
+					continue
+				}
+				var line struct {
+					Image string `json:"image"`
+				}
+				if json.Unmarshal([]byte(value), &line) == nil && line.Image != "" {
+					images = append(images, line.Image)
+				}
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return images, nil
+		}
+	}
+}
+
+func (d *MediaDao) UpdateRefCount(ctx context.Context, id uint64, count int, now time.Time) error {
+	unusedSince := any(nil)
+	if count == 0 {
+		unusedSince = gorm.Expr("IFNULL(unused_since, ?)", now)
+	}
+	err := d.db.WithContext(ctx).Model(&model.Media{}).Where("id = ?", id).
+		Updates(map[string]any{"ref_count": count, "unused_since": unusedSince}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update media ref count: %w", err)
+	}
+	return nil
+}
+
+func (d *MediaDao) DeleteUnused(ctx context.Context, id uint64, before time.Time) (bool, error) {
+	result := d.db.WithContext(ctx).
+		Where("id = ? and ref_count = 0 and unused_since <= ?", id, before).
+		Delete(&model.Media{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete media: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func NewMediaDao(db *gorm.DB) *MediaDao {
+	return &MediaDao{db: db}
+}
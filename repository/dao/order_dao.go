@@ -3,6 +3,7 @@ package dao
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/ulule/deepcopier"
 	"gorm.io/gorm"
 	"log"
@@ -27,7 +28,7 @@ func NewOrderDao() repository.OrderRepo {
 	return &OrderDao{
 		db:              global.DB,
 		AddressBookDao:  AddressBookDao{db: global.DB},
-		ShoppingCartDao: ShoppingCartDao{db: global.DB},
+		ShoppingCartDao: newShoppingCartDao(global.DB),
 	}
 }
 
@@ -49,11 +50,19 @@ func (d OrderDao) OrderSubmit(ctx context.Context, data request.OrderSubmitDTO,
 		order            model.Order
 		err              error
 	)
-	if addressBook, err = d.AddressBookDao.GetAddressById(ctx, uint64(data.AddressBookId)); err != nil {
+	// 自取与堂食订单无需收货地址
+	if data.OrderType == 0 {
+		data.OrderType = enum.OrderTypeDelivery
+	}
+	if data.OrderType == enum.OrderTypeDelivery {
+		if addressBook, err = d.AddressBookDao.GetAddressById(ctx, uint64(data.AddressBookId)); err != nil {
+			return response.OrderSubmitVO{}, err
+		}
+	}
+	if shoppingCartList, err = d.ShoppingCartDao.List(ctx, userId); err != nil {
 		return response.OrderSubmitVO{}, err
 	}
-	shoppingCartList = d.ShoppingCartDao.queryShoppingCart(ctx, model.ShoppingCart{UserId: userId})
-	if shoppingCartList == nil || len(shoppingCartList) == 0 {
+	if len(shoppingCartList) == 0 {
 		global.Log.Warn("错误的购物车")
 		return response.OrderSubmitVO{}, nil
 	}
@@ -84,7 +93,7 @@ func (d OrderDao) OrderSubmit(ctx context.Context, data request.OrderSubmitDTO,
 		return response.OrderSubmitVO{}, err
 	}
 	// 清理购物车中的数据
-	if err = d.Clean(ctx, userId); err != nil {
+	if err = d.ShoppingCartDao.Clean(ctx, userId); err != nil {
 		return response.OrderSubmitVO{}, err
 	}
 	// 封装返回结果
@@ -97,32 +106,6 @@ func (d OrderDao) OrderSubmit(ctx context.Context, data request.OrderSubmitDTO,
 
 }
 
-// RepetitionOrder 再来一单
-func (d OrderDao) RepetitionOrder(ctx context.Context, orderId string, userId int) error {
-	// 根据订单id查询当前订单详情
-	orderDetailList, err := d.GetOrderDetailByOrderId(orderId)
-	if err != nil {
-		return err
-	}
-	// 将订单详情对象转换为购物车对象
-	var shoppingCartList []model.ShoppingCart
-	for _, orderDetail := range orderDetailList {
-		// 将原订单详情里面的菜品信息重新复制到购物车对象中
-		var shoppingCart model.ShoppingCart
-		if err = deepcopier.Copy(&orderDetail).To(&shoppingCart); err != nil {
-			return err
-		}
-		shoppingCart.Id = 0
-		shoppingCart.UserId = userId
-		shoppingCartList = append(shoppingCartList, shoppingCart)
-	}
-	// 将购物车对象批量添加到数据库
-	if err = d.ShoppingCartDao.InsertBatchShoppingCart(ctx, shoppingCartList); err != nil {
-		return err
-	}
-	return nil
-}
-
 // HistoryOrders 分页查询历史订单
 func (d OrderDao) HistoryOrders(ctx context.Context, data request.PageQueryOrderDTO, userId int) (*common.PageResult, error) {
 	var (
@@ -281,8 +264,8 @@ func (d OrderDao) OrderRejection(ctx context.Context, data request.OrderRejectio
 func (d OrderDao) OrderDelivery(orderId string) error {
 	// 根据id查询订单
 	order, _ := d.GetOrderById(orderId)
-	// 校验订单是否存在，并且状态为Confirmed
-	if order == nil || order.Status != enum.Confirmed {
+	// 校验订单是否存在，并且状态为Confirmed，自取与堂食订单不经过派送
+	if order == nil || order.Status != enum.Confirmed || !order.Delivers() {
 		return errors.New("订单不存在，或者订单不可派送")
 	}
 	// 更新订单状态,状态转为派送中
@@ -297,8 +280,8 @@ func (d OrderDao) OrderDelivery(orderId string) error {
 func (d OrderDao) OrderComplete(orderId string) error {
 	// 根据id查询订单
 	order, _ := d.GetOrderById(orderId)
-	// 校验订单是否存在，并且状态为DeliveryInProgress
-	if order == nil || order.Status != enum.DeliveryInProgress {
+	// 校验订单是否存在，外卖订单需为DeliveryInProgress，自取与堂食订单接单后即可完成
+	if order == nil || order.Id == 0 || !completable(order) {
 		return errors.New("订单不存在，或者订单不可完成")
 	}
 	// 更新订单状态,状态转为完成
@@ -310,6 +293,102 @@ func (d OrderDao) OrderComplete(orderId string) error {
 	return nil
 }
 
+func completable(order *model.Order) bool {
+	if order.Delivers() {
+		return order.Status == enum.DeliveryInProgress
+	}
+	return order.Status == enum.Confirmed
+}
+
+// GetOrderByPickupCode 根据取餐码查询 since 之后下单或预约在 since 之后、待取餐的订单，不存在时返回 nil
+func (d OrderDao) GetOrderByPickupCode(ctx context.Context, pickupCode string, since time.Time) (*model.Order, error) {
+	var order model.Order
+	err := global.DB.WithContext(ctx).
+		Where("pickup_code = ? and status = ?", pickupCode, enum.Confirmed).
+		Where("order_time >= ? or estimated_delivery_time >= ?", model.LocalTime(since), model.LocalTime(since)).
+		Order("order_time desc").
+		First(&order).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get order by pickup code: %w", err)
+	}
+	return &order, nil
+}
+
+// CountSlotOrders 按送达时段统计预约订单数，待付款的订单同样占用名额，超时取消后释放
+func (d OrderDao) CountSlotOrders(ctx context.Context, from, to time.Time) (map[int64]int, error) {
+	var rows []struct {
+		Slot  model.LocalTime
+		Total int
+	}
+	err := d.db.WithContext(ctx).Model(&model.Order{}).
+		Select("estimated_delivery_time as slot, count(*) as total").
+		Where("delivery_status = ? and estimated_delivery_time >= ? and estimated_delivery_time < ?",
+			enum.DeliverAtTime, model.LocalTime(from), model.LocalTime(to)).
+		Where("status <> ?", enum.Cancelled).
+		Group("estimated_delivery_time").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count slot orders: %w", err)
+	}
+	counts := make(map[int64]int, len(rows))
+	for _, row := range rows {
+		counts[time.Time(row.Slot).Unix()] = row.Total
+	}
+	return counts, nil
+}
+
+// ListDueScheduled 查询到期待推送的预约订单
+func (d OrderDao) ListDueScheduled(ctx context.Context, before time.Time) ([]model.Order, error) {
+	var orders []model.Order
+	err := d.db.WithContext(ctx).
+		Where("status = ? and estimated_delivery_time <= ?", enum.Scheduled, model.LocalTime(before)).
+		Order("estimated_delivery_time asc").
+		Find(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due scheduled orders: %w", err)
+	}
+	return orders, nil
+}
+
+// ReleaseScheduled 按状态条件更新，多个实例同时处理时只有一个成功
+func (d OrderDao) ReleaseScheduled(ctx context.Context, id int, now time.Time) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&model.Order{}).
+		Where("id = ? and status = ?", id, enum.Scheduled).
+		Updates(map[string]any{"status": enum.ToBeConfirmed, "release_time": model.LocalTime(now)})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to release scheduled order: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ListUnconfirmedScheduled 查询临近时段仍未接单的预约订单
+func (d OrderDao) ListUnconfirmedScheduled(ctx context.Context, before time.Time) ([]model.Order, error) {
+	var orders []model.Order
+	err := d.db.WithContext(ctx).
+		Where("status = ? and delivery_status = ? and remind_time is null", enum.ToBeConfirmed, enum.DeliverAtTime).
+		Where("estimated_delivery_time <= ?", model.LocalTime(before)).
+		Order("estimated_delivery_time asc").
+		Find(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unconfirmed scheduled orders: %w", err)
+	}
+	return orders, nil
+}
+
+// MarkReminded 记录提醒时间，多个实例同时处理时只有一个成功
+func (d OrderDao) MarkReminded(ctx context.Context, id int, now time.Time) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&model.Order{}).
+		Where("id = ? and remind_time is null", id).
+		Update("remind_time", model.LocalTime(now))
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark scheduled order reminded: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // OrderConditionSearch 订单搜索
 func (d OrderDao) OrderConditionSearch(ctx context.Context, data request.OrderPageQueryDTO) (*common.PageResult, error) {
 	var (
@@ -324,6 +403,9 @@ func (d OrderDao) OrderConditionSearch(ctx context.Context, data request.OrderPa
 	if data.Status != 0 {
 		query = query.Where("status = ?", data.Status)
 	}
+	if data.OrderType != 0 {
+		query = query.Where("order_type = ?", data.OrderType)
+	}
 	if data.Number != "" {
 		query = query.Where("number like ?", "%"+data.Number+"%")
 	}
@@ -378,7 +460,7 @@ func (d OrderDao) OrderConditionSearch(ctx context.Context, data request.OrderPa
 // OrderStatistics 各个状态的订单数量统计
 func (d OrderDao) OrderStatistics(ctx context.Context) (response.OrderStatisticsVO, error) {
 	// 根据状态，分别查询出待接单、待派送、派送中的订单数量
-	toBeConfirmed, confirmed, deliveryInProgress := int64(0), int64(0), int64(0)
+	toBeConfirmed, confirmed, deliveryInProgress, scheduled := int64(0), int64(0), int64(0), int64(0)
 	if err := global.DB.Table("orders").
 		WithContext(ctx).
 		Where("status = ?", enum.ToBeConfirmed).
@@ -397,11 +479,18 @@ func (d OrderDao) OrderStatistics(ctx context.Context) (response.OrderStatistics
 		Count(&deliveryInProgress).Error; err != nil {
 		return response.OrderStatisticsVO{}, err
 	}
+	if err := global.DB.Table("orders").
+		WithContext(ctx).
+		Where("status = ?", enum.Scheduled).
+		Count(&scheduled).Error; err != nil {
+		return response.OrderStatisticsVO{}, err
+	}
 	// 将查询出的数据封装到orderStatisticsVO中响应
 	return response.OrderStatisticsVO{
 		ToBeConfirmed:      int(toBeConfirmed),
 		Confirmed:          int(confirmed),
 		DeliveryInProgress: int(deliveryInProgress),
+		Scheduled:          int(scheduled),
 	}, nil
 }
 
@@ -416,13 +505,24 @@ func GetOrderDishes(orderDetails []model.OrderDetail) string {
 }
 
 // GetOrderByStatusAndOrderTime 根据状态和下单时间查询订单
+// AA 支付的订单由拼单的超时任务取消并退还已付份额
 func (d OrderDao) GetOrderByStatusAndOrderTime(status int, orderTime model.LocalTime) ([]model.Order, error) {
 	var orders []model.Order
 	if err := d.db.
 		Where("status = ?", status).
 		Where("order_time < ?", orderTime).
+		Where("not exists (select 1 from group_payment p where p.order_id = orders.id)").
 		Find(&orders).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return []model.Order{}, err
 	}
 	return orders, nil
 }
+
+// IsSplitBill 订单存在成员份额时为 AA 支付
+func (d OrderDao) IsSplitBill(ctx context.Context, orderId int) (bool, error) {
+	var count int64
+	if err := d.db.WithContext(ctx).Model(&model.GroupPayment{}).Where("order_id = ?", orderId).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check split bill: %w", err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,199 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"takeout/common"
+	"takeout/common/enum"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/model"
+	"takeout/repository"
+	"time"
+)
+
+type PrintDao struct {
+	db *gorm.DB
+}
+
+func NewPrintDao(db *gorm.DB) repository.PrintRepo {
+	return &PrintDao{db: db}
+}
+
+// ListPrinters 查询打印机
+func (d *PrintDao) ListPrinters(ctx context.Context, enabledOnly bool) ([]model.Printer, error) {
+	var printers []model.Printer
+	query := d.db.WithContext(ctx)
+	if enabledOnly {
+		query = query.Where("status = ?", enum.ENABLE)
+	}
+	if err := query.Order("id asc").Find(&printers).Error; err != nil {
+		return nil, fmt.Errorf("failed to list printers: %w", err)
+	}
+	return printers, nil
+}
+
+// GetPrinter 根据id查询打印机
+func (d *PrintDao) GetPrinter(ctx context.Context, id uint64) (*model.Printer, error) {
+	var printer model.Printer
+	if err := d.db.WithContext(ctx).First(&printer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get printer: %w", err)
+	}
+	return &printer, nil
+}
+
+// InsertPrinter 新增打印机
+func (d *PrintDao) InsertPrinter(ctx context.Context, printer *model.Printer) error {
+	if err := d.db.WithContext(ctx).Create(printer).Error; err != nil {
+		return fmt.Errorf("failed to insert printer: %w", err)
+	}
+	return nil
+}
+
+// UpdatePrinter 修改打印机，零值字段同样写入
+func (d *PrintDao) UpdatePrinter(ctx context.Context, printer *model.Printer) error {
+	err := d.db.WithContext(ctx).Model(printer).
+		Select("name", "driver", "address", "format", "width", "encoding", "cut", "copies",
+			"kinds", "auto_print", "title", "footer", "template", "status", "update_time").
+		Updates(printer).Error
+	if err != nil {
+		return fmt.Errorf("failed to update printer: %w", err)
+	}
+	return nil
+}
+
+// DeletePrinter 删除打印机，未完成的任务一并删除，历史任务保留
+func (d *PrintDao) DeletePrinter(ctx context.Context, id uint64) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("printer_id = ? and status in ?", id, []int{enum.PrintPending, enum.PrintPrinting}).
+			Delete(&model.PrintJob{}).Error; err != nil {
+			return fmt.Errorf("failed to delete print jobs: %w", err)
+		}
+		if err := tx.Delete(&model.Printer{}, id).Error; err != nil {
+			return fmt.Errorf("failed to delete printer: %w", err)
+		}
+		return nil
+	})
+}
+
+// InsertJobs 批量新增打印任务
+func (d *PrintDao) InsertJobs(ctx context.Context, jobs []model.PrintJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	if err := d.db.WithContext(ctx).Create(&jobs).Error; err != nil {
+		return fmt.Errorf("failed to insert print jobs: %w", err)
+	}
+	return nil
+}
+
+// HasAutoJobs 订单是否已有接单打印任务
+func (d *PrintDao) HasAutoJobs(ctx context.Context, orderId int) (bool, error) {
+	var count int64
+	err := d.db.WithContext(ctx).Model(&model.PrintJob{}).
+		Where("order_id = ? and reprint = ?", orderId, false).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to count print jobs: %w", err)
+	}
+	return count > 0, nil
+}
+
+// PageJobs 打印任务分页查询，最新的在前
+func (d *PrintDao) PageJobs(ctx context.Context, dto request.PrintJobPageQueryDTO) (*common.PageResult, error) {
+	var (
+		pageResult common.PageResult
+		jobs       []model.PrintJob
+	)
+	query := d.db.WithContext(ctx).Model(&model.PrintJob{})
+	if dto.Status != "" {
+		query = query.Where("status = ?", dto.Status)
+	}
+	if dto.OrderId != 0 {
+		query = query.Where("order_id = ?", dto.OrderId)
+	}
+	if dto.PrinterId != 0 {
+		query = query.Where("printer_id = ?", dto.PrinterId)
+	}
+	if err := query.Count(&pageResult.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count print jobs: %w", err)
+	}
+	if err := query.Scopes(pageResult.Paginate(&dto.Page, &dto.PageSize)).
+		Order("id desc").
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to query print jobs: %w", err)
+	}
+	pageResult.Records = jobs
+	return &pageResult, nil
+}
+
+// GetJob 根据id查询打印任务
+func (d *PrintDao) GetJob(ctx context.Context, id uint64) (*model.PrintJob, error) {
+	var job model.PrintJob
+	if err := d.db.WithContext(ctx).First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get print job: %w", err)
+	}
+	return &job, nil
+}
+
+// ListDueJobs 查询到期任务，先进先出
+func (d *PrintDao) ListDueJobs(ctx context.Context, now time.Time, limit int) ([]model.PrintJob, error) {
+	var jobs []model.PrintJob
+	err := d.db.WithContext(ctx).
+		Where("status in ? and next_time <= ?", []int{enum.PrintPending, enum.PrintPrinting}, now).
+		Order("id asc").
+		Limit(limit).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due print jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// ClaimJob 以条件更新领取任务，多个实例同时处理时只有一个能领取成功
+func (d *PrintDao) ClaimJob(ctx context.Context, id uint64, now, lease time.Time) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&model.PrintJob{}).
+		Where("id = ? and status in ? and next_time <= ?", id, []int{enum.PrintPending, enum.PrintPrinting}, now).
+		Updates(map[string]any{
+			"status":    enum.PrintPrinting,
+			"attempts":  gorm.Expr("attempts + 1"),
+			"next_time": lease,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim print job: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FinishJob 保存打印结果
+func (d *PrintDao) FinishJob(ctx context.Context, job *model.PrintJob) error {
+	err := d.db.WithContext(ctx).Model(job).
+		Select("status", "attempts", "next_time", "last_error", "print_time").
+		Updates(job).Error
+	if err != nil {
+		return fmt.Errorf("failed to save print job: %w", err)
+	}
+	return nil
+}
+
+// ResetJob 失败任务重新排队，重新计算尝试次数
+func (d *PrintDao) ResetJob(ctx context.Context, id uint64, now time.Time) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&model.PrintJob{}).
+		Where("id = ? and status = ?", id, enum.PrintFailed).
+		Updates(map[string]any{
+			"status":    enum.PrintPending,
+			"attempts":  0,
+			"next_time": now,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to reset print job: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,54 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"takeout/common/enum"
+	"takeout/common/recommend"
+	"takeout/repository"
+	"time"
+)
+
+type RecommendDao struct {
+	db *gorm.DB
+}
+
+func NewRecommendDao(db *gorm.DB) repository.RecommendRepo {
+	return &RecommendDao{db: db}
+}
+
+// ListPurchases 查询某时间之后已完成订单的明细
+func (d RecommendDao) ListPurchases(ctx context.Context, since time.Time) ([]recommend.Purchase, error) {
+	var rows []struct {
+		OrderId   int
+		UserId    int
+		DishId    uint64
+		SetmealId uint64
+		Number    int
+	}
+	if err := d.db.WithContext(ctx).Table("order_detail").
+		Select("order_detail.order_id, orders.user_id, order_detail.dish_id, order_detail.setmeal_id, order_detail.number").
+		Joins("join orders on order_detail.order_id = orders.id").
+		Where("orders.status = ?", enum.Completed).
+		Where("orders.order_time >= ?", since).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list purchases: %w", err)
+	}
+	purchases := make([]recommend.Purchase, 0, len(rows))
+	for _, row := range rows {
+		item := recommend.Item{Type: recommend.TypeDish, Id: row.DishId}
+		if row.SetmealId != 0 {
+			item = recommend.Item{Type: recommend.TypeSetmeal, Id: row.SetmealId}
+		} else if row.DishId == 0 {
+			continue
+		}
+		purchases = append(purchases, recommend.Purchase{
+			OrderId: row.OrderId,
+			UserId:  row.UserId,
+			Item:    item,
+			Number:  row.Number,
+		})
+	}
+	return purchases, nil
+}
@@ -1,7 +1,9 @@
 package dao
 
 import (
+	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"takeout/common/enum"
 	"takeout/internal/api/admin/response"
 	"takeout/internal/api/user/request"
@@ -9,6 +11,13 @@ import (
 	"time"
 )
 
+// notRemake 售后重做的零元订单不计入营业统计
+const notRemake = "orders.remake_of = 0"
+
+// netAmount 订单金额扣除已同意的售后退款
+var netAmount = fmt.Sprintf("orders.amount - ifnull((select sum(a.refund_amount) from after_sale a "+
+	"where a.order_id = orders.id and a.status = %d), 0)", enum.AfterSaleApproved)
+
 type ReportDao struct {
 	db *gorm.DB
 }
@@ -17,14 +26,15 @@ func NewReportDao(db *gorm.DB) *ReportDao {
 	return &ReportDao{db: db}
 }
 
-// GetDailyTurnover 获取每日营业额
+// GetDailyTurnover 获取每日营业额，扣除售后退款
 func (d *ReportDao) GetDailyTurnover(begin, end time.Time) (float64, error) {
 	var turnover float64
 	if err := d.db.Table("orders").
 		Where("status = ?", enum.Completed).
+		Where(notRemake).
 		Where("order_time >= ?", model.LocalTime(begin)).
 		Where("order_time <= ?", model.LocalTime(end)).
-		Select("ifnull(sum(amount),0) as amount").
+		Select("ifnull(sum(" + netAmount + "),0) as amount").
 		Scan(&turnover).Error; err != nil {
 		return 0, err
 	}
@@ -40,6 +50,7 @@ func (d *ReportDao) GetDailyOrderCount(begin, end time.Time, status int) (int, e
 			Where("order_time >= ?", model.LocalTime(begin)).
 			Where("order_time <= ?", model.LocalTime(end)).
 			Where("status = ?", status).
+			Where(notRemake).
 			Count(&orderCount).Error; err != nil {
 			return 0, err
 		}
@@ -48,6 +59,7 @@ func (d *ReportDao) GetDailyOrderCount(begin, end time.Time, status int) (int, e
 		if err := d.db.Table("orders").
 			Where("order_time >= ?", model.LocalTime(begin)).
 			Where("order_time <= ?", model.LocalTime(end)).
+			Where(notRemake).
 			Count(&orderCount).Error; err != nil {
 			return 0, err
 		}
@@ -55,6 +67,180 @@ func (d *ReportDao) GetDailyOrderCount(begin, end time.Time, status int) (int, e
 	return int(orderCount), nil
 }
 
+// dailyRow 按日期分组的聚合结果
+type dailyRow struct {
+	Day      time.Time
+	Turnover float64
+	Total    int
+	Valid    int
+	Count    int
+}
+
+func dayKey(t time.Time) string {
+	return t.Format(time.DateOnly)
+}
+
+// GetTurnoverSeries 按日统计营业额
+func (d *ReportDao) GetTurnoverSeries(begin, end time.Time) (map[string]float64, error) {
+	var rows []dailyRow
+	if err := d.db.Table("orders").
+		Select("DATE(order_time) as day, ifnull(sum("+netAmount+"),0) as turnover").
+		Where("status = ?", enum.Completed).
+		Where(notRemake).
+		Where("order_time >= ?", model.LocalTime(begin)).
+		Where("order_time <= ?", model.LocalTime(end)).
+		Group("DATE(order_time)").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	res := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		res[dayKey(row.Day)] = row.Turnover
+	}
+	return res, nil
+}
+
+// GetOrderCountSeries 按日统计订单总数与有效订单数
+func (d *ReportDao) GetOrderCountSeries(begin, end time.Time) (map[string]int, map[string]int, error) {
+	var rows []dailyRow
+	if err := d.db.Table("orders").
+		Select("DATE(order_time) as day, count(*) as total, ifnull(sum(status = ?),0) as valid", enum.Completed).
+		Where(notRemake).
+		Where("order_time >= ?", model.LocalTime(begin)).
+		Where("order_time <= ?", model.LocalTime(end)).
+		Group("DATE(order_time)").
+		Scan(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	total := make(map[string]int, len(rows))
+	valid := make(map[string]int, len(rows))
+	for _, row := range rows {
+		total[dayKey(row.Day)] = row.Total
+		valid[dayKey(row.Day)] = row.Valid
+	}
+	return total, valid, nil
+}
+
+// GetNewUserSeries 按日统计新增用户数
+func (d *ReportDao) GetNewUserSeries(begin, end time.Time) (map[string]int, error) {
+	var rows []dailyRow
+	if err := d.db.Table("user").
+		Select("DATE(create_time) as day, count(*) as count").
+		Where("create_time >= ?", model.LocalTime(begin)).
+		Where("create_time <= ?", model.LocalTime(end)).
+		Group("DATE(create_time)").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	res := make(map[string]int, len(rows))
+	for _, row := range rows {
+		res[dayKey(row.Day)] = row.Count
+	}
+	return res, nil
+}
+
+// hourlyRow 按小时分组的聚合结果
+type hourlyRow struct {
+	Hour     string
+	Turnover float64
+	Total    int
+	Valid    int
+	Count    int
+}
+
+const hourExpr = "DATE_FORMAT(%s, '%%Y-%%m-%%d %%H:00:00')"
+
+// GetHourlyStats 按小时统计营业额、订单数与新增用户数
+func (d *ReportDao) GetHourlyStats(begin, end time.Time) ([]model.HourlyBusinessStats, error) {
+	var orderRows, userRows []hourlyRow
+	orderHour := fmt.Sprintf(hourExpr, "order_time")
+	if err := d.db.Table("orders").
+		Select(orderHour+" as hour, ifnull(sum(if(status = ?, "+netAmount+", 0)),0) as turnover, "+
+			"count(*) as total, ifnull(sum(status = ?),0) as valid", enum.Completed, enum.Completed).
+		Where(notRemake).
+		Where("order_time >= ?", model.LocalTime(begin)).
+		Where("order_time <= ?", model.LocalTime(end)).
+		Group(orderHour).
+		Scan(&orderRows).Error; err != nil {
+		return nil, err
+	}
+	userHour := fmt.Sprintf(hourExpr, "create_time")
+	if err := d.db.Table("user").
+		Select(userHour+" as hour, count(*) as count").
+		Where("create_time >= ?", model.LocalTime(begin)).
+		Where("create_time <= ?", model.LocalTime(end)).
+		Group(userHour).
+		Scan(&userRows).Error; err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]*model.HourlyBusinessStats)
+	get := func(hour string) (*model.HourlyBusinessStats, error) {
+		if stat, ok := stats[hour]; ok {
+			return stat, nil
+		}
+		t, err := time.ParseInLocation(time.DateTime, hour, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse hour %q: %w", hour, err)
+		}
+		stats[hour] = &model.HourlyBusinessStats{Hour: t}
+		return stats[hour], nil
+	}
+	for _, row := range orderRows {
+		stat, err := get(row.Hour)
+		if err != nil {
+			return nil, err
+		}
+		stat.Turnover = row.Turnover
+		stat.OrderCount = row.Total
+		stat.ValidOrderCount = row.Valid
+	}
+	for _, row := range userRows {
+		stat, err := get(row.Hour)
+		if err != nil {
+			return nil, err
+		}
+		stat.NewUsers = row.Count
+	}
+	res := make([]model.HourlyBusinessStats, 0, len(stats))
+	for _, stat := range stats {
+		res = append(res, *stat)
+	}
+	return res, nil
+}
+
+// ListDailyStats 查询预聚合的每日营业数据
+func (d *ReportDao) ListDailyStats(begin, end time.Time) ([]model.DailyBusinessStats, error) {
+	var stats []model.DailyBusinessStats
+	if err := d.db.
+		Where("stat_date >= ?", begin.Format(time.DateOnly)).
+		Where("stat_date <= ?", end.Format(time.DateOnly)).
+		Order("stat_date").
+		Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// SaveDailyStats 写入每日营业数据，已存在的日期整体覆盖
+func (d *ReportDao) SaveDailyStats(stats []model.DailyBusinessStats) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return d.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&stats).Error
+}
+
+// IncrDailyStats 订单完成或售后退款时增量更新下单日期的营业额与有效订单数，当日数据尚未预聚合时忽略
+func (d *ReportDao) IncrDailyStats(date time.Time, turnover float64, validOrders int) error {
+	return d.db.Model(&model.DailyBusinessStats{}).
+		Where("stat_date = ?", date.Format(time.DateOnly)).
+		Updates(map[string]interface{}{
+			"turnover":          gorm.Expr("turnover + ?", turnover),
+			"valid_order_count": gorm.Expr("valid_order_count + ?", validOrders),
+			"update_time":       time.Now(),
+		}).Error
+}
+
 // GetSalesTop10 获取销量前十的商品
 func (d *ReportDao) GetSalesTop10(begin, end time.Time) ([]request.GoodsSalesDTO, error) {
 	goodsSales := make([]request.GoodsSalesDTO, 0)
@@ -62,6 +248,7 @@ func (d *ReportDao) GetSalesTop10(begin, end time.Time) ([]request.GoodsSalesDTO
 		Select("order_detail.name, sum(order_detail.number) as number").
 		Joins("left join orders on order_detail.order_id = orders.id").
 		Where("orders.status = ?", enum.Completed).
+		Where(notRemake).
 		Where("orders.order_time >= ?", begin).
 		Where("orders.order_time <= ?", end).
 		Group("order_detail.name").
@@ -73,6 +260,35 @@ func (d *ReportDao) GetSalesTop10(begin, end time.Time) ([]request.GoodsSalesDTO
 	return goodsSales, nil
 }
 
+// EachGoodsSales 逐行读取商品销量与销售额
+func (d *ReportDao) EachGoodsSales(begin, end time.Time, fn func(request.GoodsSalesDTO) error) error {
+	rows, err := d.db.Table("order_detail").
+		Select("order_detail.name, sum(order_detail.number) as number, "+
+			"sum(order_detail.amount * order_detail.number) as amount").
+		Joins("left join orders on order_detail.order_id = orders.id").
+		Where("orders.status = ?", enum.Completed).
+		Where(notRemake).
+		Where("orders.order_time >= ?", begin).
+		Where("orders.order_time <= ?", end).
+		Group("order_detail.name").
+		Order("number desc").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var goods request.GoodsSalesDTO
+		if err = d.db.ScanRows(rows, &goods); err != nil {
+			return err
+		}
+		if err = fn(goods); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // GetUserCount 获取用户数量
 func (d *ReportDao) GetUserCount(begin, end time.Time) (int, error) {
 	var userCount int64
@@ -121,3 +337,50 @@ func (d *ReportDao) GetBusinessData(beginTime, endTime time.Time) (response.Busi
 		NewUsers:            newUsers,
 	}, nil
 }
+
+// GetAfterSaleSummary 统计区间内申请的售后单
+func (d *ReportDao) GetAfterSaleSummary(begin, end time.Time) (response.AfterSaleSummaryVO, error) {
+	var summary response.AfterSaleSummaryVO
+	if err := d.db.Table("after_sale").
+		Select("count(*) as tickets, "+
+			"ifnull(sum(status = ?),0) as pending, "+
+			"ifnull(sum(status = ?),0) as approved, "+
+			"ifnull(sum(status = ?),0) as rejected, "+
+			"ifnull(sum(status = ?),0) as cancelled, "+
+			"ifnull(sum(status = ? and resolution = ?),0) as refunds, "+
+			"ifnull(sum(case when status = ? then refund_amount else 0 end),0) as refund_amount, "+
+			"ifnull(sum(status = ? and resolution = ?),0) as remakes, "+
+			"ifnull(sum(escalate_level > 0),0) as escalated, "+
+			"ifnull(avg(case when status in ? then timestampdiff(second, create_time, handle_time) / 60 end),0) as avg_handle_minutes",
+			enum.AfterSalePending, enum.AfterSaleApproved, enum.AfterSaleRejected, enum.AfterSaleCancelled,
+			enum.AfterSaleApproved, enum.AfterSaleRefund, enum.AfterSaleApproved,
+			enum.AfterSaleApproved, enum.AfterSaleRemake,
+			[]int{enum.AfterSaleApproved, enum.AfterSaleRejected}).
+		Where("create_time >= ? and create_time <= ?", begin, end).
+		Scan(&summary).Error; err != nil {
+		return summary, fmt.Errorf("failed to summarize after sales: %w", err)
+	}
+	summary.Reasons = make([]response.AfterSaleReasonVO, 0)
+	if err := d.db.Table("after_sale").
+		Select("reason, count(*) as count").
+		Where("create_time >= ? and create_time <= ?", begin, end).
+		Group("reason").
+		Order("count desc").
+		Scan(&summary.Reasons).Error; err != nil {
+		return summary, fmt.Errorf("failed to summarize after sale reasons: %w", err)
+	}
+	// 撤销的售后单不计入商品统计
+	summary.Goods = make([]response.AfterSaleGoodsVO, 0)
+	if err := d.db.Table("after_sale_item").
+		Select("after_sale_item.name, sum(after_sale_item.number) as number").
+		Joins("join after_sale on after_sale_item.after_sale_id = after_sale.id").
+		Where("after_sale.status <> ?", enum.AfterSaleCancelled).
+		Where("after_sale.create_time >= ? and after_sale.create_time <= ?", begin, end).
+		Group("after_sale_item.name").
+		Order("number desc").
+		Limit(10).
+		Scan(&summary.Goods).Error; err != nil {
+		return summary, fmt.Errorf("failed to summarize after sale goods: %w", err)
+	}
+	return summary, nil
+}
@@ -0,0 +1,112 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"takeout/internal/model"
+	"takeout/repository"
+	"time"
+)
+
+type ReportScheduleDao struct {
+	db *gorm.DB
+}
+
+func NewReportScheduleDao(db *gorm.DB) repository.ReportScheduleRepo {
+	return &ReportScheduleDao{db: db}
+}
+
+// List 查询推送计划及接收方
+func (d *ReportScheduleDao) List(ctx context.Context, enabledOnly bool) ([]model.ReportSchedule, error) {
+	var schedules []model.ReportSchedule
+	query := d.db.WithContext(ctx).Preload("Recipients")
+	if enabledOnly {
+		query = query.Where("status = ?", 1)
+	}
+	if err := query.Order("id asc").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list report schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// GetById 根据id查询推送计划
+func (d *ReportScheduleDao) GetById(ctx context.Context, id uint64) (*model.ReportSchedule, error) {
+	var schedule model.ReportSchedule
+	if err := d.db.WithContext(ctx).Preload("Recipients").First(&schedule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get report schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// Insert 新增推送计划，同时写入其接收方
+func (d *ReportScheduleDao) Insert(ctx context.Context, schedule *model.ReportSchedule) error {
+	if err := d.db.WithContext(ctx).Create(schedule).Error; err != nil {
+		return fmt.Errorf("failed to insert report schedule: %w", err)
+	}
+	return nil
+}
+
+// Update 修改推送计划的基本信息与推送时间，不修改启停状态、接收方与最近推送结果
+func (d *ReportScheduleDao) Update(ctx context.Context, schedule *model.ReportSchedule) error {
+	err := d.db.WithContext(ctx).Model(schedule).
+		Select("name", "frequency", "send_time", "weekday", "time_zone", "update_time").
+		Updates(schedule).Error
+	if err != nil {
+		return fmt.Errorf("failed to update report schedule: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除推送计划及其接收方
+func (d *ReportScheduleDao) Delete(ctx context.Context, id uint64) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("schedule_id = ?", id).Delete(&model.ReportRecipient{}).Error; err != nil {
+			return fmt.Errorf("failed to delete report recipients: %w", err)
+		}
+		if err := tx.Delete(&model.ReportSchedule{}, id).Error; err != nil {
+			return fmt.Errorf("failed to delete report schedule: %w", err)
+		}
+		return nil
+	})
+}
+
+// SetStatus 启用或停用推送计划
+func (d *ReportScheduleDao) SetStatus(ctx context.Context, id uint64, status int) error {
+	err := d.db.WithContext(ctx).Model(&model.ReportSchedule{Id: id}).
+		Updates(map[string]any{"status": status, "update_time": time.Now()}).Error
+	if err != nil {
+		return fmt.Errorf("failed to set report schedule status: %w", err)
+	}
+	return nil
+}
+
+// SaveRunResult 记录最近一次推送结果
+func (d *ReportScheduleDao) SaveRunResult(ctx context.Context, id uint64, runTime time.Time, status, errMsg string) error {
+	err := d.db.WithContext(ctx).Model(&model.ReportSchedule{}).Where("id = ?", id).
+		UpdateColumns(map[string]any{"last_run_time": runTime, "last_status": status, "last_error": errMsg}).Error
+	if err != nil {
+		return fmt.Errorf("failed to save report schedule result: %w", err)
+	}
+	return nil
+}
+
+// InsertRecipient 新增接收方
+func (d *ReportScheduleDao) InsertRecipient(ctx context.Context, recipient *model.ReportRecipient) error {
+	if err := d.db.WithContext(ctx).Create(recipient).Error; err != nil {
+		return fmt.Errorf("failed to insert report recipient: %w", err)
+	}
+	return nil
+}
+
+// DeleteRecipient 删除接收方
+func (d *ReportScheduleDao) DeleteRecipient(ctx context.Context, id uint64) error {
+	if err := d.db.WithContext(ctx).Delete(&model.ReportRecipient{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete report recipient: %w", err)
+	}
+	return nil
+}
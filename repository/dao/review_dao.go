@@ -0,0 +1,187 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"takeout/common"
+	"takeout/common/enum"
+	adminRequest "takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/internal/api/user/request"
+	"takeout/internal/model"
+	"takeout/repository"
+	"time"
+)
+
+type ReviewDao struct {
+	db *gorm.DB
+}
+
+func NewReviewDao(db *gorm.DB) repository.ReviewRepo {
+	return &ReviewDao{db: db}
+}
+
+// Transaction 开始一个事务
+func (d ReviewDao) Transaction(ctx context.Context) *gorm.DB {
+	return d.db.WithContext(ctx).Begin()
+}
+
+// InsertBatch 批量插入评价
+func (d ReviewDao) InsertBatch(transaction *gorm.DB, reviews []model.Review) error {
+	if err := transaction.Create(&reviews).Error; err != nil {
+		return fmt.Errorf("failed to insert reviews: %w", err)
+	}
+	return nil
+}
+
+// GetById 根据id查询评价
+func (d ReviewDao) GetById(ctx context.Context, id uint64) (*model.Review, error) {
+	var review model.Review
+	if err := d.db.WithContext(ctx).First(&review, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get review by ID: %w", err)
+	}
+	return &review, nil
+}
+
+// GetByOrderId 查询订单的全部评价
+func (d ReviewDao) GetByOrderId(ctx context.Context, orderId int) ([]model.Review, error) {
+	var reviews []model.Review
+	if err := d.db.WithContext(ctx).Where("order_id = ?", orderId).
+		Order("order_detail_id asc").Find(&reviews).Error; err != nil {
+		return nil, fmt.Errorf("failed to get reviews by order: %w", err)
+	}
+	return reviews, nil
+}
+
+// PageByTarget 分页查询菜品/套餐的公开评价
+func (d ReviewDao) PageByTarget(ctx context.Context, dto request.ReviewPageQueryDTO) (*common.PageResult, error) {
+	var (
+		pageResult common.PageResult
+		reviews    []model.Review
+	)
+	query := d.db.WithContext(ctx).Model(&model.Review{}).
+		Where("status = ?", enum.ENABLE).
+		Where("order_detail_id <> 0")
+	if dto.DishId != 0 {
+		query = query.Where("dish_id = ?", dto.DishId)
+	}
+	if dto.SetmealId != 0 {
+		query = query.Where("setmeal_id = ?", dto.SetmealId)
+	}
+	if err := query.Count(&pageResult.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count reviews: %w", err)
+	}
+	if err := query.Scopes(pageResult.Paginate(&dto.Page, &dto.PageSize)).
+		Order("create_time desc").
+		Find(&reviews).Error; err != nil {
+		return nil, fmt.Errorf("failed to query reviews: %w", err)
+	}
+	pageResult.Records = reviews
+	return &pageResult, nil
+}
+
+// PageQuery 评价管理分页查询
+func (d ReviewDao) PageQuery(ctx context.Context, dto adminRequest.ReviewPageQueryDTO) (*common.PageResult, error) {
+	var (
+		pageResult common.PageResult
+		reviews    []model.Review
+	)
+	query := d.db.WithContext(ctx).Model(&model.Review{})
+	if dto.Rating != 0 {
+		query = query.Where("rating = ?", dto.Rating)
+	}
+	if dto.Status != "" {
+		query = query.Where("status = ?", dto.Status)
+	}
+	if dto.Flagged != "" {
+		query = query.Where("flagged = ?", dto.Flagged)
+	}
+	switch dto.Replied {
+	case "1":
+		query = query.Where("reply <> ''")
+	case "0":
+		query = query.Where("reply IS NULL OR reply = ''")
+	}
+	if dto.DishId != 0 {
+		query = query.Where("dish_id = ?", dto.DishId)
+	}
+	if dto.SetmealId != 0 {
+		query = query.Where("setmeal_id = ?", dto.SetmealId)
+	}
+	if dto.OrderId != 0 {
+		query = query.Where("order_id = ?", dto.OrderId)
+	}
+	if err := query.Count(&pageResult.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count reviews: %w", err)
+	}
+	if err := query.Scopes(pageResult.Paginate(&dto.Page, &dto.PageSize)).
+		Order("flagged desc, create_time desc").
+		Find(&reviews).Error; err != nil {
+		return nil, fmt.Errorf("failed to query reviews: %w", err)
+	}
+	pageResult.Records = reviews
+	return &pageResult, nil
+}
+
+// Reply 商家回复
+func (d ReviewDao) Reply(ctx context.Context, id uint64, reply string) error {
+	if err := d.db.WithContext(ctx).Model(&model.Review{}).Where("id = ?", id).
+		Updates(map[string]any{"reply": reply, "reply_time": model.LocalTime(time.Now())}).Error; err != nil {
+		return fmt.Errorf("failed to reply review: %w", err)
+	}
+	return nil
+}
+
+// SetStatus 展示或隐藏评价，审核后清除举报标记
+func (d ReviewDao) SetStatus(ctx context.Context, id uint64, status int) error {
+	if err := d.db.WithContext(ctx).Model(&model.Review{}).Where("id = ?", id).
+		Updates(map[string]any{"status": status, "flagged": 0}).Error; err != nil {
+		return fmt.Errorf("failed to update review status: %w", err)
+	}
+	return nil
+}
+
+// SetFlagged 设置举报标记
+func (d ReviewDao) SetFlagged(ctx context.Context, id uint64, flagged int) error {
+	if err := d.db.WithContext(ctx).Model(&model.Review{}).Where("id = ?", id).
+		Update("flagged", flagged).Error; err != nil {
+		return fmt.Errorf("failed to flag review: %w", err)
+	}
+	return nil
+}
+
+// GetDishRatings 汇总菜品的平均评分
+func (d ReviewDao) GetDishRatings(ctx context.Context, dishIds []uint64) (map[uint64]response.RatingStatVO, error) {
+	return d.getRatings(ctx, "dish_id", dishIds)
+}
+
+// GetSetmealRatings 汇总套餐的平均评分
+func (d ReviewDao) GetSetmealRatings(ctx context.Context, setmealIds []uint64) (map[uint64]response.RatingStatVO, error) {
+	return d.getRatings(ctx, "setmeal_id", setmealIds)
+}
+
+func (d ReviewDao) getRatings(ctx context.Context, column string, ids []uint64) (map[uint64]response.RatingStatVO, error) {
+	res := make(map[uint64]response.RatingStatVO, len(ids))
+	if len(ids) == 0 {
+		return res, nil
+	}
+	var stats []response.RatingStatVO
+	if err := d.db.WithContext(ctx).Model(&model.Review{}).
+		Select(column+" as target_id, avg(rating) as avg_rating, count(*) as rating_count").
+		Where(column+" IN ?", ids).
+		Where("order_detail_id <> 0").
+		Where("status = ?", enum.ENABLE).
+		Group(column).
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate ratings: %w", err)
+	}
+	for _, stat := range stats {
+		res[stat.TargetId] = stat
+	}
+	return res, nil
+}
@@ -0,0 +1,172 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"takeout/common/enum"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/repository"
+	"time"
+)
+
+type SalesDao struct {
+	db *gorm.DB
+}
+
+func NewSalesDao(db *gorm.DB) repository.SalesRepo {
+	return &SalesDao{db: db}
+}
+
+// completedDetails 区间内已完成订单的明细
+func (d *SalesDao) completedDetails(ctx context.Context, begin, end time.Time) *gorm.DB {
+	return d.db.WithContext(ctx).Table("order_detail").
+		Joins("join orders on order_detail.order_id = orders.id").
+		Where("orders.status = ?", enum.Completed).
+		Where(notRemake).
+		Where("orders.order_time >= ?", begin).
+		Where("orders.order_time <= ?", end)
+}
+
+// ItemSales 先按 dish_id/setmeal_id 聚合，再关联当前的名称与分类
+func (d *SalesDao) ItemSales(ctx context.Context, begin, end time.Time) ([]response.ItemSalesVO, error) {
+	sub := d.completedDetails(ctx, begin, end).
+		Select("order_detail.dish_id, order_detail.setmeal_id, max(order_detail.name) as name, " +
+			"sum(order_detail.number) as number, sum(order_detail.amount * order_detail.number) as amount, " +
+			"count(distinct order_detail.order_id) as order_count").
+		Group("order_detail.dish_id, order_detail.setmeal_id")
+	items := make([]response.ItemSalesVO, 0)
+	if err := d.db.WithContext(ctx).Table("(?) as t", sub).
+		Select("if(ifnull(t.setmeal_id,0) <> 0, 'setmeal', 'dish') as type, " +
+			"if(ifnull(t.setmeal_id,0) <> 0, t.setmeal_id, t.dish_id) as id, " +
+			"coalesce(dish.name, setmeal.name, t.name) as name, " +
+			"ifnull(coalesce(dish.category_id, setmeal.category_id),0) as category_id, " +
+			"ifnull(category.name,'') as category_name, t.number, t.amount, t.order_count").
+		Joins("left join dish on dish.id = t.dish_id").
+		Joins("left join setmeal on setmeal.id = t.setmeal_id").
+		Joins("left join category on category.id = coalesce(dish.category_id, setmeal.category_id)").
+		Order("amount desc").
+		Scan(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to query item sales: %w", err)
+	}
+	return items, nil
+}
+
+// CategorySales 按菜品/套餐当前所属分类汇总
+func (d *SalesDao) CategorySales(ctx context.Context, begin, end time.Time) ([]response.CategorySalesVO, error) {
+	sub := d.completedDetails(ctx, begin, end).
+		Select("ifnull(coalesce(dish.category_id, setmeal.category_id),0) as category_id, " +
+			"sum(order_detail.number) as number, sum(order_detail.amount * order_detail.number) as amount, " +
+			"count(distinct order_detail.order_id) as order_count").
+		Joins("left join dish on dish.id = order_detail.dish_id").
+		Joins("left join setmeal on setmeal.id = order_detail.setmeal_id").
+		Group("ifnull(coalesce(dish.category_id, setmeal.category_id),0)")
+	categories := make([]response.CategorySalesVO, 0)
+	if err := d.db.WithContext(ctx).Table("(?) as t", sub).
+		Select("t.category_id, ifnull(category.name,'') as category_name, ifnull(category.type,0) as category_type, " +
+			"t.number, t.amount, t.order_count").
+		Joins("left join category on category.id = t.category_id").
+		Order("amount desc").
+		Scan(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to query category sales: %w", err)
+	}
+	return categories, nil
+}
+
+// FlavorSales 按菜品与口味组合汇总销量
+func (d *SalesDao) FlavorSales(ctx context.Context, begin, end time.Time) ([]request.FlavorSalesDTO, error) {
+	sub := d.completedDetails(ctx, begin, end).
+		Select("order_detail.dish_id, ifnull(order_detail.dish_flavor,'') as dish_flavor, " +
+			"max(order_detail.name) as name, sum(order_detail.number) as number").
+		Where("ifnull(order_detail.dish_id,0) <> 0").
+		Group("order_detail.dish_id, ifnull(order_detail.dish_flavor,'')")
+	rows := make([]request.FlavorSalesDTO, 0)
+	if err := d.db.WithContext(ctx).Table("(?) as t", sub).
+		Select("t.dish_id, coalesce(dish.name, t.name) as name, t.dish_flavor, t.number, " +
+			"exists(select 1 from dish_flavor where dish_flavor.dish_id = t.dish_id) as configurable").
+		Joins("left join dish on dish.id = t.dish_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query flavor sales: %w", err)
+	}
+	return rows, nil
+}
+
+// OnSaleItemSales 起售中的菜品与套餐左关联区间销量
+func (d *SalesDao) OnSaleItemSales(ctx context.Context, begin, end time.Time) ([]response.SlowItemVO, error) {
+	res := make([]response.SlowItemVO, 0)
+	for _, kind := range []struct{ typ, table, column string }{
+		{"dish", "dish", "dish_id"},
+		{"setmeal", "setmeal", "setmeal_id"},
+	} {
+		sub := d.completedDetails(ctx, begin, end).
+			Select(fmt.Sprintf("order_detail.%s as item_id, sum(order_detail.number) as number, "+
+				"sum(order_detail.amount * order_detail.number) as amount, max(orders.order_time) as last_order_time", kind.column)).
+			Where(fmt.Sprintf("ifnull(order_detail.%s,0) <> 0", kind.column)).
+			Group("order_detail." + kind.column)
+		var items []response.SlowItemVO
+		if err := d.db.WithContext(ctx).Table(kind.table).
+			Select(fmt.Sprintf("'%s' as type, %s.id, %s.name, %s.category_id, ifnull(category.name,'') as category_name, "+
+				"ifnull(t.number,0) as number, ifnull(t.amount,0) as amount, t.last_order_time",
+				kind.typ, kind.table, kind.table, kind.table)).
+			Joins(fmt.Sprintf("left join category on category.id = %s.category_id", kind.table)).
+			Joins(fmt.Sprintf("left join (?) as t on t.item_id = %s.id", kind.table), sub).
+			Where(kind.table+".status = ?", enum.ENABLE).
+			Scan(&items).Error; err != nil {
+			return nil, fmt.Errorf("failed to query %s sales: %w", kind.typ, err)
+		}
+		res = append(res, items...)
+	}
+	return res, nil
+}
+
+// BasketSummary 订单金额取自 orders，件数与明细行数取自 order_detail
+func (d *SalesDao) BasketSummary(ctx context.Context, begin, end time.Time) (request.BasketSummaryDTO, error) {
+	var summary request.BasketSummaryDTO
+	if err := d.db.WithContext(ctx).Table("orders").
+		Select("count(*) as order_count, ifnull(sum(amount),0) as amount").
+		Where("status = ?", enum.Completed).
+		Where(notRemake).
+		Where("order_time >= ?", begin).
+		Where("order_time <= ?", end).
+		Scan(&summary).Error; err != nil {
+		return summary, fmt.Errorf("failed to query basket orders: %w", err)
+	}
+	// LINES 是 MySQL 保留字，不能直接用作别名
+	var details struct {
+		Items         int
+		LineCount     int
+		SetmealOrders int
+	}
+	if err := d.completedDetails(ctx, begin, end).
+		Select("ifnull(sum(order_detail.number),0) as items, count(*) as line_count, " +
+			"count(distinct if(ifnull(order_detail.setmeal_id,0) <> 0, order_detail.order_id, null)) as setmeal_orders").
+		Scan(&details).Error; err != nil {
+		return summary, fmt.Errorf("failed to query basket details: %w", err)
+	}
+	summary.Items, summary.Lines, summary.SetmealOrders = details.Items, details.LineCount, details.SetmealOrders
+	return summary, nil
+}
+
+// BasketSizes 统计每单件数的分布
+func (d *SalesDao) BasketSizes(ctx context.Context, begin, end time.Time, maxItems int) (map[int]int, error) {
+	sub := d.completedDetails(ctx, begin, end).
+		Select("order_detail.order_id, sum(order_detail.number) as items").
+		Group("order_detail.order_id")
+	var rows []struct {
+		Items      int
+		OrderCount int
+	}
+	size := fmt.Sprintf("least(t.items, %d)", maxItems)
+	if err := d.db.WithContext(ctx).Table("(?) as t", sub).
+		Select(size + " as items, count(*) as order_count").
+		Group(size).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query basket sizes: %w", err)
+	}
+	res := make(map[int]int, len(rows))
+	for _, row := range rows {
+		res[row.Items] = row.OrderCount
+	}
+	return res, nil
+}
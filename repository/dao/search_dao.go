@@ -0,0 +1,63 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"takeout/common/enum"
+	"takeout/internal/model"
+	"takeout/repository"
+)
+
+type SearchDao struct {
+	db *gorm.DB
+}
+
+func NewSearchDao(db *gorm.DB) repository.SearchRepo {
+	return &SearchDao{db: db}
+}
+
+// ListOnSaleDishes 查询起售中的菜品
+func (d SearchDao) ListOnSaleDishes(ctx context.Context) ([]model.Dish, error) {
+	var dishes []model.Dish
+	if err := d.db.WithContext(ctx).Where("status = ?", enum.ENABLE).Find(&dishes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list on-sale dishes: %w", err)
+	}
+	return dishes, nil
+}
+
+// ListOnSaleSetmeals 查询起售中的套餐
+func (d SearchDao) ListOnSaleSetmeals(ctx context.Context) ([]model.SetMeal, error) {
+	var setmeals []model.SetMeal
+	if err := d.db.WithContext(ctx).Where("status = ?", enum.ENABLE).Find(&setmeals).Error; err != nil {
+		return nil, fmt.Errorf("failed to list on-sale setmeals: %w", err)
+	}
+	return setmeals, nil
+}
+
+// GetSalesVolume 统计已完成订单中每个菜品/套餐的销量
+func (d SearchDao) GetSalesVolume(ctx context.Context) (map[uint64]int64, map[uint64]int64, error) {
+	var rows []struct {
+		DishId    uint64
+		SetmealId uint64
+		Number    int64
+	}
+	if err := d.db.WithContext(ctx).Table("order_detail").
+		Select("order_detail.dish_id, order_detail.setmeal_id, sum(order_detail.number) as number").
+		Joins("left join orders on order_detail.order_id = orders.id").
+		Where("orders.status = ?", enum.Completed).
+		Group("order_detail.dish_id, order_detail.setmeal_id").
+		Scan(&rows).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to query sales volume: %w", err)
+	}
+	dishSales := make(map[uint64]int64)
+	setmealSales := make(map[uint64]int64)
+	for _, row := range rows {
+		if row.SetmealId != 0 {
+			setmealSales[row.SetmealId] += row.Number
+		} else if row.DishId != 0 {
+			dishSales[row.DishId] += row.Number
+		}
+	}
+	return dishSales, setmealSales, nil
+}
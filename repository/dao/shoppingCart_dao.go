@@ -2,143 +2,298 @@ package dao
 
 import (
 	"context"
-	"errors"
-	"github.com/ulule/deepcopier"
+	"encoding/json"
+	"fmt"
 	"gorm.io/gorm"
+	"slices"
+	"strconv"
+	"takeout/common/cart"
+	"takeout/common/enum"
 	"takeout/global"
 	"takeout/internal/api/user/request"
 	"takeout/internal/model"
 	"takeout/repository"
+	"time"
 )
 
+const cartDefaultTTL = 72 * time.Hour
+
+// cartStore 购物车存储，使用 global.RedisClient
+var cartStore = cart.New(nil, cartTTL)
+
+// ShoppingCartDao 购物车存放在 Redis hash 中，多端共享同一份数据，变更后由定时任务写回 MySQL 供统计分析
 type ShoppingCartDao struct {
 	db         *gorm.DB
+	store      *cart.Store
 	dishDao    DishDao
 	setmealDao SetMealDao
 }
 
 // Add 添加购物车
 func (s ShoppingCartDao) Add(ctx context.Context, shoppingCart model.ShoppingCart) error {
-	// 先判断该商品是否存在于购物车
-	var (
-		shoppingCartList []model.ShoppingCart
-		err              error
-	)
-	shoppingCartList = s.queryShoppingCart(ctx, shoppingCart)
-	if shoppingCartList != nil && len(shoppingCartList) == 1 {
-		shoppingCart = shoppingCartList[0]
-		shoppingCart.Number++
-		if err = s.db.Updates(&shoppingCart).Error; err != nil {
-			global.Log.Warn("更新用户购物车数量失败！")
+	n, err := s.incr(shoppingCart, 1, "")
+	if err != nil || n >= 0 {
+		return err
+	}
+	// 购物车中还没有该商品，查询菜品或套餐信息后写入
+	if shoppingCart.DishId != 0 {
+		dish, err := s.dishDao.GetById(ctx, uint64(shoppingCart.DishId))
+		if err != nil {
 			return err
 		}
-	} else {
-		// 如果不存在，插入数据，数量就是1
-		// 判断当前添加到购物车的是菜品还是套餐
-		if shoppingCart.DishId != 0 {
-			dish, err := s.dishDao.GetById(ctx, uint64(shoppingCart.DishId))
-			if err != nil {
-				return err
-			}
-			shoppingCart.Name = dish.Name
-			shoppingCart.Image = dish.Image
-			shoppingCart.Amount = dish.Price
-		} else {
-			// 添加到购物车的是套餐
-			// 查询添加的套餐信息
-			setmeal, err := s.setmealDao.GetByIdWithDish(s.setmealDao.db, uint64(shoppingCart.SetmealId))
-			if err != nil {
-				return err
-			}
-			shoppingCart.Name = setmeal.Name
-			shoppingCart.Image = setmeal.Image
-			shoppingCart.Amount = setmeal.Price
+		if dish.Id == 0 {
+			return fmt.Errorf("dish %d not found", shoppingCart.DishId)
 		}
-		shoppingCart.Number = 1
-		if err = s.db.Create(&shoppingCart).Error; err != nil {
+		shoppingCart.Name = dish.Name
+		shoppingCart.Image = dish.Image
+		shoppingCart.Amount = dish.Price
+	} else {
+		// 添加到购物车的是套餐
+		setmeal, err := s.setmealDao.GetByIdWithDish(s.setmealDao.db, uint64(shoppingCart.SetmealId))
+		if err != nil {
 			return err
 		}
+		if setmeal.Id == 0 {
+			return fmt.Errorf("setmeal %d not found", shoppingCart.SetmealId)
+		}
+		shoppingCart.Name = setmeal.Name
+		shoppingCart.Image = setmeal.Image
+		shoppingCart.Amount = setmeal.Price
 	}
-	return nil
+	_, err = s.incr(shoppingCart, 1, cartMeta(shoppingCart, 0, time.Now()))
+	return err
 }
 
-// List 查看购物车所有
+// List 查看购物车所有，按加入时间倒序
 func (s ShoppingCartDao) List(ctx context.Context, userId int) ([]model.ShoppingCart, error) {
-	var shoppingCartList []model.ShoppingCart
-	shoppingCartList = s.queryShoppingCart(ctx, model.ShoppingCart{UserId: userId})
-	return shoppingCartList, nil
-}
-
-// 查询购物车数据
-func (s ShoppingCartDao) queryShoppingCart(ctx context.Context, shoppingCart model.ShoppingCart) []model.ShoppingCart {
-	var shoppingCartList []model.ShoppingCart
-	if err := s.db.WithContext(ctx).
-		Where(&shoppingCart).
-		Order("create_time desc").
-		Find(&shoppingCartList).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil
+	lines, err := s.store.Lines(userId)
+	if err != nil {
+		return nil, err
 	}
-	return shoppingCartList
+	shoppingCartList := make([]model.ShoppingCart, 0, len(lines))
+	for _, line := range lines {
+		var shoppingCart model.ShoppingCart
+		if err = json.Unmarshal([]byte(line.Meta), &shoppingCart); err != nil {
+			global.Log.Warn("错误的购物车数据", "userId", userId, "line", line.Field)
+			continue
+		}
+		shoppingCart.UserId = userId
+		shoppingCart.Number = line.Number
+		shoppingCartList = append(shoppingCartList, shoppingCart)
+	}
+	slices.SortFunc(shoppingCartList, func(a, b model.ShoppingCart) int {
+		if c := b.CreateTime.Compare(a.CreateTime); c != 0 {
+			return c
+		}
+		return b.Id - a.Id
+	})
+	return shoppingCartList, nil
 }
 
 // Clean 清空购物车
 func (s ShoppingCartDao) Clean(ctx context.Context, userId int) error {
-	if err := s.db.WithContext(ctx).Where("user_id = ?", userId).Delete(&model.ShoppingCart{}).Error; err != nil {
+	return s.store.Clean(userId)
+}
+
+// Subtract 减少购物车
+func (s ShoppingCartDao) Subtract(ctx context.Context, ShoppingCartDTO request.ShoppingCartDTO, userId int) error {
+	n, err := s.store.Decr(userId, cartLine(ShoppingCartDTO.DishId, ShoppingCartDTO.SetmealId, ShoppingCartDTO.DishFlavor))
+	if err != nil {
 		return err
 	}
+	if n < 0 {
+		global.Log.Warn("错误的购物车减少")
+	}
 	return nil
 }
 
-// Subtract 减少购物车
-func (s ShoppingCartDao) Subtract(ctx context.Context, ShoppingCartDTO request.ShoppingCartDTO, userId int) error {
+// InsertBatchShoppingCart 批量加入购物车，已有的商品累加数量
+func (s ShoppingCartDao) InsertBatchShoppingCart(ctx context.Context, shoppingCartList []model.ShoppingCart) error {
+	for _, shoppingCart := range shoppingCartList {
+		if shoppingCart.Number <= 0 {
+			continue
+		}
+		if _, err := s.incr(shoppingCart, shoppingCart.Number, cartMeta(shoppingCart, 0, time.Now())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateLine 更新购物车行的名称、图片与单价
+func (s ShoppingCartDao) UpdateLine(ctx context.Context, shoppingCart model.ShoppingCart) (bool, error) {
+	line := cartLine(shoppingCart.DishId, shoppingCart.SetmealId, shoppingCart.DishFlavor)
+	return s.store.Update(shoppingCart.UserId, line, shoppingCart.Name, shoppingCart.Image, shoppingCart.Amount)
+}
+
+// ListDishes 根据id查询菜品及口味
+func (s ShoppingCartDao) ListDishes(ctx context.Context, ids []int) ([]model.Dish, error) {
+	var dishes []model.Dish
+	if len(ids) == 0 {
+		return dishes, nil
+	}
+	if err := s.db.WithContext(ctx).Preload("Flavors").Where("id in ?", ids).Find(&dishes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list cart dishes: %w", err)
+	}
+	return dishes, nil
+}
+
+// ListSetmeals 根据id查询套餐
+func (s ShoppingCartDao) ListSetmeals(ctx context.Context, ids []int) ([]model.SetMeal, error) {
+	var setmeals []model.SetMeal
+	if len(ids) == 0 {
+		return setmeals, nil
+	}
+	if err := s.db.WithContext(ctx).Where("id in ?", ids).Find(&setmeals).Error; err != nil {
+		return nil, fmt.Errorf("failed to list cart setmeals: %w", err)
+	}
+	return setmeals, nil
+}
+
+// FindOnSaleByNames 按名称查询在售的菜品及口味与在售的套餐
+func (s ShoppingCartDao) FindOnSaleByNames(ctx context.Context, names []string) ([]model.Dish, []model.SetMeal, error) {
 	var (
-		shoppingCart model.ShoppingCart
-		err          error
+		dishes   []model.Dish
+		setmeals []model.SetMeal
 	)
-	if err = deepcopier.Copy(ShoppingCartDTO).To(&shoppingCart); err != nil {
-		return err
+	if len(names) == 0 {
+		return dishes, setmeals, nil
 	}
-	shoppingCart.UserId = userId
-	shoppingCartList := s.queryShoppingCart(ctx, shoppingCart)
-	if shoppingCartList == nil || len(shoppingCartList) == 0 {
-		global.Log.Warn("错误的购物车减少")
-		return nil
-	} else {
-		shoppingCart = shoppingCartList[0]
-		if shoppingCart.Number == 1 {
-			err = s.deleteShoppingCartById(ctx, shoppingCart)
-			if err != nil {
-				return err
-			}
-		} else {
-			shoppingCart.Number--
-			if err = s.db.WithContext(ctx).Updates(&shoppingCart).Error; err != nil {
-				return err
+	if err := s.db.WithContext(ctx).Preload("Flavors").
+		Where("name in ? and status = ?", names, enum.ENABLE).
+		Order("id desc").Find(&dishes).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to find dishes by name: %w", err)
+	}
+	if err := s.db.WithContext(ctx).
+		Where("name in ? and status = ?", names, enum.ENABLE).
+		Order("id desc").Find(&setmeals).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to find setmeals by name: %w", err)
+	}
+	return dishes, setmeals, nil
+}
+
+// Flush 将有变更的购物车写回 MySQL，每次最多处理 limit 个用户，返回处理的用户数
+func (s ShoppingCartDao) Flush(ctx context.Context, limit int) (int, error) {
+	userIds, err := s.store.PopDirty(limit)
+	if err != nil {
+		return 0, err
+	}
+	for i, userId := range userIds {
+		if err = s.persist(ctx, userId); err != nil {
+			// 写回失败的用户放回集合，下次重试
+			if err := s.store.MarkDirty(userIds[i:]...); err != nil {
+				global.Log.Warn("Requeue dirty shopping carts failed", "error", err)
 			}
+			return i, err
 		}
 	}
-	return nil
+	return len(userIds), nil
 }
 
-// deleteShoppingCartById 根据id删除购物车
-func (s ShoppingCartDao) deleteShoppingCartById(ctx context.Context, shoppingCart model.ShoppingCart) error {
-	if err := s.db.WithContext(ctx).Delete(&shoppingCart).Error; err != nil {
-		return err
+// Migrate 将改用 Redis 之前保存在 MySQL 中的购物车导入 Redis，只执行一次，Redis 中已有购物车的用户跳过
+func (s ShoppingCartDao) Migrate(ctx context.Context) (int, error) {
+	first, err := s.store.MarkMigrated()
+	if err != nil || !first {
+		return 0, err
 	}
-	return nil
+	var rows []model.ShoppingCart
+	if err = s.db.WithContext(ctx).Order("user_id asc, id asc").Find(&rows).Error; err != nil {
+		s.unmarkMigrated()
+		return 0, fmt.Errorf("failed to load shopping carts: %w", err)
+	}
+	users := 0
+	for i := 0; i < len(rows); {
+		j := i
+		lines := make([]cart.Line, 0)
+		seq := 0
+		for ; j < len(rows) && rows[j].UserId == rows[i].UserId; j++ {
+			lines = append(lines, cart.Line{
+				Field:  cartLine(rows[j].DishId, rows[j].SetmealId, rows[j].DishFlavor),
+				Meta:   cartMeta(rows[j], rows[j].Id, rows[j].CreateTime),
+				Number: rows[j].Number,
+			})
+			seq = max(seq, rows[j].Id)
+		}
+		imported, err := s.store.Import(rows[i].UserId, lines, seq)
+		if err != nil {
+			s.unmarkMigrated()
+			return users, err
+		}
+		if imported {
+			users++
+		}
+		i = j
+	}
+	return users, nil
 }
 
-// InsertBatchShoppingCart 批量插入购物车数据
-func (s ShoppingCartDao) InsertBatchShoppingCart(ctx context.Context, shoppingCartList []model.ShoppingCart) error {
-	if err := s.db.WithContext(ctx).Create(&shoppingCartList).Error; err != nil {
+func (s ShoppingCartDao) unmarkMigrated() {
+	if err := s.store.UnmarkMigrated(); err != nil {
+		global.Log.Warn("Unmark shopping cart migration failed", "error", err)
+	}
+}
+
+// persist 用 Redis 中的购物车替换该用户在 MySQL 中的购物车
+func (s ShoppingCartDao) persist(ctx context.Context, userId int) error {
+	shoppingCartList, err := s.List(ctx, userId)
+	if err != nil {
 		return err
 	}
-	return nil
+	for i := range shoppingCartList {
+		shoppingCartList[i].Id = 0
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&model.ShoppingCart{}).Error; err != nil {
+			return fmt.Errorf("failed to delete shopping cart: %w", err)
+		}
+		if len(shoppingCartList) == 0 {
+			return nil
+		}
+		if err := tx.Create(&shoppingCartList).Error; err != nil {
+			return fmt.Errorf("failed to persist shopping cart: %w", err)
+		}
+		return nil
+	})
+}
+
+// incr 增加购物车行数量，meta 为空且该行不存在时返回 -1
+func (s ShoppingCartDao) incr(shoppingCart model.ShoppingCart, number int, meta string) (int64, error) {
+	line := cartLine(shoppingCart.DishId, shoppingCart.SetmealId, shoppingCart.DishFlavor)
+	return s.store.Incr(shoppingCart.UserId, line, meta, number)
+}
+
+// cartLine 同一菜品或套餐、同一口味为购物车的一行
+func cartLine(dishId, setmealId int, dishFlavor string) string {
+	return strconv.Itoa(dishId) + ":" + strconv.Itoa(setmealId) + ":" + dishFlavor
+}
+
+// cartMeta 购物车行信息，数量与用户id不在其中，新行的 id 由购物车分配
+func cartMeta(shoppingCart model.ShoppingCart, id int, createTime time.Time) string {
+	shoppingCart.Id, shoppingCart.UserId, shoppingCart.Number = id, 0, 0
+	shoppingCart.CreateTime = createTime
+	data, _ := json.Marshal(shoppingCart)
+	return string(data)
+}
+
+func cartTTL() time.Duration {
+	if global.Config != nil {
+		if d, err := time.ParseDuration(global.Config.Cart.TTL); err == nil && d > 0 {
+			return d
+		}
+	}
+	return cartDefaultTTL
 }
 
 func NewShoppingCartDao(db *gorm.DB) repository.ShoppingCartRepo {
-	return &ShoppingCartDao{
+	dao := newShoppingCartDao(db)
+	return &dao
+}
+
+// newShoppingCartDao 供嵌入购物车的 dao 使用，与 NewShoppingCartDao 共用同一个购物车存储
+func newShoppingCartDao(db *gorm.DB) ShoppingCartDao {
+	return ShoppingCartDao{
 		db:         db,
+		store:      cartStore,
 		dishDao:    DishDao{db: db},
 		setmealDao: SetMealDao{db: db},
 	}
@@ -0,0 +1,77 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"takeout/internal/model"
+	"takeout/repository"
+	"time"
+)
+
+type TableDao struct {
+	db *gorm.DB
+}
+
+func NewTableDao(db *gorm.DB) repository.TableRepo {
+	return &TableDao{db: db}
+}
+
+// ListTables 按区域与名称排序查询桌台
+func (d *TableDao) ListTables(ctx context.Context) ([]model.DiningTable, error) {
+	var tables []model.DiningTable
+	if err := d.db.WithContext(ctx).Order("area asc, name asc").Find(&tables).Error; err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	return tables, nil
+}
+
+// GetTable 根据id查询桌台
+func (d *TableDao) GetTable(ctx context.Context, id uint64) (*model.DiningTable, error) {
+	var table model.DiningTable
+	if err := d.db.WithContext(ctx).First(&table, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get table: %w", err)
+	}
+	return &table, nil
+}
+
+// InsertTable 新增桌台
+func (d *TableDao) InsertTable(ctx context.Context, table *model.DiningTable) error {
+	if err := d.db.WithContext(ctx).Create(table).Error; err != nil {
+		return fmt.Errorf("failed to insert table: %w", err)
+	}
+	return nil
+}
+
+// UpdateTable 修改桌台，零值字段同样写入，校验码不在此修改
+func (d *TableDao) UpdateTable(ctx context.Context, table *model.DiningTable) error {
+	err := d.db.WithContext(ctx).Model(table).
+		Select("name", "area", "seats", "status", "update_time").
+		Updates(table).Error
+	if err != nil {
+		return fmt.Errorf("failed to update table: %w", err)
+	}
+	return nil
+}
+
+// DeleteTable 删除桌台，历史订单保留下单时的桌台名称
+func (d *TableDao) DeleteTable(ctx context.Context, id uint64) error {
+	if err := d.db.WithContext(ctx).Delete(&model.DiningTable{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete table: %w", err)
+	}
+	return nil
+}
+
+// UpdateCode 更新二维码校验码
+func (d *TableDao) UpdateCode(ctx context.Context, id uint64, code string) error {
+	err := d.db.WithContext(ctx).Model(&model.DiningTable{Id: id}).
+		Updates(map[string]any{"code": code, "update_time": time.Now()}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update table code: %w", err)
+	}
+	return nil
+}
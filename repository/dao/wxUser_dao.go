@@ -1,18 +1,30 @@
 package dao
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"strconv"
+	"takeout/common/enum"
+	"takeout/internal/api/user/response"
 	"takeout/internal/model"
+	"time"
 )
 
+// DeletedUserName 注销后的用户昵称
+const DeletedUserName = "已注销用户"
+
+// errAccountDeleted 事务内账号已注销，用于回滚
+var errAccountDeleted = errors.New("account already deleted")
+
 type WxUserDao struct {
 	db *gorm.DB
 }
 
-func (w WxUserDao) RegisterUser(ctx *gin.Context, user model.User) error {
-	return w.db.WithContext(ctx).Create(&user).Error
+func (w WxUserDao) RegisterUser(ctx *gin.Context, user *model.User) error {
+	return w.db.WithContext(ctx).Create(user).Error
 }
 
 func (w WxUserDao) GetUserByOpenID(ctx *gin.Context, openID string) (model.User, bool, error) {
@@ -35,6 +47,276 @@ func (w WxUserDao) GetUserByOpenID(ctx *gin.Context, openID string) (model.User,
 	return user, true, nil
 }
 
+// GetUserById 根据id查询用户
+func (w WxUserDao) GetUserById(ctx context.Context, id int) (*model.User, error) {
+	var user model.User
+	if err := w.db.WithContext(ctx).Where("id = ?", id).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, nil
+}
+
+// ListByPhone 根据手机号查询用户，最早注册的在前
+func (w WxUserDao) ListByPhone(ctx context.Context, phone string) ([]model.User, error) {
+	var users []model.User
+	if err := w.db.WithContext(ctx).Where("phone = ?", phone).Order("id asc").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to list users by phone: %w", err)
+	}
+	return users, nil
+}
+
+// BindPhone 绑定手机号，合并确认过的重复账号，其余原账号解除该手机号
+func (w WxUserDao) BindPhone(ctx context.Context, userId int, phone string, mergeIds, unbindIds []int) error {
+	return w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for _, from := range mergeIds {
+			if err := mergeUser(tx, from, userId, now); err != nil {
+				return err
+			}
+		}
+		if len(unbindIds) > 0 {
+			if err := tx.Model(&model.User{}).Where("id in ? and phone = ?", unbindIds, phone).Update("phone", "").Error; err != nil {
+				return fmt.Errorf("failed to unbind phone: %w", err)
+			}
+		}
+		if err := tx.Model(&model.User{}).Where("id = ?", userId).Update("phone", phone).Error; err != nil {
+			return fmt.Errorf("failed to bind phone: %w", err)
+		}
+		return nil
+	})
+}
+
+// mergeUser 将 from 用户的数据转移到 into 用户，带唯一索引的表先忽略冲突转移再删除剩余的重复行，拼单份额除外
+// from 用户保留为指向 into 的记录，原微信登录时进入 into 用户
+func mergeUser(tx *gorm.DB, from, into int, now time.Time) error {
+	// 保留 into 用户的默认地址
+	var defaults int64
+	if err := tx.Table("address_book").Where("user_id = ? and is_default = 1", into).Count(&defaults).Error; err != nil {
+		return fmt.Errorf("failed to count default address: %w", err)
+	}
+	if defaults > 0 {
+		if err := tx.Table("address_book").Where("user_id = ?", from).Update("is_default", 0).Error; err != nil {
+			return fmt.Errorf("failed to reset default address: %w", err)
+		}
+	}
+	for _, table := range []string{"orders", "address_book", "review", "after_sale", "group_cart_item"} {
+		if err := tx.Table(table).Where("user_id = ?", from).Update("user_id", into).Error; err != nil {
+			return fmt.Errorf("failed to merge %s: %w", table, err)
+		}
+	}
+	if err := tx.Table("group_cart").Where("owner_id = ?", from).Update("owner_id", into).Error; err != nil {
+		return fmt.Errorf("failed to merge group_cart: %w", err)
+	}
+	for _, table := range []string{"favorite", "group_cart_member"} {
+		if err := tx.Exec("UPDATE IGNORE `"+table+"` SET user_id = ? WHERE user_id = ?", into, from).Error; err != nil {
+			return fmt.Errorf("failed to merge %s: %w", table, err)
+		}
+		if err := tx.Exec("DELETE FROM `"+table+"` WHERE user_id = ?", from).Error; err != nil {
+			return fmt.Errorf("failed to clean %s: %w", table, err)
+		}
+	}
+	// 支付记录不能删除，两个账号在同一拼单中都有份额时，冲突的份额留在 from 用户上
+	if err := tx.Exec("UPDATE IGNORE `group_payment` SET user_id = ? WHERE user_id = ?", into, from).Error; err != nil {
+		return fmt.Errorf("failed to merge group_payment: %w", err)
+	}
+
+	// 资料以 into 用户为准，为空的字段取 from 用户的
+	var source model.User
+	if err := tx.Where("id = ?", from).First(&source).Error; err != nil {
+		return fmt.Errorf("failed to get merged user: %w", err)
+	}
+	profile := map[string]any{}
+	for column, value := range map[string]string{"name": source.Name, "sex": source.Sex, "id_number": source.IdNumber, "avatar": source.Avatar} {
+		if value != "" {
+			profile[column] = gorm.Expr("IF(IFNULL("+column+", '') = '', ?, "+column+")", value)
+		}
+	}
+	if len(profile) > 0 {
+		if err := tx.Model(&model.User{}).Where("id = ?", into).Updates(profile).Error; err != nil {
+			return fmt.Errorf("failed to merge profile: %w", err)
+		}
+	}
+	if err := tx.Model(&model.User{}).Where("id = ?", from).Updates(map[string]any{
+		"phone": "", "merged_into": into, "delete_time": now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to mark merged user: %w", err)
+	}
+	// 之前合并到 from 的账号改为指向 into
+	if err := tx.Model(&model.User{}).Where("merged_into = ?", from).Update("merged_into", into).Error; err != nil {
+		return fmt.Errorf("failed to relink merged users: %w", err)
+	}
+	return nil
+}
+
+// UpdateUnionId 记录微信 unionid
+func (w WxUserDao) UpdateUnionId(ctx context.Context, userId int, unionId string) error {
+	if err := w.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userId).Update("unionid", unionId).Error; err != nil {
+		return fmt.Errorf("failed to update unionid: %w", err)
+	}
+	return nil
+}
+
+// UpdateProfile 修改昵称与性别
+func (w WxUserDao) UpdateProfile(ctx context.Context, userId int, name, sex string) error {
+	err := w.db.WithContext(ctx).Model(&model.User{}).Where("id = ? and delete_time is null", userId).
+		Updates(map[string]any{"name": name, "sex": sex}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update profile: %w", err)
+	}
+	return nil
+}
+
+// UpdateAvatar 修改头像地址
+func (w WxUserDao) UpdateAvatar(ctx context.Context, userId int, avatar string) error {
+	err := w.db.WithContext(ctx).Model(&model.User{}).Where("id = ? and delete_time is null", userId).
+		Update("avatar", avatar).Error
+	if err != nil {
+		return fmt.Errorf("failed to update avatar: %w", err)
+	}
+	return nil
+}
+
+// CountInProgress 统计未完成的订单与待处理的售后单
+func (w WxUserDao) CountInProgress(ctx context.Context, userId int) (int64, error) {
+	var orders, sales int64
+	active := []int{enum.PendingPayment, enum.ToBeConfirmed, enum.Confirmed, enum.DeliveryInProgress, enum.Scheduled}
+	if err := w.db.WithContext(ctx).Model(&model.Order{}).
+		Where("user_id = ? and status in ?", userId, active).Count(&orders).Error; err != nil {
+		return 0, fmt.Errorf("failed to count active orders: %w", err)
+	}
+	if err := w.db.WithContext(ctx).Model(&model.AfterSale{}).
+		Where("user_id = ? and status = ?", userId, enum.AfterSalePending).Count(&sales).Error; err != nil {
+		return 0, fmt.Errorf("failed to count pending after-sales: %w", err)
+	}
+	return orders + sales, nil
+}
+
+// DeleteAccount 注销账号，openid 改写为占位值以便同一微信号重新注册
+func (w WxUserDao) DeleteAccount(ctx context.Context, userId int) (bool, error) {
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).Where("id = ? and delete_time is null", userId).Updates(map[string]any{
+			"openid":      "deleted:" + strconv.Itoa(userId),
+			"name":        DeletedUserName,
+			"phone":       "",
+			"sex":         "",
+			"id_number":   "",
+			"avatar":      "",
+			"unionid":     "",
+			"delete_time": time.Now(),
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to anonymize user: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errAccountDeleted
+		}
+		// 合并到该账号的原账号一并注销，原微信再次登录时注册新账号
+		if err := tx.Model(&model.User{}).Where("merged_into = ?", userId).Updates(map[string]any{
+			"openid": gorm.Expr("CONCAT('deleted:', id)"), "unionid": "", "name": DeletedUserName,
+			"sex": "", "id_number": "", "avatar": "",
+		}).Error; err != nil {
+			return fmt.Errorf("failed to anonymize merged users: %w", err)
+		}
+		// 地址只保留行政区划，历史订单引用的地址id仍然有效
+		if err := tx.Table("address_book").Where("user_id = ?", userId).Updates(map[string]any{
+			"consignee": "", "sex": "", "phone": "", "detail": "", "label": "", "is_default": 0, "longitude": 0, "latitude": 0,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to anonymize address: %w", err)
+		}
+		// 订单保留金额、菜品与状态，用于对账与报表
+		if err := tx.Table("orders").Where("user_id = ?", userId).Updates(map[string]any{
+			"user_name": DeletedUserName, "phone": "", "address": "", "consignee": "", "remark": "",
+		}).Error; err != nil {
+			return fmt.Errorf("failed to anonymize orders: %w", err)
+		}
+		// 评价保留星级用于菜品评分，文字与图片可能含个人信息
+		if err := tx.Table("review").Where("user_id = ?", userId).Updates(map[string]any{
+			"content": "", "images": "",
+		}).Error; err != nil {
+			return fmt.Errorf("failed to anonymize reviews: %w", err)
+		}
+		// 售后单保留问题类型与金额用于对账，清空描述与凭证图片
+		if err := tx.Table("after_sale").Where("user_id = ?", userId).Updates(map[string]any{
+			"description": "", "images": "",
+		}).Error; err != nil {
+			return fmt.Errorf("failed to anonymize after-sales: %w", err)
+		}
+		if err := tx.Table("group_cart_member").Where("user_id = ?", userId).
+			Update("nickname", DeletedUserName).Error; err != nil {
+			return fmt.Errorf("failed to anonymize group members: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userId).Delete(&model.Favorite{}).Error; err != nil {
+			return fmt.Errorf("failed to delete favorites: %w", err)
+		}
+		return nil
+	})
+	if errors.Is(err, errAccountDeleted) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ExportData 导出个人资料、地址、订单及明细、评价、收藏与售后单
+func (w WxUserDao) ExportData(ctx context.Context, userId int) (*response.PersonalDataVO, error) {
+	db := w.db.WithContext(ctx)
+	user, err := w.GetUserById(ctx, userId)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	data := response.PersonalDataVO{
+		ExportTime: time.Now(),
+		Profile: response.UserProfileVO{
+			Id: user.ID, Name: user.Name, Phone: user.Phone, Sex: user.Sex, Avatar: user.Avatar, CreateTime: user.CreateTime,
+		},
+		Addresses:  []model.AddressBook{},
+		Orders:     []response.PersonalOrderVO{},
+		Reviews:    []model.Review{},
+		Favorites:  []model.Favorite{},
+		AfterSales: []model.AfterSale{},
+	}
+	if err = db.Where("user_id = ?", userId).Order("id asc").Find(&data.Addresses).Error; err != nil {
+		return nil, fmt.Errorf("failed to export addresses: %w", err)
+	}
+	var orders []model.Order
+	if err = db.Where("user_id = ?", userId).Order("id asc").Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("failed to export orders: %w", err)
+	}
+	if len(orders) > 0 {
+		ids := make([]int, 0, len(orders))
+		for _, order := range orders {
+			ids = append(ids, order.Id)
+		}
+		var details []model.OrderDetail
+		if err = db.Where("order_id in ?", ids).Order("id asc").Find(&details).Error; err != nil {
+			return nil, fmt.Errorf("failed to export order details: %w", err)
+		}
+		byOrder := make(map[int][]model.OrderDetail, len(orders))
+		for _, detail := range details {
+			byOrder[detail.OrderId] = append(byOrder[detail.OrderId], detail)
+		}
+		for _, order := range orders {
+			items := byOrder[order.Id]
+			if items == nil {
+				items = []model.OrderDetail{}
+			}
+			data.Orders = append(data.Orders, response.PersonalOrderVO{Order: order, Details: items})
+		}
+	}
+	if err = db.Where("user_id = ?", userId).Order("id asc").Find(&data.Reviews).Error; err != nil {
+		return nil, fmt.Errorf("failed to export reviews: %w", err)
+	}
+	if err = db.Where("user_id = ?", userId).Order("id asc").Find(&data.Favorites).Error; err != nil {
+		return nil, fmt.Errorf("failed to export favorites: %w", err)
+	}
+	if err = db.Preload("Items").Where("user_id = ?", userId).Order("id asc").Find(&data.AfterSales).Error; err != nil {
+		return nil, fmt.Errorf("failed to export after-sales: %w", err)
+	}
+	return &data, nil
+}
+
 func NewWxUserDao(db *gorm.DB) *WxUserDao {
 	return &WxUserDao{db: db}
 }
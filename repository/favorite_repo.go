@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"takeout/internal/api/user/response"
+	"takeout/internal/model"
+)
+
+type FavoriteRepo interface {
+	Add(ctx context.Context, favorite model.Favorite) error
+	Delete(ctx context.Context, userId int, dishId, setmealId uint64) error
+	Exists(ctx context.Context, userId int, dishId, setmealId uint64) (bool, error)
+	List(ctx context.Context, userId int) ([]response.FavoriteVO, error)
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"takeout/internal/model"
+	"time"
+)
+
+type GroupCartRepo interface {
+	// CreateGroup 创建拼单，发起人同时成为第一个成员
+	CreateGroup(ctx context.Context, group *model.GroupCart, owner *model.GroupMember) error
+	// GetGroupByCode 不存在时返回 nil
+	GetGroupByCode(ctx context.Context, code string) (*model.GroupCart, error)
+	// ListUserGroups 用户参与的、未结束的拼单
+	ListUserGroups(ctx context.Context, userId int, now time.Time) ([]model.GroupCart, error)
+	// SetStatus 仅当拼单处于 from 状态时修改，状态已变化时返回 false
+	SetStatus(ctx context.Context, id uint64, from, to int) (bool, error)
+
+	ListMembers(ctx context.Context, groupId uint64) ([]model.GroupMember, error)
+	// AddMember 已是成员时更新昵称
+	AddMember(ctx context.Context, member *model.GroupMember) error
+	// RemoveMember 移除成员及其添加的菜品
+	RemoveMember(ctx context.Context, groupId uint64, userId int) error
+
+	ListItems(ctx context.Context, groupId uint64) ([]model.GroupCartItem, error)
+	// AddItem 同一成员的相同菜品与口味数量加一，否则按当前售价新增一行，菜品或套餐不存在或已停售时返回 false
+	AddItem(ctx context.Context, item model.GroupCartItem) (bool, error)
+	// SubtractItem 数量减一，减到 0 时删除，找不到时返回 false
+	SubtractItem(ctx context.Context, item model.GroupCartItem) (bool, error)
+
+	// Checkout 在一个事务中创建订单、订单明细与分账记录，并将拼单标记为已下单，拼单已不是锁定状态时返回 false
+	Checkout(ctx context.Context, group *model.GroupCart, order *model.Order, details []model.OrderDetail, payments []model.GroupPayment) (bool, error)
+	ListPayments(ctx context.Context, groupId uint64) ([]model.GroupPayment, error)
+	// PayShare 标记成员的份额为已支付，返回是否更新以及剩余未支付的份数，订单不再待支付时不更新
+	// 最后一份支付后订单在同一事务内改为 status 并标记已支付
+	PayShare(ctx context.Context, groupId uint64, orderId int, userId int, status int, now time.Time) (bool, int64, error)
+	// ListUnpaidSplitOrders 下单时间早于 before 仍待支付的 AA 订单
+	ListUnpaidSplitOrders(ctx context.Context, before time.Time) ([]model.Order, error)
+	// CancelSplitOrder 取消待支付的 AA 订单并将已付份额标记为退款，返回需退款的份额以及是否取消
+	CancelSplitOrder(ctx context.Context, orderId int, reason string, now time.Time) ([]model.GroupPayment, bool, error)
+}
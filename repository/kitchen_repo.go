@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"takeout/internal/model"
+	"time"
+)
+
+type KitchenRepo interface {
+	// 工位，CategoryIds 一并返回
+	ListStations(ctx context.Context) ([]model.KitchenStation, error)
+	GetStation(ctx context.Context, id uint64) (*model.KitchenStation, error)
+	InsertStation(ctx context.Context, station *model.KitchenStation) error
+	UpdateStation(ctx context.Context, station *model.KitchenStation) error
+	// DeleteStation 删除工位及其分类分配
+	DeleteStation(ctx context.Context, id uint64) error
+	// SetStationCategories 把分类分配给工位，分类原先所属的工位自动解除，未列出的原有分类也解除
+	SetStationCategories(ctx context.Context, stationId uint64, categoryIds []uint64) error
+	// CountOpenItems 工位上尚未出品的数量
+	CountOpenItems(ctx context.Context, stationId uint64) (int64, error)
+
+	// BuildItems 按订单明细生成出品项：套餐拆为套餐内菜品，工位取菜品分类所属工位
+	BuildItems(ctx context.Context, orderId int) ([]model.KitchenItem, error)
+	// CreateTicket 写入后厨单及出品项，后厨单已存在时不写入并返回 false
+	CreateTicket(ctx context.Context, order *model.KitchenOrder, items []model.KitchenItem) (bool, error)
+	// ListUndispatched 已接单但尚未生成后厨单的订单
+	ListUndispatched(ctx context.Context) ([]model.Order, error)
+	// ListOpenOrders 订单仍为已接单的后厨单，按进入后厨时间排序；stationId 非 0 时仅含该工位有未出品项的单
+	ListOpenOrders(ctx context.Context, stationId uint64) ([]model.KitchenOrder, error)
+	GetKitchenOrders(ctx context.Context, orderIds []int) ([]model.KitchenOrder, error)
+	SetOrderStatus(ctx context.Context, orderId int, status int, readyTime time.Time) error
+
+	// ListItems 订单的出品项，stationId 为 0 时返回全部工位
+	ListItems(ctx context.Context, orderIds []int, stationId uint64) ([]model.KitchenItem, error)
+	GetItems(ctx context.Context, ids []uint64) ([]model.KitchenItem, error)
+	// UpdateItemStatus 只推进状态：开始制作仅对待制作项生效，出品对未出品项生效，返回实际更新的数量
+	UpdateItemStatus(ctx context.Context, ids []uint64, status int, at time.Time) (int64, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"takeout/common"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/model"
+	"time"
+)
+
+type MediaRepo interface {
+	// Save 记录上传的文件，key 已存在时清除未引用时间，避免刚被重新使用的文件被清理
+	Save(ctx context.Context, media *model.Media) error
+	PageQuery(ctx context.Context, dto request.MediaPageQueryDTO) (*common.PageResult, error)
+	// ListAll 全部媒体文件，用于统计引用
+	ListAll(ctx context.Context) ([]model.Media, error)
+	// ListReferences 菜品、套餐、评价、售后图片、用户头像，以及订单明细、拼单、购物车等快照中引用的文件地址
+	ListReferences(ctx context.Context) ([]string, error)
+	// UpdateRefCount 更新引用数，变为 0 时记录未引用时间，重新被引用时清除
+	UpdateRefCount(ctx context.Context, id uint64, count int, now time.Time) error
+	// DeleteUnused 删除在 before 之前就已无引用的记录，期间被重新引用或上传时返回 false
+	DeleteUnused(ctx context.Context, id uint64, before time.Time) (bool, error)
+}
@@ -6,13 +6,13 @@ import (
 	"takeout/internal/api/user/request"
 	"takeout/internal/api/user/response"
 	"takeout/internal/model"
+	"time"
 )
 
 type OrderRepo interface {
 	// user
 	GetOrderDetailByOrderId(orderId string) (orderDetailList []model.OrderDetail, err error)
 	OrderSubmit(ctx context.Context, data request.OrderSubmitDTO, userId int) (response.OrderSubmitVO, error)
-	RepetitionOrder(ctx context.Context, orderId string, userId int) error
 	OrderReminder(orderId string)
 	GetOrderById(orderId string) (*model.Order, error)
 	UpdateOrder(order *model.Order) error
@@ -26,5 +26,21 @@ type OrderRepo interface {
 	OrderComplete(orderId string) error
 	OrderConditionSearch(ctx context.Context, data request.OrderPageQueryDTO) (*common.PageResult, error)
 	OrderStatistics(ctx context.Context) (response.OrderStatisticsVO, error)
+	// GetOrderByStatusAndOrderTime 根据状态和下单时间查询订单，不含 AA 支付的拼单订单
 	GetOrderByStatusAndOrderTime(status int, orderTime model.LocalTime) ([]model.Order, error)
+	// IsSplitBill 订单是否为 AA 支付的拼单订单
+	IsSplitBill(ctx context.Context, orderId int) (bool, error)
+	// GetOrderByPickupCode 根据取餐码查询 since 之后下单或预约的待取餐订单，不存在时返回 nil
+	GetOrderByPickupCode(ctx context.Context, pickupCode string, since time.Time) (*model.Order, error)
+
+	// CountSlotOrders 统计 [from, to) 内各时段未取消的预约订单数，键为时段开始时间的 Unix 秒
+	CountSlotOrders(ctx context.Context, from, to time.Time) (map[int64]int, error)
+	// ListDueScheduled 时段开始时间不晚于 before、尚未推送给商家的预约订单
+	ListDueScheduled(ctx context.Context, before time.Time) ([]model.Order, error)
+	// ReleaseScheduled 预约订单转为待接单，已被其他实例推送时返回 false
+	ReleaseScheduled(ctx context.Context, id int, now time.Time) (bool, error)
+	// ListUnconfirmedScheduled 时段开始时间不晚于 before、仍未接单且未提醒过的预约订单
+	ListUnconfirmedScheduled(ctx context.Context, before time.Time) ([]model.Order, error)
+	// MarkReminded 记录提醒时间，已提醒过时返回 false
+	MarkReminded(ctx context.Context, id int, now time.Time) (bool, error)
 }
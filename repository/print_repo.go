@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"takeout/common"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/model"
+	"time"
+)
+
+type PrintRepo interface {
+	// ListPrinters 打印机列表，enabledOnly 时仅返回启用的打印机
+	ListPrinters(ctx context.Context, enabledOnly bool) ([]model.Printer, error)
+	// GetPrinter 不存在时返回 nil
+	GetPrinter(ctx context.Context, id uint64) (*model.Printer, error)
+	InsertPrinter(ctx context.Context, printer *model.Printer) error
+	UpdatePrinter(ctx context.Context, printer *model.Printer) error
+	// DeletePrinter 删除打印机及其未完成的打印任务
+	DeletePrinter(ctx context.Context, id uint64) error
+
+	InsertJobs(ctx context.Context, jobs []model.PrintJob) error
+	// HasAutoJobs 订单是否已生成过接单打印任务（不含补打）
+	HasAutoJobs(ctx context.Context, orderId int) (bool, error)
+	PageJobs(ctx context.Context, dto request.PrintJobPageQueryDTO) (*common.PageResult, error)
+	// GetJob 不存在时返回 nil
+	GetJob(ctx context.Context, id uint64) (*model.PrintJob, error)
+	// ListDueJobs 到期的待打印任务及租约过期的打印中任务
+	ListDueJobs(ctx context.Context, now time.Time, limit int) ([]model.PrintJob, error)
+	// ClaimJob 领取任务并设置租约，已被其他实例领取时返回 false
+	ClaimJob(ctx context.Context, id uint64, now, lease time.Time) (bool, error)
+	// FinishJob 保存一次打印的结果：状态、尝试次数、下次尝试时间与错误
+	FinishJob(ctx context.Context, job *model.PrintJob) error
+	// ResetJob 失败的任务重新排队，任务不是失败状态时返回 false
+	ResetJob(ctx context.Context, id uint64, now time.Time) (bool, error)
+}
@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+	"takeout/common/recommend"
+	"time"
+)
+
+type RecommendRepo interface {
+	ListPurchases(ctx context.Context, since time.Time) ([]recommend.Purchase, error)
+}
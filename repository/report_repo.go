@@ -3,13 +3,28 @@ package repository
 import (
 	"takeout/internal/api/admin/response"
 	"takeout/internal/api/user/request"
+	"takeout/internal/model"
 	"time"
 )
 
 type ReportRepo interface {
-	GetDailyTurnover(begin, end time.Time) (float64, error)
-	GetDailyOrderCount(begin, end time.Time, status int) (int, error)
+	// 按下单日期分组聚合，返回 日期(2006-01-02) -> 指标，没有数据的日期不出现
+	GetTurnoverSeries(begin, end time.Time) (map[string]float64, error)
+	GetOrderCountSeries(begin, end time.Time) (total, valid map[string]int, err error)
+	GetNewUserSeries(begin, end time.Time) (map[string]int, error)
+	// 按小时分组聚合，供小时粒度与非本地时区的报表重新分桶
+	GetHourlyStats(begin, end time.Time) ([]model.HourlyBusinessStats, error)
+
 	GetSalesTop10(begin, end time.Time) ([]request.GoodsSalesDTO, error)
+	// 逐行读取区间内全部商品的销量与销售额，按销量降序，供导出流式写出
+	EachGoodsSales(begin, end time.Time, fn func(request.GoodsSalesDTO) error) error
 	GetUserCount(begin, end time.Time) (int, error)
 	GetBusinessData(beginTime, endTime time.Time) (response.BusinessDataVO, error)
+	// 按申请时间统计售后单的处理结果、问题类型与商品
+	GetAfterSaleSummary(begin, end time.Time) (response.AfterSaleSummaryVO, error)
+
+	// 每日营业数据预聚合表
+	ListDailyStats(begin, end time.Time) ([]model.DailyBusinessStats, error)
+	SaveDailyStats(stats []model.DailyBusinessStats) error
+	IncrDailyStats(date time.Time, turnover float64, validOrders int) error
 }
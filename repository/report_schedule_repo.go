@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"takeout/internal/model"
+	"time"
+)
+
+type ReportScheduleRepo interface {
+	// List 全部推送计划及其接收方，enabledOnly 时仅返回启用的计划
+	List(ctx context.Context, enabledOnly bool) ([]model.ReportSchedule, error)
+	// GetById 查询推送计划及其接收方，不存在时返回 nil
+	GetById(ctx context.Context, id uint64) (*model.ReportSchedule, error)
+	Insert(ctx context.Context, schedule *model.ReportSchedule) error
+	Update(ctx context.Context, schedule *model.ReportSchedule) error
+	// Delete 删除推送计划及其接收方
+	Delete(ctx context.Context, id uint64) error
+	SetStatus(ctx context.Context, id uint64, status int) error
+	SaveRunResult(ctx context.Context, id uint64, runTime time.Time, status, errMsg string) error
+
+	InsertRecipient(ctx context.Context, recipient *model.ReportRecipient) error
+	DeleteRecipient(ctx context.Context, id uint64) error
+}
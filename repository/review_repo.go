@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"takeout/common"
+	adminRequest "takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"takeout/internal/api/user/request"
+	"takeout/internal/model"
+)
+
+type ReviewRepo interface {
+	Transaction(ctx context.Context) *gorm.DB
+	InsertBatch(db *gorm.DB, reviews []model.Review) error
+	GetById(ctx context.Context, id uint64) (*model.Review, error)
+	GetByOrderId(ctx context.Context, orderId int) ([]model.Review, error)
+	PageByTarget(ctx context.Context, dto request.ReviewPageQueryDTO) (*common.PageResult, error)
+	PageQuery(ctx context.Context, dto adminRequest.ReviewPageQueryDTO) (*common.PageResult, error)
+	Reply(ctx context.Context, id uint64, reply string) error
+	SetStatus(ctx context.Context, id uint64, status int) error
+	SetFlagged(ctx context.Context, id uint64, flagged int) error
+	GetDishRatings(ctx context.Context, dishIds []uint64) (map[uint64]response.RatingStatVO, error)
+	GetSetmealRatings(ctx context.Context, setmealIds []uint64) (map[uint64]response.RatingStatVO, error)
+}
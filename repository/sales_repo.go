@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"takeout/internal/api/admin/request"
+	"takeout/internal/api/admin/response"
+	"time"
+)
+
+// SalesRepo 基于已完成订单明细的销售分析
+type SalesRepo interface {
+	// 按菜品/套餐 ID 汇总销量与销售额，按销售额降序
+	ItemSales(ctx context.Context, begin, end time.Time) ([]response.ItemSalesVO, error)
+	// 按分类汇总销量、销售额与订单数
+	CategorySales(ctx context.Context, begin, end time.Time) ([]response.CategorySalesVO, error)
+	// 每个菜品各口味组合的销量
+	FlavorSales(ctx context.Context, begin, end time.Time) ([]request.FlavorSalesDTO, error)
+	// 起售中的菜品与套餐及其区间销量，未售出的销量为 0，按销量升序
+	OnSaleItemSales(ctx context.Context, begin, end time.Time) ([]response.SlowItemVO, error)
+	// 订单数、金额、件数、明细行数与包含套餐的订单数
+	BasketSummary(ctx context.Context, begin, end time.Time) (request.BasketSummaryDTO, error)
+	// 每单件数 -> 订单数，超过 maxItems 的归入 maxItems
+	BasketSizes(ctx context.Context, begin, end time.Time, maxItems int) (map[int]int, error)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+	"takeout/internal/model"
+)
+
+type SearchRepo interface {
+	ListOnSaleDishes(ctx context.Context) ([]model.Dish, error)
+	ListOnSaleSetmeals(ctx context.Context) ([]model.SetMeal, error)
+	GetSalesVolume(ctx context.Context) (dishSales, setmealSales map[uint64]int64, err error)
+}
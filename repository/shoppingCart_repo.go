@@ -12,4 +12,18 @@ type ShoppingCartRepo interface {
 	Clean(ctx context.Context, userId int) error //清空
 	Subtract(ctx context.Context, ShoppingCartDTO request.ShoppingCartDTO, userId int) error
 	InsertBatchShoppingCart(ctx context.Context, shoppingCartList []model.ShoppingCart) error
+	// UpdateLine 按菜品/套餐与口味更新购物车行的名称、图片与单价，行不存在时返回 false
+	UpdateLine(ctx context.Context, shoppingCart model.ShoppingCart) (bool, error)
+	// ListDishes 查询购物车中的菜品及口味，用于校验购物车
+	ListDishes(ctx context.Context, ids []int) ([]model.Dish, error)
+	ListSetmeals(ctx context.Context, ids []int) ([]model.SetMeal, error)
+	// FindOnSaleByNames 按名称查询在售的菜品与套餐，用于替换已删除后重新上架的商品
+	FindOnSaleByNames(ctx context.Context, names []string) ([]model.Dish, []model.SetMeal, error)
+}
+
+// ShoppingCartSyncer 购物车存放在缓存中时，将变更写回数据库
+type ShoppingCartSyncer interface {
+	Flush(ctx context.Context, limit int) (int, error)
+	// Migrate 将数据库中已有的购物车导入缓存，只在首次启动时执行，返回导入的用户数
+	Migrate(ctx context.Context) (int, error)
 }
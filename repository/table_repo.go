@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"takeout/internal/model"
+)
+
+type TableRepo interface {
+	ListTables(ctx context.Context) ([]model.DiningTable, error)
+	// GetTable 不存在时返回 nil
+	GetTable(ctx context.Context, id uint64) (*model.DiningTable, error)
+	InsertTable(ctx context.Context, table *model.DiningTable) error
+	UpdateTable(ctx context.Context, table *model.DiningTable) error
+	DeleteTable(ctx context.Context, id uint64) error
+	// UpdateCode 重新生成二维码校验码
+	UpdateCode(ctx context.Context, id uint64, code string) error
+}
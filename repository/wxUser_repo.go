@@ -1,11 +1,32 @@
 package repository
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
+	"takeout/internal/api/user/response"
 	"takeout/internal/model"
 )
 
 type WxUserRepo interface {
 	GetUserByOpenID(ctx *gin.Context, openID string) (model.User, bool, error)
-	RegisterUser(ctx *gin.Context, user model.User) error
+	RegisterUser(ctx *gin.Context, user *model.User) error
+	// GetUserById 不存在时返回 nil
+	GetUserById(ctx context.Context, id int) (*model.User, error)
+	// ListByPhone 查询绑定了该手机号的用户
+	ListByPhone(ctx context.Context, phone string) ([]model.User, error)
+	// BindPhone 绑定手机号，同一事务中将 mergeIds 用户的订单、地址等数据合并到该用户，这些用户保留为指向该用户的记录；
+	// unbindIds 用户只解除该手机号的绑定
+	BindPhone(ctx context.Context, userId int, phone string, mergeIds, unbindIds []int) error
+	// UpdateUnionId 记录微信 unionid
+	UpdateUnionId(ctx context.Context, userId int, unionId string) error
+	// UpdateProfile 修改昵称与性别
+	UpdateProfile(ctx context.Context, userId int, name, sex string) error
+	// UpdateAvatar 修改头像地址
+	UpdateAvatar(ctx context.Context, userId int, avatar string) error
+	// CountInProgress 统计用户进行中的订单与待处理的售后单
+	CountInProgress(ctx context.Context, userId int) (int64, error)
+	// DeleteAccount 注销账号，匿名化用户、地址、历史订单、评价、售后与拼单昵称中的个人信息，保留金额等财务记录，账号已注销时返回 false
+	DeleteAccount(ctx context.Context, userId int) (bool, error)
+	// ExportData 导出用户的个人数据
+	ExportData(ctx context.Context, userId int) (*response.PersonalDataVO, error)
 }